@@ -0,0 +1,52 @@
+package audit
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pevans/newsfed/internal/httpjson"
+)
+
+// defaultAPILimit is how many entries ListHandler returns when no limit is
+// given.
+const defaultAPILimit = 100
+
+// ListHandler lists audit entries for GET /api/v1/meta/audit, newest first.
+// The actor, action, and target query parameters filter the entries, since
+// takes an RFC 3339 time, and limit caps how many are returned (default
+// 100).
+func (l *Log) ListHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		filter := Filter{
+			Actor:  query.Get("actor"),
+			Action: query.Get("action"),
+			Target: query.Get("target"),
+			Limit:  defaultAPILimit,
+		}
+		if since := query.Get("since"); since != "" {
+			t, err := time.Parse(time.RFC3339, since)
+			if err != nil {
+				httpjson.Error(w, http.StatusBadRequest, "since must be an RFC 3339 time")
+				return
+			}
+			filter.Since = t
+		}
+		if limit := query.Get("limit"); limit != "" {
+			n, err := strconv.Atoi(limit)
+			if err != nil || n < 1 {
+				httpjson.Error(w, http.StatusBadRequest, "limit must be a positive number")
+				return
+			}
+			filter.Limit = n
+		}
+
+		entries, err := l.List(filter)
+		if err != nil {
+			httpjson.Error(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		httpjson.Write(w, http.StatusOK, entries)
+	})
+}
@@ -0,0 +1,38 @@
+package audit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestListHandler_Filters verifies that the audit API lists entries, filters
+// them by query parameters, and rejects bad parameters
+func TestListHandler_Filters(t *testing.T) {
+	log, db := createTestLog(t)
+	require.NoError(t, Record(db, SourceCreated, "source-1", nil))
+	require.NoError(t, Record(db, SourceDeleted, "source-2", nil))
+
+	server := httptest.NewServer(log.ListHandler())
+	t.Cleanup(server.Close)
+
+	resp, err := http.Get(server.URL + "?action=source.deleted")
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var entries []Entry
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&entries))
+	require.Len(t, entries, 1)
+	assert.Equal(t, "source-2", entries[0].Target)
+
+	for _, query := range []string{"?limit=0", "?since=yesterday"} {
+		resp, err := http.Get(server.URL + query)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, query)
+	}
+}
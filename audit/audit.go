@@ -0,0 +1,281 @@
+// Package audit keeps a trail of changes to the metadata database -- sources
+// created, updated, and deleted, config changed, and secrets set and
+// deleted -- recording when each was made, by whom, and what it changed, so
+// that a surprise such as a source being disabled can be traced.
+package audit
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pevans/newsfed/sqldb"
+)
+
+// Actions recorded in the audit log.
+const (
+	SourceCreated = "source.created"
+	SourceUpdated = "source.updated"
+	SourceDeleted = "source.deleted"
+	ConfigChanged = "config.changed"
+	SecretSet     = "secret.set"
+	SecretDeleted = "secret.deleted"
+)
+
+// Actor names who makes the changes this process records, such as "cli" or
+// "daemon". Stores record changes as made by Actor, or "unknown" if it isn't
+// set.
+var Actor string
+
+// Change holds the value of a field before and after a change. A nil Old
+// means the field was unset, as does a nil New.
+type Change struct {
+	Old any `json:"old"`
+	New any `json:"new"`
+}
+
+// Entry is one change recorded in the audit log.
+type Entry struct {
+	ID      int64             `json:"id"`
+	At      time.Time         `json:"at"`
+	Actor   string            `json:"actor"`
+	Action  string            `json:"action"`
+	Target  string            `json:"target"` // The source ID, "config", or "secret:<name>"
+	Changes map[string]Change `json:"changes,omitempty"`
+}
+
+// Filter selects entries from the audit log. Zero fields match every entry.
+type Filter struct {
+	Actor  string
+	Action string
+	Target string
+	Since  time.Time
+	Limit  int
+}
+
+// postgresMigrations is the PostgreSQL schema, as migrations applied in
+// order by sqldb.
+var postgresMigrations = []string{
+	`
+	CREATE TABLE audit_log (
+		id BIGSERIAL PRIMARY KEY,
+		at TEXT NOT NULL,
+		actor TEXT NOT NULL,
+		action TEXT NOT NULL,
+		target TEXT NOT NULL,
+		changes TEXT
+	);
+	CREATE INDEX audit_log_target ON audit_log (target);
+	`,
+}
+
+// sqliteMigrations is the SQLite schema, as migrations applied in order by
+// sqldb. The first also works on databases made before the schema was
+// versioned, which already have the table.
+var sqliteMigrations = []string{
+	`
+	CREATE TABLE IF NOT EXISTS audit_log (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		at TEXT NOT NULL,
+		actor TEXT NOT NULL,
+		action TEXT NOT NULL,
+		target TEXT NOT NULL,
+		changes TEXT
+	);
+	CREATE INDEX IF NOT EXISTS audit_log_target ON audit_log (target);
+	`,
+}
+
+// InitSchema creates the audit log table in db, or brings it up to date.
+// Stores that record changes call it when they open.
+func InitSchema(db *sqldb.DB) error {
+	return db.Migrate("audit", migrationsFor(db.Dialect))
+}
+
+// SchemaStatus returns how far the audit schema of db has been migrated,
+// without migrating it, for newsfed migrate-db status.
+func SchemaStatus(db *sqldb.DB) (sqldb.SchemaStatus, error) {
+	return db.SchemaStatus("audit", len(migrationsFor(db.Dialect)))
+}
+
+// migrationsFor returns the audit migrations for dialect.
+func migrationsFor(dialect sqldb.Dialect) []string {
+	if dialect == sqldb.Postgres {
+		return postgresMigrations
+	}
+	return sqliteMigrations
+}
+
+// execer is implemented by *sqldb.DB and *sqldb.Tx.
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// Record writes an entry for a change to target, made by Actor now. Call it
+// in the same transaction as the change where there is one, so that the
+// entry is kept only if the change is.
+func Record(db execer, action, target string, changes map[string]Change) error {
+	actor := Actor
+	if actor == "" {
+		actor = "unknown"
+	}
+
+	var changesJSON any
+	if len(changes) > 0 {
+		data, err := json.Marshal(changes)
+		if err != nil {
+			return fmt.Errorf("failed to marshal audit changes: %w", err)
+		}
+		changesJSON = string(data)
+	}
+
+	_, err := db.Exec("INSERT INTO audit_log (at, actor, action, target, changes) VALUES (?, ?, ?, ?, ?)",
+		time.Now().UTC().Truncate(0).Format(time.RFC3339Nano), actor, action, target, changesJSON)
+	if err != nil {
+		return fmt.Errorf("failed to record audit entry: %w", err)
+	}
+	return nil
+}
+
+// Diff returns the fields that differ between before and after, keyed by
+// their JSON names, leaving out the fields in skip. Either may be nil, as
+// for something created or deleted.
+func Diff(before, after any, skip ...string) (map[string]Change, error) {
+	old, err := fields(before)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := fields(after)
+	if err != nil {
+		return nil, err
+	}
+	for _, field := range skip {
+		delete(old, field)
+		delete(updated, field)
+	}
+
+	changes := make(map[string]Change)
+	for field, value := range old {
+		if !reflect.DeepEqual(value, updated[field]) {
+			changes[field] = Change{Old: value, New: updated[field]}
+		}
+	}
+	for field, value := range updated {
+		if _, ok := old[field]; !ok {
+			changes[field] = Change{New: value}
+		}
+	}
+	return changes, nil
+}
+
+// fields returns v's JSON fields.
+func fields(v any) (map[string]any, error) {
+	m := make(map[string]any)
+	if v == nil || reflect.ValueOf(v).IsZero() {
+		return m, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal for audit: %w", err)
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal for audit: %w", err)
+	}
+	return m, nil
+}
+
+// Log reads the audit log.
+type Log struct {
+	db *sqldb.DB
+}
+
+// NewLog opens the audit log in the metadata database at dbPath, or a
+// postgres:// DSN.
+func NewLog(dbPath string) (*Log, error) {
+	db, err := sqldb.Open(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := InitSchema(db); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to initialize schema: %w", err)
+	}
+	return &Log{db: db}, nil
+}
+
+// Close closes the database connection.
+func (l *Log) Close() error {
+	return l.db.Close()
+}
+
+// List returns the entries matching filter, newest first.
+func (l *Log) List(filter Filter) ([]Entry, error) {
+	query := "SELECT id, at, actor, action, target, changes FROM audit_log"
+
+	var where []string
+	var args []any
+	if filter.Actor != "" {
+		where = append(where, "actor = ?")
+		args = append(args, filter.Actor)
+	}
+	if filter.Action != "" {
+		where = append(where, "action = ?")
+		args = append(args, filter.Action)
+	}
+	if filter.Target != "" {
+		where = append(where, "target = ?")
+		args = append(args, filter.Target)
+	}
+	if !filter.Since.IsZero() {
+		where = append(where, "at >= ?")
+		args = append(args, filter.Since.UTC().Truncate(0).Format(time.RFC3339Nano))
+	}
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY id DESC"
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", filter.Limit)
+	}
+
+	rows, err := l.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit log: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	entries := []Entry{}
+	for rows.Next() {
+		var entry Entry
+		var at string
+		var changes sql.NullString
+		if err := rows.Scan(&entry.ID, &at, &entry.Actor, &entry.Action, &entry.Target, &changes); err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		entry.At, _ = time.Parse(time.RFC3339Nano, at)
+		if changes.Valid {
+			if err := json.Unmarshal([]byte(changes.String), &entry.Changes); err != nil {
+				return nil, fmt.Errorf("failed to decode audit entry %d: %w", entry.ID, err)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return entries, nil
+}
+
+// Fields returns the names of the fields e changed, in order.
+func (e *Entry) Fields() []string {
+	names := make([]string, 0, len(e.Changes))
+	for name := range e.Changes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,80 @@
+package audit
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pevans/newsfed/sqldb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test helper: open an audit log and the database it reads
+func createTestLog(t *testing.T) (*Log, *sqldb.DB) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	log, err := NewLog(dbPath)
+	require.NoError(t, err, "should create audit log")
+	t.Cleanup(func() { _ = log.Close() })
+
+	db, err := sqldb.Open(dbPath)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	return log, db
+}
+
+// TestDiff_Fields verifies that only changed fields are reported, by JSON
+// name, and that skipped fields and nil values are handled
+func TestDiff_Fields(t *testing.T) {
+	type thing struct {
+		Name    string  `json:"name"`
+		Size    int     `json:"size"`
+		Note    *string `json:"note,omitempty"`
+		Changed string  `json:"changed_at"`
+	}
+	note := "hello"
+	before := &thing{Name: "a", Size: 1, Changed: "then"}
+	after := &thing{Name: "a", Size: 2, Note: &note, Changed: "now"}
+
+	changes, err := Diff(before, after, "changed_at")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]Change{
+		"size": {Old: float64(1), New: float64(2)},
+		"note": {New: "hello"},
+	}, changes)
+
+	changes, err = Diff(before, (*thing)(nil), "changed_at")
+	require.NoError(t, err)
+	assert.Equal(t, Change{Old: "a"}, changes["name"])
+}
+
+// TestRecord_List verifies that recorded entries are listed newest first
+// with their actor and changes, and can be filtered
+func TestRecord_List(t *testing.T) {
+	log, db := createTestLog(t)
+	defer func(actor string) { Actor = actor }(Actor)
+
+	Actor = ""
+	require.NoError(t, Record(db, ConfigChanged, "config", map[string]Change{"list_limit": {New: "30"}}))
+	Actor = "daemon"
+	require.NoError(t, Record(db, SourceUpdated, "source-1", map[string]Change{"enabled_at": {Old: "then"}}))
+
+	entries, err := log.List(Filter{})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "daemon", entries[0].Actor)
+	assert.Equal(t, SourceUpdated, entries[0].Action)
+	assert.Equal(t, []string{"enabled_at"}, entries[0].Fields())
+	assert.Equal(t, "unknown", entries[1].Actor)
+	assert.WithinDuration(t, time.Now(), entries[1].At, time.Minute)
+
+	entries, err = log.List(Filter{Target: "source-1"})
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+	entries, err = log.List(Filter{Actor: "cli"})
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+	entries, err = log.List(Filter{Since: time.Now().Add(time.Hour)})
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
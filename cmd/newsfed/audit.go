@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pevans/newsfed/audit"
+)
+
+func handleAudit(metadataPath string, args []string) {
+	fs := flag.NewFlagSet("audit", flag.ExitOnError)
+	target := fs.String("target", "", "Only show changes to this source ID, or \"config\"")
+	actor := fs.String("actor", "", "Only show changes made by this actor, such as cli or daemon")
+	action := fs.String("action", "", "Only show this action, such as source.updated")
+	since := fs.String("since", "", "Only show changes made within this duration (e.g., 24h, 7d)")
+	limit := fs.Int("limit", 50, "Maximum number of changes to show")
+	format := fs.String("format", "table", "Output format: table or json")
+	_ = fs.Parse(args)
+
+	filter := audit.Filter{
+		Actor:  *actor,
+		Action: *action,
+		Target: *target,
+		Limit:  *limit,
+	}
+	if *since != "" {
+		d, err := parseDuration(*since)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid -since: %v\n", err)
+			os.Exit(1)
+		}
+		filter.Since = time.Now().Add(-d)
+	}
+	if *format != "table" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "Error: invalid format: %s (use table or json)\n", *format)
+		os.Exit(1)
+	}
+
+	log, err := audit.NewLog(metadataPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to open audit log: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() { _ = log.Close() }()
+
+	entries, err := log.List(filter)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to list audit log: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *format == "json" {
+		data, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to encode audit log: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("No changes recorded.")
+		return
+	}
+	for _, entry := range entries {
+		fmt.Printf("[%s] %s %s %s\n", entry.At.Local().Format("2006-01-02 15:04:05"),
+			entry.Actor, entry.Action, entry.Target)
+		for _, field := range entry.Fields() {
+			change := entry.Changes[field]
+			fmt.Printf("    %s: %s → %s\n", field, auditValue(change.Old), auditValue(change.New))
+		}
+	}
+}
+
+// auditValue formats a value from an audit entry as compact JSON.
+func auditValue(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
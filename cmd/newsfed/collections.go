@@ -0,0 +1,430 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/pevans/newsfed/collections"
+	"github.com/pevans/newsfed/newsfeed"
+)
+
+func printCollectionsUsage() {
+	fmt.Println("newsfed collections -- Manage reading lists of news items")
+	fmt.Println()
+	fmt.Println("Usage:")
+	fmt.Println("  newsfed collections [action] [arguments]")
+	fmt.Println()
+	fmt.Println("Actions:")
+	fmt.Println("  list       List collections (the default)")
+	fmt.Println("  show       Show the items in a collection")
+	fmt.Println("  create     Create an empty collection")
+	fmt.Println("  add        Add items to a collection, creating it if needed")
+	fmt.Println("  remove     Remove an item from a collection")
+	fmt.Println("  move       Move an item to a position in a collection")
+	fmt.Println("  update     Rename a collection or change its description")
+	fmt.Println("  delete     Delete a collection (its items are kept)")
+	fmt.Println("  export     Write a collection's items as Markdown or another format")
+}
+
+func handleCollections(metadataPath, feedDSN string, args []string) {
+	action := "list"
+	if len(args) > 0 {
+		action, args = args[0], args[1:]
+	}
+
+	store, err := collections.NewCollectionStore(metadataPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to open collection store: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() { _ = store.Close() }()
+
+	switch action {
+	case "list":
+		handleCollectionsList(store)
+	case "show":
+		handleCollectionsShow(store, feedDSN, args)
+	case "create":
+		handleCollectionsCreate(store, args)
+	case "add":
+		handleCollectionsAdd(store, feedDSN, args)
+	case "remove":
+		handleCollectionsRemove(store, args)
+	case "move":
+		handleCollectionsMove(store, args)
+	case "update":
+		handleCollectionsUpdate(store, args)
+	case "delete":
+		handleCollectionsDelete(store, args)
+	case "export":
+		handleCollectionsExport(store, feedDSN, args)
+	case "help", "--help", "-h":
+		printCollectionsUsage()
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown collections command: %s\n\n", action)
+		printCollectionsUsage()
+		os.Exit(1)
+	}
+}
+
+func handleCollectionsList(store *collections.CollectionStore) {
+	list, err := store.List()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to list collections: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(list) == 0 {
+		fmt.Println("No collections.")
+		return
+	}
+
+	fmt.Printf("%-30s %6s  %s\n", "COLLECTION", "ITEMS", "DESCRIPTION")
+	fmt.Println("--------------------------------------------------------------")
+	for _, c := range list {
+		fmt.Printf("%s %6d  %s\n", fitColumn(c.Name, 30), len(c.ItemIDs), c.Description)
+	}
+}
+
+func handleCollectionsShow(store *collections.CollectionStore, feedDSN string, args []string) {
+	if len(args) != 1 {
+		fmt.Fprintf(os.Stderr, "Error: collection name is required\n")
+		fmt.Fprintf(os.Stderr, "Usage: newsfed collections show <name>\n")
+		os.Exit(1)
+	}
+
+	c := getCollection(store, args[0])
+
+	newsFeed, err := openFeed(feedDSN)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to open news feed: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() { _ = newsFeed.Close() }()
+
+	fmt.Println(c.Name)
+	if c.Description != "" {
+		fmt.Println(c.Description)
+	}
+	fmt.Println()
+
+	if len(c.ItemIDs) == 0 {
+		fmt.Println("No items in this collection.")
+		return
+	}
+
+	for i, id := range c.ItemIDs {
+		item, err := newsFeed.Get(id)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to get news item: %v\n", err)
+			os.Exit(1)
+		}
+		if item == nil {
+			fmt.Printf("%3d. %s (deleted)\n", i+1, id)
+			continue
+		}
+		status := ""
+		if item.ReadAt != nil {
+			status = " ✓"
+		}
+		fmt.Printf("%3d. %s%s\n", i+1, item.Title, status)
+		fmt.Printf("     %s  %s\n", id.String()[:8], item.URL)
+	}
+}
+
+func handleCollectionsCreate(store *collections.CollectionStore, args []string) {
+	fs := flag.NewFlagSet("collections create", flag.ExitOnError)
+	description := fs.String("description", "", "What the collection is for")
+	positional := parseInterspersed(fs, args)
+
+	if len(positional) != 1 {
+		fmt.Fprintf(os.Stderr, "Error: collection name is required\n")
+		fmt.Fprintf(os.Stderr, "Usage: newsfed collections create <name> [-description <text>]\n")
+		os.Exit(1)
+	}
+
+	c, err := store.Create(positional[0], *description)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to create collection: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✓ Created collection: %s\n", c.Name)
+}
+
+func handleCollectionsAdd(store *collections.CollectionStore, feedDSN string, args []string) {
+	if len(args) < 2 {
+		fmt.Fprintf(os.Stderr, "Error: collection name and item ID are required\n")
+		fmt.Fprintf(os.Stderr, "Usage: newsfed collections add <name> <item-id>...\n")
+		os.Exit(1)
+	}
+
+	name := args[0]
+
+	newsFeed, err := openFeed(feedDSN)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to open news feed: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() { _ = newsFeed.Close() }()
+
+	ids := make([]uuid.UUID, 0, len(args)-1)
+	for _, arg := range args[1:] {
+		ids = append(ids, resolveItemID(newsFeed, arg))
+	}
+
+	// Check every item before changing anything
+	items := make([]*newsfeed.NewsItem, len(ids))
+	for i, id := range ids {
+		items[i], err = newsFeed.Get(id)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to get news item: %v\n", err)
+			os.Exit(1)
+		}
+		if items[i] == nil {
+			fmt.Fprintf(os.Stderr, "Error: news item not found: %s\n", id)
+			os.Exit(1)
+		}
+	}
+
+	// Adding to a collection that doesn't exist yet creates it
+	c, err := store.Get(name)
+	if errors.Is(err, collections.ErrCollectionNotFound) {
+		c, err = store.Create(name, "")
+		if err == nil {
+			fmt.Printf("✓ Created collection: %s\n", c.Name)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to open collection: %v\n", err)
+		os.Exit(1)
+	}
+
+	for _, item := range items {
+		added, err := store.AddItem(c.Name, item.ID)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to add item: %v\n", err)
+			os.Exit(1)
+		}
+		if added {
+			fmt.Printf("✓ Added to %s: %s\n", c.Name, item.Title)
+		} else {
+			fmt.Printf("Already in %s: %s\n", c.Name, item.Title)
+		}
+	}
+}
+
+func handleCollectionsRemove(store *collections.CollectionStore, args []string) {
+	if len(args) != 2 {
+		fmt.Fprintf(os.Stderr, "Error: collection name and item ID are required\n")
+		fmt.Fprintf(os.Stderr, "Usage: newsfed collections remove <name> <item-id>\n")
+		os.Exit(1)
+	}
+
+	id := collectionItemID(store, args[0], args[1])
+	if err := store.RemoveItem(args[0], id); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to remove item: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✓ Removed %s from %s\n", id, args[0])
+}
+
+func handleCollectionsMove(store *collections.CollectionStore, args []string) {
+	if len(args) != 3 {
+		fmt.Fprintf(os.Stderr, "Error: collection name, item ID, and position are required\n")
+		fmt.Fprintf(os.Stderr, "Usage: newsfed collections move <name> <item-id> <position>\n")
+		os.Exit(1)
+	}
+
+	id := collectionItemID(store, args[0], args[1])
+	position, err := strconv.Atoi(args[2])
+	if err != nil || position < 1 {
+		fmt.Fprintf(os.Stderr, "Error: invalid position: %s (must be a number from 1)\n", args[2])
+		os.Exit(1)
+	}
+
+	if err := store.MoveItem(args[0], id, position); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to move item: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✓ Moved %s to position %d in %s\n", id, position, args[0])
+}
+
+func handleCollectionsUpdate(store *collections.CollectionStore, args []string) {
+	fs := flag.NewFlagSet("collections update", flag.ExitOnError)
+	name := fs.String("name", "", "New name")
+	description := fs.String("description", "", "New description (empty to clear)")
+	positional := parseInterspersed(fs, args)
+
+	if len(positional) != 1 {
+		fmt.Fprintf(os.Stderr, "Error: collection name is required\n")
+		fmt.Fprintf(os.Stderr, "Usage: newsfed collections update <name> [-name <new-name>] [-description <text>]\n")
+		os.Exit(1)
+	}
+
+	var update collections.CollectionUpdate
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "name":
+			update.Name = name
+		case "description":
+			update.Description = description
+		}
+	})
+	if update.Name == nil && update.Description == nil {
+		fmt.Fprintf(os.Stderr, "Error: at least one of -name or -description is required\n")
+		os.Exit(1)
+	}
+
+	c, err := store.Update(positional[0], update)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to update collection: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✓ Updated collection: %s\n", c.Name)
+}
+
+func handleCollectionsDelete(store *collections.CollectionStore, args []string) {
+	if len(args) != 1 {
+		fmt.Fprintf(os.Stderr, "Error: collection name is required\n")
+		fmt.Fprintf(os.Stderr, "Usage: newsfed collections delete <name>\n")
+		os.Exit(1)
+	}
+
+	if err := store.Delete(args[0]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to delete collection: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✓ Deleted collection: %s\n", args[0])
+}
+
+func handleCollectionsExport(store *collections.CollectionStore, feedDSN string, args []string) {
+	fs := flag.NewFlagSet("collections export", flag.ExitOnError)
+	format := fs.String("format", newsfeed.ExportMarkdown, "Export format: markdown, json, csv, rss, atom")
+	output := fs.String("o", "", "Write the export to this file instead of stdout")
+	fields := fs.String("fields", "", "Comma-separated item fields to write (json, csv, and markdown only)")
+	positional := parseInterspersed(fs, args)
+
+	if len(positional) != 1 {
+		fmt.Fprintf(os.Stderr, "Error: collection name is required\n")
+		fmt.Fprintf(os.Stderr, "Usage: newsfed collections export <name> [-format <format>] [-o <file>]\n")
+		os.Exit(1)
+	}
+
+	switch *format {
+	case newsfeed.ExportRSS, newsfeed.ExportAtom:
+		if *fields != "" {
+			fmt.Fprintf(os.Stderr, "Error: -fields can only be used with json, csv, and markdown formats\n")
+			os.Exit(1)
+		}
+	case newsfeed.ExportJSON, newsfeed.ExportCSV, newsfeed.ExportMarkdown:
+	default:
+		fmt.Fprintf(os.Stderr, "Error: invalid format: %s (must be markdown, json, csv, rss, or atom)\n", *format)
+		os.Exit(1)
+	}
+	selectedFields, err := newsfeed.ParseExportFields(*fields)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	c := getCollection(store, positional[0])
+
+	newsFeed, err := openFeed(feedDSN)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to open news feed: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() { _ = newsFeed.Close() }()
+
+	items, missing, err := c.Items(newsFeed)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to read collection items: %v\n", err)
+		os.Exit(1)
+	}
+	for _, id := range missing {
+		fmt.Fprintf(os.Stderr, "Warning: item %s is no longer in the feed\n", id)
+	}
+
+	info := c.ExportInfo()
+	info.Fields = selectedFields
+
+	if *output == "" {
+		if err := newsfeed.Export(os.Stdout, *format, info, items); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to export collection: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	file, err := os.Create(*output)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to create %s: %v\n", *output, err)
+		os.Exit(1)
+	}
+	if err := newsfeed.Export(file, *format, info, items); err != nil {
+		_ = file.Close()
+		fmt.Fprintf(os.Stderr, "Error: failed to export collection: %v\n", err)
+		os.Exit(1)
+	}
+	if err := file.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to write %s: %v\n", *output, err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✓ Exported %d items to %s\n", len(items), *output)
+}
+
+// getCollection returns the named collection, exiting if it can't be read.
+func getCollection(store *collections.CollectionStore, name string) *collections.Collection {
+	c, err := store.Get(name)
+	if errors.Is(err, collections.ErrCollectionNotFound) {
+		fmt.Fprintf(os.Stderr, "Error: collection not found: %s\n", name)
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to get collection: %v\n", err)
+		os.Exit(1)
+	}
+	return c
+}
+
+// collectionItemID returns the ID of the item in the named collection given
+// by arg, a full item ID or a unique prefix of one. A prefix is matched
+// against the collection's own items, since they may have left the feed.
+func collectionItemID(store *collections.CollectionStore, name, arg string) uuid.UUID {
+	if id, err := uuid.Parse(arg); err == nil {
+		return id
+	}
+	prefix, err := newsfeed.NormalizeIDPrefix(arg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	var matches []uuid.UUID
+	for _, id := range getCollection(store, name).ItemIDs {
+		if strings.HasPrefix(id.String(), prefix) {
+			matches = append(matches, id)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		fmt.Fprintf(os.Stderr, "Error: no item in %s has ID %s\n", name, arg)
+		os.Exit(1)
+	case 1:
+	default:
+		fmt.Fprintf(os.Stderr, "Error: %v: %s\n", newsfeed.ErrAmbiguousID, arg)
+		os.Exit(1)
+	}
+	return matches[0]
+}
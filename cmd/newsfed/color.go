@@ -0,0 +1,87 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// palette styles CLI output with ANSI colors. A disabled palette returns
+// text as is, so output written through it is plain when piped.
+type palette struct {
+	enabled bool
+}
+
+// colorFlag adds the -color flag to fs.
+func colorFlag(fs *flag.FlagSet) *string {
+	return fs.String("color", "auto", "Color output: auto, always, or never")
+}
+
+// newPalette returns the palette for a -color mode. With auto, output is
+// colored only when stdout is a terminal, NO_COLOR is unset, and TERM is
+// not "dumb"; always colors it even when piped or with NO_COLOR set.
+func newPalette(mode string) (palette, error) {
+	switch mode {
+	case "always":
+		return palette{enabled: true}, nil
+	case "never":
+		return palette{}, nil
+	case "auto":
+		if _, ok := os.LookupEnv("NO_COLOR"); ok || os.Getenv("TERM") == "dumb" {
+			return palette{}, nil
+		}
+		info, err := os.Stdout.Stat()
+		return palette{enabled: err == nil && info.Mode()&os.ModeCharDevice != 0}, nil
+	default:
+		return palette{}, fmt.Errorf("invalid color mode: %s (use auto, always, or never)", mode)
+	}
+}
+
+// mustPalette returns the palette for a -color mode, exiting on an invalid
+// mode.
+func mustPalette(mode string) palette {
+	colors, err := newPalette(mode)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	return colors
+}
+
+func (p palette) styled(style ansi.Style, text string) string {
+	if !p.enabled || text == "" {
+		return text
+	}
+	return style.Styled(text)
+}
+
+func (p palette) bold(text string) string {
+	return p.styled(ansi.Style{}.Bold(), text)
+}
+
+func (p palette) dim(text string) string {
+	return p.styled(ansi.Style{}.Faint(), text)
+}
+
+func (p palette) red(text string) string {
+	return p.styled(ansi.Style{}.ForegroundColor(ansi.Red), text)
+}
+
+func (p palette) green(text string) string {
+	return p.styled(ansi.Style{}.ForegroundColor(ansi.Green), text)
+}
+
+func (p palette) yellow(text string) string {
+	return p.styled(ansi.Style{}.ForegroundColor(ansi.Yellow), text)
+}
+
+func (p palette) cyan(text string) string {
+	return p.styled(ansi.Style{}.ForegroundColor(ansi.Cyan), text)
+}
+
+// highlight styles the title of a pinned item.
+func (p palette) highlight(text string) string {
+	return p.styled(ansi.Style{}.Bold().ForegroundColor(ansi.Yellow), text)
+}
@@ -0,0 +1,332 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pevans/newsfed/config"
+)
+
+// completionWord is a word a completion script offers, with the
+// description zsh and fish show beside it.
+type completionWord struct {
+	name        string
+	description string
+}
+
+// completionCommands are the commands completion scripts offer, as listed
+// by printUsage.
+var completionCommands = []completionWord{
+	{"list", "List news items"},
+	{"top", "List the highest scoring news items"},
+	{"show", "Show detailed view of a news item"},
+	{"add", "Save a link as a news item"},
+	{"edit", "Change a news item's title or summary"},
+	{"pin", "Pin a news item for later reference"},
+	{"unpin", "Unpin a news item"},
+	{"read", "Mark a news item as read"},
+	{"unread", "Mark a news item as unread"},
+	{"star", "Star a news item to keep it for reference"},
+	{"unstar", "Unstar a news item"},
+	{"note", "View or set notes on a news item"},
+	{"archive", "Hide a news item from listings"},
+	{"unarchive", "Return an archived news item to listings"},
+	{"snooze", "Hide a news item from listings for a while"},
+	{"unsnooze", "Return a snoozed news item to listings"},
+	{"delete", "Permanently delete a news item"},
+	{"open", "Open a news item URL in default browser"},
+	{"refresh", "Fetch a news item's page again to update it"},
+	{"prune", "Remove stale news items"},
+	{"export", "Write news items as a feed or as JSON, CSV, or Markdown"},
+	{"collections", "Group news items into named reading lists"},
+	{"users", "Manage the users who share this instance"},
+	{"digest", "Render or email a digest of the top news items"},
+	{"secrets", "Store credentials that sources refer to, encrypted"},
+	{"audit", "Show who changed sources and config, and when"},
+	{"journal", "Show what discovery did with each item it found, and why"},
+	{"metrics", "Show discovery metrics saved by the daemon across restarts"},
+	{"sync", "Manually sync sources to fetch new items"},
+	{"daemon", "Run continuous discovery with an HTTP metrics endpoint"},
+	{"init", "Initialize storage (create databases/directories)"},
+	{"doctor", "Check storage health and configuration"},
+	{"fsck", "Check the news feed for damaged items and repair them"},
+	{"migrate", "Copy the news feed to another storage backend"},
+	{"migrate-db", "Show or apply metadata database schema migrations"},
+	{"config", "Show or change user settings, or validate config files"},
+	{"sources", "Manage news sources"},
+	{"tui", "Launch the text user interface"},
+	{"completion", "Print a bash, zsh, or fish completion script"},
+	{"help", "Show this help message"},
+}
+
+// completionActions are the actions completion scripts offer after the
+// commands that take one.
+var completionActions = map[string][]completionWord{
+	"sources": {
+		{"list", "List all sources"},
+		{"show", "Show detailed source information"},
+		{"add", "Add a new source"},
+		{"update", "Update source configuration"},
+		{"delete", "Delete one or more sources"},
+		{"enable", "Enable one or more sources"},
+		{"disable", "Disable one or more sources"},
+		{"status", "Check source health"},
+		{"errors", "View error history for a source"},
+		{"history", "View sync history for a source"},
+		{"stats", "Show how many items sources produce and how often they fail"},
+		{"session", "Set the cookies or login a website source is scraped with"},
+		{"discover", "Find the feeds published by a website"},
+		{"categories", "List, add, or delete source categories"},
+	},
+	"collections": {
+		{"list", "List collections"},
+		{"show", "Show the items in a collection"},
+		{"create", "Create an empty collection"},
+		{"add", "Add items to a collection, creating it if needed"},
+		{"remove", "Remove an item from a collection"},
+		{"move", "Move an item to a position in a collection"},
+		{"update", "Rename a collection or change its description"},
+		{"delete", "Delete a collection (its items are kept)"},
+		{"export", "Write a collection's items as Markdown or another format"},
+	},
+	"users": {
+		{"list", "List users"},
+		{"add", "Add a user and print their API token"},
+		{"token", "Issue a new API token, replacing the old one"},
+		{"delete", "Delete a user and their item state"},
+	},
+	"secrets": {
+		{"list", "List secrets, without their values"},
+		{"set", "Store a secret, read from a prompt or stdin"},
+		{"delete", "Delete a secret"},
+	},
+	"config": {
+		{"show", "Show every setting and its value"},
+		{"set", "Change a setting"},
+		{"unset", "Return a setting to its default"},
+		{"validate", "Check config files for unknown keys and invalid values"},
+	},
+	"migrate-db": {
+		{"status", "Show the schema version of each part of the metadata database"},
+		{"up", "Apply pending schema migrations"},
+	},
+	"completion": {
+		{"bash", "Bash completion script"},
+		{"zsh", "Zsh completion script"},
+		{"fish", "Fish completion script"},
+	},
+}
+
+// completionActionCommands are the commands in completionActions, in the
+// order the scripts list them.
+var completionActionCommands = []string{"sources", "collections", "users", "secrets", "config", "migrate-db", "completion"}
+
+func handleCompletion(args []string) {
+	if len(args) != 1 {
+		fmt.Fprintf(os.Stderr, "Error: shell is required\n")
+		fmt.Fprintf(os.Stderr, "Usage: newsfed completion bash|zsh|fish\n")
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "bash":
+		fmt.Print(bashCompletion())
+	case "zsh":
+		fmt.Print(zshCompletion())
+	case "fish":
+		fmt.Print(fishCompletion())
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unsupported shell: %s (use bash, zsh, or fish)\n", args[0])
+		os.Exit(1)
+	}
+}
+
+// completionNames returns the names of words, separated by spaces.
+func completionNames(words []completionWord) string {
+	names := make([]string, len(words))
+	for i, word := range words {
+		names[i] = word.name
+	}
+	return strings.Join(names, " ")
+}
+
+// shellQuote quotes s for a POSIX shell, zsh, or fish with single quotes.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// bashCompletion returns the bash completion script. Commands come first,
+// after the global options, then the action of a command that takes one,
+// then the keys of config set and config unset; anything else falls back
+// to file names.
+func bashCompletion() string {
+	var b strings.Builder
+	b.WriteString(`# bash completion for newsfed
+# Load with: source <(newsfed completion bash)
+
+_newsfed() {
+    local cur="${COMP_WORDS[COMP_CWORD]}"
+    local cmd="" action="" i
+    for ((i = 1; i < COMP_CWORD; i++)); do
+        case "${COMP_WORDS[i]}" in
+            --user) ((i++)) ;;
+            -*) ;;
+            *)
+                if [[ -z "$cmd" ]]; then
+                    cmd="${COMP_WORDS[i]}"
+                elif [[ -z "$action" ]]; then
+                    action="${COMP_WORDS[i]}"
+                fi
+                ;;
+        esac
+    done
+
+    if [[ "${COMP_WORDS[COMP_CWORD-1]}" == "--user" ]]; then
+        return
+    fi
+    if [[ -z "$cmd" ]]; then
+        COMPREPLY=($(compgen -W "--ephemeral --user `)
+	b.WriteString(completionNames(completionCommands))
+	b.WriteString(`" -- "$cur"))
+        return
+    fi
+    if [[ -z "$action" ]]; then
+        case "$cmd" in
+`)
+	for _, command := range completionActionCommands {
+		fmt.Fprintf(&b, "            %s) COMPREPLY=($(compgen -W %q -- \"$cur\")) ;;\n",
+			command, completionNames(completionActions[command]))
+	}
+	b.WriteString(`        esac
+        return
+    fi
+    case "$cmd $action" in
+        "config set" | "config unset")
+            if [[ "${COMP_WORDS[COMP_CWORD-1]}" == "$action" ]]; then
+                COMPREPLY=($(compgen -W "`)
+	b.WriteString(strings.Join(config.Keys, " "))
+	b.WriteString(`" -- "$cur"))
+            fi
+            ;;
+    esac
+}
+
+complete -o default -F _newsfed newsfed
+`)
+	return b.String()
+}
+
+// zshCompletion returns the zsh completion script, which offers the same
+// words as the bash one with their descriptions.
+func zshCompletion() string {
+	var b strings.Builder
+	b.WriteString(`#compdef newsfed
+# Load with: source <(newsfed completion zsh)
+
+_newsfed() {
+    local curcontext="$curcontext" state line
+    local -a commands actions
+
+    _arguments -C \
+        '--ephemeral[Keep the news feed in memory]' \
+        '--user[User whose pins, read marks, and notes to use]:user: ' \
+        '1:command:->command' \
+        '*::argument:->argument'
+
+    case $state in
+        command)
+            commands=(
+`)
+	writeZshWords(&b, completionCommands, "                ")
+	b.WriteString(`            )
+            _describe -t commands 'newsfed command' commands
+            ;;
+        argument)
+            if (( CURRENT == 2 )); then
+                case $words[1] in
+`)
+	for _, command := range completionActionCommands {
+		fmt.Fprintf(&b, "                    %s)\n                        actions=(\n", command)
+		writeZshWords(&b, completionActions[command], "                            ")
+		fmt.Fprintf(&b, "                        )\n                        _describe -t actions '%s action' actions\n                        return\n                        ;;\n", command)
+	}
+	b.WriteString(`                esac
+            elif (( CURRENT == 3 )) && [[ $words[1] == config && ( $words[2] == set || $words[2] == unset ) ]]; then
+                _values 'config key' `)
+	quoted := make([]string, len(config.Keys))
+	for i, key := range config.Keys {
+		quoted[i] = shellQuote(key)
+	}
+	b.WriteString(strings.Join(quoted, " "))
+	b.WriteString(`
+                return
+            fi
+            _files
+            ;;
+    esac
+}
+
+if [[ "$funcstack[1]" == "_newsfed" ]]; then
+    _newsfed "$@"
+else
+    compdef _newsfed newsfed
+fi
+`)
+	return b.String()
+}
+
+// writeZshWords writes words as the elements of a zsh array for _describe,
+// one per line with the given indent.
+func writeZshWords(b *strings.Builder, words []completionWord, indent string) {
+	for _, word := range words {
+		b.WriteString(indent)
+		b.WriteString(shellQuote(word.name + ":" + strings.ReplaceAll(word.description, ":", `\:`)))
+		b.WriteString("\n")
+	}
+}
+
+// fishCompletion returns the fish completion script, which offers the same
+// words as the bash one with their descriptions.
+func fishCompletion() string {
+	var b strings.Builder
+	b.WriteString(`# fish completion for newsfed
+# Load with: newsfed completion fish | source
+
+# __newsfed_args prints the words after newsfed that aren't options
+function __newsfed_args
+    set -l tokens (commandline -opc)
+    set -e tokens[1]
+    set -l skip 0
+    for token in $tokens
+        if test $skip -eq 1
+            set skip 0
+        else if test "$token" = --user
+            set skip 1
+        else if not string match -q -- '-*' $token
+            echo $token
+        end
+    end
+end
+
+# __newsfed_at prints whether the words before the cursor are exactly argv
+function __newsfed_at
+    set -l args (__newsfed_args)
+    test "$args" = "$argv"
+end
+
+complete -c newsfed -n '__newsfed_at' -l ephemeral -d 'Keep the news feed in memory'
+complete -c newsfed -n '__newsfed_at' -l user -x -d 'User whose pins, read marks, and notes to use'
+`)
+	for _, command := range completionCommands {
+		fmt.Fprintf(&b, "complete -c newsfed -n '__newsfed_at' -f -a %s -d %s\n", command.name, shellQuote(command.description))
+	}
+	for _, command := range completionActionCommands {
+		for _, action := range completionActions[command] {
+			fmt.Fprintf(&b, "complete -c newsfed -n '__newsfed_at %s' -f -a %s -d %s\n", command, action.name, shellQuote(action.description))
+		}
+	}
+	fmt.Fprintf(&b, "complete -c newsfed -n '__newsfed_at config set; or __newsfed_at config unset' -f -a %s\n",
+		shellQuote(strings.Join(config.Keys, " ")))
+	return b.String()
+}
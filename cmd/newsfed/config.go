@@ -0,0 +1,362 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pevans/newsfed/config"
+	"github.com/pevans/newsfed/digest"
+	"github.com/pevans/newsfed/discovery"
+	"github.com/pevans/newsfed/newsfeed"
+	"github.com/pevans/newsfed/sources"
+)
+
+// handleConfig shows and changes the user configuration stored in the
+// metadata database.
+func handleConfig(metadataPath string, args []string) {
+	if len(args) == 0 {
+		printConfigUsage()
+		os.Exit(1)
+	}
+
+	// Validating config files doesn't need the metadata database
+	if args[0] == "validate" {
+		handleConfigValidate(args[1:])
+		return
+	}
+
+	configStore, err := config.NewConfigStore(metadataPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to open config store: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() { _ = configStore.Close() }()
+
+	switch args[0] {
+	case "show":
+		cfg, err := configStore.GetConfig()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to read config: %v\n", err)
+			os.Exit(1)
+		}
+		for _, key := range config.Keys {
+			value, _ := cfg.Value(key)
+			if value == "" {
+				value = "(default)"
+			}
+			fmt.Printf("%-26s %s\n", key, value)
+		}
+	case "set":
+		if len(args) != 3 {
+			fmt.Fprintf(os.Stderr, "Error: usage: newsfed config set <key> <value>\n")
+			os.Exit(1)
+		}
+		key, value := args[1], args[2]
+		if err := validateConfigValue(key, value); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := configStore.Set(key, value); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✓ Set %s to %s\n", key, value)
+	case "unset":
+		if len(args) != 2 {
+			fmt.Fprintf(os.Stderr, "Error: usage: newsfed config unset <key>\n")
+			os.Exit(1)
+		}
+		if err := configStore.Unset(args[1]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✓ Unset %s\n", args[1])
+	case "help", "--help", "-h":
+		printConfigUsage()
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown config command: %s\n\n", args[0])
+		printConfigUsage()
+		os.Exit(1)
+	}
+}
+
+func printConfigUsage() {
+	fmt.Println("Usage: newsfed config <command> [arguments]")
+	fmt.Println()
+	fmt.Println("Commands:")
+	fmt.Println("  show                 Show every setting and its value")
+	fmt.Println("  set <key> <value>    Change a setting")
+	fmt.Println("  unset <key>          Return a setting to its default")
+	fmt.Println("  validate [file...]   Check config files for unknown keys and invalid values")
+}
+
+// handleConfigValidate checks the given config files, or the ones newsfed
+// would load, for keys newsfed doesn't know and values it can't use.
+func handleConfigValidate(files []string) {
+	if len(files) == 0 {
+		var err error
+		files, err = config.ConfigFiles()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if len(files) == 0 {
+			fmt.Println("No config file found; built-in defaults are in use")
+			return
+		}
+	}
+
+	fmt.Println("Config files, lowest precedence first:")
+	for _, path := range files {
+		fmt.Printf("  %s\n", path)
+	}
+
+	cfg, err := config.LoadConfigFiles(files, true)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	problems := validateFileConfig(cfg)
+	if len(problems) > 0 {
+		fmt.Fprintf(os.Stderr, "Error: %d invalid setting(s):\n", len(problems))
+		for _, problem := range problems {
+			fmt.Fprintf(os.Stderr, "  %s\n", problem)
+		}
+		os.Exit(1)
+	}
+	fmt.Println("✓ Configuration is valid")
+}
+
+// validateFileConfig returns a description of each setting in cfg that
+// newsfed would reject or ignore.
+func validateFileConfig(cfg *config.FileConfig) []string {
+	var problems []string
+	invalid := func(key, value, want string) {
+		problems = append(problems, fmt.Sprintf("%s: %q (%s)", key, value, want))
+	}
+	// Durations in the config file are Go durations, except that
+	// scoring.half_life also accepts days and weeks
+	duration := func(key, value string, allowZero bool) {
+		if value == "" {
+			return
+		}
+		parse := time.ParseDuration
+		if key == "scoring.half_life" {
+			parse = parseDuration
+		}
+		d, err := parse(value)
+		if err != nil || d < 0 || (d == 0 && !allowZero) {
+			invalid(key, value, "must be a positive duration such as 30s or 5m")
+		}
+	}
+
+	switch cfg.Storage.Metadata.Type {
+	case "", "sqlite", "postgres":
+	default:
+		invalid("storage.metadata.type", cfg.Storage.Metadata.Type, "must be sqlite or postgres")
+	}
+	switch cfg.Storage.Feed.Type {
+	case "", "file", "sqlite", "postgres":
+	default:
+		invalid("storage.feed.type", cfg.Storage.Feed.Type, "must be file, sqlite, or postgres")
+	}
+
+	if cfg.Discovery.Concurrency < 0 {
+		invalid("discovery.concurrency", strconv.Itoa(cfg.Discovery.Concurrency), "must not be negative")
+	}
+	if cfg.Discovery.ArticleConcurrency < 0 {
+		invalid("discovery.article_concurrency", strconv.Itoa(cfg.Discovery.ArticleConcurrency), "must not be negative")
+	}
+	if cfg.Discovery.MaxPerDomain < 0 {
+		invalid("discovery.max_per_domain", strconv.Itoa(cfg.Discovery.MaxPerDomain), "must not be negative")
+	}
+	duration("discovery.rate_limit_jitter", cfg.Discovery.RateLimitJitter, true)
+	duration("discovery.claim_lease", cfg.Discovery.ClaimLease, false)
+	duration("discovery.summarize_timeout", cfg.Discovery.SummarizeTimeout, false)
+	if attempts := cfg.Discovery.RetryAttempts; attempts != nil && *attempts < 0 {
+		invalid("discovery.retry_attempts", strconv.Itoa(*attempts), "must not be negative")
+	}
+	duration("discovery.retry_base_delay", cfg.Discovery.RetryBaseDelay, true)
+	duration("discovery.retry_max_delay", cfg.Discovery.RetryMaxDelay, false)
+	if cfg.Discovery.RenderTabs < 0 {
+		invalid("discovery.render_tabs", strconv.Itoa(cfg.Discovery.RenderTabs), "must not be negative")
+	}
+	duration("discovery.render_timeout", cfg.Discovery.RenderTimeout, false)
+	if cfg.Discovery.MaxItemAge != "" {
+		if _, err := sources.ParseMaxAge(cfg.Discovery.MaxItemAge); err != nil {
+			invalid("discovery.max_item_age", cfg.Discovery.MaxItemAge, "must be a positive duration such as 720h or 365d")
+		}
+	}
+	duration("discovery.adaptive_min_interval", cfg.Discovery.AdaptiveMinInterval, false)
+	duration("discovery.adaptive_max_interval", cfg.Discovery.AdaptiveMaxInterval, false)
+	if lo, err := time.ParseDuration(cfg.Discovery.AdaptiveMinInterval); err == nil && lo > 0 {
+		hi, err := time.ParseDuration(cfg.Discovery.AdaptiveMaxInterval)
+		if err != nil {
+			hi = 24 * time.Hour
+		}
+		if lo < 5*time.Minute {
+			invalid("discovery.adaptive_min_interval", cfg.Discovery.AdaptiveMinInterval, "must be at least 5m")
+		} else if lo > hi {
+			invalid("discovery.adaptive_min_interval", cfg.Discovery.AdaptiveMinInterval, "must not be longer than discovery.adaptive_max_interval")
+		}
+	}
+	if cfg.Discovery.JournalRetention != "" {
+		if _, err := newsfeed.ParseRetention(cfg.Discovery.JournalRetention); err != nil {
+			invalid("discovery.journal_retention", cfg.Discovery.JournalRetention, "must be a positive duration such as 336h or 14d")
+		}
+	}
+	if cfg.Discovery.MaxResponseSize != "" {
+		if size, err := sources.ParseByteSize(cfg.Discovery.MaxResponseSize); err != nil || size == 0 {
+			invalid("discovery.max_response_size", cfg.Discovery.MaxResponseSize, "must be a size such as 20MB")
+		}
+	}
+	for i, hook := range cfg.Discovery.Hooks {
+		key := fmt.Sprintf("discovery.hooks[%d]", i)
+		if !slices.Contains(discovery.HookEvents, hook.Event) {
+			invalid(key+".event", hook.Event, "must be one of "+strings.Join(discovery.HookEvents, ", "))
+		}
+		if len(hook.Command) == 0 || hook.Command[0] == "" {
+			invalid(key+".command", strings.Join(hook.Command, " "), "must name a program to run")
+		}
+		duration(key+".timeout", hook.Timeout, false)
+	}
+	if cfg.Discovery.PublishURL != "" {
+		if err := discovery.ValidatePublishURL(cfg.Discovery.PublishURL); err != nil {
+			invalid("discovery.publish_url", cfg.Discovery.PublishURL, err.Error())
+		}
+	}
+	if cfg.Discovery.PublishSubject != "" {
+		if err := discovery.ValidatePublishSubject(cfg.Discovery.PublishSubject); err != nil {
+			invalid("discovery.publish_subject", cfg.Discovery.PublishSubject, err.Error())
+		}
+	}
+	if cfg.Discovery.PublishBuffer < 0 {
+		invalid("discovery.publish_buffer", strconv.Itoa(cfg.Discovery.PublishBuffer), "must not be negative")
+	}
+	if cfg.Discovery.ProxyURL != "" {
+		if err := sources.ValidateProxyURL(cfg.Discovery.ProxyURL); err != nil {
+			invalid("discovery.proxy_url", cfg.Discovery.ProxyURL, err.Error())
+		}
+	}
+
+	duration("scoring.half_life", cfg.Scoring.HalfLife, false)
+
+	if cfg.Server.RateBurst < 0 {
+		invalid("server.rate_burst", strconv.Itoa(cfg.Server.RateBurst), "must not be negative")
+	}
+
+	if cfg.SMTP.Port < 0 || cfg.SMTP.Port > 65535 {
+		invalid("smtp.port", strconv.Itoa(cfg.SMTP.Port), "must be between 1 and 65535")
+	}
+	if cfg.SMTP.Host == "" && (cfg.SMTP.Username != "" || cfg.SMTP.From != "") {
+		problems = append(problems, "smtp.host: not set, but other smtp settings are")
+	}
+
+	return problems
+}
+
+// validateConfigValue returns an error if value is not a valid setting for
+// key.
+func validateConfigValue(key, value string) error {
+	if _, ok := (&config.Config{}).Value(key); !ok {
+		return fmt.Errorf("unknown config key: %s", key)
+	}
+
+	positiveDuration := func() error {
+		if d, err := parseDuration(value); err != nil || d <= 0 {
+			return fmt.Errorf("invalid %s: %q (must be a positive duration such as 30m or 3d)", key, value)
+		}
+		return nil
+	}
+
+	switch key {
+	case "default_polling_interval", "check_interval", "metrics_interval", "digest_since":
+		return positiveDuration()
+	case "list_window":
+		if d, err := parseDuration(value); err != nil || d < 0 {
+			return fmt.Errorf("invalid list_window: %q (must be a duration such as 3d, or 0 to show all items)", value)
+		}
+	case "list_limit":
+		if n, err := strconv.Atoi(value); err != nil || n <= 0 {
+			return fmt.Errorf("invalid list_limit: %q (must be a positive number)", value)
+		}
+	case "list_sort":
+		return newsfeed.ListOptions{SortBy: value}.Validate()
+	case "list_format":
+		if value != "table" && value != "json" && value != "compact" {
+			return fmt.Errorf("invalid list_format: %s (must be table, json, or compact)", value)
+		}
+	case "digest_schedule":
+		_, err := digest.ParseSchedule(value)
+		return err
+	case "digest_to":
+		if len(digest.ParseRecipients(value)) == 0 {
+			return fmt.Errorf("digest_to needs at least one address")
+		}
+	case "digest_format":
+		return digest.ValidateFormat(value)
+	case "digest_group":
+		return digest.ValidateGroup(value)
+	}
+	return nil
+}
+
+// listDefaults are the settings `newsfed list` uses for flags that aren't
+// given.
+type listDefaults struct {
+	// window is how far back unpinned items are shown when no filter is
+	// given; zero shows every item.
+	window time.Duration
+	limit  int
+	sort   string
+	format string
+}
+
+// loadListDefaults returns the list defaults from the config store, falling
+// back to the built-in defaults for settings that are unset. Invalid
+// settings are reported as warnings and ignored, so a bad value never
+// stops items from being listed.
+func loadListDefaults(metadataPath string) listDefaults {
+	defaults := listDefaults{
+		window: 3 * 24 * time.Hour,
+		limit:  20,
+		sort:   newsfeed.SortPublished,
+		format: "table",
+	}
+
+	configStore, err := config.NewConfigStore(metadataPath)
+	if err != nil {
+		return defaults
+	}
+	defer func() { _ = configStore.Close() }()
+	cfg, err := configStore.GetConfig()
+	if err != nil {
+		return defaults
+	}
+
+	settings := []struct {
+		key, value string
+		apply      func(string)
+	}{
+		{"list_window", cfg.ListWindow, func(v string) { defaults.window, _ = parseDuration(v) }},
+		{"list_limit", cfg.ListLimit, func(v string) { defaults.limit, _ = strconv.Atoi(v) }},
+		{"list_sort", cfg.ListSort, func(v string) { defaults.sort = v }},
+		{"list_format", cfg.ListFormat, func(v string) { defaults.format = v }},
+	}
+	for _, s := range settings {
+		if s.value == "" {
+			continue
+		}
+		if err := validateConfigValue(s.key, s.value); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: ignoring config setting: %v\n", err)
+			continue
+		}
+		s.apply(s.value)
+	}
+	return defaults
+}
@@ -0,0 +1,774 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pevans/newsfed/audit"
+	"github.com/pevans/newsfed/collections"
+	"github.com/pevans/newsfed/config"
+	"github.com/pevans/newsfed/discovery"
+	"github.com/pevans/newsfed/grpcapi"
+	"github.com/pevans/newsfed/newsfeed"
+	"github.com/pevans/newsfed/ratelimit"
+	"github.com/pevans/newsfed/secrets"
+	"github.com/pevans/newsfed/sources"
+	"github.com/pevans/newsfed/users"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// Default per-client HTTP rate limit.
+const (
+	defaultRateLimit = 10
+	defaultRateBurst = 20
+)
+
+// shutdownTimeout bounds how long the daemon waits for the HTTP server to
+// drain after a shutdown signal.
+const shutdownTimeout = 10 * time.Second
+
+func handleDaemon(metadataPath, feedDSN string, args []string) {
+	fs := flag.NewFlagSet("daemon", flag.ExitOnError)
+	addr := fs.String("addr", "localhost:8080", "HTTP listen address (empty to disable)")
+	grpcAddr := fs.String("grpc-addr", "", "gRPC listen address (empty to disable)")
+	concurrency := fs.Int("concurrency", 0, "Maximum number of sources to fetch in parallel")
+	rateLimit := fs.Float64("rate-limit", 0, "HTTP requests per second allowed per client (negative to disable)")
+	rateBurst := fs.Int("rate-burst", 0, "HTTP requests a client may make at once")
+	workerID := fs.String("worker-id", "", "Name this daemon claims sources under (default: host name and process ID)")
+	_ = fs.Parse(args)
+
+	fileConfig, err := config.LoadConfigFile()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	discoveryConfig, err := loadDiscoveryConfig(metadataPath, fileConfig)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if *concurrency > 0 {
+		discoveryConfig.Concurrency = *concurrency
+	}
+	if *workerID != "" {
+		discoveryConfig.WorkerID = *workerID
+	}
+
+	// Initialize source store
+	sourceStore, err := sources.NewSourceStore(metadataPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to open source store: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() { _ = sourceStore.Close() }()
+
+	// Initialize news feed
+	newsFeed, err := newsfeed.Open(feedDSN)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to open news feed: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() { _ = newsFeed.Close() }()
+
+	// Initialize collection store
+	collectionStore, err := collections.NewCollectionStore(metadataPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to open collection store: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() { _ = collectionStore.Close() }()
+
+	// Initialize audit log; changes the daemon makes, such as disabling a
+	// failing source, are recorded as the daemon's
+	audit.Actor = "daemon"
+	auditLog, err := audit.NewLog(metadataPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to open audit log: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() { _ = auditLog.Close() }()
+
+	// Initialize config store
+	configStore, err := config.NewConfigStore(metadataPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to open config store: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() { _ = configStore.Close() }()
+
+	// Initialize user store
+	userStore, err := users.NewUserStore(metadataPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to open user store: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() { _ = userStore.Close() }()
+
+	service := discovery.NewDiscoveryService(sourceStore, newsFeed, discoveryConfig)
+	defer func() { _ = service.Close() }()
+	secretStore, err := openSecrets(metadataPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to open secrets: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() { _ = secretStore.Close() }()
+	service.SetSecrets(secretStore)
+	summaryEnricher, err := loadSummaryEnricher(fileConfig)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if summaryEnricher != nil {
+		service.AddEnricher(summaryEnricher)
+	}
+	shutdownTracing := setupTracing()
+
+	// Stop on SIGINT or SIGTERM; in-progress fetches are allowed to finish
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// The HTTP and gRPC servers share one rate limiter, so a client's
+	// allowance covers both
+	limitConfig, err := loadRateLimitConfig(fileConfig, *rateLimit, *rateBurst)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	limiter := ratelimit.New(limitConfig)
+
+	var server *http.Server
+	serverErr := make(chan error, 1)
+	if *addr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("GET /metrics", service.GetMetrics())
+		mux.Handle("GET /healthz", service.HealthHandler())
+		mux.Handle("GET /readyz", service.ReadyHandler())
+		mux.Handle("POST /api/v1/meta/sync", service.StartSyncHandler(ctx))
+		mux.Handle("GET /api/v1/meta/sync/{id}", service.SyncJobHandler())
+		mux.Handle("GET /api/v1/meta/audit", auditLog.ListHandler())
+		mux.Handle("GET /api/v1/meta/metrics", service.MetricsHandler())
+		mux.Handle("GET /api/v1/meta/categories", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sourceStore.CategoriesHandler(userStore.Feed(r, newsFeed)).ServeHTTP(w, r)
+		}))
+		mux.Handle("PATCH /api/v1/meta/sources", sourceStore.BatchHandler())
+		// Discovery fetches a URL the client chooses, so it needs a user as
+		// saving does
+		mux.Handle("POST /api/v1/meta/sources/discover", users.RequireUser(service.DiscoverSourcesHandler()))
+		mux.Handle("GET /api/v1/meta/sources/{id}", sourceStore.GetHandler())
+		mux.Handle("PATCH /api/v1/meta/sources/{id}", sourceStore.PatchHandler())
+		mux.Handle("GET /api/v1/meta/sources/{id}/history", sourceStore.HistoryHandler())
+		mux.Handle("GET /api/v1/meta/config", configStore.GetHandler())
+		mux.Handle("PUT /api/v1/meta/config", configStore.PutHandler(validateConfigValue))
+		mux.Handle("GET /api/v1/collections", collectionStore.ListHandler())
+		mux.Handle("POST /api/v1/collections", collectionStore.CreateHandler())
+		mux.Handle("GET /api/v1/collections/{name}", collectionStore.GetHandler())
+		mux.Handle("PATCH /api/v1/collections/{name}", collectionStore.UpdateHandler())
+		mux.Handle("DELETE /api/v1/collections/{name}", collectionStore.DeleteHandler())
+		mux.Handle("POST /api/v1/collections/{name}/items", collectionStore.AddItemHandler(newsFeed))
+		mux.Handle("DELETE /api/v1/collections/{name}/items/{id}", collectionStore.RemoveItemHandler())
+		mux.Handle("GET /api/v1/collections/{name}/export", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Export each item with the state of the user making the request
+			collectionStore.ExportHandler(userStore.Feed(r, newsFeed)).ServeHTTP(w, r)
+		}))
+		mux.Handle("GET /api/v1/items", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userStore.Feed(r, newsFeed).ListHandler().ServeHTTP(w, r)
+		}))
+		mux.Handle("POST /api/v1/items", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userStore.Feed(r, newsFeed).CreateHandler().ServeHTTP(w, r)
+		}))
+		mux.Handle("GET /api/v1/feed.xml", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userStore.Feed(r, newsFeed).FeedHandler().ServeHTTP(w, r)
+		}))
+		mux.Handle("GET /api/v1/items/changes", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userStore.Feed(r, newsFeed).ChangesHandler().ServeHTTP(w, r)
+		}))
+		mux.Handle("GET /api/v1/items/export", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userStore.Feed(r, newsFeed).ExportHandler().ServeHTTP(w, r)
+		}))
+		mux.Handle("GET /api/v1/items/facets", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userStore.Feed(r, newsFeed).FacetsHandler().ServeHTTP(w, r)
+		}))
+		mux.Handle("POST /api/v1/items/batch", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userStore.Feed(r, newsFeed).BatchHandler().ServeHTTP(w, r)
+		}))
+		// Saving fetches a URL the client chooses, so it needs a user even
+		// while the rest of the API is open
+		mux.Handle("POST /api/v1/items/save", users.RequireUser(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			service.SaveHandler(userStore.Feed(r, newsFeed)).ServeHTTP(w, r)
+		})))
+		mux.Handle("GET /api/v1/items/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userStore.Feed(r, newsFeed).GetHandler().ServeHTTP(w, r)
+		}))
+		mux.Handle("PATCH /api/v1/items/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userStore.Feed(r, newsFeed).PatchHandler().ServeHTTP(w, r)
+		}))
+		mux.Handle("PUT /api/v1/items/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userStore.Feed(r, newsFeed).EditHandler().ServeHTTP(w, r)
+		}))
+		mux.Handle("DELETE /api/v1/items/{id}", newsFeed.DeleteHandler())
+		mux.Handle("POST /api/v1/items/{id}/refresh", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			service.RefreshHandler(userStore.Feed(r, newsFeed)).ServeHTTP(w, r)
+		}))
+		mux.Handle("GET /api/v1/items/{id}/open", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userStore.Feed(r, newsFeed).OpenHandler().ServeHTTP(w, r)
+		}))
+		mux.Handle("GET /api/v1/users/me", userStore.WhoAmIHandler())
+
+		// Probes and metrics scrapers don't authenticate; everything else
+		// needs a token once there are users. Requests are limited by address
+		// before their token is checked, so guessing tokens is limited too,
+		// and by user after.
+		root := http.NewServeMux()
+		root.Handle("/", limiter.AddressMiddleware(userStore.Middleware(limiter.Middleware(mux))))
+		for _, path := range []string{"/metrics", "/healthz", "/readyz"} {
+			root.Handle("GET "+path, limiter.AddressMiddleware(mux))
+		}
+
+		server = &http.Server{
+			Addr:              *addr,
+			Handler:           root,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		go func() {
+			log.Printf("INFO: Listening on %s", *addr)
+			if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				serverErr <- err
+			}
+		}()
+	}
+
+	var grpcServer *grpc.Server
+	if *grpcAddr != "" {
+		lis, err := net.Listen("tcp", *grpcAddr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to listen on %s: %v\n", *grpcAddr, err)
+			os.Exit(1)
+		}
+		api := grpcapi.NewServer(ctx, grpcapi.Services{
+			Feed:        newsFeed,
+			Sources:     sourceStore,
+			Collections: collectionStore,
+			Users:       userStore,
+			Audit:       auditLog,
+			Discovery:   service,
+		})
+		grpcServer = grpc.NewServer(grpc.ChainUnaryInterceptor(grpcapi.RateLimitAddress(limiter), api.Authenticate, grpcapi.RateLimit(limiter)))
+		api.Register(grpcServer)
+		healthServer := health.NewServer()
+		healthpb.RegisterHealthServer(grpcServer, healthServer)
+		go func() {
+			log.Printf("INFO: Serving gRPC on %s", lis.Addr())
+			if err := grpcServer.Serve(lis); err != nil {
+				serverErr <- err
+			}
+		}()
+	}
+
+	// Reload configuration on SIGHUP without interrupting fetches
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				fileConfig, err := config.LoadConfigFile()
+				if err != nil {
+					log.Printf("ERROR: Reload failed, keeping current configuration: %v", err)
+					continue
+				}
+				reloaded, err := loadDiscoveryConfig(metadataPath, fileConfig)
+				if err != nil {
+					log.Printf("ERROR: Reload failed, keeping current configuration: %v", err)
+					continue
+				}
+				if *concurrency > 0 {
+					reloaded.Concurrency = *concurrency
+				}
+				service.Reload(reloaded)
+			}
+		}
+	}()
+
+	// Email digests on the schedule set with `newsfed digest schedule`
+	go runDigestScheduler(ctx, metadataPath, newsFeed)
+
+	log.Printf("INFO: Claiming sources as worker %s", service.WorkerID())
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- service.Run(ctx)
+	}()
+
+	exitCode := 0
+	select {
+	case err := <-serverErr:
+		log.Printf("ERROR: Server failed: %v", err)
+		exitCode = 1
+		stop()
+		<-runErr
+	case err := <-runErr:
+		if err != nil && !errors.Is(err, context.Canceled) {
+			log.Printf("ERROR: Discovery service failed: %v", err)
+			exitCode = 1
+		}
+	}
+
+	if grpcServer != nil {
+		stopGRPC(grpcServer)
+	}
+	if server != nil {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Printf("WARN: HTTP server shutdown: %v", err)
+		}
+	}
+
+	shutdownTracing()
+	log.Println("INFO: Daemon stopped")
+	if exitCode != 0 {
+		_ = sourceStore.Close()
+		_ = newsFeed.Close()
+		os.Exit(exitCode)
+	}
+}
+
+// stopGRPC stops g, letting in-flight calls finish for up to shutdownTimeout
+// before cutting them off.
+func stopGRPC(g *grpc.Server) {
+	done := make(chan struct{})
+	go func() {
+		g.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(shutdownTimeout):
+		g.Stop()
+	}
+}
+
+// loadRateLimitConfig returns the per-client HTTP rate limit from the
+// defaults, fileConfig, and flags (non-zero flags take precedence).
+func loadRateLimitConfig(fileConfig *config.FileConfig, rateFlag float64, burstFlag int) (ratelimit.Config, error) {
+	limit := ratelimit.Config{Rate: defaultRateLimit, Burst: defaultRateBurst}
+
+	if fileConfig != nil {
+		if fileConfig.Server.RateLimit != 0 {
+			limit.Rate = fileConfig.Server.RateLimit
+		}
+		if fileConfig.Server.RateBurst != 0 {
+			limit.Burst = fileConfig.Server.RateBurst
+		}
+	}
+
+	if rateFlag != 0 {
+		limit.Rate = rateFlag
+	}
+	if burstFlag != 0 {
+		limit.Burst = burstFlag
+	}
+	if limit.Burst < 0 {
+		return limit, fmt.Errorf("rate burst must not be negative")
+	}
+	return limit, nil
+}
+
+// loadProxyURL returns the default proxy for fetching sources from
+// NEWSFED_PROXY_URL or, failing that, discovery.proxy_url in fileConfig.
+// It returns "" if neither is set.
+func loadProxyURL(fileConfig *config.FileConfig) (string, error) {
+	proxyURL := os.Getenv("NEWSFED_PROXY_URL")
+	source := "NEWSFED_PROXY_URL"
+	if proxyURL == "" {
+		if fileConfig == nil {
+			return "", nil
+		}
+		proxyURL = fileConfig.Discovery.ProxyURL
+		source = "discovery.proxy_url"
+	}
+	if proxyURL == "" {
+		return "", nil
+	}
+	if err := sources.ValidateProxyURL(proxyURL); err != nil {
+		return "", fmt.Errorf("invalid %s: %w", source, err)
+	}
+	return proxyURL, nil
+}
+
+// loadRetryPolicy sets config's in-fetch retry settings from
+// discovery.retry_attempts, retry_base_delay, and retry_max_delay in the
+// config file, leaving the defaults for any that are unset.
+func loadRetryPolicy(discoveryConfig *discovery.DiscoveryConfig, fileConfig *config.FileConfig) error {
+	if fileConfig == nil {
+		return nil
+	}
+	if attempts := fileConfig.Discovery.RetryAttempts; attempts != nil {
+		if *attempts < 0 {
+			return fmt.Errorf("invalid discovery.retry_attempts: %d", *attempts)
+		}
+		discoveryConfig.RetryAttempts = *attempts
+	}
+	if val := fileConfig.Discovery.RetryBaseDelay; val != "" {
+		d, err := time.ParseDuration(val)
+		if err != nil || d < 0 {
+			return fmt.Errorf("invalid discovery.retry_base_delay: %q", val)
+		}
+		discoveryConfig.RetryBaseDelay = d
+	}
+	if val := fileConfig.Discovery.RetryMaxDelay; val != "" {
+		d, err := time.ParseDuration(val)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid discovery.retry_max_delay: %q", val)
+		}
+		discoveryConfig.RetryMaxDelay = d
+	}
+	return nil
+}
+
+// loadRenderSettings sets config's headless browser settings from
+// discovery.browser_path, render_tabs, and render_timeout in the config
+// file, leaving the defaults for any that are unset.
+func loadRenderSettings(discoveryConfig *discovery.DiscoveryConfig, fileConfig *config.FileConfig) error {
+	if fileConfig == nil {
+		return nil
+	}
+	discoveryConfig.BrowserPath = fileConfig.Discovery.BrowserPath
+	if tabs := fileConfig.Discovery.RenderTabs; tabs != 0 {
+		if tabs < 0 {
+			return fmt.Errorf("invalid discovery.render_tabs: %d", tabs)
+		}
+		discoveryConfig.RenderTabs = tabs
+	}
+	if val := fileConfig.Discovery.RenderTimeout; val != "" {
+		d, err := time.ParseDuration(val)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid discovery.render_timeout: %q", val)
+		}
+		discoveryConfig.RenderTimeout = d
+	}
+	return nil
+}
+
+// loadMaxItemAge sets the age past which items are skipped from
+// discovery.max_item_age in the config file.
+func loadMaxItemAge(discoveryConfig *discovery.DiscoveryConfig, fileConfig *config.FileConfig) error {
+	if fileConfig == nil || fileConfig.Discovery.MaxItemAge == "" {
+		return nil
+	}
+	maxAge, err := sources.ParseMaxAge(fileConfig.Discovery.MaxItemAge)
+	if err != nil {
+		return fmt.Errorf("invalid discovery.max_item_age: %q", fileConfig.Discovery.MaxItemAge)
+	}
+	discoveryConfig.MaxItemAge = maxAge
+	return nil
+}
+
+// loadMaxResponseSize sets the largest feed or page body read from
+// discovery.max_response_size in the config file.
+func loadMaxResponseSize(discoveryConfig *discovery.DiscoveryConfig, fileConfig *config.FileConfig) error {
+	if fileConfig == nil || fileConfig.Discovery.MaxResponseSize == "" {
+		return nil
+	}
+	size, err := sources.ParseByteSize(fileConfig.Discovery.MaxResponseSize)
+	if err != nil || size == 0 {
+		return fmt.Errorf("invalid discovery.max_response_size: %q", fileConfig.Discovery.MaxResponseSize)
+	}
+	discoveryConfig.MaxResponseSize = size
+	return nil
+}
+
+// loadAdaptivePolling sets whether feeds are polled as often as they post,
+// and the bounds on their intervals, from discovery.adaptive_polling,
+// adaptive_min_interval, and adaptive_max_interval in the config file.
+func loadAdaptivePolling(discoveryConfig *discovery.DiscoveryConfig, fileConfig *config.FileConfig) error {
+	if fileConfig == nil {
+		return nil
+	}
+	discoveryConfig.AdaptivePolling = fileConfig.Discovery.AdaptivePolling
+	if val := fileConfig.Discovery.AdaptiveMinInterval; val != "" {
+		d, err := time.ParseDuration(val)
+		if err != nil || d < 5*time.Minute {
+			return fmt.Errorf("invalid discovery.adaptive_min_interval: %q", val)
+		}
+		discoveryConfig.AdaptiveMinInterval = d
+	}
+	if val := fileConfig.Discovery.AdaptiveMaxInterval; val != "" {
+		d, err := time.ParseDuration(val)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid discovery.adaptive_max_interval: %q", val)
+		}
+		discoveryConfig.AdaptiveMaxInterval = d
+	}
+	if discoveryConfig.AdaptiveMinInterval > discoveryConfig.AdaptiveMaxInterval {
+		return fmt.Errorf("discovery.adaptive_min_interval is longer than adaptive_max_interval")
+	}
+	return nil
+}
+
+// loadJournal sets where discovery decisions are journaled,
+// ~/.newsfed/journal.jsonl unless discovery.journal is false in the config
+// file, and how long entries are kept, from discovery.journal_retention.
+func loadJournal(discoveryConfig *discovery.DiscoveryConfig, fileConfig *config.FileConfig) error {
+	if fileConfig != nil {
+		if enabled := fileConfig.Discovery.Journal; enabled != nil && !*enabled {
+			return nil
+		}
+		if val := fileConfig.Discovery.JournalRetention; val != "" {
+			d, err := newsfeed.ParseRetention(val)
+			if err != nil {
+				return fmt.Errorf("invalid discovery.journal_retention: %q", val)
+			}
+			discoveryConfig.JournalRetention = d
+		}
+	}
+	path, err := config.JournalPath()
+	if err != nil {
+		return err
+	}
+	discoveryConfig.JournalPath = path
+	return nil
+}
+
+// loadHooks sets the commands run on discovery events from
+// discovery.hooks in the config file.
+func loadHooks(discoveryConfig *discovery.DiscoveryConfig, fileConfig *config.FileConfig) error {
+	if fileConfig == nil {
+		return nil
+	}
+	for i, h := range fileConfig.Discovery.Hooks {
+		if !slices.Contains(discovery.HookEvents, h.Event) {
+			return fmt.Errorf("invalid discovery.hooks[%d].event: %q (use %s)", i, h.Event, strings.Join(discovery.HookEvents, ", "))
+		}
+		if len(h.Command) == 0 || h.Command[0] == "" {
+			return fmt.Errorf("discovery.hooks[%d] has no command", i)
+		}
+		hook := discovery.Hook{Event: h.Event, Command: h.Command}
+		if h.Timeout != "" {
+			timeout, err := time.ParseDuration(h.Timeout)
+			if err != nil || timeout <= 0 {
+				return fmt.Errorf("invalid discovery.hooks[%d].timeout: %q", i, h.Timeout)
+			}
+			hook.Timeout = timeout
+		}
+		discoveryConfig.Hooks = append(discoveryConfig.Hooks, hook)
+	}
+	return nil
+}
+
+// loadPublish sets the NATS server and subject new items are published to,
+// and how many are buffered, from discovery.publish_url,
+// discovery.publish_subject, and discovery.publish_buffer in the config
+// file.
+func loadPublish(discoveryConfig *discovery.DiscoveryConfig, fileConfig *config.FileConfig) error {
+	if fileConfig == nil {
+		return nil
+	}
+	d := fileConfig.Discovery
+	if d.PublishURL != "" {
+		if err := discovery.ValidatePublishURL(d.PublishURL); err != nil {
+			return fmt.Errorf("invalid discovery.publish_url: %w", err)
+		}
+		discoveryConfig.PublishURL = d.PublishURL
+	}
+	if d.PublishSubject != "" {
+		if err := discovery.ValidatePublishSubject(d.PublishSubject); err != nil {
+			return fmt.Errorf("invalid discovery.publish_subject: %q (%w)", d.PublishSubject, err)
+		}
+		discoveryConfig.PublishSubject = d.PublishSubject
+	}
+	if d.PublishBuffer < 0 {
+		return fmt.Errorf("invalid discovery.publish_buffer: %d", d.PublishBuffer)
+	}
+	discoveryConfig.PublishBuffer = d.PublishBuffer
+	return nil
+}
+
+// loadMasterKey returns the key secrets are encrypted with, from
+// NEWSFED_MASTER_KEY or, failing that, the OS keychain, or "" if neither
+// has one.
+func loadMasterKey() (string, error) {
+	if val := os.Getenv("NEWSFED_MASTER_KEY"); val != "" {
+		return val, nil
+	}
+	return secrets.KeychainMasterKey()
+}
+
+// openSecrets opens the secrets that source configs refer to, with the
+// master key from loadMasterKey. A keychain that can't be read is only
+// warned about, since most sources don't refer to secrets; those that do
+// fail to fetch without the key.
+func openSecrets(metadataPath string) (*secrets.Store, error) {
+	masterKey, err := loadMasterKey()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠ Warning: %v\n", err)
+	}
+	return secrets.Open(metadataPath, masterKey)
+}
+
+// loadSummaryEnricher returns an enricher that runs
+// discovery.summarize_command from fileConfig on items without a summary,
+// or nil if no command is configured.
+func loadSummaryEnricher(fileConfig *config.FileConfig) (*discovery.SummaryEnricher, error) {
+	if fileConfig == nil || len(fileConfig.Discovery.SummarizeCommand) == 0 {
+		return nil, nil
+	}
+
+	enricher := discovery.NewSummaryEnricher(&discovery.CommandSummarizer{
+		Command: fileConfig.Discovery.SummarizeCommand,
+	})
+	if fileConfig.Discovery.SummarizeTimeout != "" {
+		timeout, err := time.ParseDuration(fileConfig.Discovery.SummarizeTimeout)
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("invalid discovery.summarize_timeout: %q", fileConfig.Discovery.SummarizeTimeout)
+		}
+		enricher.Timeout = timeout
+	}
+	return enricher, nil
+}
+
+// loadDiscoveryConfig builds the discovery configuration from the defaults,
+// fileConfig, the user configuration in the metadata database, and
+// environment variables. It is called at startup and again on SIGHUP, with
+// the config file parsed afresh each time.
+func loadDiscoveryConfig(metadataPath string, fileConfig *config.FileConfig) (*discovery.DiscoveryConfig, error) {
+	discoveryConfig := discovery.DefaultDiscoveryConfig()
+
+	if fileConfig != nil {
+		if fileConfig.Discovery.Concurrency > 0 {
+			discoveryConfig.Concurrency = fileConfig.Discovery.Concurrency
+		}
+		if fileConfig.Discovery.ArticleConcurrency > 0 {
+			discoveryConfig.ArticleConcurrency = fileConfig.Discovery.ArticleConcurrency
+		}
+		if fileConfig.Discovery.MaxPerDomain > 0 {
+			discoveryConfig.MaxPerDomain = fileConfig.Discovery.MaxPerDomain
+		}
+		if fileConfig.Discovery.RateLimitJitter != "" {
+			jitter, err := time.ParseDuration(fileConfig.Discovery.RateLimitJitter)
+			if err != nil || jitter < 0 {
+				return nil, fmt.Errorf("invalid discovery.rate_limit_jitter: %q", fileConfig.Discovery.RateLimitJitter)
+			}
+			discoveryConfig.RateLimitJitter = jitter
+		}
+		discoveryConfig.WorkerID = fileConfig.Discovery.WorkerID
+		if fileConfig.Discovery.ClaimLease != "" {
+			lease, err := time.ParseDuration(fileConfig.Discovery.ClaimLease)
+			if err != nil || lease <= 0 {
+				return nil, fmt.Errorf("invalid discovery.claim_lease: %q", fileConfig.Discovery.ClaimLease)
+			}
+			discoveryConfig.ClaimLease = lease
+		}
+		discoveryConfig.KeepSummaryHTML = fileConfig.Discovery.KeepSummaryHTML
+	}
+
+	configStore, err := config.NewConfigStore(metadataPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open config store: %w", err)
+	}
+	defer func() { _ = configStore.Close() }()
+
+	cfg, err := configStore.GetConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	if cfg.DefaultPollingInterval != "" {
+		interval, err := parseDuration(cfg.DefaultPollingInterval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid default polling interval: %w", err)
+		}
+		discoveryConfig.PollInterval = interval
+	}
+	if cfg.CheckInterval != "" {
+		interval, err := parseDuration(cfg.CheckInterval)
+		if err != nil || interval <= 0 {
+			return nil, fmt.Errorf("invalid check interval: %q", cfg.CheckInterval)
+		}
+		discoveryConfig.CheckInterval = interval
+	}
+	if cfg.MetricsInterval != "" {
+		interval, err := parseDuration(cfg.MetricsInterval)
+		if err != nil || interval <= 0 {
+			return nil, fmt.Errorf("invalid metrics interval: %q", cfg.MetricsInterval)
+		}
+		discoveryConfig.MetricsInterval = interval
+	}
+
+	if val := os.Getenv("NEWSFED_RATE_LIMIT_INTERVAL"); val != "" {
+		if d, err := time.ParseDuration(val); err == nil {
+			discoveryConfig.RateLimitInterval = d
+		}
+	}
+	if val := os.Getenv("NEWSFED_RATE_LIMIT_JITTER"); val != "" {
+		if d, err := time.ParseDuration(val); err == nil && d >= 0 {
+			discoveryConfig.RateLimitJitter = d
+		}
+	}
+
+	if val := os.Getenv("NEWSFED_CHECK_INTERVAL"); val != "" {
+		if d, err := time.ParseDuration(val); err == nil && d > 0 {
+			discoveryConfig.CheckInterval = d
+		}
+	}
+	if val := os.Getenv("NEWSFED_METRICS_INTERVAL"); val != "" {
+		if d, err := time.ParseDuration(val); err == nil && d > 0 {
+			discoveryConfig.MetricsInterval = d
+		}
+	}
+
+	if val := os.Getenv("NEWSFED_RETENTION"); val != "" {
+		retention, err := newsfeed.ParseRetention(val)
+		if err != nil {
+			return nil, fmt.Errorf("NEWSFED_RETENTION: %w", err)
+		}
+		discoveryConfig.RetentionPeriod = retention
+	}
+
+	proxyURL, err := loadProxyURL(fileConfig)
+	if err != nil {
+		return nil, err
+	}
+	discoveryConfig.ProxyURL = proxyURL
+
+	if err := loadRetryPolicy(discoveryConfig, fileConfig); err != nil {
+		return nil, err
+	}
+	if err := loadRenderSettings(discoveryConfig, fileConfig); err != nil {
+		return nil, err
+	}
+	if err := loadMaxItemAge(discoveryConfig, fileConfig); err != nil {
+		return nil, err
+	}
+	if err := loadMaxResponseSize(discoveryConfig, fileConfig); err != nil {
+		return nil, err
+	}
+	if err := loadAdaptivePolling(discoveryConfig, fileConfig); err != nil {
+		return nil, err
+	}
+	if err := loadJournal(discoveryConfig, fileConfig); err != nil {
+		return nil, err
+	}
+	if err := loadHooks(discoveryConfig, fileConfig); err != nil {
+		return nil, err
+	}
+	if err := loadPublish(discoveryConfig, fileConfig); err != nil {
+		return nil, err
+	}
+
+	return discoveryConfig, nil
+}
@@ -0,0 +1,396 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/pevans/newsfed/config"
+	"github.com/pevans/newsfed/digest"
+	"github.com/pevans/newsfed/newsfeed"
+)
+
+// Defaults for digests built by the daemon when the config store leaves a
+// setting empty.
+const (
+	defaultDigestSince  = "24h"
+	defaultDigestFormat = digest.FormatHTML
+	defaultDigestGroup  = digest.GroupCategory
+	defaultDigestLimit  = 20
+)
+
+// digestOptions selects and lays out the items of a digest.
+type digestOptions struct {
+	since   time.Duration
+	format  string
+	groupBy string
+	limit   int
+	unread  bool
+	title   string
+}
+
+// buildDigest renders the highest scoring items discovered within
+// opts.since as a digest. It returns the rendered digest and the number of
+// items in it.
+func buildDigest(metadataPath string, newsFeed *newsfeed.NewsFeed, opts digestOptions) ([]byte, int, error) {
+	model, err := loadScoreModel(metadataPath)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	now := time.Now()
+	cutoff := now.Add(-opts.since)
+	result, err := newsFeed.Query(newsfeed.ListOptions{
+		Unread:          opts.unread,
+		DiscoveredSince: &cutoff,
+		SortBy:          newsfeed.SortScore,
+		Scoring:         model,
+		Limit:           opts.limit,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to rank news items: %w", err)
+	}
+	for _, readErr := range result.Errors {
+		log.Printf("WARN: %s", readErr.Error())
+	}
+
+	info := digest.Info{Title: opts.title, Since: cutoff, Until: now}
+	var buf bytes.Buffer
+	if err := digest.Render(&buf, opts.format, info, result.Items, opts.groupBy); err != nil {
+		return nil, 0, err
+	}
+	return buf.Bytes(), len(result.Items), nil
+}
+
+// digestSubject returns the subject line of an emailed digest.
+func digestSubject(title string) string {
+	if title == "" {
+		title = "newsfed digest"
+	}
+	return title + " for " + time.Now().Format("Jan 2, 2006")
+}
+
+// loadSMTPConfig returns the mail server settings from the smtp section of
+// the config file. NEWSFED_SMTP_PASSWORD, when set, overrides the password.
+func loadSMTPConfig() (digest.SMTPConfig, error) {
+	var smtpConfig digest.SMTPConfig
+
+	fileConfig, err := config.LoadConfigFile()
+	if err != nil {
+		return smtpConfig, err
+	}
+	if fileConfig != nil {
+		smtpConfig = digest.SMTPConfig{
+			Host:     fileConfig.SMTP.Host,
+			Port:     fileConfig.SMTP.Port,
+			Username: fileConfig.SMTP.Username,
+			Password: fileConfig.SMTP.Password,
+			From:     fileConfig.SMTP.From,
+		}
+	}
+	if val := os.Getenv("NEWSFED_SMTP_PASSWORD"); val != "" {
+		smtpConfig.Password = val
+	}
+	if smtpConfig.Host == "" {
+		return smtpConfig, fmt.Errorf("no SMTP server configured (set smtp.host in the config file)")
+	}
+	return smtpConfig, nil
+}
+
+func handleDigest(metadataPath, feedDSN string, args []string) {
+	if len(args) > 0 && args[0] == "schedule" {
+		handleDigestSchedule(metadataPath, args[1:])
+		return
+	}
+
+	fs := flag.NewFlagSet("digest", flag.ExitOnError)
+	since := fs.String("since", defaultDigestSince, "Include items discovered since duration (e.g., 24h, 7d)")
+	format := fs.String("format", digest.FormatMarkdown, "Digest format: markdown, html")
+	group := fs.String("group", defaultDigestGroup, "Group items by: category, publisher, none")
+	limit := fs.Int("limit", defaultDigestLimit, "Maximum number of items in the digest")
+	unread := fs.Bool("unread", false, "Include only unread items")
+	title := fs.String("title", "newsfed digest", "Digest title")
+	output := fs.String("o", "", "Write the digest to this file instead of stdout")
+	to := fs.String("to", "", "Email the digest to these comma-separated addresses instead of printing it")
+	_ = fs.Parse(args)
+
+	if err := digest.ValidateFormat(*format); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := digest.ValidateGroup(*group); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if *limit <= 0 {
+		fmt.Fprintf(os.Stderr, "Error: -limit must be positive\n")
+		os.Exit(1)
+	}
+	if *output != "" && *to != "" {
+		fmt.Fprintf(os.Stderr, "Error: -o and -to cannot be used together\n")
+		os.Exit(1)
+	}
+	duration, err := parseDuration(*since)
+	if err != nil || duration <= 0 {
+		fmt.Fprintf(os.Stderr, "Error: invalid duration format: %s\n", *since)
+		os.Exit(1)
+	}
+
+	newsFeed, err := openFeed(feedDSN)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to open news feed: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() { _ = newsFeed.Close() }()
+
+	body, count, err := buildDigest(metadataPath, newsFeed, digestOptions{
+		since:   duration,
+		format:  *format,
+		groupBy: *group,
+		limit:   *limit,
+		unread:  *unread,
+		title:   *title,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	switch {
+	case *to != "":
+		recipients := digest.ParseRecipients(*to)
+		smtpConfig, err := loadSMTPConfig()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := digest.Send(smtpConfig, recipients, digestSubject(*title), *format, body); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Sent digest of %d item(s) to %s\n", count, *to)
+	case *output != "":
+		if err := os.WriteFile(*output, body, 0o644); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to write %s: %v\n", *output, err)
+			os.Exit(1)
+		}
+	default:
+		_, _ = os.Stdout.Write(body)
+	}
+}
+
+// handleDigestSchedule shows or changes the schedule on which the daemon
+// emails digests. Settings not given on the command line are kept.
+func handleDigestSchedule(metadataPath string, args []string) {
+	fs := flag.NewFlagSet("digest schedule", flag.ExitOnError)
+	cron := fs.String("cron", "", "When to send, as a cron expression (e.g., \"0 7 * * *\") or @hourly, @daily, @weekly")
+	to := fs.String("to", "", "Comma-separated addresses to send the digest to")
+	since := fs.String("since", "", "Include items discovered since duration (default: 24h)")
+	format := fs.String("format", "", "Digest format: html, markdown (default: html)")
+	group := fs.String("group", "", "Group items by: category, publisher, none (default: category)")
+	clear := fs.Bool("clear", false, "Stop sending digests and forget the schedule")
+	_ = fs.Parse(args)
+
+	setFlags := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { setFlags[f.Name] = true })
+
+	if *clear && len(setFlags) > 1 {
+		fmt.Fprintf(os.Stderr, "Error: -clear cannot be used with other flags\n")
+		os.Exit(1)
+	}
+
+	configStore, err := config.NewConfigStore(metadataPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to open config store: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() { _ = configStore.Close() }()
+
+	if *clear {
+		if err := configStore.ClearDigest(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("Digest schedule cleared")
+		return
+	}
+
+	cfg, err := configStore.GetConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to read config: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(setFlags) == 0 {
+		printDigestSchedule(cfg)
+		return
+	}
+
+	if setFlags["cron"] {
+		if _, err := digest.ParseSchedule(*cron); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		cfg.DigestSchedule = *cron
+	}
+	if setFlags["to"] {
+		if len(digest.ParseRecipients(*to)) == 0 {
+			fmt.Fprintf(os.Stderr, "Error: -to needs at least one address\n")
+			os.Exit(1)
+		}
+		cfg.DigestTo = *to
+	}
+	if setFlags["since"] {
+		if d, err := parseDuration(*since); err != nil || d <= 0 {
+			fmt.Fprintf(os.Stderr, "Error: invalid duration format: %s\n", *since)
+			os.Exit(1)
+		}
+		cfg.DigestSince = *since
+	}
+	if setFlags["format"] {
+		if err := digest.ValidateFormat(*format); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		cfg.DigestFormat = *format
+	}
+	if setFlags["group"] {
+		if err := digest.ValidateGroup(*group); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		cfg.DigestGroup = *group
+	}
+
+	if err := configStore.UpdateConfig(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to save digest schedule: %v\n", err)
+		os.Exit(1)
+	}
+	printDigestSchedule(cfg)
+	if cfg.DigestSchedule == "" || cfg.DigestTo == "" {
+		fmt.Fprintf(os.Stderr, "Warning: digests are only sent once both -cron and -to are set\n")
+	}
+}
+
+// printDigestSchedule prints the digest settings of cfg.
+func printDigestSchedule(cfg *config.Config) {
+	if cfg.DigestSchedule == "" && cfg.DigestTo == "" {
+		fmt.Println("No digest schedule configured")
+		return
+	}
+
+	orDefault := func(value, def string) string {
+		if value == "" {
+			return def + " (default)"
+		}
+		return value
+	}
+	orNotSet := func(value string) string {
+		if value == "" {
+			return "(not set)"
+		}
+		return value
+	}
+	fmt.Printf("Schedule:  %s\n", orNotSet(cfg.DigestSchedule))
+	if schedule, err := digest.ParseSchedule(cfg.DigestSchedule); err == nil {
+		if next := schedule.Next(time.Now()); !next.IsZero() {
+			fmt.Printf("Next run:  %s\n", next.Format("2006-01-02 15:04 MST"))
+		}
+	}
+	fmt.Printf("To:        %s\n", orNotSet(cfg.DigestTo))
+	fmt.Printf("Since:     %s\n", orDefault(cfg.DigestSince, defaultDigestSince))
+	fmt.Printf("Format:    %s\n", orDefault(cfg.DigestFormat, defaultDigestFormat))
+	fmt.Printf("Group by:  %s\n", orDefault(cfg.DigestGroup, defaultDigestGroup))
+}
+
+// runDigestScheduler emails a digest whenever the schedule in the config
+// store comes due, until ctx is done. The settings are read again every
+// minute, so `newsfed digest schedule` takes effect without a restart.
+func runDigestScheduler(ctx context.Context, metadataPath string, newsFeed *newsfeed.NewsFeed) {
+	var lastRun time.Time
+	for {
+		// Wake at the start of each minute
+		now := time.Now()
+		wait := now.Truncate(time.Minute).Add(time.Minute).Sub(now)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+
+		minute := time.Now().Truncate(time.Minute)
+		if !minute.After(lastRun) {
+			continue
+		}
+		sent, err := sendScheduledDigest(metadataPath, newsFeed, minute)
+		if err != nil {
+			log.Printf("ERROR: Digest: %v", err)
+		}
+		if sent || err != nil {
+			lastRun = minute
+		}
+	}
+}
+
+// sendScheduledDigest builds and sends the digest if the configured
+// schedule selects at. It reports whether a digest was due.
+func sendScheduledDigest(metadataPath string, newsFeed *newsfeed.NewsFeed, at time.Time) (bool, error) {
+	configStore, err := config.NewConfigStore(metadataPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to open config store: %w", err)
+	}
+	cfg, err := configStore.GetConfig()
+	_ = configStore.Close()
+	if err != nil {
+		return false, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	if cfg.DigestSchedule == "" || cfg.DigestTo == "" {
+		return false, nil
+	}
+	schedule, err := digest.ParseSchedule(cfg.DigestSchedule)
+	if err != nil {
+		return false, err
+	}
+	if !schedule.Matches(at) {
+		return false, nil
+	}
+
+	opts := digestOptions{
+		format:  cfg.DigestFormat,
+		groupBy: cfg.DigestGroup,
+		limit:   defaultDigestLimit,
+		title:   "newsfed digest",
+	}
+	if opts.format == "" {
+		opts.format = defaultDigestFormat
+	}
+	if opts.groupBy == "" {
+		opts.groupBy = defaultDigestGroup
+	}
+	sinceValue := cfg.DigestSince
+	if sinceValue == "" {
+		sinceValue = defaultDigestSince
+	}
+	if opts.since, err = parseDuration(sinceValue); err != nil || opts.since <= 0 {
+		return true, fmt.Errorf("invalid digest_since: %q", sinceValue)
+	}
+
+	smtpConfig, err := loadSMTPConfig()
+	if err != nil {
+		return true, err
+	}
+	body, count, err := buildDigest(metadataPath, newsFeed, opts)
+	if err != nil {
+		return true, err
+	}
+	if err := digest.Send(smtpConfig, digest.ParseRecipients(cfg.DigestTo), digestSubject(opts.title), opts.format, body); err != nil {
+		return true, err
+	}
+	log.Printf("INFO: Sent digest of %d item(s) to %s", count, cfg.DigestTo)
+	return true, nil
+}
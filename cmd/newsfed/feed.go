@@ -1,43 +1,134 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"maps"
 	"os"
 	"os/exec"
 	"runtime"
-	"sort"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/pevans/newsfed/config"
 	"github.com/pevans/newsfed/newsfeed"
+	"github.com/pevans/newsfed/sources"
 )
 
-func handleList(feedDir string, args []string) {
+func handleList(metadataPath, feedDSN string, args []string) {
+	defaults := loadListDefaults(metadataPath)
+
 	// Parse flags for list command
 	fs := flag.NewFlagSet("list", flag.ExitOnError)
 	all := fs.Bool("all", false, "Show all items regardless of age")
 	pinned := fs.Bool("pinned", false, "Show only pinned items")
 	unpinned := fs.Bool("unpinned", false, "Show only unpinned items")
+	unread := fs.Bool("unread", false, "Show only unread items")
+	starred := fs.Bool("starred", false, "Show only starred items")
+	archived := fs.Bool("archived", false, "Show only archived items")
+	snoozed := fs.Bool("snoozed", false, "Show only snoozed items")
 	publisher := fs.String("publisher", "", "Filter by publisher")
+	category := fs.String("category", "", "Filter by source category")
+	lang := fs.String("lang", "", "Filter by language as an ISO 639-1 code (e.g., en)")
+	source := fs.String("source", "", "Filter by the ID of the source that discovered the item")
+	metadata := metadataFlag{}
+	fs.Var(metadata, "metadata", "Filter by item metadata as \"key=value\" (repeatable)")
 	since := fs.String("since", "", "Show items discovered since duration (e.g., 24h, 7d)")
-	sortBy := fs.String("sort", "published", "Sort by: published, discovered, pinned")
-	limit := fs.Int("limit", 20, "Maximum number of items to display")
+	sortBy := fs.String("sort", defaults.sort, "Sort by: published, discovered, pinned, score")
+	limit := fs.Int("limit", defaults.limit, "Maximum number of items to display")
 	offset := fs.Int("offset", 0, "Number of items to skip")
-	format := fs.String("format", "table", "Output format: table, json, compact")
+	cursor := fs.String("cursor", "", "Continue from the cursor printed with a previous page")
+	format := fs.String("format", defaults.format, "Output format: table, json, compact")
+	columnSpec := fs.String("columns", "", "Comma-separated fields for the table format: "+strings.Join(listColumns, ", "))
+	color := colorFlag(fs)
 	_ = fs.Parse(args)
 
+	colors := mustPalette(*color)
+	columns, err := parseColumns(*columnSpec, listColumns)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
 	// Initialize news feed
-	newsFeed, err := newsfeed.NewNewsFeed(feedDir)
+	newsFeed, err := openFeed(feedDSN)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: failed to open news feed: %v\n", err)
 		os.Exit(1)
 	}
+	defer func() { _ = newsFeed.Close() }()
+
+	if *pinned && *unpinned {
+		fmt.Fprintf(os.Stderr, "Error: -pinned and -unpinned cannot be used together\n")
+		os.Exit(1)
+	}
+
+	// Build query options from flags
+	opts := newsfeed.ListOptions{
+		Unread:    *unread,
+		Starred:   *starred,
+		Archived:  *archived,
+		Snoozed:   *snoozed,
+		Publisher: *publisher,
+		Category:  *category,
+		Language:  *lang,
+		Metadata:  metadata,
+		SortBy:    *sortBy,
+		Limit:     *limit,
+		Offset:    *offset,
+		Cursor:    *cursor,
+	}
+
+	if *source != "" {
+		sourceID := lookupSourceID(metadataPath, *source)
+		opts.SourceID = &sourceID
+	}
+
+	if *pinned {
+		opts.Pinned = pinned
+	}
+	if *unpinned {
+		isPinned := false
+		opts.Pinned = &isPinned
+	}
+
+	// Filter by discovered time (explicit --since overrides default)
+	if *since != "" {
+		duration, err := parseDuration(*since)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid duration format: %v\n", err)
+			os.Exit(1)
+		}
+		cutoff := time.Now().Add(-duration)
+		opts.DiscoveredSince = &cutoff
+	}
+
+	// Default filter: show items from the list window (3 days unless
+	// list_window is set) OR pinned items, unless --all or another filter
+	// is set
+	if !*all && *since == "" && !*pinned && !*unpinned && !*unread && !*starred && !*archived && !*snoozed && defaults.window > 0 {
+		cutoff := time.Now().Add(-defaults.window)
+		opts.DiscoveredSince = &cutoff
+		opts.IncludePinned = true
+	}
+
+	if err := opts.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if opts.SortBy == newsfeed.SortScore {
+		opts.Scoring, err = loadScoreModel(metadataPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
 
-	// Get all items
-	result, err := newsFeed.List()
+	result, err := newsFeed.Query(opts)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: failed to list news items: %v\n", err)
 		os.Exit(1)
@@ -53,106 +144,163 @@ func handleList(feedDir string, args []string) {
 		}
 	}()
 
-	// Apply filters
-	var filtered []newsfeed.NewsItem
-	for _, item := range result.Items {
-		// Default filter: show items from past 3 days OR pinned items (unless
-		// --all is set)
-		if !*all && *since == "" && !*pinned && !*unpinned {
-			threeDaysAgo := time.Now().Add(-3 * 24 * time.Hour)
-			isRecent := item.DiscoveredAt.After(threeDaysAgo)
-			isPinned := item.PinnedAt != nil
-			if !isRecent && !isPinned {
-				continue
-			}
-		}
+	total := result.Total
+	paged := result.Items
+	if len(paged) == 0 {
+		fmt.Println("No items to display.")
+		return
+	}
 
-		// Filter by pinned status
-		if *pinned && item.PinnedAt == nil {
-			continue
-		}
-		if *unpinned && item.PinnedAt != nil {
-			continue
+	// Display results based on format
+	switch *format {
+	case "json":
+		printListJSON(paged, total, result.NextCursor)
+	case "compact":
+		printListCompact(paged)
+	case "table":
+		printListTable(paged, total, *offset, *cursor != "", columns, colors)
+		if result.NextCursor != "" {
+			fmt.Printf("Next page: repeat with -cursor=%s\n", result.NextCursor)
 		}
+	default:
+		fmt.Fprintf(os.Stderr, "Error: invalid format: %s (must be table, json, or compact)\n", *format)
+		os.Exit(1)
+	}
+}
 
-		// Filter by publisher
-		if *publisher != "" {
-			if item.Publisher == nil || !strings.Contains(strings.ToLower(*item.Publisher), strings.ToLower(*publisher)) {
-				continue
-			}
-		}
+// handleTop lists the highest scoring items, ranked by the scoring model in
+// the config file.
+func handleTop(metadataPath, feedDSN string, args []string) {
+	fs := flag.NewFlagSet("top", flag.ExitOnError)
+	unread := fs.Bool("unread", false, "Rank only unread items")
+	category := fs.String("category", "", "Rank only items from this source category")
+	since := fs.String("since", "", "Rank only items discovered since duration (e.g., 24h, 7d)")
+	limit := fs.Int("limit", 10, "Number of items to display")
+	format := fs.String("format", "table", "Output format: table, json, compact")
+	_ = fs.Parse(args)
 
-		// Filter by discovered time (explicit --since overrides default)
-		if *since != "" {
-			duration, err := parseDuration(*since)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error: invalid duration format: %v\n", err)
-				os.Exit(1)
-			}
-			cutoff := time.Now().Add(-duration)
-			if item.DiscoveredAt.Before(cutoff) {
-				continue
-			}
-		}
+	if *limit <= 0 {
+		fmt.Fprintf(os.Stderr, "Error: -limit must be positive\n")
+		os.Exit(1)
+	}
 
-		filtered = append(filtered, item)
-	}
-
-	// Sort items
-	switch *sortBy {
-	case "published":
-		sort.Slice(filtered, func(i, j int) bool {
-			return filtered[i].PublishedAt.After(filtered[j].PublishedAt)
-		})
-	case "discovered":
-		sort.Slice(filtered, func(i, j int) bool {
-			return filtered[i].DiscoveredAt.After(filtered[j].DiscoveredAt)
-		})
-	case "pinned":
-		sort.Slice(filtered, func(i, j int) bool {
-			// Items with PinnedAt come first, sorted by pinned time (most
-			// recent first)
-			if filtered[i].PinnedAt == nil && filtered[j].PinnedAt == nil {
-				return false
-			}
-			if filtered[i].PinnedAt == nil {
-				return false
-			}
-			if filtered[j].PinnedAt == nil {
-				return true
-			}
-			return filtered[i].PinnedAt.After(*filtered[j].PinnedAt)
-		})
-	default:
-		fmt.Fprintf(os.Stderr, "Error: invalid sort option: %s (must be published, discovered, or pinned)\n", *sortBy)
+	model, err := loadScoreModel(metadataPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	// Apply pagination
-	total := len(filtered)
-	if *offset >= total {
-		fmt.Println("No items to display.")
-		return
+	opts := newsfeed.ListOptions{
+		Unread:   *unread,
+		Category: *category,
+		SortBy:   newsfeed.SortScore,
+		Scoring:  model,
+		Limit:    *limit,
+	}
+	if *since != "" {
+		duration, err := parseDuration(*since)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid duration format: %v\n", err)
+			os.Exit(1)
+		}
+		cutoff := time.Now().Add(-duration)
+		opts.DiscoveredSince = &cutoff
 	}
 
-	end := min(*offset+*limit, total)
-	paged := filtered[*offset:end]
+	newsFeed, err := openFeed(feedDSN)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to open news feed: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() { _ = newsFeed.Close() }()
+
+	result, err := newsFeed.Query(opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to rank news items: %v\n", err)
+		os.Exit(1)
+	}
 
-	// Display results based on format
 	switch *format {
 	case "json":
-		printListJSON(paged, total)
+		printListJSON(result.Items, result.Total, "")
 	case "compact":
-		printListCompact(paged)
+		printListCompact(result.Items)
 	case "table":
-		printListTable(paged, total, *offset)
+		printTopTable(result.Items, result.Scores)
 	default:
 		fmt.Fprintf(os.Stderr, "Error: invalid format: %s (must be table, json, or compact)\n", *format)
 		os.Exit(1)
 	}
+
+	if len(result.Errors) > 0 {
+		fmt.Fprintf(os.Stderr, "\nWarning: %d item(s) could not be read:\n", len(result.Errors))
+		for _, readErr := range result.Errors {
+			fmt.Fprintf(os.Stderr, "  %s\n", readErr.Error())
+		}
+	}
+}
+
+// loadScoreModel builds the scoring model from the scoring section of the
+// config file. Source weights may name a source by name or ID; names are
+// resolved against the metadata store.
+func loadScoreModel(metadataPath string) (*newsfeed.ScoreModel, error) {
+	model := &newsfeed.ScoreModel{}
+
+	fileConfig, err := config.LoadConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	if fileConfig == nil {
+		return model, nil
+	}
+	scoring := fileConfig.Scoring
+
+	if scoring.HalfLife != "" {
+		halfLife, err := parseDuration(scoring.HalfLife)
+		if err != nil || halfLife <= 0 {
+			return nil, fmt.Errorf("invalid scoring.half_life: %q", scoring.HalfLife)
+		}
+		model.HalfLife = halfLife
+	}
+	model.KeywordBoosts = scoring.KeywordBoosts
+	model.PinBoost = scoring.PinBoost
+
+	if len(scoring.SourceWeights) > 0 {
+		sourceStore, err := sources.NewSourceStore(metadataPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open source store: %w", err)
+		}
+		defer func() { _ = sourceStore.Close() }()
+
+		sourceList, err := sourceStore.ListSources(sources.SourceFilter{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list sources: %w", err)
+		}
+
+		model.SourceWeights = make(map[uuid.UUID]float64)
+		for key, weight := range scoring.SourceWeights {
+			found := false
+			for _, source := range sourceList {
+				if source.Name == key || source.SourceID.String() == key {
+					model.SourceWeights[source.SourceID] = weight
+					found = true
+				}
+			}
+			if !found {
+				fmt.Fprintf(os.Stderr, "Warning: scoring.source_weights names an unknown source: %s\n", key)
+			}
+		}
+	}
+
+	return model, nil
 }
 
-func handleShow(feedDir string, args []string) {
+func handleShow(feedDSN string, args []string) {
+	fs := flag.NewFlagSet("show", flag.ExitOnError)
+	color := colorFlag(fs)
+	args = parseInterspersed(fs, args)
+	colors := mustPalette(*color)
+
 	if len(args) < 1 {
 		fmt.Fprintf(os.Stderr, "Error: item ID is required\n")
 		fmt.Fprintf(os.Stderr, "Usage: newsfed show <item-id>\n")
@@ -161,19 +309,15 @@ func handleShow(feedDir string, args []string) {
 
 	itemID := args[0]
 
-	// Parse UUID
-	id, err := uuid.Parse(itemID)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: invalid item ID: %v\n", err)
-		os.Exit(1)
-	}
-
 	// Initialize news feed
-	newsFeed, err := newsfeed.NewNewsFeed(feedDir)
+	newsFeed, err := openFeed(feedDSN)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: failed to open news feed: %v\n", err)
 		os.Exit(1)
 	}
+	defer func() { _ = newsFeed.Close() }()
+
+	id := resolveItemID(newsFeed, itemID)
 
 	// Get the item
 	item, err := newsFeed.Get(id)
@@ -189,7 +333,7 @@ func handleShow(feedDir string, args []string) {
 
 	// Display the item
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-	fmt.Println(item.Title)
+	fmt.Println(colors.bold(item.Title))
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 	fmt.Println()
 
@@ -205,23 +349,64 @@ func handleShow(feedDir string, args []string) {
 		fmt.Printf("Authors:     %s\n", strings.Join(item.Authors, ", "))
 	}
 
+	// Category
+	if item.Category != nil {
+		fmt.Printf("Category:    %s\n", *item.Category)
+	}
+
+	// Language
+	if item.Language != "" {
+		fmt.Printf("Language:    %s\n", item.Language)
+	}
+
 	fmt.Println()
 
 	// Dates
 	fmt.Printf("Published:   %s\n", item.PublishedAt.Format("2006-01-02 15:04:05"))
 	fmt.Printf("Discovered:  %s\n", item.DiscoveredAt.Format("2006-01-02 15:04:05"))
+	if item.UpdatedAt != nil {
+		fmt.Printf("Updated:     %s\n", item.UpdatedAt.Format("2006-01-02 15:04:05"))
+	}
 
 	// Pinned status
 	if item.PinnedAt != nil {
-		fmt.Printf("Pinned:      📌 %s\n", item.PinnedAt.Format("2006-01-02 15:04:05"))
+		fmt.Printf("Pinned:      %s\n", colors.highlight("📌 "+item.PinnedAt.Format("2006-01-02 15:04:05")))
+		if item.PinExpiresAt != nil {
+			fmt.Printf("Pin Expires: %s\n", item.PinExpiresAt.Format("2006-01-02 15:04:05"))
+		}
 	} else {
 		fmt.Println("Pinned:      No")
 	}
 
+	// Read status
+	if item.ReadAt != nil {
+		fmt.Printf("Read:        %s\n", item.ReadAt.Format("2006-01-02 15:04:05"))
+	} else {
+		fmt.Println("Read:        No")
+	}
+	if item.OpenedAt != nil {
+		fmt.Printf("Opened:      %s\n", item.OpenedAt.Format("2006-01-02 15:04:05"))
+	}
+
+	// Starred status
+	if item.StarredAt != nil {
+		fmt.Printf("Starred:     %s\n", colors.yellow("⭐ "+item.StarredAt.Format("2006-01-02 15:04:05")))
+	}
+
+	// Archived status
+	if item.ArchivedAt != nil {
+		fmt.Printf("Archived:    %s\n", item.ArchivedAt.Format("2006-01-02 15:04:05"))
+	}
+
+	// Snoozed status
+	if item.IsSnoozed(time.Now()) {
+		fmt.Printf("Snoozed:     until %s\n", item.SnoozedUntil.Format("2006-01-02 15:04:05"))
+	}
+
 	fmt.Println()
 
 	// URL
-	fmt.Printf("URL:         %s\n", item.URL)
+	fmt.Printf("URL:         %s\n", colors.cyan(item.URL))
 	fmt.Println()
 
 	// Summary
@@ -229,34 +414,78 @@ func handleShow(feedDir string, args []string) {
 		fmt.Println("Summary:")
 		fmt.Println(wrapText(item.Summary, 80))
 		fmt.Println()
+	} else if item.GeneratedSummary != "" {
+		fmt.Println("Summary (generated):")
+		fmt.Println(wrapText(item.GeneratedSummary, 80))
+		fmt.Println()
+	}
+
+	// Notes
+	if item.Notes != "" {
+		fmt.Println("Notes:")
+		fmt.Println(wrapText(item.Notes, 80))
+		fmt.Println()
+	}
+
+	// Publisher changes, most recent first
+	if len(item.Changes) > 0 {
+		fmt.Println("Changes:")
+		for _, change := range slices.Backward(item.Changes) {
+			for _, field := range slices.Sorted(maps.Keys(change.Previous)) {
+				previous := truncate(change.Previous[field], 60)
+				fmt.Printf("  %s  %s was: %s\n", change.ChangedAt.Format("2006-01-02 15:04"), field, previous)
+			}
+		}
+		fmt.Println()
+	}
+
+	// Metadata
+	if len(item.Metadata) > 0 {
+		fmt.Println("Metadata:")
+		for _, key := range slices.Sorted(maps.Keys(item.Metadata)) {
+			value, _ := item.MetadataValue(key)
+			fmt.Printf("  %-16s %s\n", key+":", value)
+		}
+		fmt.Println()
 	}
 
 	// ID
 	fmt.Printf("ID:          %s\n", item.ID.String())
 }
 
-func handlePin(feedDir string, args []string) {
-	if len(args) < 1 {
+func handlePin(feedDSN string, args []string) {
+	fs := flag.NewFlagSet("pin", flag.ExitOnError)
+	expires := fs.String("expires", "", "Unpin the item after this duration (e.g., 7d)")
+	positional := parseInterspersed(fs, args)
+
+	if len(positional) != 1 {
 		fmt.Fprintf(os.Stderr, "Error: item ID is required\n")
-		fmt.Fprintf(os.Stderr, "Usage: newsfed pin <item-id>\n")
+		fmt.Fprintf(os.Stderr, "Usage: newsfed pin <item-id> [-expires <duration>]\n")
 		os.Exit(1)
 	}
 
-	itemID := args[0]
-
-	// Parse UUID
-	id, err := uuid.Parse(itemID)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: invalid item ID: %v\n", err)
-		os.Exit(1)
+	var expiresAt *time.Time
+	if *expires != "" {
+		duration, err := parseDuration(*expires)
+		if err != nil || duration <= 0 {
+			fmt.Fprintf(os.Stderr, "Error: invalid expiry: %s\n", *expires)
+			os.Exit(1)
+		}
+		t := time.Now().UTC().Add(duration)
+		expiresAt = &t
 	}
 
+	itemID := positional[0]
+
 	// Initialize news feed
-	newsFeed, err := newsfeed.NewNewsFeed(feedDir)
+	newsFeed, err := openFeed(feedDSN)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: failed to open news feed: %v\n", err)
 		os.Exit(1)
 	}
+	defer func() { _ = newsFeed.Close() }()
+
+	id := resolveItemID(newsFeed, itemID)
 
 	// Get the item
 	item, err := newsFeed.Get(id)
@@ -270,15 +499,18 @@ func handlePin(feedDir string, args []string) {
 		os.Exit(1)
 	}
 
-	// Check if already pinned
-	if item.PinnedAt != nil {
+	// Check if already pinned; an expiry can still be set on an existing pin
+	if item.PinnedAt != nil && expiresAt == nil {
 		fmt.Printf("Item is already pinned (pinned at: %s)\n", item.PinnedAt.Format("2006-01-02 15:04:05"))
 		return
 	}
 
 	// Pin the item
-	now := time.Now().UTC()
-	item.PinnedAt = &now
+	if item.PinnedAt == nil {
+		now := time.Now().UTC()
+		item.PinnedAt = &now
+	}
+	item.PinExpiresAt = expiresAt
 
 	err = newsFeed.Update(*item)
 	if err != nil {
@@ -287,9 +519,12 @@ func handlePin(feedDir string, args []string) {
 	}
 
 	fmt.Printf("✓ Pinned item: %s\n", item.Title)
+	if expiresAt != nil {
+		fmt.Printf("  Expires: %s\n", expiresAt.Format("2006-01-02 15:04:05"))
+	}
 }
 
-func handleUnpin(feedDir string, args []string) {
+func handleUnpin(feedDSN string, args []string) {
 	if len(args) < 1 {
 		fmt.Fprintf(os.Stderr, "Error: item ID is required\n")
 		fmt.Fprintf(os.Stderr, "Usage: newsfed unpin <item-id>\n")
@@ -298,19 +533,15 @@ func handleUnpin(feedDir string, args []string) {
 
 	itemID := args[0]
 
-	// Parse UUID
-	id, err := uuid.Parse(itemID)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: invalid item ID: %v\n", err)
-		os.Exit(1)
-	}
-
 	// Initialize news feed
-	newsFeed, err := newsfeed.NewNewsFeed(feedDir)
+	newsFeed, err := openFeed(feedDSN)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: failed to open news feed: %v\n", err)
 		os.Exit(1)
 	}
+	defer func() { _ = newsFeed.Close() }()
+
+	id := resolveItemID(newsFeed, itemID)
 
 	// Get the item
 	item, err := newsFeed.Get(id)
@@ -332,6 +563,7 @@ func handleUnpin(feedDir string, args []string) {
 
 	// Unpin the item
 	item.PinnedAt = nil
+	item.PinExpiresAt = nil
 
 	err = newsFeed.Update(*item)
 	if err != nil {
@@ -342,124 +574,618 @@ func handleUnpin(feedDir string, args []string) {
 	fmt.Printf("✓ Unpinned item: %s\n", item.Title)
 }
 
-func handleOpen(metadataPath, feedDir string, args []string) {
-	// Parse flags for open command
-	fs := flag.NewFlagSet("open", flag.ExitOnError)
-	echo := fs.Bool("echo", false, "Echo the command instead of executing it")
-	_ = fs.Parse(args)
-
-	// Get item ID from remaining args
-	if len(fs.Args()) < 1 {
+func handleRead(feedDSN string, args []string) {
+	if len(args) < 1 {
 		fmt.Fprintf(os.Stderr, "Error: item ID is required\n")
-		fmt.Fprintf(os.Stderr, "Usage: newsfed open [-echo] <item-id>\n")
+		fmt.Fprintf(os.Stderr, "Usage: newsfed read <item-id>\n")
 		os.Exit(1)
 	}
 
-	itemID := fs.Args()[0]
+	itemID := args[0]
 
-	// Parse UUID
-	id, err := uuid.Parse(itemID)
+	// Initialize news feed
+	newsFeed, err := openFeed(feedDSN)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: invalid item ID: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error: failed to open news feed: %v\n", err)
 		os.Exit(1)
 	}
+	defer func() { _ = newsFeed.Close() }()
 
-	// Initialize news feed
-	newsFeed, err := newsfeed.NewNewsFeed(feedDir)
+	id := resolveItemID(newsFeed, itemID)
+
+	item, err := newsFeed.MarkRead(id)
+	if errors.Is(err, newsfeed.ErrItemNotFound) {
+		fmt.Fprintf(os.Stderr, "Error: news item not found: %s\n", itemID)
+		os.Exit(1)
+	}
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: failed to open news feed: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error: failed to mark item as read: %v\n", err)
 		os.Exit(1)
 	}
 
-	// Get the item
-	item, err := newsFeed.Get(id)
+	fmt.Printf("✓ Marked as read: %s\n", item.Title)
+}
+
+func handleUnread(feedDSN string, args []string) {
+	if len(args) < 1 {
+		fmt.Fprintf(os.Stderr, "Error: item ID is required\n")
+		fmt.Fprintf(os.Stderr, "Usage: newsfed unread <item-id>\n")
+		os.Exit(1)
+	}
+
+	itemID := args[0]
+
+	// Initialize news feed
+	newsFeed, err := openFeed(feedDSN)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: failed to get news item: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error: failed to open news feed: %v\n", err)
 		os.Exit(1)
 	}
+	defer func() { _ = newsFeed.Close() }()
 
-	if item == nil {
+	id := resolveItemID(newsFeed, itemID)
+
+	item, err := newsFeed.MarkUnread(id)
+	if errors.Is(err, newsfeed.ErrItemNotFound) {
 		fmt.Fprintf(os.Stderr, "Error: news item not found: %s\n", itemID)
 		os.Exit(1)
 	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to mark item as unread: %v\n", err)
+		os.Exit(1)
+	}
 
-	// Load config to check for custom browser command
-	var browserCmd string
-	var cmdArgs []string
+	fmt.Printf("✓ Marked as unread: %s\n", item.Title)
+}
 
-	configStore, err := config.NewConfigStore(metadataPath)
-	if err == nil {
-		defer func() { _ = configStore.Close() }()
-		cfg, err := configStore.GetConfig()
-		if err == nil && cfg.BrowserCommand != "" {
-			// Use configured browser command
-			browserCmd = cfg.BrowserCommand
-			cmdArgs = []string{item.URL}
-		}
+func handleStar(feedDSN string, args []string) {
+	if len(args) < 1 {
+		fmt.Fprintf(os.Stderr, "Error: item ID is required\n")
+		fmt.Fprintf(os.Stderr, "Usage: newsfed star <item-id>\n")
+		os.Exit(1)
 	}
 
-	// If no configured browser command, determine based on platform
-	if browserCmd == "" {
-		switch runtime.GOOS {
-		case "darwin":
-			browserCmd = "open"
-			cmdArgs = []string{item.URL}
-		case "linux":
-			browserCmd = "xdg-open"
-			cmdArgs = []string{item.URL}
-		case "windows":
-			browserCmd = "cmd"
-			cmdArgs = []string{"/c", "start", item.URL}
-		default:
-			fmt.Fprintf(os.Stderr, "Error: unsupported platform: %s\n", runtime.GOOS)
-			os.Exit(1)
-		}
-	}
+	itemID := args[0]
 
-	// If echo mode, print the command instead of executing it
-	if *echo {
-		fmt.Printf("%s %s\n", browserCmd, strings.Join(cmdArgs, " "))
-		return
+	// Initialize news feed
+	newsFeed, err := openFeed(feedDSN)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to open news feed: %v\n", err)
+		os.Exit(1)
 	}
+	defer func() { _ = newsFeed.Close() }()
 
-	// Build and execute the command
-	cmd := exec.Command(browserCmd, cmdArgs...)
+	id := resolveItemID(newsFeed, itemID)
 
-	err = cmd.Start()
+	item, err := newsFeed.Star(id)
+	if errors.Is(err, newsfeed.ErrItemNotFound) {
+		fmt.Fprintf(os.Stderr, "Error: news item not found: %s\n", itemID)
+		os.Exit(1)
+	}
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: failed to open URL: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error: failed to star item: %v\n", err)
 		os.Exit(1)
 	}
 
-	fmt.Printf("✓ Opening in browser: %s\n", item.Title)
+	fmt.Printf("✓ Starred item: %s\n", item.Title)
 }
 
-func handlePrune(feedDir string, args []string) {
-	fs := flag.NewFlagSet("prune", flag.ExitOnError)
-	all := fs.Bool("all", false, "Remove all items, not just those older than 90 days")
-	force := fs.Bool("force", false, "Skip confirmation prompt")
-	_ = fs.Parse(args)
+func handleUnstar(feedDSN string, args []string) {
+	if len(args) < 1 {
+		fmt.Fprintf(os.Stderr, "Error: item ID is required\n")
+		fmt.Fprintf(os.Stderr, "Usage: newsfed unstar <item-id>\n")
+		os.Exit(1)
+	}
+
+	itemID := args[0]
 
 	// Initialize news feed
-	newsFeed, err := newsfeed.NewNewsFeed(feedDir)
+	newsFeed, err := openFeed(feedDSN)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: failed to open news feed: %v\n", err)
 		os.Exit(1)
 	}
+	defer func() { _ = newsFeed.Close() }()
 
-	// Get all items
-	result, err := newsFeed.List()
+	id := resolveItemID(newsFeed, itemID)
+
+	item, err := newsFeed.Unstar(id)
+	if errors.Is(err, newsfeed.ErrItemNotFound) {
+		fmt.Fprintf(os.Stderr, "Error: news item not found: %s\n", itemID)
+		os.Exit(1)
+	}
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: failed to list news items: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error: failed to unstar item: %v\n", err)
 		os.Exit(1)
 	}
 
-	// Ask for confirmation unless -force
-	if !*force {
-		if *all {
-			fmt.Print("All news items will be removed, but pinned items will remain. Are you certain you want to do this? [y/N]: ")
+	fmt.Printf("✓ Unstarred item: %s\n", item.Title)
+}
+
+func handleNote(feedDSN string, args []string) {
+	fs := flag.NewFlagSet("note", flag.ExitOnError)
+	clearNotes := fs.Bool("clear", false, "Remove the item's notes")
+	positional := parseInterspersed(fs, args)
+
+	if len(positional) < 1 {
+		fmt.Fprintf(os.Stderr, "Error: item ID is required\n")
+		fmt.Fprintf(os.Stderr, "Usage: newsfed note <item-id> [text | -clear]\n")
+		os.Exit(1)
+	}
+
+	itemID := positional[0]
+	text := strings.TrimSpace(strings.Join(positional[1:], " "))
+	if *clearNotes && text != "" {
+		fmt.Fprintf(os.Stderr, "Error: -clear cannot be combined with note text\n")
+		os.Exit(1)
+	}
+
+	// Initialize news feed
+	newsFeed, err := openFeed(feedDSN)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to open news feed: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() { _ = newsFeed.Close() }()
+
+	id := resolveItemID(newsFeed, itemID)
+
+	// Without text, show the current notes
+	if text == "" && !*clearNotes {
+		item, err := newsFeed.Get(id)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to get news item: %v\n", err)
+			os.Exit(1)
+		}
+		if item == nil {
+			fmt.Fprintf(os.Stderr, "Error: news item not found: %s\n", itemID)
+			os.Exit(1)
+		}
+		if item.Notes == "" {
+			fmt.Println("No notes.")
+			return
+		}
+		fmt.Println(item.Notes)
+		return
+	}
+
+	item, err := newsFeed.SetNotes(id, text)
+	if errors.Is(err, newsfeed.ErrItemNotFound) {
+		fmt.Fprintf(os.Stderr, "Error: news item not found: %s\n", itemID)
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to save notes: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *clearNotes {
+		fmt.Printf("✓ Cleared notes: %s\n", item.Title)
+		return
+	}
+	fmt.Printf("✓ Saved notes: %s\n", item.Title)
+}
+
+func handleAdd(feedDSN string, args []string) {
+	fs := flag.NewFlagSet("add", flag.ExitOnError)
+	title := fs.String("title", "", "Item title (defaults to the URL)")
+	summary := fs.String("summary", "", "Item summary")
+	publisher := fs.String("publisher", "", "Item publisher")
+	author := fs.String("author", "", "Item author")
+	pin := fs.Bool("pin", false, "Pin the item to read later")
+	positional := parseInterspersed(fs, args)
+
+	if len(positional) != 1 {
+		fmt.Fprintf(os.Stderr, "Error: exactly one URL is required\n")
+		fmt.Fprintf(os.Stderr, "Usage: newsfed add <url> [-title <title>] [-summary <text>] [-pin]\n")
+		os.Exit(1)
+	}
+
+	// Initialize news feed
+	newsFeed, err := openFeed(feedDSN)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to open news feed: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() { _ = newsFeed.Close() }()
+
+	item := newsfeed.NewsItem{
+		URL:     positional[0],
+		Title:   *title,
+		Summary: strings.TrimSpace(*summary),
+	}
+	if *publisher != "" {
+		item.Publisher = publisher
+	}
+	if *author != "" {
+		item.Authors = []string{*author}
+	}
+	if *pin {
+		now := time.Now()
+		item.PinnedAt = &now
+	}
+
+	created, err := newsFeed.Create(item)
+	if errors.Is(err, newsfeed.ErrDuplicateURL) {
+		fmt.Fprintf(os.Stderr, "Error: %s is already in the feed\n", item.URL)
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to add news item: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✓ Added: %s\n", created.Title)
+	fmt.Printf("  ID: %s\n", created.ID.String())
+}
+
+func handleEdit(feedDSN string, args []string) {
+	fs := flag.NewFlagSet("edit", flag.ExitOnError)
+	title := fs.String("title", "", "New title")
+	summary := fs.String("summary", "", "New summary")
+	clearSummary := fs.Bool("clear-summary", false, "Remove the item's summary")
+	positional := parseInterspersed(fs, args)
+
+	if len(positional) != 1 {
+		fmt.Fprintf(os.Stderr, "Error: item ID is required\n")
+		fmt.Fprintf(os.Stderr, "Usage: newsfed edit <item-id> [-title <title>] [-summary <text> | -clear-summary]\n")
+		os.Exit(1)
+	}
+	if *summary != "" && *clearSummary {
+		fmt.Fprintf(os.Stderr, "Error: -summary and -clear-summary cannot be used together\n")
+		os.Exit(1)
+	}
+	if *title == "" && *summary == "" && !*clearSummary {
+		fmt.Fprintf(os.Stderr, "Error: at least one of -title, -summary, or -clear-summary is required\n")
+		os.Exit(1)
+	}
+
+	itemID := positional[0]
+
+	// Initialize news feed
+	newsFeed, err := openFeed(feedDSN)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to open news feed: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() { _ = newsFeed.Close() }()
+
+	id := resolveItemID(newsFeed, itemID)
+
+	edit := newsfeed.ItemEdit{}
+	if *title != "" {
+		edit.Title = title
+	}
+	if *clearSummary {
+		empty := ""
+		edit.Summary = &empty
+	} else if *summary != "" {
+		edit.Summary = summary
+	}
+
+	item, err := newsFeed.Edit(id, edit)
+	if errors.Is(err, newsfeed.ErrItemNotFound) {
+		fmt.Fprintf(os.Stderr, "Error: news item not found: %s\n", itemID)
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to edit news item: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✓ Updated: %s\n", item.Title)
+}
+
+func handleArchive(feedDSN string, args []string) {
+	if len(args) < 1 {
+		fmt.Fprintf(os.Stderr, "Error: item ID is required\n")
+		fmt.Fprintf(os.Stderr, "Usage: newsfed archive <item-id>\n")
+		os.Exit(1)
+	}
+
+	itemID := args[0]
+
+	// Initialize news feed
+	newsFeed, err := openFeed(feedDSN)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to open news feed: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() { _ = newsFeed.Close() }()
+
+	id := resolveItemID(newsFeed, itemID)
+
+	item, err := newsFeed.Archive(id)
+	if errors.Is(err, newsfeed.ErrItemNotFound) {
+		fmt.Fprintf(os.Stderr, "Error: news item not found: %s\n", itemID)
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to archive item: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✓ Archived item: %s\n", item.Title)
+}
+
+func handleUnarchive(feedDSN string, args []string) {
+	if len(args) < 1 {
+		fmt.Fprintf(os.Stderr, "Error: item ID is required\n")
+		fmt.Fprintf(os.Stderr, "Usage: newsfed unarchive <item-id>\n")
+		os.Exit(1)
+	}
+
+	itemID := args[0]
+
+	// Initialize news feed
+	newsFeed, err := openFeed(feedDSN)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to open news feed: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() { _ = newsFeed.Close() }()
+
+	id := resolveItemID(newsFeed, itemID)
+
+	item, err := newsFeed.Unarchive(id)
+	if errors.Is(err, newsfeed.ErrItemNotFound) {
+		fmt.Fprintf(os.Stderr, "Error: news item not found: %s\n", itemID)
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to unarchive item: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✓ Unarchived item: %s\n", item.Title)
+}
+
+func handleSnooze(feedDSN string, args []string) {
+	if len(args) < 2 {
+		fmt.Fprintf(os.Stderr, "Error: item ID and duration are required\n")
+		fmt.Fprintf(os.Stderr, "Usage: newsfed snooze <item-id> <duration>\n")
+		os.Exit(1)
+	}
+
+	itemID := args[0]
+
+	duration, err := parseDuration(args[1])
+	if err != nil || duration <= 0 {
+		fmt.Fprintf(os.Stderr, "Error: invalid duration: %s\n", args[1])
+		os.Exit(1)
+	}
+
+	// Initialize news feed
+	newsFeed, err := openFeed(feedDSN)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to open news feed: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() { _ = newsFeed.Close() }()
+
+	id := resolveItemID(newsFeed, itemID)
+
+	item, err := newsFeed.Snooze(id, time.Now().Add(duration))
+	if errors.Is(err, newsfeed.ErrItemNotFound) {
+		fmt.Fprintf(os.Stderr, "Error: news item not found: %s\n", itemID)
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to snooze item: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✓ Snoozed item until %s: %s\n", item.SnoozedUntil.Format("2006-01-02 15:04:05"), item.Title)
+}
+
+func handleUnsnooze(feedDSN string, args []string) {
+	if len(args) < 1 {
+		fmt.Fprintf(os.Stderr, "Error: item ID is required\n")
+		fmt.Fprintf(os.Stderr, "Usage: newsfed unsnooze <item-id>\n")
+		os.Exit(1)
+	}
+
+	itemID := args[0]
+
+	// Initialize news feed
+	newsFeed, err := openFeed(feedDSN)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to open news feed: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() { _ = newsFeed.Close() }()
+
+	id := resolveItemID(newsFeed, itemID)
+
+	item, err := newsFeed.Unsnooze(id)
+	if errors.Is(err, newsfeed.ErrItemNotFound) {
+		fmt.Fprintf(os.Stderr, "Error: news item not found: %s\n", itemID)
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to unsnooze item: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✓ Unsnoozed item: %s\n", item.Title)
+}
+
+func handleDelete(feedDSN string, args []string) {
+	if len(args) < 1 {
+		fmt.Fprintf(os.Stderr, "Error: item ID is required\n")
+		fmt.Fprintf(os.Stderr, "Usage: newsfed delete <item-id>\n")
+		os.Exit(1)
+	}
+
+	itemID := args[0]
+
+	// Initialize news feed
+	newsFeed, err := openFeed(feedDSN)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to open news feed: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() { _ = newsFeed.Close() }()
+
+	id := resolveItemID(newsFeed, itemID)
+
+	err = newsFeed.Delete(id)
+	if errors.Is(err, newsfeed.ErrItemNotFound) {
+		fmt.Fprintf(os.Stderr, "Error: news item not found: %s\n", itemID)
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to delete item: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✓ Deleted item: %s\n", itemID)
+}
+
+func handleOpen(metadataPath, feedDSN string, args []string) {
+	// Parse flags for open command
+	fs := flag.NewFlagSet("open", flag.ExitOnError)
+	echo := fs.Bool("echo", false, "Echo the command instead of executing it")
+	_ = fs.Parse(args)
+
+	// Get item ID from remaining args
+	if len(fs.Args()) < 1 {
+		fmt.Fprintf(os.Stderr, "Error: item ID is required\n")
+		fmt.Fprintf(os.Stderr, "Usage: newsfed open [-echo] <item-id>\n")
+		os.Exit(1)
+	}
+
+	itemID := fs.Args()[0]
+
+	// Initialize news feed
+	newsFeed, err := openFeed(feedDSN)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to open news feed: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() { _ = newsFeed.Close() }()
+
+	id := resolveItemID(newsFeed, itemID)
+
+	// Get the item
+	item, err := newsFeed.Get(id)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to get news item: %v\n", err)
+		os.Exit(1)
+	}
+
+	if item == nil {
+		fmt.Fprintf(os.Stderr, "Error: news item not found: %s\n", itemID)
+		os.Exit(1)
+	}
+
+	// Load config to check for custom browser command
+	var browserCmd string
+	var cmdArgs []string
+
+	configStore, err := config.NewConfigStore(metadataPath)
+	if err == nil {
+		defer func() { _ = configStore.Close() }()
+		cfg, err := configStore.GetConfig()
+		if err == nil && cfg.BrowserCommand != "" {
+			// Use configured browser command
+			browserCmd = cfg.BrowserCommand
+			cmdArgs = []string{item.URL}
+		}
+	}
+
+	// If no configured browser command, determine based on platform
+	if browserCmd == "" {
+		switch runtime.GOOS {
+		case "darwin":
+			browserCmd = "open"
+			cmdArgs = []string{item.URL}
+		case "linux":
+			browserCmd = "xdg-open"
+			cmdArgs = []string{item.URL}
+		case "windows":
+			browserCmd = "cmd"
+			cmdArgs = []string{"/c", "start", item.URL}
+		default:
+			fmt.Fprintf(os.Stderr, "Error: unsupported platform: %s\n", runtime.GOOS)
+			os.Exit(1)
+		}
+	}
+
+	// If echo mode, print the command instead of executing it
+	if *echo {
+		fmt.Printf("%s %s\n", browserCmd, strings.Join(cmdArgs, " "))
+		return
+	}
+
+	// Build and execute the command
+	cmd := exec.Command(browserCmd, cmdArgs...)
+
+	err = cmd.Start()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to open URL: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Record the open, which also marks the item read
+	if _, err := newsFeed.MarkOpened(id); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to mark item opened: %v\n", err)
+	}
+
+	fmt.Printf("✓ Opening in browser: %s\n", item.Title)
+}
+
+func handlePrune(feedDSN string, args []string) {
+	fs := flag.NewFlagSet("prune", flag.ExitOnError)
+	all := fs.Bool("all", false, "Remove all items, not just those older than the retention period")
+	force := fs.Bool("force", false, "Skip confirmation prompt")
+	dryRun := fs.Bool("dry-run", false, "Show which items would be removed without removing them")
+	_ = fs.Parse(args)
+
+	// Determine the retention period (NEWSFED_RETENTION, default 90 days)
+	retention := newsfeed.DefaultRetention
+	if val := os.Getenv("NEWSFED_RETENTION"); val != "" {
+		parsed, err := newsfeed.ParseRetention(val)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: NEWSFED_RETENTION: %v\n", err)
+			os.Exit(1)
+		}
+		retention = parsed
+	}
+
+	// Initialize news feed
+	newsFeed, err := newsfeed.Open(feedDSN)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to open news feed: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() { _ = newsFeed.Close() }()
+
+	cutoff := time.Now().Add(-retention)
+	if *all {
+		cutoff = time.Now()
+	}
+
+	// A dry run lists the items that would be removed and stops
+	if *dryRun {
+		pruned, err := newsFeed.Prune(newsfeed.PruneOptions{Before: cutoff, DryRun: true})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to list news items: %v\n", err)
+			os.Exit(1)
+		}
+		for _, item := range pruned {
+			fmt.Printf("%s  %s\n", item.ID, item.Title)
+		}
+		fmt.Printf("%d items would be pruned\n", len(pruned))
+		return
+	}
+
+	// Ask for confirmation unless -force
+	if !*force {
+		if *all {
+			fmt.Print("All news items will be removed, but pinned and starred items will remain. Are you certain you want to do this? [y/N]: ")
 		} else {
-			fmt.Print("All news items older than 90 days will be removed, but pinned items will remain. Are you certain you want to do this? [y/N]: ")
+			fmt.Printf("All news items older than %s will be removed, but pinned and starred items will remain. Are you certain you want to do this? [y/N]: ", formatRetention(retention))
 		}
 
 		var response string
@@ -470,26 +1196,125 @@ func handlePrune(feedDir string, args []string) {
 		}
 	}
 
-	// Filter and delete
-	cutoff := time.Now().Add(-90 * 24 * time.Hour)
-	pruned := 0
-	for _, item := range result.Items {
-		// Never remove pinned items
-		if item.PinnedAt != nil {
-			continue
+	pruned, err := newsFeed.Prune(newsfeed.PruneOptions{Before: cutoff})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	fmt.Printf("%d items pruned\n", len(pruned))
+}
+
+// formatRetention describes a retention period in days when it is a whole
+// number of days.
+func formatRetention(d time.Duration) string {
+	day := 24 * time.Hour
+	if d%day == 0 {
+		return fmt.Sprintf("%d days", d/day)
+	}
+	return d.String()
+}
+
+func handleExport(feedDSN string, args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	format := fs.String("format", newsfeed.ExportRSS, "Export format: rss, atom, json, csv, markdown")
+	pinned := fs.Bool("pinned", false, "Export only pinned items")
+	unread := fs.Bool("unread", false, "Export only unread items")
+	publisher := fs.String("publisher", "", "Export only items from this publisher")
+	since := fs.String("since", "", "Export items discovered since duration (e.g., 24h, 7d)")
+	limit := fs.Int("limit", 50, "Maximum number of items to export (0 for no limit)")
+	title := fs.String("title", "newsfed", "Feed title")
+	link := fs.String("link", "", "Address of the site the feed describes")
+	description := fs.String("description", "Items collected by newsfed", "Feed description")
+	output := fs.String("o", "", "Write the feed to this file instead of stdout")
+	fields := fs.String("fields", "", "Comma-separated item fields to write (json, csv, and markdown only)")
+	_ = fs.Parse(args)
+
+	switch *format {
+	case newsfeed.ExportRSS, newsfeed.ExportAtom:
+		if *fields != "" {
+			fmt.Fprintf(os.Stderr, "Error: -fields can only be used with json, csv, and markdown formats\n")
+			os.Exit(1)
 		}
+	case newsfeed.ExportJSON, newsfeed.ExportCSV, newsfeed.ExportMarkdown:
+	default:
+		fmt.Fprintf(os.Stderr, "Error: invalid format: %s (must be rss, atom, json, csv, or markdown)\n", *format)
+		os.Exit(1)
+	}
+	selectedFields, err := newsfeed.ParseExportFields(*fields)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
 
-		// Without -all, only remove items discovered >90 days ago
-		if !*all && item.DiscoveredAt.After(cutoff) {
-			continue
+	opts := newsfeed.ListOptions{
+		Unread:    *unread,
+		Publisher: *publisher,
+		Limit:     *limit,
+	}
+	if *pinned {
+		opts.Pinned = pinned
+	}
+	if *since != "" {
+		duration, err := parseDuration(*since)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid duration format: %v\n", err)
+			os.Exit(1)
 		}
+		cutoff := time.Now().Add(-duration)
+		opts.DiscoveredSince = &cutoff
+	}
 
-		if err := newsFeed.Delete(item.ID); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to delete item %s: %v\n", item.ID, err)
-			continue
+	if err := opts.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Initialize news feed
+	newsFeed, err := openFeed(feedDSN)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to open news feed: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() { _ = newsFeed.Close() }()
+
+	result, err := newsFeed.Query(opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to list news items: %v\n", err)
+		os.Exit(1)
+	}
+	for _, readErr := range result.Errors {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", readErr.Error())
+	}
+
+	info := newsfeed.ExportInfo{
+		Title:       *title,
+		Link:        *link,
+		Description: *description,
+		Fields:      selectedFields,
+	}
+
+	if *output == "" {
+		if err := newsfeed.Export(os.Stdout, *format, info, result.Items); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to export feed: %v\n", err)
+			os.Exit(1)
 		}
-		pruned++
+		return
+	}
+
+	file, err := os.Create(*output)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to create %s: %v\n", *output, err)
+		os.Exit(1)
+	}
+	if err := newsfeed.Export(file, *format, info, result.Items); err != nil {
+		_ = file.Close()
+		fmt.Fprintf(os.Stderr, "Error: failed to export feed: %v\n", err)
+		os.Exit(1)
+	}
+	if err := file.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to write %s: %v\n", *output, err)
+		os.Exit(1)
 	}
 
-	fmt.Printf("%d items pruned\n", pruned)
+	fmt.Printf("✓ Exported %d items to %s\n", len(result.Items), *output)
 }
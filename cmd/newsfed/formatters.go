@@ -4,26 +4,82 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"slices"
 	"strings"
+	"time"
 
+	"github.com/charmbracelet/x/ansi"
+	"github.com/google/uuid"
 	"github.com/pevans/newsfed/newsfeed"
 )
 
-// printListTable prints items in human-readable table format
-func printListTable(items []newsfeed.NewsItem, total, offset int) {
+// listColumns are the fields the list table format can show, in the order
+// it shows them.
+var listColumns = []string{"title", "publisher", "published", "discovered", "summary", "url", "id"}
+
+// agedItemAge is how long after it was published an item is dimmed in
+// colored list output, so that newer items stand out.
+const agedItemAge = 24 * time.Hour
+
+// parseColumns returns the set of columns named in spec, a comma-separated
+// list of names from known. An empty spec selects every column.
+func parseColumns(spec string, known []string) (map[string]bool, error) {
+	selected := map[string]bool{}
+	if strings.TrimSpace(spec) == "" {
+		for _, name := range known {
+			selected[name] = true
+		}
+		return selected, nil
+	}
+	for name := range strings.SplitSeq(spec, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !slices.Contains(known, name) {
+			return nil, fmt.Errorf("unknown column: %s (use %s)", name, strings.Join(known, ", "))
+		}
+		selected[name] = true
+	}
+	return selected, nil
+}
+
+// printListTable prints items in human-readable table format, showing only
+// the given columns. A page reached by cursor has no known offset, so only
+// its size is shown.
+func printListTable(items []newsfeed.NewsItem, total, offset int, fromCursor bool, columns map[string]bool, colors palette) {
 	if len(items) == 0 {
 		fmt.Println("No items to display.")
 		return
 	}
 
 	// Print header
-	fmt.Printf("Showing %d-%d of %d items\n\n", offset+1, offset+len(items), total)
+	if fromCursor {
+		fmt.Printf("Showing %d more of %d items\n\n", len(items), total)
+	} else {
+		fmt.Printf("Showing %d-%d of %d items\n\n", offset+1, offset+len(items), total)
+	}
 
 	// Print each item
+	now := time.Now()
 	for _, item := range items {
 		pinnedMarker := " "
 		if item.PinnedAt != nil {
 			pinnedMarker = "📌"
+		} else if item.StarredAt != nil {
+			pinnedMarker = "⭐"
+		}
+
+		// Pinned items are highlighted and old ones dimmed
+		aged := now.Sub(item.PublishedAt) > agedItemAge
+		titleStyle := colors.bold
+		if item.PinnedAt != nil {
+			titleStyle = colors.highlight
+		} else if aged {
+			titleStyle = colors.dim
+		}
+		detailStyle := func(text string) string {
+			if aged {
+				return colors.dim(text)
+			}
+			return text
 		}
 
 		publisher := "Unknown"
@@ -31,38 +87,49 @@ func printListTable(items []newsfeed.NewsItem, total, offset int) {
 			publisher = *item.Publisher
 		}
 
-		// Truncate title and summary for display
-		title := item.Title
-		if len(title) > 70 {
-			title = title[:67] + "..."
+		var details []string
+		if columns["publisher"] {
+			details = append(details, publisher)
 		}
-
-		summary := item.Summary
-		if len(summary) > 150 {
-			summary = summary[:147] + "..."
+		if columns["published"] {
+			details = append(details, "Published: "+item.PublishedAt.Format("2006-01-02 15:04"))
+		}
+		if columns["discovered"] {
+			details = append(details, "Discovered: "+item.DiscoveredAt.Format("2006-01-02 15:04"))
 		}
 
-		fmt.Printf("%s %s\n", pinnedMarker, title)
-		fmt.Printf("   %s | Published: %s | Discovered: %s\n",
-			publisher,
-			item.PublishedAt.Format("2006-01-02 15:04"),
-			item.DiscoveredAt.Format("2006-01-02 15:04"),
-		)
-		if summary != "" {
-			fmt.Printf("   %s\n", summary)
+		// Truncate title and summary for display
+		title := truncate(item.Title, 70)
+		summary := truncate(item.DisplaySummary(), 150)
+
+		if columns["title"] {
+			fmt.Printf("%s %s\n", pinnedMarker, titleStyle(title))
+		}
+		if len(details) > 0 {
+			fmt.Printf("   %s\n", detailStyle(strings.Join(details, " | ")))
+		}
+		if columns["summary"] && summary != "" {
+			fmt.Printf("   %s\n", detailStyle(summary))
+		}
+		if columns["url"] {
+			fmt.Printf("   URL: %s\n", colors.cyan(item.URL))
+		}
+		if columns["id"] {
+			fmt.Printf("   ID: %s\n", colors.dim(item.ID.String()))
 		}
-		fmt.Printf("   URL: %s\n", item.URL)
-		fmt.Printf("   ID: %s\n", item.ID.String())
 		fmt.Println()
 	}
 }
 
 // printListJSON prints items in JSON format
-func printListJSON(items []newsfeed.NewsItem, total int) {
+func printListJSON(items []newsfeed.NewsItem, total int, nextCursor string) {
 	output := map[string]any{
 		"items": items,
 		"total": total,
 	}
+	if nextCursor != "" {
+		output["next_cursor"] = nextCursor
+	}
 
 	data, err := json.MarshalIndent(output, "", "  ")
 	if err != nil {
@@ -73,6 +140,32 @@ func printListJSON(items []newsfeed.NewsItem, total int) {
 	fmt.Println(string(data))
 }
 
+// printTopTable prints ranked items with their scores
+func printTopTable(items []newsfeed.NewsItem, scores map[uuid.UUID]float64) {
+	if len(items) == 0 {
+		fmt.Println("No items to display.")
+		return
+	}
+
+	for i, item := range items {
+		publisher := "Unknown"
+		if item.Publisher != nil {
+			publisher = *item.Publisher
+		}
+
+		title := truncate(item.Title, 70)
+
+		fmt.Printf("%2d. %s\n", i+1, title)
+		fmt.Printf("    Score: %.3f | %s | Published: %s\n",
+			scores[item.ID],
+			publisher,
+			item.PublishedAt.Format("2006-01-02 15:04"),
+		)
+		fmt.Printf("    ID: %s\n", item.ID.String())
+		fmt.Println()
+	}
+}
+
 // printListCompact prints items in compact format
 func printListCompact(items []newsfeed.NewsItem) {
 	if len(items) == 0 {
@@ -96,7 +189,8 @@ func printListCompact(items []newsfeed.NewsItem) {
 	}
 }
 
-// wrapText wraps text to a maximum line width
+// wrapText wraps text to a maximum line width, measured in terminal
+// columns
 func wrapText(text string, width int) string {
 	words := strings.Fields(text)
 	if len(words) == 0 {
@@ -105,17 +199,21 @@ func wrapText(text string, width int) string {
 
 	var lines []string
 	var currentLine strings.Builder
+	var lineWidth int
 
 	for _, word := range words {
 		if currentLine.Len() == 0 {
 			currentLine.WriteString(word)
-		} else if currentLine.Len()+1+len(word) <= width {
+			lineWidth = ansi.StringWidth(word)
+		} else if wordWidth := ansi.StringWidth(word); lineWidth+1+wordWidth <= width {
 			currentLine.WriteString(" ")
 			currentLine.WriteString(word)
+			lineWidth += 1 + wordWidth
 		} else {
 			lines = append(lines, currentLine.String())
 			currentLine.Reset()
 			currentLine.WriteString(word)
+			lineWidth = wordWidth
 		}
 	}
 
@@ -125,3 +223,25 @@ func wrapText(text string, width int) string {
 
 	return strings.Join(lines, "\n")
 }
+
+// truncate shortens s to at most width terminal columns, ending it with
+// "..." if it was cut. Wide characters such as CJK and emoji count as two
+// columns, and s is never cut in the middle of a character.
+func truncate(s string, width int) string {
+	return ansi.Truncate(s, width, "...")
+}
+
+// padRight pads s with spaces to width terminal columns, as %-*s would for
+// text of single-column characters. Text already that wide is left as is.
+func padRight(s string, width int) string {
+	if gap := width - ansi.StringWidth(s); gap > 0 {
+		return s + strings.Repeat(" ", gap)
+	}
+	return s
+}
+
+// fitColumn truncates and pads s to exactly width terminal columns, for a
+// table column.
+func fitColumn(s string, width int) string {
+	return padRight(truncate(s, width), width)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/google/uuid"
+	"github.com/pevans/newsfed/newsfeed"
+	"github.com/pevans/newsfed/sources"
+)
+
+// resolveItemID returns the ID of the item in feed named by arg, which may
+// be a full item ID or a unique prefix of one, exiting if it names no item
+// or more than one.
+func resolveItemID(feed *newsfeed.NewsFeed, arg string) uuid.UUID {
+	id, err := feed.ResolveID(arg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	return id
+}
+
+// resolveSourceID returns the ID of the source in store named by arg, which
+// may be a full source ID or a unique prefix of one, exiting if it names no
+// source or more than one.
+func resolveSourceID(store *sources.SourceStore, arg string) uuid.UUID {
+	id, err := store.ResolveSourceID(arg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	return id
+}
+
+// lookupSourceID resolves a source ID prefix as resolveSourceID does, for
+// commands that don't otherwise open the source store. A full ID is
+// returned without opening it.
+func lookupSourceID(metadataPath, arg string) uuid.UUID {
+	if id, err := uuid.Parse(arg); err == nil {
+		return id
+	}
+	store, err := sources.NewSourceStore(metadataPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to open source store: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() { _ = store.Close() }()
+	return resolveSourceID(store, arg)
+}
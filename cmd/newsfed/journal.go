@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pevans/newsfed/config"
+	"github.com/pevans/newsfed/discovery"
+	"github.com/pevans/newsfed/sources"
+)
+
+func handleJournal(metadataPath string, args []string) {
+	fs := flag.NewFlagSet("journal", flag.ExitOnError)
+	source := fs.String("source", "", "Only show decisions about items from this source ID")
+	decision := fs.String("decision", "", "Only show this decision: added, deduped, filtered, or invalid")
+	since := fs.String("since", "", "Only show decisions made within this duration (e.g., 24h, 1d)")
+	limit := fs.Int("limit", 50, "Maximum number of decisions to show")
+	format := fs.String("format", "table", "Output format: table or json")
+	_ = fs.Parse(args)
+
+	var filter discovery.JournalFilter
+	if *source != "" {
+		id := lookupSourceID(metadataPath, *source)
+		filter.SourceID = &id
+	}
+	switch *decision {
+	case "", discovery.JournalAdded, discovery.JournalDeduped, discovery.JournalFiltered, discovery.JournalInvalid:
+		filter.Decision = *decision
+	default:
+		fmt.Fprintf(os.Stderr, "Error: invalid decision: %s (use added, deduped, filtered, or invalid)\n", *decision)
+		os.Exit(1)
+	}
+	if *since != "" {
+		d, err := parseDuration(*since)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid -since: %v\n", err)
+			os.Exit(1)
+		}
+		filter.Since = time.Now().Add(-d)
+	}
+	if *format != "table" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "Error: invalid format: %s (use table or json)\n", *format)
+		os.Exit(1)
+	}
+
+	path, err := config.JournalPath()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	entries, err := discovery.ReadJournal(path, filter)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to read journal: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Newest first, as the audit log is shown
+	slices.Reverse(entries)
+	if *limit > 0 && len(entries) > *limit {
+		entries = entries[:*limit]
+	}
+
+	if *format == "json" {
+		if entries == nil {
+			entries = []discovery.JournalEntry{}
+		}
+		data, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to encode journal: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("No decisions recorded.")
+		return
+	}
+
+	names := journalSourceNames(metadataPath)
+	for _, entry := range entries {
+		name, ok := names[entry.SourceID]
+		if !ok {
+			name = entry.SourceID.String()
+		}
+		fmt.Printf("[%s] %s %s %s\n", entry.Time.Local().Format("2006-01-02 15:04:05"),
+			name, entry.Decision, entry.URL)
+		if entry.Title != "" {
+			fmt.Printf("    title: %s\n", entry.Title)
+		}
+		if entry.Reason != "" {
+			fmt.Printf("    reason: %s\n", entry.Reason)
+		}
+		if entry.ItemID != nil {
+			fmt.Printf("    item: %s\n", entry.ItemID)
+		}
+	}
+}
+
+// journalSourceNames maps source IDs to names for showing the journal. If the
+// sources can't be read, the journal is shown with IDs alone.
+func journalSourceNames(metadataPath string) map[uuid.UUID]string {
+	names := map[uuid.UUID]string{}
+	store, err := sources.NewSourceStore(metadataPath)
+	if err != nil {
+		return names
+	}
+	defer func() { _ = store.Close() }()
+
+	list, err := store.ListSources(sources.SourceFilter{})
+	if err != nil {
+		return names
+	}
+	for _, source := range list {
+		names[source.SourceID] = source.Name
+	}
+	return names
+}
@@ -4,29 +4,55 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/pevans/newsfed/audit"
+	"github.com/pevans/newsfed/newsfeed"
 	"github.com/pevans/newsfed/sources"
 )
 
 func main() {
 	// Load storage configuration with precedence: env vars > config file >
 	// defaults
-	metadataType, metadataPath, feedType, feedDir := loadStorageConfig()
+	metadataType, metadataPath, feedType, feedDSN := loadStorageConfig()
 
-	// Validate storage types (currently only sqlite and file are supported)
-	if metadataType != "sqlite" {
+	// Validate storage types (metadata: sqlite or postgres; feed: file,
+	// sqlite, or postgres)
+	if metadataType != "sqlite" && metadataType != "postgres" {
 		fmt.Fprintf(os.Stderr, "Error: unsupported metadata storage type: %s\n", metadataType)
-		fmt.Fprintf(os.Stderr, "Supported types: sqlite\n")
+		fmt.Fprintf(os.Stderr, "Supported types: sqlite, postgres\n")
 		os.Exit(1)
 	}
-	if feedType != "file" {
+	if feedType != "file" && feedType != "sqlite" && feedType != "postgres" {
 		fmt.Fprintf(os.Stderr, "Error: unsupported feed storage type: %s\n", feedType)
-		fmt.Fprintf(os.Stderr, "Supported types: file\n")
+		fmt.Fprintf(os.Stderr, "Supported types: file, sqlite, postgres\n")
 		os.Exit(1)
 	}
 
+	// Keep the news feed in memory for this run with a leading --ephemeral
+	ephemeral, args := parseEphemeralFlag(os.Args[1:])
+	if ephemeral {
+		feedDSN = newsfeed.MemoryScheme + "ephemeral"
+	}
+
+	// Select the user whose item state commands show, from a leading
+	// --user flag or NEWSFED_USER
+	userName, args := parseUserFlag(args)
+	if userName == "" {
+		userName = os.Getenv("NEWSFED_USER")
+	}
+	if userName != "" && (len(args) == 0 || args[0] != "users") {
+		selectUser(metadataPath, userName)
+	}
+	os.Args = append(os.Args[:1], args...)
+
+	// Changes to sources and config are audited as made by the CLI
+	audit.Actor = "cli"
+	if feedUser != nil {
+		audit.Actor = "cli:" + feedUser.Name
+	}
+
 	// When run without arguments, launch the TUI
 	if len(os.Args) < 2 {
-		handleTUI(metadataPath, feedDir)
+		handleTUI(metadataPath, feedDSN)
 		return
 	}
 
@@ -35,32 +61,88 @@ func main() {
 
 	switch subcommand {
 	case "list":
-		handleList(feedDir, os.Args[2:])
+		handleList(metadataPath, feedDSN, os.Args[2:])
+	case "top":
+		handleTop(metadataPath, feedDSN, os.Args[2:])
 	case "show":
-		handleShow(feedDir, os.Args[2:])
+		handleShow(feedDSN, os.Args[2:])
+	case "add":
+		handleAdd(feedDSN, os.Args[2:])
+	case "edit":
+		handleEdit(feedDSN, os.Args[2:])
 	case "pin":
-		handlePin(feedDir, os.Args[2:])
+		handlePin(feedDSN, os.Args[2:])
 	case "unpin":
-		handleUnpin(feedDir, os.Args[2:])
+		handleUnpin(feedDSN, os.Args[2:])
+	case "read":
+		handleRead(feedDSN, os.Args[2:])
+	case "unread":
+		handleUnread(feedDSN, os.Args[2:])
+	case "star":
+		handleStar(feedDSN, os.Args[2:])
+	case "unstar":
+		handleUnstar(feedDSN, os.Args[2:])
+	case "note":
+		handleNote(feedDSN, os.Args[2:])
+	case "archive":
+		handleArchive(feedDSN, os.Args[2:])
+	case "unarchive":
+		handleUnarchive(feedDSN, os.Args[2:])
+	case "snooze":
+		handleSnooze(feedDSN, os.Args[2:])
+	case "unsnooze":
+		handleUnsnooze(feedDSN, os.Args[2:])
+	case "delete":
+		handleDelete(feedDSN, os.Args[2:])
+	case "refresh":
+		handleRefresh(metadataPath, feedDSN, os.Args[2:])
 	case "open":
-		handleOpen(metadataPath, feedDir, os.Args[2:])
+		handleOpen(metadataPath, feedDSN, os.Args[2:])
 	case "prune":
-		handlePrune(feedDir, os.Args[2:])
+		handlePrune(feedDSN, os.Args[2:])
+	case "digest":
+		handleDigest(metadataPath, feedDSN, os.Args[2:])
+	case "export":
+		handleExport(feedDSN, os.Args[2:])
+	case "collections":
+		handleCollections(metadataPath, feedDSN, os.Args[2:])
+	case "users":
+		handleUsers(metadataPath, os.Args[2:])
+	case "secrets":
+		handleSecrets(metadataPath, os.Args[2:])
+	case "audit":
+		handleAudit(metadataPath, os.Args[2:])
+	case "journal":
+		handleJournal(metadataPath, os.Args[2:])
+	case "metrics":
+		handleMetrics(metadataPath, os.Args[2:])
 	case "sync":
-		handleSync(metadataPath, feedDir, os.Args[2:])
+		handleSync(metadataPath, feedDSN, os.Args[2:])
+	case "daemon":
+		handleDaemon(metadataPath, feedDSN, os.Args[2:])
 	case "init":
-		handleInit(metadataPath, feedDir, os.Args[2:])
+		handleInit(metadataPath, feedDSN, os.Args[2:])
 	case "doctor":
-		handleDoctor(metadataPath, feedDir, os.Args[2:])
+		handleDoctor(metadataPath, feedDSN, os.Args[2:])
+	case "fsck":
+		handleFsck(feedDSN, os.Args[2:])
+	case "migrate":
+		handleMigrate(feedDSN, os.Args[2:])
+	case "migrate-db":
+		handleMigrateDB(metadataPath, os.Args[2:])
+	case "config":
+		handleConfig(metadataPath, os.Args[2:])
 	case "tui":
-		handleTUI(metadataPath, feedDir)
+		handleTUI(metadataPath, feedDSN)
+	case "completion":
+		handleCompletion(os.Args[2:])
 	case "sources":
 		if len(os.Args) < 3 {
 			printSourcesUsage()
 			os.Exit(1)
 		}
 		action := os.Args[2]
-		handleSourcesCommand(action, metadataPath, os.Args[3:])
+		handleSourcesCommand(action, metadataPath, feedDSN, os.Args[3:])
 	case "help", "--help", "-h":
 		printUsage()
 	default:
@@ -70,7 +152,7 @@ func main() {
 	}
 }
 
-func handleSourcesCommand(action, metadataPath string, args []string) {
+func handleSourcesCommand(action, metadataPath, feedDSN string, args []string) {
 	// Initialize source store
 	sourceStore, err := sources.NewSourceStore(metadataPath)
 	if err != nil {
@@ -89,7 +171,7 @@ func handleSourcesCommand(action, metadataPath string, args []string) {
 	case "update":
 		handleSourcesUpdate(sourceStore, args)
 	case "delete":
-		handleSourcesDelete(sourceStore, args)
+		handleSourcesDelete(sourceStore, feedDSN, args)
 	case "enable":
 		handleSourcesEnable(sourceStore, args)
 	case "disable":
@@ -98,6 +180,16 @@ func handleSourcesCommand(action, metadataPath string, args []string) {
 		handleSourcesStatus(sourceStore, args)
 	case "errors":
 		handleSourcesErrors(sourceStore, args)
+	case "history":
+		handleSourcesHistory(sourceStore, args)
+	case "stats":
+		handleSourcesStats(sourceStore, args)
+	case "session":
+		handleSourcesSession(sourceStore, metadataPath, args)
+	case "discover":
+		handleSourcesDiscover(sourceStore, args)
+	case "categories":
+		handleSourcesCategories(sourceStore, feedDSN, args)
 	case "help", "--help", "-h":
 		printSourcesUsage()
 	default:
@@ -111,25 +203,61 @@ func printUsage() {
 	fmt.Println("newsfed -- News feed CLI client")
 	fmt.Println()
 	fmt.Println("Usage:")
-	fmt.Println("  newsfed <command> [arguments]")
+	fmt.Println("  newsfed [--ephemeral] [--user <name>] <command> [arguments]")
+	fmt.Println()
+	fmt.Println("Options:")
+	fmt.Println("  --ephemeral  Keep the news feed in memory; its items are lost on exit")
+	fmt.Println("  --user       User whose pins, read marks, and notes to use")
 	fmt.Println()
 	fmt.Println("Commands:")
 	fmt.Println("  list       List news items")
+	fmt.Println("  top        List the highest scoring news items")
 	fmt.Println("  show       Show detailed view of a news item")
+	fmt.Println("  add        Save a link as a news item")
+	fmt.Println("  edit       Change a news item's title or summary")
 	fmt.Println("  pin        Pin a news item for later reference")
 	fmt.Println("  unpin      Unpin a news item")
+	fmt.Println("  read       Mark a news item as read")
+	fmt.Println("  unread     Mark a news item as unread")
+	fmt.Println("  star       Star a news item to keep it for reference")
+	fmt.Println("  unstar     Unstar a news item")
+	fmt.Println("  note       View or set notes on a news item")
+	fmt.Println("  archive    Hide a news item from listings")
+	fmt.Println("  unarchive  Return an archived news item to listings")
+	fmt.Println("  snooze     Hide a news item from listings for a while")
+	fmt.Println("  unsnooze   Return a snoozed news item to listings")
+	fmt.Println("  delete     Permanently delete a news item")
 	fmt.Println("  open       Open a news item URL in default browser")
+	fmt.Println("  refresh    Fetch a news item's page again to update it")
 	fmt.Println("  prune      Remove stale news items")
+	fmt.Println("  export     Write news items as a feed or as JSON, CSV, or Markdown")
+	fmt.Println("  collections Group news items into named reading lists")
+	fmt.Println("  users      Manage the users who share this instance")
+	fmt.Println("  digest     Render or email a digest of the top news items")
+	fmt.Println("  secrets    Store credentials that sources refer to, encrypted")
+	fmt.Println("  audit      Show who changed sources and config, and when")
+	fmt.Println("  journal    Show what discovery did with each item it found, and why")
+	fmt.Println("  metrics    Show discovery metrics saved by the daemon across restarts")
 	fmt.Println("  sync       Manually sync sources to fetch new items")
+	fmt.Println("  daemon     Run continuous discovery with an HTTP metrics endpoint")
 	fmt.Println("  init       Initialize storage (create databases/directories)")
 	fmt.Println("  doctor     Check storage health and configuration")
+	fmt.Println("  fsck       Check the news feed for damaged items and repair them")
+	fmt.Println("  migrate    Copy the news feed to another storage backend")
+	fmt.Println("  migrate-db Show or apply metadata database schema migrations")
+	fmt.Println("  config     Show or change user settings, or validate config files")
 	fmt.Println("  sources    Manage news sources")
 	fmt.Println("  tui        Launch the text user interface")
+	fmt.Println("  completion Print a bash, zsh, or fish completion script")
 	fmt.Println("  help       Show this help message")
 	fmt.Println()
 	fmt.Println("Environment Variables:")
+	fmt.Println("  NEWSFED_CONFIG         Extra config file read after ~/.newsfed/config.yaml and ./newsfed.yaml")
 	fmt.Println("  NEWSFED_METADATA_TYPE  Metadata storage type (default: sqlite)")
 	fmt.Println("  NEWSFED_METADATA_DSN   Path to metadata database (default: metadata.db)")
-	fmt.Println("  NEWSFED_FEED_TYPE      Feed storage type (default: file)")
-	fmt.Println("  NEWSFED_FEED_DSN       Path to news feed storage (default: .news)")
+	fmt.Println("  NEWSFED_FEED_TYPE      Feed storage type: file or sqlite (default: file)")
+	fmt.Println("  NEWSFED_FEED_DSN       Path to news feed storage, sqlite://<path>, postgres://..., or memory://<name> (default: .news)")
+	fmt.Println("  NEWSFED_USER           User whose pins, read marks, and notes to use, like --user")
+	fmt.Println("  NEWSFED_RETENTION      Age after which prune removes items, e.g. 30d (default: 90d)")
+	fmt.Println("  NEWSFED_MASTER_KEY     Key secrets and source sessions are encrypted with (default: from the OS keychain)")
 }
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pevans/newsfed/discovery"
+	"github.com/pevans/newsfed/sources"
+)
+
+func printMetricsUsage() {
+	fmt.Println("Usage: newsfed metrics [options]")
+	fmt.Println()
+	fmt.Println("Show the discovery metrics the daemon has saved: fetches, items")
+	fmt.Println("discovered, fetch durations, and discovery lag, counted across restarts.")
+	fmt.Println("The daemon saves them every metrics interval and when it stops.")
+	fmt.Println()
+	fmt.Println("Options:")
+	fmt.Println("  -days <n>        Also show what was counted on each of the last n days")
+	fmt.Println("  -worker <name>   Only show the metrics saved by this worker")
+	fmt.Println("  -format <fmt>    Output format: table, json, or yaml")
+}
+
+func handleMetrics(metadataPath string, args []string) {
+	fs := flag.NewFlagSet("metrics", flag.ExitOnError)
+	fs.Usage = printMetricsUsage
+	days := fs.Int("days", 0, "Also show what was counted on each of the last n days")
+	worker := fs.String("worker", "", "Only show the metrics saved by this worker")
+	format := formatFlag(fs)
+	_ = fs.Parse(args)
+	checkFormat(*format)
+
+	if *days < 0 {
+		fmt.Fprintf(os.Stderr, "Error: -days must not be negative\n")
+		os.Exit(1)
+	}
+
+	store, err := sources.NewSourceStore(metadataPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to open source store: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() { _ = store.Close() }()
+
+	saved, err := store.ListMetrics(time.Time{})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to read metrics: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Decode each worker's snapshots, oldest first
+	byWorker := map[string][]savedSnapshot{}
+	var workers []string
+	for _, s := range saved {
+		if *worker != "" && s.Worker != *worker {
+			continue
+		}
+		var snapshot discovery.MetricsSnapshot
+		if err := json.Unmarshal(s.Metrics, &snapshot); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: metrics saved by %s on %s can't be read: %v\n", s.Worker, s.Day, err)
+			os.Exit(1)
+		}
+		if _, ok := byWorker[s.Worker]; !ok {
+			workers = append(workers, s.Worker)
+		}
+		byWorker[s.Worker] = append(byWorker[s.Worker], savedSnapshot{SavedMetrics: s, snapshot: snapshot})
+	}
+	slices.Sort(workers)
+
+	report := metricsReport{Workers: workers}
+	for _, w := range workers {
+		latest := byWorker[w][len(byWorker[w])-1]
+		report.MetricsSnapshot = report.MetricsSnapshot.Add(latest.snapshot)
+		if latest.SavedAt.After(report.SavedAt) {
+			report.SavedAt = latest.SavedAt
+		}
+	}
+	if *days > 0 {
+		report.Days = dailyMetrics(byWorker, *days, time.Now())
+	}
+
+	if structured(*format) {
+		printStructured(*format, report)
+		return
+	}
+	if len(workers) == 0 {
+		if *worker != "" {
+			fmt.Printf("No metrics saved by %s.\n", *worker)
+		} else {
+			fmt.Println("No metrics saved. The daemon saves them while it runs.")
+		}
+		return
+	}
+	printMetricsReport(report)
+}
+
+// savedSnapshot is a worker's saved metrics, decoded.
+type savedSnapshot struct {
+	sources.SavedMetrics
+	snapshot discovery.MetricsSnapshot
+}
+
+// metricsReport is the saved metrics of every worker, added up, as printed
+// by metrics.
+type metricsReport struct {
+	Workers []string  `json:"workers"`
+	SavedAt time.Time `json:"saved_at"` // When the latest snapshot was saved
+	discovery.MetricsSnapshot
+	Days []dayMetrics `json:"days,omitempty"`
+}
+
+// dayMetrics is what was counted on one day.
+type dayMetrics struct {
+	Day              string  `json:"day"`
+	Fetches          int     `json:"fetches"`
+	Failed           int     `json:"failed"`
+	ItemsDiscovered  int     `json:"items_discovered"`
+	MeanFetchSeconds float64 `json:"mean_fetch_seconds"`
+	MeanLagSeconds   float64 `json:"mean_lag_seconds"`
+}
+
+// dailyMetrics returns what was counted on each of the last days UTC days
+// before now, oldest first, from the difference between each worker's
+// snapshot of a day and of the day before it.
+func dailyMetrics(byWorker map[string][]savedSnapshot, days int, now time.Time) []dayMetrics {
+	counted := map[string]discovery.MetricsSnapshot{}
+	for _, snapshots := range byWorker {
+		var prev discovery.MetricsSnapshot
+		for _, s := range snapshots {
+			counted[s.Day] = counted[s.Day].Add(s.snapshot.Sub(prev))
+			prev = s.snapshot
+		}
+	}
+
+	today := now.UTC()
+	result := make([]dayMetrics, 0, days)
+	for i := days - 1; i >= 0; i-- {
+		day := today.AddDate(0, 0, -i).Format(time.DateOnly)
+		c := counted[day]
+		result = append(result, dayMetrics{
+			Day:              day,
+			Fetches:          c.SourcesFetched + c.SourcesFailed,
+			Failed:           c.SourcesFailed,
+			ItemsDiscovered:  c.ItemsDiscovered,
+			MeanFetchSeconds: c.FetchDuration.Mean().Seconds(),
+			MeanLagSeconds:   c.DiscoveryLag.Mean().Seconds(),
+		})
+	}
+	return result
+}
+
+// printMetricsReport prints the saved metrics and, if asked for, the table
+// of days.
+func printMetricsReport(report metricsReport) {
+	fmt.Printf("Metrics saved by: %s\n", strings.Join(report.Workers, ", "))
+	fmt.Printf("Last saved:       %s\n", report.SavedAt.Local().Format("2006-01-02 15:04:05"))
+	fmt.Printf("Counting since:   %s\n", report.Since.Local().Format("2006-01-02 15:04:05"))
+	fmt.Println()
+
+	fetches := report.SourcesFetched + report.SourcesFailed
+	failures := ""
+	if fetches > 0 {
+		failures = fmt.Sprintf(" (%.1f%%)", float64(report.SourcesFailed)/float64(fetches)*100)
+	}
+	fmt.Printf("Fetches:          %d succeeded, %d failed%s\n", report.SourcesFetched, report.SourcesFailed, failures)
+	fmt.Printf("Items discovered: %d\n", report.ItemsDiscovered)
+	fmt.Printf("Fetch duration:   %s\n", describeHistogram(report.FetchDuration))
+	fmt.Printf("Discovery lag:    %s\n", describeHistogram(report.DiscoveryLag))
+
+	if len(report.Days) == 0 {
+		return
+	}
+	fmt.Println()
+	fmt.Printf("%-10s  %7s  %6s  %6s  %10s  %8s\n", "DAY", "FETCHES", "FAILED", "ITEMS", "MEAN FETCH", "MEAN LAG")
+	fmt.Println(strings.Repeat("-", 56))
+	for _, d := range report.Days {
+		meanFetch, meanLag := "-", "-"
+		if d.Fetches > 0 {
+			meanFetch = formatMetricDuration(time.Duration(d.MeanFetchSeconds * float64(time.Second)))
+		}
+		if d.MeanLagSeconds > 0 {
+			meanLag = formatMetricDuration(time.Duration(d.MeanLagSeconds * float64(time.Second)))
+		}
+		fmt.Printf("%-10s  %7d  %6d  %6d  %10s  %8s\n", d.Day, d.Fetches, d.Failed, d.ItemsDiscovered, meanFetch, meanLag)
+	}
+}
+
+// describeHistogram describes the mean and 95th percentile of h, such as
+// "mean 1.2s, 95% within 5s".
+func describeHistogram(h discovery.HistogramSnapshot) string {
+	if h.Count == 0 {
+		return "none recorded"
+	}
+	if len(h.Buckets) == 0 {
+		return fmt.Sprintf("mean %s (%d recorded)", formatMetricDuration(h.Mean()), h.Count)
+	}
+	p95 := h.Quantile(0.95)
+	within := "95% within " + formatMetricDuration(time.Duration(p95*float64(time.Second)))
+	if math.IsInf(p95, 1) {
+		within = "more than 5% over " + formatMetricDuration(time.Duration(h.Buckets[len(h.Buckets)-1]*float64(time.Second)))
+	}
+	return fmt.Sprintf("mean %s, %s (%d recorded)", formatMetricDuration(h.Mean()), within, h.Count)
+}
+
+// formatMetricDuration formats d to the hundredth of a second under a
+// minute, and like formatDuration otherwise.
+func formatMetricDuration(d time.Duration) string {
+	if d < time.Minute {
+		return d.Round(10 * time.Millisecond).String()
+	}
+	return formatDuration(d)
+}
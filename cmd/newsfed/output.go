@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pevans/newsfed/secrets"
+	"github.com/pevans/newsfed/sources"
+	"gopkg.in/yaml.v3"
+)
+
+// The output formats of commands with machine-readable output. The table
+// format is whatever the command prints for people to read.
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatYAML  = "yaml"
+)
+
+// formatFlag adds the -format flag for choosing between table, JSON, and
+// YAML output to fs.
+func formatFlag(fs *flag.FlagSet) *string {
+	return fs.String("format", formatTable, "Output format: table, json, or yaml")
+}
+
+// checkFormat exits if format is not table, json, or yaml.
+func checkFormat(format string) {
+	if format != formatTable && format != formatJSON && format != formatYAML {
+		fmt.Fprintf(os.Stderr, "Error: invalid format: %s (use table, json, or yaml)\n", format)
+		os.Exit(1)
+	}
+}
+
+// structured reports whether format is a machine-readable one.
+func structured(format string) bool {
+	return format == formatJSON || format == formatYAML
+}
+
+// noticeWriter returns where a command writes progress and notices: stdout
+// for table output, or stderr when stdout is kept for JSON or YAML.
+func noticeWriter(format string) io.Writer {
+	if structured(format) {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// printStructured prints v as indented JSON, or as YAML with the same keys
+// in the same order.
+func printStructured(format string, v any) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err == nil && format == formatYAML {
+		data, err = jsonToYAML(data)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to encode output: %v\n", err)
+		os.Exit(1)
+	}
+	if data[len(data)-1] != '\n' {
+		data = append(data, '\n')
+	}
+	_, _ = os.Stdout.Write(data)
+}
+
+// jsonToYAML converts a JSON document to block-style YAML. JSON is itself
+// YAML, so it is read as a YAML node tree, which keeps the order of keys,
+// and written back without the flow style and quoting it was read with.
+func jsonToYAML(data []byte) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	var clearStyle func(*yaml.Node)
+	clearStyle = func(node *yaml.Node) {
+		node.Style = 0
+		for _, child := range node.Content {
+			clearStyle(child)
+		}
+	}
+	clearStyle(&doc)
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// redactValue returns sources.RedactedValue in place of a credential, or
+// value itself if it only refers to a secret by name.
+func redactValue(value string) string {
+	if _, ok := secrets.Ref(value); ok {
+		return value
+	}
+	return sources.RedactedValue
+}
+
+// redactSources returns list with each source redacted as by
+// Source.Redacted.
+// It is never nil, so an empty list is printed as one.
+func redactSources(list []sources.Source) []sources.Source {
+	redacted := make([]sources.Source, 0, len(list))
+	for _, source := range list {
+		redacted = append(redacted, source.Redacted())
+	}
+	return redacted
+}
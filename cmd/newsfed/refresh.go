@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pevans/newsfed/config"
+	"github.com/pevans/newsfed/discovery"
+	"github.com/pevans/newsfed/newsfeed"
+	"github.com/pevans/newsfed/sources"
+)
+
+func handleRefresh(metadataPath, feedDSN string, args []string) {
+	if len(args) != 1 {
+		fmt.Fprintf(os.Stderr, "Error: item ID is required\n")
+		fmt.Fprintf(os.Stderr, "Usage: newsfed refresh <item-id>\n")
+		os.Exit(1)
+	}
+
+	// The item is fetched with its source's scraper config and request
+	// settings
+	sourceStore, err := sources.NewSourceStore(metadataPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to open source store: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() { _ = sourceStore.Close() }()
+
+	newsFeed, err := openFeed(feedDSN)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to open news feed: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() { _ = newsFeed.Close() }()
+
+	id := resolveItemID(newsFeed, args[0])
+
+	fileConfig, err := config.LoadConfigFile()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	config := discovery.DefaultDiscoveryConfig()
+	config.ProxyURL, err = loadProxyURL(fileConfig)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := loadRetryPolicy(config, fileConfig); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := loadRenderSettings(config, fileConfig); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := loadMaxResponseSize(config, fileConfig); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	service := discovery.NewDiscoveryService(sourceStore, newsFeed, config)
+	defer func() { _ = service.Close() }()
+	secretStore, err := openSecrets(metadataPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to open secrets: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() { _ = secretStore.Close() }()
+	service.SetSecrets(secretStore)
+
+	before, err := newsFeed.Get(id)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to get item: %v\n", err)
+		os.Exit(1)
+	}
+
+	item, changed, err := service.RefreshItem(context.Background(), newsFeed, id)
+	if errors.Is(err, newsfeed.ErrItemNotFound) {
+		fmt.Fprintf(os.Stderr, "Error: item not found: %s\n", id)
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to refresh item: %v\n", err)
+		os.Exit(1)
+	}
+
+	if !changed {
+		fmt.Printf("Item is up to date: %s\n", item.Title)
+		return
+	}
+	fmt.Printf("✓ Refreshed item: %s\n", item.Title)
+	if item.Title != before.Title {
+		fmt.Printf("  Title:     %s → %s\n", before.Title, item.Title)
+	}
+	if item.Summary != before.Summary {
+		fmt.Println("  Summary:   updated")
+	}
+	if !item.PublishedAt.Equal(before.PublishedAt) {
+		fmt.Printf("  Published: %s\n", item.PublishedAt.Local().Format("2006-01-02 15:04"))
+	}
+	if len(item.Authors) != len(before.Authors) {
+		fmt.Printf("  Authors:   %s\n", strings.Join(item.Authors, ", "))
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/pevans/newsfed/secrets"
+)
+
+func printSecretsUsage() {
+	fmt.Println("Usage: newsfed secrets <command> [arguments]")
+	fmt.Println()
+	fmt.Println("Commands:")
+	fmt.Println("  list            List secrets, without their values (default)")
+	fmt.Println("  set <name>      Store a secret, read from a prompt or stdin")
+	fmt.Println("  delete <name>   Delete a secret")
+	fmt.Println()
+	fmt.Println("Refer to a secret in a source's header value, proxy URL, or login")
+	fmt.Println("field as secret:<name>. Secrets are encrypted with NEWSFED_MASTER_KEY,")
+	fmt.Println("or the master key in the OS keychain (service newsfed, account master-key).")
+}
+
+func handleSecrets(metadataPath string, args []string) {
+	store, err := openSecrets(metadataPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to open secrets: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() { _ = store.Close() }()
+
+	action := "list"
+	if len(args) > 0 {
+		action, args = args[0], args[1:]
+	}
+
+	switch action {
+	case "list":
+		handleSecretsList(store, args)
+	case "set":
+		handleSecretsSet(store, args)
+	case "delete":
+		handleSecretsDelete(store, args)
+	case "help", "--help", "-h":
+		printSecretsUsage()
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown secrets command: %s\n\n", action)
+		printSecretsUsage()
+		os.Exit(1)
+	}
+}
+
+func handleSecretsList(store *secrets.Store, args []string) {
+	fs := flag.NewFlagSet("secrets list", flag.ExitOnError)
+	format := formatFlag(fs)
+	_ = fs.Parse(args)
+	checkFormat(*format)
+
+	list, err := store.List()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to list secrets: %v\n", err)
+		os.Exit(1)
+	}
+	if structured(*format) {
+		printStructured(*format, list)
+		return
+	}
+	if len(list) == 0 {
+		fmt.Println("No secrets. Store one with: newsfed secrets set <name>")
+		return
+	}
+	for _, secret := range list {
+		fmt.Printf("%s updated %s\n", padRight(secret.Name, 24), secret.UpdatedAt.Local().Format("2006-01-02 15:04:05"))
+	}
+}
+
+func handleSecretsSet(store *secrets.Store, args []string) {
+	if len(args) != 1 {
+		fmt.Fprintf(os.Stderr, "Usage: newsfed secrets set <name>\n")
+		os.Exit(1)
+	}
+	name := args[0]
+	if err := secrets.ValidateName(name); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if !store.HasMasterKey() {
+		fmt.Fprintf(os.Stderr, "Error: no master key; set NEWSFED_MASTER_KEY or store one in the OS keychain\n")
+		fmt.Fprintf(os.Stderr, "Generate one with: openssl rand -base64 32\n")
+		os.Exit(1)
+	}
+
+	value, err := readSecretValue(name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to read secret: %v\n", err)
+		os.Exit(1)
+	}
+	if err := store.Set(name, value); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("✓ Stored secret %s\n", name)
+	fmt.Printf("  Refer to it in a source as: %s%s\n", secrets.RefPrefix, name)
+}
+
+// readSecretValue reads a secret's value without echoing it when stdin is a
+// terminal, or as all of stdin, less a trailing newline, when it's piped,
+// so that the value stays out of the shell history.
+func readSecretValue(name string) (string, error) {
+	if term.IsTerminal(os.Stdin.Fd()) {
+		fmt.Fprintf(os.Stderr, "Value for %s: ", name)
+		data, err := term.ReadPassword(os.Stdin.Fd())
+		fmt.Fprintln(os.Stderr)
+		return string(data), err
+	}
+	data, err := io.ReadAll(os.Stdin)
+	return strings.TrimRight(string(data), "\r\n"), err
+}
+
+func handleSecretsDelete(store *secrets.Store, args []string) {
+	if len(args) != 1 {
+		fmt.Fprintf(os.Stderr, "Usage: newsfed secrets delete <name>\n")
+		os.Exit(1)
+	}
+
+	if err := store.Delete(args[0]); err != nil {
+		if errors.Is(err, secrets.ErrNotFound) {
+			fmt.Fprintf(os.Stderr, "Error: secret not found: %s\n", args[0])
+		} else {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		os.Exit(1)
+	}
+	fmt.Printf("✓ Deleted secret %s\n", args[0])
+}
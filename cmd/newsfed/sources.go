@@ -1,15 +1,26 @@
 package main
 
 import (
+	"bytes"
+	"cmp"
 	"context"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"maps"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/pevans/newsfed/discovery"
+	"github.com/pevans/newsfed/newsfeed"
+	"github.com/pevans/newsfed/secrets"
 	"github.com/pevans/newsfed/sources"
 )
 
@@ -24,26 +35,44 @@ func printSourcesUsage() {
 	fmt.Println("  show       Show detailed source information")
 	fmt.Println("  add        Add a new source")
 	fmt.Println("  update     Update source configuration")
-	fmt.Println("  delete     Delete a source")
-	fmt.Println("  enable     Enable a source")
-	fmt.Println("  disable    Disable a source")
+	fmt.Println("  delete     Delete one or more sources")
+	fmt.Println("  enable     Enable one or more sources")
+	fmt.Println("  disable    Disable one or more sources")
 	fmt.Println("  status     Check source health")
 	fmt.Println("  errors     View error history for a source")
+	fmt.Println("  history    View sync history for a source")
+	fmt.Println("  stats      Show how many items sources produce and how often they fail")
+	fmt.Println("  session    Set the cookies or login a website source is scraped with")
+	fmt.Println("  discover   Find the feeds published by a website")
+	fmt.Println("  categories List, add, or delete source categories")
 	fmt.Println("  help       Show this help message")
 }
 
 func handleSourcesList(metadataStore *sources.SourceStore, args []string) {
 	// Parse flags for list command
 	fs := flag.NewFlagSet("sources list", flag.ExitOnError)
+	category := fs.String("category", "", "Show only sources in this category")
+	format := formatFlag(fs)
 	_ = fs.Parse(args)
+	checkFormat(*format)
 
-	// Get all sources
-	sourceList, err := metadataStore.ListSources(sources.SourceFilter{})
+	filter := sources.SourceFilter{}
+	if *category != "" {
+		filter.Category = category
+	}
+
+	// Get sources
+	sourceList, err := metadataStore.ListSources(filter)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: failed to list sources: %v\n", err)
 		os.Exit(1)
 	}
 
+	if structured(*format) {
+		printStructured(*format, redactSources(sourceList))
+		return
+	}
+
 	if len(sourceList) == 0 {
 		fmt.Println("No sources configured.")
 		return
@@ -56,25 +85,21 @@ func handleSourcesList(metadataStore *sources.SourceStore, args []string) {
 	// Print each source
 	for _, source := range sourceList {
 		// Truncate name and URL if too long
-		name := source.Name
-		if len(name) > 50 {
-			name = name[:47] + "..."
-		}
-		url := source.URL
-		if len(url) > 50 {
-			url = url[:47] + "..."
-		}
-
-		fmt.Printf("%-36s %-10s %-50s %s\n",
+		fmt.Printf("%-36s %-10s %s %s\n",
 			source.SourceID.String(),
 			source.SourceType,
-			name,
-			url,
+			fitColumn(source.Name, 50),
+			truncate(source.URL, 50),
 		)
 	}
 }
 
 func handleSourcesShow(metadataStore *sources.SourceStore, args []string) {
+	fs := flag.NewFlagSet("sources show", flag.ExitOnError)
+	format := formatFlag(fs)
+	args = parseInterspersed(fs, args)
+	checkFormat(*format)
+
 	if len(args) < 1 {
 		fmt.Fprintf(os.Stderr, "Error: source ID is required\n")
 		fmt.Fprintf(os.Stderr, "Usage: newsfed sources show <source-id>\n")
@@ -83,12 +108,7 @@ func handleSourcesShow(metadataStore *sources.SourceStore, args []string) {
 
 	sourceID := args[0]
 
-	// Parse UUID
-	id, err := uuid.Parse(sourceID)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: invalid source ID: %v\n", err)
-		os.Exit(1)
-	}
+	id := resolveSourceID(metadataStore, sourceID)
 
 	// Get the source
 	source, err := metadataStore.GetSource(id)
@@ -97,6 +117,11 @@ func handleSourcesShow(metadataStore *sources.SourceStore, args []string) {
 		os.Exit(1)
 	}
 
+	if structured(*format) {
+		printStructured(*format, source.Redacted())
+		return
+	}
+
 	// Display the source
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 	fmt.Println(source.Name)
@@ -106,11 +131,22 @@ func handleSourcesShow(metadataStore *sources.SourceStore, args []string) {
 	// Basic info
 	fmt.Printf("Type:        %s\n", source.SourceType)
 	fmt.Printf("URL:         %s\n", source.URL)
+	if source.Category != nil {
+		fmt.Printf("Category:    %s\n", *source.Category)
+	}
+	if source.Language != nil {
+		fmt.Printf("Language:    %s\n", *source.Language)
+	}
+	if source.TimeZone != nil {
+		fmt.Printf("Time Zone:   %s\n", *source.TimeZone)
+	}
 	fmt.Println()
 
 	// Status
 	if source.EnabledAt != nil {
 		fmt.Printf("Status:      ✓ Enabled (since %s)\n", source.EnabledAt.Format("2006-01-02 15:04:05"))
+	} else if source.ReprobeAt != nil {
+		fmt.Printf("Status:      ✗ Disabled (re-probing at %s)\n", source.ReprobeAt.Format("2006-01-02 15:04:05"))
 	} else {
 		fmt.Println("Status:      ✗ Disabled")
 	}
@@ -123,12 +159,27 @@ func handleSourcesShow(metadataStore *sources.SourceStore, args []string) {
 	} else {
 		fmt.Println("  Last Fetched:    Never")
 	}
+	if source.NextFetchAt != nil {
+		fmt.Printf("  Backoff Until:   %s\n", source.NextFetchAt.Format("2006-01-02 15:04:05"))
+	}
+	if worker := sources.ClaimHolder(*source, time.Now()); worker != "" {
+		fmt.Printf("  Claimed By:      %s (until %s)\n", worker, source.ClaimExpiresAt.Format("2006-01-02 15:04:05"))
+	}
 
 	if source.PollingInterval != nil {
 		fmt.Printf("  Poll Interval:   %s\n", *source.PollingInterval)
 	} else {
 		fmt.Println("  Poll Interval:   Default")
 	}
+	if source.PostingCadence != nil {
+		fmt.Printf("  Posting Cadence: about every %s\n", *source.PostingCadence)
+	}
+	if source.PollWindow != nil {
+		fmt.Printf("  Poll Window:     %s\n", source.PollWindow)
+	}
+	if source.FetchBudget != nil {
+		fmt.Printf("  Fetch Budget:    %s per sync\n", source.FetchBudget)
+	}
 	fmt.Println()
 
 	// Health status
@@ -153,10 +204,86 @@ func handleSourcesShow(metadataStore *sources.SourceStore, args []string) {
 		fmt.Println()
 	}
 
+	// Custom request settings; header values may hold credentials, so only
+	// names are shown
+	if source.UserAgent != nil || len(source.HTTPHeaders) > 0 || source.ProxyURL != nil {
+		fmt.Println("HTTP Request:")
+		if source.UserAgent != nil {
+			fmt.Printf("  User-Agent:      %s\n", *source.UserAgent)
+		}
+		if source.ProxyURL != nil {
+			fmt.Printf("  Proxy:           %s\n", *source.Redacted().ProxyURL)
+		}
+		for _, name := range slices.Sorted(maps.Keys(source.HTTPHeaders)) {
+			fmt.Printf("  %-16s %s\n", name+":", redactValue(source.HTTPHeaders[name]))
+		}
+		fmt.Println()
+	}
+
+	// TLS settings
+	if source.TLS != nil {
+		fmt.Println("TLS:")
+		if source.TLS.CAFile != "" {
+			fmt.Printf("  CA File:         %s\n", source.TLS.CAFile)
+		}
+		if source.TLS.MinVersion != "" {
+			fmt.Printf("  Min Version:     TLS %s\n", source.TLS.MinVersion)
+		}
+		if source.TLS.InsecureSkipVerify {
+			fmt.Println("  Verification:    ⚠ Disabled")
+		}
+		fmt.Println()
+	}
+
+	// Ingest rules
+	if source.IngestRules != nil {
+		rules := source.IngestRules
+		fmt.Println("Ingest Rules:")
+		if rules.MaxItems > 0 {
+			fmt.Printf("  Max Items:       %d per sync\n", rules.MaxItems)
+		}
+		if rules.MinContentLength > 0 {
+			fmt.Printf("  Min Length:      %d characters\n", rules.MinContentLength)
+		}
+		if rules.MaxAge != "" {
+			fmt.Printf("  Max Age:         %s\n", rules.MaxAge)
+		}
+		if rules.Dedup != "" {
+			fmt.Printf("  Dedup:           %s\n", rules.Dedup)
+		}
+		for _, pattern := range rules.IncludeURLs {
+			fmt.Printf("  Include URL:     %s\n", pattern)
+		}
+		for _, pattern := range rules.ExcludeURLs {
+			fmt.Printf("  Exclude URL:     %s\n", pattern)
+		}
+		for _, keyword := range rules.IncludeKeywords {
+			fmt.Printf("  Include Keyword: %s\n", keyword)
+		}
+		for _, keyword := range rules.ExcludeKeywords {
+			fmt.Printf("  Exclude Keyword: %s\n", keyword)
+		}
+		fmt.Println()
+	}
+
+	// Hacker News settings
+	if source.HackerNews != nil {
+		fmt.Println("Hacker News:")
+		fmt.Printf("  Stories:         %s\n", source.HackerNews.StoryType)
+		fmt.Printf("  Minimum Score:   %d\n", source.HackerNews.MinScore)
+		fmt.Println()
+	}
+
 	// Scraper config (for website sources)
 	if source.ScraperConfig != nil {
 		fmt.Println("Scraper Configuration:")
 		fmt.Printf("  Discovery Mode:     %s\n", source.ScraperConfig.DiscoveryMode)
+		if source.ScraperConfig.RenderJS {
+			fmt.Printf("  Render JS:          yes\n")
+		}
+		if source.HasSession {
+			fmt.Printf("  Session:            stored (encrypted)\n")
+		}
 		if source.ScraperConfig.ListConfig != nil {
 			fmt.Printf("  Article Selector:   %s\n", source.ScraperConfig.ListConfig.ArticleSelector)
 			if source.ScraperConfig.ListConfig.PaginationSelector != "" {
@@ -164,14 +291,21 @@ func handleSourcesShow(metadataStore *sources.SourceStore, args []string) {
 			}
 			fmt.Printf("  Max Pages:          %d\n", source.ScraperConfig.ListConfig.MaxPages)
 		}
-		fmt.Printf("  Title Selector:     %s\n", source.ScraperConfig.ArticleConfig.TitleSelector)
-		fmt.Printf("  Content Selector:   %s\n", source.ScraperConfig.ArticleConfig.ContentSelector)
+		if source.ScraperConfig.ArticleConfig.UsesAutoExtraction() {
+			fmt.Printf("  Extraction:         auto\n")
+		} else {
+			fmt.Printf("  Title Selector:     %s\n", source.ScraperConfig.ArticleConfig.TitleSelector)
+			fmt.Printf("  Content Selector:   %s\n", source.ScraperConfig.ArticleConfig.ContentSelector)
+		}
 		if source.ScraperConfig.ArticleConfig.AuthorSelector != "" {
 			fmt.Printf("  Author Selector:    %s\n", source.ScraperConfig.ArticleConfig.AuthorSelector)
 		}
 		if source.ScraperConfig.ArticleConfig.DateSelector != "" {
 			fmt.Printf("  Date Selector:      %s\n", source.ScraperConfig.ArticleConfig.DateSelector)
 		}
+		if source.ScraperConfig.ArticleConfig.IgnoreMetadata {
+			fmt.Printf("  Page Metadata:      ignored\n")
+		}
 		fmt.Println()
 	}
 
@@ -187,11 +321,67 @@ func handleSourcesShow(metadataStore *sources.SourceStore, args []string) {
 func handleSourcesAdd(metadataStore *sources.SourceStore, args []string) {
 	// Parse flags for add command
 	fs := flag.NewFlagSet("sources add", flag.ExitOnError)
-	sourceType := fs.String("type", "", "Source type (rss, atom, or website); omit to autodiscover")
+	sourceType := fs.String("type", "", "Source type (rss, atom, json, website, or hackernews); omit to autodiscover")
 	url := fs.String("url", "", "Source URL")
 	name := fs.String("name", "", "Source name (optional when autodiscovering)")
 	configFile := fs.String("config", "", "Scraper config file (for website sources)")
+	userAgent := fs.String("user-agent", "", "User-Agent to send when fetching this source")
+	headers := headerFlag{}
+	fs.Var(headers, "header", "Extra HTTP header as \"Name: Value\" (repeatable)")
+	proxy := fs.String("proxy", "", "Proxy to fetch this source through (e.g., socks5h://127.0.0.1:9050)")
+	caFile := fs.String("ca-file", "", "PEM file of extra CA certificates to trust for this source")
+	insecure := fs.Bool("insecure-skip-verify", false, "Accept any TLS certificate from this source")
+	tlsMinVersion := fs.String("tls-min-version", "", "Minimum TLS version for this source (1.0, 1.1, 1.2, or 1.3)")
+	category := fs.String("category", "", "Category to group the source under")
+	lang := fs.String("lang", "", "Language the source's items are expected in, as an ISO 639-1 code (e.g., en)")
+	timeZone := fs.String("time-zone", "", "Time zone of published dates that don't give one (e.g., America/New_York; default: UTC)")
+	story := fs.String("story", "top", "Story type for hackernews sources (top, new, or best)")
+	minScore := fs.Int("min-score", 0, "Skip Hacker News stories with fewer points")
+	ingestFlags := addIngestRuleFlags(fs)
+	windowFlags := addPollWindowFlags(fs)
+	budgetFlags := addFetchBudgetFlags(fs)
+	format := formatFlag(fs)
 	_ = fs.Parse(args)
+	checkFormat(*format)
+
+	var ingestRules sources.IngestRules
+	ingestFlags.apply(fs, &ingestRules)
+	if err := ingestRules.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	var pollWindow sources.PollWindow
+	if windowFlags.set() {
+		if err := windowFlags.apply(&pollWindow); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	var fetchBudget sources.FetchBudget
+	if err := budgetFlags.apply(fs, &fetchBudget); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Hacker News sources read from the API, so their URL and name default
+	// to the page that lists the chosen stories
+	var hackerNews *sources.HackerNewsConfig
+	if *sourceType == "hackernews" {
+		pageURL := discovery.HackerNewsPageURL(*story)
+		if pageURL == "" {
+			fmt.Fprintf(os.Stderr, "Error: -story must be 'top', 'new', or 'best'\n")
+			os.Exit(1)
+		}
+		if *url == "" {
+			*url = pageURL
+		}
+		if *name == "" {
+			*name = fmt.Sprintf("Hacker News (%s)", *story)
+		}
+		hackerNews = &sources.HackerNewsConfig{StoryType: *story, MinScore: *minScore}
+	}
 
 	// URL is always required
 	if *url == "" {
@@ -200,6 +390,46 @@ func handleSourcesAdd(metadataStore *sources.SourceStore, args []string) {
 		os.Exit(1)
 	}
 
+	if *proxy != "" {
+		if err := sources.ValidateProxyURL(*proxy); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid -proxy: %v\n", err)
+			os.Exit(1)
+		}
+		// Autodiscovery does not go through the source's proxy
+		if *sourceType == "" {
+			fmt.Fprintf(os.Stderr, "Error: -type is required with -proxy\n")
+			os.Exit(1)
+		}
+	}
+
+	*lang = strings.ToLower(*lang)
+	if *lang != "" {
+		if err := sources.ValidateLanguage(*lang); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid -lang: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if *timeZone != "" {
+		if err := sources.ValidateTimeZone(*timeZone); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid -time-zone: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	tlsSettings := sources.TLSConfig{CAFile: *caFile, InsecureSkipVerify: *insecure, MinVersion: *tlsMinVersion}
+	if !tlsSettings.IsZero() {
+		if err := validateTLSSettings(&tlsSettings); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		// Autodiscovery does not use the source's TLS settings
+		if *sourceType == "" {
+			fmt.Fprintf(os.Stderr, "Error: -type is required with -ca-file, -insecure-skip-verify, or -tls-min-version\n")
+			os.Exit(1)
+		}
+	}
+
 	var scraperConfig *discovery.ScraperConfig
 
 	if *sourceType == "" {
@@ -222,12 +452,12 @@ func handleSourcesAdd(metadataStore *sources.SourceStore, args []string) {
 		// succeeds -- even through a redirect -- the user's URL is itself a
 		// feed and no notice is needed.
 		if !result.FoundDirect && result.FeedURL != originalURL {
-			fmt.Printf("Discovered %s feed at %s\n", feedTypeName(result.FeedType), result.FeedURL)
+			fmt.Fprintf(noticeWriter(*format), "Discovered %s feed at %s\n", feedTypeName(result.FeedType), result.FeedURL)
 		}
 	} else {
 		// Explicit type path -- validate type and require --name
-		if *sourceType != "rss" && *sourceType != "atom" && *sourceType != "website" {
-			fmt.Fprintf(os.Stderr, "Error: -type must be 'rss', 'atom', or 'website'\n")
+		if *sourceType != "rss" && *sourceType != "atom" && *sourceType != "json" && *sourceType != "website" && *sourceType != "hackernews" {
+			fmt.Fprintf(os.Stderr, "Error: -type must be 'rss', 'atom', 'json', 'website', or 'hackernews'\n")
 			os.Exit(1)
 		}
 		if *name == "" {
@@ -265,12 +495,103 @@ func handleSourcesAdd(metadataStore *sources.SourceStore, args []string) {
 		os.Exit(1)
 	}
 
+	// Apply request settings
+	if *userAgent != "" || len(headers) > 0 || *proxy != "" || !tlsSettings.IsZero() {
+		update := sources.SourceUpdate{HTTPHeaders: headers}
+		if *userAgent != "" {
+			update.UserAgent = userAgent
+		}
+		if *proxy != "" {
+			update.ProxyURL = proxy
+		}
+		if !tlsSettings.IsZero() {
+			update.TLS = &tlsSettings
+		}
+		if err := metadataStore.UpdateSource(source.SourceID, update); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to set request headers: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if *category != "" {
+		if err := metadataStore.UpdateSource(source.SourceID, sources.SourceUpdate{Category: category}); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to set category: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if *lang != "" {
+		if err := metadataStore.UpdateSource(source.SourceID, sources.SourceUpdate{Language: lang}); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to set language: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if *timeZone != "" {
+		if err := metadataStore.UpdateSource(source.SourceID, sources.SourceUpdate{TimeZone: timeZone}); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to set time zone: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if hackerNews != nil {
+		if err := metadataStore.UpdateSource(source.SourceID, sources.SourceUpdate{HackerNews: hackerNews}); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to set Hacker News settings: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if !ingestRules.IsZero() {
+		if err := metadataStore.UpdateSource(source.SourceID, sources.SourceUpdate{IngestRules: &ingestRules}); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to set ingest rules: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if !pollWindow.IsZero() {
+		if err := metadataStore.UpdateSource(source.SourceID, sources.SourceUpdate{PollWindow: &pollWindow}); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to set polling window: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if !fetchBudget.IsZero() {
+		if err := metadataStore.UpdateSource(source.SourceID, sources.SourceUpdate{FetchBudget: &fetchBudget}); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to set fetch budget: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if tlsSettings.InsecureSkipVerify {
+		defer fmt.Fprintf(os.Stderr, "Warning: TLS certificate verification is disabled for this source\n")
+	}
+
+	if structured(*format) {
+		// The source is read again to include the settings applied above
+		created, err := metadataStore.GetSource(source.SourceID)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to get source: %v\n", err)
+			os.Exit(1)
+		}
+		printStructured(*format, created.Redacted())
+		return
+	}
+
 	fmt.Printf("Created source: %s (%s)\n", source.Name, source.SourceType)
 	fmt.Printf("  ID: %s\n", source.SourceID.String())
 	fmt.Printf("  URL: %s\n", source.URL)
+	if *category != "" {
+		fmt.Printf("  Category: %s\n", *category)
+	}
 	if scraperConfig != nil {
 		fmt.Println("  Scraper: Configured")
 	}
+	if hackerNews != nil {
+		fmt.Printf("  Stories: %s (minimum score %d)\n", hackerNews.StoryType, hackerNews.MinScore)
+	}
+	if !pollWindow.IsZero() {
+		fmt.Printf("  Poll Window: %s\n", pollWindow)
+	}
 }
 
 func handleSourcesUpdate(metadataStore *sources.SourceStore, args []string) {
@@ -282,23 +603,82 @@ func handleSourcesUpdate(metadataStore *sources.SourceStore, args []string) {
 
 	sourceID := args[0]
 
-	// Parse UUID
-	id, err := uuid.Parse(sourceID)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: invalid source ID: %v\n", err)
-		os.Exit(1)
-	}
+	id := resolveSourceID(metadataStore, sourceID)
 
 	// Parse flags for update command
 	fs := flag.NewFlagSet("sources update", flag.ExitOnError)
 	name := fs.String("name", "", "Update source name")
 	interval := fs.String("interval", "", "Update polling interval (e.g., 30m, 1h)")
 	configFile := fs.String("config", "", "Update scraper config file (for website sources)")
+	userAgent := fs.String("user-agent", "", "Set the User-Agent sent when fetching this source")
+	clearUserAgent := fs.Bool("clear-user-agent", false, "Use the default User-Agent")
+	headers := headerFlag{}
+	fs.Var(headers, "header", "Set an HTTP header as \"Name: Value\" (repeatable)")
+	clearHeaders := fs.Bool("clear-headers", false, "Remove all custom HTTP headers")
+	proxy := fs.String("proxy", "", "Fetch this source through a proxy (e.g., socks5h://127.0.0.1:9050)")
+	clearProxy := fs.Bool("clear-proxy", false, "Use the default proxy")
+	caFile := fs.String("ca-file", "", "Trust the CA certificates in this PEM file for this source")
+	insecure := fs.Bool("insecure-skip-verify", false, "Accept any TLS certificate from this source (=false to verify again)")
+	tlsMinVersion := fs.String("tls-min-version", "", "Set the minimum TLS version (1.0, 1.1, 1.2, or 1.3)")
+	clearTLS := fs.Bool("clear-tls", false, "Go back to the default TLS settings")
+	category := fs.String("category", "", "Move the source into this category")
+	clearCategory := fs.Bool("clear-category", false, "Remove the source from its category")
+	lang := fs.String("lang", "", "Set the language the source's items are expected in (e.g., en)")
+	clearLang := fs.Bool("clear-lang", false, "Remove the expected language")
+	timeZone := fs.String("time-zone", "", "Set the time zone of published dates that don't give one (e.g., America/New_York)")
+	clearTimeZone := fs.Bool("clear-time-zone", false, "Read published dates without a time zone as UTC")
+	story := fs.String("story", "", "Update the story type of a hackernews source (top, new, or best)")
+	minScore := fs.Int("min-score", 0, "Update the minimum score of a hackernews source")
+	ingestFlags := addIngestRuleFlags(fs)
+	clearIngestRules := fs.Bool("clear-ingest-rules", false, "Remove all ingest rules")
+	windowFlags := addPollWindowFlags(fs)
+	clearWindow := fs.Bool("clear-window", false, "Fetch the source at any time of day")
+	budgetFlags := addFetchBudgetFlags(fs)
+	clearBudget := fs.Bool("clear-budget", false, "Remove the fetch budget")
+	format := formatFlag(fs)
 	_ = fs.Parse(args[1:])
+	checkFormat(*format)
+
+	minScoreSet := false
+	insecureSet := false
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "min-score":
+			minScoreSet = true
+		case "insecure-skip-verify":
+			insecureSet = true
+		}
+	})
+
+	if *category != "" && *clearCategory {
+		fmt.Fprintf(os.Stderr, "Error: -category and -clear-category cannot be used together\n")
+		os.Exit(1)
+	}
+	if *lang != "" && *clearLang {
+		fmt.Fprintf(os.Stderr, "Error: -lang and -clear-lang cannot be used together\n")
+		os.Exit(1)
+	}
+	if *timeZone != "" && *clearTimeZone {
+		fmt.Fprintf(os.Stderr, "Error: -time-zone and -clear-time-zone cannot be used together\n")
+		os.Exit(1)
+	}
 
 	// Check if any updates were provided
-	if *name == "" && *interval == "" && *configFile == "" {
-		fmt.Fprintf(os.Stderr, "Error: at least one update flag is required (-name, -interval, or -config)\n")
+	requestUpdate := *userAgent != "" || *clearUserAgent || len(headers) > 0 || *clearHeaders || *proxy != "" || *clearProxy
+	tlsUpdate := *caFile != "" || insecureSet || *tlsMinVersion != "" || *clearTLS
+	categoryUpdate := *category != "" || *clearCategory
+	langUpdate := *lang != "" || *clearLang
+	timeZoneUpdate := *timeZone != "" || *clearTimeZone
+	hackerNewsUpdate := *story != "" || minScoreSet
+	ingestUpdate := ingestFlags.set(fs) || *clearIngestRules
+	windowUpdate := windowFlags.set() || *clearWindow
+	budgetUpdate := budgetFlags.set(fs) || *clearBudget
+	if *name == "" && *interval == "" && *configFile == "" && !requestUpdate && !tlsUpdate && !categoryUpdate && !langUpdate && !timeZoneUpdate && !hackerNewsUpdate && !ingestUpdate && !windowUpdate && !budgetUpdate {
+		fmt.Fprintf(os.Stderr, "Error: at least one update flag is required (-name, -interval, -config, -user-agent, -header, -proxy, -ca-file, -insecure-skip-verify, -tls-min-version, -category, -lang, -time-zone, -story, -min-score, -window, -window-cron, -window-tz, a budget flag, or an ingest rule flag)\n")
+		os.Exit(1)
+	}
+	if windowFlags.set() && *clearWindow {
+		fmt.Fprintf(os.Stderr, "Error: -clear-window cannot be used with -window, -window-cron, or -window-tz\n")
 		os.Exit(1)
 	}
 
@@ -335,13 +715,216 @@ func handleSourcesUpdate(metadataStore *sources.SourceStore, args []string) {
 		update.ScraperConfig = scraperConfig
 	}
 
+	if *clearUserAgent {
+		empty := ""
+		update.UserAgent = &empty
+	} else if *userAgent != "" {
+		update.UserAgent = userAgent
+	}
+
+	if *proxy != "" && *clearProxy {
+		fmt.Fprintf(os.Stderr, "Error: -proxy and -clear-proxy cannot be used together\n")
+		os.Exit(1)
+	}
+	if *clearProxy {
+		empty := ""
+		update.ProxyURL = &empty
+	} else if *proxy != "" {
+		update.ProxyURL = proxy
+	}
+
+	if tlsUpdate {
+		// New TLS settings are merged into the existing ones unless they are
+		// cleared
+		var tlsSettings sources.TLSConfig
+		if !*clearTLS {
+			existing, err := metadataStore.GetSource(id)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: failed to get source: %v\n", err)
+				os.Exit(1)
+			}
+			if existing.TLS != nil {
+				tlsSettings = *existing.TLS
+			}
+		}
+		if *caFile != "" {
+			tlsSettings.CAFile = *caFile
+		}
+		if insecureSet {
+			tlsSettings.InsecureSkipVerify = *insecure
+		}
+		if *tlsMinVersion != "" {
+			tlsSettings.MinVersion = *tlsMinVersion
+		}
+		if err := validateTLSSettings(&tlsSettings); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if tlsSettings.InsecureSkipVerify {
+			fmt.Fprintf(os.Stderr, "Warning: TLS certificate verification is disabled for this source\n")
+		}
+		update.TLS = &tlsSettings
+	}
+
+	if ingestUpdate {
+		// Rules given on the command line replace the matching existing
+		// ones unless all rules are cleared
+		var ingestRules sources.IngestRules
+		if !*clearIngestRules {
+			existing, err := metadataStore.GetSource(id)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: failed to get source: %v\n", err)
+				os.Exit(1)
+			}
+			if existing.IngestRules != nil {
+				ingestRules = *existing.IngestRules
+			}
+		}
+		ingestFlags.apply(fs, &ingestRules)
+		if err := ingestRules.Validate(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		update.IngestRules = &ingestRules
+	}
+
+	if windowUpdate {
+		// A new window replaces the existing one, whose time zone is kept
+		// unless another is given
+		var pollWindow sources.PollWindow
+		if !*clearWindow {
+			existing, err := metadataStore.GetSource(id)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: failed to get source: %v\n", err)
+				os.Exit(1)
+			}
+			if existing.PollWindow != nil {
+				pollWindow = *existing.PollWindow
+			}
+			if err := windowFlags.apply(&pollWindow); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		update.PollWindow = &pollWindow
+	}
+
+	if budgetUpdate {
+		// Limits given on the command line replace the matching existing
+		// ones unless the budget is cleared
+		var fetchBudget sources.FetchBudget
+		if !*clearBudget {
+			existing, err := metadataStore.GetSource(id)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: failed to get source: %v\n", err)
+				os.Exit(1)
+			}
+			if existing.FetchBudget != nil {
+				fetchBudget = *existing.FetchBudget
+			}
+		}
+		if err := budgetFlags.apply(fs, &fetchBudget); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		update.FetchBudget = &fetchBudget
+	}
+
+	if *clearHeaders || len(headers) > 0 {
+		// New headers are merged into the existing set unless it is cleared
+		merged := map[string]string{}
+		if !*clearHeaders {
+			existing, err := metadataStore.GetSource(id)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: failed to get source: %v\n", err)
+				os.Exit(1)
+			}
+			maps.Copy(merged, existing.HTTPHeaders)
+		}
+		maps.Copy(merged, headers)
+		update.HTTPHeaders = merged
+	}
+
+	if *clearCategory {
+		empty := ""
+		update.Category = &empty
+	} else if *category != "" {
+		update.Category = category
+	}
+
+	if *clearLang {
+		empty := ""
+		update.Language = &empty
+	} else if *lang != "" {
+		*lang = strings.ToLower(*lang)
+		if err := sources.ValidateLanguage(*lang); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid -lang: %v\n", err)
+			os.Exit(1)
+		}
+		update.Language = lang
+	}
+
+	if *clearTimeZone {
+		empty := ""
+		update.TimeZone = &empty
+	} else if *timeZone != "" {
+		if err := sources.ValidateTimeZone(*timeZone); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid -time-zone: %v\n", err)
+			os.Exit(1)
+		}
+		update.TimeZone = timeZone
+	}
+
+	var hackerNews sources.HackerNewsConfig
+	if hackerNewsUpdate {
+		existing, err := metadataStore.GetSource(id)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to get source: %v\n", err)
+			os.Exit(1)
+		}
+		if existing.SourceType != "hackernews" {
+			fmt.Fprintf(os.Stderr, "Error: -story and -min-score only apply to hackernews sources\n")
+			os.Exit(1)
+		}
+		hackerNews = sources.HackerNewsConfig{StoryType: "top"}
+		if existing.HackerNews != nil {
+			hackerNews = *existing.HackerNews
+		}
+		if *story != "" {
+			if discovery.HackerNewsPageURL(*story) == "" {
+				fmt.Fprintf(os.Stderr, "Error: -story must be 'top', 'new', or 'best'\n")
+				os.Exit(1)
+			}
+			// A source still pointing at its story page follows the new one
+			if existing.URL == discovery.HackerNewsPageURL(hackerNews.StoryType) {
+				pageURL := discovery.HackerNewsPageURL(*story)
+				update.URL = &pageURL
+			}
+			hackerNews.StoryType = *story
+		}
+		if minScoreSet {
+			hackerNews.MinScore = *minScore
+		}
+		update.HackerNews = &hackerNews
+	}
+
 	// Apply updates
-	err = metadataStore.UpdateSource(id, update)
+	err := metadataStore.UpdateSource(id, update)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: failed to update source: %v\n", err)
 		os.Exit(1)
 	}
 
+	if structured(*format) {
+		updated, err := metadataStore.GetSource(id)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to get source: %v\n", err)
+			os.Exit(1)
+		}
+		printStructured(*format, updated.Redacted())
+		return
+	}
+
 	fmt.Printf("✓ Updated source: %s\n", sourceID)
 	if *name != "" {
 		fmt.Printf("  Name: %s\n", *name)
@@ -352,126 +935,327 @@ func handleSourcesUpdate(metadataStore *sources.SourceStore, args []string) {
 	if *configFile != "" {
 		fmt.Println("  Scraper: Updated")
 	}
+	if requestUpdate {
+		fmt.Println("  Request headers: Updated")
+	}
+	if *clearCategory {
+		fmt.Println("  Category: None")
+	} else if *category != "" {
+		fmt.Printf("  Category: %s\n", *category)
+	}
+	if hackerNewsUpdate {
+		fmt.Printf("  Stories: %s (minimum score %d)\n", hackerNews.StoryType, hackerNews.MinScore)
+	}
 }
 
-func handleSourcesDelete(metadataStore *sources.SourceStore, args []string) {
-	if len(args) < 1 {
-		fmt.Fprintf(os.Stderr, "Error: source ID is required\n")
-		fmt.Fprintf(os.Stderr, "Usage: newsfed sources delete <source-id>\n")
+// deletedSource is a source removed by sources delete, as printed with
+// -format json or yaml.
+type deletedSource struct {
+	SourceID      uuid.UUID `json:"source_id"`
+	Name          string    `json:"name"`
+	ItemsDeleted  int       `json:"items_deleted"`
+	ItemsOrphaned int       `json:"items_orphaned"`
+}
+
+func handleSourcesDelete(metadataStore *sources.SourceStore, feedDSN string, args []string) {
+	fs := flag.NewFlagSet("sources delete", flag.ExitOnError)
+	allFailing := fs.Bool("all-failing", false, "Delete every source whose last fetch failed")
+	sourceType := fs.String("type", "", "Delete every source of this type")
+	force := fs.Bool("force", false, "Skip confirmation prompt")
+	items := fs.String("items", "keep", "What to do with the sources' items: keep, orphan (keep them without a source), or delete")
+	format := formatFlag(fs)
+	ids := parseInterspersed(fs, args)
+	checkFormat(*format)
+	notices := noticeWriter(*format)
+
+	if *items != "keep" && *items != "orphan" && *items != "delete" {
+		fmt.Fprintf(os.Stderr, "Error: invalid -items: %s (use keep, orphan, or delete)\n", *items)
 		os.Exit(1)
 	}
 
-	sourceID := args[0]
+	deleted := []deletedSource{}
+	selected := selectSources(metadataStore, "delete", ids, *allFailing, *sourceType)
+	if len(selected) == 0 {
+		fmt.Fprintln(notices, "No matching sources")
+		if structured(*format) {
+			printStructured(*format, deleted)
+		}
+		return
+	}
 
-	// Parse UUID
-	id, err := uuid.Parse(sourceID)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: invalid source ID: %v\n", err)
-		os.Exit(1)
+	// Sources picked by a selector are listed and confirmed, since the
+	// caller may not know exactly which ones match
+	if len(ids) == 0 && !*force {
+		for _, source := range selected {
+			fmt.Fprintf(notices, "%s  %s\n", source.SourceID, source.Name)
+		}
+		removed := "their error and sync history"
+		if *items == "delete" {
+			removed = "their items, error history, and sync history"
+		}
+		fmt.Fprintf(notices, "%d sources and %s will be removed. Are you certain you want to do this? [y/N]: ", len(selected), removed)
+
+		var response string
+		_, _ = fmt.Fscanln(os.Stdin, &response)
+		if response != "y" && response != "Y" {
+			fmt.Fprintln(notices, "Cancelled.")
+			if structured(*format) {
+				printStructured(*format, deleted)
+			}
+			return
+		}
 	}
 
-	// Delete the source
-	err = metadataStore.DeleteSource(id)
+	err := metadataStore.DeleteSources(sourceIDs(selected))
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: failed to delete source: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error: failed to delete sources: %v\n", err)
 		os.Exit(1)
 	}
 
-	fmt.Printf("✓ Deleted source: %s\n", sourceID)
-}
-
-func handleSourcesEnable(metadataStore *sources.SourceStore, args []string) {
-	if len(args) < 1 {
-		fmt.Fprintf(os.Stderr, "Error: source ID is required\n")
-		fmt.Fprintf(os.Stderr, "Usage: newsfed sources enable <source-id>\n")
-		os.Exit(1)
+	for _, source := range selected {
+		deleted = append(deleted, deletedSource{SourceID: source.SourceID, Name: source.Name})
+		if !structured(*format) {
+			fmt.Printf("✓ Deleted source: %s\n", source.SourceID)
+		}
 	}
 
-	sourceID := args[0]
-
-	// Parse UUID
-	id, err := uuid.Parse(sourceID)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: invalid source ID: %v\n", err)
-		os.Exit(1)
+	if *items != "keep" {
+		newsFeed, err := newsfeed.Open(feedDSN)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to open news feed: %v\n", err)
+			os.Exit(1)
+		}
+		defer func() { _ = newsFeed.Close() }()
+
+		for i, source := range selected {
+			var count int
+			if *items == "delete" {
+				count, err = newsFeed.DeleteSourceItems(source.SourceID)
+				deleted[i].ItemsDeleted = count
+			} else {
+				count, err = newsFeed.OrphanSourceItems(source.SourceID)
+				deleted[i].ItemsOrphaned = count
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: failed to %s items of source %s: %v\n", *items, source.SourceID, err)
+				os.Exit(1)
+			}
+			if structured(*format) {
+				continue
+			}
+			if *items == "delete" {
+				fmt.Printf("✓ Deleted %d items from source: %s\n", count, source.SourceID)
+			} else {
+				fmt.Printf("✓ Orphaned %d items from source: %s\n", count, source.SourceID)
+			}
+		}
 	}
 
-	// Get the source
-	source, err := metadataStore.GetSource(id)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: failed to get source: %v\n", err)
-		os.Exit(1)
+	if structured(*format) {
+		printStructured(*format, deleted)
 	}
+}
+
+func handleSourcesEnable(metadataStore *sources.SourceStore, args []string) {
+	fs := flag.NewFlagSet("sources enable", flag.ExitOnError)
+	allFailing := fs.Bool("all-failing", false, "Enable every source whose last fetch failed")
+	sourceType := fs.String("type", "", "Enable every source of this type")
+	format := formatFlag(fs)
+	ids := parseInterspersed(fs, args)
+	checkFormat(*format)
+	notices := noticeWriter(*format)
+
+	selected := selectSources(metadataStore, "enable", ids, *allFailing, *sourceType)
 
 	// Check if already enabled
-	if source.EnabledAt != nil {
-		fmt.Printf("Source is already enabled (enabled at: %s)\n", source.EnabledAt.Format("2006-01-02 15:04:05"))
+	if len(selected) == 1 && selected[0].EnabledAt != nil {
+		fmt.Fprintf(notices, "Source is already enabled (enabled at: %s)\n", selected[0].EnabledAt.Format("2006-01-02 15:04:05"))
+		if structured(*format) {
+			printStructured(*format, []sources.Source{})
+		}
 		return
 	}
 
-	// Enable the source
+	var pending []sources.Source
+	for _, source := range selected {
+		if source.EnabledAt == nil {
+			pending = append(pending, source)
+		}
+	}
+	if len(pending) == 0 {
+		fmt.Fprintln(notices, "No sources to enable")
+		if structured(*format) {
+			printStructured(*format, []sources.Source{})
+		}
+		return
+	}
+
+	// Enable the sources
 	now := time.Now().UTC()
+	zero := 0
 	update := sources.SourceUpdate{
-		EnabledAt: &now,
+		EnabledAt:        &now,
+		ClearNextFetchAt: true,
+		ClearReprobeAt:   true,
+		ReprobeCount:     &zero,
 	}
 
-	err = metadataStore.UpdateSource(id, update)
+	err := metadataStore.UpdateSources(sourceIDs(pending), update)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: failed to enable source: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error: failed to enable sources: %v\n", err)
 		os.Exit(1)
 	}
 
-	fmt.Printf("✓ Enabled source: %s\n", source.Name)
+	if structured(*format) {
+		printStructured(*format, redactSources(reloadSources(metadataStore, pending)))
+		return
+	}
+	for _, source := range pending {
+		fmt.Printf("✓ Enabled source: %s\n", source.Name)
+	}
 }
 
 func handleSourcesDisable(metadataStore *sources.SourceStore, args []string) {
-	if len(args) < 1 {
-		fmt.Fprintf(os.Stderr, "Error: source ID is required\n")
-		fmt.Fprintf(os.Stderr, "Usage: newsfed sources disable <source-id>\n")
-		os.Exit(1)
+	fs := flag.NewFlagSet("sources disable", flag.ExitOnError)
+	allFailing := fs.Bool("all-failing", false, "Disable every source whose last fetch failed")
+	sourceType := fs.String("type", "", "Disable every source of this type")
+	format := formatFlag(fs)
+	ids := parseInterspersed(fs, args)
+	checkFormat(*format)
+	notices := noticeWriter(*format)
+
+	selected := selectSources(metadataStore, "disable", ids, *allFailing, *sourceType)
+
+	// Check if already disabled. A source disabled after failures is
+	// still re-probed, and disabling it stops that.
+	if len(selected) == 1 && selected[0].EnabledAt == nil && selected[0].ReprobeAt == nil {
+		fmt.Fprintln(notices, "Source is already disabled")
+		if structured(*format) {
+			printStructured(*format, []sources.Source{})
+		}
+		return
 	}
 
-	sourceID := args[0]
+	var pending []sources.Source
+	for _, source := range selected {
+		if source.EnabledAt != nil || source.ReprobeAt != nil {
+			pending = append(pending, source)
+		}
+	}
+	if len(pending) == 0 {
+		fmt.Fprintln(notices, "No sources to disable")
+		if structured(*format) {
+			printStructured(*format, []sources.Source{})
+		}
+		return
+	}
 
-	// Parse UUID
-	id, err := uuid.Parse(sourceID)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: invalid source ID: %v\n", err)
-		os.Exit(1)
+	// Disable the sources
+	update := sources.SourceUpdate{
+		ClearEnabledAt: true,
+		ClearReprobeAt: true,
 	}
 
-	// Get the source
-	source, err := metadataStore.GetSource(id)
+	err := metadataStore.UpdateSources(sourceIDs(pending), update)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: failed to get source: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error: failed to disable sources: %v\n", err)
 		os.Exit(1)
 	}
 
-	// Check if already disabled
-	if source.EnabledAt == nil {
-		fmt.Println("Source is already disabled")
+	if structured(*format) {
+		printStructured(*format, redactSources(reloadSources(metadataStore, pending)))
 		return
 	}
-
-	// Disable the source
-	update := sources.SourceUpdate{
-		ClearEnabledAt: true,
+	for _, source := range pending {
+		fmt.Printf("✓ Disabled source: %s\n", source.Name)
 	}
+}
 
-	err = metadataStore.UpdateSource(id, update)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: failed to disable source: %v\n", err)
+// selectSources returns the sources a bulk action applies to: either the
+// sources named by ids, or those matching the -all-failing and -type
+// selectors. Naming sources and using selectors together is an error.
+func selectSources(metadataStore *sources.SourceStore, action string, ids []string, allFailing bool, sourceType string) []sources.Source {
+	usingSelectors := allFailing || sourceType != ""
+	if len(ids) == 0 && !usingSelectors {
+		fmt.Fprintf(os.Stderr, "Error: source ID is required\n")
+		fmt.Fprintf(os.Stderr, "Usage: newsfed sources %s <source-id>... | -all-failing | -type <type>\n", action)
+		os.Exit(1)
+	}
+	if len(ids) > 0 && usingSelectors {
+		fmt.Fprintf(os.Stderr, "Error: source IDs cannot be combined with -all-failing or -type\n")
 		os.Exit(1)
 	}
 
-	fmt.Printf("✓ Disabled source: %s\n", source.Name)
+	if usingSelectors {
+		filter := sources.SourceFilter{Failing: allFailing}
+		if sourceType != "" {
+			filter.Type = &sourceType
+		}
+		selected, err := metadataStore.ListSources(filter)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to list sources: %v\n", err)
+			os.Exit(1)
+		}
+		return selected
+	}
+
+	selected := make([]sources.Source, 0, len(ids))
+	for _, sourceID := range ids {
+		id := resolveSourceID(metadataStore, sourceID)
+
+		source, err := metadataStore.GetSource(id)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to get source %s: %v\n", sourceID, err)
+			os.Exit(1)
+		}
+		selected = append(selected, *source)
+	}
+	return selected
+}
+
+// reloadSources reads the given sources again, as they are after a change.
+func reloadSources(metadataStore *sources.SourceStore, list []sources.Source) []sources.Source {
+	reloaded := make([]sources.Source, 0, len(list))
+	for _, source := range list {
+		current, err := metadataStore.GetSource(source.SourceID)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to get source %s: %v\n", source.SourceID, err)
+			os.Exit(1)
+		}
+		reloaded = append(reloaded, *current)
+	}
+	return reloaded
+}
+
+// sourceIDs returns the IDs of the given sources.
+func sourceIDs(list []sources.Source) []uuid.UUID {
+	ids := make([]uuid.UUID, len(list))
+	for i, source := range list {
+		ids[i] = source.SourceID
+	}
+	return ids
+}
+
+// sourceHealth is the health of every source, as printed by sources status
+// with -format json or yaml.
+type sourceHealth struct {
+	Healthy       []sources.Source `json:"healthy"`
+	WithErrors    []sources.Source `json:"with_errors"`
+	NeverFetched  []sources.Source `json:"never_fetched"`
+	Stale         []sources.Source `json:"stale"`
+	Disabled      []sources.Source `json:"disabled"`
+	DiscoveryLags []sourceLag      `json:"discovery_lags"`
 }
 
 func handleSourcesStatus(metadataStore *sources.SourceStore, args []string) {
 	// Parse flags
 	fs := flag.NewFlagSet("sources status", flag.ExitOnError)
 	verbose := fs.Bool("verbose", false, "Show detailed error information")
+	color := colorFlag(fs)
+	format := formatFlag(fs)
 	_ = fs.Parse(args)
+	colors := mustPalette(*color)
+	checkFormat(*format)
 
 	// Get all sources to analyze
 	allSources, err := metadataStore.ListSources(sources.SourceFilter{})
@@ -480,7 +1264,7 @@ func handleSourcesStatus(metadataStore *sources.SourceStore, args []string) {
 		os.Exit(1)
 	}
 
-	if len(allSources) == 0 {
+	if len(allSources) == 0 && !structured(*format) {
 		fmt.Println("No sources configured.")
 		return
 	}
@@ -526,19 +1310,40 @@ func handleSourcesStatus(metadataStore *sources.SourceStore, args []string) {
 		healthy = append(healthy, source)
 	}
 
+	if structured(*format) {
+		printStructured(*format, sourceHealth{
+			Healthy:       redactSources(healthy),
+			WithErrors:    redactSources(withErrors),
+			NeverFetched:  redactSources(neverFetched),
+			Stale:         redactSources(stale),
+			Disabled:      redactSources(disabled),
+			DiscoveryLags: discoveryLags(metadataStore, allSources, now),
+		})
+		return
+	}
+
 	// Print summary
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-	fmt.Println("Source Health Status")
+	fmt.Println(colors.bold("Source Health Status"))
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 	fmt.Println()
 
-	fmt.Printf("✓ Healthy:          %d\n", len(healthy))
-	fmt.Printf("⚠ With Errors:      %d\n", len(withErrors))
-	fmt.Printf("⚠ Never Fetched:    %d\n", len(neverFetched))
-	fmt.Printf("⚠ Stale (>24h):     %d\n", len(stale))
-	fmt.Printf("✗ Disabled:         %d\n", len(disabled))
+	// Counts of unhealthy sources are colored only when there are some
+	countStyle := func(style func(string) string, n int) string {
+		if n == 0 {
+			return fmt.Sprint(n)
+		}
+		return style(fmt.Sprint(n))
+	}
+	fmt.Printf("%s          %s\n", colors.green("✓ Healthy:"), countStyle(colors.green, len(healthy)))
+	fmt.Printf("⚠ With Errors:      %s\n", countStyle(colors.red, len(withErrors)))
+	fmt.Printf("⚠ Never Fetched:    %s\n", countStyle(colors.yellow, len(neverFetched)))
+	fmt.Printf("⚠ Stale (>24h):     %s\n", countStyle(colors.yellow, len(stale)))
+	fmt.Printf("✗ Disabled:         %s\n", countStyle(colors.dim, len(disabled)))
 	fmt.Println()
 
+	printDiscoveryLags(discoveryLags(metadataStore, allSources, now))
+
 	// If everything is healthy, we can stop here
 	if len(withErrors) == 0 && len(neverFetched) == 0 && len(stale) == 0 && len(disabled) == 0 {
 		fmt.Println("All sources are healthy!")
@@ -547,22 +1352,18 @@ func handleSourcesStatus(metadataStore *sources.SourceStore, args []string) {
 
 	// Print sources with errors
 	if len(withErrors) > 0 {
-		fmt.Println("━━━ Sources with Errors ━━━")
+		fmt.Println(colors.bold("━━━ Sources with Errors ━━━"))
 		fmt.Println()
 		for _, source := range withErrors {
-			fmt.Printf("⚠ %s\n", source.Name)
+			fmt.Printf("%s\n", colors.red("⚠ "+source.Name))
 			fmt.Printf("  ID: %s\n", source.SourceID.String())
 			fmt.Printf("  URL: %s\n", source.URL)
-			fmt.Printf("  Error Count: %d\n", source.FetchErrorCount)
+			fmt.Printf("  Error Count: %s\n", colors.red(fmt.Sprint(source.FetchErrorCount)))
 			if source.LastError != nil && *verbose {
 				fmt.Printf("  Last Error: %s\n", *source.LastError)
 			} else if source.LastError != nil {
 				// Truncate error message if not verbose
-				errMsg := *source.LastError
-				if len(errMsg) > 80 {
-					errMsg = errMsg[:77] + "..."
-				}
-				fmt.Printf("  Last Error: %s\n", errMsg)
+				fmt.Printf("  Last Error: %s\n", truncate(*source.LastError, 80))
 			}
 			if source.LastFetchedAt != nil {
 				fmt.Printf("  Last Attempted: %s\n", source.LastFetchedAt.Format("2006-01-02 15:04:05"))
@@ -573,10 +1374,10 @@ func handleSourcesStatus(metadataStore *sources.SourceStore, args []string) {
 
 	// Print sources never fetched
 	if len(neverFetched) > 0 {
-		fmt.Println("━━━ Sources Never Fetched ━━━")
+		fmt.Println(colors.bold("━━━ Sources Never Fetched ━━━"))
 		fmt.Println()
 		for _, source := range neverFetched {
-			fmt.Printf("⚠ %s\n", source.Name)
+			fmt.Printf("%s\n", colors.yellow("⚠ "+source.Name))
 			fmt.Printf("  ID: %s\n", source.SourceID.String())
 			fmt.Printf("  URL: %s\n", source.URL)
 			fmt.Printf("  Created: %s\n", source.CreatedAt.Format("2006-01-02 15:04:05"))
@@ -586,10 +1387,10 @@ func handleSourcesStatus(metadataStore *sources.SourceStore, args []string) {
 
 	// Print stale sources
 	if len(stale) > 0 {
-		fmt.Println("━━━ Stale Sources (>24h since fetch) ━━━")
+		fmt.Println(colors.bold("━━━ Stale Sources (>24h since fetch) ━━━"))
 		fmt.Println()
 		for _, source := range stale {
-			fmt.Printf("⚠ %s\n", source.Name)
+			fmt.Printf("%s\n", colors.yellow("⚠ "+source.Name))
 			fmt.Printf("  ID: %s\n", source.SourceID.String())
 			fmt.Printf("  URL: %s\n", source.URL)
 			if source.LastFetchedAt != nil {
@@ -604,12 +1405,15 @@ func handleSourcesStatus(metadataStore *sources.SourceStore, args []string) {
 
 	// Print disabled sources
 	if len(disabled) > 0 {
-		fmt.Println("━━━ Disabled Sources ━━━")
+		fmt.Println(colors.bold("━━━ Disabled Sources ━━━"))
 		fmt.Println()
 		for _, source := range disabled {
-			fmt.Printf("✗ %s\n", source.Name)
+			fmt.Printf("%s\n", colors.dim("✗ "+source.Name))
 			fmt.Printf("  ID: %s\n", source.SourceID.String())
 			fmt.Printf("  URL: %s\n", source.URL)
+			if source.ReprobeAt != nil {
+				fmt.Printf("  Re-probe: %s\n", source.ReprobeAt.Local().Format("2006-01-02 15:04:05"))
+			}
 			if *verbose && source.LastError != nil {
 				fmt.Printf("  Last Error: %s\n", *source.LastError)
 			}
@@ -637,7 +1441,69 @@ func handleSourcesStatus(metadataStore *sources.SourceStore, args []string) {
 	fmt.Println()
 }
 
+// discoveryLagWindow is how far back `sources status` averages each
+// source's discovery lag.
+const discoveryLagWindow = 7 * 24 * time.Hour
+
+// sourceLag is the discovery lag of one source, as shown by sources status.
+type sourceLag struct {
+	SourceID        uuid.UUID     `json:"source_id"`
+	Name            string        `json:"name"`
+	Lag             time.Duration `json:"lag"`
+	PollingInterval *string       `json:"polling_interval,omitempty"`
+}
+
+// discoveryLags returns the average time between publication and discovery
+// of each enabled source's items, slowest first, alongside its polling
+// interval so that intervals can be tuned.
+func discoveryLags(metadataStore *sources.SourceStore, allSources []sources.Source, now time.Time) []sourceLag {
+	lags, err := metadataStore.DiscoveryLags(now.Add(-discoveryLagWindow))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to read discovery lag: %v\n", err)
+		return []sourceLag{}
+	}
+
+	measured := []sourceLag{}
+	for _, source := range allSources {
+		if lag, ok := lags[source.SourceID]; ok && source.IsEnabled() {
+			measured = append(measured, sourceLag{
+				SourceID:        source.SourceID,
+				Name:            source.Name,
+				Lag:             lag,
+				PollingInterval: source.PollingInterval,
+			})
+		}
+	}
+	slices.SortFunc(measured, func(a, b sourceLag) int {
+		return cmp.Compare(b.Lag, a.Lag)
+	})
+	return measured
+}
+
+// printDiscoveryLags lists the discovery lag of each source that has one.
+func printDiscoveryLags(measured []sourceLag) {
+	if len(measured) == 0 {
+		return
+	}
+
+	fmt.Println("━━━ Discovery Lag (last 7 days) ━━━")
+	fmt.Println()
+	for _, lag := range measured {
+		polling := "default polling interval"
+		if lag.PollingInterval != nil {
+			polling = "polled every " + *lag.PollingInterval
+		}
+		fmt.Printf("  %-8s %s (%s)\n", formatDuration(lag.Lag), lag.Name, polling)
+	}
+	fmt.Println()
+}
+
 func handleSourcesErrors(metadataStore *sources.SourceStore, args []string) {
+	fs := flag.NewFlagSet("sources errors", flag.ExitOnError)
+	format := formatFlag(fs)
+	args = parseInterspersed(fs, args)
+	checkFormat(*format)
+
 	if len(args) < 1 {
 		fmt.Fprintf(os.Stderr, "Error: source ID is required\n")
 		fmt.Fprintf(os.Stderr, "Usage: newsfed sources errors <source-id>\n")
@@ -646,12 +1512,7 @@ func handleSourcesErrors(metadataStore *sources.SourceStore, args []string) {
 
 	sourceID := args[0]
 
-	// Parse UUID
-	id, err := uuid.Parse(sourceID)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: invalid source ID: %v\n", err)
-		os.Exit(1)
-	}
+	id := resolveSourceID(metadataStore, sourceID)
 
 	// Get the source to verify it exists and show its name
 	source, err := metadataStore.GetSource(id)
@@ -667,6 +1528,14 @@ func handleSourcesErrors(metadataStore *sources.SourceStore, args []string) {
 		os.Exit(1)
 	}
 
+	if structured(*format) {
+		if errors == nil {
+			errors = []sources.SourceError{}
+		}
+		printStructured(*format, errors)
+		return
+	}
+
 	fmt.Printf("Error history for: %s\n", source.Name)
 	fmt.Printf("Source ID: %s\n", source.SourceID.String())
 	fmt.Println()
@@ -681,6 +1550,780 @@ func handleSourcesErrors(metadataStore *sources.SourceStore, args []string) {
 	}
 }
 
+func handleSourcesHistory(metadataStore *sources.SourceStore, args []string) {
+	fs := flag.NewFlagSet("sources history", flag.ExitOnError)
+	limit := fs.Int("limit", 20, "Maximum number of attempts to show")
+	format := formatFlag(fs)
+	positional := parseInterspersed(fs, args)
+	checkFormat(*format)
+
+	if len(positional) < 1 {
+		fmt.Fprintf(os.Stderr, "Error: source ID is required\n")
+		fmt.Fprintf(os.Stderr, "Usage: newsfed sources history [-limit N] <source-id>\n")
+		os.Exit(1)
+	}
+
+	id := resolveSourceID(metadataStore, positional[0])
+
+	// Get the source to verify it exists and show its name
+	source, err := metadataStore.GetSource(id)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to get source: %v\n", err)
+		os.Exit(1)
+	}
+
+	history, err := metadataStore.ListSyncHistory(id, *limit)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to list sync history: %v\n", err)
+		os.Exit(1)
+	}
+
+	if structured(*format) {
+		if history == nil {
+			history = []sources.SyncAttempt{}
+		}
+		printStructured(*format, history)
+		return
+	}
+
+	fmt.Printf("Sync history for: %s\n", source.Name)
+	fmt.Printf("Source ID: %s\n", source.SourceID.String())
+	fmt.Println()
+
+	if len(history) == 0 {
+		fmt.Println("No sync attempts recorded.")
+		return
+	}
+
+	succeeded := 0
+	for _, attempt := range history {
+		timestamp := attempt.StartedAt.Local().Format("2006-01-02 15:04:05")
+		if attempt.Reprobe {
+			timestamp += " re-probe"
+		}
+		if attempt.Error != nil {
+			fmt.Printf("[%s] ✗ failed after %v: %s\n", timestamp, attempt.Duration, *attempt.Error)
+			continue
+		}
+		succeeded++
+		fmt.Printf("[%s] ✓ %d new items in %v\n", timestamp, attempt.ItemsDiscovered, attempt.Duration)
+	}
+
+	fmt.Println()
+	fmt.Printf("%d of %d attempts succeeded\n", succeeded, len(history))
+}
+
+// handleSourcesStats shows how much each source produces and how often it
+// fails, from its sync history, or the same for one source in more detail.
+// Sources that produce least come first, as candidates for removal.
+func handleSourcesStats(metadataStore *sources.SourceStore, args []string) {
+	fs := flag.NewFlagSet("sources stats", flag.ExitOnError)
+	days := fs.Int("days", 30, "Number of days of sync history to summarize")
+	format := formatFlag(fs)
+	positional := parseInterspersed(fs, args)
+	checkFormat(*format)
+
+	if *days <= 0 {
+		fmt.Fprintf(os.Stderr, "Error: -days must be positive\n")
+		os.Exit(1)
+	}
+
+	var sourceList []sources.Source
+	if len(positional) > 0 {
+		id := resolveSourceID(metadataStore, positional[0])
+		source, err := metadataStore.GetSource(id)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to get source: %v\n", err)
+			os.Exit(1)
+		}
+		sourceList = []sources.Source{*source}
+	} else {
+		var err error
+		sourceList, err = metadataStore.ListSources(sources.SourceFilter{})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to list sources: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	now := time.Now()
+	allStats, err := metadataStore.SyncStats(*days, now)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to read sync history: %v\n", err)
+		os.Exit(1)
+	}
+	statsFor := func(source sources.Source) sources.SyncStats {
+		if st, ok := allStats[source.SourceID]; ok {
+			return *st
+		}
+		return sources.SyncStats{DailyItems: make([]int, *days)}
+	}
+
+	if len(positional) > 0 && structured(*format) {
+		source := sourceList[0]
+		printStructured(*format, newSourceStatsReport(source, statsFor(source), *days, now))
+		return
+	}
+	if len(positional) > 0 {
+		printSourceStats(sourceList[0], statsFor(sourceList[0]), *days, now)
+		return
+	}
+
+	if len(sourceList) == 0 && !structured(*format) {
+		fmt.Println("No sources configured.")
+		return
+	}
+	slices.SortFunc(sourceList, func(a, b sources.Source) int {
+		if c := cmp.Compare(itemsPerDay(a, statsFor(a), *days, now), itemsPerDay(b, statsFor(b), *days, now)); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Name, b.Name)
+	})
+
+	if structured(*format) {
+		reports := make([]sourceStatsReport, 0, len(sourceList))
+		for _, source := range sourceList {
+			reports = append(reports, newSourceStatsReport(source, statsFor(source), *days, now))
+		}
+		printStructured(*format, reports)
+		return
+	}
+
+	fmt.Printf("%-36s %-30s %9s %10s %9s %7s  %s\n", "ID", "NAME", "ITEMS/DAY", "ITEMS/WEEK", "PER FETCH", "ERRORS", fmt.Sprintf("LAST %d DAYS", *days))
+	fmt.Println(strings.Repeat("-", 108+*days))
+	for _, source := range sourceList {
+		st := statsFor(source)
+		errorRate := "-"
+		if st.Attempts > 0 {
+			errorRate = fmt.Sprintf("%.0f%%", st.ErrorRate()*100)
+		}
+		perDay := itemsPerDay(source, st, *days, now)
+		fmt.Printf("%-36s %s %9.1f %10.1f %9.1f %7s  %s\n",
+			source.SourceID.String(), fitColumn(source.Name, 30), perDay, perDay*7, st.ItemsPerFetch(), errorRate, sparkline(st.DailyItems))
+	}
+}
+
+// sourceStatsReport is the sync statistics of one source, as printed by
+// sources stats with -format json or yaml.
+type sourceStatsReport struct {
+	SourceID uuid.UUID `json:"source_id"`
+	Name     string    `json:"name"`
+	Days     int       `json:"days"`
+	sources.SyncStats
+	ItemsPerDay   float64 `json:"items_per_day"`
+	ItemsPerWeek  float64 `json:"items_per_week"`
+	ItemsPerFetch float64 `json:"items_per_fetch"`
+	ErrorRate     float64 `json:"error_rate"`
+}
+
+// newSourceStatsReport summarizes st, the sync statistics of source over
+// the last days.
+func newSourceStatsReport(source sources.Source, st sources.SyncStats, days int, now time.Time) sourceStatsReport {
+	perDay := itemsPerDay(source, st, days, now)
+	return sourceStatsReport{
+		SourceID:      source.SourceID,
+		Name:          source.Name,
+		Days:          days,
+		SyncStats:     st,
+		ItemsPerDay:   perDay,
+		ItemsPerWeek:  perDay * 7,
+		ItemsPerFetch: st.ItemsPerFetch(),
+		ErrorRate:     st.ErrorRate(),
+	}
+}
+
+// printSourceStats prints the sync statistics of one source.
+func printSourceStats(source sources.Source, st sources.SyncStats, days int, now time.Time) {
+	fmt.Printf("Statistics for: %s\n", source.Name)
+	fmt.Printf("Source ID: %s\n", source.SourceID.String())
+	fmt.Println()
+
+	if st.Attempts == 0 {
+		fmt.Printf("No sync attempts in the last %d days.\n", days)
+		return
+	}
+
+	perDay := itemsPerDay(source, st, days, now)
+	fmt.Printf("Last %d days:\n", days)
+	fmt.Printf("  Items:           %d\n", st.ItemsDiscovered)
+	fmt.Printf("  Items per Day:   %.1f\n", perDay)
+	fmt.Printf("  Items per Week:  %.1f\n", perDay*7)
+	fmt.Printf("  Items per Fetch: %.1f\n", st.ItemsPerFetch())
+	fmt.Printf("  Fetches:         %d (%d failed, %.1f%% error rate)\n", st.Attempts, st.Failures, st.ErrorRate()*100)
+	fmt.Println()
+	fmt.Printf("  %s  (new items per day, ending today)\n", sparkline(st.DailyItems))
+}
+
+// itemsPerDay returns the average number of new items a source found each
+// day over the last days, counting only the days since it was added.
+func itemsPerDay(source sources.Source, st sources.SyncStats, days int, now time.Time) float64 {
+	age := int(now.Sub(source.CreatedAt)/(24*time.Hour)) + 1
+	return float64(st.ItemsDiscovered) / float64(max(min(days, age), 1))
+}
+
+// sparkBlocks are the bars of a sparkline, from lowest to highest.
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline draws values as a row of bars scaled to the largest of them.
+func sparkline(values []int) string {
+	highest := 0
+	for _, v := range values {
+		highest = max(highest, v)
+	}
+	var b strings.Builder
+	for _, v := range values {
+		i := 0
+		if highest > 0 {
+			i = v * (len(sparkBlocks) - 1) / highest
+		}
+		b.WriteRune(sparkBlocks[i])
+	}
+	return b.String()
+}
+
+// handleSourcesSession shows, sets, or clears the session a website source
+// is scraped with. Sessions hold credentials, so only cookie names and the
+// login URL are ever shown.
+func handleSourcesSession(metadataStore *sources.SourceStore, metadataPath string, args []string) {
+	fs := flag.NewFlagSet("sources session", flag.ExitOnError)
+	file := fs.String("file", "", "Read the session from a JSON file (\"-\" for stdin)")
+	var cookies listFlag
+	fs.Var(&cookies, "cookie", "Send a cookie as \"name=value\" (repeatable)")
+	clearSession := fs.Bool("clear", false, "Remove the source's session")
+	format := formatFlag(fs)
+	positional := parseInterspersed(fs, args)
+	checkFormat(*format)
+
+	if len(positional) < 1 {
+		fmt.Fprintf(os.Stderr, "Error: source ID is required\n")
+		fmt.Fprintf(os.Stderr, "Usage: newsfed sources session <source-id> [--file=session.json] [--cookie name=value ...] [--clear]\n")
+		os.Exit(1)
+	}
+	id := resolveSourceID(metadataStore, positional[0])
+	source, err := metadataStore.GetSource(id)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to get source: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *clearSession {
+		if *file != "" || len(cookies) > 0 {
+			fmt.Fprintf(os.Stderr, "Error: --clear can't be combined with --file or --cookie\n")
+			os.Exit(1)
+		}
+		if err := metadataStore.SetSession(id, nil, nil); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to clear session: %v\n", err)
+			os.Exit(1)
+		}
+		if structured(*format) {
+			printStructured(*format, newSessionReport(*source, nil))
+			return
+		}
+		fmt.Printf("✓ Cleared session for: %s\n", source.Name)
+		return
+	}
+
+	// Without changes, describe the stored session
+	if *file == "" && len(cookies) == 0 {
+		if !source.HasSession {
+			if structured(*format) {
+				printStructured(*format, newSessionReport(*source, nil))
+				return
+			}
+			fmt.Printf("%s has no session.\n", source.Name)
+			return
+		}
+		secretStore := openSessionSecrets(metadataPath)
+		defer func() { _ = secretStore.Close() }()
+		session, err := metadataStore.GetSession(id, secretStore)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to read session: %v\n", err)
+			os.Exit(1)
+		}
+		if structured(*format) {
+			printStructured(*format, newSessionReport(*source, session))
+			return
+		}
+		fmt.Printf("Session for: %s\n", source.Name)
+		for _, c := range session.Cookies {
+			fmt.Printf("  Cookie:          %s (set)\n", c.Name)
+		}
+		if session.Login != nil {
+			fmt.Printf("  Login:           %s\n", session.Login.URL)
+			for _, name := range slices.Sorted(maps.Keys(session.Login.Fields)) {
+				fmt.Printf("  %-16s %s\n", name+":", redactValue(session.Login.Fields[name]))
+			}
+		}
+		return
+	}
+
+	if source.SourceType != "website" {
+		fmt.Fprintf(os.Stderr, "Error: sessions are only used by website sources\n")
+		os.Exit(1)
+	}
+
+	session := &sources.Session{}
+	if *file != "" {
+		var data []byte
+		if *file == "-" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(*file)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to read session: %v\n", err)
+			os.Exit(1)
+		}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(session); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid session file: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	for _, cookie := range cookies {
+		name, value, ok := strings.Cut(cookie, "=")
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Error: cookie must be in the form \"name=value\"\n")
+			os.Exit(1)
+		}
+		session.Cookies = append(session.Cookies, sources.SessionCookie{Name: strings.TrimSpace(name), Value: value})
+	}
+
+	secretStore := openSessionSecrets(metadataPath)
+	defer func() { _ = secretStore.Close() }()
+	if err := metadataStore.SetSession(id, session, secretStore); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to set session: %v\n", err)
+		os.Exit(1)
+	}
+	if structured(*format) {
+		printStructured(*format, newSessionReport(*source, session))
+		return
+	}
+	fmt.Printf("✓ Set session for: %s\n", source.Name)
+}
+
+// openSessionSecrets opens the secrets that sessions are kept in, exiting
+// if there is no master key to read or write them with.
+func openSessionSecrets(metadataPath string) *secrets.Store {
+	secretStore, err := openSecrets(metadataPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to open secrets: %v\n", err)
+		os.Exit(1)
+	}
+	if !secretStore.HasMasterKey() {
+		_ = secretStore.Close()
+		fmt.Fprintf(os.Stderr, "Error: no master key; set NEWSFED_MASTER_KEY or store one in the OS keychain\n")
+		fmt.Fprintf(os.Stderr, "Generate one with: openssl rand -base64 32\n")
+		os.Exit(1)
+	}
+	return secretStore
+}
+
+// sessionReport describes the session of a website source, as printed by
+// sources session with -format json or yaml. As in the table output,
+// cookies and login fields are named but their values are never given.
+type sessionReport struct {
+	SourceID    uuid.UUID `json:"source_id"`
+	Name        string    `json:"name"`
+	HasSession  bool      `json:"has_session"`
+	Cookies     []string  `json:"cookies,omitempty"`
+	LoginURL    string    `json:"login_url,omitempty"`
+	LoginFields []string  `json:"login_fields,omitempty"`
+}
+
+// newSessionReport describes session, the session of source, which is nil
+// if source has none.
+func newSessionReport(source sources.Source, session *sources.Session) sessionReport {
+	report := sessionReport{SourceID: source.SourceID, Name: source.Name, HasSession: session != nil}
+	if session == nil {
+		return report
+	}
+	for _, c := range session.Cookies {
+		report.Cookies = append(report.Cookies, c.Name)
+	}
+	if session.Login != nil {
+		report.LoginURL = session.Login.URL
+		report.LoginFields = slices.Sorted(maps.Keys(session.Login.Fields))
+	}
+	return report
+}
+
+// discoverReport is what sources discover found, as printed with -format
+// json or yaml.
+type discoverReport struct {
+	*discovery.FeedDiscovery
+	SuggestedName string          `json:"suggested_name"`
+	Source        *sources.Source `json:"source,omitempty"` // The source created with -add
+}
+
+func handleSourcesDiscover(metadataStore *sources.SourceStore, args []string) {
+	if len(args) < 1 {
+		fmt.Fprintf(os.Stderr, "Error: URL is required\n")
+		fmt.Fprintf(os.Stderr, "Usage: newsfed sources discover <url> [-add] [-name <name>] [-pick <n>]\n")
+		os.Exit(1)
+	}
+
+	inputURL := args[0]
+
+	fs := flag.NewFlagSet("sources discover", flag.ExitOnError)
+	add := fs.Bool("add", false, "Create a source from the chosen feed")
+	name := fs.String("name", "", "Source name for -add (default: the suggested name)")
+	pick := fs.Int("pick", 1, "Which discovered feed to add, by number")
+	format := formatFlag(fs)
+	_ = fs.Parse(args[1:])
+	checkFormat(*format)
+
+	// Per Spec 10 section 5.2
+	ctx, cancel := context.WithTimeout(context.Background(), discovery.AutodiscoverTimeout)
+	defer cancel()
+
+	found, err := discovery.DiscoverFeeds(ctx, inputURL)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err.Error())
+		os.Exit(1)
+	}
+
+	report := discoverReport{FeedDiscovery: found, SuggestedName: found.SuggestedName()}
+	if !structured(*format) {
+		if found.PageTitle != "" {
+			fmt.Printf("Page: %s\n", found.PageTitle)
+		}
+		fmt.Printf("Found %d feed(s):\n", len(found.Feeds))
+		for i, feed := range found.Feeds {
+			fmt.Printf("  %d. %s (%s)\n", i+1, feed.FeedURL, feedTypeName(feed.FeedType))
+			if feed.Title != "" {
+				fmt.Printf("     Title: %s\n", feed.Title)
+			}
+		}
+		fmt.Printf("Suggested name: %s\n", report.SuggestedName)
+	}
+
+	if !*add {
+		if structured(*format) {
+			printStructured(*format, report)
+		}
+		return
+	}
+
+	if *pick < 1 || *pick > len(found.Feeds) {
+		fmt.Fprintf(os.Stderr, "Error: -pick must be between 1 and %d\n", len(found.Feeds))
+		os.Exit(1)
+	}
+	feed := found.Feeds[*pick-1]
+
+	sourceName := *name
+	if sourceName == "" {
+		sourceName = found.SuggestedName()
+	}
+	if sourceName == "" {
+		fmt.Fprintf(os.Stderr, "Error: no name could be suggested; use -name\n")
+		os.Exit(1)
+	}
+
+	now := time.Now().UTC()
+	source, err := metadataStore.CreateSource(feed.FeedType, feed.FeedURL, sourceName, nil, &now)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to create source: %v\n", err)
+		os.Exit(1)
+	}
+
+	if structured(*format) {
+		created := source.Redacted()
+		report.Source = &created
+		printStructured(*format, report)
+		return
+	}
+
+	fmt.Println()
+	fmt.Printf("✓ Created source: %s (%s)\n", source.Name, source.SourceType)
+	fmt.Printf("  ID: %s\n", source.SourceID.String())
+	fmt.Printf("  URL: %s\n", source.URL)
+}
+
+// handleSourcesCategories lists source categories with their source and item
+// counts, or adds or deletes a category.
+func handleSourcesCategories(metadataStore *sources.SourceStore, feedDSN string, args []string) {
+	fs := flag.NewFlagSet("sources categories", flag.ExitOnError)
+	format := formatFlag(fs)
+	args = parseInterspersed(fs, args)
+	checkFormat(*format)
+
+	if len(args) > 0 {
+		if len(args) != 2 || (args[0] != "add" && args[0] != "delete") {
+			fmt.Fprintf(os.Stderr, "Usage: newsfed sources categories [add|delete <name>]\n")
+			os.Exit(1)
+		}
+		name := args[1]
+		if args[0] == "add" {
+			if err := metadataStore.CreateCategory(name); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: failed to add category: %v\n", err)
+				os.Exit(1)
+			}
+			if structured(*format) {
+				printStructured(*format, findCategory(metadataStore, name))
+				return
+			}
+			fmt.Printf("✓ Added category: %s\n", name)
+			return
+		}
+		// The category is read first so that JSON and YAML output can
+		// describe what was deleted
+		var deleted sources.Category
+		if structured(*format) {
+			deleted = findCategory(metadataStore, name)
+		}
+		if err := metadataStore.DeleteCategory(name); err != nil {
+			if errors.Is(err, sources.ErrCategoryNotFound) {
+				fmt.Fprintf(os.Stderr, "Error: category not found: %s\n", name)
+			} else {
+				fmt.Fprintf(os.Stderr, "Error: failed to delete category: %v\n", err)
+			}
+			os.Exit(1)
+		}
+		if structured(*format) {
+			printStructured(*format, deleted)
+			return
+		}
+		fmt.Printf("✓ Deleted category: %s\n", name)
+		return
+	}
+
+	newsFeed, err := newsfeed.Open(feedDSN)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to open news feed: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() { _ = newsFeed.Close() }()
+
+	reports, err := metadataStore.ReportCategories(newsFeed)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to list categories: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(reports) == 0 && !structured(*format) {
+		fmt.Println("No categories configured.")
+		return
+	}
+
+	if structured(*format) {
+		printStructured(*format, reports)
+		return
+	}
+
+	fmt.Printf("%-30s %8s %8s %8s\n", "CATEGORY", "SOURCES", "ITEMS", "UNREAD")
+	fmt.Println("--------------------------------------------------------------")
+	for _, report := range reports {
+		fmt.Printf("%s %8d %8d %8d\n", padRight(report.Name, 30), report.SourceCount, report.Items, report.Unread)
+	}
+}
+
+// findCategory returns the category with the given name, exiting if there
+// is none.
+func findCategory(metadataStore *sources.SourceStore, name string) sources.Category {
+	categories, err := metadataStore.ListCategories()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to list categories: %v\n", err)
+		os.Exit(1)
+	}
+	for _, category := range categories {
+		if strings.EqualFold(category.Name, name) {
+			return category
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Error: category not found: %s\n", name)
+	os.Exit(1)
+	return sources.Category{}
+}
+
+// validateTLSSettings checks that a source's TLS settings can be used: the
+// minimum version is known and the CA file holds at least one certificate.
+// The CA file path is made absolute, since the daemon may run elsewhere.
+func validateTLSSettings(settings *sources.TLSConfig) error {
+	if _, err := settings.Version(); err != nil {
+		return err
+	}
+	if settings.CAFile != "" {
+		abs, err := filepath.Abs(settings.CAFile)
+		if err != nil {
+			return fmt.Errorf("invalid CA file path: %w", err)
+		}
+		settings.CAFile = abs
+
+		pem, err := os.ReadFile(settings.CAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read CA file: %w", err)
+		}
+		if !x509.NewCertPool().AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in CA file %s", settings.CAFile)
+		}
+	}
+	return nil
+}
+
+// ingestRuleFlags are the flags that set a source's ingest rules.
+type ingestRuleFlags struct {
+	maxItems        *int
+	minLength       *int
+	maxAge          *string
+	dedup           *string
+	includeURLs     listFlag
+	excludeURLs     listFlag
+	includeKeywords listFlag
+	excludeKeywords listFlag
+}
+
+// addIngestRuleFlags defines the ingest rule flags on fs.
+func addIngestRuleFlags(fs *flag.FlagSet) *ingestRuleFlags {
+	f := &ingestRuleFlags{}
+	f.maxItems = fs.Int("max-items", 0, "Add at most this many new items per sync (0 for no limit)")
+	f.minLength = fs.Int("min-length", 0, "Skip items whose summary is shorter than this many characters")
+	f.maxAge = fs.String("max-age", "", "Skip items published longer ago than this (e.g., 30d or 720h; empty for the global limit)")
+	f.dedup = fs.String("dedup", "", "Deduplicate items against: global (every item), source (the source's own items), or off")
+	fs.Var(&f.includeURLs, "include-url", "Only add items whose URL matches this regular expression (repeatable)")
+	fs.Var(&f.excludeURLs, "exclude-url", "Skip items whose URL matches this regular expression (repeatable)")
+	fs.Var(&f.includeKeywords, "include-keyword", "Only add items whose title contains this keyword (repeatable)")
+	fs.Var(&f.excludeKeywords, "exclude-keyword", "Skip items whose title contains this keyword (repeatable)")
+	return f
+}
+
+// set reports whether any ingest rule flag was given.
+func (f *ingestRuleFlags) set(fs *flag.FlagSet) bool {
+	set := false
+	fs.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "max-items", "min-length", "max-age", "dedup", "include-url", "exclude-url", "include-keyword", "exclude-keyword":
+			set = true
+		}
+	})
+	return set
+}
+
+// apply copies the rules given on the command line into rules. Repeated
+// flags replace the whole list they set.
+func (f *ingestRuleFlags) apply(fs *flag.FlagSet, rules *sources.IngestRules) {
+	fs.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "max-items":
+			rules.MaxItems = *f.maxItems
+		case "min-length":
+			rules.MinContentLength = *f.minLength
+		case "max-age":
+			rules.MaxAge = *f.maxAge
+		case "dedup":
+			rules.Dedup = *f.dedup
+		case "include-url":
+			rules.IncludeURLs = f.includeURLs
+		case "exclude-url":
+			rules.ExcludeURLs = f.excludeURLs
+		case "include-keyword":
+			rules.IncludeKeywords = f.includeKeywords
+		case "exclude-keyword":
+			rules.ExcludeKeywords = f.excludeKeywords
+		}
+	})
+}
+
+// pollWindowFlags are the flags that set a source's polling window.
+type pollWindowFlags struct {
+	daily    *string
+	cron     *string
+	timeZone *string
+}
+
+// addPollWindowFlags defines the polling window flags on fs.
+func addPollWindowFlags(fs *flag.FlagSet) *pollWindowFlags {
+	return &pollWindowFlags{
+		daily:    fs.String("window", "", "Only fetch the source on schedule between these times each day (e.g., 06:00-22:00)"),
+		cron:     fs.String("window-cron", "", "Only fetch the source on schedule in the minutes this cron expression matches"),
+		timeZone: fs.String("window-tz", "", "Time zone of the polling window (e.g., Europe/London; default: local time)"),
+	}
+}
+
+// set reports whether any polling window flag was given.
+func (f *pollWindowFlags) set() bool {
+	return *f.daily != "" || *f.cron != "" || *f.timeZone != ""
+}
+
+// apply sets the window given on the command line in window, replacing a
+// daily window with a cron expression or the other way around, and checks
+// the result.
+func (f *pollWindowFlags) apply(window *sources.PollWindow) error {
+	if *f.daily != "" && *f.cron != "" {
+		return fmt.Errorf("-window and -window-cron cannot be used together")
+	}
+	if *f.daily != "" {
+		start, end, ok := strings.Cut(*f.daily, "-")
+		if !ok {
+			return fmt.Errorf("invalid -window: %q must be START-END, such as 06:00-22:00", *f.daily)
+		}
+		window.Start, window.End, window.Cron = strings.TrimSpace(start), strings.TrimSpace(end), ""
+	}
+	if *f.cron != "" {
+		window.Start, window.End, window.Cron = "", "", *f.cron
+	}
+	if *f.timeZone != "" {
+		window.TimeZone = *f.timeZone
+	}
+	return window.Validate()
+}
+
+// fetchBudgetFlags are the flags that set a source's fetch budget.
+type fetchBudgetFlags struct {
+	requests *int
+	bytes    *string
+	duration *string
+}
+
+// addFetchBudgetFlags defines the fetch budget flags on fs.
+func addFetchBudgetFlags(fs *flag.FlagSet) *fetchBudgetFlags {
+	return &fetchBudgetFlags{
+		requests: fs.Int("budget-requests", 0, "Send at most this many requests per sync (0 for no limit)"),
+		bytes:    fs.String("budget-bytes", "", "Download at most this much per sync (e.g., 50MB; 0 for no limit)"),
+		duration: fs.String("budget-duration", "", "Start no request after this long into a sync (e.g., 10m; 0 for no limit)"),
+	}
+}
+
+// set reports whether any fetch budget flag was given.
+func (f *fetchBudgetFlags) set(fs *flag.FlagSet) bool {
+	set := false
+	fs.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "budget-requests", "budget-bytes", "budget-duration":
+			set = true
+		}
+	})
+	return set
+}
+
+// apply copies the limits given on the command line into budget, where 0
+// removes a limit, and checks the result.
+func (f *fetchBudgetFlags) apply(fs *flag.FlagSet, budget *sources.FetchBudget) error {
+	var err error
+	fs.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "budget-requests":
+			budget.MaxRequests = *f.requests
+		case "budget-bytes":
+			size, parseErr := sources.ParseByteSize(*f.bytes)
+			if parseErr != nil {
+				err = fmt.Errorf("invalid -budget-bytes: %w", parseErr)
+			}
+			budget.MaxBytes = size
+		case "budget-duration":
+			budget.MaxDuration = *f.duration
+			if *f.duration == "0" {
+				budget.MaxDuration = ""
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return budget.Validate()
+}
+
 // feedTypeName returns the conventional display name for a feed type string.
 func feedTypeName(t string) string {
 	switch t {
@@ -688,6 +2331,8 @@ func feedTypeName(t string) string {
 		return "RSS"
 	case "atom":
 		return "Atom"
+	case "json":
+		return "JSON Feed"
 	default:
 		return t
 	}
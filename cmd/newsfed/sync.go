@@ -4,31 +4,30 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/pevans/newsfed/config"
 	"github.com/pevans/newsfed/discovery"
 	"github.com/pevans/newsfed/newsfeed"
 	"github.com/pevans/newsfed/sources"
 )
 
-func handleSync(metadataPath, feedDir string, args []string) {
+func handleSync(metadataPath, feedDSN string, args []string) {
 	// Parse flags for sync command
 	fs := flag.NewFlagSet("sync", flag.ExitOnError)
 	verbose := fs.Bool("verbose", false, "Show verbose output")
-	_ = fs.Parse(args)
+	dryRun := fs.Bool("dry-run", false, "Show what would be added without writing to the feed")
+	progress := fs.Bool("progress", false, "Print each source's result as it finishes (with -verbose, also as it is queued and fetched)")
+	format := formatFlag(fs)
+	positional := parseInterspersed(fs, args)
+	checkFormat(*format)
 
-	// Check if a specific source ID was provided
-	var sourceID *uuid.UUID
-	if len(fs.Args()) > 0 {
-		id, err := uuid.Parse(fs.Args()[0])
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error: invalid source ID: %v\n", err)
-			os.Exit(1)
-		}
-		sourceID = &id
-	}
+	// With JSON or YAML output, stdout is kept for the result and progress
+	// goes to stderr
+	notices := noticeWriter(*format)
 
 	// Initialize source store
 	sourceStore, err := sources.NewSourceStore(metadataPath)
@@ -38,24 +37,101 @@ func handleSync(metadataPath, feedDir string, args []string) {
 	}
 	defer func() { _ = sourceStore.Close() }()
 
+	// Check if a specific source ID was provided
+	var sourceID *uuid.UUID
+	if len(positional) > 0 {
+		id := resolveSourceID(sourceStore, positional[0])
+		sourceID = &id
+	}
+
 	// Initialize news feed
-	newsFeed, err := newsfeed.NewNewsFeed(feedDir)
+	newsFeed, err := newsfeed.Open(feedDSN)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: failed to open news feed: %v\n", err)
 		os.Exit(1)
 	}
+	defer func() { _ = newsFeed.Close() }()
 
 	// Create discovery service
+	fileConfig, err := config.LoadConfigFile()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
 	config := &discovery.DiscoveryConfig{
 		FetchTimeout:      60 * time.Second,
 		RateLimitInterval: 1 * time.Second,
+		RetryAttempts:     2,
+		RetryBaseDelay:    1 * time.Second,
+		RetryMaxDelay:     30 * time.Second,
 	}
 	if envInterval := os.Getenv("NEWSFED_RATE_LIMIT_INTERVAL"); envInterval != "" {
 		if d, err := time.ParseDuration(envInterval); err == nil {
 			config.RateLimitInterval = d
 		}
 	}
+	if envJitter := os.Getenv("NEWSFED_RATE_LIMIT_JITTER"); envJitter != "" {
+		if d, err := time.ParseDuration(envJitter); err == nil && d >= 0 {
+			config.RateLimitJitter = d
+		}
+	}
+	config.ProxyURL, err = loadProxyURL(fileConfig)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := loadRetryPolicy(config, fileConfig); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := loadRenderSettings(config, fileConfig); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := loadMaxItemAge(config, fileConfig); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := loadMaxResponseSize(config, fileConfig); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := loadAdaptivePolling(config, fileConfig); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := loadJournal(config, fileConfig); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := loadHooks(config, fileConfig); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := loadPublish(config, fileConfig); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if fileConfig != nil {
+		config.KeepSummaryHTML = fileConfig.Discovery.KeepSummaryHTML
+	}
 	service := discovery.NewDiscoveryService(sourceStore, newsFeed, config)
+	defer func() { _ = service.Close() }()
+	secretStore, err := openSecrets(metadataPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to open secrets: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() { _ = secretStore.Close() }()
+	service.SetSecrets(secretStore)
+	summaryEnricher, err := loadSummaryEnricher(fileConfig)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if summaryEnricher != nil {
+		service.AddEnricher(summaryEnricher)
+	}
 
 	// Perform sync
 	if sourceID != nil {
@@ -64,24 +140,74 @@ func handleSync(metadataPath, feedDir string, args []string) {
 			fmt.Fprintf(os.Stderr, "Error: failed to get source: %v\n", err)
 			os.Exit(1)
 		}
-		fmt.Printf("Syncing source: %s\n", source.Name)
+		fmt.Fprintf(notices, "Syncing source: %s\n", source.Name)
 	} else {
-		fmt.Println("Syncing all enabled sources...")
+		fmt.Fprintln(notices, "Syncing all enabled sources...")
+	}
+	if *dryRun {
+		fmt.Fprintln(notices, "Dry run: nothing will be written to the feed or sources")
 	}
 
+	shutdownTracing := setupTracing()
 	ctx := context.Background()
-	result, err := service.SyncSources(ctx, sourceID, nil)
+	var progressCh chan discovery.SourceProgress
+	progressDone := make(chan struct{})
+	if *progress {
+		progressCh = make(chan discovery.SourceProgress)
+		go func() {
+			defer close(progressDone)
+			printSyncProgress(notices, progressCh, *verbose)
+		}()
+	} else {
+		close(progressDone)
+	}
+	result, err := service.SyncSources(ctx, discovery.SyncOptions{SourceID: sourceID, DryRun: *dryRun}, progressCh)
+	<-progressDone
+	service.Flush()
+	shutdownTracing()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: sync failed: %v\n", err)
 		os.Exit(1)
 	}
 
+	if structured(*format) {
+		printStructured(*format, newSyncReport(result, *dryRun))
+		if result.SourcesFailed > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *dryRun {
+		printSyncPlan(result.Planned)
+	}
+
 	// Display results
 	fmt.Println()
-	fmt.Println("Sync completed:")
-	fmt.Printf("  Sources synced: %d\n", result.SourcesSynced)
-	fmt.Printf("  Sources failed: %d\n", result.SourcesFailed)
-	fmt.Printf("  Items discovered: %d\n", result.ItemsDiscovered)
+	if *dryRun {
+		fmt.Println("Dry run completed:")
+		fmt.Printf("  Sources fetched: %d\n", result.SourcesSynced)
+		fmt.Printf("  Sources failed: %d\n", result.SourcesFailed)
+		fmt.Printf("  Items that would be added: %d\n", result.ItemsDiscovered)
+	} else {
+		fmt.Println("Sync completed:")
+		fmt.Printf("  Sources synced: %d\n", result.SourcesSynced)
+		fmt.Printf("  Sources failed: %d\n", result.SourcesFailed)
+		fmt.Printf("  Items discovered: %d\n", result.ItemsDiscovered)
+	}
+	if result.Duplicates.Total() > 0 {
+		fmt.Printf("  Duplicates skipped: %d (%d by GUID, %d by URL, %d by content)\n",
+			result.Duplicates.Total(), result.Duplicates.GUIDDuplicates, result.Duplicates.URLDuplicates, result.Duplicates.ContentDuplicates)
+	}
+	if result.Duplicates.Updated > 0 {
+		fmt.Printf("  Items updated: %d\n", result.Duplicates.Updated)
+	}
+	if len(result.BudgetsReached) > 0 {
+		fmt.Printf("  Stopped at fetch budget: %d\n", len(result.BudgetsReached))
+		for _, reached := range result.BudgetsReached {
+			fmt.Printf("    ⚠ %s: %v\n", reached.Source.Name, reached.Error)
+		}
+	}
 
 	// Show errors if any
 	if len(result.Errors) > 0 && *verbose {
@@ -97,3 +223,128 @@ func handleSync(metadataPath, feedDir string, args []string) {
 		os.Exit(1)
 	}
 }
+
+// printSyncProgress prints a line to w as each source in a sync finishes,
+// and with verbose, as each is queued and starts fetching. It returns when
+// ch is closed.
+func printSyncProgress(w io.Writer, ch <-chan discovery.SourceProgress, verbose bool) {
+	total, finished := 0, 0
+	for p := range ch {
+		switch p.Status {
+		case discovery.ProgressQueued:
+			total++
+			if verbose {
+				fmt.Fprintf(w, "  queued    %s\n", p.Source.Name)
+			}
+		case discovery.ProgressFetching:
+			if verbose {
+				fmt.Fprintf(w, "  fetching  %s\n", p.Source.Name)
+			}
+		case discovery.ProgressDone:
+			finished++
+			fmt.Fprintf(w, "[%d/%d] ✓ %s: %d new items in %v",
+				finished, total, p.Source.Name, p.NewItems, p.Duration.Round(time.Millisecond))
+			if p.Budget != nil {
+				fmt.Fprintf(w, " (stopped early: %v)", p.Budget)
+			}
+			fmt.Fprintln(w)
+		case discovery.ProgressError:
+			finished++
+			fmt.Fprintf(w, "[%d/%d] ✗ %s: %v\n", finished, total, p.Source.Name, p.Error)
+		}
+	}
+}
+
+// printSyncPlan lists the items a dry-run sync found, grouped by source,
+// marking each as one that would be added (+) or skipped as a duplicate (=).
+func printSyncPlan(planned []discovery.PlannedItem) {
+	var order []uuid.UUID
+	bySource := map[uuid.UUID][]discovery.PlannedItem{}
+	for _, p := range planned {
+		if _, ok := bySource[p.Source.SourceID]; !ok {
+			order = append(order, p.Source.SourceID)
+		}
+		bySource[p.Source.SourceID] = append(bySource[p.Source.SourceID], p)
+	}
+
+	for _, id := range order {
+		items := bySource[id]
+		fmt.Println()
+		fmt.Printf("%s:\n", items[0].Source.Name)
+		for _, p := range items {
+			title := p.Item.Title
+			if title == "" {
+				title = "(not fetched)"
+			}
+			switch p.Duplicate {
+			case newsfeed.NotDuplicate:
+				fmt.Printf("  + %s\n", title)
+			case newsfeed.DuplicateGUID:
+				fmt.Printf("  = %s (duplicate GUID)\n", title)
+			case newsfeed.DuplicateURL:
+				fmt.Printf("  = %s (duplicate URL)\n", title)
+			default:
+				fmt.Printf("  = %s (duplicate content)\n", title)
+			}
+			fmt.Printf("    %s\n", p.Item.URL)
+		}
+	}
+}
+
+// syncReport is the result of a sync, as printed with -format json or yaml.
+type syncReport struct {
+	DryRun          bool                     `json:"dry_run"`
+	SourcesSynced   int                      `json:"sources_synced"`
+	SourcesFailed   int                      `json:"sources_failed"`
+	ItemsDiscovered int                      `json:"items_discovered"` // In a dry run, the items that would be added
+	Duplicates      discovery.DedupReport    `json:"duplicates"`
+	Errors          []discovery.SyncJobError `json:"errors"`
+	BudgetsReached  []discovery.SyncJobError `json:"budgets_reached,omitempty"` // Sources that stopped at their fetch budget
+	Planned         []plannedItem            `json:"planned,omitempty"`
+}
+
+// plannedItem is an item found by a dry-run sync, as printed in a
+// syncReport.
+type plannedItem struct {
+	SourceID  uuid.UUID              `json:"source_id"`
+	Source    string                 `json:"source"`
+	Title     string                 `json:"title,omitempty"`
+	URL       string                 `json:"url"`
+	Duplicate newsfeed.DuplicateKind `json:"duplicate,omitempty"` // Empty if the item would be added
+}
+
+// newSyncReport summarizes result, the result of a sync.
+func newSyncReport(result *discovery.SyncResult, dryRun bool) syncReport {
+	report := syncReport{
+		DryRun:          dryRun,
+		SourcesSynced:   result.SourcesSynced,
+		SourcesFailed:   result.SourcesFailed,
+		ItemsDiscovered: result.ItemsDiscovered,
+		Duplicates:      result.Duplicates,
+		Errors:          []discovery.SyncJobError{},
+	}
+	for _, syncErr := range result.Errors {
+		report.Errors = append(report.Errors, discovery.SyncJobError{
+			SourceID: syncErr.Source.SourceID,
+			Name:     syncErr.Source.Name,
+			Error:    syncErr.Error.Error(),
+		})
+	}
+	for _, reached := range result.BudgetsReached {
+		report.BudgetsReached = append(report.BudgetsReached, discovery.SyncJobError{
+			SourceID: reached.Source.SourceID,
+			Name:     reached.Source.Name,
+			Error:    reached.Error.Error(),
+		})
+	}
+	for _, p := range result.Planned {
+		report.Planned = append(report.Planned, plannedItem{
+			SourceID:  p.Source.SourceID,
+			Source:    p.Source.Name,
+			Title:     p.Item.Title,
+			URL:       p.Item.URL,
+			Duplicate: p.Duplicate,
+		})
+	}
+	return report
+}
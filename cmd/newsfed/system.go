@@ -1,26 +1,39 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"math/rand/v2"
+	"net/url"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/pevans/newsfed/audit"
+	"github.com/pevans/newsfed/collections"
 	"github.com/pevans/newsfed/config"
+	"github.com/pevans/newsfed/discovery"
 	"github.com/pevans/newsfed/newsfeed"
+	"github.com/pevans/newsfed/secrets"
 	"github.com/pevans/newsfed/sources"
+	"github.com/pevans/newsfed/sqldb"
+	"github.com/pevans/newsfed/users"
 )
 
 // loadStorageConfig loads storage configuration with precedence:
 // 1. Environment variables (highest priority)
 // 2. Configuration file (~/.newsfed/config.yaml)
 // 3. Default values (lowest priority)
-func loadStorageConfig() (metadataType, metadataPath, feedType, feedDir string) {
+func loadStorageConfig() (metadataType, metadataPath, feedType, feedDSN string) {
 	// Set defaults
 	metadataType = "sqlite"
 	metadataPath = "metadata.db"
 	feedType = "file"
-	feedDir = ".news"
+	feedDSN = ".news"
 
 	// Load config file (if it exists)
 	cfg, err := config.LoadConfigFile()
@@ -41,7 +54,7 @@ func loadStorageConfig() (metadataType, metadataPath, feedType, feedDir string)
 			feedType = cfg.Storage.Feed.Type
 		}
 		if cfg.Storage.Feed.DSN != "" {
-			feedDir = cfg.Storage.Feed.DSN
+			feedDSN = cfg.Storage.Feed.DSN
 		}
 	}
 
@@ -56,13 +69,36 @@ func loadStorageConfig() (metadataType, metadataPath, feedType, feedDir string)
 		feedType = val
 	}
 	if val := os.Getenv("NEWSFED_FEED_DSN"); val != "" {
-		feedDir = val
+		feedDSN = val
 	}
 
-	return metadataType, metadataPath, feedType, feedDir
+	// A sqlite:// DSN implies the SQLite feed backend, and the SQLite feed
+	// type implies a sqlite:// DSN. A postgres:// DSN implies PostgreSQL for
+	// either store.
+	if sqldb.IsPostgres(metadataPath) {
+		metadataType = "postgres"
+	}
+	if sqldb.IsPostgres(feedDSN) {
+		feedType = "postgres"
+	} else if strings.HasPrefix(feedDSN, newsfeed.SQLiteScheme) {
+		feedType = "sqlite"
+	} else if feedType == "sqlite" {
+		feedDSN = newsfeed.SQLiteScheme + feedDSN
+	}
+
+	return metadataType, metadataPath, feedType, feedDSN
+}
+
+// parseEphemeralFlag removes a leading --ephemeral (or -ephemeral) from
+// args and reports whether it was there.
+func parseEphemeralFlag(args []string) (bool, []string) {
+	if len(args) > 0 && (args[0] == "--ephemeral" || args[0] == "-ephemeral") {
+		return true, args[1:]
+	}
+	return false, args
 }
 
-func handleInit(metadataPath, feedDir string, args []string) {
+func handleInit(metadataPath, feedDSN string, args []string) {
 	// Parse flags for init command
 	fs := flag.NewFlagSet("init", flag.ExitOnError)
 	force := fs.Bool("force", false, "Force reinitialization even if storage already exists")
@@ -92,7 +128,7 @@ func handleInit(metadataPath, feedDir string, args []string) {
 				metadataPath = newMeta
 			}
 			if os.Getenv("NEWSFED_FEED_DSN") == "" {
-				feedDir = newFeed
+				feedDSN = newFeed
 			}
 		}
 	} else {
@@ -100,13 +136,18 @@ func handleInit(metadataPath, feedDir string, args []string) {
 		fmt.Printf("  Config file: %s (already exists)\n", configPath)
 	}
 
-	// Check and create metadata database
+	// Check and create metadata database. A PostgreSQL database must already
+	// exist; opening it creates or upgrades the schema.
 	metadataExists := false
 	if _, err := os.Stat(metadataPath); err == nil {
 		metadataExists = true
 	}
 
-	if metadataExists && !*force {
+	if sqldb.IsPostgres(metadataPath) {
+		if !initPostgresMetadata(metadataPath) {
+			initSucceeded = false
+		}
+	} else if metadataExists && !*force {
 		fmt.Printf("  Metadata database: %s (already exists)\n", metadataPath)
 	} else {
 		// Create directory if needed
@@ -146,31 +187,93 @@ func handleInit(metadataPath, feedDir string, args []string) {
 				_ = configStore.Close()
 			}
 		}
+
+		// Initialize collection tables in metadata database
+		if initSucceeded {
+			collectionStore, err := collections.NewCollectionStore(metadataPath)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "  ✗ Failed to initialize collection tables: %v\n", err)
+				initSucceeded = false
+			} else {
+				_ = collectionStore.Close()
+			}
+		}
+
+		// Initialize user tables in metadata database
+		if initSucceeded {
+			userStore, err := users.NewUserStore(metadataPath)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "  ✗ Failed to initialize user tables: %v\n", err)
+				initSucceeded = false
+			} else {
+				_ = userStore.Close()
+			}
+		}
+
+		// Initialize secrets table in metadata database
+		if initSucceeded {
+			secretStore, err := secrets.Open(metadataPath, "")
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "  ✗ Failed to initialize secrets table: %v\n", err)
+				initSucceeded = false
+			} else {
+				_ = secretStore.Close()
+			}
+		}
 	}
 
-	// Check and create feed storage directory
+	// Check and create feed storage
+	feedPath, isSQLiteFeed := strings.CutPrefix(feedDSN, newsfeed.SQLiteScheme)
 	feedExists := false
-	if stat, err := os.Stat(feedDir); err == nil && stat.IsDir() {
+	if stat, err := os.Stat(feedPath); err == nil && (isSQLiteFeed || stat.IsDir()) {
 		feedExists = true
 	}
 
-	if feedExists && !*force {
-		fmt.Printf("  Feed storage: %s (already exists)\n", feedDir)
+	if sqldb.IsPostgres(feedDSN) {
+		newsFeed, err := newsfeed.Open(feedDSN)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "  ✗ Failed to initialize feed storage: %v\n", err)
+			initSucceeded = false
+		} else {
+			_ = newsFeed.Close()
+			fmt.Printf("  ✓ Feed storage: %s\n", redactDSN(feedDSN))
+		}
+	} else if feedExists && !*force {
+		fmt.Printf("  Feed storage: %s (already exists)\n", feedPath)
+	} else if isSQLiteFeed {
+		if dir := filepath.Dir(feedPath); dir != "." {
+			if err := os.MkdirAll(dir, 0o700); err != nil {
+				fmt.Fprintf(os.Stderr, "  ✗ Failed to create feed storage directory: %v\n", err)
+				initSucceeded = false
+			}
+		}
+
+		if initSucceeded {
+			newsFeed, err := newsfeed.Open(feedDSN)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "  ✗ Failed to initialize feed storage: %v\n", err)
+				initSucceeded = false
+			} else {
+				_ = newsFeed.Close()
+				fmt.Printf("  ✓ Feed storage: %s\n", feedPath)
+				createdSomething = true
+			}
+		}
 	} else {
 		// Create feed storage directory with proper permissions (0700 per RFC
 		// 8 section 8.1)
-		if err := os.MkdirAll(feedDir, 0o700); err != nil {
+		if err := os.MkdirAll(feedDSN, 0o700); err != nil {
 			fmt.Fprintf(os.Stderr, "  ✗ Failed to create feed storage directory: %v\n", err)
 			initSucceeded = false
 		} else {
 			// Verify we can write to it by initializing the NewsFeed
-			newsFeed, err := newsfeed.NewNewsFeed(feedDir)
+			newsFeed, err := newsfeed.Open(feedDSN)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "  ✗ Failed to initialize feed storage: %v\n", err)
 				initSucceeded = false
 			} else {
-				_ = newsFeed
-				fmt.Printf("  ✓ Feed storage: %s\n", feedDir)
+				_ = newsFeed.Close()
+				fmt.Printf("  ✓ Feed storage: %s\n", feedDSN)
 				createdSomething = true
 			}
 		}
@@ -196,10 +299,11 @@ func handleInit(metadataPath, feedDir string, args []string) {
 	}
 }
 
-func handleDoctor(metadataPath, feedDir string, args []string) {
+func handleDoctor(metadataPath, feedDSN string, args []string) {
 	// Parse flags for doctor command
 	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
 	verbose := fs.Bool("verbose", false, "Show detailed diagnostic information")
+	sample := fs.Int("sample", doctorSampleSize, "Number of enabled sources whose URLs to fetch (0 to skip)")
 	_ = fs.Parse(args)
 
 	fmt.Println("Checking newsfed storage health...")
@@ -207,15 +311,25 @@ func handleDoctor(metadataPath, feedDir string, args []string) {
 
 	hasErrors := false
 	hasWarnings := false
+	// needsInit is set by the errors that newsfed init fixes
+	needsInit := false
+	// sourceList is nil unless the sources could be listed
+	var sourceList []sources.Source
 
 	// Check metadata database
 	fmt.Println("Metadata Database:")
-	fmt.Printf("  Path: %s\n", metadataPath)
+	fmt.Printf("  Path: %s\n", redactDSN(metadataPath))
 
-	if _, err := os.Stat(metadataPath); os.IsNotExist(err) {
+	if sqldb.IsPostgres(metadataPath) {
+		var ok, warned bool
+		sourceList, ok, warned = checkPostgresMetadata(metadataPath, *verbose)
+		hasErrors = hasErrors || !ok
+		hasWarnings = hasWarnings || warned
+	} else if _, err := os.Stat(metadataPath); os.IsNotExist(err) {
 		fmt.Println("  ✗ Database file does not exist")
 		fmt.Println("    Run 'newsfed init' to create it")
 		hasErrors = true
+		needsInit = true
 	} else if err != nil {
 		fmt.Printf("  ✗ Cannot access database file: %v\n", err)
 		hasErrors = true
@@ -244,14 +358,13 @@ func handleDoctor(metadataPath, feedDir string, args []string) {
 				}
 			}
 
-			// Count sources
-			sourceList, err := metadataStore.ListSources(sources.SourceFilter{})
+			listed, warned, err := checkSources(metadataStore, *verbose)
 			if err != nil {
 				fmt.Printf("  ⚠ Warning: Could not list sources: %v\n", err)
 				hasWarnings = true
-			} else if *verbose || len(sourceList) > 0 {
-				fmt.Printf("  Sources configured: %d\n", len(sourceList))
 			}
+			sourceList = listed
+			hasWarnings = hasWarnings || warned
 		}
 	}
 
@@ -259,12 +372,23 @@ func handleDoctor(metadataPath, feedDir string, args []string) {
 
 	// Check feed storage
 	fmt.Println("Feed Storage:")
-	fmt.Printf("  Path: %s\n", feedDir)
+	feedPath, isSQLiteFeed := strings.CutPrefix(feedDSN, newsfeed.SQLiteScheme)
+	fmt.Printf("  Path: %s\n", redactDSN(feedPath))
 
-	if stat, err := os.Stat(feedDir); os.IsNotExist(err) {
+	if sqldb.IsPostgres(feedDSN) {
+		feedErrors, feedWarnings := checkPostgresFeed(feedDSN, *verbose)
+		hasErrors = hasErrors || feedErrors
+		hasWarnings = hasWarnings || feedWarnings
+	} else if isSQLiteFeed {
+		feedErrors, feedWarnings, missing := checkSQLiteFeed(feedDSN, feedPath, *verbose)
+		hasErrors = hasErrors || feedErrors
+		hasWarnings = hasWarnings || feedWarnings
+		needsInit = needsInit || missing
+	} else if stat, err := os.Stat(feedDSN); os.IsNotExist(err) {
 		fmt.Println("  ✗ Storage directory does not exist")
 		fmt.Println("    Run 'newsfed init' to create it")
 		hasErrors = true
+		needsInit = true
 	} else if err != nil {
 		fmt.Printf("  ✗ Cannot access storage directory: %v\n", err)
 		hasErrors = true
@@ -273,7 +397,7 @@ func handleDoctor(metadataPath, feedDir string, args []string) {
 		hasErrors = true
 	} else {
 		// Try to initialize the feed storage
-		newsFeed, err := newsfeed.NewNewsFeed(feedDir)
+		newsFeed, err := newsfeed.Open(feedDSN)
 		if err != nil {
 			fmt.Printf("  ✗ Failed to initialize feed storage: %v\n", err)
 			hasErrors = true
@@ -289,12 +413,12 @@ func handleDoctor(metadataPath, feedDir string, args []string) {
 			if perm&0o077 != 0 {
 				fmt.Println("  ⚠ Warning: Storage directory has overly permissive permissions")
 				fmt.Printf("    Current: %o, expected: 700\n", perm)
-				fmt.Println("    Consider: chmod 700 " + feedDir)
+				fmt.Println("    Consider: chmod 700 " + feedDSN)
 				hasWarnings = true
 			}
 
 			// Check individual feed file permissions
-			entries, dirErr := os.ReadDir(feedDir)
+			entries, dirErr := os.ReadDir(feedDSN)
 			if dirErr != nil {
 				fmt.Printf("  ⚠ Warning: Could not read feed directory: %v\n", dirErr)
 				hasWarnings = true
@@ -315,34 +439,41 @@ func handleDoctor(metadataPath, feedDir string, args []string) {
 				}
 				if looseFileCount > 0 {
 					fmt.Printf("  ⚠ Warning: %d file(s) have overly permissive permissions\n", looseFileCount)
-					fmt.Printf("    Consider: chmod 600 %s/*\n", feedDir)
+					fmt.Printf("    Consider: chmod 600 %s/*\n", feedDSN)
 					hasWarnings = true
 				}
 			}
 
-			// Count items
-			result, err := newsFeed.List()
-			if err != nil {
-				fmt.Printf("  ⚠ Warning: Could not list items: %v\n", err)
+			feedErrors, feedWarnings := checkFeedItems(newsFeed, *verbose)
+			hasErrors = hasErrors || feedErrors
+			hasWarnings = hasWarnings || feedWarnings
+
+			// Files moved aside by fsck are kept until someone looks at
+			// them
+			quarantined, _ := os.ReadDir(filepath.Join(feedDSN, newsfeed.QuarantineDir))
+			if len(quarantined) > 0 {
+				fmt.Printf("  ⚠ Warning: %d damaged file(s) are in quarantine\n", len(quarantined))
+				fmt.Printf("    Inspect them in %s, then restore or delete them\n", filepath.Join(feedDSN, newsfeed.QuarantineDir))
 				hasWarnings = true
-			} else {
-				if *verbose || len(result.Items) > 0 {
-					fmt.Printf("  News items stored: %d\n", len(result.Items))
-				}
-				if len(result.Errors) > 0 {
-					fmt.Printf("  ⚠ Warning: %d item(s) could not be read\n", len(result.Errors))
-					hasWarnings = true
-				}
 			}
 		}
 	}
 
 	fmt.Println()
 
+	if *sample > 0 && len(sourceList) > 0 {
+		if checkSourceURLs(metadataPath, sourceList, *sample, *verbose) {
+			hasWarnings = true
+		}
+		fmt.Println()
+	}
+
 	// Print summary
 	if hasErrors {
 		fmt.Println("✗ Storage has errors")
-		fmt.Println("  Run 'newsfed init' to initialize storage")
+		if needsInit {
+			fmt.Println("  Run 'newsfed init' to initialize storage")
+		}
 		os.Exit(1)
 	} else if hasWarnings {
 		fmt.Println("✓ Storage is functional but has warnings")
@@ -353,3 +484,545 @@ func handleDoctor(metadataPath, feedDir string, args []string) {
 		fmt.Println("✓ All checks passed")
 	}
 }
+
+// initPostgresMetadata creates or upgrades the metadata schema in a
+// PostgreSQL database and reports whether it succeeded.
+func initPostgresMetadata(dsn string) bool {
+	metadataStore, err := sources.NewSourceStore(dsn)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "  ✗ Failed to initialize metadata database: %v\n", err)
+		return false
+	}
+	_ = metadataStore.Close()
+
+	configStore, err := config.NewConfigStore(dsn)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "  ✗ Failed to initialize config table: %v\n", err)
+		return false
+	}
+	_ = configStore.Close()
+
+	collectionStore, err := collections.NewCollectionStore(dsn)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "  ✗ Failed to initialize collection tables: %v\n", err)
+		return false
+	}
+	_ = collectionStore.Close()
+
+	userStore, err := users.NewUserStore(dsn)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "  ✗ Failed to initialize user tables: %v\n", err)
+		return false
+	}
+	_ = userStore.Close()
+
+	secretStore, err := secrets.Open(dsn, "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "  ✗ Failed to initialize secrets table: %v\n", err)
+		return false
+	}
+	_ = secretStore.Close()
+
+	fmt.Printf("  ✓ Metadata database: %s\n", redactDSN(dsn))
+	return true
+}
+
+// checkPostgresMetadata runs the doctor checks for a PostgreSQL metadata
+// database. It reports whether the database is usable and whether there
+// were warnings, and returns its sources.
+func checkPostgresMetadata(dsn string, verbose bool) (sourceList []sources.Source, ok, hasWarnings bool) {
+	metadataStore, err := sources.NewSourceStore(dsn)
+	if err != nil {
+		fmt.Printf("  ✗ Failed to open database: %v\n", err)
+		return nil, false, false
+	}
+	defer func() { _ = metadataStore.Close() }()
+	fmt.Println("  ✓ Database is accessible")
+
+	sourceList, hasWarnings, err = checkSources(metadataStore, verbose)
+	if err != nil {
+		fmt.Printf("  ✗ Could not list sources: %v\n", err)
+		return nil, false, hasWarnings
+	}
+	return sourceList, true, hasWarnings
+}
+
+// checkSources runs the doctor checks that read the metadata database: that
+// its schema is one this newsfed knows, and that each website source's
+// scraper config is usable. It prints how many sources there are and
+// returns them, and reports whether there were warnings.
+func checkSources(store *sources.SourceStore, verbose bool) (sourceList []sources.Source, hasWarnings bool, err error) {
+	version, latest, err := store.SchemaVersion()
+	if err != nil {
+		fmt.Printf("  ⚠ Warning: Could not read schema version: %v\n", err)
+		hasWarnings = true
+	} else if version > latest {
+		fmt.Printf("  ⚠ Warning: Database schema is version %d, newer than this newsfed knows (%d)\n", version, latest)
+		fmt.Println("    Upgrade newsfed to the release that last opened this database")
+		hasWarnings = true
+	} else if verbose && latest > 0 {
+		fmt.Printf("  Schema version: %d (current)\n", version)
+	}
+
+	sourceList, err = store.ListSources(sources.SourceFilter{})
+	if err != nil {
+		return nil, hasWarnings, err
+	}
+	if verbose || len(sourceList) > 0 {
+		fmt.Printf("  Sources configured: %d\n", len(sourceList))
+	}
+
+	for _, source := range sourceList {
+		if source.SourceType != "website" {
+			continue
+		}
+		if err := discovery.ValidateScraperConfig(source.ScraperConfig); err != nil {
+			fmt.Printf("  ⚠ Warning: %s has an unusable scraper config: %v\n", source.Name, err)
+			fmt.Printf("    Fix the config and run: newsfed sources update %s -config=<file>\n", source.SourceID.String()[:8])
+			hasWarnings = true
+		}
+	}
+	return sourceList, hasWarnings, nil
+}
+
+// doctorSampleSize is how many source URLs doctor fetches by default.
+const doctorSampleSize = 5
+
+// doctorProbeTimeout bounds the URL checks of doctor as a whole.
+const doctorProbeTimeout = 15 * time.Second
+
+// checkSourceURLs fetches the URLs of up to sample enabled sources, chosen
+// at random, and reports whether any failed. Sources with a session are left
+// out, since they answer only once logged in.
+func checkSourceURLs(metadataPath string, sourceList []sources.Source, sample int, verbose bool) bool {
+	var candidates []sources.Source
+	for _, source := range sourceList {
+		if source.IsEnabled() && !source.HasSession {
+			candidates = append(candidates, source)
+		}
+	}
+	if len(candidates) == 0 {
+		return false
+	}
+	rand.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+	candidates = candidates[:min(sample, len(candidates))]
+
+	fmt.Printf("Source URLs (%d of %d enabled, chosen at random):\n", len(candidates), countEnabled(sourceList))
+
+	fileConfig, _ := config.LoadConfigFile()
+	config := discovery.DefaultDiscoveryConfig()
+	config.ProxyURL, _ = loadProxyURL(fileConfig)
+	service := discovery.NewDiscoveryService(nil, nil, config)
+	defer func() { _ = service.Close() }()
+	if secretStore, err := openSecrets(metadataPath); err == nil {
+		defer func() { _ = secretStore.Close() }()
+		service.SetSecrets(secretStore)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), doctorProbeTimeout)
+	defer cancel()
+	probeErrs := make([]error, len(candidates))
+	var wg sync.WaitGroup
+	for i, source := range candidates {
+		wg.Go(func() {
+			probeErrs[i] = service.ProbeSource(ctx, source)
+		})
+	}
+	wg.Wait()
+
+	failed := 0
+	for i, source := range candidates {
+		err := probeErrs[i]
+		if err == nil {
+			if verbose {
+				fmt.Printf("  ✓ %s\n", source.URL)
+			}
+			continue
+		}
+		failed++
+		id := source.SourceID.String()[:8]
+		fmt.Printf("  ⚠ Warning: %s could not be fetched: %v\n", source.Name, err)
+		var statusErr *discovery.HTTPStatusError
+		if errors.As(err, &statusErr) && statusErr.Permanent() {
+			fmt.Println("    If it has moved, add its new URL with 'newsfed sources add' and delete this source")
+			fmt.Printf("    If it is gone, run: newsfed sources disable %s\n", id)
+		} else {
+			fmt.Printf("    Check your network or proxy, then run: newsfed sync %s\n", id)
+		}
+	}
+	if failed == 0 {
+		fmt.Printf("  ✓ All %d URL(s) answered\n", len(candidates))
+	}
+	return failed > 0
+}
+
+// countEnabled returns how many of sourceList are enabled.
+func countEnabled(sourceList []sources.Source) int {
+	n := 0
+	for _, source := range sourceList {
+		if source.IsEnabled() {
+			n++
+		}
+	}
+	return n
+}
+
+// checkPostgresFeed runs the doctor checks for a PostgreSQL-backed news feed
+// and reports whether any errors or warnings were found.
+func checkPostgresFeed(feedDSN string, verbose bool) (hasErrors, hasWarnings bool) {
+	newsFeed, err := newsfeed.Open(feedDSN)
+	if err != nil {
+		fmt.Printf("  ✗ Failed to open database: %v\n", err)
+		return true, false
+	}
+	defer func() { _ = newsFeed.Close() }()
+	fmt.Println("  ✓ Database is accessible")
+
+	return checkFeedItems(newsFeed, verbose)
+}
+
+// checkFeedItems runs the doctor checks common to every feed backend: that
+// the storage can be written to, how many items it holds, and whether any
+// are damaged, misnamed, or left behind by an interrupted write. It reports
+// whether any errors or warnings were found.
+func checkFeedItems(newsFeed *newsfeed.NewsFeed, verbose bool) (hasErrors, hasWarnings bool) {
+	if err := newsFeed.Ping(); err != nil {
+		fmt.Printf("  ✗ Storage is not writable: %v\n", err)
+		fmt.Println("    Check that you own the storage and can write to it")
+		hasErrors = true
+	}
+
+	result, err := newsFeed.List()
+	if err != nil {
+		fmt.Printf("  ⚠ Warning: Could not list items: %v\n", err)
+		return hasErrors, true
+	}
+	if verbose || len(result.Items) > 0 {
+		fmt.Printf("  News items stored: %d\n", len(result.Items))
+	}
+	if len(result.Errors) > 0 {
+		fmt.Printf("  ⚠ Warning: %d item(s) could not be read\n", len(result.Errors))
+		hasWarnings = true
+	}
+
+	report, err := newsFeed.Check(false)
+	if err != nil {
+		fmt.Printf("  ⚠ Warning: Could not check stored items: %v\n", err)
+		return hasErrors, true
+	}
+	if len(report.Issues) > 0 {
+		fmt.Printf("  ⚠ Warning: %d problem(s) with stored items\n", len(report.Issues))
+		if verbose {
+			for _, issue := range report.Issues {
+				if issue.Filename != "" {
+					fmt.Printf("    %s: %s\n", issue.Filename, issue.Problem)
+				} else {
+					fmt.Printf("    %s\n", issue.Problem)
+				}
+			}
+		}
+		fmt.Println("    Run 'newsfed fsck' to repair them")
+		hasWarnings = true
+	}
+	return hasErrors, hasWarnings
+}
+
+// redactDSN hides the password in a database URL so it can be printed.
+// Paths are returned unchanged.
+func redactDSN(dsn string) string {
+	if !sqldb.IsPostgres(dsn) {
+		return dsn
+	}
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "postgres://..."
+	}
+	return u.Redacted()
+}
+
+// checkSQLiteFeed runs the doctor checks for a SQLite-backed news feed and
+// reports whether any errors or warnings were found, and whether the
+// database is missing.
+func checkSQLiteFeed(feedDSN, feedPath string, verbose bool) (hasErrors, hasWarnings, missing bool) {
+	stat, err := os.Stat(feedPath)
+	if os.IsNotExist(err) {
+		fmt.Println("  ✗ Database file does not exist")
+		fmt.Println("    Run 'newsfed init' to create it")
+		return true, false, true
+	} else if err != nil {
+		fmt.Printf("  ✗ Cannot access database file: %v\n", err)
+		return true, false, false
+	}
+
+	newsFeed, err := newsfeed.Open(feedDSN)
+	if err != nil {
+		fmt.Printf("  ✗ Failed to open database: %v\n", err)
+		return true, false, false
+	}
+	defer func() { _ = newsFeed.Close() }()
+	fmt.Println("  ✓ Database is accessible")
+
+	// Database files should be 0600 (owner read/write only)
+	perm := stat.Mode().Perm()
+	if verbose {
+		fmt.Printf("  Permissions: %o\n", perm)
+	}
+	if perm&0o077 != 0 {
+		fmt.Println("  ⚠ Warning: Database file has overly permissive permissions")
+		fmt.Printf("    Current: %o, expected: 600\n", perm)
+		fmt.Println("    Consider: chmod 600 " + feedPath)
+		hasWarnings = true
+	}
+
+	feedErrors, feedWarnings := checkFeedItems(newsFeed, verbose)
+	return feedErrors, hasWarnings || feedWarnings, false
+}
+
+func handleFsck(feedDSN string, args []string) {
+	fs := flag.NewFlagSet("fsck", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "Report problems without repairing them")
+	_ = fs.Parse(args)
+
+	newsFeed, err := newsfeed.Open(feedDSN)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to open news feed: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() { _ = newsFeed.Close() }()
+
+	fmt.Printf("Checking news feed at %s...\n", feedDSN)
+
+	report, err := newsFeed.Check(!*dryRun)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: check failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("  Items checked: %d\n", report.Checked)
+	if len(report.Issues) == 0 {
+		fmt.Println()
+		fmt.Println("✓ No problems found")
+		return
+	}
+
+	unrepaired := 0
+	fmt.Println()
+	for _, issue := range report.Issues {
+		status := "✗"
+		if issue.Repaired {
+			status = "✓ repaired:"
+		} else {
+			unrepaired++
+		}
+		if issue.Filename != "" {
+			fmt.Printf("  %s %s: %s\n", status, issue.Filename, issue.Problem)
+		} else {
+			fmt.Printf("  %s %s\n", status, issue.Problem)
+		}
+	}
+
+	fmt.Println()
+	if unrepaired == 0 {
+		fmt.Printf("✓ Repaired %d problem(s)\n", len(report.Issues))
+		return
+	}
+	if *dryRun {
+		fmt.Printf("Found %d problem(s). Run without -dry-run to repair.\n", unrepaired)
+	} else {
+		fmt.Printf("✗ %d problem(s) could not be repaired\n", unrepaired)
+	}
+	os.Exit(1)
+}
+
+// migrateProgressEvery is how many items migrate copies between progress
+// lines.
+const migrateProgressEvery = 100
+
+func handleMigrate(feedDSN string, args []string) {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	from := fs.String("from", feedDSN, "News feed to copy from (default: the configured feed)")
+	to := fs.String("to", "", "News feed to copy to, e.g. sqlite://feed.db")
+	_ = fs.Parse(args)
+
+	if *to == "" {
+		fmt.Fprintf(os.Stderr, "Error: -to is required\n")
+		fmt.Fprintf(os.Stderr, "Usage: newsfed migrate -to <dsn> [-from <dsn>]\n")
+		os.Exit(1)
+	}
+	if *to == *from {
+		fmt.Fprintf(os.Stderr, "Error: -from and -to are the same feed\n")
+		os.Exit(1)
+	}
+
+	source, err := newsfeed.Open(*from)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to open %s: %v\n", *from, err)
+		os.Exit(1)
+	}
+	defer func() { _ = source.Close() }()
+
+	dest, err := newsfeed.Open(*to)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to open %s: %v\n", *to, err)
+		os.Exit(1)
+	}
+	defer func() { _ = dest.Close() }()
+
+	fmt.Printf("Migrating news feed from %s to %s...\n", *from, *to)
+
+	report, err := newsfeed.Migrate(source, dest, newsfeed.MigrateOptions{
+		Progress: func(done, total int) {
+			if done%migrateProgressEvery == 0 || done == total {
+				fmt.Printf("  %d/%d items\n", done, total)
+			}
+		},
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: migration failed: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Items already copied are kept; run the command again to resume.\n")
+		_ = source.Close()
+		_ = dest.Close()
+		os.Exit(1)
+	}
+
+	fmt.Println()
+	fmt.Printf("  Copied: %d\n", report.Copied)
+	fmt.Printf("  Updated: %d\n", report.Updated)
+	fmt.Printf("  Already present: %d\n", report.Unchanged)
+
+	if len(report.ReadErrors) > 0 {
+		fmt.Fprintf(os.Stderr, "\nWarning: %d item(s) could not be read and were not copied:\n", len(report.ReadErrors))
+		for _, readErr := range report.ReadErrors {
+			fmt.Fprintf(os.Stderr, "  %s\n", readErr.Error())
+		}
+	}
+
+	fmt.Println()
+	fmt.Printf("✓ Verified %d item(s) in %s\n", report.Total, *to)
+}
+
+// metadataSchemaStatus returns how far each versioned part of the metadata
+// database's schema has been migrated.
+func metadataSchemaStatus(db *sqldb.DB) ([]sqldb.SchemaStatus, error) {
+	var statuses []sqldb.SchemaStatus
+	for _, status := range []func(*sqldb.DB) (sqldb.SchemaStatus, error){
+		sources.SchemaStatus,
+		config.SchemaStatus,
+		audit.SchemaStatus,
+		secrets.SchemaStatus,
+		users.SchemaStatus,
+		collections.SchemaStatus,
+	} {
+		s, err := status(db)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, s)
+	}
+	return statuses, nil
+}
+
+func handleMigrateDB(metadataPath string, args []string) {
+	if len(args) < 1 {
+		fmt.Fprintf(os.Stderr, "Error: action is required\n")
+		fmt.Fprintf(os.Stderr, "Usage: newsfed migrate-db status|up\n")
+		os.Exit(1)
+	}
+
+	fs := flag.NewFlagSet("migrate-db "+args[0], flag.ExitOnError)
+	format := formatFlag(fs)
+	parseInterspersed(fs, args[1:])
+	checkFormat(*format)
+
+	if !sqldb.IsPostgres(metadataPath) {
+		if _, err := os.Stat(metadataPath); os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "Error: metadata database not found: %s\n", metadataPath)
+			fmt.Fprintf(os.Stderr, "Run 'newsfed init' to create it.\n")
+			os.Exit(1)
+		}
+	}
+
+	switch args[0] {
+	case "status":
+	case "up":
+		// Opening the stores applies their pending migrations
+		metadataStore, err := sources.NewSourceStore(metadataPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to migrate metadata database: %v\n", err)
+			os.Exit(1)
+		}
+		_ = metadataStore.Close()
+		configStore, err := config.NewConfigStore(metadataPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to migrate metadata database: %v\n", err)
+			os.Exit(1)
+		}
+		_ = configStore.Close()
+		secretStore, err := secrets.Open(metadataPath, "")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to migrate metadata database: %v\n", err)
+			os.Exit(1)
+		}
+		_ = secretStore.Close()
+		userStore, err := users.NewUserStore(metadataPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to migrate metadata database: %v\n", err)
+			os.Exit(1)
+		}
+		_ = userStore.Close()
+		collectionStore, err := collections.NewCollectionStore(metadataPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to migrate metadata database: %v\n", err)
+			os.Exit(1)
+		}
+		_ = collectionStore.Close()
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown action: %s (use status or up)\n", args[0])
+		os.Exit(1)
+	}
+
+	db, err := sqldb.Open(metadataPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() { _ = db.Close() }()
+
+	statuses, err := metadataSchemaStatus(db)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to read schema versions: %v\n", err)
+		_ = db.Close()
+		os.Exit(1)
+	}
+
+	if structured(*format) {
+		printStructured(*format, statuses)
+		return
+	}
+
+	fmt.Printf("Metadata database: %s\n\n", redactDSN(metadataPath))
+	fmt.Printf("%-12s %-8s %-8s %-8s %s\n", "SCHEMA", "VERSION", "LATEST", "PENDING", "APPLIED")
+	pending, newer := 0, false
+	for _, status := range statuses {
+		applied := "-"
+		if status.AppliedAt != nil {
+			applied = status.AppliedAt.Local().Format("2006-01-02 15:04:05")
+		}
+		fmt.Printf("%-12s %-8d %-8d %-8d %s\n", status.Component, status.Version, status.Latest, status.Pending(), applied)
+		pending += status.Pending()
+		newer = newer || status.Version > status.Latest
+	}
+
+	fmt.Println()
+	switch {
+	case newer:
+		fmt.Println("⚠ Warning: The database has been migrated by a newer newsfed than this one")
+	case pending > 0:
+		fmt.Printf("%d migration(s) pending; run 'newsfed migrate-db up' to apply them\n", pending)
+		fmt.Println("(they are also applied the next time newsfed opens the database)")
+	default:
+		fmt.Println("✓ Schema is up to date")
+	}
+}
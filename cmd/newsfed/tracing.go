@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// tracingFlushTimeout bounds how long exiting commands wait to send their
+// remaining spans.
+const tracingFlushTimeout = 5 * time.Second
+
+// setupTracing exports discovery spans over OTLP/HTTP when an OTLP endpoint
+// is configured with the standard OTEL_EXPORTER_OTLP_ENDPOINT or
+// OTEL_EXPORTER_OTLP_TRACES_ENDPOINT variables. The exporter reads the rest
+// of its settings (headers, TLS, timeouts) from the standard variables too.
+// The returned function flushes and stops the exporter; it is a no-op when
+// tracing is off.
+func setupTracing() func() {
+	if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" && os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") == "" {
+		return func() {}
+	}
+
+	ctx := context.Background()
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: tracing disabled: %v\n", err)
+		return func() {}
+	}
+
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES override the defaults
+	res, err := resource.New(ctx,
+		resource.WithAttributes(attribute.String("service.name", "newsfed")),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: tracing resource: %v\n", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), tracingFlushTimeout)
+		defer cancel()
+		if err := provider.Shutdown(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to flush traces: %v\n", err)
+		}
+	}
+}
@@ -4,13 +4,19 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/pevans/newsfed/audit"
+	"github.com/pevans/newsfed/config"
 	"github.com/pevans/newsfed/discovery"
-	"github.com/pevans/newsfed/newsfeed"
 	"github.com/pevans/newsfed/sources"
 	"github.com/pevans/newsfed/tui"
 )
 
-func handleTUI(metadataPath, feedDir string) {
+func handleTUI(metadataPath, feedDSN string) {
+	audit.Actor = "tui"
+	if feedUser != nil {
+		audit.Actor = "tui:" + feedUser.Name
+	}
+
 	sourceStore, err := sources.NewSourceStore(metadataPath)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: failed to open source store: %v\n", err)
@@ -18,13 +24,64 @@ func handleTUI(metadataPath, feedDir string) {
 	}
 	defer func() { _ = sourceStore.Close() }()
 
-	newsFeed, err := newsfeed.NewNewsFeed(feedDir)
+	newsFeed, err := openFeed(feedDSN)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: failed to open news feed: %v\n", err)
 		os.Exit(1)
 	}
+	defer func() { _ = newsFeed.Close() }()
 
-	discSvc := discovery.NewDiscoveryService(sourceStore, newsFeed, nil)
+	fileConfig, err := config.LoadConfigFile()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	discoveryConfig := discovery.DefaultDiscoveryConfig()
+	discoveryConfig.ProxyURL, err = loadProxyURL(fileConfig)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if fileConfig != nil {
+		discoveryConfig.KeepSummaryHTML = fileConfig.Discovery.KeepSummaryHTML
+	}
+	if err := loadRenderSettings(discoveryConfig, fileConfig); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := loadMaxItemAge(discoveryConfig, fileConfig); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := loadMaxResponseSize(discoveryConfig, fileConfig); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := loadAdaptivePolling(discoveryConfig, fileConfig); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := loadJournal(discoveryConfig, fileConfig); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	discSvc := discovery.NewDiscoveryService(sourceStore, newsFeed, discoveryConfig)
+	defer func() { _ = discSvc.Close() }()
+	secretStore, err := openSecrets(metadataPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to open secrets: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() { _ = secretStore.Close() }()
+	discSvc.SetSecrets(secretStore)
+	summaryEnricher, err := loadSummaryEnricher(fileConfig)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if summaryEnricher != nil {
+		discSvc.AddEnricher(summaryEnricher)
+	}
 
 	if err := tui.Run(sourceStore, newsFeed, discSvc); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: TUI exited with error: %v\n", err)
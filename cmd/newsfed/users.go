@@ -0,0 +1,168 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pevans/newsfed/newsfeed"
+	"github.com/pevans/newsfed/users"
+)
+
+// feedUser is the user selected with --user or NEWSFED_USER, whose item
+// state openFeed shows; nil selects the default user.
+var feedUser *users.User
+
+// userStore holds feedUser's item state. It stays open until the process
+// exits.
+var userStore *users.UserStore
+
+// parseUserFlag removes a leading --user <name> (or --user=<name>, with one
+// dash or two) from args, returning the name and the remaining arguments.
+func parseUserFlag(args []string) (string, []string) {
+	if len(args) == 0 {
+		return "", args
+	}
+	for _, prefix := range []string{"--user", "-user"} {
+		if args[0] == prefix && len(args) > 1 {
+			return args[1], args[2:]
+		}
+		if name, ok := strings.CutPrefix(args[0], prefix+"="); ok {
+			return name, args[1:]
+		}
+	}
+	return "", args
+}
+
+// selectUser makes openFeed show the item state of the user called name.
+func selectUser(metadataPath, name string) {
+	store, err := users.NewUserStore(metadataPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to open user store: %v\n", err)
+		os.Exit(1)
+	}
+	user, err := store.Get(name)
+	if err != nil {
+		_ = store.Close()
+		fmt.Fprintf(os.Stderr, "Error: %s: %v\n", name, err)
+		fmt.Fprintf(os.Stderr, "Add users with: newsfed users add <name>\n")
+		os.Exit(1)
+	}
+	feedUser, userStore = user, store
+}
+
+// openFeed opens the news feed at feedDSN as seen by the selected user.
+// Commands that change what every reader sees, such as prune, open the feed
+// directly instead.
+func openFeed(feedDSN string) (*newsfeed.NewsFeed, error) {
+	feed, err := newsfeed.Open(feedDSN)
+	if err != nil || feedUser == nil {
+		return feed, err
+	}
+	return feed.ForUser(feedUser.Name, userStore), nil
+}
+
+func printUsersUsage() {
+	fmt.Println("Usage: newsfed users <command> [arguments]")
+	fmt.Println()
+	fmt.Println("Commands:")
+	fmt.Println("  list            List users (default)")
+	fmt.Println("  add <name>      Add a user and print their API token")
+	fmt.Println("  token <name>    Issue a new API token, replacing the old one")
+	fmt.Println("  delete <name>   Delete a user and their item state")
+	fmt.Println()
+	fmt.Println("Select a user for any command with: newsfed --user <name> <command>")
+}
+
+func handleUsers(metadataPath string, args []string) {
+	store, err := users.NewUserStore(metadataPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to open user store: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() { _ = store.Close() }()
+
+	action := "list"
+	if len(args) > 0 {
+		action, args = args[0], args[1:]
+	}
+
+	switch action {
+	case "list":
+		handleUsersList(store)
+	case "add":
+		handleUsersAdd(store, args)
+	case "token":
+		handleUsersToken(store, args)
+	case "delete":
+		handleUsersDelete(store, args)
+	case "help", "--help", "-h":
+		printUsersUsage()
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown users command: %s\n\n", action)
+		printUsersUsage()
+		os.Exit(1)
+	}
+}
+
+func handleUsersList(store *users.UserStore) {
+	list, err := store.List()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to list users: %v\n", err)
+		os.Exit(1)
+	}
+	if len(list) == 0 {
+		fmt.Println("No users. Item state belongs to the default user.")
+		return
+	}
+	for _, user := range list {
+		fmt.Printf("%s added %s\n", padRight(user.Name, 20), user.CreatedAt.Local().Format("2006-01-02 15:04:05"))
+	}
+}
+
+func handleUsersAdd(store *users.UserStore, args []string) {
+	fs := flag.NewFlagSet("users add", flag.ExitOnError)
+	_ = fs.Parse(args)
+	if fs.NArg() != 1 {
+		fmt.Fprintf(os.Stderr, "Usage: newsfed users add <name>\n")
+		os.Exit(1)
+	}
+
+	user, token, err := store.Create(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("✓ Added user %s\n", user.Name)
+	fmt.Printf("  API token: %s\n", token)
+	fmt.Println("  The token is not shown again; issue a new one with: newsfed users token " + user.Name)
+}
+
+func handleUsersToken(store *users.UserStore, args []string) {
+	if len(args) != 1 {
+		fmt.Fprintf(os.Stderr, "Usage: newsfed users token <name>\n")
+		os.Exit(1)
+	}
+
+	token, err := store.ResetToken(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("✓ Issued a new API token for %s\n", args[0])
+	fmt.Printf("  API token: %s\n", token)
+}
+
+func handleUsersDelete(store *users.UserStore, args []string) {
+	if len(args) != 1 {
+		fmt.Fprintf(os.Stderr, "Usage: newsfed users delete <name>\n")
+		os.Exit(1)
+	}
+
+	if err := store.Delete(args[0]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("✓ Deleted user %s\n", args[0])
+}
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"net/http"
 	"strings"
 	"time"
 )
@@ -38,3 +40,74 @@ func parseDuration(s string) (time.Duration, error) {
 
 	return 0, fmt.Errorf("invalid duration: %s", s)
 }
+
+// headerFlag collects repeated -header "Name: Value" flags.
+type headerFlag map[string]string
+
+func (h headerFlag) String() string {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	return strings.Join(names, ", ")
+}
+
+func (h headerFlag) Set(value string) error {
+	name, val, ok := strings.Cut(value, ":")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" || strings.ContainsAny(name, " \t") {
+		return fmt.Errorf("header must be in the form \"Name: Value\"")
+	}
+	h[http.CanonicalHeaderKey(name)] = strings.TrimSpace(val)
+	return nil
+}
+
+// listFlag collects the values of a repeated flag.
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// metadataFlag collects repeated -metadata key=value flags.
+type metadataFlag map[string]string
+
+func (m metadataFlag) String() string {
+	pairs := make([]string, 0, len(m))
+	for key, value := range m {
+		pairs = append(pairs, key+"="+value)
+	}
+	return strings.Join(pairs, ", ")
+}
+
+func (m metadataFlag) Set(value string) error {
+	key, val, ok := strings.Cut(value, "=")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" {
+		return fmt.Errorf("metadata filter must be in the form \"key=value\"")
+	}
+	m[key] = val
+	return nil
+}
+
+// parseInterspersed parses fs from args, allowing flags to appear before,
+// between, or after positional arguments, and returns the positional
+// arguments in order. The flag package stops at the first positional
+// argument, so parsing resumes after each one.
+func parseInterspersed(fs *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		_ = fs.Parse(args)
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
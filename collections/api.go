@@ -0,0 +1,233 @@
+package collections
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/pevans/newsfed/internal/httpjson"
+	"github.com/pevans/newsfed/newsfeed"
+)
+
+// maxRequestBody bounds the size of API request bodies.
+const maxRequestBody = 1 << 16
+
+// createRequest is the body of a request to create a collection.
+type createRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// addItemRequest is the body of a request to add an item to a collection.
+// Position, counting from 1, places the item; otherwise it is appended.
+type addItemRequest struct {
+	ItemID   uuid.UUID `json:"item_id"`
+	Position int       `json:"position,omitempty"`
+}
+
+// ListHandler lists every collection for GET /api/v1/collections.
+func (s *CollectionStore) ListHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		collections, err := s.List()
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		httpjson.Write(w, http.StatusOK, collections)
+	})
+}
+
+// CreateHandler creates a collection for POST /api/v1/collections from a
+// body of {"name": "...", "description": "..."}, responding 201 Created with
+// the collection.
+func (s *CollectionStore) CreateHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req createRequest
+		if !decodeBody(w, r, &req) {
+			return
+		}
+		c, err := s.Create(req.Name, req.Description)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		w.Header().Set("Location", r.URL.Path+"/"+c.Name)
+		httpjson.Write(w, http.StatusCreated, c)
+	})
+}
+
+// GetHandler reports a collection for GET /api/v1/collections/{name}.
+func (s *CollectionStore) GetHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := s.Get(r.PathValue("name"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		httpjson.Write(w, http.StatusOK, c)
+	})
+}
+
+// UpdateHandler renames a collection or changes its description for
+// PATCH /api/v1/collections/{name}, from a body of CollectionUpdate fields.
+func (s *CollectionStore) UpdateHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var update CollectionUpdate
+		if !decodeBody(w, r, &update) {
+			return
+		}
+		c, err := s.Update(r.PathValue("name"), update)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		httpjson.Write(w, http.StatusOK, c)
+	})
+}
+
+// DeleteHandler deletes a collection for DELETE /api/v1/collections/{name},
+// responding 204 No Content.
+func (s *CollectionStore) DeleteHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := s.Delete(r.PathValue("name")); err != nil {
+			writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// AddItemHandler adds an item from feed to a collection for
+// POST /api/v1/collections/{name}/items, from a body of
+// {"item_id": "...", "position": n}, responding with the collection. Adding
+// an item that is already in the collection moves it when a position is
+// given.
+func (s *CollectionStore) AddItemHandler(feed *newsfeed.NewsFeed) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req addItemRequest
+		if !decodeBody(w, r, &req) {
+			return
+		}
+		if req.Position < 0 {
+			httpjson.Error(w, http.StatusBadRequest, "position must be at least 1")
+			return
+		}
+		item, err := feed.Get(req.ItemID)
+		if err != nil {
+			httpjson.Error(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if item == nil {
+			httpjson.Error(w, http.StatusNotFound, newsfeed.ErrItemNotFound.Error())
+			return
+		}
+
+		name := r.PathValue("name")
+		if _, err := s.AddItem(name, req.ItemID); err != nil {
+			writeError(w, err)
+			return
+		}
+		if req.Position > 0 {
+			if err := s.MoveItem(name, req.ItemID, req.Position); err != nil {
+				writeError(w, err)
+				return
+			}
+		}
+
+		c, err := s.Get(name)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		httpjson.Write(w, http.StatusOK, c)
+	})
+}
+
+// RemoveItemHandler takes an item out of a collection for
+// DELETE /api/v1/collections/{name}/items/{id}, responding 204 No Content.
+func (s *CollectionStore) RemoveItemHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			httpjson.Error(w, http.StatusBadRequest, "invalid item ID")
+			return
+		}
+		if err := s.RemoveItem(r.PathValue("name"), id); err != nil {
+			writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// ExportHandler writes a collection's items from feed for
+// GET /api/v1/collections/{name}/export, as Markdown unless the format query
+// parameter names another newsfeed export format.
+func (s *CollectionStore) ExportHandler(feed *newsfeed.NewsFeed) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = newsfeed.ExportMarkdown
+		}
+		contentType, ok := newsfeed.ExportContentType(format)
+		if !ok {
+			httpjson.Error(w, http.StatusBadRequest, "invalid format: "+format)
+			return
+		}
+
+		c, err := s.Get(r.PathValue("name"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		items, _, err := c.Items(feed)
+		if err != nil {
+			httpjson.Error(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		// Render first so that a failure can still be reported as JSON
+		var buf bytes.Buffer
+		if err := newsfeed.Export(&buf, format, c.ExportInfo(), items); err != nil {
+			httpjson.Error(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", contentType)
+		_, _ = buf.WriteTo(w)
+	})
+}
+
+// ExportInfo describes c for newsfeed.Export.
+func (c *Collection) ExportInfo() newsfeed.ExportInfo {
+	return newsfeed.ExportInfo{
+		Title:       c.Name,
+		Description: c.Description,
+	}
+}
+
+// decodeBody decodes a JSON request body into v, responding 400 Bad Request
+// and returning false if it can't.
+func decodeBody(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxRequestBody)).Decode(v); err != nil {
+		httpjson.Error(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// writeError writes err with the status that fits it.
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrCollectionNotFound), errors.Is(err, ErrItemNotInList):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrCollectionExists):
+		status = http.StatusConflict
+	case errors.Is(err, ErrInvalidName):
+		status = http.StatusBadRequest
+	}
+	httpjson.Error(w, status, err.Error())
+}
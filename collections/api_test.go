@@ -0,0 +1,118 @@
+package collections
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/pevans/newsfed/newsfeed"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test helper: serve the collection API for a store and feed the way the
+// daemon does
+func newTestAPI(t *testing.T) (*httptest.Server, *newsfeed.NewsFeed) {
+	store := createTestCollectionStore(t)
+	feed := newsfeed.NewMemoryNewsFeed()
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /api/v1/collections", store.ListHandler())
+	mux.Handle("POST /api/v1/collections", store.CreateHandler())
+	mux.Handle("GET /api/v1/collections/{name}", store.GetHandler())
+	mux.Handle("PATCH /api/v1/collections/{name}", store.UpdateHandler())
+	mux.Handle("DELETE /api/v1/collections/{name}", store.DeleteHandler())
+	mux.Handle("POST /api/v1/collections/{name}/items", store.AddItemHandler(feed))
+	mux.Handle("DELETE /api/v1/collections/{name}/items/{id}", store.RemoveItemHandler())
+	mux.Handle("GET /api/v1/collections/{name}/export", store.ExportHandler(feed))
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server, feed
+}
+
+// Test helper: send a request with an optional JSON body
+func doRequest(t *testing.T, method, url, body string) *http.Response {
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = resp.Body.Close() })
+	return resp
+}
+
+// TestAPI_CRUD verifies creating, reading, renaming, and deleting a
+// collection over HTTP, with errors mapped to status codes
+func TestAPI_CRUD(t *testing.T) {
+	server, _ := newTestAPI(t)
+	base := server.URL + "/api/v1/collections"
+
+	resp := doRequest(t, http.MethodPost, base, `{"name": "interview prep", "description": "Design reading"}`)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	resp = doRequest(t, http.MethodPost, base, `{"name": "Interview Prep"}`)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	resp = doRequest(t, http.MethodPost, base, `{"name": ""}`)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp = doRequest(t, http.MethodPatch, base+"/interview%20prep", `{"name": "interviews"}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = doRequest(t, http.MethodGet, base, "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var list []Collection
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+	require.Len(t, list, 1)
+	assert.Equal(t, "interviews", list[0].Name)
+	assert.Equal(t, "Design reading", list[0].Description)
+
+	resp = doRequest(t, http.MethodDelete, base+"/interviews", "")
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	resp = doRequest(t, http.MethodGet, base+"/interviews", "")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+// TestAPI_ItemsAndExport verifies adding items at a position, removing them,
+// and exporting the collection as Markdown in order
+func TestAPI_ItemsAndExport(t *testing.T) {
+	server, feed := newTestAPI(t)
+	base := server.URL + "/api/v1/collections"
+
+	first := newsfeed.NewsItem{ID: uuid.New(), Title: "First", URL: "https://example.com/1"}
+	second := newsfeed.NewsItem{ID: uuid.New(), Title: "Second", URL: "https://example.com/2"}
+	require.NoError(t, feed.Add(first))
+	require.NoError(t, feed.Add(second))
+
+	doRequest(t, http.MethodPost, base, `{"name": "reading"}`)
+	resp := doRequest(t, http.MethodPost, base+"/reading/items", `{"item_id": "`+first.ID.String()+`"}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = doRequest(t, http.MethodPost, base+"/reading/items", `{"item_id": "`+second.ID.String()+`", "position": 1}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var c Collection
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&c))
+	assert.Equal(t, []uuid.UUID{second.ID, first.ID}, c.ItemIDs)
+
+	// Items must exist in the feed
+	resp = doRequest(t, http.MethodPost, base+"/reading/items", `{"item_id": "`+uuid.NewString()+`"}`)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp = doRequest(t, http.MethodGet, base+"/reading/export", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "text/markdown")
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	markdown := string(body)
+	assert.Contains(t, markdown, "# reading")
+	assert.Less(t, strings.Index(markdown, "Second"), strings.Index(markdown, "First"))
+
+	resp = doRequest(t, http.MethodDelete, base+"/reading/items/"+second.ID.String(), "")
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	resp = doRequest(t, http.MethodDelete, base+"/reading/items/"+second.ID.String(), "")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp = doRequest(t, http.MethodGet, base+"/reading/export?format=pdf", "")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
@@ -0,0 +1,505 @@
+// Package collections keeps named, ordered reading lists of news items, such
+// as "interview prep", in the metadata database. Collections hold item IDs
+// only; the items themselves stay in the news feed.
+package collections
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pevans/newsfed/newsfeed"
+	"github.com/pevans/newsfed/sqldb"
+)
+
+var (
+	ErrCollectionNotFound = errors.New("collection not found")
+	ErrCollectionExists   = errors.New("a collection with this name already exists")
+	ErrInvalidName        = errors.New("collection name must not be empty")
+	ErrItemNotInList      = errors.New("item is not in the collection")
+)
+
+// Collection is a named, ordered list of news items.
+type Collection struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+	ItemIDs     []uuid.UUID `json:"item_ids"`
+}
+
+// CollectionUpdate holds the fields to change on a collection. Nil fields are
+// left alone.
+type CollectionUpdate struct {
+	Name        *string `json:"name,omitempty"`
+	Description *string `json:"description,omitempty"`
+}
+
+// CollectionStore manages collections in SQLite or PostgreSQL.
+type CollectionStore struct {
+	db *sqldb.DB
+}
+
+// NewCollectionStore creates a new collection store with the given database
+// path, or a postgres:// DSN.
+func NewCollectionStore(dbPath string) (*CollectionStore, error) {
+	db, err := sqldb.Open(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	store := &CollectionStore{db: db}
+	if err := store.initSchema(); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to initialize schema: %w", err)
+	}
+
+	return store, nil
+}
+
+// postgresMigrations is the PostgreSQL schema, as migrations applied in
+// order by sqldb. Names are unique regardless of case, as in SQLite.
+var postgresMigrations = []string{
+	`
+	CREATE TABLE collections (
+		name TEXT PRIMARY KEY,
+		description TEXT NOT NULL DEFAULT '',
+		created_at TEXT NOT NULL,
+		updated_at TEXT NOT NULL
+	);
+	CREATE UNIQUE INDEX collections_name_lower ON collections (lower(name));
+
+	CREATE TABLE collection_items (
+		collection TEXT NOT NULL REFERENCES collections(name) ON DELETE CASCADE ON UPDATE CASCADE,
+		item_id TEXT NOT NULL,
+		position INTEGER NOT NULL,
+		added_at TEXT NOT NULL,
+		PRIMARY KEY (collection, item_id)
+	);
+	`,
+}
+
+// sqliteMigrations are the SQLite collections schema, applied in order by
+// initSchema. The first is the schema as it stood when SQLite databases
+// began to be versioned, which a database made before then already has;
+// like postgresMigrations, new ones must only ever be appended.
+var sqliteMigrations = []string{
+	`
+	CREATE TABLE IF NOT EXISTS collections (
+		name TEXT PRIMARY KEY COLLATE NOCASE,
+		description TEXT NOT NULL DEFAULT '',
+		created_at TEXT NOT NULL,
+		updated_at TEXT NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS collection_items (
+		collection TEXT NOT NULL,
+		item_id TEXT NOT NULL,
+		position INTEGER NOT NULL,
+		added_at TEXT NOT NULL,
+		PRIMARY KEY (collection, item_id)
+	);
+	`,
+}
+
+// migrationsFor returns the collections migrations for dialect.
+func migrationsFor(dialect sqldb.Dialect) []string {
+	if dialect == sqldb.Postgres {
+		return postgresMigrations
+	}
+	return sqliteMigrations
+}
+
+// SchemaStatus returns how far the collections schema of db has been
+// migrated, without migrating it, for newsfed migrate-db status.
+func SchemaStatus(db *sqldb.DB) (sqldb.SchemaStatus, error) {
+	return db.SchemaStatus("collections", len(migrationsFor(db.Dialect)))
+}
+
+// initSchema creates the collection tables, or brings them up to date, when
+// the store is opened.
+func (s *CollectionStore) initSchema() error {
+	return s.db.Migrate("collections", migrationsFor(s.db.Dialect))
+}
+
+// Close closes the database connection.
+func (s *CollectionStore) Close() error {
+	return s.db.Close()
+}
+
+// Create adds an empty collection.
+func (s *CollectionStore) Create(name, description string) (*Collection, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrInvalidName
+	}
+
+	err := s.inTx(func(tx *sqldb.Tx) error {
+		if _, err := lookupName(tx, name); err == nil {
+			return ErrCollectionExists
+		} else if !errors.Is(err, ErrCollectionNotFound) {
+			return err
+		}
+
+		now := formatTime(time.Now().UTC())
+		_, err := tx.Exec(
+			"INSERT INTO collections (name, description, created_at, updated_at) VALUES (?, ?, ?, ?)",
+			name, description, now, now)
+		if err != nil {
+			if sqldb.IsUniqueViolation(err) {
+				return ErrCollectionExists
+			}
+			return fmt.Errorf("failed to create collection: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Get(name)
+}
+
+// Get returns the collection with the given name, ignoring case, along with
+// its item IDs in order.
+func (s *CollectionStore) Get(name string) (*Collection, error) {
+	var c Collection
+	var createdAt, updatedAt string
+	err := s.db.QueryRow(
+		"SELECT name, description, created_at, updated_at FROM collections WHERE lower(name) = lower(?)",
+		strings.TrimSpace(name),
+	).Scan(&c.Name, &c.Description, &createdAt, &updatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrCollectionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read collection: %w", err)
+	}
+	c.CreatedAt = parseTime(createdAt)
+	c.UpdatedAt = parseTime(updatedAt)
+
+	c.ItemIDs, err = s.itemIDs(c.Name)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// List returns every collection, ordered by name.
+func (s *CollectionStore) List() ([]Collection, error) {
+	rows, err := s.db.Query("SELECT name FROM collections ORDER BY lower(name)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query collections: %w", err)
+	}
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			_ = rows.Close()
+			return nil, fmt.Errorf("failed to scan collection: %w", err)
+		}
+		names = append(names, name)
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read collections: %w", err)
+	}
+
+	collections := make([]Collection, 0, len(names))
+	for _, name := range names {
+		c, err := s.Get(name)
+		if err != nil {
+			return nil, err
+		}
+		collections = append(collections, *c)
+	}
+	return collections, nil
+}
+
+// Update renames a collection or changes its description.
+func (s *CollectionStore) Update(name string, update CollectionUpdate) (*Collection, error) {
+	newName := ""
+	if update.Name != nil {
+		newName = strings.TrimSpace(*update.Name)
+		if newName == "" {
+			return nil, ErrInvalidName
+		}
+	}
+
+	err := s.inTx(func(tx *sqldb.Tx) error {
+		current, err := lookupName(tx, name)
+		if err != nil {
+			return err
+		}
+
+		now := formatTime(time.Now().UTC())
+		if update.Description != nil {
+			_, err := tx.Exec("UPDATE collections SET description = ?, updated_at = ? WHERE name = ?",
+				*update.Description, now, current)
+			if err != nil {
+				return fmt.Errorf("failed to update collection: %w", err)
+			}
+		}
+
+		if newName != "" && newName != current {
+			// A change of case alone renames the collection in place
+			if existing, err := lookupName(tx, newName); err == nil && existing != current {
+				return ErrCollectionExists
+			} else if err != nil && !errors.Is(err, ErrCollectionNotFound) {
+				return err
+			}
+			if _, err := tx.Exec("UPDATE collections SET name = ?, updated_at = ? WHERE name = ?",
+				newName, now, current); err != nil {
+				return fmt.Errorf("failed to rename collection: %w", err)
+			}
+			// PostgreSQL cascades the rename itself
+			if tx.Dialect == sqldb.SQLite {
+				if _, err := tx.Exec("UPDATE collection_items SET collection = ? WHERE collection = ?",
+					newName, current); err != nil {
+					return fmt.Errorf("failed to rename collection: %w", err)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if newName != "" {
+		name = newName
+	}
+	return s.Get(name)
+}
+
+// Delete removes a collection. The items in it are kept in the feed.
+func (s *CollectionStore) Delete(name string) error {
+	return s.inTx(func(tx *sqldb.Tx) error {
+		current, err := lookupName(tx, name)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec("DELETE FROM collection_items WHERE collection = ?", current); err != nil {
+			return fmt.Errorf("failed to delete collection items: %w", err)
+		}
+		if _, err := tx.Exec("DELETE FROM collections WHERE name = ?", current); err != nil {
+			return fmt.Errorf("failed to delete collection: %w", err)
+		}
+		return nil
+	})
+}
+
+// AddItem appends an item to the end of a collection. It reports whether the
+// item was added; an item already in the collection keeps its place.
+func (s *CollectionStore) AddItem(name string, itemID uuid.UUID) (bool, error) {
+	added := false
+	err := s.inTx(func(tx *sqldb.Tx) error {
+		current, err := lookupName(tx, name)
+		if err != nil {
+			return err
+		}
+
+		var count int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM collection_items WHERE collection = ? AND item_id = ?",
+			current, itemID.String()).Scan(&count); err != nil {
+			return fmt.Errorf("failed to read collection items: %w", err)
+		}
+		if count > 0 {
+			return nil
+		}
+
+		var last sql.NullInt64
+		if err := tx.QueryRow("SELECT MAX(position) FROM collection_items WHERE collection = ?",
+			current).Scan(&last); err != nil {
+			return fmt.Errorf("failed to read collection items: %w", err)
+		}
+
+		now := formatTime(time.Now().UTC())
+		if _, err := tx.Exec(
+			"INSERT INTO collection_items (collection, item_id, position, added_at) VALUES (?, ?, ?, ?)",
+			current, itemID.String(), last.Int64+1, now); err != nil {
+			return fmt.Errorf("failed to add item to collection: %w", err)
+		}
+		added = true
+		return touch(tx, current, now)
+	})
+	return added, err
+}
+
+// RemoveItem takes an item out of a collection.
+func (s *CollectionStore) RemoveItem(name string, itemID uuid.UUID) error {
+	return s.inTx(func(tx *sqldb.Tx) error {
+		current, err := lookupName(tx, name)
+		if err != nil {
+			return err
+		}
+
+		result, err := tx.Exec("DELETE FROM collection_items WHERE collection = ? AND item_id = ?",
+			current, itemID.String())
+		if err != nil {
+			return fmt.Errorf("failed to remove item from collection: %w", err)
+		}
+		removed, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if removed == 0 {
+			return ErrItemNotInList
+		}
+		return touch(tx, current, formatTime(time.Now().UTC()))
+	})
+}
+
+// MoveItem moves an item to position in a collection, counting from 1.
+// Positions past the end move the item to the end.
+func (s *CollectionStore) MoveItem(name string, itemID uuid.UUID, position int) error {
+	if position < 1 {
+		return fmt.Errorf("position must be at least 1")
+	}
+
+	return s.inTx(func(tx *sqldb.Tx) error {
+		current, err := lookupName(tx, name)
+		if err != nil {
+			return err
+		}
+
+		ids, err := queryItemIDs(tx, current)
+		if err != nil {
+			return err
+		}
+		from := -1
+		for i, id := range ids {
+			if id == itemID {
+				from = i
+				break
+			}
+		}
+		if from < 0 {
+			return ErrItemNotInList
+		}
+
+		ids = append(ids[:from], ids[from+1:]...)
+		to := min(position-1, len(ids))
+		ids = append(ids[:to], append([]uuid.UUID{itemID}, ids[to:]...)...)
+
+		for i, id := range ids {
+			if _, err := tx.Exec("UPDATE collection_items SET position = ? WHERE collection = ? AND item_id = ?",
+				i+1, current, id.String()); err != nil {
+				return fmt.Errorf("failed to reorder collection: %w", err)
+			}
+		}
+		return touch(tx, current, formatTime(time.Now().UTC()))
+	})
+}
+
+// Items returns the items in c from feed, in the collection's order. Items
+// that have since been deleted from the feed are skipped, and their IDs are
+// returned as missing.
+func (c *Collection) Items(feed *newsfeed.NewsFeed) (items []newsfeed.NewsItem, missing []uuid.UUID, err error) {
+	for _, id := range c.ItemIDs {
+		item, err := feed.Get(id)
+		if err != nil {
+			return nil, nil, err
+		}
+		if item == nil {
+			missing = append(missing, id)
+			continue
+		}
+		items = append(items, *item)
+	}
+	return items, missing, nil
+}
+
+// itemIDs returns the IDs of the items in the named collection, in order.
+func (s *CollectionStore) itemIDs(name string) ([]uuid.UUID, error) {
+	rows, err := s.db.Query(
+		"SELECT item_id FROM collection_items WHERE collection = ? ORDER BY position", name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query collection items: %w", err)
+	}
+	return scanItemIDs(rows)
+}
+
+// queryItemIDs is itemIDs within a transaction.
+func queryItemIDs(tx *sqldb.Tx, name string) ([]uuid.UUID, error) {
+	rows, err := tx.Query(
+		"SELECT item_id FROM collection_items WHERE collection = ? ORDER BY position", name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query collection items: %w", err)
+	}
+	return scanItemIDs(rows)
+}
+
+// scanItemIDs reads and closes rows of item IDs.
+func scanItemIDs(rows *sql.Rows) ([]uuid.UUID, error) {
+	defer func() { _ = rows.Close() }()
+
+	ids := []uuid.UUID{}
+	for rows.Next() {
+		var idStr string
+		if err := rows.Scan(&idStr); err != nil {
+			return nil, fmt.Errorf("failed to scan collection item: %w", err)
+		}
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid item ID in collection: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// lookupName returns the stored name of the collection called name, ignoring
+// case.
+func lookupName(tx *sqldb.Tx, name string) (string, error) {
+	var current string
+	err := tx.QueryRow("SELECT name FROM collections WHERE lower(name) = lower(?)",
+		strings.TrimSpace(name)).Scan(&current)
+	if err == sql.ErrNoRows {
+		return "", ErrCollectionNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read collection: %w", err)
+	}
+	return current, nil
+}
+
+// touch sets a collection's updated time.
+func touch(tx *sqldb.Tx, name, now string) error {
+	if _, err := tx.Exec("UPDATE collections SET updated_at = ? WHERE name = ?", now, name); err != nil {
+		return fmt.Errorf("failed to update collection: %w", err)
+	}
+	return nil
+}
+
+// inTx runs fn in a transaction, committing if it succeeds and rolling back
+// otherwise.
+func (s *CollectionStore) inTx(fn func(tx *sqldb.Tx) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// formatTime formats a timestamp for storage, without its monotonic clock.
+func formatTime(t time.Time) string {
+	return t.Truncate(0).Format(time.RFC3339Nano)
+}
+
+// parseTime parses a stored timestamp.
+func parseTime(s string) time.Time {
+	t, _ := time.Parse(time.RFC3339Nano, s)
+	return t
+}
@@ -0,0 +1,213 @@
+package collections
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/pevans/newsfed/newsfeed"
+	"github.com/pevans/newsfed/sqldb"
+	"github.com/pevans/newsfed/sqldb/sqldbtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test helper: create a test collection store
+func createTestCollectionStore(t *testing.T) *CollectionStore {
+	store, err := NewCollectionStore(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err, "should create collection store")
+	t.Cleanup(func() { _ = store.Close() })
+	return store
+}
+
+// TestNewCollectionStore_VersionsSchema verifies that a database made before
+// the collections schema was versioned keeps its collections and is
+// recorded as up to date
+func TestNewCollectionStore_VersionsSchema(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	db, err := sqldb.Open(dbPath)
+	require.NoError(t, err)
+	_, err = db.Exec(sqliteMigrations[0])
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO collections (name, created_at, updated_at) VALUES ('reading', '2026-10-01T00:00:00Z', '2026-10-01T00:00:00Z')")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	store, err := NewCollectionStore(dbPath)
+	require.NoError(t, err)
+	defer func() { _ = store.Close() }()
+
+	_, err = store.Get("reading")
+	assert.NoError(t, err)
+	status, err := SchemaStatus(store.db)
+	require.NoError(t, err)
+	assert.Equal(t, len(sqliteMigrations), status.Version)
+	assert.Equal(t, 0, status.Pending())
+}
+
+// TestCreate_NamesIgnoreCase verifies that collections are created empty and
+// that names are unique and looked up regardless of case
+func TestCreate_NamesIgnoreCase(t *testing.T) {
+	store := createTestCollectionStore(t)
+
+	c, err := store.Create("Interview Prep", "System design reading")
+	require.NoError(t, err)
+	assert.Equal(t, "Interview Prep", c.Name)
+	assert.Empty(t, c.ItemIDs)
+
+	_, err = store.Create("interview prep", "")
+	assert.ErrorIs(t, err, ErrCollectionExists)
+	_, err = store.Create("  ", "")
+	assert.ErrorIs(t, err, ErrInvalidName)
+
+	found, err := store.Get("INTERVIEW PREP")
+	require.NoError(t, err)
+	assert.Equal(t, "System design reading", found.Description)
+
+	_, err = store.Get("missing")
+	assert.ErrorIs(t, err, ErrCollectionNotFound)
+}
+
+// TestAddItem_KeepsOrder verifies that items are kept in the order they were
+// added, that adding an item twice keeps its place, and that items can be
+// moved and removed
+func TestAddItem_KeepsOrder(t *testing.T) {
+	store := createTestCollectionStore(t)
+	_, err := store.Create("reading", "")
+	require.NoError(t, err)
+
+	a, b, c := uuid.New(), uuid.New(), uuid.New()
+	for _, id := range []uuid.UUID{a, b, c} {
+		added, err := store.AddItem("reading", id)
+		require.NoError(t, err)
+		assert.True(t, added)
+	}
+	added, err := store.AddItem("Reading", a)
+	require.NoError(t, err)
+	assert.False(t, added, "an item already in the collection is not added again")
+
+	got, err := store.Get("reading")
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{a, b, c}, got.ItemIDs)
+
+	require.NoError(t, store.MoveItem("reading", c, 1))
+	got, err = store.Get("reading")
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{c, a, b}, got.ItemIDs)
+
+	require.NoError(t, store.MoveItem("reading", c, 99))
+	require.NoError(t, store.RemoveItem("reading", a))
+	got, err = store.Get("reading")
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{b, c}, got.ItemIDs)
+
+	// Items added after a removal still go to the end
+	_, err = store.AddItem("reading", a)
+	require.NoError(t, err)
+	got, err = store.Get("reading")
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{b, c, a}, got.ItemIDs)
+
+	assert.ErrorIs(t, store.RemoveItem("reading", uuid.New()), ErrItemNotInList)
+	assert.ErrorIs(t, store.MoveItem("reading", uuid.New(), 1), ErrItemNotInList)
+	_, err = store.AddItem("missing", a)
+	assert.ErrorIs(t, err, ErrCollectionNotFound)
+}
+
+// TestUpdate_RenameKeepsItems verifies that renaming a collection keeps its
+// items and refuses a name already in use
+func TestUpdate_RenameKeepsItems(t *testing.T) {
+	store := createTestCollectionStore(t)
+	_, err := store.Create("drafts", "")
+	require.NoError(t, err)
+	_, err = store.Create("taken", "")
+	require.NoError(t, err)
+	id := uuid.New()
+	_, err = store.AddItem("drafts", id)
+	require.NoError(t, err)
+
+	newName, description := "Research", "Papers to cite"
+	c, err := store.Update("drafts", CollectionUpdate{Name: &newName, Description: &description})
+	require.NoError(t, err)
+	assert.Equal(t, "Research", c.Name)
+	assert.Equal(t, "Papers to cite", c.Description)
+	assert.Equal(t, []uuid.UUID{id}, c.ItemIDs)
+
+	taken := "TAKEN"
+	_, err = store.Update("research", CollectionUpdate{Name: &taken})
+	assert.ErrorIs(t, err, ErrCollectionExists)
+
+	// Changing only the case of a name is allowed
+	lower := "research"
+	c, err = store.Update("Research", CollectionUpdate{Name: &lower})
+	require.NoError(t, err)
+	assert.Equal(t, "research", c.Name)
+	assert.Equal(t, []uuid.UUID{id}, c.ItemIDs)
+}
+
+// TestDelete_RemovesItems verifies that deleting a collection removes its
+// item list, so a new collection of the same name starts empty
+func TestDelete_RemovesItems(t *testing.T) {
+	store := createTestCollectionStore(t)
+	_, err := store.Create("old", "")
+	require.NoError(t, err)
+	_, err = store.AddItem("old", uuid.New())
+	require.NoError(t, err)
+
+	require.NoError(t, store.Delete("OLD"))
+	assert.ErrorIs(t, store.Delete("old"), ErrCollectionNotFound)
+
+	c, err := store.Create("old", "")
+	require.NoError(t, err)
+	assert.Empty(t, c.ItemIDs)
+
+	list, err := store.List()
+	require.NoError(t, err)
+	assert.Len(t, list, 1)
+}
+
+// TestCollection_Items verifies that a collection's items are read from the
+// feed in order and that deleted items are reported as missing
+func TestCollection_Items(t *testing.T) {
+	feed, err := newsfeed.NewNewsFeed(t.TempDir())
+	require.NoError(t, err)
+	first := newsfeed.NewsItem{ID: uuid.New(), Title: "First", URL: "https://example.com/1"}
+	second := newsfeed.NewsItem{ID: uuid.New(), Title: "Second", URL: "https://example.com/2"}
+	require.NoError(t, feed.Add(first))
+	require.NoError(t, feed.Add(second))
+
+	gone := uuid.New()
+	c := Collection{Name: "reading", ItemIDs: []uuid.UUID{second.ID, gone, first.ID}}
+	items, missing, err := c.Items(feed)
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.Equal(t, "Second", items[0].Title)
+	assert.Equal(t, "First", items[1].Title)
+	assert.Equal(t, []uuid.UUID{gone}, missing)
+}
+
+// TestPostgres_CollectionStore verifies the collection store against
+// PostgreSQL. It is skipped unless a test database is configured.
+func TestPostgres_CollectionStore(t *testing.T) {
+	store, err := NewCollectionStore(sqldbtest.PostgresDSN(t))
+	require.NoError(t, err)
+	defer func() { _ = store.Close() }()
+
+	name := "pg-" + uuid.NewString()
+	_, err = store.Create(name, "")
+	require.NoError(t, err)
+	defer func() { _ = store.Delete(name) }()
+
+	a, b := uuid.New(), uuid.New()
+	_, err = store.AddItem(name, a)
+	require.NoError(t, err)
+	_, err = store.AddItem(name, b)
+	require.NoError(t, err)
+	require.NoError(t, store.MoveItem(name, b, 1))
+
+	renamed := name + "-renamed"
+	c, err := store.Update(name, CollectionUpdate{Name: &renamed})
+	require.NoError(t, err)
+	name = renamed
+	assert.Equal(t, []uuid.UUID{b, a}, c.ItemIDs)
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"github.com/pevans/newsfed/internal/httpjson"
+)
+
+// GetHandler returns the user configuration for GET /api/v1/meta/config.
+func (c *ConfigStore) GetHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cfg, err := c.GetConfig()
+		if err != nil {
+			httpjson.Error(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		httpjson.Write(w, http.StatusOK, cfg)
+	})
+}
+
+// PutHandler changes settings for PUT /api/v1/meta/config, as `newsfed
+// config set` and `newsfed config unset` do, from a JSON object of config
+// keys to values, where null unsets a key. Every value is checked with
+// validate before anything is stored, so a bad key or value responds 400 and
+// changes nothing. It responds with the resulting configuration.
+func (c *ConfigStore) PutHandler(validate func(key, value string) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var changes map[string]*string
+		if err := json.NewDecoder(r.Body).Decode(&changes); err != nil {
+			httpjson.Error(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		keys := make([]string, 0, len(changes))
+		for key, value := range changes {
+			if _, ok := (&Config{}).Value(key); !ok {
+				httpjson.Error(w, http.StatusBadRequest, "unknown config key: "+key)
+				return
+			}
+			if value != nil {
+				if err := validate(key, *value); err != nil {
+					httpjson.Error(w, http.StatusBadRequest, err.Error())
+					return
+				}
+			}
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			var err error
+			if value := changes[key]; value != nil {
+				err = c.Set(key, *value)
+			} else {
+				err = c.Unset(key)
+			}
+			if err != nil {
+				httpjson.Error(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+		}
+		cfg, err := c.GetConfig()
+		if err != nil {
+			httpjson.Error(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		httpjson.Write(w, http.StatusOK, cfg)
+	})
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestConfigHandlers verifies that settings are read and changed through
+// the API, that null unsets a key, and that bad keys and values change
+// nothing
+func TestConfigHandlers(t *testing.T) {
+	store := createTestConfigStore(t)
+	require.NoError(t, store.Set("list_sort", "score"))
+	validate := func(key, value string) error {
+		if key == "list_limit" && value == "none" {
+			return errors.New("invalid list_limit")
+		}
+		return nil
+	}
+	put := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		store.PutHandler(validate).ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/api/v1/meta/config", strings.NewReader(body)))
+		return rec
+	}
+
+	rec := put(`{"list_limit": "25", "list_sort": null}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var cfg Config
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &cfg))
+	assert.Equal(t, "25", cfg.ListLimit)
+	assert.Empty(t, cfg.ListSort)
+
+	assert.Equal(t, http.StatusBadRequest, put(`{"list_window": "7d", "nope": "1"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, put(`{"list_window": "7d", "list_limit": "none"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, put(`not json`).Code)
+
+	rec = httptest.NewRecorder()
+	store.GetHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/meta/config", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &cfg))
+	assert.Equal(t, "25", cfg.ListLimit)
+	assert.Empty(t, cfg.ListWindow, "a rejected request should change nothing")
+}
@@ -4,25 +4,112 @@ import (
 	"database/sql"
 	"fmt"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/pevans/newsfed/audit"
+	"github.com/pevans/newsfed/sqldb"
 )
 
-// ConfigStore manages user configuration using SQLite.
+// ConfigStore manages user configuration in SQLite or PostgreSQL.
 type ConfigStore struct {
-	db *sql.DB
+	db *sqldb.DB
 }
 
 // Config represents user configuration.
 type Config struct {
 	DefaultPollingInterval string `json:"default_polling_interval"`
 	BrowserCommand         string `json:"browser_command"`
+	// CheckInterval and MetricsInterval tune the discovery daemon's
+	// scheduler. Empty values leave the daemon defaults in place.
+	CheckInterval   string `json:"check_interval,omitempty"`
+	MetricsInterval string `json:"metrics_interval,omitempty"`
+	// The Digest settings schedule the daemon's email digest, which is only
+	// sent when both DigestSchedule and DigestTo are set.
+	DigestSchedule string `json:"digest_schedule,omitempty"` // Cron expression such as "0 7 * * *"
+	DigestTo       string `json:"digest_to,omitempty"`       // Comma-separated email addresses
+	DigestSince    string `json:"digest_since,omitempty"`    // Window of items covered; empty means 24h
+	DigestFormat   string `json:"digest_format,omitempty"`   // "html" or "markdown"; empty means html
+	DigestGroup    string `json:"digest_group,omitempty"`    // "category", "publisher", or "none"; empty means category
+	// The List settings change what `newsfed list` shows when no flags are
+	// given. Empty values leave the built-in defaults in place.
+	ListWindow string `json:"list_window,omitempty"` // Age of unpinned items shown, such as "3d"; "0" shows all
+	ListLimit  string `json:"list_limit,omitempty"`  // Number of items shown
+	ListSort   string `json:"list_sort,omitempty"`   // "published", "discovered", "pinned", or "score"
+	ListFormat string `json:"list_format,omitempty"` // "table", "json", or "compact"
 }
 
-// NewConfigStore creates a new config store with the given database path.
+// Keys lists every config key, in the order `newsfed config show` prints
+// them.
+var Keys = []string{
+	"default_polling_interval",
+	"browser_command",
+	"check_interval",
+	"metrics_interval",
+	"list_window",
+	"list_limit",
+	"list_sort",
+	"list_format",
+	"digest_schedule",
+	"digest_to",
+	"digest_since",
+	"digest_format",
+	"digest_group",
+}
+
+// digestKeys returns the config keys of cfg's digest settings.
+func (cfg *Config) digestKeys() map[string]*string {
+	return map[string]*string{
+		"digest_schedule": &cfg.DigestSchedule,
+		"digest_to":       &cfg.DigestTo,
+		"digest_since":    &cfg.DigestSince,
+		"digest_format":   &cfg.DigestFormat,
+		"digest_group":    &cfg.DigestGroup,
+	}
+}
+
+// listKeys returns the config keys of cfg's list settings.
+func (cfg *Config) listKeys() map[string]*string {
+	return map[string]*string{
+		"list_window": &cfg.ListWindow,
+		"list_limit":  &cfg.ListLimit,
+		"list_sort":   &cfg.ListSort,
+		"list_format": &cfg.ListFormat,
+	}
+}
+
+// optionalKeys returns the config keys of cfg's settings that are left
+// unset unless the user sets them.
+func (cfg *Config) optionalKeys() map[string]*string {
+	keys := cfg.listKeys()
+	for key, value := range cfg.digestKeys() {
+		keys[key] = value
+	}
+	return keys
+}
+
+// Value returns the value of key in cfg, and whether key is a config key.
+func (cfg *Config) Value(key string) (string, bool) {
+	switch key {
+	case "default_polling_interval":
+		return cfg.DefaultPollingInterval, true
+	case "browser_command":
+		return cfg.BrowserCommand, true
+	case "check_interval":
+		return cfg.CheckInterval, true
+	case "metrics_interval":
+		return cfg.MetricsInterval, true
+	}
+	value, ok := cfg.optionalKeys()[key]
+	if !ok {
+		return "", false
+	}
+	return *value, true
+}
+
+// NewConfigStore creates a new config store with the given database path,
+// or a postgres:// DSN.
 func NewConfigStore(dbPath string) (*ConfigStore, error) {
-	db, err := sql.Open("sqlite3", dbPath)
+	db, err := sqldb.Open(dbPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open database: %w", err)
+		return nil, err
 	}
 
 	store := &ConfigStore{db: db}
@@ -34,17 +121,32 @@ func NewConfigStore(dbPath string) (*ConfigStore, error) {
 	return store, nil
 }
 
-// initSchema creates the config table if it doesn't exist.
-func (c *ConfigStore) initSchema() error {
-	schema := `
+// migrations are the config schema, applied in order by sqldb; new ones
+// must only ever be appended. The first is written so that it also works on
+// databases made before the schema was versioned, which already have the
+// table.
+var migrations = []string{
+	`
 	CREATE TABLE IF NOT EXISTS config (
 		key TEXT PRIMARY KEY,
 		value TEXT NOT NULL
 	);
-	`
+	`,
+}
 
-	_, err := c.db.Exec(schema)
-	return err
+// SchemaStatus returns how far the config schema of db has been migrated,
+// without migrating it, for newsfed migrate-db status.
+func SchemaStatus(db *sqldb.DB) (sqldb.SchemaStatus, error) {
+	return db.SchemaStatus("config", len(migrations))
+}
+
+// initSchema creates the config table, or brings it up to date, when the
+// store is opened.
+func (c *ConfigStore) initSchema() error {
+	if err := audit.InitSchema(c.db); err != nil {
+		return err
+	}
+	return c.db.Migrate("config", migrations)
 }
 
 // Close closes the database connection.
@@ -71,15 +173,42 @@ func (c *ConfigStore) GetConfig() (*Config, error) {
 	}
 	// If not found, browserCommand will be empty string (default)
 
-	return &Config{
+	var checkInterval string
+	err = c.db.QueryRow(query, "check_interval").Scan(&checkInterval)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to query check_interval: %w", err)
+	}
+
+	var metricsInterval string
+	err = c.db.QueryRow(query, "metrics_interval").Scan(&metricsInterval)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to query metrics_interval: %w", err)
+	}
+
+	cfg := &Config{
 		DefaultPollingInterval: defaultPollingInterval,
 		BrowserCommand:         browserCommand,
-	}, nil
+		CheckInterval:          checkInterval,
+		MetricsInterval:        metricsInterval,
+	}
+	for key, value := range cfg.optionalKeys() {
+		err = c.db.QueryRow(query, key).Scan(value)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, fmt.Errorf("failed to query %s: %w", key, err)
+		}
+	}
+
+	return cfg, nil
 }
 
 // UpdateConfig updates user configuration.
 func (c *ConfigStore) UpdateConfig(cfg *Config) error {
-	query := "INSERT OR REPLACE INTO config (key, value) VALUES (?, ?)"
+	return c.audited(func() error { return c.updateConfig(cfg) })
+}
+
+// updateConfig stores cfg's settings.
+func (c *ConfigStore) updateConfig(cfg *Config) error {
+	query := "INSERT INTO config (key, value) VALUES (?, ?) ON CONFLICT (key) DO UPDATE SET value = excluded.value"
 
 	_, err := c.db.Exec(query, "default_polling_interval", cfg.DefaultPollingInterval)
 	if err != nil {
@@ -93,5 +222,91 @@ func (c *ConfigStore) UpdateConfig(cfg *Config) error {
 		}
 	}
 
+	if cfg.CheckInterval != "" {
+		_, err = c.db.Exec(query, "check_interval", cfg.CheckInterval)
+		if err != nil {
+			return fmt.Errorf("failed to update check_interval: %w", err)
+		}
+	}
+
+	if cfg.MetricsInterval != "" {
+		_, err = c.db.Exec(query, "metrics_interval", cfg.MetricsInterval)
+		if err != nil {
+			return fmt.Errorf("failed to update metrics_interval: %w", err)
+		}
+	}
+
+	for key, value := range cfg.optionalKeys() {
+		if *value == "" {
+			continue
+		}
+		if _, err := c.db.Exec(query, key, *value); err != nil {
+			return fmt.Errorf("failed to update %s: %w", key, err)
+		}
+	}
+
 	return nil
 }
+
+// Set stores value under key, which must be one of Keys.
+func (c *ConfigStore) Set(key, value string) error {
+	if _, ok := (&Config{}).Value(key); !ok {
+		return fmt.Errorf("unknown config key: %s", key)
+	}
+	query := "INSERT INTO config (key, value) VALUES (?, ?) ON CONFLICT (key) DO UPDATE SET value = excluded.value"
+	return c.audited(func() error {
+		if _, err := c.db.Exec(query, key, value); err != nil {
+			return fmt.Errorf("failed to update %s: %w", key, err)
+		}
+		return nil
+	})
+}
+
+// Unset removes key, which must be one of Keys, so that its default
+// applies again.
+func (c *ConfigStore) Unset(key string) error {
+	if _, ok := (&Config{}).Value(key); !ok {
+		return fmt.Errorf("unknown config key: %s", key)
+	}
+	return c.audited(func() error {
+		if _, err := c.db.Exec("DELETE FROM config WHERE key = ?", key); err != nil {
+			return fmt.Errorf("failed to clear %s: %w", key, err)
+		}
+		return nil
+	})
+}
+
+// ClearDigest removes the digest settings, which stops the daemon from
+// sending digests.
+func (c *ConfigStore) ClearDigest() error {
+	return c.audited(func() error {
+		for key := range (&Config{}).digestKeys() {
+			if _, err := c.db.Exec("DELETE FROM config WHERE key = ?", key); err != nil {
+				return fmt.Errorf("failed to clear %s: %w", key, err)
+			}
+		}
+		return nil
+	})
+}
+
+// audited makes a change to the config and records what it changed in the
+// audit log.
+func (c *ConfigStore) audited(change func() error) error {
+	before, err := c.GetConfig()
+	if err != nil {
+		return err
+	}
+	if err := change(); err != nil {
+		return err
+	}
+	after, err := c.GetConfig()
+	if err != nil {
+		return err
+	}
+
+	changes, err := audit.Diff(before, after)
+	if err != nil || len(changes) == 0 {
+		return err
+	}
+	return audit.Record(c.db, audit.ConfigChanged, "config", changes)
+}
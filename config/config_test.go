@@ -4,6 +4,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/pevans/newsfed/audit"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -18,6 +19,22 @@ func createTestConfigStore(t *testing.T) *ConfigStore {
 	return store
 }
 
+// TestNewConfigStore_VersionsSchema verifies that opening a store migrates
+// the config and audit schemas to their latest versions
+func TestNewConfigStore_VersionsSchema(t *testing.T) {
+	store := createTestConfigStore(t)
+
+	status, err := SchemaStatus(store.db)
+	require.NoError(t, err)
+	assert.Equal(t, len(migrations), status.Latest)
+	assert.Equal(t, 0, status.Pending())
+
+	status, err = audit.SchemaStatus(store.db)
+	require.NoError(t, err)
+	assert.Equal(t, 0, status.Pending())
+	assert.Positive(t, status.Version)
+}
+
 // TestGetConfig_Default verifies default config is returned when not set
 func TestGetConfig_Default(t *testing.T) {
 	store := createTestConfigStore(t)
@@ -63,3 +80,143 @@ func TestUpdateConfig_Overwrites(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, "2h", retrieved.DefaultPollingInterval)
 }
+
+// TestUpdateConfig_SchedulerIntervals verifies the scheduler intervals are
+// stored and left empty until set
+func TestUpdateConfig_SchedulerIntervals(t *testing.T) {
+	store := createTestConfigStore(t)
+
+	config, err := store.GetConfig()
+	require.NoError(t, err)
+	assert.Empty(t, config.CheckInterval)
+	assert.Empty(t, config.MetricsInterval)
+
+	err = store.UpdateConfig(&Config{
+		DefaultPollingInterval: "1h",
+		CheckInterval:          "1m",
+		MetricsInterval:        "30m",
+	})
+	require.NoError(t, err)
+
+	retrieved, err := store.GetConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "1m", retrieved.CheckInterval)
+	assert.Equal(t, "30m", retrieved.MetricsInterval)
+}
+
+// TestUpdateConfig_Digest verifies that digest settings are stored, that
+// unset ones are left alone, and that ClearDigest removes them all
+func TestUpdateConfig_Digest(t *testing.T) {
+	store := createTestConfigStore(t)
+
+	err := store.UpdateConfig(&Config{
+		DefaultPollingInterval: "1h",
+		DigestSchedule:         "0 7 * * *",
+		DigestTo:               "me@example.com",
+		DigestFormat:           "markdown",
+	})
+	require.NoError(t, err)
+	require.NoError(t, store.UpdateConfig(&Config{DefaultPollingInterval: "1h", DigestSince: "7d"}))
+
+	retrieved, err := store.GetConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "0 7 * * *", retrieved.DigestSchedule)
+	assert.Equal(t, "me@example.com", retrieved.DigestTo)
+	assert.Equal(t, "markdown", retrieved.DigestFormat)
+	assert.Equal(t, "7d", retrieved.DigestSince)
+	assert.Empty(t, retrieved.DigestGroup)
+
+	require.NoError(t, store.ClearDigest())
+	retrieved, err = store.GetConfig()
+	require.NoError(t, err)
+	assert.Empty(t, retrieved.DigestSchedule)
+	assert.Empty(t, retrieved.DigestTo)
+	assert.Equal(t, "1h", retrieved.DefaultPollingInterval)
+}
+
+// TestUpdateConfig_ListDefaults verifies that list settings round-trip
+// through the store
+func TestUpdateConfig_ListDefaults(t *testing.T) {
+	store := createTestConfigStore(t)
+
+	err := store.UpdateConfig(&Config{
+		DefaultPollingInterval: "1h",
+		ListWindow:             "7d",
+		ListLimit:              "50",
+		ListSort:               "score",
+		ListFormat:             "compact",
+	})
+	require.NoError(t, err)
+
+	retrieved, err := store.GetConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "7d", retrieved.ListWindow)
+	assert.Equal(t, "50", retrieved.ListLimit)
+	assert.Equal(t, "score", retrieved.ListSort)
+	assert.Equal(t, "compact", retrieved.ListFormat)
+}
+
+// TestSetAndUnset verifies setting and clearing single keys, and that
+// unknown keys are rejected
+func TestSetAndUnset(t *testing.T) {
+	store := createTestConfigStore(t)
+
+	require.NoError(t, store.Set("list_window", "0"))
+	require.NoError(t, store.Set("default_polling_interval", "2h"))
+
+	retrieved, err := store.GetConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "0", retrieved.ListWindow)
+	assert.Equal(t, "2h", retrieved.DefaultPollingInterval)
+
+	require.NoError(t, store.Unset("list_window"))
+	require.NoError(t, store.Unset("default_polling_interval"))
+	retrieved, err = store.GetConfig()
+	require.NoError(t, err)
+	assert.Empty(t, retrieved.ListWindow)
+	assert.Equal(t, "1h", retrieved.DefaultPollingInterval)
+
+	assert.Error(t, store.Set("no_such_key", "x"))
+	assert.Error(t, store.Unset("no_such_key"))
+}
+
+// TestConfigValue verifies that every key in Keys can be read from a
+// Config
+func TestConfigValue(t *testing.T) {
+	cfg := &Config{BrowserCommand: "firefox", ListSort: "score", DigestTo: "me@example.com"}
+	for _, key := range Keys {
+		_, ok := cfg.Value(key)
+		assert.True(t, ok, key)
+	}
+
+	value, _ := cfg.Value("list_sort")
+	assert.Equal(t, "score", value)
+	value, _ = cfg.Value("browser_command")
+	assert.Equal(t, "firefox", value)
+
+	_, ok := cfg.Value("no_such_key")
+	assert.False(t, ok)
+}
+
+// TestSet_Audited verifies that config changes are recorded in the audit log
+// with their old and new values
+func TestSet_Audited(t *testing.T) {
+	store := createTestConfigStore(t)
+	require.NoError(t, store.Set("list_limit", "30"))
+	require.NoError(t, store.Set("list_limit", "30"))
+	require.NoError(t, store.Unset("list_limit"))
+
+	var changes []string
+	rows, err := store.db.Query("SELECT changes FROM audit_log WHERE action = 'config.changed' ORDER BY id")
+	require.NoError(t, err)
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var change string
+		require.NoError(t, rows.Scan(&change))
+		changes = append(changes, change)
+	}
+	assert.Equal(t, []string{
+		`{"list_limit":{"old":null,"new":"30"}}`,
+		`{"list_limit":{"old":"30","new":null}}`,
+	}, changes, "setting the same value again should not be recorded")
+}
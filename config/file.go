@@ -1,7 +1,10 @@
 package config
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 
@@ -20,9 +23,103 @@ type StorageConfig struct {
 	} `yaml:"feed"`
 }
 
+// DiscoveryFileConfig represents discovery daemon settings from config file.
+type DiscoveryFileConfig struct {
+	Concurrency     int    `yaml:"concurrency"`
+	MaxPerDomain    int    `yaml:"max_per_domain"`
+	RateLimitJitter string `yaml:"rate_limit_jitter"`
+	WorkerID        string `yaml:"worker_id"`
+	ClaimLease      string `yaml:"claim_lease"`
+	ProxyURL        string `yaml:"proxy_url"`
+	KeepSummaryHTML bool   `yaml:"keep_summary_html"`
+	// ArticleConcurrency is how many article pages of one list-mode source
+	// are scraped at once.
+	ArticleConcurrency int `yaml:"article_concurrency"`
+	// MaxItemAge skips items published longer ago, for sources whose
+	// ingest rules don't set their own; see sources.ParseMaxAge.
+	MaxItemAge string `yaml:"max_item_age"`
+	// AdaptivePolling polls feeds as often as they post, within
+	// AdaptiveMinInterval and AdaptiveMaxInterval, unless they set their
+	// own polling interval.
+	AdaptivePolling     bool   `yaml:"adaptive_polling"`
+	AdaptiveMinInterval string `yaml:"adaptive_min_interval"`
+	AdaptiveMaxInterval string `yaml:"adaptive_max_interval"`
+	// SummarizeCommand is run to summarize items that have no summary; see
+	// discovery.CommandSummarizer.
+	SummarizeCommand []string `yaml:"summarize_command"`
+	SummarizeTimeout string   `yaml:"summarize_timeout"`
+	// RetryAttempts is a pointer so that 0, which disables retries, can be
+	// told apart from unset.
+	RetryAttempts  *int   `yaml:"retry_attempts"`
+	RetryBaseDelay string `yaml:"retry_base_delay"`
+	RetryMaxDelay  string `yaml:"retry_max_delay"`
+	// BrowserPath, RenderTabs, and RenderTimeout configure the headless
+	// browser used by website sources with render_js set.
+	BrowserPath   string `yaml:"browser_path"`
+	RenderTabs    int    `yaml:"render_tabs"`
+	RenderTimeout string `yaml:"render_timeout"`
+	// Journal is a pointer so that the journal, which is on unless turned
+	// off, can be told apart from unset. JournalRetention is parsed as a
+	// newsfeed retention period.
+	Journal          *bool  `yaml:"journal"`
+	JournalRetention string `yaml:"journal_retention"`
+	// MaxResponseSize is the largest feed or page body read, parsed by
+	// sources.ParseByteSize, such as "20MB".
+	MaxResponseSize string `yaml:"max_response_size"`
+	// Hooks are external commands run on discovery events; see
+	// discovery.Hook.
+	Hooks []HookFileConfig `yaml:"hooks"`
+	// PublishURL is the NATS server items added to the feed are published
+	// to, under PublishSubject, keeping up to PublishBuffer of them while it
+	// can't be reached; see discovery.DiscoveryConfig.
+	PublishURL     string `yaml:"publish_url"`
+	PublishSubject string `yaml:"publish_subject"`
+	PublishBuffer  int    `yaml:"publish_buffer"`
+}
+
+// HookFileConfig represents one hook from config file. Timeout is a
+// duration such as "10s".
+type HookFileConfig struct {
+	Event   string   `yaml:"event"`
+	Command []string `yaml:"command"`
+	Timeout string   `yaml:"timeout"`
+}
+
+// ScoringFileConfig represents the item scoring model from config file.
+type ScoringFileConfig struct {
+	HalfLife string `yaml:"half_life"`
+	// SourceWeights is keyed by source name or ID.
+	SourceWeights map[string]float64 `yaml:"source_weights"`
+	KeywordBoosts map[string]float64 `yaml:"keyword_boosts"`
+	PinBoost      float64            `yaml:"pin_boost"`
+}
+
+// ServerFileConfig represents HTTP server settings from config file.
+type ServerFileConfig struct {
+	// RateLimit is the requests per second each client may sustain; a
+	// negative value turns rate limiting off.
+	RateLimit float64 `yaml:"rate_limit"`
+	RateBurst int     `yaml:"rate_burst"`
+}
+
+// SMTPFileConfig represents the mail server that digests are sent through.
+type SMTPFileConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Username string `yaml:"username"`
+	// Password may be left out of the file and given in the
+	// NEWSFED_SMTP_PASSWORD environment variable instead.
+	Password string `yaml:"password"`
+	From     string `yaml:"from"`
+}
+
 // FileConfig represents the structure of ~/.newsfed/config.yaml.
 type FileConfig struct {
-	Storage StorageConfig `yaml:"storage"`
+	Storage   StorageConfig       `yaml:"storage"`
+	Discovery DiscoveryFileConfig `yaml:"discovery"`
+	Scoring   ScoringFileConfig   `yaml:"scoring"`
+	Server    ServerFileConfig    `yaml:"server"`
+	SMTP      SMTPFileConfig      `yaml:"smtp"`
 }
 
 // ConfigFilePath returns the path to the default config file
@@ -36,6 +133,17 @@ func ConfigFilePath() (string, error) {
 	return filepath.Join(homeDir, ".newsfed", "config.yaml"), nil
 }
 
+// JournalPath returns the path to the journal of discovery decisions
+// (~/.newsfed/journal.jsonl).
+func JournalPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+
+	return filepath.Join(homeDir, ".newsfed", "journal.jsonl"), nil
+}
+
 // WriteDefaultConfigFile creates a default config file at
 // ~/.newsfed/config.yaml with absolute paths. If the file already exists and
 // force is false, it is skipped (returns false, nil). If force is true, the
@@ -87,31 +195,74 @@ storage:
 	return true, nil
 }
 
-// LoadConfigFile loads configuration from ~/.newsfed/config.yaml. Returns nil
-// if the file doesn't exist (not an error). Returns error if the file exists
-// but cannot be parsed.
-func LoadConfigFile() (*FileConfig, error) {
-	configPath, err := ConfigFilePath()
+// LocalConfigFile is the name of the config file read from the current
+// directory, whose settings override those in ~/.newsfed/config.yaml.
+const LocalConfigFile = "newsfed.yaml"
+
+// ConfigFiles returns the config files that apply, lowest precedence first:
+// ~/.newsfed/config.yaml, newsfed.yaml in the current directory, and the
+// file named by NEWSFED_CONFIG. Files that don't exist are left out, except
+// that a missing NEWSFED_CONFIG file is an error.
+func ConfigFiles() ([]string, error) {
+	var files []string
+
+	homePath, err := ConfigFilePath()
 	if err != nil {
 		return nil, err
 	}
+	if _, err := os.Stat(homePath); err == nil {
+		files = append(files, homePath)
+	}
 
-	// Check if file exists
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		return nil, nil // File doesn't exist -- not an error
+	if localPath, err := filepath.Abs(LocalConfigFile); err == nil && localPath != homePath {
+		if _, err := os.Stat(localPath); err == nil {
+			files = append(files, localPath)
+		}
 	}
 
-	// Read file
-	data, err := os.ReadFile(configPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
+	if envPath := os.Getenv("NEWSFED_CONFIG"); envPath != "" {
+		if _, err := os.Stat(envPath); err != nil {
+			return nil, fmt.Errorf("NEWSFED_CONFIG: %w", err)
+		}
+		files = append(files, envPath)
 	}
 
-	// Parse YAML
+	return files, nil
+}
+
+// LoadConfigFiles parses files in order into one configuration. Each file
+// overrides only the settings it contains, so a later file can change one
+// setting of a section and keep the rest; lists are replaced, and maps are
+// merged key by key. With strict set, keys that newsfed doesn't know are
+// errors, which catches misspelled settings.
+func LoadConfigFiles(files []string, strict bool) (*FileConfig, error) {
 	var cfg FileConfig
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
-		return nil, fmt.Errorf("failed to parse config file: %w", err)
-	}
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
 
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(strict)
+		if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	}
 	return &cfg, nil
 }
+
+// LoadConfigFile loads configuration from ~/.newsfed/config.yaml,
+// newsfed.yaml in the current directory, and NEWSFED_CONFIG, in that order
+// of precedence (see ConfigFiles). Returns nil if none of them exist (not
+// an error). Returns error if a file exists but cannot be parsed.
+func LoadConfigFile() (*FileConfig, error) {
+	files, err := ConfigFiles()
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, nil // No config file -- not an error
+	}
+	return LoadConfigFiles(files, false)
+}
@@ -228,3 +228,151 @@ func TestLoadConfigFile_PartialConfig(t *testing.T) {
 	assert.Equal(t, "", cfg.Storage.Feed.Type, "Unspecified feed type should be empty string")
 	assert.Equal(t, "", cfg.Storage.Feed.DSN, "Unspecified feed DSN should be empty string")
 }
+
+func TestLoadConfigFile_DiscoverySection(t *testing.T) {
+	tmpDir := t.TempDir()
+	newsfedDir := filepath.Join(tmpDir, ".newsfed")
+	require.NoError(t, os.MkdirAll(newsfedDir, 0o700))
+
+	configContent := `discovery:
+  concurrency: 12
+  max_per_domain: 2
+  rate_limit_jitter: 500ms
+server:
+  rate_limit: 2.5
+  rate_burst: 5
+`
+	require.NoError(t, os.WriteFile(filepath.Join(newsfedDir, "config.yaml"), []byte(configContent), 0o600))
+
+	oldHome := os.Getenv("HOME")
+	_ = os.Setenv("HOME", tmpDir)
+	defer func() { _ = os.Setenv("HOME", oldHome) }()
+
+	cfg, err := LoadConfigFile()
+	require.NoError(t, err)
+	require.NotNil(t, cfg)
+
+	assert.Equal(t, 12, cfg.Discovery.Concurrency)
+	assert.Equal(t, 2, cfg.Discovery.MaxPerDomain)
+	assert.Equal(t, "500ms", cfg.Discovery.RateLimitJitter)
+	assert.Equal(t, 2.5, cfg.Server.RateLimit)
+	assert.Equal(t, 5, cfg.Server.RateBurst)
+	assert.Empty(t, cfg.Storage.Feed.DSN)
+}
+
+func TestLoadConfigFile_ScoringSection(t *testing.T) {
+	tmpDir := t.TempDir()
+	newsfedDir := filepath.Join(tmpDir, ".newsfed")
+	require.NoError(t, os.MkdirAll(newsfedDir, 0o700))
+
+	configContent := `scoring:
+  half_life: 12h
+  source_weights:
+    Hacker News (top): 1.5
+  keyword_boosts:
+    golang: 0.5
+  pin_boost: 0.1
+`
+	require.NoError(t, os.WriteFile(filepath.Join(newsfedDir, "config.yaml"), []byte(configContent), 0o600))
+
+	oldHome := os.Getenv("HOME")
+	_ = os.Setenv("HOME", tmpDir)
+	defer func() { _ = os.Setenv("HOME", oldHome) }()
+
+	cfg, err := LoadConfigFile()
+	require.NoError(t, err)
+	require.NotNil(t, cfg)
+
+	assert.Equal(t, "12h", cfg.Scoring.HalfLife)
+	assert.Equal(t, map[string]float64{"Hacker News (top)": 1.5}, cfg.Scoring.SourceWeights)
+	assert.Equal(t, map[string]float64{"golang": 0.5}, cfg.Scoring.KeywordBoosts)
+	assert.Equal(t, 0.1, cfg.Scoring.PinBoost)
+}
+
+// TestLoadConfigFile_Layers verifies that newsfed.yaml in the current
+// directory and NEWSFED_CONFIG override the home config setting by setting
+func TestLoadConfigFile_Layers(t *testing.T) {
+	homeDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(homeDir, ".newsfed"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(homeDir, ".newsfed", "config.yaml"), []byte(`storage:
+  metadata:
+    type: sqlite
+    dsn: /home/metadata.db
+discovery:
+  concurrency: 5
+  max_per_domain: 2
+scoring:
+  keyword_boosts:
+    golang: 0.5
+`), 0o600))
+
+	workDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(workDir, LocalConfigFile), []byte(`discovery:
+  concurrency: 10
+scoring:
+  keyword_boosts:
+    rust: 0.25
+`), 0o600))
+
+	envPath := filepath.Join(t.TempDir(), "override.yaml")
+	require.NoError(t, os.WriteFile(envPath, []byte(`storage:
+  metadata:
+    dsn: /env/metadata.db
+`), 0o600))
+
+	t.Setenv("HOME", homeDir)
+	t.Setenv("NEWSFED_CONFIG", envPath)
+	t.Chdir(workDir)
+
+	files, err := ConfigFiles()
+	require.NoError(t, err)
+	require.Len(t, files, 3)
+	assert.Equal(t, filepath.Join(homeDir, ".newsfed", "config.yaml"), files[0])
+	assert.Equal(t, envPath, files[2])
+
+	cfg, err := LoadConfigFile()
+	require.NoError(t, err)
+	require.NotNil(t, cfg)
+	assert.Equal(t, "sqlite", cfg.Storage.Metadata.Type)
+	assert.Equal(t, "/env/metadata.db", cfg.Storage.Metadata.DSN)
+	assert.Equal(t, 10, cfg.Discovery.Concurrency)
+	assert.Equal(t, 2, cfg.Discovery.MaxPerDomain)
+	assert.Equal(t, map[string]float64{"golang": 0.5, "rust": 0.25}, cfg.Scoring.KeywordBoosts)
+}
+
+// TestConfigFiles_MissingEnvFile verifies that a NEWSFED_CONFIG naming a
+// file that doesn't exist is an error
+func TestConfigFiles_MissingEnvFile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("NEWSFED_CONFIG", filepath.Join(t.TempDir(), "missing.yaml"))
+
+	_, err := ConfigFiles()
+	assert.ErrorContains(t, err, "NEWSFED_CONFIG")
+	_, err = LoadConfigFile()
+	assert.Error(t, err)
+}
+
+// TestLoadConfigFiles_Strict verifies that strict loading rejects unknown
+// keys that normal loading ignores
+func TestLoadConfigFiles_Strict(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("discovery:\n  concurency: 5\n"), 0o600))
+
+	_, err := LoadConfigFiles([]string{path}, false)
+	require.NoError(t, err)
+
+	_, err = LoadConfigFiles([]string{path}, true)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "concurency")
+	assert.Contains(t, err.Error(), path)
+}
+
+// TestLoadConfigFiles_EmptyFile verifies that an empty file is valid
+func TestLoadConfigFiles_EmptyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, nil, 0o600))
+
+	cfg, err := LoadConfigFiles([]string{path}, true)
+	require.NoError(t, err)
+	assert.NotNil(t, cfg)
+}
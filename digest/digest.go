@@ -0,0 +1,246 @@
+// Package digest renders a selection of news items into a shareable digest,
+// in Markdown or HTML, and sends digests by email on a cron-like schedule.
+package digest
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pevans/newsfed/newsfeed"
+)
+
+// Formats accepted by Render.
+const (
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+)
+
+// Groupings accepted by Render.
+const (
+	GroupCategory  = "category"
+	GroupPublisher = "publisher"
+	GroupNone      = "none"
+)
+
+// Info describes a digest as a whole.
+type Info struct {
+	Title string
+	// Since and Until bound the discovery times the digest covers. Since may
+	// be zero, in which case only Until is shown.
+	Since time.Time
+	Until time.Time
+}
+
+// Group is a run of digest items that share a category or publisher.
+type Group struct {
+	Name  string // Empty when items are not grouped
+	Items []newsfeed.NewsItem
+}
+
+// ValidateFormat returns an error unless format is one Render accepts.
+func ValidateFormat(format string) error {
+	if format != FormatMarkdown && format != FormatHTML {
+		return fmt.Errorf("invalid digest format: %s (must be markdown or html)", format)
+	}
+	return nil
+}
+
+// ValidateGroup returns an error unless groupBy is one Render accepts.
+func ValidateGroup(groupBy string) error {
+	switch groupBy {
+	case GroupCategory, GroupPublisher, GroupNone:
+		return nil
+	}
+	return fmt.Errorf("invalid digest grouping: %s (must be category, publisher, or none)", groupBy)
+}
+
+// GroupItems splits items into groups by category or publisher. Items keep
+// their order within each group, and groups are ordered by their first
+// item, so the highest ranked item's group comes first. Items without a
+// category or publisher are gathered into a group named "Other", which
+// always comes last.
+func GroupItems(items []newsfeed.NewsItem, groupBy string) []Group {
+	if groupBy == GroupNone || groupBy == "" {
+		if len(items) == 0 {
+			return nil
+		}
+		return []Group{{Items: items}}
+	}
+
+	var groups []Group
+	var other []newsfeed.NewsItem
+	index := map[string]int{}
+	for _, item := range items {
+		var key *string
+		if groupBy == GroupPublisher {
+			key = item.Publisher
+		} else {
+			key = item.Category
+		}
+		if key == nil || *key == "" {
+			other = append(other, item)
+			continue
+		}
+		i, ok := index[*key]
+		if !ok {
+			i = len(groups)
+			index[*key] = i
+			groups = append(groups, Group{Name: *key})
+		}
+		groups[i].Items = append(groups[i].Items, item)
+	}
+	if len(other) > 0 {
+		groups = append(groups, Group{Name: "Other", Items: other})
+	}
+	return groups
+}
+
+// Render writes items as a digest in the given format, grouped by groupBy.
+// Items are expected in the order they should be read, best first.
+func Render(w io.Writer, format string, info Info, items []newsfeed.NewsItem, groupBy string) error {
+	if err := ValidateFormat(format); err != nil {
+		return err
+	}
+	if err := ValidateGroup(groupBy); err != nil {
+		return err
+	}
+	if info.Title == "" {
+		info.Title = "newsfed digest"
+	}
+	if info.Until.IsZero() {
+		info.Until = time.Now()
+	}
+
+	groups := GroupItems(items, groupBy)
+	if format == FormatHTML {
+		return htmlDigest.Execute(w, digestData{Info: info, Period: period(info), Groups: groups})
+	}
+	return renderMarkdown(w, info, groups)
+}
+
+// period describes the time span a digest covers.
+func period(info Info) string {
+	const layout = "Jan 2, 2006 15:04"
+	if info.Since.IsZero() {
+		return "Until " + info.Until.Format(layout)
+	}
+	return info.Since.Format(layout) + " to " + info.Until.Format(layout)
+}
+
+// renderMarkdown writes a Markdown digest.
+func renderMarkdown(w io.Writer, info Info, groups []Group) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", markdownEscape(info.Title))
+	fmt.Fprintf(&b, "_%s_\n", period(info))
+
+	if len(groups) == 0 {
+		b.WriteString("\nNo new items.\n")
+	}
+	for _, g := range groups {
+		if g.Name != "" {
+			fmt.Fprintf(&b, "\n## %s\n", markdownEscape(g.Name))
+		}
+		b.WriteString("\n")
+		for _, item := range g.Items {
+			title := markdownEscape(item.Title)
+			if item.URL != "" {
+				fmt.Fprintf(&b, "- [%s](%s)", title, item.URL)
+			} else {
+				fmt.Fprintf(&b, "- %s", title)
+			}
+			if byline := byline(item); byline != "" {
+				fmt.Fprintf(&b, " (%s)", markdownEscape(byline))
+			}
+			b.WriteString("\n")
+			if summary := item.DisplaySummary(); summary != "" {
+				fmt.Fprintf(&b, "  %s\n", markdownEscape(truncate(summary, summaryLength)))
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// summaryLength is the most characters of an item's summary shown in a
+// digest.
+const summaryLength = 280
+
+// truncate shortens s to at most n characters, ending it with an ellipsis
+// if anything was cut.
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return strings.TrimSpace(string(r[:n-1])) + "…"
+}
+
+// byline returns the publisher and publication date of item.
+func byline(item newsfeed.NewsItem) string {
+	var parts []string
+	if item.Publisher != nil && *item.Publisher != "" {
+		parts = append(parts, *item.Publisher)
+	}
+	if !item.PublishedAt.IsZero() {
+		parts = append(parts, item.PublishedAt.Format("Jan 2"))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// markdownEscaper escapes the characters that would start Markdown
+// formatting inside titles and summaries.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`,
+	"<", `\<`, ">", `\>`,
+)
+
+// markdownEscape returns s with Markdown formatting characters escaped.
+func markdownEscape(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+// digestData is the value the HTML template is executed with.
+type digestData struct {
+	Info   Info
+	Period string
+	Groups []Group
+}
+
+// htmlDigest renders an HTML digest. Inline styles are used because many
+// mail clients ignore style sheets.
+var htmlDigest = template.Must(template.New("digest").Funcs(template.FuncMap{
+	"byline":  byline,
+	"summary": func(item newsfeed.NewsItem) string { return truncate(item.DisplaySummary(), summaryLength) },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Info.Title}}</title>
+</head>
+<body style="font-family: sans-serif; max-width: 40em; margin: auto;">
+<h1>{{.Info.Title}}</h1>
+<p><em>{{.Period}}</em></p>
+{{- if not .Groups}}
+<p>No new items.</p>
+{{- end}}
+{{- range .Groups}}
+{{- if .Name}}
+<h2>{{.Name}}</h2>
+{{- end}}
+<ul>
+{{- range .Items}}
+<li>
+{{- if .URL}}<a href="{{.URL}}">{{.Title}}</a>{{else}}{{.Title}}{{end}}
+{{- with byline .}} <small>({{.}})</small>{{end}}
+{{- with summary .}}<br>{{.}}{{end}}
+</li>
+{{- end}}
+</ul>
+{{- end}}
+</body>
+</html>
+`))
@@ -0,0 +1,118 @@
+package digest
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pevans/newsfed/newsfeed"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func strPtr(s string) *string { return &s }
+
+// testItems returns items from two categories and one without a category,
+// in rank order
+func testItems() []newsfeed.NewsItem {
+	return []newsfeed.NewsItem{
+		{Title: "Go 2 released", URL: "https://go.dev/blog", Category: strPtr("tech"), Publisher: strPtr("Go Blog"), Summary: "Big news."},
+		{Title: "Rain tomorrow", URL: "https://weather.example", Category: strPtr("local")},
+		{Title: "Uncategorized", URL: "https://example.com/x", GeneratedSummary: "A generated summary."},
+		{Title: "Rust 2 released", URL: "https://rust-lang.org", Category: strPtr("tech")},
+	}
+}
+
+// TestGroupItems_ByCategory verifies that groups follow the rank of their
+// first item and that items without a category come last
+func TestGroupItems_ByCategory(t *testing.T) {
+	groups := GroupItems(testItems(), GroupCategory)
+
+	require.Len(t, groups, 3)
+	assert.Equal(t, "tech", groups[0].Name)
+	require.Len(t, groups[0].Items, 2)
+	assert.Equal(t, "Go 2 released", groups[0].Items[0].Title)
+	assert.Equal(t, "Rust 2 released", groups[0].Items[1].Title)
+	assert.Equal(t, "local", groups[1].Name)
+	assert.Equal(t, "Other", groups[2].Name)
+	require.Len(t, groups[2].Items, 1)
+}
+
+// TestGroupItems_None verifies that ungrouped digests keep every item in
+// one unnamed group
+func TestGroupItems_None(t *testing.T) {
+	groups := GroupItems(testItems(), GroupNone)
+	require.Len(t, groups, 1)
+	assert.Empty(t, groups[0].Name)
+	assert.Len(t, groups[0].Items, 4)
+
+	assert.Nil(t, GroupItems(nil, GroupNone))
+}
+
+// TestRender_Markdown verifies the Markdown digest's headings, links, and
+// summaries
+func TestRender_Markdown(t *testing.T) {
+	info := Info{
+		Title: "Daily",
+		Since: time.Date(2026, 5, 1, 7, 0, 0, 0, time.UTC),
+		Until: time.Date(2026, 5, 2, 7, 0, 0, 0, time.UTC),
+	}
+	var buf bytes.Buffer
+	require.NoError(t, Render(&buf, FormatMarkdown, info, testItems(), GroupCategory))
+	out := buf.String()
+
+	assert.True(t, strings.HasPrefix(out, "# Daily\n\n_May 1, 2026 07:00 to May 2, 2026 07:00_\n"))
+	assert.Contains(t, out, "\n## tech\n\n- [Go 2 released](https://go.dev/blog) (Go Blog)\n  Big news.\n")
+	assert.Contains(t, out, "\n## Other\n\n- [Uncategorized](https://example.com/x)\n  A generated summary.\n")
+	assert.Less(t, strings.Index(out, "## tech"), strings.Index(out, "## local"))
+}
+
+// TestRender_MarkdownEscapes verifies that titles can't inject Markdown
+// formatting
+func TestRender_MarkdownEscapes(t *testing.T) {
+	items := []newsfeed.NewsItem{{Title: "[click](javascript:x) *bold*", URL: "https://example.com"}}
+	var buf bytes.Buffer
+	require.NoError(t, Render(&buf, FormatMarkdown, Info{}, items, GroupNone))
+	assert.Contains(t, buf.String(), `- [\[click\](javascript:x) \*bold\*](https://example.com)`)
+}
+
+// TestRender_HTMLEscapes verifies that the HTML digest escapes item text
+// and refuses unsafe link schemes
+func TestRender_HTMLEscapes(t *testing.T) {
+	items := []newsfeed.NewsItem{
+		{Title: "<script>alert(1)</script>", URL: "javascript:alert(1)", Category: strPtr("a & b")},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, Render(&buf, FormatHTML, Info{Title: "Digest"}, items, GroupCategory))
+	out := buf.String()
+
+	assert.Contains(t, out, "<title>Digest</title>")
+	assert.Contains(t, out, "<h2>a &amp; b</h2>")
+	assert.Contains(t, out, "&lt;script&gt;alert(1)&lt;/script&gt;")
+	assert.NotContains(t, out, "<script>")
+	assert.NotContains(t, out, `href="javascript:`)
+}
+
+// TestRender_Empty verifies that a digest with no items says so
+func TestRender_Empty(t *testing.T) {
+	for _, format := range []string{FormatMarkdown, FormatHTML} {
+		var buf bytes.Buffer
+		require.NoError(t, Render(&buf, format, Info{}, nil, GroupCategory))
+		assert.Contains(t, buf.String(), "No new items.", format)
+	}
+}
+
+// TestRender_InvalidOptions verifies that unknown formats and groupings are
+// rejected
+func TestRender_InvalidOptions(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Error(t, Render(&buf, "pdf", Info{}, nil, GroupNone))
+	assert.Error(t, Render(&buf, FormatHTML, Info{}, nil, "author"))
+}
+
+// TestTruncate verifies that long summaries are cut with an ellipsis
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "short", truncate("short", 10))
+	assert.Equal(t, "abcd…", truncate("abcd efghij", 6))
+}
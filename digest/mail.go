@@ -0,0 +1,93 @@
+package digest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SMTPConfig holds the settings for sending digests by email.
+type SMTPConfig struct {
+	Host     string
+	Port     int // Zero means 587
+	Username string
+	Password string
+	From     string // Sender address; defaults to Username
+}
+
+// sendMail delivers a message. It is a variable so tests can capture mail
+// instead of sending it.
+var sendMail = smtp.SendMail
+
+// ParseRecipients splits a comma-separated list of email addresses,
+// dropping empty entries.
+func ParseRecipients(list string) []string {
+	var to []string
+	for _, addr := range strings.Split(list, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			to = append(to, addr)
+		}
+	}
+	return to
+}
+
+// Send emails a rendered digest to each address in to. The body is sent as
+// text/html when format is FormatHTML and as text/plain otherwise, since
+// Markdown reads well as plain text.
+func Send(cfg SMTPConfig, to []string, subject, format string, body []byte) error {
+	if cfg.Host == "" {
+		return errors.New("no SMTP host configured")
+	}
+	if len(to) == 0 {
+		return errors.New("no recipients")
+	}
+	from := cfg.From
+	if from == "" {
+		from = cfg.Username
+	}
+	if from == "" {
+		return errors.New("no sender address configured")
+	}
+	for _, addr := range append([]string{from}, to...) {
+		if strings.ContainsAny(addr, "\r\n") {
+			return fmt.Errorf("invalid email address: %q", addr)
+		}
+	}
+	port := cfg.Port
+	if port == 0 {
+		port = 587
+	}
+
+	contentType := "text/plain; charset=utf-8"
+	if format == FormatHTML {
+		contentType = "text/html; charset=utf-8"
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: %s\r\n", contentType)
+	msg.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	msg.WriteString("\r\n")
+	msg.Write(bytes.ReplaceAll(bytes.ReplaceAll(body, []byte("\r\n"), []byte("\n")), []byte("\n"), []byte("\r\n")))
+
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(port))
+	if err := sendMail(addr, auth, from, to, msg.Bytes()); err != nil {
+		return fmt.Errorf("failed to send digest: %w", err)
+	}
+	return nil
+}
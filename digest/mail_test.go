@@ -0,0 +1,83 @@
+package digest
+
+import (
+	"errors"
+	"net/smtp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// captureMail replaces sendMail for the length of a test and returns the
+// arguments of the last call
+func captureMail(t *testing.T, err error) *sentMail {
+	t.Helper()
+	sent := &sentMail{}
+	orig := sendMail
+	sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		sent.addr, sent.auth, sent.from, sent.to, sent.msg = addr, a, from, to, string(msg)
+		return err
+	}
+	t.Cleanup(func() { sendMail = orig })
+	return sent
+}
+
+type sentMail struct {
+	addr string
+	auth smtp.Auth
+	from string
+	to   []string
+	msg  string
+}
+
+// TestSend_BuildsMessage verifies the headers and body of a sent digest
+func TestSend_BuildsMessage(t *testing.T) {
+	sent := captureMail(t, nil)
+	cfg := SMTPConfig{Host: "smtp.example.com", Username: "me@example.com", Password: "secret"}
+
+	err := Send(cfg, []string{"a@example.com", "b@example.com"}, "Digest – May 1", FormatHTML, []byte("<p>hi</p>\nbye\n"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "smtp.example.com:587", sent.addr)
+	assert.NotNil(t, sent.auth)
+	assert.Equal(t, "me@example.com", sent.from)
+	assert.Equal(t, []string{"a@example.com", "b@example.com"}, sent.to)
+	assert.Contains(t, sent.msg, "From: me@example.com\r\n")
+	assert.Contains(t, sent.msg, "To: a@example.com, b@example.com\r\n")
+	assert.Contains(t, sent.msg, "Subject: =?utf-8?q?")
+	assert.Contains(t, sent.msg, "Content-Type: text/html; charset=utf-8\r\n")
+	assert.Contains(t, sent.msg, "\r\n\r\n<p>hi</p>\r\nbye\r\n")
+}
+
+// TestSend_PlainText verifies that Markdown digests are sent as plain text
+// without authentication when no username is set
+func TestSend_PlainText(t *testing.T) {
+	sent := captureMail(t, nil)
+	cfg := SMTPConfig{Host: "localhost", Port: 25, From: "newsfed@localhost"}
+
+	require.NoError(t, Send(cfg, []string{"me@example.com"}, "Digest", FormatMarkdown, []byte("# Digest\n")))
+	assert.Equal(t, "localhost:25", sent.addr)
+	assert.Nil(t, sent.auth)
+	assert.Contains(t, sent.msg, "Content-Type: text/plain; charset=utf-8\r\n")
+}
+
+// TestSend_Errors verifies that missing settings and delivery failures are
+// reported
+func TestSend_Errors(t *testing.T) {
+	captureMail(t, errors.New("connection refused"))
+
+	assert.Error(t, Send(SMTPConfig{}, []string{"a@example.com"}, "s", FormatHTML, nil))
+	assert.Error(t, Send(SMTPConfig{Host: "h", From: "f@x"}, nil, "s", FormatHTML, nil))
+	assert.Error(t, Send(SMTPConfig{Host: "h"}, []string{"a@example.com"}, "s", FormatHTML, nil))
+	assert.Error(t, Send(SMTPConfig{Host: "h", From: "f@x"}, []string{"a@example.com\r\nBcc: x@y"}, "s", FormatHTML, nil))
+
+	err := Send(SMTPConfig{Host: "h", From: "f@x"}, []string{"a@example.com"}, "s", FormatHTML, nil)
+	assert.ErrorContains(t, err, "connection refused")
+}
+
+// TestParseRecipients verifies that recipient lists are split and trimmed
+func TestParseRecipients(t *testing.T) {
+	assert.Equal(t, []string{"a@x", "b@y"}, ParseRecipients(" a@x, ,b@y ,"))
+	assert.Nil(t, ParseRecipients(""))
+}
@@ -0,0 +1,164 @@
+package digest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron expression with the usual five fields: minute,
+// hour, day of month, month, and day of week. Each field may be "*", a
+// number, a range such as "1-5", a list such as "1,15", or any of those
+// with a step such as "*/15". The shorthands @hourly, @daily, and @weekly
+// are also accepted.
+type Schedule struct {
+	minute, hour, dom, month, dow uint64 // Bit n is set when value n matches
+	// Cron treats the two day fields specially: when both are restricted,
+	// a day matches if either does.
+	domAny, dowAny bool
+}
+
+// cronFields gives the range of each field of a cron expression.
+var cronFields = []struct {
+	name     string
+	min, max int
+}{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// cronShorthands maps the accepted @ shorthands to their expressions.
+var cronShorthands = map[string]string{
+	"@hourly": "0 * * * *",
+	"@daily":  "0 0 * * *",
+	"@weekly": "0 0 * * 0",
+}
+
+// ParseSchedule parses a cron expression.
+func ParseSchedule(expr string) (*Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if full, ok := cronShorthands[expr]; ok {
+		expr = full
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("invalid schedule %q: expected 5 fields, got %d", expr, len(fields))
+	}
+
+	var bits [5]uint64
+	for i, field := range fields {
+		b, err := parseCronField(field, cronFields[i].min, cronFields[i].max)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %s: %w", expr, cronFields[i].name, err)
+		}
+		bits[i] = b
+	}
+
+	// Sunday may be written as 0 or 7
+	if bits[4]&(1<<7) != 0 {
+		bits[4] |= 1
+	}
+
+	return &Schedule{
+		minute: bits[0],
+		hour:   bits[1],
+		dom:    bits[2],
+		month:  bits[3],
+		dow:    bits[4],
+		domAny: fields[2] == "*",
+		dowAny: fields[4] == "*",
+	}, nil
+}
+
+// parseCronField parses one comma-separated field of a cron expression into
+// a bit set of the values it matches.
+func parseCronField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if i := strings.IndexByte(part, '/'); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			rangePart, step = part[:i], n
+		}
+
+		lo, hi := min, max
+		if rangePart != "*" {
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if lo, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, fmt.Errorf("invalid value %q", part)
+			}
+			hi = lo
+			if len(bounds) == 2 {
+				if hi, err = strconv.Atoi(bounds[1]); err != nil {
+					return 0, fmt.Errorf("invalid value %q", part)
+				}
+			} else if step > 1 {
+				// "5/15" means every 15 starting at 5
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("%q is outside %d-%d", part, min, max)
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// Matches reports whether t falls in a minute the schedule selects.
+func (s *Schedule) Matches(t time.Time) bool {
+	return s.minute&(1<<uint(t.Minute())) != 0 &&
+		s.hour&(1<<uint(t.Hour())) != 0 &&
+		s.matchesDay(t)
+}
+
+// matchesDay reports whether the schedule selects any minute of t's day.
+func (s *Schedule) matchesDay(t time.Time) bool {
+	if s.month&(1<<uint(t.Month())) == 0 {
+		return false
+	}
+
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	switch {
+	case s.domAny && s.dowAny:
+		return true
+	case s.domAny:
+		return dowMatch
+	case s.dowAny:
+		return domMatch
+	}
+	return domMatch || dowMatch
+}
+
+// Next returns the first minute after t that the schedule selects, or the
+// zero time if there is none within the next five years (as with "0 0 30
+// 2 *").
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	end := t.AddDate(5, 0, 0)
+	for t.Before(end) {
+		if !s.matchesDay(t) {
+			// Skip to the start of the next day
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.Matches(t) {
+			return t
+		}
+		t = t.Add(time.Minute)
+	}
+	return time.Time{}
+}
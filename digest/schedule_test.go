@@ -0,0 +1,93 @@
+package digest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseSchedule_Matches verifies field syntax against specific times
+func TestParseSchedule_Matches(t *testing.T) {
+	// Friday, May 1 2026, 07:30
+	at := time.Date(2026, 5, 1, 7, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{"* * * * *", true},
+		{"30 7 * * *", true},
+		{"0 7 * * *", false},
+		{"*/15 * * * *", true},
+		{"*/20 * * * *", false},
+		{"0,30 6-8 * * *", true},
+		{"30 7 * * 1-5", true},
+		{"30 7 * * 0,6", false},
+		{"30 7 1 * *", true},
+		{"30 7 2 * *", false},
+		// When both day fields are restricted, either may match
+		{"30 7 15 * 5", true},
+		{"30 7 * 6 *", false},
+		{"10/20 * * * *", true},
+	}
+	for _, tt := range tests {
+		s, err := ParseSchedule(tt.expr)
+		require.NoError(t, err, tt.expr)
+		assert.Equal(t, tt.want, s.Matches(at), tt.expr)
+	}
+}
+
+// TestParseSchedule_Sunday verifies that Sunday may be written as 0 or 7
+func TestParseSchedule_Sunday(t *testing.T) {
+	sunday := time.Date(2026, 5, 3, 0, 0, 0, 0, time.UTC)
+	for _, expr := range []string{"0 0 * * 0", "0 0 * * 7", "@weekly"} {
+		s, err := ParseSchedule(expr)
+		require.NoError(t, err)
+		assert.True(t, s.Matches(sunday), expr)
+	}
+}
+
+// TestParseSchedule_Invalid verifies that malformed expressions are
+// rejected
+func TestParseSchedule_Invalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"@monthly",
+	} {
+		_, err := ParseSchedule(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+// TestSchedule_Next verifies that Next finds the following run, including
+// across days, and gives up on impossible dates
+func TestSchedule_Next(t *testing.T) {
+	from := time.Date(2026, 5, 1, 7, 30, 0, 0, time.UTC)
+
+	s, err := ParseSchedule("@daily")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 5, 2, 0, 0, 0, 0, time.UTC), s.Next(from))
+
+	s, err = ParseSchedule("30 7 * * *")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 5, 2, 7, 30, 0, 0, time.UTC), s.Next(from))
+
+	s, err = ParseSchedule("0 9 * * 1")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 5, 4, 9, 0, 0, 0, time.UTC), s.Next(from))
+
+	s, err = ParseSchedule("0 0 30 2 *")
+	require.NoError(t, err)
+	assert.True(t, s.Next(from).IsZero())
+}
@@ -0,0 +1,67 @@
+package discovery
+
+import (
+	"context"
+	"sync"
+)
+
+// articleScrape is the outcome of scraping one article page. done is closed
+// once article and err are set.
+type articleScrape struct {
+	article *ScrapedArticle
+	err     error
+	done    chan struct{}
+}
+
+// articleScrapes are article pages of one list page being scraped in the
+// background.
+type articleScrapes struct {
+	byURL  map[string]*articleScrape
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// scrapeArticles starts scraping urls in order, at most workers at a time.
+// Each scrape still waits its turn with the domain's rate limiter, so
+// parallel scrapes overlap slow responses rather than sending requests
+// faster. A URL listed more than once is scraped once.
+func (ds *DiscoveryService) scrapeArticles(ctx context.Context, urls []string, domain string, config ArticleConfig, opts RequestOptions, workers int) *articleScrapes {
+	ctx, cancel := context.WithCancel(ctx)
+	s := &articleScrapes{byURL: make(map[string]*articleScrape, len(urls)), cancel: cancel}
+
+	queue := make(chan string, len(urls))
+	for _, url := range urls {
+		if _, ok := s.byURL[url]; !ok {
+			s.byURL[url] = &articleScrape{done: make(chan struct{})}
+			queue <- url
+		}
+	}
+	close(queue)
+
+	for range min(max(workers, 1), len(s.byURL)) {
+		s.wg.Go(func() {
+			for url := range queue {
+				scrape := s.byURL[url]
+				if scrape.err = ds.rateLimiter.wait(ctx, domain); scrape.err == nil {
+					scrape.article, scrape.err = scrapeArticle(ctx, url, config, opts)
+				}
+				close(scrape.done)
+			}
+		})
+	}
+	return s
+}
+
+// result waits for url's scrape to finish and returns its outcome.
+func (s *articleScrapes) result(url string) (*ScrapedArticle, error) {
+	scrape := s.byURL[url]
+	<-scrape.done
+	return scrape.article, scrape.err
+}
+
+// stop cancels the scrapes still running or waiting and waits for them to
+// return.
+func (s *articleScrapes) stop() {
+	s.cancel()
+	s.wg.Wait()
+}
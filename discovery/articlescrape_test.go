@@ -0,0 +1,78 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pevans/newsfed/newsfeed"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSyncSources_ListModeParallel verifies that a list-mode source's
+// articles are scraped several at a time, no more than ArticleConcurrency,
+// with requests still spaced by the rate limit, and that a link listed
+// twice is scraped once and counted as a duplicate
+func TestSyncSources_ListModeParallel(t *testing.T) {
+	var mu sync.Mutex
+	var starts []time.Time
+	var inFlight, maxInFlight atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/list", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, "<html><body>")
+		for i := range 8 {
+			_, _ = fmt.Fprintf(w, `<a class="post" href="/article/%d">Post %d</a>`, i, i)
+		}
+		_, _ = fmt.Fprint(w, `<a class="post" href="/article/0">Post 0 again</a></body></html>`)
+	})
+	mux.HandleFunc("/article/", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		starts = append(starts, time.Now())
+		mu.Unlock()
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			peak := maxInFlight.Load()
+			if n <= peak || maxInFlight.CompareAndSwap(peak, n) {
+				break
+			}
+		}
+		time.Sleep(150 * time.Millisecond)
+		_, _ = fmt.Fprintf(w, `<html><head><title>%s</title></head><body><h1>%s</h1><div class="content">Body</div></body></html>`, r.URL.Path, r.URL.Path)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	sourceStore := newTestSourceStore(t)
+	newsFeed := newsfeed.NewMemoryNewsFeed()
+
+	config := DefaultDiscoveryConfig()
+	config.RateLimitInterval = 20 * time.Millisecond
+	config.ArticleConcurrency = 3
+	service := NewDiscoveryService(sourceStore, newsFeed, config)
+
+	now := time.Now()
+	source, err := sourceStore.CreateSource("website", server.URL+"/list", "Test Site", &ScraperConfig{
+		DiscoveryMode: "list",
+		ListConfig:    &ListConfig{ArticleSelector: "a.post", MaxPages: 1},
+		ArticleConfig: ArticleConfig{TitleSelector: "h1", ContentSelector: ".content"},
+	}, &now)
+	require.NoError(t, err)
+
+	result, err := service.SyncSources(context.Background(), SyncOptions{SourceID: &source.SourceID}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 8, result.ItemsDiscovered)
+	assert.Equal(t, 1, result.Duplicates.URLDuplicates)
+
+	require.Len(t, starts, 8)
+	assert.Equal(t, int32(3), maxInFlight.Load())
+	for i := 1; i < len(starts); i++ {
+		assert.GreaterOrEqual(t, starts[i].Sub(starts[i-1]), 15*time.Millisecond, "requests should keep to the rate limit")
+	}
+}
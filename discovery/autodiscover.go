@@ -2,14 +2,20 @@ package discovery
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
+	"net/http"
 	"net/url"
-	"regexp"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
+	"github.com/pevans/newsfed/internal/httpjson"
+	"github.com/pevans/newsfed/sources"
 )
 
 // AutodiscoverTimeout is the recommended overall timeout for a complete feed
@@ -18,10 +24,109 @@ const AutodiscoverTimeout = 30 * time.Second
 
 // DiscoveredFeed holds the result of a successful feed autodiscovery.
 type DiscoveredFeed struct {
-	FeedURL     string
-	FeedType    string // "rss" or "atom"
-	Title       string
-	FoundDirect bool // true when Strategy 1 (direct parse) found the feed
+	FeedURL     string `json:"feed_url"`
+	FeedType    string `json:"feed_type"` // "rss", "atom", or "json"
+	Title       string `json:"title,omitempty"`
+	FoundDirect bool   `json:"found_direct"` // true when Strategy 1 (direct parse) found the feed
+}
+
+// FeedDiscovery lists every feed found for a URL, along with the title of
+// the page that advertised them.
+type FeedDiscovery struct {
+	InputURL  string           `json:"input_url"`
+	PageTitle string           `json:"page_title,omitempty"` // Empty when the URL was itself a feed
+	Feeds     []DiscoveredFeed `json:"feeds"`
+}
+
+// SuggestedName returns a name for a source created from the discovery: the
+// page title if there is one, otherwise the first feed's title.
+func (d *FeedDiscovery) SuggestedName() string {
+	if d.PageTitle != "" {
+		return d.PageTitle
+	}
+	if len(d.Feeds) > 0 {
+		return d.Feeds[0].Title
+	}
+	return ""
+}
+
+// feedLinkTypes are the <link rel="alternate"> types that advertise a feed.
+var feedLinkTypes = map[string]bool{
+	"application/rss+xml":   true,
+	"application/atom+xml":  true,
+	"application/feed+json": true,
+}
+
+// feedProber tries candidate feed URLs, remembering each URL it has tried and
+// why it failed so that a failed discovery can explain itself.
+type feedProber struct {
+	ctx      context.Context
+	inputURL string
+	opts     RequestOptions
+	tried    []probeAttempt
+	triedSet map[string]bool
+}
+
+// probeAttempt records one URL that did not parse as a feed.
+type probeAttempt struct {
+	url    string
+	reason string
+}
+
+func newFeedProber(ctx context.Context, inputURL string, opts RequestOptions) *feedProber {
+	return &feedProber{ctx: ctx, inputURL: inputURL, opts: opts, triedSet: map[string]bool{}}
+}
+
+// try attempts to parse u as a feed. On success it returns a DiscoveredFeed.
+// If u has been tried before it returns nil without logging. On failure it
+// records the attempt and returns nil.
+func (p *feedProber) try(u string) *DiscoveredFeed {
+	if p.triedSet[u] {
+		return nil
+	}
+	p.triedSet[u] = true
+	result, err := FetchFeedConditional(p.ctx, u, nil, nil, p.opts)
+	if err != nil {
+		p.tried = append(p.tried, probeAttempt{u, describeErr(err)})
+		return nil
+	}
+	return &DiscoveredFeed{FeedURL: u, FeedType: result.Feed.FeedType, Title: result.Feed.Title}
+}
+
+// note records a URL that was fetched but yielded no feed.
+func (p *feedProber) note(u, reason string) {
+	p.tried = append(p.tried, probeAttempt{u, reason})
+}
+
+// probeCommonPaths runs Strategy 3, returning the first common feed path
+// that parses.
+func (p *feedProber) probeCommonPaths() (*DiscoveredFeed, error) {
+	// try skips any URL already tried, providing cross-strategy
+	// deduplication at no extra cost.
+	for _, pu := range generateProbeURLs(p.inputURL) {
+		if p.ctx.Err() != nil {
+			return nil, p.err(p.ctx.Err())
+		}
+		if result := p.try(pu); result != nil {
+			return result, nil
+		}
+	}
+	return nil, p.err(nil)
+}
+
+// err assembles a descriptive error listing every URL tried and why it
+// failed, optionally wrapping a cause (e.g. context deadline).
+func (p *feedProber) err(cause error) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "no feed found at %s\n\nTried:\n", p.inputURL)
+	for _, a := range p.tried {
+		fmt.Fprintf(&sb, "  %s -- %s\n", a.url, a.reason)
+	}
+	msg := strings.TrimRight(sb.String(), "\n")
+	if cause != nil {
+		return fmt.Errorf("%s: %w", msg, cause)
+	}
+	return errors.New(msg)
 }
 
 // DiscoverFeed runs the three-strategy probe sequence defined in Spec 10
@@ -30,101 +135,216 @@ type DiscoveredFeed struct {
 // 5.2. Returns a DiscoveredFeed on success, or a descriptive error listing
 // every URL tried and why it failed.
 func DiscoverFeed(ctx context.Context, inputURL string) (*DiscoveredFeed, error) {
-	type attempt struct {
-		url    string
-		reason string
-	}
-	var tried []attempt
-	triedSet := map[string]bool{}
-
-	// tryFeed attempts to parse u as a feed. On success it returns a
-	// DiscoveredFeed. If u has been tried before it returns nil without
-	// logging. On failure it appends to tried and returns nil.
-	tryFeed := func(u string) *DiscoveredFeed {
-		if triedSet[u] {
-			return nil
-		}
-		triedSet[u] = true
-		feed, err := FetchFeed(ctx, u)
-		if err != nil {
-			tried = append(tried, attempt{u, describeErr(err)})
-			return nil
-		}
-		return &DiscoveredFeed{FeedURL: u, FeedType: feed.FeedType, Title: feed.Title}
-	}
+	p := newFeedProber(ctx, inputURL, RequestOptions{})
 
 	// Strategy 1 -- direct parse
-	if result := tryFeed(inputURL); result != nil {
+	if result := p.try(inputURL); result != nil {
 		result.FoundDirect = true
 		return result, nil
 	}
 
-	// buildErr assembles a descriptive error listing every URL tried and why
-	// it failed, optionally wrapping a cause (e.g. context deadline).
-	buildErr := func(cause error) error {
-		var sb strings.Builder
-		fmt.Fprintf(&sb, "no feed found at %s\n\nTried:\n", inputURL)
-		for _, a := range tried {
-			fmt.Fprintf(&sb, "  %s -- %s\n", a.url, a.reason)
-		}
-		msg := strings.TrimRight(sb.String(), "\n")
-		if cause != nil {
-			return fmt.Errorf("%s: %w", msg, cause)
-		}
-		return errors.New(msg)
-	}
-
 	// Stop early if the context has been cancelled between strategies.
 	if ctx.Err() != nil {
-		return nil, buildErr(ctx.Err())
+		return nil, p.err(ctx.Err())
 	}
 
 	// Strategy 2 -- HTML link tags. The HTML-fetch outcome is recorded in
 	// tried separately from the feed-parse attempts made against each
 	// discovered link URL.
 	if doc, err := FetchHTML(ctx, inputURL); err == nil {
-		var linkURLs []string
-		doc.Find(`link[rel="alternate"]`).Each(func(_ int, s *goquery.Selection) {
-			t := s.AttrOr("type", "")
-			if t != "application/rss+xml" && t != "application/atom+xml" {
-				return
-			}
-			href := s.AttrOr("href", "")
-			if href == "" {
-				return
-			}
-			if resolved := resolveRef(inputURL, href); resolved != "" {
-				linkURLs = append(linkURLs, resolved)
-			}
-		})
+		linkURLs := feedLinks(doc, inputURL)
 		for _, lu := range linkURLs {
-			if result := tryFeed(lu); result != nil {
+			if result := p.try(lu); result != nil {
 				return result, nil
 			}
 		}
 		// Record that the HTML was fetched but yielded no usable feed links.
 		if len(linkURLs) == 0 {
-			tried = append(tried, attempt{inputURL, "no feed links in page"})
+			p.note(inputURL, "no feed links in page")
 		}
 	}
 
 	// Stop early if the context has been cancelled between strategies.
 	if ctx.Err() != nil {
-		return nil, buildErr(ctx.Err())
+		return nil, p.err(ctx.Err())
+	}
+
+	// Strategy 3 -- common path probing.
+	return p.probeCommonPaths()
+}
+
+// DiscoverFeeds finds every feed for a URL rather than stopping at the first.
+// If the URL is itself a feed, that feed is the only result. Otherwise every
+// feed advertised by the page's link tags that parses is returned, in page
+// order, along with the page title. Only if the page advertises no working
+// feed are common feed paths probed, and then the first match is returned.
+// The same timeout guidance as DiscoverFeed applies.
+func DiscoverFeeds(ctx context.Context, inputURL string) (*FeedDiscovery, error) {
+	return DiscoverFeedsWithOptions(ctx, inputURL, RequestOptions{})
+}
+
+// DiscoverFeedsWithOptions is DiscoverFeeds with the given request options
+// applied to every page and feed it fetches.
+func DiscoverFeedsWithOptions(ctx context.Context, inputURL string, opts RequestOptions) (*FeedDiscovery, error) {
+	p := newFeedProber(ctx, inputURL, opts)
+	found := &FeedDiscovery{InputURL: inputURL}
+
+	if result := p.try(inputURL); result != nil {
+		result.FoundDirect = true
+		found.Feeds = []DiscoveredFeed{*result}
+		return found, nil
+	}
+	if ctx.Err() != nil {
+		return nil, p.err(ctx.Err())
 	}
 
-	// Strategy 3 -- common path probing. tryFeed skips any URL already in
-	// triedSet, providing cross-strategy deduplication at no extra cost.
-	for _, pu := range generateProbeURLs(inputURL) {
-		if ctx.Err() != nil {
-			return nil, buildErr(ctx.Err())
+	if doc, err := FetchHTMLWithOptions(ctx, inputURL, p.opts); err == nil {
+		found.PageTitle = pageTitle(doc)
+		linkURLs := feedLinks(doc, inputURL)
+		for _, lu := range linkURLs {
+			if result := p.try(lu); result != nil {
+				found.Feeds = append(found.Feeds, *result)
+			}
 		}
-		if result := tryFeed(pu); result != nil {
-			return result, nil
+		if len(found.Feeds) > 0 {
+			return found, nil
 		}
+		if len(linkURLs) == 0 {
+			p.note(inputURL, "no feed links in page")
+		}
+	}
+	if ctx.Err() != nil {
+		return nil, p.err(ctx.Err())
 	}
 
-	return nil, buildErr(nil)
+	result, err := p.probeCommonPaths()
+	if err != nil {
+		return nil, err
+	}
+	found.Feeds = []DiscoveredFeed{*result}
+	return found, nil
+}
+
+// discoverRequest is the body of a request to discover a site's feeds.
+type discoverRequest struct {
+	URL    string `json:"url"`
+	Create bool   `json:"create"`
+	Name   string `json:"name"`
+	Pick   int    `json:"pick"`
+}
+
+// discoverResponse reports the feeds found for a URL and the source created
+// from one, if any.
+type discoverResponse struct {
+	*FeedDiscovery
+	SuggestedName string          `json:"suggested_name"`
+	Source        *sources.Source `json:"source,omitempty"`
+}
+
+// DiscoverSourcesHandler finds the feeds a page advertises, as
+// DiscoverFeeds does, for POST /api/v1/meta/sources/discover, from {"url":
+// "..."}. With "create": true it also creates a source from the feed
+// numbered pick (default 1), named name or else the suggested name, as
+// newsfed sources discover -add does. It responds 200 OK with the feeds
+// found and the suggested name, 201 Created with the new source as well, 400
+// for a missing or non-http(s) URL, a URL on a private address, a pick out
+// of range, or no name to give the source, and 422 if no feed is found.
+func (ds *DiscoveryService) DiscoverSourcesHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req discoverRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&req); err != nil {
+			httpjson.Error(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+			return
+		}
+		req.URL = strings.TrimSpace(req.URL)
+		parsed, err := url.Parse(req.URL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			httpjson.Error(w, http.StatusBadRequest, "url must be an absolute http or https URL")
+			return
+		}
+		if req.Pick == 0 {
+			req.Pick = 1
+		}
+
+		// Per Spec 10 section 5.2
+		ctx, cancel := context.WithTimeout(r.Context(), AutodiscoverTimeout)
+		defer cancel()
+		if !ds.savePrivate {
+			if err := checkPublicHost(ctx, parsed.Hostname()); err != nil {
+				httpjson.Error(w, http.StatusBadRequest, err.Error())
+				return
+			}
+		}
+		opts, err := ds.requestOptionsFor(sources.Source{})
+		if err != nil {
+			httpjson.Error(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		opts.PublicOnly = !ds.savePrivate
+
+		found, err := DiscoverFeedsWithOptions(ctx, req.URL, opts)
+		if err != nil {
+			httpjson.Error(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+		resp := discoverResponse{FeedDiscovery: found, SuggestedName: found.SuggestedName()}
+		if !req.Create {
+			httpjson.Write(w, http.StatusOK, resp)
+			return
+		}
+
+		if req.Pick < 1 || req.Pick > len(found.Feeds) {
+			httpjson.Error(w, http.StatusBadRequest, fmt.Sprintf("pick must be between 1 and %d", len(found.Feeds)))
+			return
+		}
+		feed := found.Feeds[req.Pick-1]
+		name := strings.TrimSpace(req.Name)
+		if name == "" {
+			name = resp.SuggestedName
+		}
+		if name == "" {
+			httpjson.Error(w, http.StatusBadRequest, "no name could be suggested; name is required")
+			return
+		}
+		now := time.Now().UTC()
+		resp.Source, err = ds.sourceStore.CreateSource(feed.FeedType, feed.FeedURL, name, nil, &now)
+		if err != nil {
+			httpjson.Error(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		httpjson.Write(w, http.StatusCreated, resp)
+	})
+}
+
+// feedLinks returns the absolute URLs of the feeds advertised by a page's
+// <link rel="alternate"> tags, in page order.
+func feedLinks(doc *goquery.Document, pageURL string) []string {
+	var linkURLs []string
+	doc.Find(`link[rel="alternate"]`).Each(func(_ int, s *goquery.Selection) {
+		t := strings.ToLower(strings.TrimSpace(s.AttrOr("type", "")))
+		if !feedLinkTypes[t] {
+			return
+		}
+		href := s.AttrOr("href", "")
+		if href == "" {
+			return
+		}
+		if resolved := resolveRef(pageURL, href); resolved != "" {
+			linkURLs = append(linkURLs, resolved)
+		}
+	})
+	return linkURLs
+}
+
+// pageTitle returns a page's site name, preferring og:site_name over the
+// <title> element.
+func pageTitle(doc *goquery.Document) string {
+	if name, ok := doc.Find(`meta[property="og:site_name"]`).First().Attr("content"); ok {
+		if name = strings.TrimSpace(name); name != "" {
+			return name
+		}
+	}
+	return strings.TrimSpace(doc.Find("title").First().Text())
 }
 
 // generateProbeURLs returns candidate feed URLs for the given input URL,
@@ -186,26 +406,24 @@ func resolveRef(base, href string) string {
 	return b.ResolveReference(h).String()
 }
 
-// httpErrRe matches gofeed's "http error: NNN" error format, anchored to
-// avoid false positives from port numbers or other digit sequences.
-var httpErrRe = regexp.MustCompile(`http error: (\d{3})`)
-
 // describeErr produces a short human-readable reason for a FetchFeed failure.
 func describeErr(err error) string {
 	if err == nil {
 		return ""
 	}
-	msg := err.Error()
-	if m := httpErrRe.FindStringSubmatch(msg); m != nil {
-		return m[1]
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return strconv.Itoa(statusErr.StatusCode)
 	}
-	if strings.Contains(msg, "connection refused") {
+	if errors.Is(err, syscall.ECONNREFUSED) {
 		return "connection refused"
 	}
-	if strings.Contains(msg, "no such host") {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
 		return "no such host"
 	}
-	if strings.Contains(msg, "timeout") || strings.Contains(msg, "deadline exceeded") {
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
 		return "timeout"
 	}
 	return "not a feed"
@@ -2,12 +2,15 @@ package discovery
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 
+	"github.com/pevans/newsfed/newsfeed"
+	"github.com/pevans/newsfed/sources"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -206,3 +209,175 @@ func TestDiscoverFeed_CrossStrategyDedup(t *testing.T) {
 	_, _ = DiscoverFeed(context.Background(), srv.URL+"/")
 	assert.Equal(t, 1, tries, "/index.xml should only be fetched once across all strategies")
 }
+
+const atomBody = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Test Atom Feed</title>
+  <id>urn:test</id>
+  <updated>2026-01-01T00:00:00Z</updated>
+</feed>`
+
+const jsonFeedBody = `{
+  "version": "https://jsonfeed.org/version/1.1",
+  "title": "Test JSON Feed",
+  "items": []
+}`
+
+// TestDiscoverFeeds_AllLinkedFeeds verifies every working advertised feed is
+// returned in page order along with the page title
+func TestDiscoverFeeds_AllLinkedFeeds(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/rss.xml", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(rssBody))
+	})
+	mux.HandleFunc("/atom.xml", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(atomBody))
+	})
+	mux.HandleFunc("/feed.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/feed+json")
+		_, _ = w.Write([]byte(jsonFeedBody))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`<!DOCTYPE html><html><head>
+<title>Example Blog | Home</title>
+<meta property="og:site_name" content="Example Blog">
+<link rel="alternate" type="application/rss+xml" href="/rss.xml">
+<link rel="alternate" type="application/atom+xml" href="/atom.xml">
+<link rel="alternate" type="application/rss+xml" href="/missing.xml">
+<link rel="alternate" type="application/feed+json" href="/feed.json">
+</head><body>Hello</body></html>`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	found, err := DiscoverFeeds(context.Background(), srv.URL+"/")
+	require.NoError(t, err)
+	assert.Equal(t, "Example Blog", found.PageTitle)
+	assert.Equal(t, "Example Blog", found.SuggestedName())
+
+	require.Len(t, found.Feeds, 3)
+	assert.Equal(t, srv.URL+"/rss.xml", found.Feeds[0].FeedURL)
+	assert.Equal(t, "rss", found.Feeds[0].FeedType)
+	assert.Equal(t, srv.URL+"/atom.xml", found.Feeds[1].FeedURL)
+	assert.Equal(t, "atom", found.Feeds[1].FeedType)
+	assert.Equal(t, srv.URL+"/feed.json", found.Feeds[2].FeedURL)
+	assert.Equal(t, "json", found.Feeds[2].FeedType)
+	assert.Equal(t, "Test JSON Feed", found.Feeds[2].Title)
+}
+
+// TestDiscoverFeeds_DirectFeed verifies a feed URL yields only that feed and
+// the feed title is suggested as the name
+func TestDiscoverFeeds_DirectFeed(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(jsonFeedBody))
+	}))
+	defer srv.Close()
+
+	found, err := DiscoverFeeds(context.Background(), srv.URL)
+	require.NoError(t, err)
+	require.Len(t, found.Feeds, 1)
+	assert.True(t, found.Feeds[0].FoundDirect)
+	assert.Equal(t, "json", found.Feeds[0].FeedType)
+	assert.Empty(t, found.PageTitle)
+	assert.Equal(t, "Test JSON Feed", found.SuggestedName())
+}
+
+// TestDiscoverFeeds_FallsBackToProbing verifies common paths are probed when
+// the page advertises no feeds
+func TestDiscoverFeeds_FallsBackToProbing(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/feed", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(rssBody))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`<!DOCTYPE html><html><head><title>Plain Site</title></head><body></body></html>`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	found, err := DiscoverFeeds(context.Background(), srv.URL+"/")
+	require.NoError(t, err)
+	assert.Equal(t, "Plain Site", found.PageTitle)
+	require.Len(t, found.Feeds, 1)
+	assert.Equal(t, srv.URL+"/feed", found.Feeds[0].FeedURL)
+}
+
+// TestDiscoveryService_DiscoverSourcesHandler verifies that the feeds a page
+// advertises are listed, that the picked one is created as a source when
+// asked, and that bad requests are refused
+func TestDiscoveryService_DiscoverSourcesHandler(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/rss.xml", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(rssBody))
+	})
+	mux.HandleFunc("/atom.xml", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(atomBody))
+	})
+	mux.HandleFunc("/feed.json", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(jsonFeedBody))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`<!DOCTYPE html><html><head>
+<title>Example Blog</title>
+<link rel="alternate" type="application/rss+xml" href="/rss.xml">
+<link rel="alternate" type="application/atom+xml" href="/atom.xml">
+<link rel="alternate" type="application/feed+json" href="/feed.json">
+</head><body>Hello</body></html>`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	sourceStore := newTestSourceStore(t)
+	service := NewDiscoveryService(sourceStore, newsfeed.NewMemoryNewsFeed(), nil)
+	service.savePrivate = true
+	post := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		service.DiscoverSourcesHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/meta/sources/discover", strings.NewReader(body)))
+		return rec
+	}
+
+	rec := post(fmt.Sprintf(`{"url": %q}`, srv.URL+"/"))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var listed discoverResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &listed))
+	assert.Equal(t, "Example Blog", listed.SuggestedName)
+	require.Len(t, listed.Feeds, 3)
+	assert.Equal(t, []string{"rss", "atom", "json"}, []string{listed.Feeds[0].FeedType, listed.Feeds[1].FeedType, listed.Feeds[2].FeedType})
+	assert.Nil(t, listed.Source)
+	all, err := sourceStore.ListSources(sources.SourceFilter{})
+	require.NoError(t, err)
+	assert.Empty(t, all)
+
+	rec = post(fmt.Sprintf(`{"url": %q, "create": true, "pick": 2}`, srv.URL+"/"))
+	require.Equal(t, http.StatusCreated, rec.Code)
+	var created discoverResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	require.NotNil(t, created.Source)
+	assert.Equal(t, "atom", created.Source.SourceType)
+	assert.Equal(t, srv.URL+"/atom.xml", created.Source.URL)
+	assert.Equal(t, "Example Blog", created.Source.Name)
+	stored, err := sourceStore.GetSource(created.Source.SourceID)
+	require.NoError(t, err)
+	assert.Equal(t, created.Source.URL, stored.URL)
+
+	assert.Equal(t, http.StatusBadRequest, post(fmt.Sprintf(`{"url": %q, "create": true, "pick": 4}`, srv.URL+"/")).Code)
+	assert.Equal(t, http.StatusBadRequest, post(`{"url": "ftp://example.com/"}`).Code)
+	empty := httptest.NewServer(http.NotFoundHandler())
+	defer empty.Close()
+	assert.Equal(t, http.StatusUnprocessableEntity, post(fmt.Sprintf(`{"url": %q}`, empty.URL+"/")).Code)
+
+	service.savePrivate = false
+	assert.Equal(t, http.StatusBadRequest, post(fmt.Sprintf(`{"url": %q}`, srv.URL+"/")).Code)
+}
@@ -0,0 +1,118 @@
+package discovery
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/pevans/newsfed/sources"
+)
+
+// The limits of a fetch budget, as reported by BudgetError.
+const (
+	BudgetRequests = "requests"
+	BudgetBytes    = "bytes"
+	BudgetDuration = "duration"
+)
+
+// BudgetError is returned for a request that a source's fetch budget
+// doesn't allow, or a response body read past it. Fetches stop at the first
+// one, keeping the items they have already found, and count as successful.
+type BudgetError struct {
+	Limit  string // BudgetRequests, BudgetBytes, or BudgetDuration
+	Budget sources.FetchBudget
+}
+
+func (e *BudgetError) Error() string {
+	switch e.Limit {
+	case BudgetRequests:
+		return fmt.Sprintf("fetch budget of %d requests reached", e.Budget.MaxRequests)
+	case BudgetBytes:
+		return fmt.Sprintf("fetch budget of %s reached", sources.FormatByteSize(e.Budget.MaxBytes))
+	default:
+		return fmt.Sprintf("fetch budget of %s reached", e.Budget.MaxDuration)
+	}
+}
+
+// fetchBudget tracks what one fetch of a source has spent against its
+// budget. It is shared by the requests the fetch makes, which may run
+// concurrently. A nil fetchBudget allows everything.
+type fetchBudget struct {
+	limits   sources.FetchBudget
+	deadline time.Time // Zero without a duration limit
+	requests atomic.Int64
+	bytes    atomic.Int64
+}
+
+// newFetchBudget starts tracking a fetch against limits, returning nil if
+// there are none. The duration limit runs from now.
+func newFetchBudget(limits *sources.FetchBudget) *fetchBudget {
+	if limits == nil || limits.IsZero() {
+		return nil
+	}
+	b := &fetchBudget{limits: *limits}
+	if d, err := limits.Duration(); err == nil && d > 0 {
+		b.deadline = time.Now().Add(d)
+	}
+	return b
+}
+
+// spendRequest counts a request about to be sent, returning a *BudgetError
+// instead if the budget has run out. A request in flight when the duration
+// runs out is allowed to finish.
+func (b *fetchBudget) spendRequest() error {
+	if b == nil {
+		return nil
+	}
+	if !b.deadline.IsZero() && !time.Now().Before(b.deadline) {
+		return b.exceeded(BudgetDuration)
+	}
+	if b.limits.MaxBytes > 0 && b.bytes.Load() >= b.limits.MaxBytes {
+		return b.exceeded(BudgetBytes)
+	}
+	if b.limits.MaxRequests > 0 && b.requests.Add(1) > int64(b.limits.MaxRequests) {
+		return b.exceeded(BudgetRequests)
+	}
+	return nil
+}
+
+// limitBody returns body counting the bytes read from it, whose read past
+// the byte limit fails with a *BudgetError.
+func (b *fetchBudget) limitBody(body io.ReadCloser) io.ReadCloser {
+	if b == nil || body == nil {
+		return body
+	}
+	return &budgetBody{ReadCloser: body, budget: b}
+}
+
+// exceeded returns the error for reaching limit.
+func (b *fetchBudget) exceeded(limit string) error {
+	return &BudgetError{Limit: limit, Budget: b.limits}
+}
+
+// budgetBody is a response body whose reads are counted against a budget.
+type budgetBody struct {
+	io.ReadCloser
+	budget *fetchBudget
+}
+
+func (r *budgetBody) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	total := r.budget.bytes.Add(int64(n))
+	if limit := r.budget.limits.MaxBytes; limit > 0 && total > limit {
+		return n, r.budget.exceeded(BudgetBytes)
+	}
+	return n, err
+}
+
+// budgetReached returns the *BudgetError in err's chain, or nil if err
+// isn't one.
+func budgetReached(err error) *BudgetError {
+	var budgetErr *BudgetError
+	if errors.As(err, &budgetErr) {
+		return budgetErr
+	}
+	return nil
+}
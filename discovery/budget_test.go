@@ -0,0 +1,137 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pevans/newsfed/newsfeed"
+	"github.com/pevans/newsfed/sources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFetchBudget_Requests verifies that retries count against a request
+// budget, and that a request past it isn't sent
+func TestFetchBudget_Requests(t *testing.T) {
+	server, requests := newFlakyServer(t, 10, http.StatusServiceUnavailable, nil)
+	opts := RequestOptions{
+		Retry:  RetryPolicy{Attempts: 5, BaseDelay: time.Millisecond},
+		budget: newFetchBudget(&sources.FetchBudget{MaxRequests: 2}),
+	}
+
+	_, err := FetchFeedConditional(context.Background(), server.URL, nil, nil, opts)
+	budgetErr := budgetReached(err)
+	require.NotNil(t, budgetErr, "got %v", err)
+	assert.Equal(t, BudgetRequests, budgetErr.Limit)
+	assert.Equal(t, int32(2), requests.Load())
+	assert.EqualError(t, budgetErr, "fetch budget of 2 requests reached")
+}
+
+// TestFetchBudget_Bytes verifies that reading a response past a byte budget
+// fails, and that no request is sent once it is spent
+func TestFetchBudget_Bytes(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		_, _ = fmt.Fprintf(w, "<html><body><p>%s</p></body></html>", strings.Repeat("x", 4096))
+	}))
+	defer server.Close()
+
+	opts := RequestOptions{budget: newFetchBudget(&sources.FetchBudget{MaxBytes: 1024})}
+	_, err := FetchHTMLWithOptions(context.Background(), server.URL, opts)
+	budgetErr := budgetReached(err)
+	require.NotNil(t, budgetErr, "got %v", err)
+	assert.Equal(t, BudgetBytes, budgetErr.Limit)
+
+	_, err = FetchHTMLWithOptions(context.Background(), server.URL, opts)
+	assert.NotNil(t, budgetReached(err))
+	assert.Equal(t, int32(1), requests.Load())
+}
+
+// TestFetchBudget_Duration verifies that no request is started once a
+// duration budget has run out, and that a nil budget allows everything
+func TestFetchBudget_Duration(t *testing.T) {
+	budget := newFetchBudget(&sources.FetchBudget{MaxDuration: "20ms"})
+	require.NoError(t, budget.spendRequest())
+	time.Sleep(30 * time.Millisecond)
+	budgetErr := budgetReached(budget.spendRequest())
+	require.NotNil(t, budgetErr)
+	assert.Equal(t, BudgetDuration, budgetErr.Limit)
+
+	assert.Nil(t, newFetchBudget(&sources.FetchBudget{}))
+	assert.Nil(t, newFetchBudget(nil))
+	var unlimited *fetchBudget
+	assert.NoError(t, unlimited.spendRequest())
+}
+
+// TestSyncSources_FetchBudget verifies that a list-mode scrape stops at its
+// source's request budget, keeps the articles it scraped, counts as synced,
+// and is reported in the result and progress updates
+func TestSyncSources_FetchBudget(t *testing.T) {
+	var articleRequests atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/list", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, "<html><body>")
+		for i := range 10 {
+			_, _ = fmt.Fprintf(w, `<a class="post" href="/article/%d">Post %d</a>`, i, i)
+		}
+		_, _ = fmt.Fprint(w, "</body></html>")
+	})
+	mux.HandleFunc("/article/", func(w http.ResponseWriter, r *http.Request) {
+		articleRequests.Add(1)
+		_, _ = fmt.Fprintf(w, `<html><head><title>%s</title></head><body><h1>%s</h1><div class="content">Body</div></body></html>`, r.URL.Path, r.URL.Path)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	sourceStore := newTestSourceStore(t)
+	newsFeed := newsfeed.NewMemoryNewsFeed()
+
+	config := DefaultDiscoveryConfig()
+	config.RateLimitInterval = 0
+	service := NewDiscoveryService(sourceStore, newsFeed, config)
+
+	now := time.Now()
+	source, err := sourceStore.CreateSource("website", server.URL+"/list", "Test Site", &ScraperConfig{
+		DiscoveryMode: "list",
+		ListConfig:    &ListConfig{ArticleSelector: "a.post", MaxPages: 1},
+		ArticleConfig: ArticleConfig{TitleSelector: "h1", ContentSelector: ".content"},
+	}, &now)
+	require.NoError(t, err)
+	require.NoError(t, sourceStore.UpdateSource(source.SourceID, sources.SourceUpdate{
+		FetchBudget: &sources.FetchBudget{MaxRequests: 4},
+	}))
+
+	progressCh := make(chan SourceProgress, 3)
+	result, err := service.SyncSources(context.Background(), SyncOptions{SourceID: &source.SourceID}, progressCh)
+	require.NoError(t, err)
+
+	// The list page and three articles fit in the budget
+	assert.Equal(t, int32(3), articleRequests.Load())
+	assert.Equal(t, 3, result.ItemsDiscovered)
+	assert.Equal(t, 1, result.SourcesSynced)
+	assert.Equal(t, 0, result.SourcesFailed)
+	require.Len(t, result.BudgetsReached, 1)
+	assert.Equal(t, source.SourceID, result.BudgetsReached[0].Source.SourceID)
+	assert.ErrorContains(t, result.BudgetsReached[0].Error, "4 requests")
+
+	var done SourceProgress
+	for p := range progressCh {
+		if p.Status == ProgressDone {
+			done = p
+		}
+	}
+	require.NotNil(t, done.Budget)
+	assert.Equal(t, BudgetRequests, done.Budget.Limit)
+
+	updated, err := sourceStore.GetSource(source.SourceID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, updated.FetchErrorCount)
+	assert.NotNil(t, updated.LastFetchedAt)
+}
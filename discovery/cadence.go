@@ -0,0 +1,78 @@
+package discovery
+
+import (
+	"log"
+	"slices"
+	"time"
+
+	"github.com/pevans/newsfed/newsfeed"
+	"github.com/pevans/newsfed/sources"
+)
+
+// cadenceSampleSize is how many of a feed's most recent dated items its
+// posting cadence is measured over.
+const cadenceSampleSize = 20
+
+// postingCadence estimates how often a feed posts from the items it carries:
+// the time from the oldest of its most recent dated items until now, divided
+// by the number of those items. Counting up to now rather than to the newest
+// item lets a feed that has gone quiet drift toward longer intervals. Items
+// without a published date of their own are ignored. It returns false if no
+// item has one.
+func postingCadence(items []newsfeed.NewsItem, now time.Time) (time.Duration, bool) {
+	var dates []time.Time
+	for _, item := range items {
+		if _, ok := item.MetadataValue(publishedRawKey); !ok || item.PublishedAt.After(now) {
+			continue
+		}
+		dates = append(dates, item.PublishedAt)
+	}
+	if len(dates) == 0 {
+		return 0, false
+	}
+
+	slices.SortFunc(dates, func(a, b time.Time) int { return b.Compare(a) })
+	if len(dates) > cadenceSampleSize {
+		dates = dates[:cadenceSampleSize]
+	}
+	cadence := now.Sub(dates[len(dates)-1]) / time.Duration(len(dates))
+	return cadence.Round(time.Minute), true
+}
+
+// recordPostingCadence stores a feed's posting cadence, as measured from the
+// items in its latest fetch, if it has changed. Sources whose items carry no
+// dates keep the cadence they had.
+func (ds *DiscoveryService) recordPostingCadence(source sources.Source, items []newsfeed.NewsItem) {
+	cadence, ok := postingCadence(items, time.Now())
+	if !ok {
+		return
+	}
+	value := cadence.String()
+	if source.PostingCadence != nil && *source.PostingCadence == value {
+		return
+	}
+	if err := ds.sourceStore.UpdateSource(source.SourceID, sources.SourceUpdate{PostingCadence: &value}); err != nil {
+		log.Printf("WARN: Failed to save posting cadence for %s: %v", source.URL, err)
+	}
+}
+
+// adaptiveInterval returns the polling interval adaptive polling gives
+// source: its posting cadence, kept within the configured bounds. It returns
+// false if the source has no cadence yet.
+func (c *DiscoveryConfig) adaptiveInterval(source sources.Source) (time.Duration, bool) {
+	if source.PostingCadence == nil {
+		return 0, false
+	}
+	cadence, err := time.ParseDuration(*source.PostingCadence)
+	if err != nil {
+		return 0, false
+	}
+	minInterval, maxInterval := c.AdaptiveMinInterval, c.AdaptiveMaxInterval
+	if minInterval <= 0 {
+		minInterval = 15 * time.Minute
+	}
+	if maxInterval <= 0 {
+		maxInterval = 24 * time.Hour
+	}
+	return min(max(cadence, minInterval), maxInterval), true
+}
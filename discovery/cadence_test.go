@@ -0,0 +1,106 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pevans/newsfed/newsfeed"
+	"github.com/pevans/newsfed/sources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPostingCadence verifies that cadence is measured from the oldest of
+// the most recent dated items until now, ignoring undated and future items
+func TestPostingCadence(t *testing.T) {
+	now := time.Date(2024, time.March, 10, 12, 0, 0, 0, time.UTC)
+	dated := func(age time.Duration) newsfeed.NewsItem {
+		item := newsfeed.NewsItem{PublishedAt: now.Add(-age)}
+		setPublishedRaw(&item, "dated")
+		return item
+	}
+
+	_, ok := postingCadence([]newsfeed.NewsItem{{PublishedAt: now.Add(-time.Hour)}}, now)
+	assert.False(t, ok, "undated items give no cadence")
+
+	cadence, ok := postingCadence([]newsfeed.NewsItem{
+		dated(2 * time.Hour), dated(4 * time.Hour), dated(6 * time.Hour),
+		{PublishedAt: now.Add(-100 * time.Hour)},
+		dated(-time.Hour),
+	}, now)
+	require.True(t, ok)
+	assert.Equal(t, 2*time.Hour, cadence)
+
+	var items []newsfeed.NewsItem
+	for i := 1; i <= 30; i++ {
+		items = append(items, dated(time.Duration(i)*time.Hour))
+	}
+	cadence, ok = postingCadence(items, now)
+	require.True(t, ok)
+	assert.Equal(t, time.Hour, cadence, "only the most recent items count")
+}
+
+// TestDiscoveryService_getPollingInterval_Adaptive verifies that adaptive
+// polling follows a source's posting cadence within its bounds, and that an
+// explicit polling interval still wins
+func TestDiscoveryService_getPollingInterval_Adaptive(t *testing.T) {
+	config := DefaultDiscoveryConfig()
+	config.AdaptivePolling = true
+	config.AdaptiveMinInterval = 30 * time.Minute
+	config.AdaptiveMaxInterval = 12 * time.Hour
+	service := NewDiscoveryService(nil, nil, config)
+
+	tests := []struct {
+		name     string
+		source   sources.Source
+		expected time.Duration
+	}{
+		{"no cadence -- uses default", sources.Source{}, time.Hour},
+		{"cadence within bounds", sources.Source{PostingCadence: strPtr("3h0m0s")}, 3 * time.Hour},
+		{"frequent poster -- clamped to minimum", sources.Source{PostingCadence: strPtr("5m0s")}, 30 * time.Minute},
+		{"rare poster -- clamped to maximum", sources.Source{PostingCadence: strPtr("72h0m0s")}, 12 * time.Hour},
+		{"explicit interval", sources.Source{PollingInterval: strPtr("2h"), PostingCadence: strPtr("3h0m0s")}, 2 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, service.getPollingInterval(tt.source))
+		})
+	}
+
+	config.AdaptivePolling = false
+	assert.Equal(t, time.Hour, service.getPollingInterval(sources.Source{PostingCadence: strPtr("3h0m0s")}))
+}
+
+// TestDiscoveryService_fetchSource_PostingCadence verifies that a feed's
+// posting cadence is stored after it is fetched
+func TestDiscoveryService_fetchSource_PostingCadence(t *testing.T) {
+	var items strings.Builder
+	for i := 1; i <= 4; i++ {
+		published := time.Now().Add(-time.Duration(i) * 6 * time.Hour).UTC().Format(time.RFC1123Z)
+		fmt.Fprintf(&items, `<item><title>Item %d</title><link>http://example.com/%d</link><pubDate>%s</pubDate></item>`, i, i, published)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, `<?xml version="1.0"?><rss version="2.0"><channel><title>Test</title>%s</channel></rss>`, items.String())
+	}))
+	defer server.Close()
+
+	sourceStore := newTestSourceStore(t)
+	newsFeed := newsfeed.NewMemoryNewsFeed()
+	service := NewDiscoveryService(sourceStore, newsFeed, nil)
+
+	source, err := sourceStore.CreateSource("rss", server.URL, "Test Feed", nil, nil)
+	require.NoError(t, err)
+	_, err = service.fetchSource(context.Background(), *source, nil)
+	require.NoError(t, err)
+
+	source, err = sourceStore.GetSource(source.SourceID)
+	require.NoError(t, err)
+	require.NotNil(t, source.PostingCadence)
+	assert.Equal(t, "6h0m0s", *source.PostingCadence)
+}
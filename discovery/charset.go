@@ -0,0 +1,81 @@
+package discovery
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html/charset"
+)
+
+// htmlReader returns body decoded to UTF-8. The encoding is taken from a
+// byte order mark, the charset in contentType, or a <meta> tag near the
+// start of the page, in that order, as browsers do. Pages that declare none
+// are read as UTF-8 if they are valid UTF-8 and as windows-1252 otherwise.
+func htmlReader(body io.Reader, contentType string) (io.Reader, error) {
+	r, err := charset.NewReader(body, contentType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode HTML: %w", err)
+	}
+	return r, nil
+}
+
+// xmlEncodingDecl matches the encoding given in an XML declaration.
+var xmlEncodingDecl = regexp.MustCompile(`^(\s*<\?xml[^>]*?encoding\s*=\s*["'])[^"']*(["'])`)
+
+// xmlDeclMaxLen is how far into a feed its XML declaration is looked for.
+const xmlDeclMaxLen = 512
+
+// feedReader returns a feed body converted to UTF-8 as it is read, when its
+// Content-Type names another charset. The HTTP charset takes precedence over
+// the XML declaration (RFC 7303 section 3.2), so the declaration is
+// rewritten to match the converted body. Bodies without a charset in their
+// Content-Type are returned unchanged; the feed parser honors their XML
+// declaration.
+func feedReader(body io.Reader, contentType string) (io.Reader, error) {
+	label := contentTypeCharset(contentType)
+	if label == "" || isUTF8Label(label) {
+		return body, nil
+	}
+
+	r, err := charset.NewReaderLabel(label, body)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported feed charset %q: %w", label, err)
+	}
+
+	// The declaration comes first, so only the start of the body needs
+	// rewriting
+	head := make([]byte, xmlDeclMaxLen)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, fmt.Errorf("failed to decode feed from %s: %w", label, err)
+	}
+	head = xmlEncodingDecl.ReplaceAll(head[:n], []byte("${1}UTF-8${2}"))
+	return io.MultiReader(bytes.NewReader(head), r), nil
+}
+
+// contentTypeCharset returns the charset parameter of a Content-Type header,
+// or "" if there is none.
+func contentTypeCharset(contentType string) string {
+	if contentType == "" {
+		return ""
+	}
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(params["charset"])
+}
+
+// isUTF8Label reports whether label names UTF-8 or its ASCII subset, which
+// need no conversion.
+func isUTF8Label(label string) bool {
+	switch strings.ToLower(label) {
+	case "utf-8", "utf8", "us-ascii", "ascii":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+package discovery
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// "Привет" in windows-1251 and "Łódź" in ISO-8859-2
+const (
+	privetWindows1251 = "\xcf\xf0\xe8\xe2\xe5\xf2"
+	lodzISO88592      = "\xa3\xf3d\xbc"
+)
+
+// Test helper: serve body with the given Content-Type
+func serveBytes(t *testing.T, contentType, body string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// TestFetchFeedConditional_Charset verifies that feeds in other charsets are
+// decoded whether the charset is given by the Content-Type header, which
+// wins over a conflicting XML declaration, or by the XML declaration alone
+func TestFetchFeedConditional_Charset(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        string
+	}{
+		{
+			name:        "header charset without declaration",
+			contentType: "application/rss+xml; charset=windows-1251",
+			body:        `<rss version="2.0"><channel><title>` + privetWindows1251 + `</title><item><title>` + privetWindows1251 + `</title><link>http://example.com/1</link></item></channel></rss>`,
+			want:        "Привет",
+		},
+		{
+			name:        "header charset overrides declaration",
+			contentType: "text/xml; charset=windows-1251",
+			body:        `<?xml version="1.0" encoding="UTF-8"?><rss version="2.0"><channel><title>` + privetWindows1251 + `</title><item><title>` + privetWindows1251 + `</title><link>http://example.com/1</link></item></channel></rss>`,
+			want:        "Привет",
+		},
+		{
+			name:        "declaration only",
+			contentType: "application/rss+xml",
+			body:        `<?xml version="1.0" encoding="ISO-8859-2"?><rss version="2.0"><channel><title>` + lodzISO88592 + `</title><item><title>` + lodzISO88592 + `</title><link>http://example.com/1</link></item></channel></rss>`,
+			want:        "Łódź",
+		},
+		{
+			name:        "utf-8",
+			contentType: "application/rss+xml; charset=utf-8",
+			body:        `<?xml version="1.0" encoding="UTF-8"?><rss version="2.0"><channel><title>Łódź</title><item><title>Łódź</title><link>http://example.com/1</link></item></channel></rss>`,
+			want:        "Łódź",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := serveBytes(t, tt.contentType, tt.body)
+			feed, err := FetchFeed(context.Background(), server.URL)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, feed.Title)
+			require.Len(t, feed.Items, 1)
+			assert.Equal(t, tt.want, feed.Items[0].Title)
+		})
+	}
+}
+
+// TestFetchHTML_Charset verifies that pages in other charsets are decoded
+// from the Content-Type header or a meta tag
+func TestFetchHTML_Charset(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        string
+	}{
+		{
+			name:        "meta charset",
+			contentType: "text/html",
+			body:        `<html><head><meta charset="windows-1251"><title>` + privetWindows1251 + `</title></head><body></body></html>`,
+			want:        "Привет",
+		},
+		{
+			name:        "meta http-equiv",
+			contentType: "text/html",
+			body:        `<html><head><meta http-equiv="Content-Type" content="text/html; charset=iso-8859-2"><title>` + lodzISO88592 + `</title></head></html>`,
+			want:        "Łódź",
+		},
+		{
+			name:        "header charset",
+			contentType: "text/html; charset=iso-8859-2",
+			body:        `<html><head><title>` + lodzISO88592 + `</title></head></html>`,
+			want:        "Łódź",
+		},
+		{
+			name:        "undeclared utf-8",
+			contentType: "text/html",
+			body:        `<html><head><title>Привет</title></head></html>`,
+			want:        "Привет",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := serveBytes(t, tt.contentType, tt.body)
+			doc, err := FetchHTML(context.Background(), server.URL)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, doc.Find("title").Text())
+		})
+	}
+}
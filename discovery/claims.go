@@ -0,0 +1,125 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pevans/newsfed/sources"
+)
+
+// defaultWorkerID identifies this process in source claims by host name and
+// process ID.
+func defaultWorkerID() string {
+	return fmt.Sprintf("%s-%d", hostName(), os.Getpid())
+}
+
+// hostName returns the host name, or "newsfed" if it isn't known.
+func hostName() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		return "newsfed"
+	}
+	return host
+}
+
+// WorkerID returns the name under which this service claims sources.
+func (ds *DiscoveryService) WorkerID() string {
+	return ds.workerID
+}
+
+// leaseSource claims source in the metadata store for this service and keeps
+// the claim alive, renewing it a few times per lease, until the returned
+// release function is called. It returns the source as stored when it was
+// claimed. The returned context is canceled if the claim is lost, so that
+// the fetch stops before another service fetches the source as well. It
+// returns sources.ErrSourceClaimed if another service holds the source.
+func (ds *DiscoveryService) leaseSource(ctx context.Context, source sources.Source) (context.Context, *sources.Source, func(), error) {
+	lease := ds.currentConfig().claimLease()
+
+	claimed, err := ds.sourceStore.ClaimSource(source.SourceID, ds.workerID, lease)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	leaseCtx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ds.renewLease(leaseCtx, cancel, source, lease)
+	}()
+
+	release := func() {
+		cancel()
+		wg.Wait()
+		if err := ds.sourceStore.ReleaseClaim(source.SourceID, ds.workerID); err != nil {
+			log.Printf("WARN: Failed to release claim on %s (%s): %v", source.Name, source.URL, err)
+		}
+	}
+	return leaseCtx, claimed, release, nil
+}
+
+// claimForSync claims source for a manual sync, both in this service and in
+// the metadata store, as the run loop does before fetching it. It returns
+// sources.ErrSourceClaimed if this or another service is already fetching
+// the source.
+func (ds *DiscoveryService) claimForSync(ctx context.Context, source sources.Source) (context.Context, *sources.Source, func(), error) {
+	if !ds.claimSource(source.SourceID) {
+		return nil, nil, nil, sources.ErrSourceClaimed
+	}
+	leaseCtx, claimed, releaseLease, err := ds.leaseSource(ctx, source)
+	if err != nil {
+		ds.releaseSource(source.SourceID)
+		return nil, nil, nil, err
+	}
+	release := func() {
+		releaseLease()
+		ds.releaseSource(source.SourceID)
+	}
+	return leaseCtx, claimed, release, nil
+}
+
+// renewLease renews this service's claim on source every third of lease
+// until ctx is done. If the claim has been lost it logs a warning and calls
+// cancel. Other renewal failures are retried, since the claim remains valid
+// until it expires.
+func (ds *DiscoveryService) renewLease(ctx context.Context, cancel context.CancelFunc, source sources.Source, lease time.Duration) {
+	ticker := time.NewTicker(lease / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		err := ds.sourceStore.RenewClaim(source.SourceID, ds.workerID, lease)
+		if errors.Is(err, sources.ErrClaimLost) {
+			log.Printf("WARN: Lost claim on %s (%s); stopping its fetch", source.Name, source.URL)
+			cancel()
+			return
+		}
+		if err != nil {
+			log.Printf("WARN: Failed to renew claim on %s (%s): %v", source.Name, source.URL, err)
+		}
+	}
+}
+
+// reclaimExpired clears claims whose lease has run out, such as those left
+// by a service that stopped without releasing them.
+func (ds *DiscoveryService) reclaimExpired() {
+	n, err := ds.sourceStore.ReclaimExpired(time.Now())
+	if err != nil {
+		log.Printf("WARN: Failed to reclaim expired source claims: %v", err)
+		return
+	}
+	if n > 0 {
+		log.Printf("INFO: Reclaimed %d expired source claims", n)
+	}
+}
@@ -0,0 +1,148 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pevans/newsfed/newsfeed"
+	"github.com/pevans/newsfed/sources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test helper: create a discovery service with the given worker ID and
+// claim lease over temporary stores
+func newClaimTestService(t *testing.T, workerID string, lease time.Duration) (*DiscoveryService, *sources.SourceStore) {
+	tempDir := t.TempDir()
+	sourceStore, err := sources.NewSourceStore(tempDir + "/metadata.db")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = sourceStore.Close() })
+
+	newsFeed, err := newsfeed.NewNewsFeed(tempDir + "/.news")
+	require.NoError(t, err)
+
+	config := DefaultDiscoveryConfig()
+	config.WorkerID = workerID
+	config.ClaimLease = lease
+	config.RateLimitInterval = 0
+	return NewDiscoveryService(sourceStore, newsFeed, config), sourceStore
+}
+
+// TestFetchSources_SkipsClaimedSource verifies that a source claimed by
+// another worker is not fetched, and that it is fetched and its claim
+// released once the other worker lets go of it
+func TestFetchSources_SkipsClaimedSource(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		_, _ = fmt.Fprint(w, `<?xml version="1.0"?>
+<rss version="2.0"><channel><title>Test</title>
+<item><title>One</title><link>http://example.com/1</link></item>
+</channel></rss>`)
+	}))
+	defer server.Close()
+
+	service, sourceStore := newClaimTestService(t, "worker-a", time.Minute)
+	now := time.Now()
+	source, err := sourceStore.CreateSource("rss", server.URL, "Feed", nil, &now)
+	require.NoError(t, err)
+
+	_, err = sourceStore.ClaimSource(source.SourceID, "worker-b", time.Minute)
+	require.NoError(t, err)
+
+	_, err = service.fetchSources(context.Background())
+	require.NoError(t, err)
+	service.wg.Wait()
+	assert.Equal(t, int32(0), requests.Load(), "claimed source should not be fetched")
+
+	require.NoError(t, sourceStore.ReleaseClaim(source.SourceID, "worker-b"))
+	_, err = service.fetchSources(context.Background())
+	require.NoError(t, err)
+	service.wg.Wait()
+	assert.Equal(t, int32(1), requests.Load())
+
+	fetched, err := sourceStore.GetSource(source.SourceID)
+	require.NoError(t, err)
+	assert.NotNil(t, fetched.LastFetchedAt)
+	assert.Nil(t, fetched.ClaimedBy, "claim should be released after the fetch")
+}
+
+// TestSyncSources_SkipsClaimedSource verifies that a manual sync doesn't
+// fetch a source another worker has claimed, reporting it as failed, and
+// that it claims and releases the source itself once it is free
+func TestSyncSources_SkipsClaimedSource(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		_, _ = fmt.Fprint(w, `<?xml version="1.0"?>
+<rss version="2.0"><channel><title>Test</title>
+<item><title>One</title><link>http://example.com/1</link></item>
+</channel></rss>`)
+	}))
+	defer server.Close()
+
+	service, sourceStore := newClaimTestService(t, "worker-a", time.Minute)
+	now := time.Now()
+	source, err := sourceStore.CreateSource("rss", server.URL, "Feed", nil, &now)
+	require.NoError(t, err)
+
+	_, err = sourceStore.ClaimSource(source.SourceID, "worker-b", time.Minute)
+	require.NoError(t, err)
+
+	result, err := service.SyncSources(context.Background(), SyncOptions{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int32(0), requests.Load(), "claimed source should not be fetched")
+	assert.Equal(t, 1, result.SourcesFailed)
+	require.Len(t, result.Errors, 1)
+	assert.ErrorIs(t, result.Errors[0].Error, sources.ErrSourceClaimed)
+
+	require.NoError(t, sourceStore.ReleaseClaim(source.SourceID, "worker-b"))
+	result, err = service.SyncSources(context.Background(), SyncOptions{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), requests.Load())
+	assert.Equal(t, 1, result.SourcesSynced)
+
+	fetched, err := sourceStore.GetSource(source.SourceID)
+	require.NoError(t, err)
+	assert.NotNil(t, fetched.LastFetchedAt)
+	assert.Nil(t, fetched.ClaimedBy, "claim should be released after the sync")
+}
+
+// TestLeaseSource_RenewsAndCancelsOnLoss verifies that a held lease is
+// renewed past its original expiry, and that losing it cancels the fetch
+// context without releasing the new holder's claim
+func TestLeaseSource_RenewsAndCancelsOnLoss(t *testing.T) {
+	service, sourceStore := newClaimTestService(t, "worker-a", 150*time.Millisecond)
+	source, err := sourceStore.CreateSource("rss", "http://example.com/feed", "Feed", nil, nil)
+	require.NoError(t, err)
+
+	ctx, claimed, release, err := service.leaseSource(context.Background(), *source)
+	require.NoError(t, err)
+	assert.Equal(t, "worker-a", *claimed.ClaimedBy)
+
+	time.Sleep(400 * time.Millisecond)
+	_, err = sourceStore.ClaimSource(source.SourceID, "worker-b", time.Minute)
+	assert.ErrorIs(t, err, sources.ErrSourceClaimed, "lease should have been renewed")
+	assert.NoError(t, ctx.Err())
+
+	// Another worker takes the source over
+	require.NoError(t, sourceStore.ReleaseClaim(source.SourceID, "worker-a"))
+	_, err = sourceStore.ClaimSource(source.SourceID, "worker-b", time.Minute)
+	require.NoError(t, err)
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("fetch context should be canceled when the claim is lost")
+	}
+
+	release()
+	current, err := sourceStore.GetSource(source.SourceID)
+	require.NoError(t, err)
+	assert.Equal(t, "worker-b", sources.ClaimHolder(*current, time.Now()))
+}
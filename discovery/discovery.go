@@ -2,48 +2,111 @@ package discovery
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
 	"log"
+	"math"
+	"net"
 	"net/url"
-	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/google/uuid"
 	"github.com/pevans/newsfed/newsfeed"
+	"github.com/pevans/newsfed/secrets"
 	"github.com/pevans/newsfed/sources"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // DiscoveryService is a background service that automatically discovers and
 // ingests news items from configured sources. Implements Spec 7.
 type DiscoveryService struct {
-	sourceStore     *sources.SourceStore
-	newsFeed        *newsfeed.NewsFeed
-	config          *DiscoveryConfig
-	stopChan        chan struct{}
-	wg              sync.WaitGroup
-	sourceSemaphore chan struct{}
-	rateLimiter     *domainRateLimiter
-	metrics         *DiscoveryMetrics
+	sourceStore *sources.SourceStore
+	newsFeed    *newsfeed.NewsFeed
+	configMu    sync.RWMutex // Guards config
+	config      *DiscoveryConfig
+	stopChan    chan struct{}
+	reloadChan  chan struct{}
+	wg          sync.WaitGroup
+	fetchQueue  *fetchQueue // Admits source fetches and schedules their requests
+	rateLimiter *domainRateLimiter
+	metrics     *DiscoveryMetrics
+	lastTick    atomic.Int64 // UnixNano of the loop's last wakeup; 0 when not running
+	workerID    string       // Identifies this service in source claims
+
+	inFlightMu sync.Mutex
+	inFlight   map[uuid.UUID]struct{} // Sources with a fetch in progress
+
+	enrichersMu sync.RWMutex
+	enrichers   []Enricher // Run on each new item before it is added
+
+	syncJobs syncJobs // Background syncs started with StartSyncJob
+
+	rendererMu sync.Mutex
+	pageRender *Renderer // Created by the first fetch of a source that renders pages
+
+	journalMu sync.Mutex // Serializes writes to the journal
+
+	hookMu      sync.Mutex
+	hookQueue   []hookRun // Hooks waiting to run, in order
+	hookRunning bool      // Whether hookQueue is being drained
+	hookWG      sync.WaitGroup
+
+	publisherMu   sync.Mutex
+	itemPublisher *itemPublisher // Started by the first item added, if PublishURL is set
+
+	secrets atomic.Pointer[secrets.Store] // Resolves secret references in source configs
+
+	savePrivate bool // Lets SaveURL and DiscoverSourcesHandler fetch private addresses, for tests
 }
 
 // DiscoveryMetrics tracks service metrics per Spec 7 section 10.2.
 type DiscoveryMetrics struct {
 	mu                   sync.Mutex
+	since                time.Time       // When counting began
 	SourcesTotal         int             // Total enabled sources
 	SourcesFetchedTotal  int             // Counter of successful fetches
 	SourcesFailedTotal   int             // Counter of failed fetches
 	ItemsDiscoveredTotal int             // Counter of new items added
 	FetchDurations       []time.Duration // Recent fetch durations for histogram
 	maxDurations         int             // Max durations to keep
+
+	// Cumulative fetch duration histogram, bucketed by
+	// fetchDurationBuckets, for Prometheus exposition
+	durationBucketCounts []int
+	durationCount        int
+	durationSum          time.Duration
+
+	// Cumulative discovery lag histogram, bucketed by discoveryLagBuckets,
+	// and the lag of each source's items
+	lagBucketCounts []int
+	lagCount        int
+	lagSum          time.Duration
+	sourceLags      map[uuid.UUID]*sourceLag
+}
+
+// sourceLag totals the discovery lag of one source's items.
+type sourceLag struct {
+	name       string
+	count      int
+	sum        time.Duration
+	fetchCount int // Items since the source's last sync attempt was recorded
+	fetchSum   time.Duration
 }
 
 func newDiscoveryMetrics() *DiscoveryMetrics {
 	return &DiscoveryMetrics{
-		FetchDurations: make([]time.Duration, 0),
-		maxDurations:   1000, // Keep last 1000 durations
+		since:                time.Now(),
+		FetchDurations:       make([]time.Duration, 0),
+		maxDurations:         1000, // Keep last 1000 durations
+		durationBucketCounts: make([]int, len(fetchDurationBuckets)),
+		lagBucketCounts:      make([]int, len(discoveryLagBuckets)),
+		sourceLags:           map[uuid.UUID]*sourceLag{},
 	}
 }
 
@@ -67,6 +130,46 @@ func (m *DiscoveryMetrics) recordItemsDiscovered(count int) {
 	m.ItemsDiscoveredTotal += count
 }
 
+// recordDiscoveryLag records the time between an item's publication and
+// its discovery by source.
+func (m *DiscoveryMetrics) recordDiscoveryLag(source sources.Source, lag time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lagCount++
+	m.lagSum += lag
+	for i, bound := range discoveryLagBuckets {
+		if lag.Seconds() <= bound {
+			m.lagBucketCounts[i]++
+		}
+	}
+
+	totals, ok := m.sourceLags[source.SourceID]
+	if !ok {
+		totals = &sourceLag{}
+		m.sourceLags[source.SourceID] = totals
+	}
+	totals.name = source.Name
+	totals.count++
+	totals.sum += lag
+	totals.fetchCount++
+	totals.fetchSum += lag
+}
+
+// takeFetchLag returns the average discovery lag of the items a source has
+// discovered since it was last called for the source, or nil if there were
+// none.
+func (m *DiscoveryMetrics) takeFetchLag(sourceID uuid.UUID) *time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	totals, ok := m.sourceLags[sourceID]
+	if !ok || totals.fetchCount == 0 {
+		return nil
+	}
+	lag := totals.fetchSum / time.Duration(totals.fetchCount)
+	totals.fetchCount, totals.fetchSum = 0, 0
+	return &lag
+}
+
 func (m *DiscoveryMetrics) updateSourcesTotal(count int) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -74,6 +177,14 @@ func (m *DiscoveryMetrics) updateSourcesTotal(count int) {
 }
 
 func (m *DiscoveryMetrics) recordDuration(duration time.Duration) {
+	m.durationCount++
+	m.durationSum += duration
+	for i, bound := range fetchDurationBuckets {
+		if duration.Seconds() <= bound {
+			m.durationBucketCounts[i]++
+		}
+	}
+
 	m.FetchDurations = append(m.FetchDurations, duration)
 	// Keep only the most recent durations
 	if len(m.FetchDurations) > m.maxDurations {
@@ -88,59 +199,155 @@ func (m *DiscoveryMetrics) GetMetrics() (sourcesTotal, sourcesFetched, sourcesFa
 	return m.SourcesTotal, m.SourcesFetchedTotal, m.SourcesFailedTotal, m.ItemsDiscoveredTotal
 }
 
-// domainRateLimiter implements per-domain rate limiting per Spec 7 section
-// 8.2.
-type domainRateLimiter struct {
-	mu              sync.Mutex
-	lastRequestTime map[string]time.Time
-	minInterval     time.Duration
-}
-
-func newDomainRateLimiter(minInterval time.Duration) *domainRateLimiter {
-	return &domainRateLimiter{
-		lastRequestTime: make(map[string]time.Time),
-		minInterval:     minInterval,
-	}
-}
-
-// wait blocks until it's safe to make a request to the given domain.
-func (rl *domainRateLimiter) wait(domain string) {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
-
-	if lastTime, ok := rl.lastRequestTime[domain]; ok {
-		elapsed := time.Since(lastTime)
-		if elapsed < rl.minInterval {
-			time.Sleep(rl.minInterval - elapsed)
-		}
-	}
-
-	rl.lastRequestTime[domain] = time.Now()
-}
-
 // DiscoveryConfig holds configuration for the discovery service.
 type DiscoveryConfig struct {
 	// Global polling interval for sources without explicit interval
 	PollInterval time.Duration
-	// Maximum number of sources to fetch in parallel
+	// Poll feeds without an explicit interval as often as they post,
+	// within AdaptiveMinInterval and AdaptiveMaxInterval, rather than every
+	// PollInterval
+	AdaptivePolling bool
+	// Bounds on adaptive polling intervals; zero means fifteen minutes and
+	// twenty-four hours
+	AdaptiveMinInterval time.Duration
+	AdaptiveMaxInterval time.Duration
+	// Maximum number of sources to fetch in parallel, and of their
+	// requests running at once; zero means five. Requests take turns across
+	// domains and sources in the service's fetch queue.
 	Concurrency int
 	// Timeout per source fetch
 	FetchTimeout time.Duration
 	// Number of consecutive failures before auto-disabling a source
 	DisableThreshold int
+	// Upper bound on the delay applied after consecutive transient failures
+	MaxBackoff time.Duration
 	// Minimum interval between requests to the same domain
 	RateLimitInterval time.Duration
+	// Upper bound on a random delay added to RateLimitInterval, so requests
+	// to a domain don't arrive on a fixed beat
+	RateLimitJitter time.Duration
+	// Maximum number of sources on the same domain fetched at once; zero
+	// means one
+	MaxPerDomain int
+	// Age after which unpinned items are pruned; zero disables pruning
+	RetentionPeriod time.Duration
+	// Longest time the scheduler waits before checking for due sources. It
+	// normally wakes when the next source is due; this bounds the wait so
+	// that sources added or changed by other processes are noticed. Zero
+	// means five minutes.
+	CheckInterval time.Duration
+	// Interval between metrics log lines; zero means fifteen minutes
+	MetricsInterval time.Duration
+	// Identifies this process when it claims sources, so that several
+	// services sharing a metadata store don't fetch the same source. Empty
+	// means the host name and process ID. Changes take effect on restart.
+	WorkerID string
+	// How long a claim on a source lasts without renewal; claims are
+	// renewed while a fetch runs. Zero means five minutes.
+	ClaimLease time.Duration
+	// Proxy for sources that don't set their own, as an http, https,
+	// socks5, or socks5h URL. Empty means the proxy environment variables
+	// apply.
+	ProxyURL string
+	// Most article pages of one list-mode source scraped at once; zero
+	// means one. Requests still wait for the domain's rate limit.
+	ArticleConcurrency int
+	// Keep the sanitized HTML of feed summaries in each item's SummaryHTML,
+	// in addition to the plain-text Summary
+	KeepSummaryHTML bool
+	// Number of times a request is retried within one fetch after a
+	// network error, 429, or 5xx response; zero disables retries
+	RetryAttempts int
+	// Delay before the first retry, doubling for each one after
+	RetryBaseDelay time.Duration
+	// Upper bound on a retry delay, including one a server asks for with
+	// Retry-After. Zero means thirty seconds.
+	RetryMaxDelay time.Duration
+	// Chrome or Chromium executable for sources that render pages; empty
+	// looks for one on the PATH. Changes to the render settings take
+	// effect on restart.
+	BrowserPath string
+	// Most pages rendered at once; zero means two
+	RenderTabs int
+	// Longest a page may take to render, including waiting for its
+	// content to appear; zero means thirty seconds
+	RenderTimeout time.Duration
+	// Age past which items are skipped when they are found, for sources
+	// whose ingest rules don't set max_age; zero means no limit
+	MaxItemAge time.Duration
+	// File that what discovery does with each item it finds is appended
+	// to, as JSON lines; empty turns the journal off
+	JournalPath string
+	// Age after which journal entries are pruned; zero keeps them
+	JournalRetention time.Duration
+	// Largest feed or page body read, in bytes; larger ones fail to fetch.
+	// Zero means DefaultMaxResponseSize.
+	MaxResponseSize int64
+	// External commands run when items are added, sources are disabled,
+	// and syncs complete
+	Hooks []Hook
+	// NATS server that items added to the feed are published to, or a
+	// reference to a secret holding its URL; empty publishes nothing.
+	// Changes to the publish settings take effect on restart.
+	PublishURL string
+	// Subject items are published to; empty means DefaultPublishSubject
+	PublishSubject string
+	// Most items kept while the server can't be reached; zero means 1000
+	PublishBuffer int
+}
+
+// checkInterval returns CheckInterval, or its default if unset.
+func (c *DiscoveryConfig) checkInterval() time.Duration {
+	if c.CheckInterval <= 0 {
+		return 5 * time.Minute
+	}
+	return c.CheckInterval
+}
+
+// articleConcurrency returns ArticleConcurrency, or one if unset.
+func (c *DiscoveryConfig) articleConcurrency() int {
+	return max(c.ArticleConcurrency, 1)
+}
+
+// metricsInterval returns MetricsInterval, or its default if unset.
+func (c *DiscoveryConfig) metricsInterval() time.Duration {
+	if c.MetricsInterval <= 0 {
+		return 15 * time.Minute
+	}
+	return c.MetricsInterval
+}
+
+// claimLease returns ClaimLease, or its default if unset.
+func (c *DiscoveryConfig) claimLease() time.Duration {
+	if c.ClaimLease <= 0 {
+		return 5 * time.Minute
+	}
+	return c.ClaimLease
 }
 
 // DefaultDiscoveryConfig returns the default configuration per Spec 7 section
 // 9.1.2.
 func DefaultDiscoveryConfig() *DiscoveryConfig {
 	return &DiscoveryConfig{
-		PollInterval:      1 * time.Hour,
-		Concurrency:       5,
-		FetchTimeout:      60 * time.Second,
-		DisableThreshold:  10,
-		RateLimitInterval: 1 * time.Second,
+		PollInterval:        1 * time.Hour,
+		AdaptiveMinInterval: 15 * time.Minute,
+		AdaptiveMaxInterval: 24 * time.Hour,
+		Concurrency:         5,
+		ArticleConcurrency:  4,
+		FetchTimeout:        60 * time.Second,
+		DisableThreshold:    10,
+		MaxBackoff:          24 * time.Hour,
+		RateLimitInterval:   1 * time.Second,
+		MaxPerDomain:        1,
+		CheckInterval:       5 * time.Minute,
+		MetricsInterval:     15 * time.Minute,
+		ClaimLease:          5 * time.Minute,
+		RetryAttempts:       2,
+		RetryBaseDelay:      1 * time.Second,
+		RetryMaxDelay:       30 * time.Second,
+		RenderTabs:          defaultRenderTabs,
+		RenderTimeout:       defaultRenderTimeout,
+		JournalRetention:    14 * 24 * time.Hour,
 	}
 }
 
@@ -154,17 +361,72 @@ func NewDiscoveryService(
 		config = DefaultDiscoveryConfig()
 	}
 
+	workerID := config.WorkerID
+	if workerID == "" {
+		workerID = defaultWorkerID()
+	}
+
 	return &DiscoveryService{
-		sourceStore:     sourceStore,
-		newsFeed:        newsFeed,
-		config:          config,
-		stopChan:        make(chan struct{}),
-		sourceSemaphore: make(chan struct{}, config.Concurrency),
-		rateLimiter:     newDomainRateLimiter(config.RateLimitInterval),
-		metrics:         newDiscoveryMetrics(),
+		sourceStore: sourceStore,
+		newsFeed:    newsFeed,
+		config:      config,
+		stopChan:    make(chan struct{}),
+		reloadChan:  make(chan struct{}, 1),
+		fetchQueue:  newFetchQueue(config.Concurrency),
+		rateLimiter: newDomainRateLimiter(config),
+		metrics:     newDiscoveryMetrics(),
+		inFlight:    make(map[uuid.UUID]struct{}),
+		workerID:    workerID,
+		enrichers:   DefaultEnrichers(),
 	}
 }
 
+// currentConfig returns the configuration in effect. The returned value must
+// not be modified; Reload replaces it instead.
+func (ds *DiscoveryService) currentConfig() *DiscoveryConfig {
+	ds.configMu.RLock()
+	defer ds.configMu.RUnlock()
+	return ds.config
+}
+
+// Reload replaces the service configuration while it is running. Fetches
+// already in progress finish under the old settings; new fetches use the
+// new polling intervals, concurrency, timeouts, and rate limit. A running
+// service re-evaluates which sources are due immediately.
+func (ds *DiscoveryService) Reload(config *DiscoveryConfig) {
+	ds.configMu.Lock()
+	ds.config = config
+	ds.configMu.Unlock()
+
+	ds.fetchQueue.resize(config.Concurrency)
+	ds.rateLimiter.configure(config)
+
+	// Wake the run loop without blocking if a reload is already pending
+	select {
+	case ds.reloadChan <- struct{}{}:
+	default:
+	}
+}
+
+// claimSource marks a source as being fetched. It returns false if a fetch
+// of the source is already in progress.
+func (ds *DiscoveryService) claimSource(sourceID uuid.UUID) bool {
+	ds.inFlightMu.Lock()
+	defer ds.inFlightMu.Unlock()
+	if _, ok := ds.inFlight[sourceID]; ok {
+		return false
+	}
+	ds.inFlight[sourceID] = struct{}{}
+	return true
+}
+
+// releaseSource marks a source's fetch as finished.
+func (ds *DiscoveryService) releaseSource(sourceID uuid.UUID) {
+	ds.inFlightMu.Lock()
+	defer ds.inFlightMu.Unlock()
+	delete(ds.inFlight, sourceID)
+}
+
 // GetMetrics returns the current metrics for monitoring.
 func (ds *DiscoveryService) GetMetrics() *DiscoveryMetrics {
 	return ds.metrics
@@ -174,42 +436,114 @@ func (ds *DiscoveryService) GetMetrics() *DiscoveryMetrics {
 // the context is cancelled.
 func (ds *DiscoveryService) Run(ctx context.Context) error {
 	log.Println("INFO: Discovery service starting")
+	ds.tick()
+	defer ds.lastTick.Store(0)
+
+	ds.restoreMetrics()
 
 	// Fetch sources immediately on startup per Spec 7 section 3.3
-	if err := ds.fetchSources(ctx); err != nil {
+	nextDue, err := ds.fetchSources(ctx)
+	if err != nil {
 		log.Printf("ERROR: Initial source fetch failed: %v", err)
 	}
 
-	// Start polling loop
-	ticker := time.NewTicker(5 * time.Minute) // Check for due sources every 5 minutes
-	defer ticker.Stop()
+	// Start polling loop; the timer is reset after each check to fire when
+	// the next source is due
+	timer := time.NewTimer(ds.nextCheckDelay(nextDue))
+	defer timer.Stop()
 
-	// Start metrics logging
-	metricsTicker := time.NewTicker(15 * time.Minute) // Log metrics every 15 minutes
+	// Start metrics logging; the metrics are saved along with each log
+	// line, so that they carry on from where they were after a restart
+	metricsInterval := ds.currentConfig().metricsInterval()
+	metricsTicker := time.NewTicker(metricsInterval)
 	defer metricsTicker.Stop()
 
+	// Start retention pruning; pruneItems only prunes the change log unless
+	// a retention period is configured. Expired pins are removed first so that their
+	// items can be pruned.
+	ds.expirePins()
+	ds.pruneItems()
+	ds.pruneJournal()
+	pruneTicker := time.NewTicker(1 * time.Hour) // Prune old items every hour
+	defer pruneTicker.Stop()
+
 	for {
+		ds.tick()
 		select {
 		case <-ctx.Done():
 			log.Println("INFO: Discovery service stopping (context cancelled)")
-			ds.logMetrics()
 			ds.wg.Wait() // Wait for in-progress fetches to complete
+			ds.logMetrics()
+			ds.saveMetrics()
 			return ctx.Err()
 		case <-ds.stopChan:
 			log.Println("INFO: Discovery service stopping")
-			ds.logMetrics()
 			ds.wg.Wait() // Wait for in-progress fetches to complete
+			ds.logMetrics()
+			ds.saveMetrics()
 			return nil
-		case <-ticker.C:
-			if err := ds.fetchSources(ctx); err != nil {
+		case <-timer.C:
+			nextDue, err := ds.fetchSources(ctx)
+			if err != nil {
 				log.Printf("ERROR: Source fetch failed: %v", err)
 			}
+			timer.Reset(ds.nextCheckDelay(nextDue))
 		case <-metricsTicker.C:
 			ds.logMetrics()
+			ds.saveMetrics()
+		case <-ds.reloadChan:
+			log.Println("INFO: Configuration reloaded; checking for due sources")
+			nextDue, err := ds.fetchSources(ctx)
+			if err != nil {
+				log.Printf("ERROR: Source fetch failed: %v", err)
+			}
+			timer.Reset(ds.nextCheckDelay(nextDue))
+			if interval := ds.currentConfig().metricsInterval(); interval != metricsInterval {
+				metricsInterval = interval
+				metricsTicker.Reset(interval)
+			}
+		case <-pruneTicker.C:
+			ds.expirePins()
+			ds.pruneItems()
+			ds.pruneJournal()
 		}
 	}
 }
 
+// expirePins unpins items whose pins have expired.
+func (ds *DiscoveryService) expirePins() {
+	expired, err := ds.newsFeed.ExpirePins(time.Now())
+	if err != nil {
+		log.Printf("ERROR: Expiring pins failed: %v", err)
+	}
+	if len(expired) > 0 {
+		log.Printf("INFO: Unpinned %d items whose pins expired", len(expired))
+	}
+}
+
+// pruneItems removes unpinned items older than the configured retention
+// period, if there is one, and the changes logged more than
+// newsfeed.ChangeRetention ago.
+func (ds *DiscoveryService) pruneItems() {
+	if _, err := ds.newsFeed.PruneChanges(time.Now().Add(-newsfeed.ChangeRetention)); err != nil {
+		log.Printf("ERROR: Pruning the change log failed: %v", err)
+	}
+
+	retention := ds.currentConfig().RetentionPeriod
+	if retention <= 0 {
+		return
+	}
+
+	cutoff := time.Now().Add(-retention)
+	pruned, err := ds.newsFeed.Prune(newsfeed.PruneOptions{Before: cutoff})
+	if err != nil {
+		log.Printf("ERROR: Pruning failed: %v", err)
+	}
+	if len(pruned) > 0 {
+		log.Printf("INFO: Pruned %d items older than %v", len(pruned), retention)
+	}
+}
+
 // logMetrics logs current metrics per Spec 7 section 10.2.
 func (ds *DiscoveryService) logMetrics() {
 	sourcesTotal, sourcesFetched, sourcesFailed, itemsDiscovered := ds.metrics.GetMetrics()
@@ -222,14 +556,65 @@ func (ds *DiscoveryService) Stop() {
 	close(ds.stopChan)
 }
 
-// fetchSources fetches all sources that are due for polling.
-func (ds *DiscoveryService) fetchSources(ctx context.Context) error {
+// Close waits for hooks still to run and items still to be published, then
+// stops the browser started for sources that render pages, if any. It
+// doesn't stop the service; see Stop.
+func (ds *DiscoveryService) Close() error {
+	ds.Flush()
+	ds.rendererMu.Lock()
+	defer ds.rendererMu.Unlock()
+	if ds.pageRender == nil {
+		return nil
+	}
+	return ds.pageRender.Close()
+}
+
+// renderer returns the renderer for sources that render pages, creating it
+// the first time it is needed.
+func (ds *DiscoveryService) renderer() *Renderer {
+	ds.rendererMu.Lock()
+	defer ds.rendererMu.Unlock()
+	if ds.pageRender == nil {
+		config := ds.currentConfig()
+		ds.pageRender = NewRenderer(config.BrowserPath, config.RenderTabs, config.RenderTimeout)
+	}
+	return ds.pageRender
+}
+
+// nextCheckDelay returns how long the scheduler should wait before checking
+// for due sources again: until nextDue, but no longer than the configured
+// check interval. A zero nextDue means no source is scheduled.
+func (ds *DiscoveryService) nextCheckDelay(nextDue time.Time) time.Duration {
+	delay := ds.currentConfig().checkInterval()
+	if !nextDue.IsZero() {
+		if untilDue := time.Until(nextDue); untilDue < delay {
+			delay = untilDue
+		}
+	}
+
+	// Don't spin on sources that are due now, such as ones whose fetch is
+	// still running
+	if delay < minCheckDelay {
+		delay = minCheckDelay
+	}
+	return delay
+}
+
+// minCheckDelay is the shortest time the scheduler waits between checks.
+const minCheckDelay = time.Second
+
+// fetchSources starts fetches of all sources that are due for polling. It
+// returns the time the next enabled source becomes due, or the zero time if
+// there are none.
+func (ds *DiscoveryService) fetchSources(ctx context.Context) (time.Time, error) {
 	// Get all sources from metadata store
 	sourceList, err := ds.sourceStore.ListSources(sources.SourceFilter{})
 	if err != nil {
-		return fmt.Errorf("failed to list sources: %w", err)
+		return time.Time{}, fmt.Errorf("failed to list sources: %w", err)
 	}
 
+	ds.reclaimExpired()
+
 	// Update metrics with total enabled sources
 	enabledCount := 0
 	for _, s := range sourceList {
@@ -241,50 +626,156 @@ func (ds *DiscoveryService) fetchSources(ctx context.Context) error {
 
 	// Filter for enabled sources that are due
 	dueSources := ds.filterDueSources(sourceList)
+	nextDue := ds.nextDueTime(sourceList, time.Now())
 	if len(dueSources) == 0 {
-		return nil
+		return nextDue, nil
 	}
 
 	log.Printf("INFO: Fetching %d due sources (of %d enabled)", len(dueSources), enabledCount)
 
-	// Fetch sources in parallel with concurrency limit
+	// Fetch sources in parallel with concurrency limits, skipping any whose
+	// previous fetch is still running here or that another service has
+	// claimed
 	for _, source := range dueSources {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case ds.sourceSemaphore <- struct{}{}: // Acquire semaphore
-			ds.wg.Add(1)
-			go func(s sources.Source) {
-				defer ds.wg.Done()
-				defer func() { <-ds.sourceSemaphore }() // Release semaphore
-
-				if err := ds.fetchSource(ctx, s); err != nil {
-					log.Printf("ERROR: Failed to fetch source %s (%s): %v", s.Name, s.URL, err)
-				}
-			}(source)
+		if err := ctx.Err(); err != nil {
+			return nextDue, err
+		}
+		if !ds.claimSource(source.SourceID) {
+			continue
 		}
+
+		ds.wg.Add(1)
+		go func(s sources.Source) {
+			defer ds.wg.Done()
+			defer ds.releaseSource(s.SourceID)
+
+			release, err := ds.acquireFetchSlot(ctx, s)
+			if err != nil {
+				return
+			}
+			defer release()
+
+			leaseCtx, claimed, releaseLease, err := ds.leaseSource(ctx, s)
+			if err != nil {
+				if !errors.Is(err, sources.ErrSourceClaimed) {
+					log.Printf("ERROR: Failed to claim source %s (%s): %v", s.Name, s.URL, err)
+				}
+				return
+			}
+			defer releaseLease()
+
+			// Another service may have fetched the source while this one
+			// waited for a slot
+			if !ds.shouldFetch(*claimed, time.Now()) {
+				return
+			}
+
+			if _, err := ds.fetchSource(leaseCtx, *claimed, nil); err != nil {
+				log.Printf("ERROR: Failed to fetch source %s (%s): %v", s.Name, s.URL, err)
+			}
+		}(source)
 	}
 
-	return nil
+	return nextDue, nil
 }
 
-// filterDueSources returns sources that are enabled and due for fetching.
-// Implements Spec 7 section 3.2 and 3.3.
-func (ds *DiscoveryService) filterDueSources(sourceList []sources.Source) []sources.Source {
-	now := time.Now()
-	var dueSources []sources.Source
-
+// nextDueTime returns the earliest time after now that an enabled source
+// becomes due, or the zero time if no source is enabled. Sources that are
+// due now are being fetched, so they count as due again one polling
+// interval from now. A source due outside its polling window is due when
+// the window next opens.
+func (ds *DiscoveryService) nextDueTime(sourceList []sources.Source, now time.Time) time.Time {
+	var next time.Time
 	for _, source := range sourceList {
-		// Skip disabled sources (enabled_at is nil)
+		var due time.Time
 		if source.EnabledAt == nil {
+			if source.ReprobeAt == nil {
+				continue
+			}
+			due = *source.ReprobeAt
+			if !due.After(now) && inPollWindow(source, now) {
+				// Re-probes that are due now are being fetched
+				continue
+			}
+		} else {
+			interval := ds.getPollingInterval(source)
+			due = ds.sourceDueAt(source, interval)
+			if !due.After(now) && inPollWindow(source, now) {
+				due = now.Add(interval)
+			}
+		}
+
+		due = pollWindowOpens(source, latest(due, now))
+		if due.IsZero() {
 			continue
 		}
+		if next.IsZero() || due.Before(next) {
+			next = due
+		}
+	}
+	return next
+}
+
+// inPollWindow reports whether source may be fetched on schedule at now,
+// which it always may unless it has a polling window.
+func inPollWindow(source sources.Source, now time.Time) bool {
+	return source.PollWindow == nil || source.PollWindow.Contains(now)
+}
+
+// pollWindowOpens returns the earliest time at or after t that source may
+// be fetched on schedule, or the zero time if its polling window never
+// opens.
+func pollWindowOpens(source sources.Source, t time.Time) time.Time {
+	if source.PollWindow == nil {
+		return t
+	}
+	return source.PollWindow.Next(t)
+}
 
-		// Get polling interval for this source
-		interval := ds.getPollingInterval(source)
+// latest returns the later of a and b.
+func latest(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// acquireFetchSlot waits until source may be fetched and returns a function
+// that gives its slot back. Sources queue for their domain before asking the
+// fetch queue to start them, so sources waiting behind a busy domain don't
+// hold places that sources on other domains could use.
+func (ds *DiscoveryService) acquireFetchSlot(ctx context.Context, source sources.Source) (func(), error) {
+	domain, err := ds.extractDomain(source.URL)
+	if err != nil {
+		domain = source.URL
+	}
 
-		// Check if source is due
-		if ds.isSourceDue(source, interval, now) {
+	releaseDomain, err := ds.rateLimiter.acquire(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+
+	finish, err := ds.fetchQueue.startFetch(ctx)
+	if err != nil {
+		releaseDomain()
+		return nil, err
+	}
+
+	return func() {
+		finish()
+		releaseDomain()
+	}, nil
+}
+
+// filterDueSources returns sources that are enabled and due for fetching,
+// and auto-disabled sources that are due to be re-probed. Implements Spec 7
+// section 3.2 and 3.3.
+func (ds *DiscoveryService) filterDueSources(sourceList []sources.Source) []sources.Source {
+	now := time.Now()
+	var dueSources []sources.Source
+
+	for _, source := range sourceList {
+		if ds.shouldFetch(source, now) {
 			dueSources = append(dueSources, source)
 		}
 	}
@@ -292,9 +783,39 @@ func (ds *DiscoveryService) filterDueSources(sourceList []sources.Source) []sour
 	return dueSources
 }
 
+// shouldFetch reports whether source is due for fetching at now: an enabled
+// source whose polling interval has passed, or a disabled source whose
+// re-probe cooldown has, as long as its polling window is open.
+func (ds *DiscoveryService) shouldFetch(source sources.Source, now time.Time) bool {
+	if !inPollWindow(source, now) {
+		return false
+	}
+	if source.EnabledAt == nil {
+		return isReprobe(source) && !now.Before(*source.ReprobeAt)
+	}
+	return ds.isSourceDue(source, ds.getPollingInterval(source), now)
+}
+
+// isReprobe reports whether a fetch of source would be a re-probe: a try of
+// a source that was disabled after repeated transient failures, to see
+// whether it has recovered.
+func isReprobe(source sources.Source) bool {
+	return source.EnabledAt == nil && source.ReprobeAt != nil
+}
+
+// reprobeCooldowns are how long an auto-disabled source waits before each
+// re-probe; the last repeats once the others are used up.
+var reprobeCooldowns = []time.Duration{24 * time.Hour, 72 * time.Hour, 7 * 24 * time.Hour}
+
+// reprobeCooldown returns the wait before re-probe number n, from zero.
+func reprobeCooldown(n int) time.Duration {
+	return reprobeCooldowns[min(n, len(reprobeCooldowns)-1)]
+}
+
 // getPollingInterval returns the polling interval for a source. Uses the
-// source's specific interval if set, otherwise uses the global default.
-// Implements Spec 7 section 3.1.
+// source's specific interval if set; otherwise, with adaptive polling, its
+// posting cadence, and failing that the global default. Implements Spec 7
+// section 3.1.
 func (ds *DiscoveryService) getPollingInterval(source sources.Source) time.Duration {
 	if source.PollingInterval != nil {
 		interval, err := time.ParseDuration(*source.PollingInterval)
@@ -312,68 +833,180 @@ func (ds *DiscoveryService) getPollingInterval(source sources.Source) time.Durat
 			return interval
 		}
 	}
-	return ds.config.PollInterval
+	config := ds.currentConfig()
+	if config.AdaptivePolling {
+		if interval, ok := config.adaptiveInterval(source); ok {
+			return interval
+		}
+	}
+	return config.PollInterval
 }
 
 // isSourceDue checks if a source is due for fetching based on its last fetch
 // time and polling interval. Implements Spec 7 section 3.2 and 3.3.
 func (ds *DiscoveryService) isSourceDue(source sources.Source, interval time.Duration, now time.Time) bool {
+	// Overdue or due now
+	return !now.Before(ds.sourceDueAt(source, interval))
+}
+
+// sourceDueAt returns the time a source is next due for fetching. A source
+// that has never been fetched is due immediately, which is reported as the
+// zero time.
+func (ds *DiscoveryService) sourceDueAt(source sources.Source, interval time.Duration) time.Time {
+	// A source backing off after failures waits until its scheduled time
+	if source.NextFetchAt != nil {
+		return *source.NextFetchAt
+	}
+
 	// Never fetched -- fetch immediately per Spec 7 section 3.3
 	if source.LastFetchedAt == nil {
-		return true
+		return time.Time{}
 	}
 
-	// Calculate next fetch time
-	nextFetchAt := source.LastFetchedAt.Add(interval)
+	return source.LastFetchedAt.Add(interval)
+}
 
-	// Overdue or due now
-	return now.After(nextFetchAt) || now.Equal(nextFetchAt)
+// fetchReport describes a finished fetch of a source.
+type fetchReport struct {
+	newItems int // In a dry run, the number of items that would be added
+	dedup    DedupReport
+	budget   *BudgetError // Non-nil if the fetch stopped at its fetch budget
+	duration time.Duration
 }
 
 // fetchSource fetches a single source and processes its items. Implements RFC
-// 7 section 4 for RSS/Atom feeds.
-func (ds *DiscoveryService) fetchSource(ctx context.Context, source sources.Source) error {
+// 7 section 4 for RSS/Atom feeds. When plan is non-nil, the fetch is a dry
+// run: items are recorded in plan rather than added, and the source's
+// metadata, sync history, and the service's metrics are left as they were.
+func (ds *DiscoveryService) fetchSource(ctx context.Context, source sources.Source, plan *dryRunPlan) (fetchReport, error) {
 	startTime := time.Now()
 
 	// Create context with timeout
-	fetchCtx, cancel := context.WithTimeout(ctx, ds.config.FetchTimeout)
+	fetchCtx, cancel := context.WithTimeout(ctx, ds.currentConfig().FetchTimeout)
 	defer cancel()
 
+	fetchCtx, span := startFetchSpan(fetchCtx, source)
+
 	// Process based on source type
-	var newItemCount int
+	var report fetchReport
 	var err error
 
 	switch source.SourceType {
-	case "rss", "atom":
-		newItemCount, err = ds.fetchRSSFeed(fetchCtx, source)
+	case "rss", "atom", "json":
+		report.newItems, report.dedup, err = ds.fetchRSSFeed(fetchCtx, source, plan)
 	case "website":
-		newItemCount, err = ds.fetchWebsite(fetchCtx, source)
+		report.newItems, report.dedup, err = ds.fetchWebsite(fetchCtx, source, plan)
+	case "hackernews":
+		report.newItems, report.dedup, err = ds.fetchHackerNews(fetchCtx, source, plan)
 	default:
-		return fmt.Errorf("unsupported source type: %s", source.SourceType)
+		err = fmt.Errorf("unsupported source type: %s", source.SourceType)
+		endSpan(span, err)
+		return report, err
+	}
+
+	// A fetch stopped by its budget keeps the items it found and counts as
+	// a success
+	report.budget = budgetReached(err)
+	if report.budget != nil {
+		err = nil
 	}
+	endFetchSpan(span, report.newItems, report.dedup, err)
 
-	duration := time.Since(startTime)
+	report.duration = time.Since(startTime)
+	if plan != nil {
+		return report, err
+	}
+	ds.recordSyncAttempt(source, startTime, report.duration, report.newItems, err)
+
+	// A fetch cut short by shutdown keeps the items it found but is neither
+	// counted as a failure nor marked as fetched, so the source is fetched in
+	// full next time
+	if err != nil && ctx.Err() != nil {
+		ds.metrics.recordItemsDiscovered(report.newItems)
+		log.Printf("INFO: Fetch of %s (%s) interrupted after %d new items", source.Name, source.URL, report.newItems)
+		return report, err
+	}
 
 	// Update source metadata
 	if err != nil {
 		ds.handleFetchError(source, err)
-		ds.metrics.recordFetchFailure(duration)
-		return err
+		ds.metrics.recordFetchFailure(report.duration)
+		return report, err
 	}
 
 	// Success -- update metadata and metrics
 	ds.handleFetchSuccess(source)
-	ds.metrics.recordFetchSuccess(duration)
-	ds.metrics.recordItemsDiscovered(newItemCount)
+	ds.metrics.recordFetchSuccess(report.duration)
+	ds.metrics.recordItemsDiscovered(report.newItems)
 
 	// Log success per Spec 7 section 10.1
-	if duration > 30*time.Second {
-		log.Printf("WARN: Slow fetch for %s (%s): %d new items in %v", source.Name, source.URL, newItemCount, duration)
+	if report.duration > 30*time.Second {
+		log.Printf("WARN: Slow fetch for %s (%s): %d new items in %v", source.Name, source.URL, report.newItems, report.duration)
 	} else {
-		log.Printf("INFO: Fetched %s (%s): %d new items in %v", source.Name, source.URL, newItemCount, duration)
+		log.Printf("INFO: Fetched %s (%s): %d new items in %v", source.Name, source.URL, report.newItems, report.duration)
+	}
+	if report.dedup.Total() > 0 {
+		log.Printf("INFO: Skipped %d duplicate items from %s (%d by GUID, %d by URL, %d by content)", report.dedup.Total(), source.Name, report.dedup.GUIDDuplicates, report.dedup.URLDuplicates, report.dedup.ContentDuplicates)
+	}
+	if report.dedup.Updated > 0 {
+		log.Printf("INFO: Updated %d changed items from %s", report.dedup.Updated, source.Name)
+	}
+	if report.budget != nil {
+		log.Printf("WARN: Fetch of %s (%s) stopped early: %v", source.Name, source.URL, report.budget)
 	}
 
-	return nil
+	return report, nil
+}
+
+// recordSyncAttempt adds a fetch attempt to the source's sync history.
+// Failures are logged rather than returned so they never mask the fetch
+// result.
+func (ds *DiscoveryService) recordSyncAttempt(
+	source sources.Source,
+	startTime time.Time,
+	duration time.Duration,
+	newItemCount int,
+	fetchErr error,
+) {
+	attempt := sources.SyncAttempt{
+		SourceID:        source.SourceID,
+		StartedAt:       startTime,
+		Duration:        duration,
+		ItemsDiscovered: newItemCount,
+		Reprobe:         isReprobe(source),
+		DiscoveryLag:    ds.metrics.takeFetchLag(source.SourceID),
+	}
+	if fetchErr != nil {
+		errMsg := fetchErr.Error()
+		attempt.Error = &errMsg
+	}
+
+	if err := ds.sourceStore.RecordSyncAttempt(attempt); err != nil {
+		log.Printf("WARN: Failed to record sync history for %s: %v", source.URL, err)
+	}
+	ds.runHooks(HookEvent{Event: HookSyncCompleted, SourceID: source.SourceID, SourceName: source.Name, Sync: &attempt})
+}
+
+// itemAdded records an item added to the feed from source in the metrics
+// and journal, and runs the item_added hooks.
+func (ds *DiscoveryService) itemAdded(source sources.Source, item newsfeed.NewsItem, journal *fetchJournal) {
+	ds.recordDiscoveryLag(source, item)
+	journal.record(item, JournalAdded, "", item.ID)
+	ds.runHooks(HookEvent{Event: HookItemAdded, SourceID: source.SourceID, SourceName: source.Name, Item: &item})
+	ds.publishItem(item)
+}
+
+// recordDiscoveryLag records how long after its publication a new item was
+// discovered. Items found by a source's first sync are its backlog rather
+// than new posts, and items without a date are given their discovery time,
+// so neither is counted.
+func (ds *DiscoveryService) recordDiscoveryLag(source sources.Source, item newsfeed.NewsItem) {
+	if source.LastFetchedAt == nil {
+		return
+	}
+	if lag := item.DiscoveredAt.Sub(item.PublishedAt); lag > 0 {
+		ds.metrics.recordDiscoveryLag(source, lag)
+	}
 }
 
 // shouldApplyItemLimit determines whether to apply the 20-item limit based on
@@ -398,56 +1031,154 @@ func (ds *DiscoveryService) shouldApplyItemLimit(source sources.Source) bool {
 	return false
 }
 
-// fetchRSSFeed fetches and processes an RSS or Atom feed. Implements Spec 7
-// section 4 with conditional 20-item limit per Spec 2 section 2.2.3.
-func (ds *DiscoveryService) fetchRSSFeed(ctx context.Context, source sources.Source) (int, error) {
-	// Fetch the feed (FetchFeed from Spec 2)
-	feed, err := FetchFeed(ctx, source.URL)
+// fetchRSSFeed fetches and processes an RSS, Atom, or JSON Feed. Implements
+// Spec 7 section 4 with conditional 20-item limit per Spec 2 section 2.2.3.
+// When plan is non-nil, items are recorded in it instead of being added to
+// the feed, and the feed is fetched in full rather than conditionally.
+func (ds *DiscoveryService) fetchRSSFeed(ctx context.Context, source sources.Source, plan *dryRunPlan) (int, DedupReport, error) {
+	// Rate limit before fetching
+	if domain, err := ds.extractDomain(source.URL); err == nil {
+		if err := ds.rateLimiter.wait(ctx, domain); err != nil {
+			return 0, DedupReport{}, err
+		}
+	}
+
+	// Fetch the feed (FetchFeed from Spec 2), sending cache validators from
+	// the previous fetch so unchanged feeds can be skipped
+	etag, lastModified := source.ETag, source.LastModified
+	if plan != nil {
+		etag, lastModified = nil, nil
+	}
+	opts, err := ds.requestOptionsFor(source)
 	if err != nil {
-		return 0, fmt.Errorf("failed to fetch feed: %w", err)
+		return 0, DedupReport{}, err
 	}
 
 	// Determine if we should apply the 20-item limit (Spec 2 section 2.2.3)
 	// Limit applies for:
 	// 1. First-time sync (last_fetched_at is nil)
 	// 2. Stale sources (not synced for >15 days)
+	// Feeds that get it stop being read once they have given that many
 	applyLimit := ds.shouldApplyItemLimit(source)
+	if applyLimit {
+		opts.MaxFeedItems = feedItemLimit
+	}
+	result, err := FetchFeedConditional(ctx, source.URL, etag, lastModified, opts)
+	if err != nil {
+		return 0, DedupReport{}, fmt.Errorf("failed to fetch feed: %w", err)
+	}
+
+	if result.NotModified {
+		if plan == nil {
+			ds.saveCacheValidators(source, result)
+		}
+		return 0, DedupReport{}, nil
+	}
+	feed := result.Feed
 
 	// Convert feed items to NewsItems (FeedToNewsItems from Spec 2)
 	newsItems := FeedToNewsItems(feed, applyLimit, source.SourceID)
+	if plan == nil {
+		ds.recordPostingCadence(source, newsItems)
+	}
 
-	// Build URL set once for deduplication (Spec 7 section 4.2).
-	knownURLs, err := BuildURLSet(ds.newsFeed)
+	// Build the dedup index once for deduplication (Spec 7 section 4.2).
+	index, err := ds.newsFeed.DedupIndex()
 	if err != nil {
-		return 0, fmt.Errorf("failed to build URL set: %w", err)
+		return 0, DedupReport{}, fmt.Errorf("failed to build dedup index: %w", err)
 	}
 
+	filter, err := newIngestFilter(source.IngestRules, ds.currentConfig().MaxItemAge)
+	if err != nil {
+		return 0, DedupReport{}, err
+	}
+
+	journal := ds.startJournal(source, plan)
+	defer journal.flush()
+
 	newItemCount := 0
+	var dedup DedupReport
+	seen := map[uuid.UUID]bool{} // Items added or updated by this fetch
+	addFailed := false
 	for _, item := range newsItems {
-		normalized := normalizeURL(item.URL)
-		if _, exists := knownURLs[normalized]; exists {
+		if err := ctx.Err(); err != nil {
+			return newItemCount, dedup, err
+		}
+		if reason := filter.rejection(item); reason != "" {
+			journal.record(item, JournalFiltered, reason, uuid.Nil)
 			continue
 		}
-
-		if err := ds.newsFeed.Add(item); err != nil {
-			log.Printf("WARN: Failed to add item %s: %v", item.URL, err)
+		if filter.full(newItemCount) {
+			journal.record(item, JournalFiltered, "max_items reached for this sync", uuid.Nil)
 			continue
 		}
+		item.Category = source.Category
+		if source.Language != nil {
+			item.Language = *source.Language
+		}
+		localizePublishedAt(&item, sourceLocation(source))
+		if !ds.currentConfig().KeepSummaryHTML {
+			item.SummaryHTML = ""
+		}
+		if kind, existingID := index.MatchScope(item, filter.scope()); kind != newsfeed.NotDuplicate {
+			dedup.record(kind)
+			plan.record(source, item, kind)
+			journal.recordDuplicate(item, kind, existingID)
+			traceDuplicate(ctx, item.URL, kind)
+			// The feed still carries the item, so pick up any correction
+			// the publisher made to it
+			if plan == nil && ds.updateDuplicate(index, seen, kind, existingID, item) {
+				dedup.Updated++
+			}
+			continue
+		}
+
+		if plan != nil {
+			plan.record(source, item, newsfeed.NotDuplicate)
+		} else {
+			ds.enrich(ctx, &item)
+			if err := ds.newsFeed.Add(item); err != nil {
+				log.Printf("WARN: Failed to add item %s: %v", item.URL, err)
+				addFailed = true
+				continue
+			}
+			ds.itemAdded(source, item, journal)
+		}
 
-		// Track the newly added URL so later items in the same batch are also
-		// deduplicated.
-		knownURLs[normalized] = struct{}{}
+		// Track the newly added item so later items in the same batch are
+		// also deduplicated.
+		index.Add(item)
+		seen[item.ID] = true
 		newItemCount++
 	}
 
-	return newItemCount, nil
+	// Only once every item has been dealt with may the next fetch be
+	// conditional; an item that failed to be added is tried again then
+	if plan == nil && !addFailed {
+		ds.saveCacheValidators(source, result)
+	}
+	return newItemCount, dedup, nil
+}
+
+// saveCacheValidators saves the ETag and Last-Modified of a feed response,
+// if they changed, so that the next fetch of source can be conditional. They
+// must only be saved once the feed's items are in the feed, or a 304 would
+// keep the items that weren't from ever being added.
+func (ds *DiscoveryService) saveCacheValidators(source sources.Source, result *FeedFetchResult) {
+	if equalStringPtr(result.ETag, source.ETag) && equalStringPtr(result.LastModified, source.LastModified) {
+		return
+	}
+	if err := ds.UpdateSourceFetchMetadata(source.SourceID, result.LastModified, result.ETag); err != nil {
+		log.Printf("WARN: Failed to save cache headers for %s: %v", source.URL, err)
+	}
 }
 
 // fetchWebsite fetches and processes a website source. Implements Spec 7
-// section 5.
-func (ds *DiscoveryService) fetchWebsite(ctx context.Context, source sources.Source) (int, error) {
+// section 5. When plan is non-nil, items are recorded in it instead of being
+// added to the feed.
+func (ds *DiscoveryService) fetchWebsite(ctx context.Context, source sources.Source, plan *dryRunPlan) (int, DedupReport, error) {
 	if source.ScraperConfig == nil {
-		return 0, fmt.Errorf("scraper config is required for website sources")
+		return 0, DedupReport{}, fmt.Errorf("scraper config is required for website sources")
 	}
 
 	config := source.ScraperConfig
@@ -455,66 +1186,113 @@ func (ds *DiscoveryService) fetchWebsite(ctx context.Context, source sources.Sou
 	// Get domain for rate limiting
 	domain, err := ds.extractDomain(source.URL)
 	if err != nil {
-		return 0, fmt.Errorf("invalid source URL: %w", err)
+		return 0, DedupReport{}, fmt.Errorf("invalid source URL: %w", err)
+	}
+
+	// A source behind a login gets its session's cookies, logging in first
+	// if it has a login form
+	opts, err := ds.requestOptionsFor(source)
+	if err != nil {
+		return 0, DedupReport{}, err
+	}
+	if source.HasSession {
+		if opts.Jar, err = ds.sessionJar(ctx, source, domain, opts); err != nil {
+			return 0, DedupReport{}, err
+		}
 	}
 
 	switch config.DiscoveryMode {
 	case "direct":
-		return ds.fetchDirectMode(ctx, source, config, domain)
+		return ds.fetchDirectMode(ctx, source, config, domain, opts, plan)
 	case "list":
-		return ds.fetchListMode(ctx, source, config, domain)
+		return ds.fetchListMode(ctx, source, config, domain, opts, plan)
 	default:
-		return 0, fmt.Errorf("unsupported discovery mode: %s", config.DiscoveryMode)
+		return 0, DedupReport{}, fmt.Errorf("unsupported discovery mode: %s", config.DiscoveryMode)
 	}
 }
 
 // fetchDirectMode fetches a single article page directly. Implements Spec 7
 // section 5.1.1.
-func (ds *DiscoveryService) fetchDirectMode(ctx context.Context, source sources.Source, config *ScraperConfig, domain string) (int, error) {
+func (ds *DiscoveryService) fetchDirectMode(ctx context.Context, source sources.Source, config *ScraperConfig, domain string, opts RequestOptions, plan *dryRunPlan) (int, DedupReport, error) {
 	// Rate limit before fetching
-	ds.rateLimiter.wait(domain)
+	if err := ds.rateLimiter.wait(ctx, domain); err != nil {
+		return 0, DedupReport{}, err
+	}
 
 	// Scrape the article
-	article, err := ScrapeArticle(ctx, source.URL, config.ArticleConfig)
+	article, err := scrapeArticle(ctx, source.URL, config.ArticleConfig, articleOptions(opts, config.ArticleConfig))
 	if err != nil {
-		return 0, fmt.Errorf("failed to scrape article: %w", err)
+		return 0, DedupReport{}, fmt.Errorf("failed to scrape article: %w", err)
 	}
 
+	journal := ds.startJournal(source, plan)
+	defer journal.flush()
+
 	// Validate the article
 	if err := ValidateScrapedArticle(article, source.URL); err != nil {
 		// Validation errors don't count as fetch failures per Spec 7 section
 		// 7.4
 		log.Printf("WARN: Validation failed for %s: %v", source.URL, err)
-		return 0, nil
+		journal.record(newsfeed.NewsItem{URL: source.URL, Title: article.Title}, JournalInvalid, err.Error(), uuid.Nil)
+		return 0, DedupReport{}, nil
+	}
+
+	// Convert to NewsItem
+	newsItem := ScrapedArticleToNewsItem(article, source.Name, source.SourceID)
+	newsItem.Category = source.Category
+	if source.Language != nil {
+		newsItem.Language = *source.Language
+	}
+	localizePublishedAt(&newsItem, sourceLocation(source))
+
+	filter, err := newIngestFilter(source.IngestRules, ds.currentConfig().MaxItemAge)
+	if err != nil {
+		return 0, DedupReport{}, err
+	}
+	if reason := filter.rejection(newsItem); reason != "" {
+		journal.record(newsItem, JournalFiltered, reason, uuid.Nil)
+		return 0, DedupReport{}, nil
 	}
 
 	// Check for duplicates
-	exists, err := URLExists(ds.newsFeed, article.URL)
+	index, err := ds.newsFeed.DedupIndex()
 	if err != nil {
-		return 0, fmt.Errorf("failed to check URL existence: %w", err)
+		return 0, DedupReport{}, fmt.Errorf("failed to build dedup index: %w", err)
 	}
 
-	if exists {
-		// Already have this article
-		return 0, nil
+	if kind, existingID := index.MatchScope(newsItem, filter.scope()); kind != newsfeed.NotDuplicate {
+		// Already have this article, though it may have changed
+		var dedup DedupReport
+		dedup.record(kind)
+		plan.record(source, newsItem, kind)
+		journal.recordDuplicate(newsItem, kind, existingID)
+		traceDuplicate(ctx, newsItem.URL, kind)
+		if plan == nil && ds.updateDuplicate(index, map[uuid.UUID]bool{}, kind, existingID, newsItem) {
+			dedup.Updated++
+		}
+		return 0, dedup, nil
 	}
 
-	// Convert to NewsItem
-	newsItem := ScrapedArticleToNewsItem(article, source.Name, source.SourceID)
+	if plan != nil {
+		plan.record(source, newsItem, newsfeed.NotDuplicate)
+		return 1, DedupReport{}, nil
+	}
 
 	// Add to feed
+	ds.enrich(ctx, &newsItem)
 	if err := ds.newsFeed.Add(newsItem); err != nil {
-		return 0, fmt.Errorf("failed to add item: %w", err)
+		return 0, DedupReport{}, fmt.Errorf("failed to add item: %w", err)
 	}
+	ds.itemAdded(source, newsItem, journal)
 
-	return 1, nil
+	return 1, DedupReport{}, nil
 }
 
 // fetchListMode fetches articles from a list/index page. Implements Spec 7
 // section 5.1.2 with conditional 20-article cap per Spec 3 section 3.1.1.
-func (ds *DiscoveryService) fetchListMode(ctx context.Context, source sources.Source, config *ScraperConfig, domain string) (int, error) {
+func (ds *DiscoveryService) fetchListMode(ctx context.Context, source sources.Source, config *ScraperConfig, domain string, opts RequestOptions, plan *dryRunPlan) (int, DedupReport, error) {
 	if config.ListConfig == nil {
-		return 0, fmt.Errorf("list_config is required for list mode")
+		return 0, DedupReport{}, fmt.Errorf("list_config is required for list mode")
 	}
 
 	listConfig := config.ListConfig
@@ -528,13 +1306,42 @@ func (ds *DiscoveryService) fetchListMode(ctx context.Context, source sources.So
 	applyLimit := ds.shouldApplyItemLimit(source)
 	const maxArticles = 20 // Spec 3 section 3.1.1
 
-	// Build URL set once for deduplication.
-	knownURLs, err := BuildURLSet(ds.newsFeed)
+	// Build the dedup index once for deduplication.
+	index, err := ds.newsFeed.DedupIndex()
+	if err != nil {
+		return 0, DedupReport{}, fmt.Errorf("failed to build dedup index: %w", err)
+	}
+	var dedup DedupReport
+
+	filter, err := newIngestFilter(source.IngestRules, ds.currentConfig().MaxItemAge)
 	if err != nil {
-		return 0, fmt.Errorf("failed to build URL set: %w", err)
+		return 0, DedupReport{}, err
+	}
+
+	journal := ds.startJournal(source, plan)
+	defer journal.flush()
+
+	// duplicateURL records an article link we already have as a duplicate
+	duplicateURL := func(articleURL string) bool {
+		linked := newsfeed.NewsItem{URL: articleURL, SourceID: &source.SourceID}
+		kind, existingID := index.MatchScope(linked, filter.scope())
+		if kind != newsfeed.DuplicateURL {
+			return false
+		}
+		dedup.record(kind)
+		plan.record(source, linked, kind)
+		journal.recordDuplicate(linked, kind, existingID)
+		traceDuplicate(ctx, articleURL, kind)
+		return true
 	}
 
-	for pagesProcessed < listConfig.MaxPages {
+	for pagesProcessed < listConfig.MaxPages && !filter.full(newItemCount) {
+		// Stop between pages once the fetch is cancelled; items already
+		// added are kept
+		if err := ctx.Err(); err != nil {
+			return newItemCount, dedup, err
+		}
+
 		// Conditionally enforce max articles limit per Spec 3 section 3.1.1
 		// Only apply for first-time syncs or stale sources
 		if applyLimit && articlesCollected >= maxArticles {
@@ -542,12 +1349,17 @@ func (ds *DiscoveryService) fetchListMode(ctx context.Context, source sources.So
 		}
 
 		// Rate limit before fetching
-		ds.rateLimiter.wait(domain)
+		if err := ds.rateLimiter.wait(ctx, domain); err != nil {
+			return newItemCount, dedup, err
+		}
 
-		// Fetch the list page
-		doc, err := FetchHTML(ctx, currentURL)
+		// Fetch the list page, which when rendered is read once it lists
+		// articles
+		listOpts := opts
+		listOpts.WaitSelector = listConfig.ArticleSelector
+		doc, err := FetchHTMLWithOptions(ctx, currentURL, listOpts)
 		if err != nil {
-			return newItemCount, fmt.Errorf("failed to fetch list page: %w", err)
+			return newItemCount, dedup, fmt.Errorf("failed to fetch list page: %w", err)
 		}
 
 		// Extract article URLs
@@ -566,47 +1378,101 @@ func (ds *DiscoveryService) fetchListMode(ctx context.Context, source sources.So
 			}
 		}
 
-		// Process each article URL
+		// Choose the articles to scrape, skipping ones the source's rules
+		// exclude or that we already have before spending a request on them
+		var pending []string
 		for _, articleURL := range articleURLs {
+			if reason := filter.urlRejection(articleURL); reason != "" {
+				journal.record(newsfeed.NewsItem{URL: articleURL}, JournalFiltered, reason, uuid.Nil)
+				continue
+			}
+
 			// Only increment counter if limit is being applied
 			if applyLimit {
 				articlesCollected++
 			}
 
-			// Check if URL already exists (deduplication)
-			normalized := normalizeURL(articleURL)
-			if _, exists := knownURLs[normalized]; exists {
+			if duplicateURL(articleURL) {
 				continue
 			}
+			pending = append(pending, articleURL)
+		}
+
+		// Scrape them in parallel, adding them in the order they're listed
+		// scrapes is stopped at the end of the page, and before returning
+		// from it, rather than deferred, so that a page's scrapes don't
+		// outlive it
+		scrapes := ds.scrapeArticles(ctx, pending, domain, config.ArticleConfig, articleOptions(opts, config.ArticleConfig), ds.currentConfig().articleConcurrency())
+		for _, articleURL := range pending {
+			if filter.full(newItemCount) {
+				break
+			}
 
-			// Rate limit before fetching article
-			ds.rateLimiter.wait(domain)
+			article, err := scrapes.result(articleURL)
 
-			// Scrape the article
-			article, err := ScrapeArticle(ctx, articleURL, config.ArticleConfig)
+			// Stop between articles once the fetch is cancelled
+			if ctx.Err() != nil {
+				scrapes.stop()
+				return newItemCount, dedup, ctx.Err()
+			}
 			if err != nil {
+				if budgetReached(err) != nil {
+					scrapes.stop()
+					return newItemCount, dedup, err
+				}
 				log.Printf("WARN: Failed to scrape article %s: %v", articleURL, err)
 				continue
 			}
 
+			// A link listed twice was added the first time
+			if duplicateURL(articleURL) {
+				continue
+			}
+
 			// Validate the article
 			if err := ValidateScrapedArticle(article, source.URL); err != nil {
 				log.Printf("WARN: Validation failed for %s: %v", articleURL, err)
+				journal.record(newsfeed.NewsItem{URL: articleURL, Title: article.Title}, JournalInvalid, err.Error(), uuid.Nil)
 				continue
 			}
 
 			// Convert to NewsItem
 			newsItem := ScrapedArticleToNewsItem(article, source.Name, source.SourceID)
+			newsItem.Category = source.Category
+			if source.Language != nil {
+				newsItem.Language = *source.Language
+			}
+			localizePublishedAt(&newsItem, sourceLocation(source))
+			if reason := filter.rejection(newsItem); reason != "" {
+				journal.record(newsItem, JournalFiltered, reason, uuid.Nil)
+				continue
+			}
 
-			// Add to feed
-			if err := ds.newsFeed.Add(newsItem); err != nil {
-				log.Printf("WARN: Failed to add item %s: %v", articleURL, err)
+			// The article may redirect to, or republish, one we already have
+			if kind, existingID := index.MatchScope(newsItem, filter.scope()); kind != newsfeed.NotDuplicate {
+				dedup.record(kind)
+				plan.record(source, newsItem, kind)
+				journal.recordDuplicate(newsItem, kind, existingID)
+				traceDuplicate(ctx, newsItem.URL, kind)
 				continue
 			}
 
-			knownURLs[normalized] = struct{}{}
+			// Add to feed
+			if plan != nil {
+				plan.record(source, newsItem, newsfeed.NotDuplicate)
+			} else {
+				ds.enrich(ctx, &newsItem)
+				if err := ds.newsFeed.Add(newsItem); err != nil {
+					log.Printf("WARN: Failed to add item %s: %v", articleURL, err)
+					continue
+				}
+				ds.itemAdded(source, newsItem, journal)
+			}
+
+			index.Add(newsItem)
 			newItemCount++
 		}
+		scrapes.stop()
 
 		pagesProcessed++
 
@@ -631,7 +1497,7 @@ func (ds *DiscoveryService) fetchListMode(ctx context.Context, source sources.So
 		currentURL = nextURL
 	}
 
-	return newItemCount, nil
+	return newItemCount, dedup, nil
 }
 
 // extractArticleURLs extracts article URLs from a list page.
@@ -698,16 +1564,25 @@ func (ds *DiscoveryService) extractDomain(urlStr string) (string, error) {
 	return parsed.Host, nil
 }
 
-// handleFetchSuccess updates source metadata after a successful fetch.
-// Implements Spec 7 section 4.3.
+// handleFetchSuccess updates source metadata after a successful fetch. A
+// successful re-probe enables the source again. Implements Spec 7 section
+// 4.3.
 func (ds *DiscoveryService) handleFetchSuccess(source sources.Source) {
 	now := time.Now().UTC()
 	zero := 0
 	var nilStr *string
 	update := sources.SourceUpdate{
-		LastFetchedAt:   &now,
-		FetchErrorCount: &zero,
-		LastError:       nilStr,
+		LastFetchedAt:    &now,
+		ClearNextFetchAt: true,
+		FetchErrorCount:  &zero,
+		LastError:        nilStr,
+	}
+
+	if isReprobe(source) {
+		log.Printf("INFO: Re-enabling source %s (%s) after a successful re-probe", source.Name, source.URL)
+		update.EnabledAt = &now
+		update.ClearReprobeAt = true
+		update.ReprobeCount = &zero
 	}
 
 	if err := ds.sourceStore.UpdateSource(source.SourceID, update); err != nil {
@@ -730,25 +1605,48 @@ func (ds *DiscoveryService) handleFetchError(source sources.Source, fetchErr err
 	}
 
 	if isPermanent {
-		// Permanent errors -- disable immediately (Spec 7 section 7.2)
+		// Permanent errors -- disable immediately, without re-probing (Spec
+		// 7 section 7.2)
 		log.Printf("ERROR: Disabling source %s (%s) due to permanent error: %v", source.Name, source.URL, fetchErr)
 		update.ClearEnabledAt = true
+		update.ClearReprobeAt = true
+		newCount := source.FetchErrorCount + 1
+		update.FetchErrorCount = &newCount
+	} else if isReprobe(source) {
+		// A failed re-probe waits out a longer cooldown before the next
 		newCount := source.FetchErrorCount + 1
 		update.FetchErrorCount = &newCount
+		reprobeCount := source.ReprobeCount + 1
+		update.ReprobeCount = &reprobeCount
+		reprobeAt := now.Add(reprobeCooldown(reprobeCount))
+		update.ReprobeAt = &reprobeAt
+		log.Printf("INFO: Re-probe of source %s (%s) failed; trying again at %s", source.Name, source.URL, reprobeAt.Format(time.RFC3339))
 	} else {
 		// Transient errors -- increment counter and check threshold (Spec 7
 		// section 7.1 and 7.3)
 		newErrorCount := source.FetchErrorCount + 1
 		update.FetchErrorCount = &newErrorCount
 
-		if newErrorCount >= ds.config.DisableThreshold {
-			log.Printf("ERROR: Auto-disabling source %s (%s) after %d consecutive failures", source.Name, source.URL, newErrorCount)
+		if newErrorCount >= ds.currentConfig().DisableThreshold {
+			// Transient failures may pass, so the source is tried again
+			// after a cooldown (Spec 7 section 7.3)
+			reprobeAt := now.Add(reprobeCooldown(0))
+			log.Printf("ERROR: Auto-disabling source %s (%s) after %d consecutive failures; re-probing at %s",
+				source.Name, source.URL, newErrorCount, reprobeAt.Format(time.RFC3339))
 			update.ClearEnabledAt = true
+			update.ReprobeAt = &reprobeAt
+			zero := 0
+			update.ReprobeCount = &zero
+		} else {
+			nextFetchAt := now.Add(ds.backoffDelay(source, newErrorCount))
+			update.NextFetchAt = &nextFetchAt
 		}
 	}
 
 	if err := ds.sourceStore.UpdateSource(source.SourceID, update); err != nil {
 		log.Printf("ERROR: Failed to update source metadata for %s: %v", source.Name, err)
+	} else if update.ClearEnabledAt {
+		ds.runHooks(HookEvent{Event: HookSourceDisabled, SourceID: source.SourceID, SourceName: source.Name, Error: errorMsg})
 	}
 
 	// Record error in history for troubleshooting (Spec 8 section 3.3.2)
@@ -757,56 +1655,93 @@ func (ds *DiscoveryService) handleFetchError(source sources.Source, fetchErr err
 	}
 }
 
+// backoffDelay returns how long to wait before retrying a source that has
+// failed errorCount consecutive times: 2^n times its polling interval, capped
+// at MaxBackoff.
+func (ds *DiscoveryService) backoffDelay(source sources.Source, errorCount int) time.Duration {
+	maxBackoff := ds.currentConfig().MaxBackoff
+	delay := ds.getPollingInterval(source)
+	for i := 0; i < errorCount; i++ {
+		if (maxBackoff > 0 && delay >= maxBackoff) || delay > math.MaxInt64/2 {
+			break
+		}
+		delay *= 2
+	}
+	if maxBackoff > 0 && delay > maxBackoff {
+		delay = maxBackoff
+	}
+	return delay
+}
+
 // isPermanentError determines if an error is permanent (requiring immediate
 // disable) or transient (retryable). Implements Spec 7 section 7.1 and 7.2.
+// Errors are classified by type, not message: an HTTP status says whether
+// the source is gone, and anything unrecognized is assumed transient.
 func (ds *DiscoveryService) isPermanentError(err error) bool {
 	if err == nil {
 		return false
 	}
 
-	errMsg := strings.ToLower(err.Error())
-
-	// HTTP 404 Not Found
-	if strings.Contains(errMsg, "404") || strings.Contains(errMsg, "not found") {
-		return true
+	// Certificate problems can be fixed with the source's TLS settings, so
+	// they are transient
+	if isCertificateError(err) {
+		return false
 	}
 
-	// HTTP 410 Gone
-	if strings.Contains(errMsg, "410") || strings.Contains(errMsg, "gone") {
-		return true
+	// A fetch that ran out of time or was cancelled says nothing about the
+	// source
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return false
 	}
 
-	// Invalid feed format
-	if strings.Contains(errMsg, "failed to parse") || strings.Contains(errMsg, "invalid feed") {
-		return true
+	// HTTP 401, 404, and 410 are permanent; 429 and 5xx are transient
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Permanent()
 	}
 
-	// Invalid HTML/XML
-	if strings.Contains(errMsg, "failed to parse html") || strings.Contains(errMsg, "invalid html") {
+	// A feed or page that arrived but can't be parsed
+	var parseErr *ParseError
+	if errors.As(err, &parseErr) {
 		return true
 	}
 
-	// DNS errors (domain doesn't exist)
-	if strings.Contains(errMsg, "no such host") {
+	// A URL that can't be fetched at all
+	if errors.Is(err, ErrInvalidURL) {
 		return true
 	}
 
-	// Invalid URL
-	if strings.Contains(errMsg, "invalid url") || strings.Contains(errMsg, "unsupported protocol") {
+	// The domain doesn't exist
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
 		return true
 	}
 
-	// Check for wrapped errors
-	var unwrapped error
-	if errors.Unwrap(err) != nil {
-		unwrapped = errors.Unwrap(err)
-		return ds.isPermanentError(unwrapped)
-	}
-
 	// Default to transient (network timeouts, temporary server errors, etc.)
 	return false
 }
 
+// isCertificateError reports whether err comes from verifying a server's TLS
+// certificate.
+func isCertificateError(err error) bool {
+	var verificationErr *tls.CertificateVerificationError
+	var unknownAuthorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+	return errors.As(err, &verificationErr) ||
+		errors.As(err, &unknownAuthorityErr) ||
+		errors.As(err, &hostnameErr) ||
+		errors.As(err, &invalidErr)
+}
+
+// equalStringPtr reports whether two optional strings hold the same value.
+func equalStringPtr(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
 // UpdateSourceFetchMetadata updates the fetch metadata for a source. This is
 // a helper function for external use.
 func (ds *DiscoveryService) UpdateSourceFetchMetadata(
@@ -826,12 +1761,91 @@ func (ds *DiscoveryService) UpdateSourceFetchMetadata(
 	return ds.sourceStore.UpdateSource(sourceID, update)
 }
 
+// SyncOptions selects what SyncSources fetches and whether it writes.
+type SyncOptions struct {
+	// SourceID limits the sync to one source, which is synced even if it is
+	// disabled. When nil, all enabled sources are synced.
+	SourceID *uuid.UUID
+	// DryRun fetches and parses sources without adding items to the feed or
+	// updating source metadata. What a real sync would do is reported in
+	// SyncResult.Planned.
+	DryRun bool
+}
+
 // SyncResult contains the results of a manual sync operation.
 type SyncResult struct {
 	SourcesSynced   int
 	SourcesFailed   int
-	ItemsDiscovered int
+	ItemsDiscovered int // In a dry run, the number of items that would be added
+	Duplicates      DedupReport
 	Errors          []SyncError
+	// BudgetsReached lists the sources whose fetch stopped at their fetch
+	// budget. They are counted as synced, with the items found before
+	// stopping.
+	BudgetsReached []SyncError
+	// Planned lists every item a dry run found. Items from one source are in
+	// the order they were found. It is empty for a real sync.
+	Planned []PlannedItem
+}
+
+// PlannedItem is an item found by a dry-run sync, together with whether a
+// real sync would add it or skip it as a duplicate. Items from list pages
+// that were skipped by URL before being scraped have only a URL.
+type PlannedItem struct {
+	Source    sources.Source
+	Item      newsfeed.NewsItem
+	Duplicate newsfeed.DuplicateKind // NotDuplicate if the item would be added
+}
+
+// dryRunPlan collects the items found by dry-run fetches. A nil plan means
+// the fetch is real, so recording into it does nothing.
+type dryRunPlan struct {
+	mu    sync.Mutex
+	items []PlannedItem
+}
+
+// record notes what would happen to item.
+func (p *dryRunPlan) record(source sources.Source, item newsfeed.NewsItem, kind newsfeed.DuplicateKind) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.items = append(p.items, PlannedItem{Source: source, Item: item, Duplicate: kind})
+}
+
+// DedupReport counts fetched items that were skipped because they duplicate
+// items already in the feed.
+type DedupReport struct {
+	GUIDDuplicates    int `json:"guid_duplicates"`    // Same source and feed GUID as an existing item
+	URLDuplicates     int `json:"url_duplicates"`     // Same canonical URL as an existing item
+	ContentDuplicates int `json:"content_duplicates"` // Different URL, same title and summary
+	Updated           int `json:"updated"`            // GUID or URL duplicates whose stored item was updated with the publisher's changes
+}
+
+// Total returns the number of skipped items.
+func (r DedupReport) Total() int {
+	return r.GUIDDuplicates + r.URLDuplicates + r.ContentDuplicates
+}
+
+// add adds the counts in other to r.
+func (r *DedupReport) add(other DedupReport) {
+	r.GUIDDuplicates += other.GUIDDuplicates
+	r.URLDuplicates += other.URLDuplicates
+	r.ContentDuplicates += other.ContentDuplicates
+	r.Updated += other.Updated
+}
+
+// record counts one duplicate of the given kind.
+func (r *DedupReport) record(kind newsfeed.DuplicateKind) {
+	switch kind {
+	case newsfeed.DuplicateGUID:
+		r.GUIDDuplicates++
+	case newsfeed.DuplicateURL:
+		r.URLDuplicates++
+	case newsfeed.DuplicateContent:
+		r.ContentDuplicates++
+	}
 }
 
 // SyncError contains details about a source sync failure.
@@ -844,6 +1858,7 @@ type SyncError struct {
 type ProgressStatus string
 
 const (
+	ProgressQueued   ProgressStatus = "queued"
 	ProgressFetching ProgressStatus = "fetching"
 	ProgressDone     ProgressStatus = "done"
 	ProgressError    ProgressStatus = "error"
@@ -857,27 +1872,55 @@ type SourceProgress struct {
 	NewItems int           // number of new items discovered (0 while fetching)
 	Error    error         // nil unless Status is ProgressError
 	Duration time.Duration // elapsed fetch time; 0 while fetching
+	Budget   *BudgetError  // non-nil if the fetch stopped at its fetch budget
 }
 
-// SyncSources performs a manual sync of sources. If sourceID is provided,
-// only that source is synced. Otherwise, all enabled sources are synced. This
+// SyncSources performs a manual sync of the sources selected by opts. This
 // is a synchronous operation that returns when all fetches complete.
 //
 // progressCh is an optional channel that receives per-source progress updates
-// as each fetch begins and completes. When progressCh is nil, SyncSources
+// as each source is queued and as its fetch begins and completes. Every
+// source is queued before any is fetched, so the queued updates give the
+// number of sources in the sync. When progressCh is nil, SyncSources
 // behaves exactly as it did before this parameter was added. When non-nil,
 // SyncSources closes the channel after all fetches complete.
-func (ds *DiscoveryService) SyncSources(ctx context.Context, sourceID *uuid.UUID, progressCh chan<- SourceProgress) (*SyncResult, error) {
+func (ds *DiscoveryService) SyncSources(ctx context.Context, opts SyncOptions, progressCh chan<- SourceProgress) (_ *SyncResult, err error) {
 	result := &SyncResult{
 		Errors: make([]SyncError, 0),
 	}
+
+	// Close the progress channel on every return, once all fetches are done,
+	// to signal that no more updates will arrive
+	if progressCh != nil {
+		defer close(progressCh)
+	}
+
+	ctx, span := tracer.Start(ctx, "discovery.sync_sources", trace.WithAttributes(
+		attribute.Bool("sync.dry_run", opts.DryRun),
+	))
+	defer func() {
+		span.SetAttributes(
+			attribute.Int("sources.synced", result.SourcesSynced),
+			attribute.Int("sources.failed", result.SourcesFailed),
+			attribute.Int("items.new", result.ItemsDiscovered),
+			attribute.Int("items.duplicate_guid", result.Duplicates.GUIDDuplicates),
+			attribute.Int("items.duplicate_url", result.Duplicates.URLDuplicates),
+			attribute.Int("items.duplicate_content", result.Duplicates.ContentDuplicates),
+		)
+		endSpan(span, err)
+	}()
 	var resultMu sync.Mutex
 
+	var plan *dryRunPlan
+	if opts.DryRun {
+		plan = &dryRunPlan{}
+	}
+
 	var sourceList []sources.Source
 
-	if sourceID != nil {
+	if opts.SourceID != nil {
 		// Sync single source
-		source, err := ds.sourceStore.GetSource(*sourceID)
+		source, err := ds.sourceStore.GetSource(*opts.SourceID)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get source: %w", err)
 		}
@@ -897,96 +1940,105 @@ func (ds *DiscoveryService) SyncSources(ctx context.Context, sourceID *uuid.UUID
 		}
 	}
 
+	span.SetAttributes(attribute.Int("sources.total", len(sourceList)))
 	if len(sourceList) == 0 {
 		return result, nil
 	}
-
-	// Use a concurrency limit (default to 5 concurrent fetches)
-	concurrency := 5
-	if ds.config.Concurrency > 0 {
-		concurrency = ds.config.Concurrency
+	if progressCh != nil {
+		for _, source := range sourceList {
+			progressCh <- SourceProgress{Source: source, Status: ProgressQueued}
+		}
 	}
-	semaphore := make(chan struct{}, concurrency)
 
 	// Fetch sources concurrently with WaitGroup
 	var wg sync.WaitGroup
+	var cancelled atomic.Bool
 
-	// The caller must size progressCh to at least 2 * len(sourceList) to
-	// prevent goroutines from blocking on sends and starving the semaphore.
+	// The caller must read progressCh as updates arrive, or size it to at
+	// least 3 * len(sourceList), to prevent goroutines from blocking on
+	// sends and starving the fetch queue.
 	for _, source := range sourceList {
-		select {
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		case semaphore <- struct{}{}: // Acquire semaphore
-			wg.Add(1)
-			go func(s sources.Source) {
-				defer wg.Done()
-				defer func() { <-semaphore }() // Release semaphore
-
-				// Signal that this source is now being fetched. Sent before
-				// any lock is acquired so the channel send never blocks while
-				// holding resultMu.
-				if progressCh != nil {
-					progressCh <- SourceProgress{Source: s, Status: ProgressFetching}
-				}
-
-				startTime := time.Now()
-
-				// Create context with timeout
-				fetchCtx, cancel := context.WithTimeout(ctx, ds.config.FetchTimeout)
-				defer cancel()
-
-				// Process based on source type
-				var newItemCount int
-				var fetchErr error
-
-				switch s.SourceType {
-				case "rss", "atom":
-					newItemCount, fetchErr = ds.fetchRSSFeed(fetchCtx, s)
-				case "website":
-					newItemCount, fetchErr = ds.fetchWebsite(fetchCtx, s)
-				default:
-					fetchErr = fmt.Errorf("unsupported source type: %s", s.SourceType)
-				}
-
-				duration := time.Since(startTime)
+		if ctx.Err() != nil {
+			cancelled.Store(true)
+			break
+		}
+		wg.Add(1)
+		go func(s sources.Source) {
+			defer wg.Done()
 
-				// Update source metadata and results (with mutex protection),
-				// then send the progress update outside the lock to avoid
-				// blocking the channel send while holding resultMu.
-				resultMu.Lock()
-				if fetchErr != nil {
-					ds.handleFetchError(s, fetchErr)
+			release, err := ds.acquireFetchSlot(ctx, s)
+			if err != nil {
+				cancelled.Store(true)
+				return
+			}
+			defer release()
+
+			// A real sync claims each source as the run loop does, so that
+			// it doesn't fetch a source this or another service is already
+			// fetching. A dry run changes nothing, so it needn't.
+			fetchCtx, fetched := ctx, s
+			if !opts.DryRun {
+				leaseCtx, claimed, releaseClaim, err := ds.claimForSync(ctx, s)
+				if err != nil {
+					resultMu.Lock()
 					result.SourcesFailed++
-					result.Errors = append(result.Errors, SyncError{
-						Source: s,
-						Error:  fetchErr,
-					})
-					log.Printf("ERROR: Failed to sync %s (%s): %v", s.Name, s.URL, fetchErr)
+					result.Errors = append(result.Errors, SyncError{Source: s, Error: err})
 					resultMu.Unlock()
+					log.Printf("ERROR: Failed to sync %s (%s): %v", s.Name, s.URL, err)
 					if progressCh != nil {
-						progressCh <- SourceProgress{Source: s, Status: ProgressError, Error: fetchErr}
-					}
-				} else {
-					// Success -- update metadata
-					ds.handleFetchSuccess(s)
-					result.SourcesSynced++
-					result.ItemsDiscovered += newItemCount
-					log.Printf("INFO: Synced %s (%s): %d new items in %v", s.Name, s.URL, newItemCount, duration)
-					resultMu.Unlock()
-					if progressCh != nil {
-						progressCh <- SourceProgress{Source: s, Status: ProgressDone, NewItems: newItemCount, Duration: duration}
+						progressCh <- SourceProgress{Source: s, Status: ProgressError, Error: err}
 					}
+					return
 				}
-			}(source)
-		}
+				defer releaseClaim()
+				fetchCtx, fetched = leaseCtx, *claimed
+			}
+
+			// Signal that this source is now being fetched. Sent before
+			// any lock is acquired so the channel send never blocks while
+			// holding resultMu.
+			if progressCh != nil {
+				progressCh <- SourceProgress{Source: s, Status: ProgressFetching}
+			}
+
+			report, fetchErr := ds.fetchSource(fetchCtx, fetched, plan)
+
+			// Record the results (with mutex protection), then send the
+			// progress update outside the lock to avoid blocking the
+			// channel send while holding resultMu.
+			resultMu.Lock()
+			result.Duplicates.add(report.dedup)
+			if fetchErr != nil {
+				result.SourcesFailed++
+				result.Errors = append(result.Errors, SyncError{
+					Source: s,
+					Error:  fetchErr,
+				})
+				log.Printf("ERROR: Failed to sync %s (%s): %v", s.Name, s.URL, fetchErr)
+				resultMu.Unlock()
+				if progressCh != nil {
+					progressCh <- SourceProgress{Source: s, Status: ProgressError, Error: fetchErr}
+				}
+			} else {
+				result.SourcesSynced++
+				result.ItemsDiscovered += report.newItems
+				if report.budget != nil {
+					result.BudgetsReached = append(result.BudgetsReached, SyncError{Source: s, Error: report.budget})
+				}
+				resultMu.Unlock()
+				if progressCh != nil {
+					progressCh <- SourceProgress{Source: s, Status: ProgressDone, NewItems: report.newItems, Duration: report.duration, Budget: report.budget}
+				}
+			}
+		}(source)
 	}
 
-	// Wait for all goroutines to complete, then close the progress channel to
-	// signal that no more updates will arrive.
 	wg.Wait()
-	if progressCh != nil {
-		close(progressCh)
+	if cancelled.Load() {
+		return nil, ctx.Err()
+	}
+	if plan != nil {
+		result.Planned = plan.items
 	}
 
 	return result, nil
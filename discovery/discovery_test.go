@@ -2,9 +2,14 @@ package discovery
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -12,24 +17,25 @@ import (
 	"github.com/google/uuid"
 	"github.com/pevans/newsfed/newsfeed"
 	"github.com/pevans/newsfed/sources"
+	"github.com/pevans/newsfed/sqldb/sqldbtest"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
+// Test helper: create a source store in an in-memory database, closed when
+// the test ends
+func newTestSourceStore(t *testing.T) *sources.SourceStore {
+	sourceStore, err := sources.NewSourceStore(sqldbtest.MemoryDSN(t))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = sourceStore.Close() })
+	return sourceStore
+}
+
 // TestDiscoveryService_filterDueSources verifies source scheduling logic per
 // Spec 7 section 3.2.
 func TestDiscoveryService_filterDueSources(t *testing.T) {
-	// Create temporary storage
-	tempDir := t.TempDir()
-	metadataPath := tempDir + "/metadata.db"
-	feedDir := tempDir + "/.news"
-
-	sourceStore, err := sources.NewSourceStore(metadataPath)
-	require.NoError(t, err)
-	defer func() { _ = sourceStore.Close() }()
-
-	newsFeed, err := newsfeed.NewNewsFeed(feedDir)
-	require.NoError(t, err)
+	sourceStore := newTestSourceStore(t)
+	newsFeed := newsfeed.NewMemoryNewsFeed()
 
 	config := DefaultDiscoveryConfig()
 	config.PollInterval = 1 * time.Hour
@@ -72,16 +78,8 @@ func TestDiscoveryService_filterDueSources(t *testing.T) {
 // TestDiscoveryService_getPollingInterval verifies polling interval logic per
 // Spec 7 section 3.1.
 func TestDiscoveryService_getPollingInterval(t *testing.T) {
-	tempDir := t.TempDir()
-	metadataPath := tempDir + "/metadata.db"
-	feedDir := tempDir + "/.news"
-
-	sourceStore, err := sources.NewSourceStore(metadataPath)
-	require.NoError(t, err)
-	defer func() { _ = sourceStore.Close() }()
-
-	newsFeed, err := newsfeed.NewNewsFeed(feedDir)
-	require.NoError(t, err)
+	sourceStore := newTestSourceStore(t)
+	newsFeed := newsfeed.NewMemoryNewsFeed()
 
 	config := DefaultDiscoveryConfig()
 	config.PollInterval = 1 * time.Hour
@@ -128,16 +126,8 @@ func TestDiscoveryService_getPollingInterval(t *testing.T) {
 // TestDiscoveryService_isSourceDue verifies due checking logic per Spec 7
 // section 3.2 and 3.3.
 func TestDiscoveryService_isSourceDue(t *testing.T) {
-	tempDir := t.TempDir()
-	metadataPath := tempDir + "/metadata.db"
-	feedDir := tempDir + "/.news"
-
-	sourceStore, err := sources.NewSourceStore(metadataPath)
-	require.NoError(t, err)
-	defer func() { _ = sourceStore.Close() }()
-
-	newsFeed, err := newsfeed.NewNewsFeed(feedDir)
-	require.NoError(t, err)
+	sourceStore := newTestSourceStore(t)
+	newsFeed := newsfeed.NewMemoryNewsFeed()
 
 	service := NewDiscoveryService(sourceStore, newsFeed, nil)
 
@@ -187,19 +177,79 @@ func TestDiscoveryService_isSourceDue(t *testing.T) {
 	}
 }
 
+// TestDiscoveryService_nextDueTime verifies that the scheduler finds the
+// earliest time an enabled source becomes due.
+func TestDiscoveryService_nextDueTime(t *testing.T) {
+	service := NewDiscoveryService(nil, nil, &DiscoveryConfig{PollInterval: time.Hour})
+
+	now := time.Now()
+	enabled := timePtr(now.Add(-24 * time.Hour))
+	sourceList := []sources.Source{
+		// Due in 40 minutes
+		{EnabledAt: enabled, LastFetchedAt: timePtr(now.Add(-20 * time.Minute))},
+		// Backing off for 10 minutes
+		{EnabledAt: enabled, LastFetchedAt: timePtr(now), NextFetchAt: timePtr(now.Add(10 * time.Minute))},
+		// Disabled sources are never due
+		{LastFetchedAt: timePtr(now.Add(-59 * time.Minute))},
+	}
+	assert.Equal(t, now.Add(10*time.Minute), service.nextDueTime(sourceList, now))
+
+	// A source due now is being fetched, so it is next due an interval later
+	dueNow := []sources.Source{{EnabledAt: enabled}}
+	assert.Equal(t, now.Add(time.Hour), service.nextDueTime(dueNow, now))
+
+	assert.True(t, service.nextDueTime(nil, now).IsZero())
+}
+
+// TestDiscoveryService_pollWindow verifies that a source due outside its
+// polling window waits for the window to open
+func TestDiscoveryService_pollWindow(t *testing.T) {
+	service := NewDiscoveryService(nil, nil, &DiscoveryConfig{PollInterval: time.Hour})
+
+	night := time.Date(2026, time.July, 1, 23, 0, 0, 0, time.UTC)
+	source := sources.Source{
+		EnabledAt:     timePtr(night.Add(-24 * time.Hour)),
+		LastFetchedAt: timePtr(night.Add(-2 * time.Hour)),
+		PollWindow:    &sources.PollWindow{Start: "06:00", End: "22:00", TimeZone: "UTC"},
+	}
+	morning := time.Date(2026, time.July, 2, 6, 0, 0, 0, time.UTC)
+
+	assert.False(t, service.shouldFetch(source, night))
+	assert.True(t, service.shouldFetch(source, morning))
+	assert.Equal(t, morning, service.nextDueTime([]sources.Source{source}, night))
+
+	// A source due later in the evening is next due in the morning too
+	source.LastFetchedAt = timePtr(night.Add(-90 * time.Minute))
+	assert.Equal(t, morning, service.nextDueTime([]sources.Source{source}, night.Add(-time.Hour)))
+
+	// Re-probes wait for the window as well
+	reprobe := sources.Source{ReprobeAt: timePtr(night), PollWindow: source.PollWindow}
+	assert.False(t, service.shouldFetch(reprobe, night))
+	assert.Equal(t, morning, service.nextDueTime([]sources.Source{reprobe}, night))
+}
+
+// TestDiscoveryService_nextCheckDelay verifies that the scheduler waits until
+// the next source is due, bounded by the check interval.
+func TestDiscoveryService_nextCheckDelay(t *testing.T) {
+	service := NewDiscoveryService(nil, nil, &DiscoveryConfig{CheckInterval: 10 * time.Minute})
+
+	delay := service.nextCheckDelay(time.Now().Add(2 * time.Minute))
+	assert.InDelta(t, float64(2*time.Minute), float64(delay), float64(time.Second))
+
+	assert.Equal(t, 10*time.Minute, service.nextCheckDelay(time.Now().Add(time.Hour)))
+	assert.Equal(t, 10*time.Minute, service.nextCheckDelay(time.Time{}))
+	assert.Equal(t, minCheckDelay, service.nextCheckDelay(time.Now().Add(-time.Minute)))
+
+	// An unset check interval uses the default
+	service = NewDiscoveryService(nil, nil, &DiscoveryConfig{})
+	assert.Equal(t, 5*time.Minute, service.nextCheckDelay(time.Time{}))
+}
+
 // TestDiscoveryService_handleFetchError verifies error handling per Spec 7
 // section 7.
 func TestDiscoveryService_handleFetchError(t *testing.T) {
-	tempDir := t.TempDir()
-	metadataPath := tempDir + "/metadata.db"
-	feedDir := tempDir + "/.news"
-
-	sourceStore, err := sources.NewSourceStore(metadataPath)
-	require.NoError(t, err)
-	defer func() { _ = sourceStore.Close() }()
-
-	newsFeed, err := newsfeed.NewNewsFeed(feedDir)
-	require.NoError(t, err)
+	sourceStore := newTestSourceStore(t)
+	newsFeed := newsfeed.NewMemoryNewsFeed()
 
 	config := DefaultDiscoveryConfig()
 	config.DisableThreshold = 3
@@ -235,19 +285,58 @@ func TestDiscoveryService_handleFetchError(t *testing.T) {
 	assert.Nil(t, updated.EnabledAt, "source should be disabled after reaching threshold")
 }
 
-// TestDiscoveryService_handleFetchSuccess verifies success handling per Spec
-// 7 section 4.3.
-func TestDiscoveryService_handleFetchSuccess(t *testing.T) {
-	tempDir := t.TempDir()
-	metadataPath := tempDir + "/metadata.db"
-	feedDir := tempDir + "/.news"
+// TestDiscoveryService_backoffDelay verifies the delay doubles with each
+// consecutive failure and is capped at MaxBackoff.
+func TestDiscoveryService_backoffDelay(t *testing.T) {
+	config := DefaultDiscoveryConfig()
+	config.PollInterval = 30 * time.Minute
+	config.MaxBackoff = 6 * time.Hour
+	service := NewDiscoveryService(nil, nil, config)
+
+	source := sources.Source{}
+	assert.Equal(t, 1*time.Hour, service.backoffDelay(source, 1))
+	assert.Equal(t, 2*time.Hour, service.backoffDelay(source, 2))
+	assert.Equal(t, 4*time.Hour, service.backoffDelay(source, 3))
+	assert.Equal(t, 6*time.Hour, service.backoffDelay(source, 4))
+	assert.Equal(t, 6*time.Hour, service.backoffDelay(source, 100))
+}
+
+// TestDiscoveryService_Backoff verifies transient failures schedule the next
+// fetch and a successful fetch clears the schedule.
+func TestDiscoveryService_Backoff(t *testing.T) {
+	sourceStore := newTestSourceStore(t)
+	newsFeed := newsfeed.NewMemoryNewsFeed()
 
-	sourceStore, err := sources.NewSourceStore(metadataPath)
+	config := DefaultDiscoveryConfig()
+	config.PollInterval = 1 * time.Hour
+	service := NewDiscoveryService(sourceStore, newsFeed, config)
+
+	now := time.Now()
+	source, err := sourceStore.CreateSource("rss", "http://example.com/feed", "Test Feed", nil, &now)
 	require.NoError(t, err)
-	defer func() { _ = sourceStore.Close() }()
 
-	newsFeed, err := newsfeed.NewNewsFeed(feedDir)
+	service.handleFetchError(*source, assert.AnError)
+	updated, err := sourceStore.GetSource(source.SourceID)
 	require.NoError(t, err)
+	require.NotNil(t, updated.NextFetchAt)
+	assert.WithinDuration(t, updated.LastFetchedAt.Add(2*time.Hour), *updated.NextFetchAt, time.Second)
+
+	// Backing off -- not due even though the polling interval has elapsed
+	assert.False(t, service.isSourceDue(*updated, time.Hour, now.Add(90*time.Minute)))
+	assert.True(t, service.isSourceDue(*updated, time.Hour, now.Add(3*time.Hour)))
+
+	service.handleFetchSuccess(*updated)
+	updated, err = sourceStore.GetSource(source.SourceID)
+	require.NoError(t, err)
+	assert.Nil(t, updated.NextFetchAt)
+	assert.Equal(t, 0, updated.FetchErrorCount)
+}
+
+// TestDiscoveryService_handleFetchSuccess verifies success handling per Spec
+// 7 section 4.3.
+func TestDiscoveryService_handleFetchSuccess(t *testing.T) {
+	sourceStore := newTestSourceStore(t)
+	newsFeed := newsfeed.NewMemoryNewsFeed()
 
 	service := NewDiscoveryService(sourceStore, newsFeed, nil)
 
@@ -285,16 +374,8 @@ func TestDiscoveryService_Run_StartupBehavior(t *testing.T) {
 		t.Skip("Skipping integration test in CI")
 	}
 
-	tempDir := t.TempDir()
-	metadataPath := tempDir + "/metadata.db"
-	feedDir := tempDir + "/.news"
-
-	sourceStore, err := sources.NewSourceStore(metadataPath)
-	require.NoError(t, err)
-	defer func() { _ = sourceStore.Close() }()
-
-	newsFeed, err := newsfeed.NewNewsFeed(feedDir)
-	require.NoError(t, err)
+	sourceStore := newTestSourceStore(t)
+	newsFeed := newsfeed.NewMemoryNewsFeed()
 
 	config := DefaultDiscoveryConfig()
 	config.PollInterval = 1 * time.Hour
@@ -302,7 +383,7 @@ func TestDiscoveryService_Run_StartupBehavior(t *testing.T) {
 
 	// Create enabled source that has never been fetched
 	now := time.Now()
-	_, err = sourceStore.CreateSource("rss", "http://example.com/feed", "Test Feed", nil, &now)
+	_, err := sourceStore.CreateSource("rss", "http://example.com/feed", "Test Feed", nil, &now)
 	require.NoError(t, err)
 
 	// Start service with short timeout
@@ -321,84 +402,92 @@ func TestDiscoveryService_Run_StartupBehavior(t *testing.T) {
 // TestDiscoveryService_isPermanentError verifies permanent vs transient error
 // detection per Spec 7 section 7.1 and 7.2.
 func TestDiscoveryService_isPermanentError(t *testing.T) {
-	tempDir := t.TempDir()
-	metadataPath := tempDir + "/metadata.db"
-	feedDir := tempDir + "/.news"
-
-	sourceStore, err := sources.NewSourceStore(metadataPath)
-	require.NoError(t, err)
-	defer func() { _ = sourceStore.Close() }()
-
-	newsFeed, err := newsfeed.NewNewsFeed(feedDir)
-	require.NoError(t, err)
+	sourceStore := newTestSourceStore(t)
+	newsFeed := newsfeed.NewMemoryNewsFeed()
 
 	service := NewDiscoveryService(sourceStore, newsFeed, nil)
 
 	tests := []struct {
 		name        string
-		errorMsg    string
+		err         error
 		isPermanent bool
 	}{
 		{
 			name:        "404 not found",
-			errorMsg:    "HTTP error: 404 Not Found",
+			err:         &HTTPStatusError{StatusCode: 404, Status: "404 Not Found"},
 			isPermanent: true,
 		},
 		{
 			name:        "410 gone",
-			errorMsg:    "HTTP error: 410 Gone",
+			err:         &HTTPStatusError{StatusCode: 410, Status: "410 Gone"},
+			isPermanent: true,
+		},
+		{
+			name:        "401 unauthorized",
+			err:         &HTTPStatusError{StatusCode: 401, Status: "401 Unauthorized"},
 			isPermanent: true,
 		},
 		{
 			name:        "invalid feed format",
-			errorMsg:    "failed to parse feed: invalid XML",
+			err:         &ParseError{Format: "feed", Err: errors.New("invalid XML")},
+			isPermanent: true,
+		},
+		{
+			name:        "invalid URL",
+			err:         fmt.Errorf("%w %q: scheme must be http or https", ErrInvalidURL, "ftp://example.com"),
 			isPermanent: true,
 		},
 		{
 			name:        "no such host",
-			errorMsg:    "dial tcp: lookup example.invalid: no such host",
+			err:         &net.DNSError{Err: "no such host", Name: "example.invalid", IsNotFound: true},
 			isPermanent: true,
 		},
 		{
 			name:        "timeout (transient)",
-			errorMsg:    "context deadline exceeded",
+			err:         context.DeadlineExceeded,
 			isPermanent: false,
 		},
 		{
 			name:        "500 server error (transient)",
-			errorMsg:    "HTTP error: 500 Internal Server Error",
+			err:         &HTTPStatusError{StatusCode: 500, Status: "500 Internal Server Error"},
+			isPermanent: false,
+		},
+		{
+			name:        "429 too many requests (transient)",
+			err:         &HTTPStatusError{StatusCode: 429, Status: "429 Too Many Requests"},
 			isPermanent: false,
 		},
 		{
 			name:        "connection refused (transient)",
-			errorMsg:    "dial tcp: connection refused",
+			err:         errors.New("dial tcp: connection refused"),
+			isPermanent: false,
+		},
+		{
+			// Classification no longer depends on the message text
+			name:        "message mentioning 404 (transient)",
+			err:         errors.New("HTTP error: 404 Not Found"),
 			isPermanent: false,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Wrap error with message
-			err := fmt.Errorf("%s", tt.errorMsg)
+			// Wrap the error as the fetch path does
+			err := fmt.Errorf("failed to fetch feed: %w", tt.err)
 			isPermanent := service.isPermanentError(err)
 			assert.Equal(t, tt.isPermanent, isPermanent)
 		})
 	}
+
+	// A deadline is transient even while parsing
+	assert.False(t, service.isPermanentError(&ParseError{Format: "feed", Err: context.DeadlineExceeded}))
 }
 
 // TestDiscoveryService_handleFetchError_PermanentError verifies that
 // permanent errors immediately disable the source per Spec 7 section 7.2.
 func TestDiscoveryService_handleFetchError_PermanentError(t *testing.T) {
-	tempDir := t.TempDir()
-	metadataPath := tempDir + "/metadata.db"
-	feedDir := tempDir + "/.news"
-
-	sourceStore, err := sources.NewSourceStore(metadataPath)
-	require.NoError(t, err)
-	defer func() { _ = sourceStore.Close() }()
-
-	newsFeed, err := newsfeed.NewNewsFeed(feedDir)
-	require.NoError(t, err)
+	sourceStore := newTestSourceStore(t)
+	newsFeed := newsfeed.NewMemoryNewsFeed()
 
 	service := NewDiscoveryService(sourceStore, newsFeed, nil)
 
@@ -408,7 +497,7 @@ func TestDiscoveryService_handleFetchError_PermanentError(t *testing.T) {
 	require.NoError(t, err)
 
 	// Simulate permanent error (404)
-	permanentErr := fmt.Errorf("HTTP error: 404 Not Found")
+	permanentErr := &HTTPStatusError{URL: source.URL, StatusCode: 404, Status: "404 Not Found"}
 	service.handleFetchError(*source, permanentErr)
 
 	// Verify source was disabled immediately
@@ -421,42 +510,138 @@ func TestDiscoveryService_handleFetchError_PermanentError(t *testing.T) {
 // TestDiscoveryService_domainRateLimiter verifies rate limiting per Spec 7
 // section 8.2.
 func TestDiscoveryService_domainRateLimiter(t *testing.T) {
-	limiter := newDomainRateLimiter(100 * time.Millisecond)
+	limiter := newDomainRateLimiter(&DiscoveryConfig{RateLimitInterval: 100 * time.Millisecond})
 
+	ctx := context.Background()
 	domain := "example.com"
 
 	// First request should be immediate
 	start := time.Now()
-	limiter.wait(domain)
+	require.NoError(t, limiter.wait(ctx, domain))
 	elapsed := time.Since(start)
 	assert.Less(t, elapsed, 50*time.Millisecond, "first request should be immediate")
 
 	// Second request should be rate limited
 	start = time.Now()
-	limiter.wait(domain)
+	require.NoError(t, limiter.wait(ctx, domain))
 	elapsed = time.Since(start)
 	assert.GreaterOrEqual(t, elapsed, 100*time.Millisecond, "second request should wait at least 100ms")
 
 	// Request to different domain should be immediate
 	start = time.Now()
-	limiter.wait("other.com")
+	require.NoError(t, limiter.wait(ctx, "other.com"))
 	elapsed = time.Since(start)
 	assert.Less(t, elapsed, 50*time.Millisecond, "request to different domain should be immediate")
 }
 
-// TestDiscoveryService_extractDomain verifies domain extraction for rate
-// limiting.
-func TestDiscoveryService_extractDomain(t *testing.T) {
-	tempDir := t.TempDir()
-	metadataPath := tempDir + "/metadata.db"
-	feedDir := tempDir + "/.news"
+// TestDiscoveryService_domainRateLimiterJitter verifies that jitter spaces
+// requests by the minimum interval plus at most the jitter, and that a
+// request waiting on one domain doesn't hold up another.
+func TestDiscoveryService_domainRateLimiterJitter(t *testing.T) {
+	limiter := newDomainRateLimiter(&DiscoveryConfig{
+		RateLimitInterval: 50 * time.Millisecond,
+		RateLimitJitter:   50 * time.Millisecond,
+	})
+	ctx := context.Background()
+
+	require.NoError(t, limiter.wait(ctx, "example.com"))
+	next := limiter.nextRequest["example.com"]
+	gap := time.Until(next)
+	assert.Greater(t, gap, 40*time.Millisecond)
+	assert.LessOrEqual(t, gap, 100*time.Millisecond)
+
+	done := make(chan struct{})
+	go func() {
+		_ = limiter.wait(ctx, "example.com")
+		close(done)
+	}()
 
-	sourceStore, err := sources.NewSourceStore(metadataPath)
+	start := time.Now()
+	require.NoError(t, limiter.wait(ctx, "other.com"))
+	assert.Less(t, time.Since(start), 30*time.Millisecond, "other domains should not wait")
+	<-done
+
+	// A cancelled wait returns promptly
+	require.NoError(t, limiter.wait(ctx, "slow.com"))
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.ErrorIs(t, limiter.wait(cancelled, "slow.com"), context.Canceled)
+}
+
+// TestDiscoveryService_domainRateLimiterSlots verifies that no more than
+// MaxPerDomain fetches of one domain run at once.
+func TestDiscoveryService_domainRateLimiterSlots(t *testing.T) {
+	limiter := newDomainRateLimiter(&DiscoveryConfig{MaxPerDomain: 2})
+	ctx := context.Background()
+
+	release1, err := limiter.acquire(ctx, "example.com")
+	require.NoError(t, err)
+	release2, err := limiter.acquire(ctx, "example.com")
+	require.NoError(t, err)
+
+	// Other domains have their own slots
+	releaseOther, err := limiter.acquire(ctx, "other.com")
+	require.NoError(t, err)
+	releaseOther()
+
+	// A third fetch of the same domain waits for a slot
+	timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	_, err = limiter.acquire(timeout, "example.com")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	release1()
+	release3, err := limiter.acquire(ctx, "example.com")
 	require.NoError(t, err)
-	defer func() { _ = sourceStore.Close() }()
+	release2()
+	release3()
+}
+
+// TestDiscoveryService_SyncSources_SerializesDomain verifies that sources on
+// the same host are fetched one at a time by default while other hosts
+// proceed.
+func TestDiscoveryService_SyncSources_SerializesDomain(t *testing.T) {
+	var active, maxActive atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := active.Add(1)
+		defer active.Add(-1)
+		for {
+			m := maxActive.Load()
+			if n <= m || maxActive.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/rss+xml")
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><rss version="2.0"><channel><title>t</title></channel></rss>`))
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	sourceStore := newTestSourceStore(t)
+	feed := newsfeed.NewMemoryNewsFeed()
+
+	now := time.Now()
+	for i := range 4 {
+		_, err := sourceStore.CreateSource("rss", fmt.Sprintf("%s/feed%d.xml", server.URL, i), fmt.Sprintf("Feed %d", i), nil, &now)
+		require.NoError(t, err)
+	}
+
+	config := DefaultDiscoveryConfig()
+	config.RateLimitInterval = 0
+	service := NewDiscoveryService(sourceStore, feed, config)
 
-	newsFeed, err := newsfeed.NewNewsFeed(feedDir)
+	result, err := service.SyncSources(context.Background(), SyncOptions{}, nil)
 	require.NoError(t, err)
+	assert.Equal(t, 4, result.SourcesSynced)
+	assert.Equal(t, int32(1), maxActive.Load(), "fetches of one host should not overlap")
+}
+
+// TestDiscoveryService_extractDomain verifies domain extraction for rate
+// limiting.
+func TestDiscoveryService_extractDomain(t *testing.T) {
+	sourceStore := newTestSourceStore(t)
+	newsFeed := newsfeed.NewMemoryNewsFeed()
 
 	service := NewDiscoveryService(sourceStore, newsFeed, nil)
 
@@ -493,16 +678,8 @@ func TestDiscoveryService_extractDomain(t *testing.T) {
 
 // TestDiscoveryService_resolveURL verifies URL resolution for relative links.
 func TestDiscoveryService_resolveURL(t *testing.T) {
-	tempDir := t.TempDir()
-	metadataPath := tempDir + "/metadata.db"
-	feedDir := tempDir + "/.news"
-
-	sourceStore, err := sources.NewSourceStore(metadataPath)
-	require.NoError(t, err)
-	defer func() { _ = sourceStore.Close() }()
-
-	newsFeed, err := newsfeed.NewNewsFeed(feedDir)
-	require.NoError(t, err)
+	sourceStore := newTestSourceStore(t)
+	newsFeed := newsfeed.NewMemoryNewsFeed()
 
 	service := NewDiscoveryService(sourceStore, newsFeed, nil)
 
@@ -550,16 +727,8 @@ func TestDiscoveryService_resolveURL(t *testing.T) {
 // TestDiscoveryService_extractArticleURLs verifies article link extraction
 // from list pages per Spec 7 section 5.1.2.
 func TestDiscoveryService_extractArticleURLs(t *testing.T) {
-	tempDir := t.TempDir()
-	metadataPath := tempDir + "/metadata.db"
-	feedDir := tempDir + "/.news"
-
-	sourceStore, err := sources.NewSourceStore(metadataPath)
-	require.NoError(t, err)
-	defer func() { _ = sourceStore.Close() }()
-
-	newsFeed, err := newsfeed.NewNewsFeed(feedDir)
-	require.NoError(t, err)
+	sourceStore := newTestSourceStore(t)
+	newsFeed := newsfeed.NewMemoryNewsFeed()
 
 	service := NewDiscoveryService(sourceStore, newsFeed, nil)
 
@@ -659,16 +828,8 @@ func TestDiscoveryService_extractArticleURLs(t *testing.T) {
 // TestDiscoveryService_extractNextPageURL verifies pagination link extraction
 // per Spec 7 section 5.1.2.
 func TestDiscoveryService_extractNextPageURL(t *testing.T) {
-	tempDir := t.TempDir()
-	metadataPath := tempDir + "/metadata.db"
-	feedDir := tempDir + "/.news"
-
-	sourceStore, err := sources.NewSourceStore(metadataPath)
-	require.NoError(t, err)
-	defer func() { _ = sourceStore.Close() }()
-
-	newsFeed, err := newsfeed.NewNewsFeed(feedDir)
-	require.NoError(t, err)
+	sourceStore := newTestSourceStore(t)
+	newsFeed := newsfeed.NewMemoryNewsFeed()
 
 	service := NewDiscoveryService(sourceStore, newsFeed, nil)
 
@@ -748,16 +909,8 @@ func TestDiscoveryService_extractNextPageURL(t *testing.T) {
 // TestDiscoveryService_fetchWebsite_InvalidConfig verifies error handling for
 // invalid scraper configurations.
 func TestDiscoveryService_fetchWebsite_InvalidConfig(t *testing.T) {
-	tempDir := t.TempDir()
-	metadataPath := tempDir + "/metadata.db"
-	feedDir := tempDir + "/.news"
-
-	sourceStore, err := sources.NewSourceStore(metadataPath)
-	require.NoError(t, err)
-	defer func() { _ = sourceStore.Close() }()
-
-	newsFeed, err := newsfeed.NewNewsFeed(feedDir)
-	require.NoError(t, err)
+	sourceStore := newTestSourceStore(t)
+	newsFeed := newsfeed.NewMemoryNewsFeed()
 
 	service := NewDiscoveryService(sourceStore, newsFeed, nil)
 
@@ -803,7 +956,7 @@ func TestDiscoveryService_fetchWebsite_InvalidConfig(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			ctx := context.Background()
-			_, err := service.fetchWebsite(ctx, tt.source)
+			_, _, err := service.fetchWebsite(ctx, tt.source, nil)
 			require.Error(t, err)
 			assert.Contains(t, err.Error(), tt.expectedError)
 		})
@@ -817,16 +970,7 @@ func TestDiscoveryService_Deduplication_WebScraping(t *testing.T) {
 		t.Skip("Skipping integration test in CI")
 	}
 
-	tempDir := t.TempDir()
-	metadataPath := tempDir + "/metadata.db"
-	feedDir := tempDir + "/.news"
-
-	sourceStore, err := sources.NewSourceStore(metadataPath)
-	require.NoError(t, err)
-	defer func() { _ = sourceStore.Close() }()
-
-	newsFeed, err := newsfeed.NewNewsFeed(feedDir)
-	require.NoError(t, err)
+	newsFeed := newsfeed.NewMemoryNewsFeed()
 
 	// Add an existing item to the feed
 	existingItem := newsfeed.NewsItem{
@@ -837,7 +981,7 @@ func TestDiscoveryService_Deduplication_WebScraping(t *testing.T) {
 		DiscoveredAt: time.Now(),
 		PublishedAt:  time.Now(),
 	}
-	err = newsFeed.Add(existingItem)
+	err := newsFeed.Add(existingItem)
 	require.NoError(t, err)
 
 	// Verify URL exists
@@ -903,16 +1047,8 @@ func TestDiscoveryMetrics_DurationLimit(t *testing.T) {
 
 // TestDiscoveryService_GetMetrics verifies metrics can be retrieved.
 func TestDiscoveryService_GetMetrics(t *testing.T) {
-	tempDir := t.TempDir()
-	metadataPath := tempDir + "/metadata.db"
-	feedDir := tempDir + "/.news"
-
-	sourceStore, err := sources.NewSourceStore(metadataPath)
-	require.NoError(t, err)
-	defer func() { _ = sourceStore.Close() }()
-
-	newsFeed, err := newsfeed.NewNewsFeed(feedDir)
-	require.NoError(t, err)
+	sourceStore := newTestSourceStore(t)
+	newsFeed := newsfeed.NewMemoryNewsFeed()
 
 	service := NewDiscoveryService(sourceStore, newsFeed, nil)
 
@@ -931,17 +1067,8 @@ func TestDiscoveryService_GetMetrics(t *testing.T) {
 // TestDiscoveryService_shouldApplyItemLimit verifies the conditional 20-item
 // limit logic per Spec 2 section 2.2.3 and Spec 3 section 3.1.1.
 func TestDiscoveryService_shouldApplyItemLimit(t *testing.T) {
-	// Create temporary storage
-	tempDir := t.TempDir()
-	metadataPath := tempDir + "/metadata.db"
-	feedDir := tempDir + "/.news"
-
-	sourceStore, err := sources.NewSourceStore(metadataPath)
-	require.NoError(t, err)
-	defer func() { _ = sourceStore.Close() }()
-
-	newsFeed, err := newsfeed.NewNewsFeed(feedDir)
-	require.NoError(t, err)
+	sourceStore := newTestSourceStore(t)
+	newsFeed := newsfeed.NewMemoryNewsFeed()
 
 	config := DefaultDiscoveryConfig()
 	service := NewDiscoveryService(sourceStore, newsFeed, config)
@@ -997,17 +1124,12 @@ func TestDiscoveryService_shouldApplyItemLimit(t *testing.T) {
 	}
 }
 
-// TestSyncSources_progressChannel verifies that SyncSources sends "fetching"
-// and "error"/"done" progress messages and closes the channel when finished.
+// TestSyncSources_progressChannel verifies that SyncSources sends "queued",
+// "fetching", and "error"/"done" progress messages and closes the channel when finished.
 // Implements Spec 11 section 4.
 func TestSyncSources_progressChannel(t *testing.T) {
-	tempDir := t.TempDir()
-	sourceStore, err := sources.NewSourceStore(tempDir + "/metadata.db")
-	require.NoError(t, err)
-	defer func() { _ = sourceStore.Close() }()
-
-	newsFeed, err := newsfeed.NewNewsFeed(tempDir + "/.news")
-	require.NoError(t, err)
+	sourceStore := newTestSourceStore(t)
+	newsFeed := newsfeed.NewMemoryNewsFeed()
 
 	config := DefaultDiscoveryConfig()
 	config.FetchTimeout = 2 * time.Second
@@ -1015,13 +1137,13 @@ func TestSyncSources_progressChannel(t *testing.T) {
 
 	// Create a source with an unreachable URL so the fetch fails quickly.
 	now := time.Now()
-	_, err = sourceStore.CreateSource("rss", "http://127.0.0.1:1/nonexistent", "Test Source", nil, &now)
+	_, err := sourceStore.CreateSource("rss", "http://127.0.0.1:1/nonexistent", "Test Source", nil, &now)
 	require.NoError(t, err)
 
 	progressCh := make(chan SourceProgress, 10)
 	ctx := context.Background()
 
-	result, err := svc.SyncSources(ctx, nil, progressCh)
+	result, err := svc.SyncSources(ctx, SyncOptions{}, progressCh)
 	require.NoError(t, err)
 	assert.Equal(t, 1, result.SourcesFailed)
 
@@ -1031,39 +1153,257 @@ func TestSyncSources_progressChannel(t *testing.T) {
 		messages = append(messages, msg)
 	}
 
-	// Expect exactly two messages per source: fetching then error.
-	require.Len(t, messages, 2)
-	assert.Equal(t, ProgressFetching, messages[0].Status)
-	assert.Equal(t, ProgressError, messages[1].Status)
-	assert.NotNil(t, messages[1].Error)
+	// Expect exactly three messages per source: queued, fetching, then
+	// error.
+	require.Len(t, messages, 3)
+	assert.Equal(t, ProgressQueued, messages[0].Status)
+	assert.Equal(t, ProgressFetching, messages[1].Status)
+	assert.Equal(t, ProgressError, messages[2].Status)
+	assert.NotNil(t, messages[2].Error)
+}
+
+// TestSyncSources_progressChannelClosedOnError verifies that the progress
+// channel is closed even when the sync fails before fetching anything
+func TestSyncSources_progressChannelClosedOnError(t *testing.T) {
+	sourceStore := newTestSourceStore(t)
+	newsFeed := newsfeed.NewMemoryNewsFeed()
+
+	svc := NewDiscoveryService(sourceStore, newsFeed, nil)
+
+	progressCh := make(chan SourceProgress, 1)
+	missing := uuid.New()
+	_, err := svc.SyncSources(context.Background(), SyncOptions{SourceID: &missing}, progressCh)
+	require.Error(t, err)
+
+	_, open := <-progressCh
+	assert.False(t, open)
 }
 
 // TestSyncSources_progressChannelNil verifies that passing a nil progress
 // channel leaves SyncSources behaviour unchanged.
 func TestSyncSources_progressChannelNil(t *testing.T) {
-	tempDir := t.TempDir()
-	sourceStore, err := sources.NewSourceStore(tempDir + "/metadata.db")
-	require.NoError(t, err)
-	defer func() { _ = sourceStore.Close() }()
-
-	newsFeed, err := newsfeed.NewNewsFeed(tempDir + "/.news")
-	require.NoError(t, err)
+	sourceStore := newTestSourceStore(t)
+	newsFeed := newsfeed.NewMemoryNewsFeed()
 
 	config := DefaultDiscoveryConfig()
 	config.FetchTimeout = 2 * time.Second
 	svc := NewDiscoveryService(sourceStore, newsFeed, config)
 
 	now := time.Now()
-	_, err = sourceStore.CreateSource("rss", "http://127.0.0.1:1/nonexistent", "Test Source", nil, &now)
+	_, err := sourceStore.CreateSource("rss", "http://127.0.0.1:1/nonexistent", "Test Source", nil, &now)
 	require.NoError(t, err)
 
 	// nil channel must not panic and must still return results.
-	result, err := svc.SyncSources(context.Background(), nil, nil)
+	result, err := svc.SyncSources(context.Background(), SyncOptions{}, nil)
 	require.NoError(t, err)
 	assert.Equal(t, 1, result.SourcesFailed)
 }
 
 // Helper functions
+// TestDiscoveryService_fetchRSSFeed_ConditionalGet verifies that cache
+// validators are persisted after a fetch and sent on the next one, and that a
+// 304 response adds no items.
+func TestDiscoveryService_fetchRSSFeed_ConditionalGet(t *testing.T) {
+	const etag = `"v1"`
+	const lastModified = "Mon, 02 Jan 2006 15:04:05 GMT"
+	var conditionalRequests int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == etag && r.Header.Get("If-Modified-Since") == lastModified {
+			conditionalRequests++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", lastModified)
+		_, _ = fmt.Fprint(w, `<?xml version="1.0"?>
+<rss version="2.0"><channel><title>Test</title>
+<item><title>One</title><link>http://example.com/1</link></item>
+</channel></rss>`)
+	}))
+	defer server.Close()
+
+	sourceStore := newTestSourceStore(t)
+	newsFeed := newsfeed.NewMemoryNewsFeed()
+
+	service := NewDiscoveryService(sourceStore, newsFeed, nil)
+	source, err := sourceStore.CreateSource("rss", server.URL, "Test Feed", nil, nil)
+	require.NoError(t, err)
+
+	count, _, err := service.fetchRSSFeed(context.Background(), *source, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	updated, err := sourceStore.GetSource(source.SourceID)
+	require.NoError(t, err)
+	require.NotNil(t, updated.ETag)
+	require.NotNil(t, updated.LastModified)
+	assert.Equal(t, etag, *updated.ETag)
+	assert.Equal(t, lastModified, *updated.LastModified)
+
+	count, _, err = service.fetchRSSFeed(context.Background(), *updated, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+	assert.Equal(t, 1, conditionalRequests, "second fetch should be conditional")
+}
+
+// TestDiscoveryService_fetchRSSFeed_InterruptedKeepsValidators verifies
+// that cache validators aren't saved when a fetch stops before all of its
+// items are in the feed, so that the next fetch isn't answered with a 304
+// and gets the rest of them.
+func TestDiscoveryService_fetchRSSFeed_InterruptedKeepsValidators(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") != "" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = fmt.Fprint(w, `<?xml version="1.0"?>
+<rss version="2.0"><channel><title>Test</title>
+<item><title>One</title><link>http://example.com/1</link></item>
+<item><title>Two</title><link>http://example.com/2</link></item>
+</channel></rss>`)
+	}))
+	defer server.Close()
+
+	sourceStore := newTestSourceStore(t)
+
+	service := NewDiscoveryService(sourceStore, newsfeed.NewMemoryNewsFeed(), nil)
+	source, err := sourceStore.CreateSource("rss", server.URL, "Test Feed", nil, nil)
+	require.NoError(t, err)
+
+	// Shut down once the first item is enriched
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	service.AddEnricher(EnricherFunc(func(ctx context.Context, item *newsfeed.NewsItem) error {
+		cancel()
+		return nil
+	}))
+
+	count, _, err := service.fetchRSSFeed(ctx, *source, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, count)
+
+	updated, err := sourceStore.GetSource(source.SourceID)
+	require.NoError(t, err)
+	assert.Nil(t, updated.ETag, "validators aren't saved until every item is in")
+}
+
+// TestDiscoveryService_fetchRSSFeed_Category verifies that discovered items
+// take the category of their source.
+func TestDiscoveryService_fetchRSSFeed_Category(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `<?xml version="1.0"?>
+<rss version="2.0"><channel><title>Test</title>
+<item><title>One</title><link>http://example.com/1</link></item>
+</channel></rss>`)
+	}))
+	defer server.Close()
+
+	sourceStore := newTestSourceStore(t)
+	newsFeed := newsfeed.NewMemoryNewsFeed()
+
+	service := NewDiscoveryService(sourceStore, newsFeed, nil)
+	source, err := sourceStore.CreateSource("rss", server.URL, "Test Feed", nil, nil)
+	require.NoError(t, err)
+	category := "security"
+	source.Category = &category
+
+	_, _, err = service.fetchRSSFeed(context.Background(), *source, nil)
+	require.NoError(t, err)
+
+	result, err := newsFeed.Query(newsfeed.ListOptions{Category: "Security"})
+	require.NoError(t, err)
+	require.Len(t, result.Items, 1)
+	require.NotNil(t, result.Items[0].Category)
+	assert.Equal(t, "security", *result.Items[0].Category)
+}
+
+// TestDiscoveryService_recordSyncAttempt verifies that successful and failed
+// fetches are added to the source's sync history.
+func TestDiscoveryService_recordSyncAttempt(t *testing.T) {
+	sourceStore := newTestSourceStore(t)
+
+	service := NewDiscoveryService(sourceStore, nil, nil)
+	source, err := sourceStore.CreateSource("rss", "http://example.com/feed", "Test Feed", nil, nil)
+	require.NoError(t, err)
+
+	start := time.Now()
+	service.recordSyncAttempt(*source, start.Add(-time.Minute), time.Second, 3, nil)
+	service.recordSyncAttempt(*source, start, time.Second, 0, fmt.Errorf("timeout"))
+
+	history, err := sourceStore.ListSyncHistory(source.SourceID, 0)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	require.NotNil(t, history[0].Error)
+	assert.Equal(t, "timeout", *history[0].Error)
+	assert.Nil(t, history[1].Error)
+	assert.Equal(t, 3, history[1].ItemsDiscovered)
+}
+
+// TestDiscoveryService_pruneItems verifies that items older than the
+// retention period are removed while pinned items are kept.
+func TestDiscoveryService_pruneItems(t *testing.T) {
+	newsFeed := newsfeed.NewMemoryNewsFeed()
+
+	now := time.Now()
+	old := newsfeed.NewsItem{ID: uuid.New(), Title: "old", URL: "http://example.com/old", DiscoveredAt: now.Add(-48 * time.Hour)}
+	pinned := newsfeed.NewsItem{ID: uuid.New(), Title: "pinned", URL: "http://example.com/pinned", DiscoveredAt: now.Add(-48 * time.Hour), PinnedAt: &now}
+	recent := newsfeed.NewsItem{ID: uuid.New(), Title: "recent", URL: "http://example.com/recent", DiscoveredAt: now}
+	for _, item := range []newsfeed.NewsItem{old, pinned, recent} {
+		require.NoError(t, newsFeed.Add(item))
+	}
+
+	service := NewDiscoveryService(nil, newsFeed, &DiscoveryConfig{RetentionPeriod: 24 * time.Hour})
+	service.pruneItems()
+
+	result, err := newsFeed.List()
+	require.NoError(t, err)
+	assert.Len(t, result.Items, 2)
+	for _, item := range result.Items {
+		assert.NotEqual(t, old.ID, item.ID, "old item should be pruned")
+	}
+}
+
+// TestDiscoveryService_Reload verifies that a reload swaps the configuration,
+// resizes the fetch queue, and wakes the run loop.
+func TestDiscoveryService_Reload(t *testing.T) {
+	service := NewDiscoveryService(nil, nil, &DiscoveryConfig{
+		PollInterval:      time.Hour,
+		Concurrency:       2,
+		RateLimitInterval: time.Second,
+	})
+	assert.Equal(t, 2, service.fetchQueue.slots)
+
+	service.Reload(&DiscoveryConfig{
+		PollInterval:      30 * time.Minute,
+		Concurrency:       8,
+		RateLimitInterval: 3 * time.Second,
+	})
+
+	assert.Equal(t, 30*time.Minute, service.getPollingInterval(sources.Source{}))
+	assert.Equal(t, 8, service.fetchQueue.slots)
+	assert.Equal(t, 3*time.Second, service.rateLimiter.minInterval)
+
+	// Repeated reloads coalesce into a single pending wake-up
+	service.Reload(service.currentConfig())
+	assert.Len(t, service.reloadChan, 1)
+}
+
+// TestDiscoveryService_claimSource verifies that a source cannot be fetched
+// twice at once.
+func TestDiscoveryService_claimSource(t *testing.T) {
+	service := NewDiscoveryService(nil, nil, nil)
+	id := uuid.New()
+
+	assert.True(t, service.claimSource(id))
+	assert.False(t, service.claimSource(id), "source already in flight")
+	assert.True(t, service.claimSource(uuid.New()), "other sources are unaffected")
+
+	service.releaseSource(id)
+	assert.True(t, service.claimSource(id))
+}
+
 func strPtr(s string) *string {
 	return &s
 }
@@ -1071,3 +1411,270 @@ func strPtr(s string) *string {
 func timePtr(t time.Time) *time.Time {
 	return &t
 }
+
+// TestDiscoveryService_SyncSources_ReportsDuplicates verifies items matching
+// existing items by canonical URL or content are skipped and counted.
+func TestDiscoveryService_SyncSources_ReportsDuplicates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `<?xml version="1.0"?>
+<rss version="2.0"><channel><title>Test</title>
+<item><title>Tracked</title><link>https://example.com/1?utm_source=rss</link></item>
+<item><title>Syndicated</title><description>Same story.</description><link>http://mirror.example.org/2</link></item>
+<item><title>Fresh</title><link>http://example.com/3</link></item>
+<item><title>Fresh again</title><link>http://example.com/3/</link></item>
+</channel></rss>`)
+	}))
+	defer server.Close()
+
+	sourceStore := newTestSourceStore(t)
+	newsFeed := newsfeed.NewMemoryNewsFeed()
+
+	now := time.Now()
+	for _, item := range []newsfeed.NewsItem{
+		{ID: uuid.New(), Title: "One", URL: "http://example.com/1", Authors: []string{}, PublishedAt: now, DiscoveredAt: now},
+		{ID: uuid.New(), Title: "Syndicated", Summary: "Same story.", URL: "http://example.com/2", Authors: []string{}, PublishedAt: now, DiscoveredAt: now},
+	} {
+		require.NoError(t, newsFeed.Add(item))
+	}
+
+	service := NewDiscoveryService(sourceStore, newsFeed, nil)
+	source, err := sourceStore.CreateSource("rss", server.URL, "Test Feed", nil, &now)
+	require.NoError(t, err)
+
+	result, err := service.SyncSources(context.Background(), SyncOptions{SourceID: &source.SourceID}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.ItemsDiscovered)
+	assert.Equal(t, DedupReport{URLDuplicates: 2, ContentDuplicates: 1}, result.Duplicates)
+	assert.Equal(t, 3, result.Duplicates.Total())
+}
+
+// TestDiscoveryService_fetchSource_ListModeCancelled verifies that a list-mode
+// scrape starts no articles beyond those already in flight once cancelled,
+// keeps what it found, and does not count the interruption as a failure.
+func TestDiscoveryService_fetchSource_ListModeCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var articleRequests atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/list", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, "<html><body>")
+		for i := range 10 {
+			_, _ = fmt.Fprintf(w, `<a class="post" href="/article/%d">Post %d</a>`, i, i)
+		}
+		_, _ = fmt.Fprint(w, "</body></html>")
+	})
+	mux.HandleFunc("/article/", func(w http.ResponseWriter, r *http.Request) {
+		articleRequests.Add(1)
+		_, _ = fmt.Fprintf(w, `<html><head><title>%s</title></head><body><h1>%s</h1><div class="content">Body</div></body></html>`, r.URL.Path, r.URL.Path)
+		// Shutdown arrives while the first article is being served
+		cancel()
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	sourceStore := newTestSourceStore(t)
+	newsFeed := newsfeed.NewMemoryNewsFeed()
+
+	config := DefaultDiscoveryConfig()
+	config.RateLimitInterval = 0
+	service := NewDiscoveryService(sourceStore, newsFeed, config)
+
+	now := time.Now()
+	source, err := sourceStore.CreateSource("website", server.URL+"/list", "Test Site", &ScraperConfig{
+		DiscoveryMode: "list",
+		ListConfig:    &ListConfig{ArticleSelector: "a.post", MaxPages: 1},
+		ArticleConfig: ArticleConfig{TitleSelector: "h1", ContentSelector: ".content"},
+	}, &now)
+	require.NoError(t, err)
+
+	_, err = service.fetchSource(ctx, *source, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.LessOrEqual(t, articleRequests.Load(), int32(config.ArticleConcurrency), "no articles should be fetched after cancellation")
+
+	updated, err := sourceStore.GetSource(source.SourceID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, updated.FetchErrorCount)
+	assert.Nil(t, updated.LastFetchedAt)
+}
+
+// TestSyncSources_DryRun verifies that a dry run reports what would be added
+// and skipped without writing items or source metadata.
+func TestSyncSources_DryRun(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = fmt.Fprint(w, `<?xml version="1.0"?>
+<rss version="2.0"><channel><title>Test</title>
+<item><title>Existing</title><link>http://example.com/1</link></item>
+<item><title>Fresh</title><link>http://example.com/2</link></item>
+</channel></rss>`)
+	}))
+	defer server.Close()
+
+	sourceStore := newTestSourceStore(t)
+	newsFeed := newsfeed.NewMemoryNewsFeed()
+
+	now := time.Now()
+	existing := newsfeed.NewsItem{ID: uuid.New(), Title: "Existing", URL: "http://example.com/1", Authors: []string{}, PublishedAt: now, DiscoveredAt: now}
+	require.NoError(t, newsFeed.Add(existing))
+
+	service := NewDiscoveryService(sourceStore, newsFeed, nil)
+	source, err := sourceStore.CreateSource("rss", server.URL, "Test Feed", nil, &now)
+	require.NoError(t, err)
+
+	result, err := service.SyncSources(context.Background(), SyncOptions{DryRun: true}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.ItemsDiscovered)
+	require.Len(t, result.Planned, 2)
+	decisions := map[string]newsfeed.DuplicateKind{}
+	for _, planned := range result.Planned {
+		decisions[planned.Item.Title] = planned.Duplicate
+	}
+	assert.Equal(t, map[string]newsfeed.DuplicateKind{
+		"Existing": newsfeed.DuplicateURL,
+		"Fresh":    newsfeed.NotDuplicate,
+	}, decisions)
+
+	// Nothing was written
+	items, err := newsFeed.List()
+	require.NoError(t, err)
+	assert.Len(t, items.Items, 1)
+
+	updated, err := sourceStore.GetSource(source.SourceID)
+	require.NoError(t, err)
+	assert.Nil(t, updated.LastFetchedAt)
+	assert.Nil(t, updated.ETag)
+
+	history, err := sourceStore.ListSyncHistory(source.SourceID, 0)
+	require.NoError(t, err)
+	assert.Empty(t, history)
+}
+
+// TestDiscoveryService_fetchSource_FetchTimeout verifies that FetchTimeout
+// bounds a fetch from a server that never answers, and that the timeout is
+// recorded as a transient error that leaves the source enabled
+func TestDiscoveryService_fetchSource_FetchTimeout(t *testing.T) {
+	server := newStalledServer(t)
+
+	sourceStore := newTestSourceStore(t)
+	newsFeed := newsfeed.NewMemoryNewsFeed()
+
+	config := DefaultDiscoveryConfig()
+	config.RateLimitInterval = 0
+	config.FetchTimeout = 100 * time.Millisecond
+	service := NewDiscoveryService(sourceStore, newsFeed, config)
+
+	now := time.Now()
+	source, err := sourceStore.CreateSource("rss", server.URL, "Stalled Feed", nil, &now)
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, err = service.fetchSource(context.Background(), *source, nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 2*time.Second)
+
+	updated, err := sourceStore.GetSource(source.SourceID)
+	require.NoError(t, err)
+	require.NotNil(t, updated.LastError)
+	assert.NotNil(t, updated.EnabledAt, "a timeout should not disable the source")
+	assert.Equal(t, 1, updated.FetchErrorCount)
+}
+
+// TestDiscoveryService_Reprobe verifies that a source auto-disabled after
+// transient failures is re-probed after escalating cooldowns and enabled
+// again once a re-probe succeeds
+func TestDiscoveryService_Reprobe(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusServiceUnavailable)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if code := int(status.Load()); code != http.StatusOK {
+			w.WriteHeader(code)
+			return
+		}
+		_, _ = fmt.Fprint(w, `<?xml version="1.0"?>
+<rss version="2.0"><channel><title>Back</title>
+<item><title>One</title><link>http://feed.invalid/1</link></item>
+</channel></rss>`)
+	}))
+	defer server.Close()
+
+	sourceStore := newTestSourceStore(t)
+	newsFeed := newsfeed.NewMemoryNewsFeed()
+
+	config := DefaultDiscoveryConfig()
+	config.DisableThreshold = 1
+	config.RateLimitInterval = 0
+	config.RetryAttempts = 0
+	service := NewDiscoveryService(sourceStore, newsFeed, config)
+
+	now := time.Now()
+	source, err := sourceStore.CreateSource("rss", server.URL, "Flaky Feed", nil, &now)
+	require.NoError(t, err)
+
+	// Reaching the threshold disables the source and schedules a re-probe
+	// a day out
+	_, err = service.fetchSource(context.Background(), *source, nil)
+	require.Error(t, err)
+	updated, err := sourceStore.GetSource(source.SourceID)
+	require.NoError(t, err)
+	assert.Nil(t, updated.EnabledAt)
+	require.NotNil(t, updated.ReprobeAt)
+	assert.WithinDuration(t, time.Now().Add(24*time.Hour), *updated.ReprobeAt, time.Minute)
+	assert.False(t, service.shouldFetch(*updated, time.Now()))
+	assert.True(t, service.shouldFetch(*updated, time.Now().Add(25*time.Hour)))
+
+	// Each failed re-probe waits longer
+	for _, cooldown := range []time.Duration{72 * time.Hour, 7 * 24 * time.Hour, 7 * 24 * time.Hour} {
+		_, err = service.fetchSource(context.Background(), *updated, nil)
+		require.Error(t, err)
+		updated, err = sourceStore.GetSource(source.SourceID)
+		require.NoError(t, err)
+		assert.Nil(t, updated.EnabledAt)
+		require.NotNil(t, updated.ReprobeAt)
+		assert.WithinDuration(t, time.Now().Add(cooldown), *updated.ReprobeAt, time.Minute)
+	}
+	assert.Equal(t, 3, updated.ReprobeCount)
+
+	// A successful re-probe enables the source again
+	status.Store(http.StatusOK)
+	_, err = service.fetchSource(context.Background(), *updated, nil)
+	require.NoError(t, err)
+	updated, err = sourceStore.GetSource(source.SourceID)
+	require.NoError(t, err)
+	assert.NotNil(t, updated.EnabledAt)
+	assert.Nil(t, updated.ReprobeAt)
+	assert.Equal(t, 0, updated.ReprobeCount)
+	assert.Equal(t, 0, updated.FetchErrorCount)
+
+	// The re-probes are marked in the sync history
+	history, err := sourceStore.ListSyncHistory(source.SourceID, 0)
+	require.NoError(t, err)
+	require.Len(t, history, 5)
+	assert.True(t, history[0].Reprobe)
+	assert.Nil(t, history[0].Error)
+	assert.False(t, history[4].Reprobe)
+}
+
+// TestDiscoveryService_Reprobe_PermanentError verifies that a permanent
+// error during a re-probe stops further re-probes
+func TestDiscoveryService_Reprobe_PermanentError(t *testing.T) {
+	sourceStore := newTestSourceStore(t)
+	newsFeed := newsfeed.NewMemoryNewsFeed()
+
+	service := NewDiscoveryService(sourceStore, newsFeed, nil)
+
+	reprobeAt := time.Now().Add(-time.Minute)
+	source, err := sourceStore.CreateSource("rss", "http://example.com/feed", "Gone Feed", nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, sourceStore.UpdateSource(source.SourceID, sources.SourceUpdate{ReprobeAt: &reprobeAt}))
+	source, err = sourceStore.GetSource(source.SourceID)
+	require.NoError(t, err)
+	assert.True(t, service.shouldFetch(*source, time.Now()))
+
+	service.handleFetchError(*source, &HTTPStatusError{StatusCode: http.StatusGone, Status: "410 Gone"})
+	updated, err := sourceStore.GetSource(source.SourceID)
+	require.NoError(t, err)
+	assert.Nil(t, updated.EnabledAt)
+	assert.Nil(t, updated.ReprobeAt)
+	assert.False(t, service.shouldFetch(*updated, time.Now().Add(30*24*time.Hour)))
+}
@@ -0,0 +1,100 @@
+package discovery
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/pevans/newsfed/newsfeed"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Enricher adds information to a news item after it has been parsed from a
+// source and before it is added to the feed, for example by counting its
+// words or summarizing it. An Enricher may change any field of the item.
+type Enricher interface {
+	Enrich(ctx context.Context, item *newsfeed.NewsItem) error
+}
+
+// EnricherFunc adapts an ordinary function to the Enricher interface.
+type EnricherFunc func(ctx context.Context, item *newsfeed.NewsItem) error
+
+// Enrich calls f(ctx, item).
+func (f EnricherFunc) Enrich(ctx context.Context, item *newsfeed.NewsItem) error {
+	return f(ctx, item)
+}
+
+// DefaultEnrichers returns the built-in enrichment pipeline that new
+// discovery services start with.
+func DefaultEnrichers() []Enricher {
+	return []Enricher{LanguageEnricher{}, WordCountEnricher{}}
+}
+
+// WordCountEnricher records the number of words in an item's summary as the
+// "word_count" metadata value. Items without a summary are left alone.
+type WordCountEnricher struct{}
+
+// Enrich implements Enricher.
+func (WordCountEnricher) Enrich(_ context.Context, item *newsfeed.NewsItem) error {
+	count := len(strings.Fields(item.Summary))
+	if count == 0 {
+		return nil
+	}
+	if item.Metadata == nil {
+		item.Metadata = map[string]any{}
+	}
+	item.Metadata["word_count"] = count
+	return nil
+}
+
+// AddEnricher appends e to the service's enrichment pipeline. It may be
+// called while the service is running; an item already being enriched
+// finishes with the pipeline it started with.
+func (ds *DiscoveryService) AddEnricher(e Enricher) {
+	ds.enrichersMu.Lock()
+	defer ds.enrichersMu.Unlock()
+	ds.enrichers = append(ds.enrichers, e)
+}
+
+// SetEnrichers replaces the service's enrichment pipeline with enrichers,
+// which run in the order given. Calling it with no arguments turns
+// enrichment off, including the built-in enrichers.
+func (ds *DiscoveryService) SetEnrichers(enrichers ...Enricher) {
+	ds.enrichersMu.Lock()
+	defer ds.enrichersMu.Unlock()
+	ds.enrichers = append([]Enricher(nil), enrichers...)
+}
+
+// Enrichers returns the service's enrichment pipeline in the order it runs.
+func (ds *DiscoveryService) Enrichers() []Enricher {
+	ds.enrichersMu.RLock()
+	defer ds.enrichersMu.RUnlock()
+	return append([]Enricher(nil), ds.enrichers...)
+}
+
+// enrich runs item through the enrichment pipeline in order, so each
+// enricher sees the changes made by the ones before it. A failing enricher
+// is logged and skipped: the item is still added, with whatever the other
+// enrichers contributed.
+func (ds *DiscoveryService) enrich(ctx context.Context, item *newsfeed.NewsItem) {
+	enrichers := ds.Enrichers()
+	if len(enrichers) == 0 {
+		return
+	}
+
+	ctx, span := tracer.Start(ctx, "discovery.enrich", trace.WithAttributes(
+		attribute.String("item.url", item.URL),
+	))
+	defer span.End()
+
+	for _, e := range enrichers {
+		if err := ctx.Err(); err != nil {
+			return
+		}
+		if err := e.Enrich(ctx, item); err != nil {
+			log.Printf("WARN: Enricher %T failed for %s: %v", e, item.URL, err)
+			span.RecordError(err)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pevans/newsfed/newsfeed"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestWordCountEnricher verifies that the word count of a summary is stored
+// in the item's metadata and that empty summaries are skipped
+func TestWordCountEnricher(t *testing.T) {
+	item := newsfeed.NewsItem{Summary: "Three short words"}
+	require.NoError(t, WordCountEnricher{}.Enrich(context.Background(), &item))
+	assert.Equal(t, 3, item.Metadata["word_count"])
+
+	empty := newsfeed.NewsItem{}
+	require.NoError(t, WordCountEnricher{}.Enrich(context.Background(), &empty))
+	assert.Nil(t, empty.Metadata)
+}
+
+// TestFetchRSSFeed_Enrichers verifies that enrichers run in order on new
+// items before they are added, that a failing enricher does not stop the
+// others, and that dry runs are not enriched
+func TestFetchRSSFeed_Enrichers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `<?xml version="1.0"?>
+<rss version="2.0"><channel><title>Test</title>
+<item><title>One</title><link>http://example.com/1</link><description>Four words of summary</description></item>
+</channel></rss>`)
+	}))
+	defer server.Close()
+
+	sourceStore := newTestSourceStore(t)
+	newsFeed := newsfeed.NewMemoryNewsFeed()
+
+	service := NewDiscoveryService(sourceStore, newsFeed, nil)
+	var calls []string
+	service.AddEnricher(EnricherFunc(func(ctx context.Context, item *newsfeed.NewsItem) error {
+		calls = append(calls, "failing")
+		return errors.New("enricher unavailable")
+	}))
+	service.AddEnricher(EnricherFunc(func(ctx context.Context, item *newsfeed.NewsItem) error {
+		calls = append(calls, "tagger")
+		item.Metadata["tagged"] = item.Metadata["word_count"] == 4
+		return nil
+	}))
+	assert.Len(t, service.Enrichers(), len(DefaultEnrichers())+2)
+
+	source, err := sourceStore.CreateSource("rss", server.URL, "Test", nil, nil)
+	require.NoError(t, err)
+
+	_, _, err = service.fetchRSSFeed(context.Background(), *source, &dryRunPlan{})
+	require.NoError(t, err)
+	assert.Empty(t, calls, "dry runs should not be enriched")
+
+	count, _, err := service.fetchRSSFeed(context.Background(), *source, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, []string{"failing", "tagger"}, calls)
+
+	result, err := newsFeed.List()
+	require.NoError(t, err)
+	require.Len(t, result.Items, 1)
+	assert.Equal(t, true, result.Items[0].Metadata["tagged"])
+	assert.EqualValues(t, 4, result.Items[0].Metadata["word_count"])
+}
+
+// TestSetEnrichers verifies that the pipeline can be replaced or emptied
+func TestSetEnrichers(t *testing.T) {
+	service := NewDiscoveryService(nil, nil, nil)
+	assert.Equal(t, DefaultEnrichers(), service.Enrichers())
+
+	service.SetEnrichers()
+	assert.Empty(t, service.Enrichers())
+
+	item := newsfeed.NewsItem{Summary: "Some words"}
+	service.enrich(context.Background(), &item)
+	assert.Nil(t, item.Metadata)
+}
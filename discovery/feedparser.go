@@ -3,6 +3,7 @@ package discovery
 import (
 	"context"
 	"fmt"
+	"io"
 	"net/http"
 	"sort"
 	"strings"
@@ -11,6 +12,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/mmcdole/gofeed"
 	"github.com/pevans/newsfed/newsfeed"
+	"github.com/pevans/newsfed/sources"
 )
 
 // httpClient is the shared HTTP client used for all outbound requests in the
@@ -20,18 +22,224 @@ var httpClient = &http.Client{
 	Timeout: 10 * time.Second,
 }
 
+// feedUserAgent is the User-Agent sent with feed requests that don't set
+// their own. It is the one gofeed sends.
+const feedUserAgent = "Gofeed/1.0"
+
+// RequestOptions customizes outbound requests for a source.
+type RequestOptions struct {
+	// UserAgent replaces the default User-Agent when non-empty.
+	UserAgent string
+	// Headers are added to every request.
+	Headers map[string]string
+	// ProxyURL routes requests through an http, https, socks5, or socks5h
+	// proxy when non-empty. Otherwise the proxy environment variables apply.
+	ProxyURL string
+	// TLS changes how servers' certificates are verified when non-nil.
+	TLS *sources.TLSConfig
+	// Retry retries transient failures within one request. The zero value
+	// makes a single attempt.
+	Retry RetryPolicy
+	// Renderer loads HTML pages in a headless browser, running their
+	// scripts, instead of fetching them over HTTP when non-nil. Feeds are
+	// always fetched over HTTP.
+	Renderer *Renderer
+	// WaitSelector is a CSS selector a rendered page must match before it
+	// is read; empty reads the page once it has loaded.
+	WaitSelector string
+	// Jar holds cookies sent with HTML page requests, and keeps the ones
+	// the site sets, when non-nil.
+	Jar http.CookieJar
+	// MaxResponseSize is the largest response body read, in bytes; larger
+	// responses fail with ErrResponseTooLarge. Zero means
+	// DefaultMaxResponseSize.
+	MaxResponseSize int64
+	// MaxFeedItems stops reading a feed that lists its items newest first
+	// once it has read this many; zero reads every item.
+	MaxFeedItems int
+	// PublicOnly refuses to connect to loopback, private, link-local, and
+	// other addresses that aren't on the public internet, including after a
+	// redirect, so that a URL given by a client can't reach internal
+	// services. Through a proxy only the proxy's address is seen, so the
+	// check is left to the caller.
+	PublicOnly bool
+	// budget limits the requests sent with these options, and the bytes
+	// read in response, when non-nil.
+	budget *fetchBudget
+	// queue schedules the requests sent with these options, as made for
+	// queueSource, when non-nil.
+	queue       *fetchQueue
+	queueSource string
+}
+
+// requestOptionsFor returns the request options configured on a source. A
+// source without its own proxy uses the service's default proxy, if any.
+// Header values and proxy URLs that refer to secrets are replaced by the
+// secrets' values; it returns an error if one can't be read. Requests made
+// with the options are limited by the source's fetch budget, which starts
+// when they are returned, and take their turn in the service's fetch queue.
+func (ds *DiscoveryService) requestOptionsFor(source sources.Source) (RequestOptions, error) {
+	headers, err := ds.resolveValues("header", source.HTTPHeaders)
+	if err != nil {
+		return RequestOptions{}, err
+	}
+	opts := RequestOptions{Headers: headers}
+	if source.UserAgent != nil {
+		opts.UserAgent = *source.UserAgent
+	}
+	proxyURL := ds.currentConfig().ProxyURL
+	if source.ProxyURL != nil {
+		proxyURL = *source.ProxyURL
+	}
+	if opts.ProxyURL, err = ds.resolveSecret(proxyURL); err != nil {
+		return RequestOptions{}, fmt.Errorf("proxy URL: %w", err)
+	}
+	opts.TLS = source.TLS
+	opts.budget = newFetchBudget(source.FetchBudget)
+	opts.queue, opts.queueSource = ds.fetchQueue, source.SourceID.String()
+	config := ds.currentConfig()
+	opts.MaxResponseSize = config.MaxResponseSize
+	opts.Retry = RetryPolicy{
+		Attempts:  config.RetryAttempts,
+		BaseDelay: config.RetryBaseDelay,
+		MaxDelay:  config.RetryMaxDelay,
+	}
+	if source.ScraperConfig != nil && source.ScraperConfig.RenderJS {
+		opts.Renderer = ds.renderer()
+	}
+	return opts, nil
+}
+
+// articleOptions returns opts for an article page extracted with config: a
+// rendered page is read once its content selector matches.
+func articleOptions(opts RequestOptions, config ArticleConfig) RequestOptions {
+	if !config.UsesAutoExtraction() {
+		opts.WaitSelector = config.ContentSelector
+	}
+	return opts
+}
+
+// acquireSlot waits for the fetch queue to let a request to host through,
+// and returns a function that gives its slot back.
+func (opts RequestOptions) acquireSlot(ctx context.Context, host string) (func(), error) {
+	return opts.queue.acquire(ctx, opts.queueSource, host)
+}
+
+// apply sets the User-Agent, falling back to defaultUserAgent, and the extra
+// headers on req.
+func (opts RequestOptions) apply(req *http.Request, defaultUserAgent string) {
+	for name, value := range opts.Headers {
+		req.Header.Set(name, value)
+	}
+	if opts.UserAgent != "" {
+		req.Header.Set("User-Agent", opts.UserAgent)
+	} else if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", defaultUserAgent)
+	}
+}
+
 // FetchFeed fetches and parses an RSS or Atom feed from the given URL. The
 // gofeed library automatically detects and handles both RSS and Atom formats.
 // The context is used for cancellation; each request is also subject to a
 // 10-second per-request HTTP timeout per Spec 2 section 2.2.1.
 func FetchFeed(ctx context.Context, url string) (*gofeed.Feed, error) {
-	fp := gofeed.NewParser()
-	fp.Client = httpClient
-	feed, err := fp.ParseURLWithContext(url, ctx)
+	result, err := FetchFeedConditional(ctx, url, nil, nil, RequestOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return result.Feed, nil
+}
+
+// FeedFetchResult is the outcome of a conditional feed fetch. When the server
+// reports the feed is unchanged, NotModified is true and Feed is nil.
+// Truncated is true when the feed was read only up to the request's
+// MaxFeedItems.
+type FeedFetchResult struct {
+	Feed         *gofeed.Feed
+	NotModified  bool
+	Truncated    bool
+	ETag         *string
+	LastModified *string
+}
+
+// FetchFeedConditional fetches and parses a feed like FetchFeed, but sends
+// If-None-Match and If-Modified-Since when cache validators from a previous
+// fetch are given. A 304 response short-circuits parsing. The returned
+// validators are those sent by the server, falling back to the ones passed
+// in when the server omits them. opts supplies any per-source User-Agent and
+// headers.
+func FetchFeedConditional(ctx context.Context, url string, etag, lastModified *string, opts RequestOptions) (*FeedFetchResult, error) {
+	req, err := newRequest(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	opts.apply(req, feedUserAgent)
+	if etag != nil && *etag != "" {
+		req.Header.Set("If-None-Match", *etag)
+	}
+	if lastModified != nil && *lastModified != "" {
+		req.Header.Set("If-Modified-Since", *lastModified)
+	}
+
+	client, err := opts.client()
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse feed: %w", err)
+		return nil, fmt.Errorf("failed to fetch feed: %w", err)
 	}
-	return feed, nil
+	resp, err := opts.do(client, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch feed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	result := &FeedFetchResult{
+		ETag:         headerOr(resp.Header, "ETag", etag),
+		LastModified: headerOr(resp.Header, "Last-Modified", lastModified),
+	}
+
+	if resp.StatusCode == http.StatusNotModified {
+		result.NotModified = true
+		return result, nil
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, newHTTPStatusError(resp)
+	}
+
+	// The feed is parsed as it arrives, so that a huge one isn't held in
+	// memory, and a larger one than allowed is abandoned partway
+	body, err := newResponseBody(resp, opts.maxResponseSize())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read feed: %w", err)
+	}
+	feed, truncated, err := parseDecodedFeed(body, resp.Header.Get("Content-Type"), opts.MaxFeedItems)
+	if err != nil {
+		if body.err != nil {
+			return nil, fmt.Errorf("failed to read feed: %w", body.err)
+		}
+		return nil, &ParseError{Format: "feed", URL: url, Err: err}
+	}
+	result.Feed = feed
+	result.Truncated = truncated
+	return result, nil
+}
+
+// parseDecodedFeed parses a feed body after converting it to UTF-8 per its
+// Content-Type, as parseFeed does.
+func parseDecodedFeed(body io.Reader, contentType string, maxItems int) (*gofeed.Feed, bool, error) {
+	r, err := feedReader(body, contentType)
+	if err != nil {
+		return nil, false, err
+	}
+	return parseFeed(r, maxItems)
+}
+
+// headerOr returns the named response header, or fallback if the header is
+// absent.
+func headerOr(header http.Header, name string, fallback *string) *string {
+	if value := header.Get(name); value != "" {
+		return &value
+	}
+	return fallback
 }
 
 // FeedItemToNewsItem converts an RSS or Atom feed item to a
@@ -49,8 +257,10 @@ func FeedItemToNewsItem(item *gofeed.Item, feedTitle string, sourceID uuid.UUID)
 	}
 
 	// Summary: from <description> (RSS) or <summary>/<content> (Atom) gofeed
-	// normalizes both to item.Description
-	summary := item.Description
+	// normalizes both to item.Description. Descriptions often carry HTML, so
+	// the summary is its text with entities decoded, and the sanitized HTML
+	// is kept alongside.
+	summary, summaryHTML := cleanSummary(item.Description)
 
 	// URL: from <link> (RSS) or <link rel="alternate"> (Atom) gofeed
 	// normalizes both to item.Link
@@ -85,30 +295,33 @@ func FeedItemToNewsItem(item *gofeed.Item, feedTitle string, sourceID uuid.UUID)
 		}
 	}
 
+	// Discovered_at: set to current time when ingesting
+	discoveredAt := time.Now().UTC()
+
 	// Published_at: from <updated> (most current) or <published> as fallback.
 	// gofeed parses both RSS <pubDate> and Atom <published>/<updated> into
-	// PublishedParsed and UpdatedParsed.
+	// PublishedParsed and UpdatedParsed. It is stored in UTC, with the date
+	// as the feed gave it kept in metadata.
 	var publishedAt time.Time
+	var publishedRaw string
 	if item.UpdatedParsed != nil {
 		// Prefer updated date as it's more current
-		publishedAt = *item.UpdatedParsed
+		publishedAt, publishedRaw = item.UpdatedParsed.UTC(), item.Updated
 	} else if item.PublishedParsed != nil {
-		publishedAt = *item.PublishedParsed
+		publishedAt, publishedRaw = item.PublishedParsed.UTC(), item.Published
 	} else {
-		// If no date available, use current time
-		publishedAt = time.Now().UTC()
+		// If no date available, use the discovery time
+		publishedAt = discoveredAt
 	}
 
-	// Discovered_at: set to current time when ingesting
-	discoveredAt := time.Now().UTC()
-
 	// Pinned_at: set to nil (not yet pinned)
 	var pinnedAt *time.Time
 
-	return newsfeed.NewsItem{
+	newsItem := newsfeed.NewsItem{
 		ID:           id,
 		Title:        title,
 		Summary:      summary,
+		SummaryHTML:  summaryHTML,
 		URL:          url,
 		Publisher:    publisher,
 		Authors:      authors,
@@ -116,9 +329,16 @@ func FeedItemToNewsItem(item *gofeed.Item, feedTitle string, sourceID uuid.UUID)
 		DiscoveredAt: discoveredAt,
 		PinnedAt:     pinnedAt,
 		SourceID:     &sourceID,
+		GUID:         item.GUID,
 	}
+	setPublishedRaw(&newsItem, publishedRaw)
+	return newsItem
 }
 
+// feedItemLimit is how many of a feed's most recent items are kept on a
+// first-time sync or of a stale source, per Spec 2 section 2.2.3.
+const feedItemLimit = 20
+
 // FeedToNewsItems converts all items in an RSS or Atom feed to
 // newsfeed.NewsItems. Implements Spec 2 section 2.2.3: conditionally limits
 // to 20 most recent items based on published_at timestamp.
@@ -130,8 +350,10 @@ func FeedItemToNewsItem(item *gofeed.Item, feedTitle string, sourceID uuid.UUID)
 func FeedToNewsItems(feed *gofeed.Feed, applyLimit bool, sourceID uuid.UUID) []newsfeed.NewsItem {
 	// Convert all items to newsfeed.NewsItems
 	items := make([]newsfeed.NewsItem, 0, len(feed.Items))
+	language := feedLanguage(feed.Language)
 	for _, item := range feed.Items {
 		newsItem := FeedItemToNewsItem(item, feed.Title, sourceID)
+		newsItem.Language = language
 		items = append(items, newsItem)
 	}
 
@@ -142,16 +364,24 @@ func FeedToNewsItems(feed *gofeed.Feed, applyLimit bool, sourceID uuid.UUID) []n
 
 	// Conditionally limit to 20 most recent items per Spec 2 section 2.2.3
 	// Apply limit only for first-time syncs or stale sources (>15 days)
-	if applyLimit {
-		const maxItems = 20
-		if len(items) > maxItems {
-			items = items[:maxItems]
-		}
+	if applyLimit && len(items) > feedItemLimit {
+		items = items[:feedItemLimit]
 	}
 
 	return items
 }
 
+// feedLanguage returns the ISO 639-1 code of a feed's declared language,
+// such as "en" for "en-US", or "" if the feed declares none or it is not a
+// language code.
+func feedLanguage(declared string) string {
+	lang, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(declared)), "-")
+	if sources.ValidateLanguage(lang) != nil {
+		return ""
+	}
+	return lang
+}
+
 // contains checks if a string slice contains a specific string
 func contains(slice []string, str string) bool {
 	for _, s := range slice {
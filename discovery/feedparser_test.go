@@ -1,7 +1,11 @@
 package discovery
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -512,3 +516,115 @@ func TestContains_Reflexive(t *testing.T) {
 		assert.True(t, contains(slice, str), "slice containing string should find it")
 	}
 }
+
+// TestFetchFeedConditional_NotModified verifies that a 304 response is
+// reported without parsing and that the caller's validators are kept
+func TestFetchFeedConditional_NotModified(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, `"abc"`, r.Header.Get("If-None-Match"))
+		w.WriteHeader(http.StatusNotModified)
+	}))
+	defer server.Close()
+
+	etag := `"abc"`
+	result, err := FetchFeedConditional(context.Background(), server.URL, &etag, nil, RequestOptions{})
+	require.NoError(t, err)
+	assert.True(t, result.NotModified)
+	assert.Nil(t, result.Feed)
+	require.NotNil(t, result.ETag)
+	assert.Equal(t, etag, *result.ETag)
+	assert.Nil(t, result.LastModified)
+}
+
+// TestFetchFeedConditional_HTTPError verifies that non-2xx responses are
+// reported as an HTTPStatusError with their status code and URL
+func TestFetchFeedConditional_HTTPError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	_, err := FetchFeedConditional(context.Background(), server.URL, nil, nil, RequestOptions{})
+	var statusErr *HTTPStatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusNotFound, statusErr.StatusCode)
+	assert.Equal(t, server.URL, statusErr.URL)
+	assert.Contains(t, err.Error(), "HTTP error: 404 Not Found")
+}
+
+// TestFetchFeedConditional_RequestOptions verifies that a custom User-Agent
+// and extra headers are sent
+func TestFetchFeedConditional_RequestOptions(t *testing.T) {
+	var userAgent, authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+		authorization = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusNotModified)
+	}))
+	defer server.Close()
+
+	opts := RequestOptions{
+		UserAgent: "CustomAgent/2.0",
+		Headers:   map[string]string{"Authorization": "Bearer token"},
+	}
+	_, err := FetchFeedConditional(context.Background(), server.URL, nil, nil, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "CustomAgent/2.0", userAgent)
+	assert.Equal(t, "Bearer token", authorization)
+
+	// Without options the default User-Agent is used
+	_, err = FetchFeedConditional(context.Background(), server.URL, nil, nil, RequestOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, gofeed.NewParser().UserAgent, userAgent)
+	assert.Empty(t, authorization)
+}
+
+// newStalledServer returns a server that never answers, holding each
+// request open until the client gives up
+func newStalledServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// TestFetchFeed_ContextDeadline verifies that the context's deadline bounds
+// the whole request, well inside the client's own timeout
+func TestFetchFeed_ContextDeadline(t *testing.T) {
+	server := newStalledServer(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := FetchFeed(ctx, server.URL)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
+
+// TestFetchFeed_ServerErrorIsTransient verifies that an error status from
+// the server is reported as a fetch failure that will be retried, while a
+// missing feed is still permanent
+func TestFetchFeed_ServerErrorIsTransient(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusInternalServerError)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer server.Close()
+	service := NewDiscoveryService(nil, nil, nil)
+
+	_, err := FetchFeed(context.Background(), server.URL)
+	require.Error(t, err)
+	assert.False(t, service.isPermanentError(err))
+
+	status.Store(http.StatusNotFound)
+	_, err = FetchFeed(context.Background(), server.URL)
+	require.Error(t, err)
+	assert.True(t, service.isPermanentError(err))
+}
@@ -0,0 +1,95 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// ErrInvalidURL is wrapped by errors for URLs that can't be fetched at all:
+// ones that don't parse or don't use http or https.
+var ErrInvalidURL = errors.New("invalid URL")
+
+// HTTPStatusError reports a response whose status code means the fetch
+// failed.
+type HTTPStatusError struct {
+	// URL is the URL that was requested
+	URL string
+	// StatusCode is the response's status code, such as 404
+	StatusCode int
+	// Status is the response's status line, such as "404 Not Found"
+	Status string
+}
+
+// newHTTPStatusError returns an HTTPStatusError for resp.
+func newHTTPStatusError(resp *http.Response) *HTTPStatusError {
+	err := &HTTPStatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+	if resp.Request != nil {
+		err.URL = resp.Request.URL.Redacted()
+	}
+	return err
+}
+
+// Error implements error.
+func (e *HTTPStatusError) Error() string {
+	status := e.Status
+	if status == "" {
+		status = fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	if e.URL == "" {
+		return "HTTP error: " + status
+	}
+	return fmt.Sprintf("HTTP error: %s (%s)", status, e.URL)
+}
+
+// Permanent reports whether the status says the source is gone or off
+// limits, rather than that the server is having trouble: 401 Unauthorized,
+// 404 Not Found, and 410 Gone. Everything else, including 429 Too Many
+// Requests and 5xx statuses, may pass on its own.
+func (e *HTTPStatusError) Permanent() bool {
+	switch e.StatusCode {
+	case http.StatusUnauthorized, http.StatusNotFound, http.StatusGone:
+		return true
+	}
+	return false
+}
+
+// ParseError reports a response that arrived but couldn't be read as the
+// feed or HTML page that was expected.
+type ParseError struct {
+	// Format is what the response was parsed as, such as "feed" or "HTML"
+	Format string
+	// URL is the URL that was requested
+	URL string
+	// Err is the parser's error
+	Err error
+}
+
+// Error implements error.
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("failed to parse %s: %v", e.Format, e.Err)
+}
+
+// Unwrap returns the parser's error.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// newRequest returns a GET request for rawURL, or an error wrapping
+// ErrInvalidURL if rawURL can't be fetched.
+func newRequest(ctx context.Context, rawURL string) (*http.Request, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("%w %q: scheme must be http or https", ErrInvalidURL, rawURL)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidURL, err)
+	}
+	return req, nil
+}
@@ -0,0 +1,72 @@
+package discovery
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHTTPStatusError_Permanent verifies which statuses are permanent
+func TestHTTPStatusError_Permanent(t *testing.T) {
+	permanent := []int{401, 404, 410}
+	transient := []int{400, 403, 408, 429, 500, 502, 503, 504}
+
+	for _, code := range permanent {
+		assert.True(t, (&HTTPStatusError{StatusCode: code}).Permanent(), code)
+	}
+	for _, code := range transient {
+		assert.False(t, (&HTTPStatusError{StatusCode: code}).Permanent(), code)
+	}
+}
+
+// TestHTTPStatusError_Error verifies the message with and without a status
+// line and URL
+func TestHTTPStatusError_Error(t *testing.T) {
+	err := &HTTPStatusError{URL: "https://example.com/feed", StatusCode: 410, Status: "410 Gone"}
+	assert.Equal(t, "HTTP error: 410 Gone (https://example.com/feed)", err.Error())
+
+	err = &HTTPStatusError{StatusCode: 503}
+	assert.Equal(t, "HTTP error: 503 Service Unavailable", err.Error())
+}
+
+// TestFetchHTML_HTTPStatusError verifies that HTML fetches report error
+// statuses with their code and URL
+func TestFetchHTML_HTTPStatusError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	_, err := FetchHTML(context.Background(), server.URL+"/article")
+	var statusErr *HTTPStatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusUnauthorized, statusErr.StatusCode)
+	assert.Equal(t, server.URL+"/article", statusErr.URL)
+	assert.True(t, NewDiscoveryService(nil, nil, nil).isPermanentError(err))
+}
+
+// TestNewRequest_InvalidURL verifies that URLs that can't be fetched are
+// reported as ErrInvalidURL
+func TestNewRequest_InvalidURL(t *testing.T) {
+	for _, rawURL := range []string{"ftp://example.com/feed", "example.com/feed", "http://[::1"} {
+		_, err := newRequest(context.Background(), rawURL)
+		assert.ErrorIs(t, err, ErrInvalidURL, rawURL)
+	}
+
+	req, err := newRequest(context.Background(), "https://example.com/feed")
+	require.NoError(t, err)
+	assert.Equal(t, http.MethodGet, req.Method)
+}
+
+// TestDescribeErr verifies the short reasons autodiscovery gives for
+// candidates that aren't feeds
+func TestDescribeErr(t *testing.T) {
+	assert.Equal(t, "404", describeErr(&HTTPStatusError{StatusCode: 404}))
+	assert.Equal(t, "timeout", describeErr(context.DeadlineExceeded))
+	assert.Equal(t, "not a feed", describeErr(&ParseError{Format: "feed"}))
+	assert.Equal(t, "", describeErr(nil))
+}
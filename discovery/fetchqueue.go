@@ -0,0 +1,173 @@
+package discovery
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// defaultConcurrency is how many sources are fetched, and how many requests
+// run, at once when the config doesn't say.
+const defaultConcurrency = 5
+
+// fetchQueue is the service's central queue for fetching. It admits up to
+// slots source fetches at once, in the order they ask, and runs up to slots
+// of their requests at once. A freed request slot goes to the waiting
+// request whose domain, then source, has the fewest requests running; among
+// those, to the source let through least recently, and then to the request
+// that has waited longest. A source scraping hundreds of pages so takes
+// turns with newly due feeds, rather than filling every slot while they
+// wait.
+type fetchQueue struct {
+	mu       sync.Mutex
+	slots    int
+	fetches  int               // Source fetches admitted
+	starts   []*queuedFetch    // Source fetches waiting, in order
+	running  int               // Requests running
+	waiting  []*queuedFetch    // Requests waiting, in order
+	bySource map[string]int    // Requests running per source
+	byDomain map[string]int    // Requests running per domain
+	grants   uint64            // Requests let through so far
+	lastSeen map[string]uint64 // Value of grants when each source was last let through
+}
+
+// queuedFetch is a source fetch or request waiting in a fetchQueue. ready
+// is closed when it is let through.
+type queuedFetch struct {
+	source string
+	domain string
+	ready  chan struct{}
+}
+
+// newFetchQueue returns a queue with slots slots, or defaultConcurrency if
+// slots isn't positive.
+func newFetchQueue(slots int) *fetchQueue {
+	q := &fetchQueue{bySource: map[string]int{}, byDomain: map[string]int{}, lastSeen: map[string]uint64{}}
+	q.resize(slots)
+	return q
+}
+
+// resize changes the number of slots. Fetches and requests already let
+// through keep going; if there are fewer slots than before, no more are let
+// through until enough of them finish.
+func (q *fetchQueue) resize(slots int) {
+	if slots <= 0 {
+		slots = defaultConcurrency
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.slots = slots
+	q.dispatch()
+}
+
+// startFetch waits for a source fetch to be admitted and returns a function
+// to call when it is finished.
+func (q *fetchQueue) startFetch(ctx context.Context) (func(), error) {
+	return q.wait(ctx, &q.starts, &queuedFetch{ready: make(chan struct{})}, q.finishFetch)
+}
+
+// acquire waits for a slot for one of source's requests to domain and
+// returns a function that gives it back. A nil queue lets every request
+// through.
+func (q *fetchQueue) acquire(ctx context.Context, source, domain string) (func(), error) {
+	if q == nil {
+		return func() {}, nil
+	}
+	w := &queuedFetch{source: source, domain: domain, ready: make(chan struct{})}
+	return q.wait(ctx, &q.waiting, w, q.finishRequest)
+}
+
+// wait adds w to queue and waits for it to be let through, returning a
+// function that calls finish with it once. If ctx ends first, w leaves the
+// queue, or is finished at once if it was let through meanwhile.
+func (q *fetchQueue) wait(ctx context.Context, queue *[]*queuedFetch, w *queuedFetch, finish func(*queuedFetch)) (func(), error) {
+	q.mu.Lock()
+	*queue = append(*queue, w)
+	q.dispatch()
+	q.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		var once sync.Once
+		return func() { once.Do(func() { finish(w) }) }, nil
+	case <-ctx.Done():
+	}
+
+	q.mu.Lock()
+	if i := slices.Index(*queue, w); i >= 0 {
+		*queue = slices.Delete(*queue, i, i+1)
+		q.mu.Unlock()
+	} else {
+		q.mu.Unlock()
+		finish(w)
+	}
+	return nil, ctx.Err()
+}
+
+// finishFetch ends an admitted source fetch.
+func (q *fetchQueue) finishFetch(*queuedFetch) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.fetches--
+	q.dispatch()
+}
+
+// finishRequest gives back the slot of a request let through.
+func (q *fetchQueue) finishRequest(w *queuedFetch) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.running--
+	decrement(q.bySource, w.source)
+	decrement(q.byDomain, w.domain)
+	q.dispatch()
+}
+
+// dispatch lets through waiting fetches and requests while there are slots
+// for them. q.mu must be held.
+func (q *fetchQueue) dispatch() {
+	for q.fetches < q.slots && len(q.starts) > 0 {
+		w := q.starts[0]
+		q.starts = q.starts[1:]
+		q.fetches++
+		close(w.ready)
+	}
+	for q.running < q.slots && len(q.waiting) > 0 {
+		next := 0
+		for i, w := range q.waiting[1:] {
+			if q.fairer(w, q.waiting[next]) {
+				next = i + 1
+			}
+		}
+		w := q.waiting[next]
+		q.waiting = slices.Delete(q.waiting, next, next+1)
+		q.running++
+		q.bySource[w.source]++
+		q.byDomain[w.domain]++
+		q.grants++
+		q.lastSeen[w.source] = q.grants
+		close(w.ready)
+	}
+}
+
+// fairer reports whether request a should be let through before b, which
+// has waited longer: whether a's domain, or failing that its source, has
+// fewer requests running, or failing that whether its source was let
+// through less recently.
+func (q *fetchQueue) fairer(a, b *queuedFetch) bool {
+	if da, db := q.byDomain[a.domain], q.byDomain[b.domain]; da != db {
+		return da < db
+	}
+	if sa, sb := q.bySource[a.source], q.bySource[b.source]; sa != sb {
+		return sa < sb
+	}
+	return q.lastSeen[a.source] < q.lastSeen[b.source]
+}
+
+// decrement lowers m[key] by one, removing it at zero.
+func decrement(m map[string]int, key string) {
+	if m[key] <= 1 {
+		delete(m, key)
+	} else {
+		m[key]--
+	}
+}
@@ -0,0 +1,148 @@
+package discovery
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test helper: start acquiring a slot for source's request to domain in the
+// background, sending source on order and then the function that gives the
+// slot back on released once it's let through, and wait until it's queued
+func queueRequest(t *testing.T, q *fetchQueue, source, domain string, order chan<- string, released chan<- func()) {
+	q.mu.Lock()
+	queued := len(q.waiting)
+	q.mu.Unlock()
+	go func() {
+		release, err := q.acquire(context.Background(), source, domain)
+		assert.NoError(t, err)
+		order <- source
+		released <- release
+	}()
+	require.Eventually(t, func() bool {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		return len(q.waiting) > queued
+	}, time.Second, time.Millisecond)
+}
+
+// TestFetchQueue_Fairness verifies that a freed slot goes to a source with
+// fewer requests running, then to the source let through least recently,
+// rather than to whichever request has waited longest
+func TestFetchQueue_Fairness(t *testing.T) {
+	q := newFetchQueue(2)
+	ctx := context.Background()
+
+	// A busy source takes both slots and queues three more requests
+	first, err := q.acquire(ctx, "busy", "a.example")
+	require.NoError(t, err)
+	second, err := q.acquire(ctx, "busy", "a.example")
+	require.NoError(t, err)
+
+	order, released := make(chan string, 5), make(chan func(), 5)
+	for range 3 {
+		queueRequest(t, q, "busy", "a.example", order, released)
+	}
+	queueRequest(t, q, "feed", "b.example", order, released)
+	queueRequest(t, q, "other", "a.example", order, released)
+
+	// The new feed, on a domain with nothing running, goes first
+	first()
+	assert.Equal(t, "feed", <-order)
+
+	// Then the other source on the busy domain, which has none running
+	second()
+	assert.Equal(t, "other", <-order)
+
+	(<-released)()
+	(<-released)()
+	for range 3 {
+		assert.Equal(t, "busy", <-order)
+		(<-released)()
+	}
+}
+
+// TestFetchQueue_RoundRobin verifies that with one slot, sources whose
+// requests wait together take turns instead of being served in order
+func TestFetchQueue_RoundRobin(t *testing.T) {
+	q := newFetchQueue(1)
+	hold, err := q.acquire(context.Background(), "busy", "example.com")
+	require.NoError(t, err)
+
+	order, released := make(chan string, 4), make(chan func(), 4)
+	for _, source := range []string{"busy", "busy", "busy", "feed"} {
+		queueRequest(t, q, source, "example.com", order, released)
+	}
+
+	hold()
+	var got []string
+	for range 4 {
+		got = append(got, <-order)
+		(<-released)()
+	}
+	assert.Equal(t, []string{"feed", "busy", "busy", "busy"}, got)
+}
+
+// TestFetchQueue_Cancel verifies that a request whose context ends while it
+// waits leaves the queue without taking a slot, and that a source fetch is
+// admitted only while fewer than the slots are running
+func TestFetchQueue_Cancel(t *testing.T) {
+	q := newFetchQueue(1)
+	hold, err := q.acquire(context.Background(), "a", "example.com")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = q.acquire(ctx, "b", "example.com")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	hold()
+	assert.Empty(t, q.waiting)
+	assert.Equal(t, 0, q.running)
+
+	finish, err := q.startFetch(context.Background())
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = q.startFetch(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	finish()
+	finish()
+	assert.Equal(t, 0, q.fetches, "finishing twice counts once")
+}
+
+// TestFetchFeedConditional_FetchQueue verifies that a request holds its
+// fetch queue slot until its response body is closed
+func TestFetchFeedConditional_FetchQueue(t *testing.T) {
+	var mu sync.Mutex
+	var running, peak int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		running++
+		peak = max(peak, running)
+		mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		_, _ = w.Write([]byte(rssWithItems(1, false, "")))
+	}))
+	defer server.Close()
+
+	opts := RequestOptions{queue: newFetchQueue(1), queueSource: "source"}
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Go(func() {
+			_, err := FetchFeedConditional(context.Background(), server.URL, nil, nil, opts)
+			assert.NoError(t, err)
+		})
+	}
+	wg.Wait()
+	assert.Equal(t, 1, peak)
+	assert.Equal(t, 0, opts.queue.running)
+}
@@ -0,0 +1,182 @@
+package discovery
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/pevans/newsfed/scraper"
+)
+
+// relativeDate matches phrases such as "2 hours ago", "an hour ago", and
+// "3d ago".
+var relativeDate = regexp.MustCompile(`(?i)\b(\d+|an?|one)\s*(s|secs?|seconds?|m|mins?|minutes?|h|hrs?|hours?|d|days?|w|wks?|weeks?|mos?|months?|y|yrs?|years?)\s+ago\b`)
+
+// ordinalSuffix matches the suffix of a day such as "3rd".
+var ordinalSuffix = regexp.MustCompile(`(?i)\b(\d{1,2})(st|nd|rd|th)\b`)
+
+// meridiem matches a time or marker in the 12-hour clock, such as "3:15pm"
+// or "a.m.", so that it can be given in the upper case time.Parse expects.
+var meridiem = regexp.MustCompile(`(?i)^(\d{1,2}(:\d{2})?)?([ap])\.?m\.?$`)
+
+// fuzzyDateLayouts are the human date formats parseFuzzyDate knows, with
+// commas, ordinal suffixes, and filler words already removed. Numeric dates
+// are read month first, as in the US, unless the day is over 12.
+var fuzzyDateLayouts = func() []string {
+	dates := []string{
+		"January 2 2006", "Jan 2 2006", "2 January 2006", "2 Jan 2006",
+		"Monday January 2 2006", "Mon Jan 2 2006", "Monday 2 January 2006", "Mon 2 Jan 2006",
+		"2006-01-02", "2006/01/02", "1/2/2006", "2/1/2006", "2.1.2006",
+		"January 2", "Jan 2", "2 January", "2 Jan",
+	}
+	times := []string{
+		"", " 15:04", " 15:04:05", " 3:04 PM", " 3:04PM", " 3 PM", " 3PM",
+		" 15:04 MST", " 15:04:05 MST", " 15:04:05 -0700", " 3:04 PM MST",
+	}
+	var layouts []string
+	for _, d := range dates {
+		for _, t := range times {
+			layouts = append(layouts, d+t)
+		}
+	}
+	return layouts
+}()
+
+// fillerWords are dropped from dates so that "March 3 at 10:00" reads as a
+// date and time.
+var fillerWords = map[string]bool{"at": true, "@": true, "-": true, "–": true, "|": true, "·": true, "•": true}
+
+// maxFuzzyDateWords bounds the words parseFuzzyDate searches for a date.
+const maxFuzzyDateWords = 16
+
+// parseFuzzyDate parses a date as people write it: relative phrases such as
+// "2 hours ago" or "yesterday", measured from now, and absolute dates such
+// as "March 3rd, 2024" or "Posted on 3 Mar 2024 at 10:15 am". Surrounding
+// words are ignored, and a date without a year is taken to be the most
+// recent one. Dates without a time zone are taken as UTC. It returns false
+// if s holds no date it knows.
+func parseFuzzyDate(s string, now time.Time) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, false
+	}
+	if t := parseMetaTime(s); t != nil {
+		return *t, true
+	}
+	if t, ok := parseRelativeDate(s, now); ok {
+		return t, true
+	}
+
+	s = ordinalSuffix.ReplaceAllString(strings.ReplaceAll(s, ",", " "), "$1")
+	var words []string
+	for _, word := range strings.Fields(s) {
+		if fillerWords[word] || strings.EqualFold(word, "on") {
+			continue
+		}
+		if m := meridiem.FindStringSubmatch(word); m != nil {
+			word = m[1] + strings.ToUpper(m[3]) + "M"
+		} else if strings.HasSuffix(word, ".") && !strings.ContainsAny(word, "0123456789") {
+			// Abbreviations such as "Jan."
+			word = strings.TrimSuffix(word, ".")
+		}
+		if strings.EqualFold(word, "sept") {
+			word = "Sep"
+		}
+		words = append(words, word)
+	}
+	if len(words) > maxFuzzyDateWords {
+		words = words[:maxFuzzyDateWords]
+	}
+
+	// The longest run of words that reads as a date wins, so that a time
+	// after it is kept
+	for length := len(words); length > 0; length-- {
+		for start := 0; start+length <= len(words); start++ {
+			candidate := strings.Join(words[start:start+length], " ")
+			for _, layout := range fuzzyDateLayouts {
+				t, err := time.Parse(layout, candidate)
+				if err != nil {
+					continue
+				}
+				if t.Year() == 0 {
+					t = t.AddDate(now.Year(), 0, 0)
+					if t.After(now.AddDate(0, 0, 1)) {
+						t = t.AddDate(-1, 0, 0)
+					}
+				}
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// parseRelativeDate parses a date given relative to now, such as "5 mins
+// ago", "yesterday", or "just now".
+func parseRelativeDate(s string, now time.Time) (time.Time, bool) {
+	lower := strings.ToLower(s)
+	if m := relativeDate.FindStringSubmatch(lower); m != nil {
+		n, err := strconv.Atoi(m[1])
+		if err != nil {
+			n = 1 // "a", "an", or "one"
+		}
+		switch unit := m[2]; {
+		case unit == "mo" || strings.HasPrefix(unit, "month"):
+			return now.AddDate(0, -n, 0), true
+		case strings.HasPrefix(unit, "s"):
+			return now.Add(-time.Duration(n) * time.Second), true
+		case strings.HasPrefix(unit, "m"):
+			return now.Add(-time.Duration(n) * time.Minute), true
+		case strings.HasPrefix(unit, "h"):
+			return now.Add(-time.Duration(n) * time.Hour), true
+		case strings.HasPrefix(unit, "d"):
+			return now.AddDate(0, 0, -n), true
+		case strings.HasPrefix(unit, "w"):
+			return now.AddDate(0, 0, -7*n), true
+		case strings.HasPrefix(unit, "y"):
+			return now.AddDate(-n, 0, 0), true
+		}
+	}
+
+	switch {
+	case strings.Contains(lower, "just now") || strings.Contains(lower, "moments ago") ||
+		strings.Contains(lower, "a moment ago") || lower == "now" || lower == "today":
+		return now, true
+	case strings.Contains(lower, "yesterday"):
+		return now.AddDate(0, 0, -1), true
+	}
+	return time.Time{}, false
+}
+
+// scrapedDate returns the date in the element a date selector matched, and
+// the text it was read from. The element's datetime, text, content, and
+// title are tried in turn, each with the config's date formats and then
+// parseFuzzyDate. It returns nil if none holds a date.
+func scrapedDate(s *goquery.Selection, config scraper.ArticleConfig, now time.Time) (*time.Time, string) {
+	if s.Length() == 0 {
+		return nil, ""
+	}
+	formats := config.DateFormats
+	if config.DateFormat != "" {
+		formats = append([]string{config.DateFormat}, formats...)
+	}
+
+	candidates := []string{s.AttrOr("datetime", ""), s.Text(), s.AttrOr("content", ""), s.AttrOr("title", "")}
+	for _, candidate := range candidates {
+		candidate = strings.Join(strings.Fields(candidate), " ")
+		if candidate == "" {
+			continue
+		}
+		for _, format := range formats {
+			if t, err := time.Parse(format, candidate); err == nil {
+				return &t, candidate
+			}
+		}
+		if t, ok := parseFuzzyDate(candidate, now); ok {
+			return &t, candidate
+		}
+	}
+	return nil, ""
+}
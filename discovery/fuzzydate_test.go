@@ -0,0 +1,87 @@
+package discovery
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseFuzzyDate verifies that relative phrases and common human date
+// formats are read, with surrounding words ignored
+func TestParseFuzzyDate(t *testing.T) {
+	now := time.Date(2024, time.March, 10, 12, 0, 0, 0, time.UTC)
+	date := func(year int, month time.Month, day, hour, minute int) time.Time {
+		return time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		input string
+		want  time.Time
+	}{
+		{"2 hours ago", now.Add(-2 * time.Hour)},
+		{"Posted an hour ago", now.Add(-time.Hour)},
+		{"5 mins ago", now.Add(-5 * time.Minute)},
+		{"3d ago", date(2024, time.March, 7, 12, 0)},
+		{"1 week ago", date(2024, time.March, 3, 12, 0)},
+		{"2 months ago", date(2024, time.January, 10, 12, 0)},
+		{"Just now", now},
+		{"Yesterday", date(2024, time.March, 9, 12, 0)},
+		{"March 3rd, 2024", date(2024, time.March, 3, 0, 0)},
+		{"Sept. 21, 2023", date(2023, time.September, 21, 0, 0)},
+		{"Published on Sunday, 3 March 2024 at 10:15 am", date(2024, time.March, 3, 10, 15)},
+		{"Mar 3, 2024 4:30PM", date(2024, time.March, 3, 16, 30)},
+		{"2024-03-03 08:00", date(2024, time.March, 3, 8, 0)},
+		{"2024-03-03T08:00:00Z", date(2024, time.March, 3, 8, 0)},
+		{"3/4/2024", date(2024, time.March, 4, 0, 0)},
+		{"15/03/2024", date(2024, time.March, 15, 0, 0)},
+		{"Updated: 1 March", date(2024, time.March, 1, 0, 0)},
+		{"December 24", date(2023, time.December, 24, 0, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, ok := parseFuzzyDate(tt.input, now)
+			require.True(t, ok)
+			assert.True(t, tt.want.Equal(got), "got %s", got)
+		})
+	}
+
+	for _, input := range []string{"", "invalid-date", "Read more", "2024"} {
+		_, ok := parseFuzzyDate(input, now)
+		assert.False(t, ok, input)
+	}
+}
+
+// TestExtractArticle_FuzzyDates verifies that a date selector reads relative
+// dates without a format, tries each configured format, prefers the
+// element's datetime, and falls back to a <time> element
+func TestExtractArticle_FuzzyDates(t *testing.T) {
+	config := ArticleConfig{TitleSelector: "h1", ContentSelector: "article", DateSelector: ".date"}
+	extract := func(page string, config ArticleConfig) *time.Time {
+		article, err := ExtractArticle(parseTestHTML(t, page), config, "http://example.com/a")
+		require.NoError(t, err)
+		return article.PublishedAt
+	}
+
+	before := time.Now()
+	published := extract(`<html><body><span class="date">2 hours ago</span></body></html>`, config)
+	require.NotNil(t, published)
+	assert.WithinDuration(t, before.Add(-2*time.Hour), *published, time.Minute)
+
+	withFormats := config
+	withFormats.DateFormat = "2006-01-02"
+	withFormats.DateFormats = []string{"02 Jan 06"}
+	published = extract(`<html><body><span class="date">15 Jan 24</span></body></html>`, withFormats)
+	require.NotNil(t, published)
+	assert.Equal(t, time.Date(2024, time.January, 15, 0, 0, 0, 0, time.UTC), *published)
+
+	published = extract(`<html><body><time class="date" datetime="2024-01-15T10:00:00Z">Monday</time></body></html>`, config)
+	require.NotNil(t, published)
+	assert.Equal(t, 10, published.Hour())
+
+	published = extract(`<html><body><span class="date">Breaking</span><time datetime="2024-02-01">Feb 1</time></body></html>`, config)
+	require.NotNil(t, published)
+	assert.Equal(t, time.February, published.Month())
+}
@@ -0,0 +1,255 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pevans/newsfed/newsfeed"
+	"github.com/pevans/newsfed/sources"
+)
+
+// hackerNewsAPIBase is the root of the Hacker News Firebase API. Tests point
+// it at a local server.
+var hackerNewsAPIBase = "https://hacker-news.firebaseio.com/v0"
+
+// maxHackerNewsStories is the number of stories read from a list on each
+// fetch, the length of the Hacker News front page.
+const maxHackerNewsStories = 30
+
+// hackerNewsPages maps each story type to its page on the Hacker News site.
+var hackerNewsPages = map[string]string{
+	"top":  "https://news.ycombinator.com/news",
+	"new":  "https://news.ycombinator.com/newest",
+	"best": "https://news.ycombinator.com/best",
+}
+
+// HackerNewsPageURL returns the Hacker News page that lists stories of the
+// given type ("top", "new", or "best"), or an empty string if the type is not
+// known. It serves as the URL of a hackernews source.
+func HackerNewsPageURL(storyType string) string {
+	return hackerNewsPages[storyType]
+}
+
+// hackerNewsItem is a story as returned by the Hacker News API.
+type hackerNewsItem struct {
+	ID          int    `json:"id"`
+	Type        string `json:"type"`
+	By          string `json:"by"`
+	Time        int64  `json:"time"`
+	Title       string `json:"title"`
+	URL         string `json:"url"`
+	Text        string `json:"text"`
+	Score       int    `json:"score"`
+	Descendants int    `json:"descendants"`
+	Dead        bool   `json:"dead"`
+	Deleted     bool   `json:"deleted"`
+}
+
+// discussionURL returns the story's comment page on Hacker News.
+func (story hackerNewsItem) discussionURL() string {
+	return fmt.Sprintf("https://news.ycombinator.com/item?id=%d", story.ID)
+}
+
+// hackerNewsStoryToNewsItem converts a story to a NewsItem. Stories without a
+// link, such as Ask HN posts, use their discussion page as the URL. The
+// score and comment count are kept in the item's metadata.
+func hackerNewsStoryToNewsItem(story hackerNewsItem, sourceID uuid.UUID) newsfeed.NewsItem {
+	url := story.URL
+	if url == "" {
+		url = story.discussionURL()
+	}
+
+	authors := []string{}
+	if story.By != "" {
+		authors = append(authors, story.By)
+	}
+
+	publisher := "Hacker News"
+	now := time.Now()
+	publishedAt := now
+	if story.Time > 0 {
+		publishedAt = time.Unix(story.Time, 0).UTC()
+	}
+
+	summary, summaryHTML := cleanSummary(story.Text)
+
+	return newsfeed.NewsItem{
+		ID:           uuid.New(),
+		Title:        story.Title,
+		Summary:      summary,
+		SummaryHTML:  summaryHTML,
+		URL:          url,
+		Publisher:    &publisher,
+		Authors:      authors,
+		PublishedAt:  publishedAt,
+		DiscoveredAt: now,
+		SourceID:     &sourceID,
+		Metadata: map[string]any{
+			"hn_id":          story.ID,
+			"score":          story.Score,
+			"comments":       story.Descendants,
+			"discussion_url": story.discussionURL(),
+		},
+	}
+}
+
+// fetchHackerNewsJSON fetches path from the Hacker News API and decodes the
+// response into v.
+func fetchHackerNewsJSON(ctx context.Context, path string, opts RequestOptions, v any) error {
+	req, err := newRequest(ctx, hackerNewsAPIBase+path)
+	if err != nil {
+		return err
+	}
+	opts.apply(req, scraperUserAgent)
+
+	client, err := opts.client()
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s: %w", path, err)
+	}
+	resp, err := opts.do(client, req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s: %w", path, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return newHTTPStatusError(resp)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return nil
+}
+
+// fetchHackerNews discovers stories from the Hacker News API. Stories that
+// are not live, or that score below the source's minimum, are skipped. When
+// plan is non-nil, items are recorded in it instead of being added to the
+// feed.
+func (ds *DiscoveryService) fetchHackerNews(ctx context.Context, source sources.Source, plan *dryRunPlan) (int, DedupReport, error) {
+	config := sources.HackerNewsConfig{StoryType: "top"}
+	if source.HackerNews != nil {
+		config = *source.HackerNews
+	}
+	if HackerNewsPageURL(config.StoryType) == "" {
+		return 0, DedupReport{}, fmt.Errorf("unsupported story type: %s", config.StoryType)
+	}
+
+	opts, err := ds.requestOptionsFor(source)
+	if err != nil {
+		return 0, DedupReport{}, err
+	}
+	domain, err := ds.extractDomain(hackerNewsAPIBase)
+	if err != nil {
+		return 0, DedupReport{}, fmt.Errorf("invalid API URL: %w", err)
+	}
+
+	if err := ds.rateLimiter.wait(ctx, domain); err != nil {
+		return 0, DedupReport{}, err
+	}
+	var storyIDs []int
+	if err := fetchHackerNewsJSON(ctx, "/"+config.StoryType+"stories.json", opts, &storyIDs); err != nil {
+		return 0, DedupReport{}, fmt.Errorf("failed to fetch story list: %w", err)
+	}
+
+	// Apply the 20-item limit on first-time or stale syncs, as for feeds
+	// (Spec 2 section 2.2.3)
+	limit := maxHackerNewsStories
+	if ds.shouldApplyItemLimit(source) {
+		limit = 20
+	}
+	if len(storyIDs) > limit {
+		storyIDs = storyIDs[:limit]
+	}
+
+	index, err := ds.newsFeed.DedupIndex()
+	if err != nil {
+		return 0, DedupReport{}, fmt.Errorf("failed to build dedup index: %w", err)
+	}
+
+	filter, err := newIngestFilter(source.IngestRules, ds.currentConfig().MaxItemAge)
+	if err != nil {
+		return 0, DedupReport{}, err
+	}
+
+	journal := ds.startJournal(source, plan)
+	defer journal.flush()
+
+	newItemCount := 0
+	var dedup DedupReport
+	seen := map[uuid.UUID]bool{} // Items added or updated by this fetch
+	for _, id := range storyIDs {
+		// Stop between stories once the fetch is cancelled
+		if err := ctx.Err(); err != nil {
+			return newItemCount, dedup, err
+		}
+		if filter.full(newItemCount) {
+			break
+		}
+
+		var story hackerNewsItem
+		if err := fetchHackerNewsJSON(ctx, fmt.Sprintf("/item/%d.json", id), opts, &story); err != nil {
+			if ctx.Err() != nil {
+				return newItemCount, dedup, ctx.Err()
+			}
+			if budgetReached(err) != nil {
+				return newItemCount, dedup, err
+			}
+			log.Printf("WARN: Failed to fetch Hacker News story %d: %v", id, err)
+			continue
+		}
+		if story.Type != "story" || story.Dead || story.Deleted || story.Title == "" {
+			continue
+		}
+		if story.Score < config.MinScore {
+			journal.record(hackerNewsStoryToNewsItem(story, source.SourceID), JournalFiltered,
+				fmt.Sprintf("score %d is below min_score %d", story.Score, config.MinScore), uuid.Nil)
+			continue
+		}
+
+		item := hackerNewsStoryToNewsItem(story, source.SourceID)
+		item.Category = source.Category
+		if source.Language != nil {
+			item.Language = *source.Language
+		}
+		if !ds.currentConfig().KeepSummaryHTML {
+			item.SummaryHTML = ""
+		}
+		if reason := filter.rejection(item); reason != "" {
+			journal.record(item, JournalFiltered, reason, uuid.Nil)
+			continue
+		}
+		if kind, existingID := index.MatchScope(item, filter.scope()); kind != newsfeed.NotDuplicate {
+			dedup.record(kind)
+			plan.record(source, item, kind)
+			journal.recordDuplicate(item, kind, existingID)
+			traceDuplicate(ctx, item.URL, kind)
+			if plan == nil && ds.updateDuplicate(index, seen, kind, existingID, item) {
+				dedup.Updated++
+			}
+			continue
+		}
+
+		if plan != nil {
+			plan.record(source, item, newsfeed.NotDuplicate)
+		} else {
+			ds.enrich(ctx, &item)
+			if err := ds.newsFeed.Add(item); err != nil {
+				log.Printf("WARN: Failed to add item %s: %v", item.URL, err)
+				continue
+			}
+			ds.itemAdded(source, item, journal)
+		}
+
+		index.Add(item)
+		seen[item.ID] = true
+		newItemCount++
+	}
+
+	return newItemCount, dedup, nil
+}
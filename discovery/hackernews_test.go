@@ -0,0 +1,74 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pevans/newsfed/newsfeed"
+	"github.com/pevans/newsfed/sources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDiscoveryService_fetchHackerNews verifies that live stories at or
+// above the minimum score become items carrying their score and comment
+// count, and that the rest are skipped.
+func TestDiscoveryService_fetchHackerNews(t *testing.T) {
+	stories := map[string]string{
+		"/beststories.json": `[1, 2, 3, 4]`,
+		"/item/1.json":      `{"id": 1, "type": "story", "by": "pg", "time": 1700000000, "title": "Linked", "url": "http://example.com/1", "score": 150, "descendants": 42}`,
+		"/item/2.json":      `{"id": 2, "type": "story", "by": "dang", "time": 1700000000, "title": "Ask HN: Text", "text": "<p>Some <i>text</i></p>", "score": 120, "descendants": 7}`,
+		"/item/3.json":      `{"id": 3, "type": "story", "title": "Low", "url": "http://example.com/3", "score": 5}`,
+		"/item/4.json":      `{"id": 4, "type": "story", "title": "Dead", "url": "http://example.com/4", "score": 500, "dead": true}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := stories[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = fmt.Fprint(w, body)
+	}))
+	defer server.Close()
+
+	original := hackerNewsAPIBase
+	hackerNewsAPIBase = server.URL
+	defer func() { hackerNewsAPIBase = original }()
+
+	sourceStore := newTestSourceStore(t)
+	newsFeed := newsfeed.NewMemoryNewsFeed()
+
+	service := NewDiscoveryService(sourceStore, newsFeed, nil)
+	source, err := sourceStore.CreateSource("hackernews", HackerNewsPageURL("best"), "Hacker News (best)", nil, nil)
+	require.NoError(t, err)
+	source.HackerNews = &sources.HackerNewsConfig{StoryType: "best", MinScore: 100}
+
+	count, _, err := service.fetchHackerNews(context.Background(), *source, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	result, err := newsFeed.List()
+	require.NoError(t, err)
+	require.Len(t, result.Items, 2)
+
+	byTitle := map[string]newsfeed.NewsItem{}
+	for _, item := range result.Items {
+		byTitle[item.Title] = item
+	}
+
+	linked := byTitle["Linked"]
+	assert.Equal(t, "http://example.com/1", linked.URL)
+	assert.Equal(t, []string{"pg"}, linked.Authors)
+	require.NotNil(t, linked.Publisher)
+	assert.Equal(t, "Hacker News", *linked.Publisher)
+	assert.EqualValues(t, 150, linked.Metadata["score"])
+	assert.EqualValues(t, 42, linked.Metadata["comments"])
+	assert.Equal(t, "https://news.ycombinator.com/item?id=1", linked.Metadata["discussion_url"])
+
+	text := byTitle["Ask HN: Text"]
+	assert.Equal(t, "https://news.ycombinator.com/item?id=2", text.URL)
+	assert.Equal(t, "Some text", text.Summary)
+}
@@ -0,0 +1,104 @@
+package discovery
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pevans/newsfed/internal/httpjson"
+)
+
+// HealthStatus is the body of the /healthz and /readyz responses.
+type HealthStatus struct {
+	Status   string            `json:"status"` // "ok" or "unavailable"
+	Checks   map[string]string `json:"checks"` // "ok" or the failure, by check
+	LastTick *time.Time        `json:"last_tick,omitempty"`
+}
+
+// tick records that the discovery loop is alive.
+func (ds *DiscoveryService) tick() {
+	ds.lastTick.Store(time.Now().UnixNano())
+}
+
+// LastTick returns when the discovery loop last woke up, or the zero time if
+// the loop is not running.
+func (ds *DiscoveryService) LastTick() time.Time {
+	nanos := ds.lastTick.Load()
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos)
+}
+
+// checkLoop reports whether the discovery loop is running and has woken up
+// recently. The loop wakes at least once per check interval, so missing
+// several in a row means it is stuck.
+func (ds *DiscoveryService) checkLoop(now time.Time) error {
+	last := ds.LastTick()
+	if last.IsZero() {
+		return fmt.Errorf("discovery loop is not running")
+	}
+	if stale := 3 * ds.currentConfig().checkInterval(); now.Sub(last) > stale {
+		return fmt.Errorf("discovery loop has not run since %s", last.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// Health reports whether the discovery loop is alive.
+func (ds *DiscoveryService) Health() HealthStatus {
+	return ds.healthStatus(map[string]error{
+		"discovery": ds.checkLoop(time.Now()),
+	})
+}
+
+// Ready reports whether the service can do its work: the discovery loop is
+// alive, the metadata database is reachable, and the news feed's storage is
+// reachable and writable.
+func (ds *DiscoveryService) Ready() HealthStatus {
+	return ds.healthStatus(map[string]error{
+		"discovery": ds.checkLoop(time.Now()),
+		"metadata":  ds.sourceStore.Ping(),
+		"feed":      ds.newsFeed.Ping(),
+	})
+}
+
+// healthStatus builds a HealthStatus from the results of named checks.
+func (ds *DiscoveryService) healthStatus(checks map[string]error) HealthStatus {
+	status := HealthStatus{Status: "ok", Checks: make(map[string]string, len(checks))}
+	for name, err := range checks {
+		if err != nil {
+			status.Status = "unavailable"
+			status.Checks[name] = err.Error()
+		} else {
+			status.Checks[name] = "ok"
+		}
+	}
+	if last := ds.LastTick(); !last.IsZero() {
+		last = last.UTC()
+		status.LastTick = &last
+	}
+	return status
+}
+
+// HealthHandler serves Health as JSON, for liveness probes at /healthz.
+func (ds *DiscoveryService) HealthHandler() http.Handler {
+	return healthHandler(ds.Health)
+}
+
+// ReadyHandler serves Ready as JSON, for readiness probes at /readyz.
+func (ds *DiscoveryService) ReadyHandler() http.Handler {
+	return healthHandler(ds.Ready)
+}
+
+// healthHandler serves the status returned by check, with 503 Service
+// Unavailable when any check fails.
+func healthHandler(check func() HealthStatus) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := check()
+		code := http.StatusOK
+		if status.Status != "ok" {
+			code = http.StatusServiceUnavailable
+		}
+		httpjson.Write(w, code, status)
+	})
+}
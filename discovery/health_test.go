@@ -0,0 +1,79 @@
+package discovery
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/pevans/newsfed/newsfeed"
+	"github.com/pevans/newsfed/sources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test helper: create a discovery service over a temporary directory feed
+func newHealthTestService(t *testing.T) (*DiscoveryService, string) {
+	tempDir := t.TempDir()
+	sourceStore, err := sources.NewSourceStore(tempDir + "/metadata.db")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = sourceStore.Close() })
+
+	feedDir := tempDir + "/.news"
+	newsFeed, err := newsfeed.NewNewsFeed(feedDir)
+	require.NoError(t, err)
+
+	return NewDiscoveryService(sourceStore, newsFeed, nil), feedDir
+}
+
+// Test helper: serve one request and decode the response
+func getHealth(t *testing.T, handler http.Handler) (int, HealthStatus) {
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+	var status HealthStatus
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&status))
+	return rec.Code, status
+}
+
+// TestHealthHandler_Loop verifies that liveness follows the discovery loop:
+// unavailable before it runs, ok while it ticks, and unavailable once it has
+// missed several check intervals.
+func TestHealthHandler_Loop(t *testing.T) {
+	service, _ := newHealthTestService(t)
+
+	code, status := getHealth(t, service.HealthHandler())
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "unavailable", status.Status)
+	assert.Nil(t, status.LastTick)
+
+	service.tick()
+	code, status = getHealth(t, service.HealthHandler())
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, map[string]string{"discovery": "ok"}, status.Checks)
+	require.NotNil(t, status.LastTick)
+
+	service.lastTick.Store(time.Now().Add(-time.Hour).UnixNano())
+	code, status = getHealth(t, service.HealthHandler())
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Contains(t, status.Checks["discovery"], "has not run since")
+}
+
+// TestReadyHandler_Storage verifies that readiness fails when the feed
+// directory can't be written.
+func TestReadyHandler_Storage(t *testing.T) {
+	service, feedDir := newHealthTestService(t)
+	service.tick()
+
+	code, status := getHealth(t, service.ReadyHandler())
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, map[string]string{"discovery": "ok", "metadata": "ok", "feed": "ok"}, status.Checks)
+
+	require.NoError(t, os.RemoveAll(feedDir))
+	code, status = getHealth(t, service.ReadyHandler())
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "ok", status.Checks["metadata"])
+	assert.Contains(t, status.Checks["feed"], "not writable")
+}
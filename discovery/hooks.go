@@ -0,0 +1,192 @@
+package discovery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pevans/newsfed/newsfeed"
+	"github.com/pevans/newsfed/sources"
+)
+
+// The events hooks can be run on.
+const (
+	HookItemAdded      = "item_added"      // An item was added to the feed
+	HookSourceDisabled = "source_disabled" // A source was disabled after failing
+	HookSyncCompleted  = "sync_completed"  // A source was fetched, or failed to be
+)
+
+// HookEvents are the events hooks can be run on.
+var HookEvents = []string{HookItemAdded, HookSourceDisabled, HookSyncCompleted}
+
+// defaultHookTimeout is how long a hook may run when it doesn't say.
+const defaultHookTimeout = 30 * time.Second
+
+// maxQueuedHooks is how many hook runs may wait for the ones before them.
+// Runs beyond it are dropped, so that a slow hook can't hold an unbounded
+// backlog of items.
+const maxQueuedHooks = 1000
+
+// Hook is an external program run when an event happens, such as a script
+// that passes new items on to another tool. It receives a HookEvent as JSON
+// on its standard input, and the event's name in NEWSFED_EVENT. Hooks run
+// in the background, one at a time and in the order their events happened;
+// a hook that fails or runs past its timeout is logged and never affects
+// discovery.
+type Hook struct {
+	Event string // One of HookEvents
+	// Command is the program and its arguments; it is run directly, not
+	// through a shell
+	Command []string
+	// Longest the command may run before it is killed; zero means thirty
+	// seconds
+	Timeout time.Duration
+}
+
+// HookEvent is what a hook receives on its standard input.
+type HookEvent struct {
+	Event      string    `json:"event"`
+	Time       time.Time `json:"time"`
+	SourceID   uuid.UUID `json:"source_id"`
+	SourceName string    `json:"source_name"`
+	// Item is the item added, for item_added
+	Item *newsfeed.NewsItem `json:"item,omitempty"`
+	// Sync is the fetch attempt, as recorded in the sync history, for
+	// sync_completed
+	Sync *sources.SyncAttempt `json:"sync,omitempty"`
+	// Error is the error that disabled the source, for source_disabled
+	Error string `json:"error,omitempty"`
+}
+
+// hookRun is a hook waiting to run with its input.
+type hookRun struct {
+	hook  Hook
+	input []byte
+}
+
+// runHooks queues the hooks configured for event.Event to run with event.
+func (ds *DiscoveryService) runHooks(event HookEvent) {
+	var hooks []Hook
+	for _, hook := range ds.currentConfig().Hooks {
+		if hook.Event == event.Event {
+			hooks = append(hooks, hook)
+		}
+	}
+	if len(hooks) == 0 {
+		return
+	}
+
+	event.Time = time.Now().UTC()
+	input, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("WARN: Failed to encode %s event for hooks: %v", event.Event, err)
+		return
+	}
+
+	ds.hookMu.Lock()
+	defer ds.hookMu.Unlock()
+	for _, hook := range hooks {
+		if len(ds.hookQueue) >= maxQueuedHooks {
+			log.Printf("WARN: Dropped %s hook: %d hooks are already waiting", hook.Event, maxQueuedHooks)
+			continue
+		}
+		ds.hookQueue = append(ds.hookQueue, hookRun{hook: hook, input: input})
+	}
+	if !ds.hookRunning && len(ds.hookQueue) > 0 {
+		ds.hookRunning = true
+		ds.hookWG.Go(ds.drainHooks)
+	}
+}
+
+// drainHooks runs queued hooks in order until none are left.
+func (ds *DiscoveryService) drainHooks() {
+	for {
+		ds.hookMu.Lock()
+		if len(ds.hookQueue) == 0 {
+			ds.hookRunning = false
+			ds.hookMu.Unlock()
+			return
+		}
+		run := ds.hookQueue[0]
+		ds.hookQueue = ds.hookQueue[1:]
+		ds.hookMu.Unlock()
+
+		if err := run.hook.run(run.input); err != nil {
+			log.Printf("WARN: %s hook failed: %v", run.hook.Event, err)
+		}
+	}
+}
+
+// WaitForHooks waits for the hooks queued so far to finish running.
+func (ds *DiscoveryService) WaitForHooks() {
+	ds.hookWG.Wait()
+}
+
+// run runs the hook's command with input on its standard input.
+func (h Hook) run(input []byte) error {
+	if len(h.Command) == 0 {
+		return fmt.Errorf("no command configured")
+	}
+	timeout := h.Timeout
+	if timeout <= 0 {
+		timeout = defaultHookTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	stderr := &hookOutput{limit: hookOutputLimit}
+	cmd := exec.CommandContext(ctx, h.Command[0], h.Command[1:]...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stderr = stderr
+	cmd.Env = append(os.Environ(), "NEWSFED_EVENT="+h.Event)
+	// Don't wait on programs the hook left running with its output open
+	cmd.WaitDelay = time.Second
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			err = fmt.Errorf("timed out after %v", timeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s: %w: %s", h.Command[0], err, truncateHookOutput(msg))
+		}
+		return fmt.Errorf("%s: %w", h.Command[0], err)
+	}
+	return nil
+}
+
+// hookOutputLimit is how much of a hook's error output is logged.
+const hookOutputLimit = 500
+
+// truncateHookOutput shortens a hook's error output for the log.
+func truncateHookOutput(s string) string {
+	if len(s) <= hookOutputLimit {
+		return s
+	}
+	return strings.ToValidUTF8(s[:hookOutputLimit], "") + "..."
+}
+
+// hookOutput keeps the first limit bytes written to it and discards the
+// rest, so that a hook writing without end to its standard error can't
+// exhaust memory. One byte past the limit is kept so that
+// truncateHookOutput still marks the output as cut short.
+type hookOutput struct {
+	buf   bytes.Buffer
+	limit int
+}
+
+func (o *hookOutput) Write(p []byte) (int, error) {
+	if room := o.limit + 1 - o.buf.Len(); room > 0 {
+		o.buf.Write(p[:min(len(p), room)])
+	}
+	return len(p), nil
+}
+
+func (o *hookOutput) String() string {
+	return o.buf.String()
+}
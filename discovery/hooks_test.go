@@ -0,0 +1,200 @@
+package discovery
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pevans/newsfed/newsfeed"
+	"github.com/pevans/newsfed/sources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test helper: a hook for event that appends each event it receives, and
+// the NEWSFED_EVENT it was run with, to a file, one per line
+func recordingHook(event, path string) Hook {
+	return Hook{
+		Event:   event,
+		Command: []string{"sh", "-c", `cat >> "$1" && echo >> "$1" && echo "$NEWSFED_EVENT" >> "$1.env"`, "sh", path},
+	}
+}
+
+// Test helper: read the events a recording hook wrote to path
+func readHookEvents(t *testing.T, path string) []HookEvent {
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer func() { _ = file.Close() }()
+
+	var events []HookEvent
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var event HookEvent
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+		events = append(events, event)
+	}
+	require.NoError(t, scanner.Err())
+	return events
+}
+
+// TestDiscoveryService_fetchRSSFeed_Hooks verifies that item_added hooks
+// receive each new item once, with the event in NEWSFED_EVENT, and that dry
+// runs don't run hooks
+func TestDiscoveryService_fetchRSSFeed_Hooks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(rssWithItems(3, false, "")))
+	}))
+	defer server.Close()
+
+	tempDir := t.TempDir()
+	sourceStore, err := sources.NewSourceStore(tempDir + "/metadata.db")
+	require.NoError(t, err)
+	defer func() { _ = sourceStore.Close() }()
+
+	path := filepath.Join(tempDir, "events.jsonl")
+	config := DefaultDiscoveryConfig()
+	config.Hooks = []Hook{recordingHook(HookItemAdded, path)}
+	service := NewDiscoveryService(sourceStore, newsfeed.NewMemoryNewsFeed(), config)
+	source, err := sourceStore.CreateSource("rss", server.URL, "Test Feed", nil, nil)
+	require.NoError(t, err)
+
+	_, _, err = service.fetchRSSFeed(context.Background(), *source, &dryRunPlan{})
+	require.NoError(t, err)
+	service.WaitForHooks()
+	assert.NoFileExists(t, path, "dry runs don't run hooks")
+
+	items, _, err := service.fetchRSSFeed(context.Background(), *source, nil)
+	require.NoError(t, err)
+	require.Equal(t, 3, items)
+	service.WaitForHooks()
+
+	events := readHookEvents(t, path)
+	require.Len(t, events, 3)
+	for _, event := range events {
+		assert.Equal(t, HookItemAdded, event.Event)
+		assert.Equal(t, source.SourceID, event.SourceID)
+		assert.Equal(t, "Test Feed", event.SourceName)
+		require.NotNil(t, event.Item)
+		assert.False(t, event.Time.IsZero())
+	}
+	feedItems, err := service.newsFeed.List()
+	require.NoError(t, err)
+	added := map[string]bool{}
+	for _, item := range feedItems.Items {
+		added[item.URL] = true
+	}
+	hooked := map[string]bool{}
+	for _, event := range events {
+		assert.True(t, added[event.Item.URL], "hooked item %s is in the feed", event.Item.URL)
+		hooked[event.Item.URL] = true
+	}
+	assert.Len(t, hooked, 3)
+
+	env, err := os.ReadFile(path + ".env")
+	require.NoError(t, err)
+	assert.Equal(t, "item_added\nitem_added\nitem_added\n", string(env))
+}
+
+// TestDiscoveryService_Hooks_SyncAndDisable verifies that sync_completed
+// hooks receive the fetch attempt and that source_disabled hooks run when a
+// source is disabled, with the error that disabled it
+func TestDiscoveryService_Hooks_SyncAndDisable(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceStore, err := sources.NewSourceStore(tempDir + "/metadata.db")
+	require.NoError(t, err)
+	defer func() { _ = sourceStore.Close() }()
+
+	syncPath := filepath.Join(tempDir, "sync.jsonl")
+	disabledPath := filepath.Join(tempDir, "disabled.jsonl")
+	config := DefaultDiscoveryConfig()
+	config.DisableThreshold = 2
+	config.Hooks = []Hook{
+		recordingHook(HookSyncCompleted, syncPath),
+		recordingHook(HookSourceDisabled, disabledPath),
+	}
+	service := NewDiscoveryService(sourceStore, newsfeed.NewMemoryNewsFeed(), config)
+	source, err := sourceStore.CreateSource("rss", "http://example.com/feed", "Test Feed", nil, nil)
+	require.NoError(t, err)
+
+	fetchErr := errors.New("connection refused")
+	service.recordSyncAttempt(*source, time.Now(), time.Second, 0, fetchErr)
+	service.handleFetchError(*source, fetchErr)
+	service.WaitForHooks()
+	assert.NoFileExists(t, disabledPath, "one failure doesn't disable the source")
+
+	updated, err := sourceStore.GetSource(source.SourceID)
+	require.NoError(t, err)
+	service.handleFetchError(*updated, fetchErr)
+	service.WaitForHooks()
+
+	syncs := readHookEvents(t, syncPath)
+	require.Len(t, syncs, 1)
+	require.NotNil(t, syncs[0].Sync)
+	assert.Equal(t, source.SourceID, syncs[0].Sync.SourceID)
+	require.NotNil(t, syncs[0].Sync.Error)
+	assert.Equal(t, "connection refused", *syncs[0].Sync.Error)
+
+	disabled := readHookEvents(t, disabledPath)
+	require.Len(t, disabled, 1)
+	assert.Equal(t, source.SourceID, disabled[0].SourceID)
+	assert.Equal(t, "connection refused", disabled[0].Error)
+}
+
+// TestHook_run verifies that a hook that fails reports its error output, cut
+// short if it is long, and that one that runs past its timeout is stopped
+func TestHook_run(t *testing.T) {
+	hook := Hook{Event: HookItemAdded, Command: []string{"sh", "-c", "echo no good >&2; exit 3"}}
+	err := hook.run([]byte("{}"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exit status 3")
+	assert.Contains(t, err.Error(), "no good")
+
+	hook = Hook{Event: HookItemAdded, Command: []string{"sh", "-c", "head -c 1000000 /dev/zero | tr '\\0' x >&2; exit 1"}}
+	err = hook.run([]byte("{}"))
+	require.Error(t, err)
+	assert.True(t, strings.HasSuffix(err.Error(), strings.Repeat("x", hookOutputLimit)+"..."))
+
+	hook = Hook{Event: HookItemAdded, Command: []string{"sleep", "10"}, Timeout: 50 * time.Millisecond}
+	start := time.Now()
+	err = hook.run([]byte("{}"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "timed out after 50ms")
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	hook = Hook{Event: HookItemAdded, Command: []string{"true"}}
+	assert.NoError(t, hook.run([]byte("{}")))
+}
+
+// TestDiscoveryService_runHooks_Order verifies that hooks run one at a time
+// in the order their events happened, even when an earlier one fails
+func TestDiscoveryService_runHooks_Order(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	config := DefaultDiscoveryConfig()
+	config.Hooks = []Hook{
+		{Event: HookSyncCompleted, Command: []string{"false"}},
+		recordingHook(HookSyncCompleted, path),
+	}
+	service := NewDiscoveryService(nil, nil, config)
+
+	var names []string
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		names = append(names, name)
+		service.runHooks(HookEvent{Event: HookSyncCompleted, SourceName: name})
+		service.runHooks(HookEvent{Event: HookItemAdded, SourceName: name})
+	}
+	service.WaitForHooks()
+
+	var got []string
+	for _, event := range readHookEvents(t, path) {
+		got = append(got, event.SourceName)
+	}
+	assert.Equal(t, names, got)
+}
@@ -0,0 +1,179 @@
+package discovery
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/pevans/newsfed/sources"
+)
+
+// customClients caches one HTTP client per combination of proxy and TLS
+// settings, so that connections are reused across fetches. A CA file is read
+// when its client is first built.
+var (
+	customClientsMu sync.Mutex
+	customClients   = map[string]*http.Client{}
+)
+
+// client returns the HTTP client to send requests with: the shared client,
+// or one that routes requests through opts.ProxyURL and verifies servers as
+// opts.TLS asks. Custom clients have the same per-request timeout as the
+// shared client. With opts.Jar set, the client sends and keeps cookies in
+// it.
+func (opts RequestOptions) client() (*http.Client, error) {
+	client, err := opts.transportClient()
+	if err != nil || opts.Jar == nil {
+		return client, err
+	}
+	withJar := *client
+	withJar.Jar = opts.Jar
+	return &withJar, nil
+}
+
+// transportClient returns the cached client for opts's proxy, TLS, and
+// address settings, without a cookie jar.
+func (opts RequestOptions) transportClient() (*http.Client, error) {
+	var tlsSettings sources.TLSConfig
+	if opts.TLS != nil {
+		tlsSettings = *opts.TLS
+	}
+	publicOnly := opts.PublicOnly && opts.ProxyURL == ""
+	if opts.ProxyURL == "" && tlsSettings.IsZero() && !publicOnly {
+		return httpClient, nil
+	}
+
+	key := fmt.Sprintf("%s|%+v|%t", opts.ProxyURL, tlsSettings, publicOnly)
+
+	customClientsMu.Lock()
+	defer customClientsMu.Unlock()
+
+	if client, ok := customClients[key]; ok {
+		return client, nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if opts.ProxyURL != "" {
+		if err := sources.ValidateProxyURL(opts.ProxyURL); err != nil {
+			return nil, err
+		}
+		proxyURL, err := url.Parse(opts.ProxyURL)
+		if err != nil {
+			return nil, sources.ErrInvalidProxyURL
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	if !tlsSettings.IsZero() {
+		tlsConfig, err := buildTLSConfig(tlsSettings)
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+	if publicOnly {
+		dialer := &net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+			Control:   dialPublicOnly,
+		}
+		transport.DialContext = dialer.DialContext
+	}
+
+	client := &http.Client{
+		Timeout:   httpClient.Timeout,
+		Transport: transport,
+	}
+	customClients[key] = client
+	return client, nil
+}
+
+// buildTLSConfig returns the crypto/tls configuration for a source's TLS
+// settings. Certificates in the CA file are trusted in addition to the
+// system roots.
+func buildTLSConfig(settings sources.TLSConfig) (*tls.Config, error) {
+	minVersion, err := settings.Version()
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         minVersion,
+		InsecureSkipVerify: settings.InsecureSkipVerify,
+	}
+
+	if settings.CAFile != "" {
+		pem, err := os.ReadFile(settings.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		roots, err := x509.SystemCertPool()
+		if err != nil {
+			roots = x509.NewCertPool()
+		}
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", settings.CAFile)
+		}
+		tlsConfig.RootCAs = roots
+	}
+
+	return tlsConfig, nil
+}
+
+// ErrPrivateAddress is returned for a URL whose host is, or resolves to, an
+// address that isn't on the public internet.
+var ErrPrivateAddress = errors.New("url must not point to a private or local address")
+
+// dialPublicOnly is a net.Dialer Control function that refuses to connect
+// to addresses that aren't public. It sees the address after its host has
+// been resolved, so a name that resolves differently on a second lookup
+// can't get past it.
+func dialPublicOnly(network, address string, c syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil || !isPublicAddr(addrPort.Addr()) {
+		return ErrPrivateAddress
+	}
+	return nil
+}
+
+// checkPublicHost returns ErrPrivateAddress if host is, or resolves to, an
+// address that isn't public. It lets a URL be refused before anything is
+// saved for it, where dialPublicOnly only stops it being fetched.
+func checkPublicHost(ctx context.Context, host string) error {
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if !isPublicAddr(addr) {
+			return ErrPrivateAddress
+		}
+		return nil
+	}
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		// Nothing can be fetched from a host that doesn't resolve, and
+		// dialPublicOnly checks the address if it resolves later
+		return nil
+	}
+	for _, addr := range addrs {
+		if !isPublicAddr(addr) {
+			return ErrPrivateAddress
+		}
+	}
+	return nil
+}
+
+// isPublicAddr reports whether addr is on the public internet: not
+// loopback, private, link-local, multicast, or unspecified.
+func isPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsValid() && !addr.IsLoopback() && !addr.IsPrivate() &&
+		!addr.IsLinkLocalUnicast() && !addr.IsLinkLocalMulticast() &&
+		!addr.IsInterfaceLocalMulticast() && !addr.IsMulticast() && !addr.IsUnspecified()
+}
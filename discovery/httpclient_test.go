@@ -0,0 +1,142 @@
+package discovery
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/pevans/newsfed/newsfeed"
+	"github.com/pevans/newsfed/sources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test helper: start an HTTP proxy that answers every request with a feed
+// and records the hosts requested through it
+func newTestProxy(t *testing.T) (*httptest.Server, func() []string) {
+	var mu sync.Mutex
+	var hosts []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hosts = append(hosts, r.URL.Host)
+		mu.Unlock()
+		_, _ = fmt.Fprint(w, `<?xml version="1.0"?>
+<rss version="2.0"><channel><title>Hidden</title>
+<item><title>One</title><link>http://feed.invalid/1</link></item>
+</channel></rss>`)
+	}))
+	t.Cleanup(proxy.Close)
+
+	return proxy, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), hosts...)
+	}
+}
+
+// TestFetchRSSFeed_Proxy verifies that a source's proxy, or else the default
+// proxy, carries its requests
+func TestFetchRSSFeed_Proxy(t *testing.T) {
+	sourceProxy, sourceProxyHosts := newTestProxy(t)
+	defaultProxy, defaultProxyHosts := newTestProxy(t)
+
+	sourceStore := newTestSourceStore(t)
+	newsFeed := newsfeed.NewMemoryNewsFeed()
+
+	config := DefaultDiscoveryConfig()
+	config.ProxyURL = defaultProxy.URL
+	service := NewDiscoveryService(sourceStore, newsFeed, config)
+
+	// feed.invalid does not resolve, so the fetch only succeeds through a
+	// proxy
+	source, err := sourceStore.CreateSource("rss", "http://feed.invalid/feed", "Hidden", nil, nil)
+	require.NoError(t, err)
+	proxyURL := sourceProxy.URL
+	require.NoError(t, sourceStore.UpdateSource(source.SourceID, sources.SourceUpdate{ProxyURL: &proxyURL}))
+	source, err = sourceStore.GetSource(source.SourceID)
+	require.NoError(t, err)
+
+	count, _, err := service.fetchRSSFeed(context.Background(), *source, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, []string{"feed.invalid"}, sourceProxyHosts())
+	assert.Empty(t, defaultProxyHosts())
+
+	other, err := sourceStore.CreateSource("rss", "http://other.invalid/feed", "Other", nil, nil)
+	require.NoError(t, err)
+	_, _, err = service.fetchRSSFeed(context.Background(), *other, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"other.invalid"}, defaultProxyHosts())
+}
+
+// TestRequestOptions_Client verifies that clients are shared per proxy and
+// TLS settings and that invalid proxies are rejected
+func TestRequestOptions_Client(t *testing.T) {
+	client, err := RequestOptions{}.client()
+	require.NoError(t, err)
+	assert.Same(t, httpClient, client)
+
+	first, err := RequestOptions{ProxyURL: "socks5h://127.0.0.1:9050"}.client()
+	require.NoError(t, err)
+	second, err := RequestOptions{ProxyURL: "socks5h://127.0.0.1:9050"}.client()
+	require.NoError(t, err)
+	assert.Same(t, first, second)
+	assert.NotSame(t, httpClient, first)
+
+	withTLS, err := RequestOptions{ProxyURL: "socks5h://127.0.0.1:9050", TLS: &sources.TLSConfig{MinVersion: "1.2"}}.client()
+	require.NoError(t, err)
+	assert.NotSame(t, first, withTLS)
+
+	_, err = RequestOptions{ProxyURL: "ftp://127.0.0.1:21"}.client()
+	assert.ErrorIs(t, err, sources.ErrInvalidProxyURL)
+}
+
+// TestFetchFeedConditional_TLS verifies that a server with an untrusted
+// certificate can be fetched by trusting its CA or skipping verification,
+// that the minimum TLS version is enforced, and that certificate failures
+// are not treated as permanent
+func TestFetchFeedConditional_TLS(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `<?xml version="1.0"?>
+<rss version="2.0"><channel><title>Intranet</title>
+<item><title>One</title><link>https://intranet.example.com/1</link></item>
+</channel></rss>`)
+	}))
+	server.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+	server.Config.ErrorLog = log.New(io.Discard, "", 0) // Rejected handshakes are expected
+	server.StartTLS()
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	require.NoError(t, os.WriteFile(caFile, caPEM, 0o600))
+
+	ctx := context.Background()
+	service := NewDiscoveryService(nil, nil, nil)
+
+	_, err := FetchFeedConditional(ctx, server.URL, nil, nil, RequestOptions{})
+	require.Error(t, err)
+	assert.False(t, service.isPermanentError(err), "certificate errors should be transient")
+
+	result, err := FetchFeedConditional(ctx, server.URL, nil, nil, RequestOptions{TLS: &sources.TLSConfig{CAFile: caFile}})
+	require.NoError(t, err)
+	assert.Len(t, result.Feed.Items, 1)
+
+	_, err = FetchFeedConditional(ctx, server.URL, nil, nil, RequestOptions{TLS: &sources.TLSConfig{InsecureSkipVerify: true}})
+	require.NoError(t, err)
+
+	_, err = FetchFeedConditional(ctx, server.URL, nil, nil, RequestOptions{TLS: &sources.TLSConfig{CAFile: caFile, MinVersion: "1.3"}})
+	assert.Error(t, err, "server only speaks TLS 1.2")
+
+	_, err = FetchFeedConditional(ctx, server.URL, nil, nil, RequestOptions{TLS: &sources.TLSConfig{CAFile: filepath.Join(t.TempDir(), "missing.pem")}})
+	assert.ErrorContains(t, err, "failed to read CA file")
+}
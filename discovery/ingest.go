@@ -0,0 +1,139 @@
+package discovery
+
+import (
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pevans/newsfed/newsfeed"
+	"github.com/pevans/newsfed/sources"
+)
+
+// ingestFilter applies a source's ingest rules to the items found in one
+// sync. A nil filter lets every item through.
+type ingestFilter struct {
+	rules   sources.IngestRules
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+	cutoff  time.Time // Items published before it are skipped; zero means no limit
+}
+
+// newIngestFilter compiles rules, returning nil if there are none. maxAge is
+// the age past which items are skipped for every source, which the rules'
+// own max_age replaces; zero means no limit. Rules are validated when they
+// are stored, but are checked again in case they were edited in the
+// database.
+func newIngestFilter(rules *sources.IngestRules, maxAge time.Duration) (*ingestFilter, error) {
+	if (rules == nil || rules.IsZero()) && maxAge <= 0 {
+		return nil, nil
+	}
+
+	f := &ingestFilter{}
+	if rules != nil {
+		if err := rules.Validate(); err != nil {
+			return nil, err
+		}
+		f.rules = *rules
+	}
+	if f.rules.MaxAge != "" {
+		maxAge, _ = sources.ParseMaxAge(f.rules.MaxAge)
+	}
+	if maxAge > 0 {
+		f.cutoff = time.Now().Add(-maxAge)
+	}
+	for _, pattern := range f.rules.IncludeURLs {
+		f.include = append(f.include, regexp.MustCompile(pattern))
+	}
+	for _, pattern := range f.rules.ExcludeURLs {
+		f.exclude = append(f.exclude, regexp.MustCompile(pattern))
+	}
+	return f, nil
+}
+
+// full reports whether added new items reach the source's per-sync limit.
+func (f *ingestFilter) full(added int) bool {
+	return f != nil && f.rules.MaxItems > 0 && added >= f.rules.MaxItems
+}
+
+// urlRejection returns why the URL rules skip an item at rawURL, or "" if
+// they let it through. It lets list pages skip articles without fetching
+// them.
+func (f *ingestFilter) urlRejection(rawURL string) string {
+	if f == nil {
+		return ""
+	}
+	if len(f.include) > 0 && !matchesAny(f.include, rawURL) {
+		return "URL matches none of include_urls"
+	}
+	if matchesAny(f.exclude, rawURL) {
+		return "URL matches exclude_urls"
+	}
+	return ""
+}
+
+// scope returns which items the source's items are deduplicated against.
+func (f *ingestFilter) scope() newsfeed.DedupScope {
+	if f == nil {
+		return newsfeed.ScopeGlobal
+	}
+	switch f.rules.Dedup {
+	case sources.DedupSource:
+		return newsfeed.ScopeSource
+	case sources.DedupOff:
+		return newsfeed.ScopeOff
+	}
+	return newsfeed.ScopeGlobal
+}
+
+// allows reports whether every rule lets item through.
+func (f *ingestFilter) allows(item newsfeed.NewsItem) bool {
+	return f.rejection(item) == ""
+}
+
+// rejection returns why the rules skip item, or "" if every rule lets it
+// through.
+func (f *ingestFilter) rejection(item newsfeed.NewsItem) string {
+	if f == nil {
+		return ""
+	}
+	if reason := f.urlRejection(item.URL); reason != "" {
+		return reason
+	}
+	if item.PublishedAt.Before(f.cutoff) {
+		return "published before the max_age cutoff"
+	}
+	if utf8.RuneCountInString(strings.TrimSpace(item.Summary)) < f.rules.MinContentLength {
+		return "summary shorter than min_content_length"
+	}
+
+	title := strings.ToLower(item.Title)
+	if len(f.rules.IncludeKeywords) > 0 && !containsAny(title, f.rules.IncludeKeywords) {
+		return "title has none of include_keywords"
+	}
+	if containsAny(title, f.rules.ExcludeKeywords) {
+		return "title has one of exclude_keywords"
+	}
+	return ""
+}
+
+// matchesAny reports whether any of patterns matches s.
+func matchesAny(patterns []*regexp.Regexp, s string) bool {
+	for _, re := range patterns {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsAny reports whether the lowercased title contains any of
+// keywords, ignoring case.
+func containsAny(title string, keywords []string) bool {
+	for _, keyword := range keywords {
+		if strings.Contains(title, strings.ToLower(strings.TrimSpace(keyword))) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,180 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pevans/newsfed/newsfeed"
+	"github.com/pevans/newsfed/sources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestIngestFilter_allows verifies that each ingest rule skips the items it
+// should
+func TestIngestFilter_allows(t *testing.T) {
+	item := newsfeed.NewsItem{
+		Title:   "Go 1.25 Released",
+		URL:     "https://example.com/news/go-1-25",
+		Summary: "The Go team has released version 1.25.",
+	}
+
+	tests := []struct {
+		name  string
+		rules sources.IngestRules
+		want  bool
+	}{
+		{"no rules", sources.IngestRules{}, true},
+		{"include URL matches", sources.IngestRules{IncludeURLs: []string{`/news/`}}, true},
+		{"include URL misses", sources.IngestRules{IncludeURLs: []string{`/blog/`}}, false},
+		{"exclude URL matches", sources.IngestRules{ExcludeURLs: []string{`go-\d`}}, false},
+		{"summary long enough", sources.IngestRules{MinContentLength: 20}, true},
+		{"summary too short", sources.IngestRules{MinContentLength: 100}, false},
+		{"include keyword ignores case", sources.IngestRules{IncludeKeywords: []string{"RELEASED"}}, true},
+		{"include keyword misses", sources.IngestRules{IncludeKeywords: []string{"rust", "zig"}}, false},
+		{"exclude keyword matches", sources.IngestRules{ExcludeKeywords: []string{"go 1.25"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := newIngestFilter(&tt.rules, 0)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, filter.allows(item))
+		})
+	}
+}
+
+// TestIngestFilter_full verifies the per-sync item limit, and that a nil
+// filter has none
+func TestIngestFilter_full(t *testing.T) {
+	var none *ingestFilter
+	assert.False(t, none.full(1000))
+	assert.True(t, none.allows(newsfeed.NewsItem{}))
+
+	filter, err := newIngestFilter(&sources.IngestRules{MaxItems: 2}, 0)
+	require.NoError(t, err)
+	assert.False(t, filter.full(1))
+	assert.True(t, filter.full(2))
+}
+
+// TestIngestFilter_maxAge verifies that items published before the global
+// age limit are skipped, and that a source's own max_age replaces it
+func TestIngestFilter_maxAge(t *testing.T) {
+	old := newsfeed.NewsItem{PublishedAt: time.Now().Add(-60 * 24 * time.Hour)}
+	recent := newsfeed.NewsItem{PublishedAt: time.Now().Add(-time.Hour)}
+
+	filter, err := newIngestFilter(nil, 30*24*time.Hour)
+	require.NoError(t, err)
+	assert.False(t, filter.allows(old))
+	assert.True(t, filter.allows(recent))
+
+	filter, err = newIngestFilter(&sources.IngestRules{MaxAge: "90d"}, 30*24*time.Hour)
+	require.NoError(t, err)
+	assert.True(t, filter.allows(old))
+
+	filter, err = newIngestFilter(&sources.IngestRules{MaxAge: "30m"}, 0)
+	require.NoError(t, err)
+	assert.False(t, filter.allows(recent))
+}
+
+// TestDiscoveryService_fetchRSSFeed_IngestRules verifies that items a source's
+// rules reject are not added, and that the item limit counts only new items
+func TestDiscoveryService_fetchRSSFeed_IngestRules(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var items strings.Builder
+		for i := 1; i <= 5; i++ {
+			fmt.Fprintf(&items, "<item><title>Story %d</title><link>http://example.com/%d</link></item>\n", i, i)
+		}
+		fmt.Fprint(&items, "<item><title>Sponsored</title><link>http://example.com/sponsored/1</link></item>\n")
+		_, _ = fmt.Fprintf(w, `<?xml version="1.0"?>
+<rss version="2.0"><channel><title>Test</title>
+%s</channel></rss>`, items.String())
+	}))
+	defer server.Close()
+
+	sourceStore := newTestSourceStore(t)
+	newsFeed := newsfeed.NewMemoryNewsFeed()
+
+	service := NewDiscoveryService(sourceStore, newsFeed, nil)
+	source, err := sourceStore.CreateSource("rss", server.URL, "Test Feed", nil, nil)
+	require.NoError(t, err)
+	source.IngestRules = &sources.IngestRules{
+		MaxItems:        2,
+		ExcludeURLs:     []string{`/sponsored/`},
+		ExcludeKeywords: []string{"story 1"},
+	}
+
+	count, _, err := service.fetchRSSFeed(context.Background(), *source, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	// The next sync skips the items already added rather than counting them
+	// toward the limit
+	count, _, err = service.fetchRSSFeed(context.Background(), *source, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	result, err := newsFeed.Query(newsfeed.ListOptions{})
+	require.NoError(t, err)
+	var urls []string
+	for _, item := range result.Items {
+		urls = append(urls, item.URL)
+	}
+	assert.ElementsMatch(t, []string{
+		"http://example.com/2", "http://example.com/3",
+		"http://example.com/4", "http://example.com/5",
+	}, urls)
+}
+
+// TestDiscoveryService_fetchRSSFeed_DedupPerSource verifies that a source
+// deduplicating against its own items keeps an article another source has
+// already added, attributed to it
+func TestDiscoveryService_fetchRSSFeed_DedupPerSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `<?xml version="1.0"?>
+<rss version="2.0"><channel><title>Test</title>
+<item><title>Shared</title><link>http://example.com/shared</link></item>
+</channel></rss>`)
+	}))
+	defer server.Close()
+
+	sourceStore := newTestSourceStore(t)
+
+	newsFeed := newsfeed.NewMemoryNewsFeed()
+	service := NewDiscoveryService(sourceStore, newsFeed, nil)
+	first, err := sourceStore.CreateSource("rss", server.URL+"/a", "First", nil, nil)
+	require.NoError(t, err)
+	second, err := sourceStore.CreateSource("rss", server.URL+"/b", "Second", nil, nil)
+	require.NoError(t, err)
+
+	count, _, err := service.fetchRSSFeed(context.Background(), *first, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	// Deduplicated globally, the second source's copy is skipped
+	count, dedup, err := service.fetchRSSFeed(context.Background(), *second, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+	assert.Equal(t, 1, dedup.URLDuplicates)
+
+	second.IngestRules = &sources.IngestRules{Dedup: sources.DedupSource}
+	count, _, err = service.fetchRSSFeed(context.Background(), *second, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	// Fetching again finds the source's own copy
+	count, _, err = service.fetchRSSFeed(context.Background(), *second, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	result, err := newsFeed.List()
+	require.NoError(t, err)
+	require.Len(t, result.Items, 2)
+	assert.Equal(t, first.SourceID, *result.Items[0].SourceID)
+	assert.Equal(t, second.SourceID, *result.Items[1].SourceID)
+}
@@ -0,0 +1,43 @@
+package discovery
+
+import (
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pevans/newsfed/newsfeed"
+)
+
+// updateDuplicate applies a publisher's changes to the stored item that
+// fetched duplicates, such as a corrected headline or a moved URL. Only
+// items matched by GUID or URL, from the same source, whose title, summary,
+// or URL have changed are updated; content duplicates may be different
+// articles and are left alone. Items in seen, the ones this fetch has
+// already added or updated, are not updated again, so a feed that lists an
+// item twice doesn't flip it between the copies. It reports whether the item
+// was updated, and if so, re-indexes it and adds it to seen.
+func (ds *DiscoveryService) updateDuplicate(index *newsfeed.DedupIndex, seen map[uuid.UUID]bool, kind newsfeed.DuplicateKind, id uuid.UUID, fetched newsfeed.NewsItem) bool {
+	if kind != newsfeed.DuplicateGUID && kind != newsfeed.DuplicateURL {
+		return false
+	}
+	if seen[id] || !index.Revised(id, fetched) {
+		return false
+	}
+
+	existing, err := ds.newsFeed.Get(id)
+	if err != nil {
+		log.Printf("WARN: Failed to read item %s to update it: %v", id, err)
+		return false
+	}
+	if existing == nil || !existing.ApplyRevision(fetched, time.Now().UTC()) {
+		return false
+	}
+	if err := ds.newsFeed.Update(*existing); err != nil {
+		log.Printf("WARN: Failed to update item %s: %v", existing.URL, err)
+		return false
+	}
+
+	index.Add(*existing)
+	seen[id] = true
+	return true
+}
@@ -0,0 +1,129 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/pevans/newsfed/newsfeed"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDiscoveryService_fetchRSSFeed_GUIDUpdate verifies that an item whose
+// feed GUID is already stored is not added again, and that the publisher's
+// corrections to it are applied without losing the reader's state
+func TestDiscoveryService_fetchRSSFeed_GUIDUpdate(t *testing.T) {
+	var corrected atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		title, link := "Old Headline", "http://example.com/old"
+		if corrected.Load() {
+			title, link = "Fixed Headline", "http://example.com/fixed"
+		}
+		_, _ = fmt.Fprintf(w, `<?xml version="1.0"?>
+<rss version="2.0"><channel><title>Test</title>
+<item><title>%s</title><link>%s</link><guid isPermaLink="false">story-1</guid></item>
+</channel></rss>`, title, link)
+	}))
+	defer server.Close()
+
+	sourceStore := newTestSourceStore(t)
+	newsFeed := newsfeed.NewMemoryNewsFeed()
+
+	service := NewDiscoveryService(sourceStore, newsFeed, nil)
+	source, err := sourceStore.CreateSource("rss", server.URL, "Test Feed", nil, nil)
+	require.NoError(t, err)
+
+	count, _, err := service.fetchRSSFeed(context.Background(), *source, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	result, err := newsFeed.Query(newsfeed.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, result.Items, 1)
+	original := result.Items[0]
+	assert.Equal(t, "story-1", original.GUID)
+	_, err = newsFeed.MarkRead(original.ID)
+	require.NoError(t, err)
+
+	// An unchanged item is skipped without being rewritten
+	count, dedup, err := service.fetchRSSFeed(context.Background(), *source, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+	assert.Equal(t, 1, dedup.GUIDDuplicates)
+	assert.Equal(t, 0, dedup.Updated)
+
+	corrected.Store(true)
+	count, dedup, err = service.fetchRSSFeed(context.Background(), *source, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+	assert.Equal(t, 1, dedup.GUIDDuplicates)
+	assert.Equal(t, 1, dedup.Updated)
+
+	updated, err := newsFeed.Get(original.ID)
+	require.NoError(t, err)
+	require.NotNil(t, updated)
+	assert.Equal(t, "Fixed Headline", updated.Title)
+	assert.Equal(t, "http://example.com/fixed", updated.URL)
+	assert.NotNil(t, updated.ReadAt, "reader state should be kept")
+
+	result, err = newsFeed.Query(newsfeed.ListOptions{})
+	require.NoError(t, err)
+	assert.Len(t, result.Items, 1)
+}
+
+// TestDiscoveryService_fetchRSSFeed_URLUpdate verifies that an item matched
+// by URL is updated when its headline changes, with the change audited, and
+// that a reader's edit is not undone by a feed that hasn't changed
+func TestDiscoveryService_fetchRSSFeed_URLUpdate(t *testing.T) {
+	var title atomic.Value
+	title.Store("Big Nwes")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, `<?xml version="1.0"?>
+<rss version="2.0"><channel><title>Test</title>
+<item><title>%s</title><link>http://example.com/1</link><description>Something happened.</description></item>
+</channel></rss>`, title.Load())
+	}))
+	defer server.Close()
+
+	sourceStore := newTestSourceStore(t)
+	newsFeed := newsfeed.NewMemoryNewsFeed()
+
+	service := NewDiscoveryService(sourceStore, newsFeed, nil)
+	source, err := sourceStore.CreateSource("rss", server.URL, "Test Feed", nil, nil)
+	require.NoError(t, err)
+
+	_, _, err = service.fetchRSSFeed(context.Background(), *source, nil)
+	require.NoError(t, err)
+	result, err := newsFeed.Query(newsfeed.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, result.Items, 1)
+	id := result.Items[0].ID
+
+	title.Store("Big News")
+	_, dedup, err := service.fetchRSSFeed(context.Background(), *source, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, dedup.URLDuplicates)
+	assert.Equal(t, 1, dedup.Updated)
+
+	item, err := newsFeed.Get(id)
+	require.NoError(t, err)
+	assert.Equal(t, "Big News", item.Title)
+	assert.NotNil(t, item.UpdatedAt)
+	require.Len(t, item.Changes, 1)
+	assert.Equal(t, map[string]string{"title": "Big Nwes"}, item.Changes[0].Previous)
+
+	myTitle := "My Title"
+	_, err = newsFeed.Edit(id, newsfeed.ItemEdit{Title: &myTitle})
+	require.NoError(t, err)
+
+	_, dedup, err = service.fetchRSSFeed(context.Background(), *source, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 0, dedup.Updated)
+	item, err = newsFeed.Get(id)
+	require.NoError(t, err)
+	assert.Equal(t, "My Title", item.Title)
+}
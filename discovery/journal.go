@@ -0,0 +1,235 @@
+package discovery
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pevans/newsfed/newsfeed"
+	"github.com/pevans/newsfed/sources"
+)
+
+// The decisions recorded in the journal.
+const (
+	JournalAdded    = "added"    // The item was added to the feed
+	JournalDeduped  = "deduped"  // The item duplicates one already in the feed
+	JournalFiltered = "filtered" // The source's ingest rules skipped the item
+	JournalInvalid  = "invalid"  // The scraped article failed validation
+)
+
+// JournalEntry records what discovery did with one item it found, and why.
+type JournalEntry struct {
+	Time     time.Time `json:"time"`
+	SourceID uuid.UUID `json:"source_id"`
+	Decision string    `json:"decision"`
+	URL      string    `json:"url"`
+	Title    string    `json:"title,omitempty"`
+	Reason   string    `json:"reason,omitempty"`
+	// ItemID is the item added, or the existing item a duplicate matched
+	ItemID *uuid.UUID `json:"item_id,omitempty"`
+}
+
+// JournalFilter selects journal entries. Zero fields match every entry.
+type JournalFilter struct {
+	Since    time.Time
+	SourceID *uuid.UUID
+	Decision string
+}
+
+// matches reports whether entry is selected by f.
+func (f JournalFilter) matches(entry JournalEntry) bool {
+	if entry.Time.Before(f.Since) {
+		return false
+	}
+	if f.SourceID != nil && entry.SourceID != *f.SourceID {
+		return false
+	}
+	return f.Decision == "" || entry.Decision == f.Decision
+}
+
+// ReadJournal returns the entries of the journal at path selected by
+// filter, oldest first. A journal that doesn't exist yet has no entries.
+// Lines that can't be read, such as one cut short by a crash, are skipped.
+func ReadJournal(path string, filter JournalFilter) ([]JournalEntry, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	var entries []JournalEntry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for scanner.Scan() {
+		var entry JournalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		if filter.matches(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read journal: %w", err)
+	}
+	return entries, nil
+}
+
+// appendJournal writes entries to the end of the journal at path, creating
+// it if needed.
+func appendJournal(path string, entries []JournalEntry) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create journal directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open journal: %w", err)
+	}
+
+	w := bufio.NewWriter(file)
+	enc := json.NewEncoder(w)
+	for _, entry := range entries {
+		if err := enc.Encode(entry); err != nil {
+			_ = file.Close()
+			return fmt.Errorf("failed to write journal: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	return file.Close()
+}
+
+// PruneJournal removes the entries of the journal at path recorded before
+// the given time, and returns how many were removed. The journal is
+// rewritten to a temporary file that then replaces it.
+func PruneJournal(path string, before time.Time) (int, error) {
+	entries, err := ReadJournal(path, JournalFilter{})
+	if err != nil || len(entries) == 0 {
+		return 0, err
+	}
+	keep := entries[:0]
+	for _, entry := range entries {
+		if !entry.Time.Before(before) {
+			keep = append(keep, entry)
+		}
+	}
+	pruned := len(entries) - len(keep)
+	if pruned == 0 {
+		return 0, nil
+	}
+
+	tmp := path + ".tmp"
+	_ = os.Remove(tmp)
+	if err := appendJournal(tmp, keep); err != nil {
+		_ = os.Remove(tmp)
+		return 0, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return 0, fmt.Errorf("failed to replace journal: %w", err)
+	}
+	return pruned, nil
+}
+
+// fetchJournal collects the journal entries of one fetch of a source, which
+// are written together by flush. A nil fetchJournal, as used for dry runs
+// and when no journal is configured, records nothing.
+type fetchJournal struct {
+	ds      *DiscoveryService
+	path    string
+	source  uuid.UUID
+	entries []JournalEntry
+}
+
+// startJournal returns the journal for a fetch of source, or nil if the
+// fetch is a dry run or the journal is off.
+func (ds *DiscoveryService) startJournal(source sources.Source, plan *dryRunPlan) *fetchJournal {
+	path := ds.currentConfig().JournalPath
+	if path == "" || plan != nil {
+		return nil
+	}
+	return &fetchJournal{ds: ds, path: path, source: source.SourceID}
+}
+
+// record notes the decision made about item. itemID is the item added or
+// matched, if any.
+func (j *fetchJournal) record(item newsfeed.NewsItem, decision, reason string, itemID uuid.UUID) {
+	if j == nil {
+		return
+	}
+	entry := JournalEntry{
+		Time:     time.Now().UTC(),
+		SourceID: j.source,
+		Decision: decision,
+		URL:      item.URL,
+		Title:    item.Title,
+		Reason:   reason,
+	}
+	if itemID != uuid.Nil {
+		entry.ItemID = &itemID
+	}
+	j.entries = append(j.entries, entry)
+}
+
+// recordDuplicate notes that item was skipped as a duplicate of the kind
+// given of the existing item with ID existingID.
+func (j *fetchJournal) recordDuplicate(item newsfeed.NewsItem, kind newsfeed.DuplicateKind, existingID uuid.UUID) {
+	j.record(item, JournalDeduped, duplicateReason(kind), existingID)
+}
+
+// flush writes the recorded entries to the journal. Failures are logged
+// rather than failing the fetch.
+func (j *fetchJournal) flush() {
+	if j == nil || len(j.entries) == 0 {
+		return
+	}
+	j.ds.journalMu.Lock()
+	defer j.ds.journalMu.Unlock()
+	if err := appendJournal(j.path, j.entries); err != nil {
+		log.Printf("WARN: Failed to write discovery journal: %v", err)
+	}
+	j.entries = nil
+}
+
+// duplicateReason describes a duplicate of the given kind for the journal.
+func duplicateReason(kind newsfeed.DuplicateKind) string {
+	switch kind {
+	case newsfeed.DuplicateGUID:
+		return "same GUID as an existing item from this source"
+	case newsfeed.DuplicateURL:
+		return "same URL as an existing item"
+	case newsfeed.DuplicateContent:
+		return "same title and summary as an existing item"
+	}
+	return string(kind)
+}
+
+// pruneJournal removes journal entries older than the configured journal
+// retention.
+func (ds *DiscoveryService) pruneJournal() {
+	config := ds.currentConfig()
+	if config.JournalPath == "" || config.JournalRetention <= 0 {
+		return
+	}
+
+	ds.journalMu.Lock()
+	defer ds.journalMu.Unlock()
+	pruned, err := PruneJournal(config.JournalPath, time.Now().Add(-config.JournalRetention))
+	if err != nil {
+		log.Printf("ERROR: Pruning the discovery journal failed: %v", err)
+	}
+	if pruned > 0 {
+		log.Printf("INFO: Pruned %d journal entries older than %v", pruned, config.JournalRetention)
+	}
+}
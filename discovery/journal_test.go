@@ -0,0 +1,129 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pevans/newsfed/newsfeed"
+	"github.com/pevans/newsfed/sources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestReadJournal verifies that entries are selected by time, source, and
+// decision, that unreadable lines are skipped, and that a missing journal
+// has no entries
+func TestReadJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	entries, err := ReadJournal(path, JournalFilter{})
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	now := time.Now().UTC()
+	source, other := uuid.New(), uuid.New()
+	require.NoError(t, appendJournal(path, []JournalEntry{
+		{Time: now.Add(-48 * time.Hour), SourceID: source, Decision: JournalAdded, URL: "http://example.com/old"},
+		{Time: now, SourceID: source, Decision: JournalDeduped, URL: "http://example.com/dup"},
+		{Time: now, SourceID: other, Decision: JournalFiltered, URL: "http://example.com/other"},
+	}))
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"time": "cut short`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	entries, err = ReadJournal(path, JournalFilter{})
+	require.NoError(t, err)
+	assert.Len(t, entries, 3)
+
+	entries, err = ReadJournal(path, JournalFilter{Since: now.Add(-time.Hour), SourceID: &source})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "http://example.com/dup", entries[0].URL)
+
+	entries, err = ReadJournal(path, JournalFilter{Decision: JournalFiltered})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, other, entries[0].SourceID)
+}
+
+// TestPruneJournal verifies that entries recorded before the cutoff are
+// removed and the rest kept
+func TestPruneJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	now := time.Now().UTC()
+	require.NoError(t, appendJournal(path, []JournalEntry{
+		{Time: now.Add(-30 * 24 * time.Hour), Decision: JournalAdded, URL: "http://example.com/old"},
+		{Time: now, Decision: JournalAdded, URL: "http://example.com/new"},
+	}))
+
+	pruned, err := PruneJournal(path, now.Add(-24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, pruned)
+
+	entries, err := ReadJournal(path, JournalFilter{})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "http://example.com/new", entries[0].URL)
+}
+
+// TestDiscoveryService_fetchRSSFeed_Journal verifies that each item of a
+// fetch is journaled with what was done with it and why, and that dry runs
+// aren't journaled
+func TestDiscoveryService_fetchRSSFeed_Journal(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `<?xml version="1.0"?>
+<rss version="2.0"><channel><title>Test</title>
+<item><title>New story</title><link>http://example.com/new</link></item>
+<item><title>Known story</title><link>http://example.com/known</link></item>
+<item><title>Sponsored post</title><link>http://example.com/sponsored</link></item>
+</channel></rss>`)
+	}))
+	defer server.Close()
+
+	tempDir := t.TempDir()
+	sourceStore, err := sources.NewSourceStore(tempDir + "/metadata.db")
+	require.NoError(t, err)
+	defer func() { _ = sourceStore.Close() }()
+	newsFeed := newsfeed.NewMemoryNewsFeed()
+	known := newsfeed.NewsItem{ID: uuid.New(), Title: "Known story", URL: "http://example.com/known"}
+	require.NoError(t, newsFeed.Add(known))
+
+	config := DefaultDiscoveryConfig()
+	config.JournalPath = filepath.Join(tempDir, "journal.jsonl")
+	service := NewDiscoveryService(sourceStore, newsFeed, config)
+	source, err := sourceStore.CreateSource("rss", server.URL, "Test Feed", nil, nil)
+	require.NoError(t, err)
+	source.IngestRules = &sources.IngestRules{ExcludeKeywords: []string{"sponsored"}}
+
+	_, _, err = service.fetchRSSFeed(context.Background(), *source, &dryRunPlan{})
+	require.NoError(t, err)
+	entries, err := ReadJournal(config.JournalPath, JournalFilter{})
+	require.NoError(t, err)
+	assert.Empty(t, entries, "dry runs aren't journaled")
+
+	_, _, err = service.fetchRSSFeed(context.Background(), *source, nil)
+	require.NoError(t, err)
+	entries, err = ReadJournal(config.JournalPath, JournalFilter{SourceID: &source.SourceID})
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+
+	decisions := map[string]JournalEntry{}
+	for _, entry := range entries {
+		decisions[entry.URL] = entry
+	}
+	assert.Equal(t, JournalAdded, decisions["http://example.com/new"].Decision)
+	assert.NotNil(t, decisions["http://example.com/new"].ItemID)
+	assert.Equal(t, JournalDeduped, decisions["http://example.com/known"].Decision)
+	assert.Equal(t, "same URL as an existing item", decisions["http://example.com/known"].Reason)
+	assert.Equal(t, &known.ID, decisions["http://example.com/known"].ItemID)
+	assert.Equal(t, JournalFiltered, decisions["http://example.com/sponsored"].Decision)
+	assert.Equal(t, "title has one of exclude_keywords", decisions["http://example.com/sponsored"].Reason)
+}
@@ -0,0 +1,165 @@
+package discovery
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"github.com/pevans/newsfed/newsfeed"
+)
+
+// LanguageEnricher detects the language of an item from its title and
+// summary and stores it as an ISO 639-1 code in the item's Language. When
+// the text is too short or too mixed to tell, the item keeps the language
+// it already has, which is the source's expected language if one is set.
+type LanguageEnricher struct{}
+
+// Enrich implements Enricher.
+func (LanguageEnricher) Enrich(_ context.Context, item *newsfeed.NewsItem) error {
+	if lang := DetectLanguage(item.Title + "\n" + item.Summary); lang != "" {
+		item.Language = lang
+	}
+	return nil
+}
+
+// scriptLanguages maps writing systems used by a single language (or by
+// one language far more than any other) to that language.
+var scriptLanguages = []struct {
+	script *unicode.RangeTable
+	lang   string
+}{
+	{unicode.Hangul, "ko"},
+	{unicode.Greek, "el"},
+	{unicode.Arabic, "ar"},
+	{unicode.Hebrew, "he"},
+	{unicode.Thai, "th"},
+	{unicode.Devanagari, "hi"},
+}
+
+// stopWords lists very common words of each language written in the Latin
+// script. Text is attributed to the language whose words it uses most.
+var stopWords = map[string][]string{
+	"en": {"the", "and", "of", "to", "is", "in", "that", "for", "with", "was", "on", "are", "this", "it", "be", "as", "by", "from", "have", "at", "not", "you", "they", "which"},
+	"de": {"der", "die", "und", "das", "ist", "nicht", "mit", "den", "von", "ein", "eine", "zu", "sich", "auf", "für", "dem", "auch", "es", "im", "wird", "sind", "werden"},
+	"fr": {"le", "la", "les", "et", "des", "est", "une", "un", "du", "dans", "pour", "que", "qui", "pas", "sur", "au", "avec", "ce", "sont", "par", "aux", "été"},
+	"es": {"el", "la", "los", "las", "y", "de", "que", "en", "un", "una", "es", "por", "con", "para", "del", "se", "no", "al", "como", "más", "su", "está"},
+	"it": {"il", "la", "di", "che", "e", "un", "una", "per", "non", "è", "sono", "del", "della", "con", "nel", "gli", "le", "si", "da", "al", "anche", "questo"},
+	"pt": {"o", "a", "os", "as", "de", "que", "e", "do", "da", "em", "um", "uma", "para", "com", "não", "é", "no", "na", "dos", "por", "são", "mais"},
+	"nl": {"de", "het", "een", "en", "van", "is", "dat", "niet", "op", "te", "zijn", "voor", "met", "die", "ook", "aan", "er", "maar", "wordt", "naar"},
+	"sv": {"och", "att", "det", "är", "en", "som", "på", "för", "med", "av", "inte", "till", "den", "har", "om", "ett", "var", "men", "de", "kan"},
+	"pl": {"i", "w", "na", "z", "się", "nie", "do", "to", "że", "jest", "o", "jak", "ale", "od", "po", "za", "czy", "przez", "dla", "są"},
+}
+
+// stopWordLanguages maps each stop word to the languages that use it.
+var stopWordLanguages = func() map[string][]string {
+	m := map[string][]string{}
+	for lang, words := range stopWords {
+		for _, w := range words {
+			m[w] = append(m[w], lang)
+		}
+	}
+	return m
+}()
+
+// minScriptLetters and minStopWords are the least evidence DetectLanguage
+// needs before naming a language.
+const (
+	minScriptLetters = 3
+	minStopWords     = 2
+)
+
+// DetectLanguage returns the ISO 639-1 code of the language text is written
+// in, or "" if it cannot tell. Languages with their own script are told
+// apart by script; languages written in the Latin script are told apart by
+// their most common words. Cyrillic text is Ukrainian if it has letters
+// only Ukrainian uses, and Russian otherwise; Han text is Japanese if it
+// has kana, and Chinese otherwise.
+func DetectLanguage(text string) string {
+	var letters, latin, cyrillic, han, kana, ukrainian int
+	scriptCounts := make([]int, len(scriptLanguages))
+
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+			if strings.ContainsRune("іїєґІЇЄҐ", r) {
+				ukrainian++
+			}
+		case unicode.Is(unicode.Hiragana, r), unicode.Is(unicode.Katakana, r):
+			kana++
+		case unicode.Is(unicode.Han, r):
+			han++
+		default:
+			for i, sl := range scriptLanguages {
+				if unicode.Is(sl.script, r) {
+					scriptCounts[i]++
+					break
+				}
+			}
+		}
+	}
+
+	if letters < minScriptLetters {
+		return ""
+	}
+
+	// A script used by most of the letters decides the language outright
+	majority := func(n int) bool { return n*2 > letters }
+	switch {
+	case majority(kana + han):
+		if kana > 0 {
+			return "ja"
+		}
+		return "zh"
+	case majority(cyrillic):
+		if ukrainian > 0 {
+			return "uk"
+		}
+		return "ru"
+	}
+	for i, sl := range scriptLanguages {
+		if majority(scriptCounts[i]) {
+			return sl.lang
+		}
+	}
+	if !majority(latin) {
+		return ""
+	}
+
+	return detectLatinLanguage(text)
+}
+
+// detectLatinLanguage returns the language whose stop words text uses most,
+// or "" if no language clearly leads.
+func detectLatinLanguage(text string) string {
+	scores := map[string]int{}
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	for _, w := range words {
+		for _, lang := range stopWordLanguages[w] {
+			scores[lang]++
+		}
+	}
+
+	best, bestScore, runnerUp := "", 0, 0
+	for lang, score := range scores {
+		switch {
+		case score > bestScore:
+			best, bestScore, runnerUp = lang, score, bestScore
+		case score > runnerUp:
+			runnerUp = score
+		}
+	}
+
+	if bestScore < minStopWords || bestScore == runnerUp {
+		return ""
+	}
+	return best
+}
@@ -0,0 +1,99 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pevans/newsfed/newsfeed"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDetectLanguage verifies detection by script and by common words, and
+// that short or ambiguous text is left undetected
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"The committee said that the new rules are expected to take effect in the spring", "en"},
+		{"Die Regierung hat am Montag angekündigt, dass die neuen Regeln nicht sofort gelten werden", "de"},
+		{"Le gouvernement a annoncé que les nouvelles règles ne sont pas encore en vigueur dans le pays", "fr"},
+		{"El gobierno anunció que las nuevas normas entrarán en vigor para los ciudadanos del país", "es"},
+		{"Il governo ha annunciato che le nuove regole non sono ancora in vigore per questo anno", "it"},
+		{"O governo anunciou que as novas regras não entram em vigor para os cidadãos do país", "pt"},
+		{"De regering heeft maandag aangekondigd dat het nieuwe beleid niet voor iedereen geldt", "nl"},
+		{"Regeringen meddelade att de nya reglerna inte gäller för alla som bor i landet", "sv"},
+		{"Rząd ogłosił, że nowe przepisy nie są jeszcze w mocy i czy to się zmieni", "pl"},
+		{"Правительство объявило о новых правилах", "ru"},
+		{"Уряд оголосив про нові правила для їхніх громадян", "uk"},
+		{"政府は新しい規則を発表しました", "ja"},
+		{"政府宣布了新的规定", "zh"},
+		{"정부가 새로운 규칙을 발표했다", "ko"},
+		{"Η κυβέρνηση ανακοίνωσε νέους κανόνες", "el"},
+		{"أعلنت الحكومة عن قواعد جديدة", "ar"},
+		{"Kubernetes 1.31", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want+" "+tt.text, func(t *testing.T) {
+			assert.Equal(t, tt.want, DetectLanguage(tt.text))
+		})
+	}
+}
+
+// TestFetchRSSFeed_Language verifies that detection wins over the source's
+// expected language, which in turn wins over the feed's declared language,
+// for items whose language can't be detected
+func TestFetchRSSFeed_Language(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `<?xml version="1.0"?>
+<rss version="2.0"><channel><title>Mixed</title><language>fr-FR</language>
+<item><title>Die Regierung hat die neuen Regeln nicht veröffentlicht</title><link>http://example.com/1</link></item>
+<item><title>Kubernetes 1.31</title><link>http://example.com/2</link></item>
+</channel></rss>`)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		expected *string
+		want     map[string]string
+	}{
+		{
+			name: "feed language",
+			want: map[string]string{"http://example.com/1": "de", "http://example.com/2": "fr"},
+		},
+		{
+			name:     "source language",
+			expected: strPtr("en"),
+			want:     map[string]string{"http://example.com/1": "de", "http://example.com/2": "en"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sourceStore := newTestSourceStore(t)
+			newsFeed := newsfeed.NewMemoryNewsFeed()
+			service := NewDiscoveryService(sourceStore, newsFeed, nil)
+
+			source, err := sourceStore.CreateSource("rss", server.URL, "Mixed", nil, nil)
+			require.NoError(t, err)
+			source.Language = tt.expected
+			_, _, err = service.fetchRSSFeed(context.Background(), *source, nil)
+			require.NoError(t, err)
+
+			result, err := newsFeed.List()
+			require.NoError(t, err)
+			got := map[string]string{}
+			for _, item := range result.Items {
+				got[item.URL] = item.Language
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package discovery
+
+import (
+	"encoding/json"
+	"html"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// articleMetadata is what a page declares about itself in JSON-LD, Open
+// Graph, and standard meta tags.
+type articleMetadata struct {
+	Title       string
+	Description string
+	Authors     []string
+	PublishedAt *time.Time
+	// PublishedRaw is the published date as the page gave it
+	PublishedRaw string
+}
+
+// pageMetadata returns the article metadata doc declares. Each field is
+// taken from a schema.org Article in JSON-LD if there is one, else from Open
+// Graph tags, else from standard meta tags. Implements Spec 3 section 3.4.2.
+func pageMetadata(doc *goquery.Document) articleMetadata {
+	meta := jsonLDMetadata(doc)
+
+	if meta.Title == "" {
+		meta.Title = metaContent(doc, `meta[property="og:title"]`, `meta[name="twitter:title"]`)
+	}
+	if meta.Description == "" {
+		meta.Description = metaContent(doc, `meta[property="og:description"]`, `meta[name="description"]`, `meta[name="twitter:description"]`)
+	}
+	for _, selector := range []string{`meta[property="article:author"]`, `meta[name="author"]`} {
+		// article:author is often a profile URL rather than a name
+		author := metaContent(doc, selector)
+		if len(meta.Authors) == 0 && author != "" && !strings.HasPrefix(author, "http") {
+			meta.Authors = ParseAuthors(author)
+		}
+	}
+	if meta.PublishedAt == nil {
+		raw := metaContent(doc,
+			`meta[property="article:published_time"]`,
+			`meta[itemprop="datePublished"]`,
+			`meta[name="date"]`,
+			`meta[name="dc.date"]`,
+		)
+		if meta.PublishedAt = parseMetaTime(raw); meta.PublishedAt != nil {
+			meta.PublishedRaw = raw
+		}
+	}
+	return meta
+}
+
+// metaContent returns the content of the first tag matching one of
+// selectors, in order, that has any.
+func metaContent(doc *goquery.Document, selectors ...string) string {
+	for _, selector := range selectors {
+		content := strings.TrimSpace(doc.Find(selector).First().AttrOr("content", ""))
+		if content != "" {
+			return content
+		}
+	}
+	return ""
+}
+
+// jsonLDMetadata returns the metadata of the first schema.org Article, such
+// as a NewsArticle or BlogPosting, in doc's JSON-LD scripts. Blocks that
+// aren't valid JSON are skipped.
+func jsonLDMetadata(doc *goquery.Document) articleMetadata {
+	var nodes []map[string]any
+	doc.Find(`script[type="application/ld+json"]`).Each(func(_ int, s *goquery.Selection) {
+		var data any
+		if err := json.Unmarshal([]byte(s.Text()), &data); err == nil {
+			nodes = collectJSONLDNodes(data, nodes)
+		}
+	})
+
+	// Authors may be given as references to Person nodes elsewhere in the
+	// graph
+	byID := map[string]map[string]any{}
+	for _, node := range nodes {
+		if id, ok := node["@id"].(string); ok {
+			byID[id] = node
+		}
+	}
+
+	for _, node := range nodes {
+		if !isJSONLDArticle(node["@type"]) {
+			continue
+		}
+		meta := articleMetadata{
+			Title:       jsonLDString(node["headline"]),
+			Description: jsonLDString(node["description"]),
+			Authors:     jsonLDAuthors(node["author"], byID),
+		}
+		if raw := jsonLDString(node["datePublished"]); raw != "" {
+			if meta.PublishedAt = parseMetaTime(raw); meta.PublishedAt != nil {
+				meta.PublishedRaw = raw
+			}
+		}
+		if meta.Title == "" {
+			meta.Title = jsonLDString(node["name"])
+		}
+		return meta
+	}
+	return articleMetadata{}
+}
+
+// collectJSONLDNodes appends the objects in data, including those in arrays
+// and @graph lists, to nodes.
+func collectJSONLDNodes(data any, nodes []map[string]any) []map[string]any {
+	switch v := data.(type) {
+	case []any:
+		for _, item := range v {
+			nodes = collectJSONLDNodes(item, nodes)
+		}
+	case map[string]any:
+		nodes = append(nodes, v)
+		if graph, ok := v["@graph"]; ok {
+			nodes = collectJSONLDNodes(graph, nodes)
+		}
+	}
+	return nodes
+}
+
+// isJSONLDArticle reports whether a JSON-LD @type, a string or a list of
+// them, names a kind of schema.org Article or posting.
+func isJSONLDArticle(t any) bool {
+	var types []string
+	switch v := t.(type) {
+	case string:
+		types = []string{v}
+	case []any:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				types = append(types, s)
+			}
+		}
+	}
+	for _, name := range types {
+		// Types may be given as full IRIs, e.g. https://schema.org/NewsArticle
+		name = name[strings.LastIndexAny(name, "/:")+1:]
+		if strings.HasSuffix(name, "Article") || strings.HasSuffix(name, "Posting") || name == "Report" {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonLDAuthors returns the names in a JSON-LD author value: a name, a
+// Person or Organization, a reference to one in byID, or a list of these.
+func jsonLDAuthors(value any, byID map[string]map[string]any) []string {
+	var authors []string
+	switch v := value.(type) {
+	case string:
+		authors = ParseAuthors(jsonLDString(v))
+	case []any:
+		for _, item := range v {
+			authors = append(authors, jsonLDAuthors(item, byID)...)
+		}
+	case map[string]any:
+		if _, named := v["name"]; !named {
+			if id, ok := v["@id"].(string); ok && byID[id] != nil {
+				v = byID[id]
+			}
+		}
+		if name := jsonLDString(v["name"]); name != "" {
+			authors = append(authors, name)
+		}
+	}
+	return authors
+}
+
+// jsonLDString returns a JSON-LD text value with entities decoded and
+// whitespace normalized, or "" if it isn't a string.
+func jsonLDString(value any) string {
+	s, _ := value.(string)
+	return strings.Join(strings.Fields(html.UnescapeString(s)), " ")
+}
+
+// metaTimeLayouts are the date formats found in page metadata. Times
+// without a zone are taken as UTC.
+var metaTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// parseMetaTime parses a date from page metadata, or returns nil if it is
+// empty or in no format it knows.
+func parseMetaTime(s string) *time.Time {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	for _, layout := range metaTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
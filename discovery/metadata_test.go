@@ -0,0 +1,94 @@
+package discovery
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPageMetadata_JSONLD verifies that a NewsArticle in a JSON-LD graph is
+// read, including authors given by reference, and preferred over Open Graph
+func TestPageMetadata_JSONLD(t *testing.T) {
+	doc := parseTestHTML(t, `<html><head>
+<meta property="og:title" content="Gophers Everywhere | Example News">
+<meta property="og:description" content="Open Graph description">
+<script type="application/ld+json">not json</script>
+<script type="application/ld+json">{
+  "@context": "https://schema.org",
+  "@graph": [
+    {"@type": "WebSite", "@id": "#site", "name": "Example News"},
+    {"@type": "Person", "@id": "#ada", "name": "Ada Lovelace"},
+    {
+      "@type": ["NewsArticle"],
+      "headline": "Gophers &amp; Moles Everywhere",
+      "description": "Residents report gophers in every corner of the city.",
+      "datePublished": "2024-03-01T09:30:00+01:00",
+      "author": [{"@id": "#ada"}, {"@type": "Person", "name": "Grace Hopper"}]
+    }
+  ]
+}</script>
+</head><body></body></html>`)
+
+	meta := pageMetadata(doc)
+	assert.Equal(t, "Gophers & Moles Everywhere", meta.Title)
+	assert.Equal(t, "Residents report gophers in every corner of the city.", meta.Description)
+	assert.Equal(t, []string{"Ada Lovelace", "Grace Hopper"}, meta.Authors)
+	require.NotNil(t, meta.PublishedAt)
+	assert.True(t, meta.PublishedAt.Equal(time.Date(2024, 3, 1, 8, 30, 0, 0, time.UTC)))
+}
+
+// TestPageMetadata_Fallbacks verifies that fields missing from JSON-LD are
+// taken from Open Graph, then from standard meta tags, and that author
+// profile URLs are skipped
+func TestPageMetadata_Fallbacks(t *testing.T) {
+	doc := parseTestHTML(t, `<html><head>
+<script type="application/ld+json">{"@type": "BlogPosting", "headline": "From JSON-LD"}</script>
+<meta property="og:title" content="From Open Graph">
+<meta name="description" content="From the description tag">
+<meta property="article:author" content="https://example.com/staff/jane">
+<meta name="author" content="Jane Doe">
+<meta name="date" content="2024-01-15">
+</head><body></body></html>`)
+
+	meta := pageMetadata(doc)
+	assert.Equal(t, "From JSON-LD", meta.Title)
+	assert.Equal(t, "From the description tag", meta.Description)
+	assert.Equal(t, []string{"Jane Doe"}, meta.Authors, "a profile URL is not a name")
+	require.NotNil(t, meta.PublishedAt)
+	assert.Equal(t, 15, meta.PublishedAt.Day())
+
+	assert.Equal(t, articleMetadata{}, pageMetadata(parseTestHTML(t, `<html><body><p>Nothing</p></body></html>`)))
+}
+
+// TestExtractArticle_PrefersMetadata verifies that page metadata is used
+// before selectors, that selectors fill in what it lacks, and that it can be
+// ignored
+func TestExtractArticle_PrefersMetadata(t *testing.T) {
+	doc := parseTestHTML(t, `<html><head>
+<meta property="og:title" content="Declared Title">
+<meta property="og:description" content="Declared description.">
+</head><body>
+<h1>Page Heading</h1>
+<span class="byline">Jane Doe</span>
+<div class="content">The article body.</div>
+</body></html>`)
+	config := ArticleConfig{TitleSelector: "h1", ContentSelector: ".content", AuthorSelector: ".byline"}
+
+	article, err := ExtractArticle(doc, config, "http://example.com/a")
+	require.NoError(t, err)
+	assert.Equal(t, "Declared Title", article.Title)
+	assert.Equal(t, "The article body.", article.Content)
+	assert.Equal(t, "Declared description.", article.Description)
+	assert.Equal(t, []string{"Jane Doe"}, article.Authors)
+	assert.Equal(t, "Declared description.", ScrapedArticleToNewsItem(article, "Example", uuid.Nil).Summary)
+
+	config.IgnoreMetadata = true
+	article, err = ExtractArticle(doc, config, "http://example.com/a")
+	require.NoError(t, err)
+	assert.Equal(t, "Page Heading", article.Title)
+	assert.Empty(t, article.Description)
+	assert.Equal(t, "The article body.", ScrapedArticleToNewsItem(article, "Example", uuid.Nil).Summary)
+}
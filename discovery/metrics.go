@@ -0,0 +1,183 @@
+package discovery
+
+import (
+	"bufio"
+	"cmp"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pevans/newsfed/internal/httpjson"
+	"github.com/pevans/newsfed/sources"
+)
+
+// fetchDurationBuckets are the upper bounds, in seconds, of the fetch
+// duration histogram. They span fast feed fetches up to the default 60-second
+// fetch timeout.
+var fetchDurationBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// discoveryLagBuckets are the upper bounds, in seconds, of the discovery lag
+// histogram, from a minute to a week.
+var discoveryLagBuckets = []float64{60, 300, 900, 1800, 3600, 3 * 3600, 6 * 3600, 12 * 3600, 86400, 3 * 86400, 7 * 86400}
+
+// labelEscaper escapes a Prometheus label value.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// WritePrometheus writes the metrics in the Prometheus text exposition
+// format.
+func (m *DiscoveryMetrics) WritePrometheus(w io.Writer) error {
+	m.mu.Lock()
+	sourcesTotal := m.SourcesTotal
+	fetched := m.SourcesFetchedTotal
+	failed := m.SourcesFailedTotal
+	discovered := m.ItemsDiscoveredTotal
+	bucketCounts := append([]int(nil), m.durationBucketCounts...)
+	count := m.durationCount
+	sum := m.durationSum
+	lagBucketCounts := append([]int(nil), m.lagBucketCounts...)
+	lagCount := m.lagCount
+	lagSum := m.lagSum
+	sourceLags := make(map[uuid.UUID]sourceLag, len(m.sourceLags))
+	for id, totals := range m.sourceLags {
+		sourceLags[id] = *totals
+	}
+	m.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintln(bw, "# HELP newsfed_sources_total Number of enabled sources.")
+	fmt.Fprintln(bw, "# TYPE newsfed_sources_total gauge")
+	fmt.Fprintf(bw, "newsfed_sources_total %d\n", sourcesTotal)
+
+	fmt.Fprintln(bw, "# HELP newsfed_fetches_total Source fetches by outcome.")
+	fmt.Fprintln(bw, "# TYPE newsfed_fetches_total counter")
+	fmt.Fprintf(bw, "newsfed_fetches_total{status=\"success\"} %d\n", fetched)
+	fmt.Fprintf(bw, "newsfed_fetches_total{status=\"failure\"} %d\n", failed)
+
+	fmt.Fprintln(bw, "# HELP newsfed_items_discovered_total New items added to the news feed.")
+	fmt.Fprintln(bw, "# TYPE newsfed_items_discovered_total counter")
+	fmt.Fprintf(bw, "newsfed_items_discovered_total %d\n", discovered)
+
+	fmt.Fprintln(bw, "# HELP newsfed_fetch_duration_seconds Time taken to fetch a source.")
+	fmt.Fprintln(bw, "# TYPE newsfed_fetch_duration_seconds histogram")
+	for i, bound := range fetchDurationBuckets {
+		le := strconv.FormatFloat(bound, 'g', -1, 64)
+		fmt.Fprintf(bw, "newsfed_fetch_duration_seconds_bucket{le=\"%s\"} %d\n", le, bucketCounts[i])
+	}
+	fmt.Fprintf(bw, "newsfed_fetch_duration_seconds_bucket{le=\"+Inf\"} %d\n", count)
+	fmt.Fprintf(bw, "newsfed_fetch_duration_seconds_sum %s\n", strconv.FormatFloat(sum.Seconds(), 'g', -1, 64))
+	fmt.Fprintf(bw, "newsfed_fetch_duration_seconds_count %d\n", count)
+
+	fmt.Fprintln(bw, "# HELP newsfed_discovery_lag_seconds Time from an item's publication to its discovery.")
+	fmt.Fprintln(bw, "# TYPE newsfed_discovery_lag_seconds histogram")
+	for i, bound := range discoveryLagBuckets {
+		le := strconv.FormatFloat(bound, 'g', -1, 64)
+		fmt.Fprintf(bw, "newsfed_discovery_lag_seconds_bucket{le=\"%s\"} %d\n", le, lagBucketCounts[i])
+	}
+	fmt.Fprintf(bw, "newsfed_discovery_lag_seconds_bucket{le=\"+Inf\"} %d\n", lagCount)
+	fmt.Fprintf(bw, "newsfed_discovery_lag_seconds_sum %s\n", strconv.FormatFloat(lagSum.Seconds(), 'g', -1, 64))
+	fmt.Fprintf(bw, "newsfed_discovery_lag_seconds_count %d\n", lagCount)
+
+	fmt.Fprintln(bw, "# HELP newsfed_source_discovery_lag_seconds Time from an item's publication to its discovery, by source.")
+	fmt.Fprintln(bw, "# TYPE newsfed_source_discovery_lag_seconds summary")
+	ids := make([]uuid.UUID, 0, len(sourceLags))
+	for id := range sourceLags {
+		ids = append(ids, id)
+	}
+	slices.SortFunc(ids, func(a, b uuid.UUID) int { return strings.Compare(a.String(), b.String()) })
+	for _, id := range ids {
+		totals := sourceLags[id]
+		labels := fmt.Sprintf("source_id=\"%s\",source=\"%s\"", id, labelEscaper.Replace(totals.name))
+		fmt.Fprintf(bw, "newsfed_source_discovery_lag_seconds_sum{%s} %s\n", labels, strconv.FormatFloat(totals.sum.Seconds(), 'g', -1, 64))
+		fmt.Fprintf(bw, "newsfed_source_discovery_lag_seconds_count{%s} %d\n", labels, totals.count)
+	}
+
+	return bw.Flush()
+}
+
+// ServeHTTP serves the metrics in the Prometheus text exposition format, so
+// a DiscoveryMetrics can be mounted directly at /metrics.
+func (m *DiscoveryMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = m.WritePrometheus(w)
+}
+
+// MetricsReport is the body of GET /api/v1/meta/metrics: the service's
+// metrics and how each source's fetches have gone.
+type MetricsReport struct {
+	SourcesTotal int `json:"sources_total"` // Enabled sources
+	MetricsSnapshot
+	Sources []SourceFetchStats `json:"sources"`
+}
+
+// SourceFetchStats is how one source's fetches have gone.
+type SourceFetchStats struct {
+	SourceID        uuid.UUID            `json:"source_id"`
+	Name            string               `json:"name"`
+	Enabled         bool                 `json:"enabled"`
+	LastFetchedAt   *time.Time           `json:"last_fetched_at,omitempty"`
+	NextFetchAt     *time.Time           `json:"next_fetch_at,omitempty"`
+	FetchErrorCount int                  `json:"fetch_error_count"` // Consecutive failures
+	LastError       *string              `json:"last_error,omitempty"`
+	LastSync        *sources.SyncAttempt `json:"last_sync,omitempty"` // The last fetch attempt, from the sync history
+}
+
+// MetricsReport returns the service's metrics and each source's fetch
+// stats, sorted by source name.
+func (ds *DiscoveryService) MetricsReport() (MetricsReport, error) {
+	list, err := ds.sourceStore.ListSources(sources.SourceFilter{})
+	if err != nil {
+		return MetricsReport{}, err
+	}
+	last, err := ds.sourceStore.LastSyncAttempts()
+	if err != nil {
+		return MetricsReport{}, err
+	}
+
+	sourcesTotal, _, _, _ := ds.metrics.GetMetrics()
+	report := MetricsReport{
+		SourcesTotal:    sourcesTotal,
+		MetricsSnapshot: ds.metrics.Snapshot(),
+		Sources:         make([]SourceFetchStats, 0, len(list)),
+	}
+	for _, source := range list {
+		stats := SourceFetchStats{
+			SourceID:        source.SourceID,
+			Name:            source.Name,
+			Enabled:         source.IsEnabled(),
+			LastFetchedAt:   source.LastFetchedAt,
+			NextFetchAt:     source.NextFetchAt,
+			FetchErrorCount: source.FetchErrorCount,
+			LastError:       source.LastError,
+		}
+		if attempt, ok := last[source.SourceID]; ok {
+			stats.LastSync = &attempt
+		}
+		report.Sources = append(report.Sources, stats)
+	}
+	slices.SortFunc(report.Sources, func(a, b SourceFetchStats) int {
+		if c := cmp.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name)); c != 0 {
+			return c
+		}
+		return strings.Compare(a.SourceID.String(), b.SourceID.String())
+	})
+	return report, nil
+}
+
+// MetricsHandler reports the service's metrics and each source's fetch
+// stats as JSON for GET /api/v1/meta/metrics.
+func (ds *DiscoveryService) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report, err := ds.MetricsReport()
+		if err != nil {
+			httpjson.Error(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		httpjson.Write(w, http.StatusOK, report)
+	})
+}
@@ -0,0 +1,189 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pevans/newsfed/newsfeed"
+	"github.com/pevans/newsfed/sources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDiscoveryMetrics_WritePrometheus verifies the exposition output for
+// counters, gauges, and the fetch duration histogram.
+func TestDiscoveryMetrics_WritePrometheus(t *testing.T) {
+	metrics := newDiscoveryMetrics()
+	metrics.updateSourcesTotal(3)
+	metrics.recordFetchSuccess(50 * time.Millisecond)
+	metrics.recordFetchSuccess(2 * time.Second)
+	metrics.recordFetchFailure(90 * time.Second)
+	metrics.recordItemsDiscovered(7)
+
+	var sb strings.Builder
+	require.NoError(t, metrics.WritePrometheus(&sb))
+	out := sb.String()
+
+	for _, line := range []string{
+		"newsfed_sources_total 3",
+		`newsfed_fetches_total{status="success"} 2`,
+		`newsfed_fetches_total{status="failure"} 1`,
+		"newsfed_items_discovered_total 7",
+		`newsfed_fetch_duration_seconds_bucket{le="0.1"} 1`,
+		`newsfed_fetch_duration_seconds_bucket{le="2.5"} 2`,
+		`newsfed_fetch_duration_seconds_bucket{le="60"} 2`,
+		`newsfed_fetch_duration_seconds_bucket{le="+Inf"} 3`,
+		"newsfed_fetch_duration_seconds_sum 92.05",
+		"newsfed_fetch_duration_seconds_count 3",
+	} {
+		assert.Contains(t, out, line+"\n")
+	}
+}
+
+// TestDiscoveryMetrics_DiscoveryLag verifies the discovery lag histogram
+// and per-source totals, and that a source's lag since its last sync
+// attempt is averaged and then reset
+func TestDiscoveryMetrics_DiscoveryLag(t *testing.T) {
+	metrics := newDiscoveryMetrics()
+	source := sources.Source{SourceID: uuid.MustParse("00000000-0000-0000-0000-000000000001"), Name: `The "Daily"`}
+	metrics.recordDiscoveryLag(source, 2*time.Minute)
+	metrics.recordDiscoveryLag(source, 2*time.Hour)
+
+	lag := metrics.takeFetchLag(source.SourceID)
+	require.NotNil(t, lag)
+	assert.Equal(t, time.Hour+time.Minute, *lag)
+	assert.Nil(t, metrics.takeFetchLag(source.SourceID))
+	assert.Nil(t, metrics.takeFetchLag(uuid.New()))
+
+	var sb strings.Builder
+	require.NoError(t, metrics.WritePrometheus(&sb))
+	out := sb.String()
+	for _, line := range []string{
+		`newsfed_discovery_lag_seconds_bucket{le="60"} 0`,
+		`newsfed_discovery_lag_seconds_bucket{le="300"} 1`,
+		`newsfed_discovery_lag_seconds_bucket{le="10800"} 2`,
+		`newsfed_discovery_lag_seconds_bucket{le="+Inf"} 2`,
+		"newsfed_discovery_lag_seconds_sum 7320",
+		`newsfed_source_discovery_lag_seconds_sum{source_id="00000000-0000-0000-0000-000000000001",source="The \"Daily\""} 7320`,
+		`newsfed_source_discovery_lag_seconds_count{source_id="00000000-0000-0000-0000-000000000001",source="The \"Daily\""} 2`,
+	} {
+		assert.Contains(t, out, line+"\n")
+	}
+}
+
+// TestDiscoveryService_fetchSource_DiscoveryLag verifies that the lag of
+// new dated items is recorded in the sync history, and that a source's
+// first sync and undated items are not counted
+func TestDiscoveryService_fetchSource_DiscoveryLag(t *testing.T) {
+	published := time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC1123Z)
+	items := `<item><title>Undated</title><link>http://example.com/undated</link></item>`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, `<?xml version="1.0"?><rss version="2.0"><channel><title>Test</title>%s</channel></rss>`, items)
+	}))
+	defer server.Close()
+
+	sourceStore := newTestSourceStore(t)
+	newsFeed := newsfeed.NewMemoryNewsFeed()
+	service := NewDiscoveryService(sourceStore, newsFeed, nil)
+
+	source, err := sourceStore.CreateSource("rss", server.URL, "Test Feed", nil, nil)
+	require.NoError(t, err)
+	_, err = service.fetchSource(context.Background(), *source, nil)
+	require.NoError(t, err)
+
+	items += fmt.Sprintf(`<item><title>Dated</title><link>http://example.com/dated</link><pubDate>%s</pubDate></item>`, published)
+	source, err = sourceStore.GetSource(source.SourceID)
+	require.NoError(t, err)
+	_, err = service.fetchSource(context.Background(), *source, nil)
+	require.NoError(t, err)
+
+	history, err := sourceStore.ListSyncHistory(source.SourceID, 0)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	require.NotNil(t, history[0].DiscoveryLag)
+	assert.InDelta(t, 2*time.Hour, *history[0].DiscoveryLag, float64(time.Minute))
+	assert.Nil(t, history[1].DiscoveryLag, "the first sync is the source's backlog")
+}
+
+// TestDiscoveryMetrics_HistogramIsCumulative verifies that bucket counts
+// never decrease as the bound increases and are not limited by the recent
+// duration window.
+func TestDiscoveryMetrics_HistogramIsCumulative(t *testing.T) {
+	metrics := newDiscoveryMetrics()
+	metrics.maxDurations = 5
+
+	for i := range 50 {
+		metrics.recordFetchSuccess(time.Duration(i) * 500 * time.Millisecond)
+	}
+
+	assert.Equal(t, 50, metrics.durationCount)
+	for i := 1; i < len(metrics.durationBucketCounts); i++ {
+		assert.GreaterOrEqual(t, metrics.durationBucketCounts[i], metrics.durationBucketCounts[i-1])
+	}
+	assert.LessOrEqual(t, metrics.durationBucketCounts[len(metrics.durationBucketCounts)-1], metrics.durationCount)
+}
+
+// TestDiscoveryMetrics_ServeHTTP verifies the metrics are served with the
+// Prometheus content type.
+func TestDiscoveryMetrics_ServeHTTP(t *testing.T) {
+	metrics := newDiscoveryMetrics()
+
+	rec := httptest.NewRecorder()
+	metrics.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Header().Get("Content-Type"), "text/plain; version=0.0.4")
+	assert.Contains(t, rec.Body.String(), "newsfed_sources_total 0")
+}
+
+// TestDiscoveryService_MetricsHandler verifies that the metrics are served
+// as JSON with each source's fetch stats and last sync attempt, sorted by
+// name
+func TestDiscoveryService_MetricsHandler(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `<?xml version="1.0"?><rss version="2.0"><channel><title>Test</title><item><title>One</title><link>http://example.com/1</link></item></channel></rss>`)
+	}))
+	defer server.Close()
+
+	sourceStore := newTestSourceStore(t)
+	newsFeed := newsfeed.NewMemoryNewsFeed()
+	service := NewDiscoveryService(sourceStore, newsFeed, nil)
+
+	now := time.Now()
+	fetched, err := sourceStore.CreateSource("rss", server.URL, "b feed", nil, &now)
+	require.NoError(t, err)
+	_, err = sourceStore.CreateSource("rss", server.URL+"/never", "A feed", nil, nil)
+	require.NoError(t, err)
+	_, err = service.fetchSource(context.Background(), *fetched, nil)
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	service.MetricsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/meta/metrics", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var report MetricsReport
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	assert.Equal(t, 1, report.SourcesFetched)
+	assert.Equal(t, 1, report.ItemsDiscovered)
+	assert.Equal(t, 1, report.FetchDuration.Count)
+	require.Len(t, report.Sources, 2)
+
+	assert.Equal(t, "A feed", report.Sources[0].Name)
+	assert.False(t, report.Sources[0].Enabled)
+	assert.Nil(t, report.Sources[0].LastSync)
+
+	assert.Equal(t, fetched.SourceID, report.Sources[1].SourceID)
+	assert.True(t, report.Sources[1].Enabled)
+	assert.NotNil(t, report.Sources[1].LastFetchedAt)
+	require.NotNil(t, report.Sources[1].LastSync)
+	assert.Equal(t, 1, report.Sources[1].LastSync.ItemsDiscovered)
+	assert.Nil(t, report.Sources[1].LastSync.Error)
+}
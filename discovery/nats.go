@@ -0,0 +1,79 @@
+package discovery
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+
+	"github.com/pevans/newsfed/secrets"
+)
+
+// natsTimeout is how long connecting to a NATS server, or waiting for it to
+// confirm what was sent, may take.
+const natsTimeout = 10 * time.Second
+
+// ErrInvalidPublishURL is returned for a publish URL that isn't a NATS
+// server URL.
+var ErrInvalidPublishURL = errors.New("must be a NATS URL such as nats://localhost:4222")
+
+// ErrKafkaPublishURL is returned for a Kafka publish URL. Items are only
+// published to NATS.
+var ErrKafkaPublishURL = fmt.Errorf("%w; Kafka isn't supported", ErrInvalidPublishURL)
+
+// ErrInvalidPublishSubject is returned for a subject messages can't be
+// published to.
+var ErrInvalidPublishSubject = errors.New("must be a NATS subject such as newsfed.items, without wildcards")
+
+// ValidatePublishURL checks that rawURL names a NATS server, or refers to a
+// secret holding one, which is checked when it is read.
+func ValidatePublishURL(rawURL string) error {
+	if _, ok := secrets.Ref(rawURL); ok {
+		return nil
+	}
+	return parsePublishURL(rawURL)
+}
+
+// parsePublishURL checks the URL of a NATS server: nats:// or, to require
+// TLS, tls://, with an optional user and password or token.
+func parsePublishURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err == nil && u.Scheme == "kafka" {
+		return ErrKafkaPublishURL
+	}
+	if err != nil || u.Hostname() == "" || (u.Scheme != "nats" && u.Scheme != "tls") {
+		return ErrInvalidPublishURL
+	}
+	return nil
+}
+
+// ValidatePublishSubject checks that subject can be published to: dot
+// separated tokens without whitespace or wildcards.
+func ValidatePublishSubject(subject string) error {
+	if subject == "" || strings.ContainsAny(subject, " \t\r\n*>") {
+		return ErrInvalidPublishSubject
+	}
+	for token := range strings.SplitSeq(subject, ".") {
+		if token == "" {
+			return ErrInvalidPublishSubject
+		}
+	}
+	return nil
+}
+
+// dialNATS connects to the NATS server at rawURL, with any credentials in
+// the URL. The connection isn't reconnected when it drops: the publisher
+// retries what wasn't confirmed over a new one.
+func dialNATS(rawURL string) (*nats.Conn, error) {
+	if err := parsePublishURL(rawURL); err != nil {
+		return nil, err
+	}
+	return nats.Connect(rawURL,
+		nats.Name("newsfed"),
+		nats.Timeout(natsTimeout),
+		nats.NoReconnect(),
+	)
+}
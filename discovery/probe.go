@@ -0,0 +1,38 @@
+package discovery
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/pevans/newsfed/sources"
+)
+
+// ProbeSource checks that a source's URL answers, for newsfed doctor. It
+// makes one GET request with the source's request settings, without
+// retrying, logging in, or reading the body, and returns the network error
+// or HTTPStatusError if the fetch failed.
+func (ds *DiscoveryService) ProbeSource(ctx context.Context, source sources.Source) error {
+	req, err := newRequest(ctx, source.URL)
+	if err != nil {
+		return err
+	}
+	opts, err := ds.requestOptionsFor(source)
+	if err != nil {
+		return err
+	}
+	opts.apply(req, scraperUserAgent)
+	client, err := opts.client()
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		return newHTTPStatusError(resp)
+	}
+	return nil
+}
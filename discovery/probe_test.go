@@ -0,0 +1,43 @@
+package discovery
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pevans/newsfed/sources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDiscoveryService_ProbeSource verifies that a probe succeeds for a URL
+// that answers, sends the source's headers, and reports error statuses and
+// unusable URLs
+func TestDiscoveryService_ProbeSource(t *testing.T) {
+	var gotHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeader = r.Header.Get("X-Token")
+		if r.URL.Path == "/gone" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte("<rss></rss>"))
+	}))
+	defer server.Close()
+
+	service := NewDiscoveryService(nil, nil, nil)
+	ctx := context.Background()
+
+	source := sources.Source{URL: server.URL + "/feed", HTTPHeaders: map[string]string{"X-Token": "secret"}}
+	require.NoError(t, service.ProbeSource(ctx, source))
+	assert.Equal(t, "secret", gotHeader)
+
+	err := service.ProbeSource(ctx, sources.Source{URL: server.URL + "/gone"})
+	var statusErr *HTTPStatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusNotFound, statusErr.StatusCode)
+
+	err = service.ProbeSource(ctx, sources.Source{URL: "ftp://example.com/feed"})
+	assert.ErrorIs(t, err, ErrInvalidURL)
+}
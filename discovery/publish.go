@@ -0,0 +1,252 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+
+	"github.com/pevans/newsfed/newsfeed"
+)
+
+// DefaultPublishSubject is the NATS subject items are published to unless
+// another is configured.
+const DefaultPublishSubject = "newsfed.items"
+
+// defaultPublishBuffer is how many items wait to be published, while the
+// server can't be reached, unless another limit is configured.
+const defaultPublishBuffer = 1000
+
+// publishBatch is the most items sent before waiting for the server to
+// confirm them.
+const publishBatch = 100
+
+// publishMaxRetryDelay caps the delay between attempts to reach the server.
+const publishMaxRetryDelay = 30 * time.Second
+
+// publishCloseTimeout is how long closing the service waits for buffered
+// items to be published.
+const publishCloseTimeout = 5 * time.Second
+
+// itemPublisher publishes the items added to the feed to a NATS subject as
+// JSON, so that other services can be told about new items rather than
+// poll the API. Items are buffered and sent in the background, in the order
+// they were added; while the server can't be reached they wait, up to the
+// buffer's limit, past which the oldest are dropped. An item leaves the
+// buffer only once the server confirms it, so one sent as the connection
+// dropped is sent again: delivery is at least once.
+type itemPublisher struct {
+	url        string // NATS server URL, or a reference to a secret holding it
+	subject    string
+	limit      int
+	resolveURL func(string) (string, error)
+	retryDelay time.Duration // Delay before the first retry, doubling after
+
+	mu      sync.Mutex
+	queue   [][]byte
+	dropped int // Items dropped since the last batch was published
+
+	wake    chan struct{}
+	closing chan struct{}
+	ctx     context.Context
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// newItemPublisher starts publishing items to subject on the NATS server at
+// url. resolveURL resolves a url that refers to a secret.
+func newItemPublisher(url, subject string, limit int, resolveURL func(string) (string, error)) *itemPublisher {
+	if subject == "" {
+		subject = DefaultPublishSubject
+	}
+	if limit <= 0 {
+		limit = defaultPublishBuffer
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &itemPublisher{
+		url:        url,
+		subject:    subject,
+		limit:      limit,
+		resolveURL: resolveURL,
+		retryDelay: time.Second,
+		wake:       make(chan struct{}, 1),
+		closing:    make(chan struct{}),
+		ctx:        ctx,
+		cancel:     cancel,
+		done:       make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+// publish queues item to be published.
+func (p *itemPublisher) publish(item newsfeed.NewsItem) {
+	data, err := json.Marshal(item)
+	if err != nil {
+		log.Printf("WARN: Failed to encode item %s for publishing: %v", item.ID, err)
+		return
+	}
+
+	p.mu.Lock()
+	if len(p.queue) >= p.limit {
+		if p.dropped == 0 {
+			log.Printf("WARN: Publish buffer is full; dropping the oldest items until %s can be reached", p.subject)
+		}
+		p.queue = p.queue[1:]
+		p.dropped++
+	}
+	p.queue = append(p.queue, data)
+	p.mu.Unlock()
+
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+// close publishes the buffered items, waiting up to publishCloseTimeout for
+// the server, and stops the publisher.
+func (p *itemPublisher) close() {
+	close(p.closing)
+	select {
+	case <-p.done:
+	case <-time.After(publishCloseTimeout):
+		p.cancel()
+		p.mu.Lock()
+		log.Printf("WARN: %d items were not published to %s", len(p.queue), p.subject)
+		p.mu.Unlock()
+	}
+}
+
+// sent removes the first n items, which the server has confirmed, from the
+// buffer. Items dropped while they were being sent may have been among
+// them, so fewer are removed.
+func (p *itemPublisher) sent(n, batchDropped int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n = max(n-(p.dropped-batchDropped), 0)
+	p.queue = p.queue[min(n, len(p.queue)):]
+	if p.dropped > 0 {
+		log.Printf("WARN: Dropped %d items the publish buffer had no room for", p.dropped)
+		p.dropped = 0
+	}
+}
+
+// run sends buffered items until the publisher is closed, connecting to the
+// server when there is something to send and again after a failure.
+func (p *itemPublisher) run() {
+	defer close(p.done)
+	defer p.cancel()
+
+	var conn *nats.Conn
+	defer func() {
+		if conn != nil {
+			conn.Close()
+		}
+	}()
+	delay := p.retryDelay
+
+	for {
+		p.mu.Lock()
+		batch, dropped := p.queue[:min(len(p.queue), publishBatch)], p.dropped
+		p.mu.Unlock()
+
+		if len(batch) == 0 {
+			select {
+			case <-p.wake:
+			case <-p.closing:
+				return
+			case <-p.ctx.Done():
+				return
+			}
+			continue
+		}
+
+		err := p.send(&conn, batch)
+		if err == nil {
+			p.sent(len(batch), dropped)
+			delay = p.retryDelay
+			continue
+		}
+		log.Printf("WARN: Publishing items to %s failed: %v; retrying in %v", p.subject, err, delay)
+		if conn != nil {
+			conn.Close()
+			conn = nil
+		}
+		select {
+		case <-time.After(delay):
+		case <-p.ctx.Done():
+			return
+		}
+		delay = min(delay*2, publishMaxRetryDelay)
+	}
+}
+
+// send publishes batch, connecting first if *conn is nil or has closed, and
+// waits for the server to confirm it. Items too large for the server are
+// logged and skipped rather than retried.
+func (p *itemPublisher) send(conn **nats.Conn, batch [][]byte) error {
+	if *conn == nil || (*conn).IsClosed() {
+		url, err := p.resolveURL(p.url)
+		if err != nil {
+			return err
+		}
+		c, err := dialNATS(url)
+		if err != nil {
+			return err
+		}
+		*conn = c
+	}
+	for _, data := range batch {
+		err := (*conn).Publish(p.subject, data)
+		if errors.Is(err, nats.ErrMaxPayload) {
+			log.Printf("WARN: Not publishing an item of %d bytes to %s: %v", len(data), p.subject, err)
+		} else if err != nil {
+			return err
+		}
+	}
+	ctx, cancel := context.WithTimeout(p.ctx, natsTimeout)
+	defer cancel()
+	return (*conn).FlushWithContext(ctx)
+}
+
+// publishItem publishes item if a NATS server is configured to publish to.
+func (ds *DiscoveryService) publishItem(item newsfeed.NewsItem) {
+	if p := ds.publisher(); p != nil {
+		p.publish(item)
+	}
+}
+
+// publisher returns the item publisher, starting it the first time it is
+// needed, or nil if no server is configured.
+func (ds *DiscoveryService) publisher() *itemPublisher {
+	config := ds.currentConfig()
+	ds.publisherMu.Lock()
+	defer ds.publisherMu.Unlock()
+	if ds.itemPublisher == nil && config.PublishURL != "" {
+		ds.itemPublisher = newItemPublisher(config.PublishURL, config.PublishSubject, config.PublishBuffer, ds.resolveSecret)
+	}
+	return ds.itemPublisher
+}
+
+// Flush waits for the hooks queued so far to run and for buffered items to
+// be published. Items added after it returns are published as before.
+func (ds *DiscoveryService) Flush() {
+	ds.WaitForHooks()
+	ds.closePublisher()
+}
+
+// closePublisher publishes the items still buffered and stops the item
+// publisher, if it was started.
+func (ds *DiscoveryService) closePublisher() {
+	ds.publisherMu.Lock()
+	defer ds.publisherMu.Unlock()
+	if ds.itemPublisher != nil {
+		ds.itemPublisher.close()
+		ds.itemPublisher = nil
+	}
+}
@@ -0,0 +1,122 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pevans/newsfed/newsfeed"
+	"github.com/pevans/newsfed/secrets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test helper: the address of a local port nothing is listening on
+func unusedAddr(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	require.NoError(t, ln.Close())
+	return addr
+}
+
+// Test helper: the titles of the items in messages
+func publishedTitles(t *testing.T, messages []natsMessage) []string {
+	var titles []string
+	for _, m := range messages {
+		var item newsfeed.NewsItem
+		require.NoError(t, json.Unmarshal(m.data, &item))
+		titles = append(titles, item.Title)
+	}
+	return titles
+}
+
+// TestDiscoveryService_fetchRSSFeed_Publish verifies that each item added
+// to the feed is published to the configured subject as JSON, and that dry
+// runs publish nothing
+func TestDiscoveryService_fetchRSSFeed_Publish(t *testing.T) {
+	feed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(rssWithItems(3, false, "")))
+	}))
+	defer feed.Close()
+	server := startFakeNATS(t, "127.0.0.1:0", nil)
+
+	sourceStore := newTestSourceStore(t)
+	config := DefaultDiscoveryConfig()
+	config.PublishURL = server.url()
+	config.PublishSubject = "news.added"
+	service := NewDiscoveryService(sourceStore, newsfeed.NewMemoryNewsFeed(), config)
+	source, err := sourceStore.CreateSource("rss", feed.URL, "Test Feed", nil, nil)
+	require.NoError(t, err)
+
+	_, _, err = service.fetchRSSFeed(context.Background(), *source, &dryRunPlan{})
+	require.NoError(t, err)
+	service.Flush()
+	assert.Empty(t, server.received(), "dry runs don't publish")
+
+	_, _, err = service.fetchRSSFeed(context.Background(), *source, nil)
+	require.NoError(t, err)
+	service.Flush()
+
+	messages := server.received()
+	require.Len(t, messages, 3)
+	for _, m := range messages {
+		assert.Equal(t, "news.added", m.subject)
+	}
+	items, err := service.newsFeed.List()
+	require.NoError(t, err)
+	var titles []string
+	for _, item := range items.Items {
+		titles = append(titles, item.Title)
+	}
+	assert.ElementsMatch(t, titles, publishedTitles(t, messages))
+}
+
+// TestItemPublisher_Retry verifies that items published while the server
+// can't be reached, or sent as the connection dropped, are sent once it
+// can, in order
+func TestItemPublisher_Retry(t *testing.T) {
+	addr := unusedAddr(t)
+	p := newItemPublisher("nats://"+addr, "", 0, (*secrets.Store)(nil).Resolve)
+	for i := range 3 {
+		p.publish(newsfeed.NewsItem{Title: fmt.Sprintf("Item %d", i)})
+	}
+
+	server := startFakeNATS(t, addr, func(s *fakeNATS) { s.dropAfter = 1 })
+	require.Eventually(t, func() bool { return len(server.received()) == 3 }, 10*time.Second, 10*time.Millisecond)
+	p.close()
+
+	messages := server.received()
+	assert.Equal(t, DefaultPublishSubject, messages[0].subject)
+	assert.Equal(t, []string{"Item 0", "Item 1", "Item 2"}, publishedTitles(t, messages))
+	server.mu.Lock()
+	assert.True(t, server.dropped)
+	server.mu.Unlock()
+}
+
+// TestItemPublisher_Buffer verifies that once the buffer is full, the
+// oldest items are dropped to make room for new ones
+func TestItemPublisher_Buffer(t *testing.T) {
+	p := newItemPublisher("nats://"+unusedAddr(t), "", 2, (*secrets.Store)(nil).Resolve)
+	defer func() {
+		p.cancel()
+		<-p.done
+	}()
+	for i := range 3 {
+		p.publish(newsfeed.NewsItem{Title: fmt.Sprintf("Item %d", i)})
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var queued []natsMessage
+	for _, data := range p.queue {
+		queued = append(queued, natsMessage{data: data})
+	}
+	assert.Equal(t, []string{"Item 1", "Item 2"}, publishedTitles(t, queued))
+	assert.Equal(t, 1, p.dropped)
+}
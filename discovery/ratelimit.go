@@ -0,0 +1,99 @@
+package discovery
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// domainRateLimiter limits how hard the service presses on any one domain,
+// per Spec 7 section 8.2. It caps the number of sources on a domain fetched
+// at once, and spaces requests to a domain by a minimum interval plus a
+// random jitter.
+type domainRateLimiter struct {
+	mu           sync.Mutex
+	nextRequest  map[string]time.Time // Earliest time of the next request
+	slots        map[string]chan struct{}
+	minInterval  time.Duration
+	jitter       time.Duration
+	maxPerDomain int
+}
+
+func newDomainRateLimiter(config *DiscoveryConfig) *domainRateLimiter {
+	rl := &domainRateLimiter{
+		nextRequest: make(map[string]time.Time),
+	}
+	rl.configure(config)
+	return rl
+}
+
+// configure applies the rate limiting settings in config. Fetches holding a
+// domain slot keep it until they finish; a changed per-domain limit applies
+// to fetches that start afterward.
+func (rl *domainRateLimiter) configure(config *DiscoveryConfig) {
+	maxPerDomain := config.MaxPerDomain
+	if maxPerDomain < 1 {
+		maxPerDomain = 1
+	}
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.minInterval = config.RateLimitInterval
+	rl.jitter = config.RateLimitJitter
+	if maxPerDomain != rl.maxPerDomain {
+		rl.maxPerDomain = maxPerDomain
+		rl.slots = make(map[string]chan struct{})
+	}
+}
+
+// acquire waits for one of the domain's fetch slots and returns a function
+// that releases it. Waiting sources are served in no particular order.
+func (rl *domainRateLimiter) acquire(ctx context.Context, domain string) (func(), error) {
+	rl.mu.Lock()
+	slots, ok := rl.slots[domain]
+	if !ok {
+		slots = make(chan struct{}, rl.maxPerDomain)
+		rl.slots[domain] = slots
+	}
+	rl.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case slots <- struct{}{}:
+		return func() { <-slots }, nil
+	}
+}
+
+// wait blocks until it's safe to make a request to the given domain, or ctx
+// is done. Each caller reserves its own time, so callers waiting on one
+// domain don't hold up requests to others.
+func (rl *domainRateLimiter) wait(ctx context.Context, domain string) error {
+	rl.mu.Lock()
+	now := time.Now()
+	start := now
+	if next, ok := rl.nextRequest[domain]; ok && next.After(now) {
+		start = next
+	}
+	gap := rl.minInterval
+	if rl.jitter > 0 {
+		gap += rand.N(rl.jitter)
+	}
+	rl.nextRequest[domain] = start.Add(gap)
+	rl.mu.Unlock()
+
+	delay := start.Sub(now)
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,175 @@
+package discovery
+
+import (
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// Class and ID patterns used to judge whether an element holds article
+// content. These follow the heuristics popularized by Arc90's Readability.
+var (
+	unlikelyCandidate = regexp.MustCompile(`(?i)banner|breadcrumb|combx|comment|community|cookie|disqus|footer|header|menu|modal|nav|popup|promo|related|remark|share|sidebar|social|sponsor|subscribe|advert`)
+	maybeCandidate    = regexp.MustCompile(`(?i)and|article|body|column|content|main|shadow`)
+	positiveWeight    = regexp.MustCompile(`(?i)article|blog|body|content|entry|main|page|post|story|text`)
+	negativeWeight    = regexp.MustCompile(`(?i)comment|footer|masthead|meta|nav|outbrain|promo|related|share|sidebar|social|sponsor|widget`)
+)
+
+// boilerplateElements never contain article text.
+const boilerplateElements = "script, style, noscript, nav, header, footer, aside, form, iframe, svg, button, select"
+
+// minParagraphLength is the shortest paragraph that contributes to a
+// candidate's score.
+const minParagraphLength = 25
+
+// readableTitle finds an article title without selectors, preferring the
+// Open Graph title, then the first heading, then the document title.
+func readableTitle(doc *goquery.Document) string {
+	if title, ok := doc.Find(`meta[property="og:title"]`).Attr("content"); ok && strings.TrimSpace(title) != "" {
+		return title
+	}
+	if h1 := doc.Find("h1").First().Text(); strings.TrimSpace(h1) != "" {
+		return h1
+	}
+	return doc.Find("title").First().Text()
+}
+
+// readableContent finds the main article text without selectors. Boilerplate
+// is stripped, then each block containing paragraphs is scored by the amount
+// of prose it holds, and the text of the best-scoring block is returned. The
+// document is not modified.
+func readableContent(doc *goquery.Document) string {
+	body := doc.Find("body").First()
+	if body.Length() == 0 {
+		body = doc.Selection
+	}
+	root := body.Clone()
+
+	root.Find(boilerplateElements).Remove()
+	removeUnlikelyCandidates(root)
+
+	best := topCandidate(root)
+	if best == nil {
+		// No block held enough prose; fall back to semantic containers
+		if semantic := root.Find("article, main, [role=main]").First(); semantic.Length() > 0 {
+			return semantic.Text()
+		}
+		return root.Text()
+	}
+	return best.Text()
+}
+
+// removeUnlikelyCandidates removes elements whose class or ID marks them as
+// navigation, comments, or other page chrome.
+func removeUnlikelyCandidates(root *goquery.Selection) {
+	var unlikely []*goquery.Selection
+	root.Find("*").Each(func(_ int, s *goquery.Selection) {
+		if s.Is("body, article, main") {
+			return
+		}
+		match := classAndID(s)
+		if unlikelyCandidate.MatchString(match) && !maybeCandidate.MatchString(match) {
+			unlikely = append(unlikely, s)
+		}
+	})
+	for _, s := range unlikely {
+		s.Remove()
+	}
+}
+
+// topCandidate scores the parents and grandparents of every paragraph and
+// returns the highest-scoring element, or nil if no paragraph is long enough
+// to count.
+func topCandidate(root *goquery.Selection) *goquery.Selection {
+	scores := map[*html.Node]float64{}
+	var candidates []*goquery.Selection
+
+	addScore := func(s *goquery.Selection, score float64) {
+		if s.Length() == 0 {
+			return
+		}
+		node := s.Get(0)
+		if _, ok := scores[node]; !ok {
+			scores[node] = classWeight(s)
+			candidates = append(candidates, s)
+		}
+		scores[node] += score
+	}
+
+	root.Find("p, pre, td, blockquote").Each(func(_ int, p *goquery.Selection) {
+		text := strings.Join(strings.Fields(p.Text()), " ")
+		if len(text) < minParagraphLength {
+			return
+		}
+
+		// One point for the paragraph, one per comma, and up to three for
+		// its length
+		score := 1 + float64(strings.Count(text, ",")) + math.Min(float64(len(text)/100), 3)
+
+		parent := p.Parent()
+		addScore(parent, score)
+		addScore(parent.Parent(), score/2)
+	})
+
+	// Candidates are visited in document order so ties resolve the same way
+	// on every run
+	var best *goquery.Selection
+	bestScore := 0.0
+	for _, s := range candidates {
+		score := scores[s.Get(0)] * (1 - linkDensity(s))
+		if best == nil || score > bestScore {
+			best, bestScore = s, score
+		}
+	}
+	return best
+}
+
+// classWeight rewards elements whose class or ID suggests content and
+// penalizes those that suggest page chrome.
+func classWeight(s *goquery.Selection) float64 {
+	match := classAndID(s)
+	weight := 0.0
+	if positiveWeight.MatchString(match) {
+		weight += 25
+	}
+	if negativeWeight.MatchString(match) {
+		weight -= 25
+	}
+	return weight
+}
+
+// classAndID returns an element's class and ID joined for pattern matching.
+func classAndID(s *goquery.Selection) string {
+	class, _ := s.Attr("class")
+	id, _ := s.Attr("id")
+	return class + " " + id
+}
+
+// linkDensity returns the fraction of an element's text that is inside
+// links.
+func linkDensity(s *goquery.Selection) float64 {
+	textLength := len(strings.TrimSpace(s.Text()))
+	if textLength == 0 {
+		return 0
+	}
+	linkLength := 0
+	s.Find("a").Each(func(_ int, a *goquery.Selection) {
+		linkLength += len(strings.TrimSpace(a.Text()))
+	})
+	return float64(linkLength) / float64(textLength)
+}
+
+// timeElementPublishedAt returns the time in the page's first <time
+// datetime> element, if any, for pages that declare no date in their
+// metadata, and the datetime it was read from.
+func timeElementPublishedAt(doc *goquery.Document) (*time.Time, string) {
+	raw := doc.Find("time[datetime]").First().AttrOr("datetime", "")
+	if t := parseMetaTime(raw); t != nil {
+		return t, raw
+	}
+	return nil, ""
+}
@@ -0,0 +1,129 @@
+package discovery
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const readabilityTestPage = `<!DOCTYPE html>
+<html>
+<head>
+  <title>Gophers Everywhere | Example News</title>
+  <meta property="og:title" content="Gophers Everywhere">
+  <meta name="author" content="Ada Lovelace and Grace Hopper">
+  <meta property="article:published_time" content="2024-03-01T09:30:00Z">
+  <script>var tracking = "ignore me";</script>
+</head>
+<body>
+  <header><a href="/">Home</a> <a href="/world">World</a></header>
+  <nav class="menu"><a href="/a">Section A</a><a href="/b">Section B</a></nav>
+  <div class="layout">
+    <div id="sidebar" class="sidebar">
+      <p>Subscribe to our newsletter for the latest updates, offers, and more.</p>
+    </div>
+    <div class="post-body">
+      <p>Gophers have been spotted in every corner of the city, according to residents.</p>
+      <p>Experts say the population grew steadily, year after year, for a decade.</p>
+      <p>City officials, however, remain unconcerned and continue to monitor it.</p>
+    </div>
+    <div class="comments">
+      <p>Great article, thanks for sharing this with everyone, really!</p>
+    </div>
+  </div>
+  <footer><p>Copyright Example News, all rights reserved, since forever.</p></footer>
+</body>
+</html>`
+
+// Test helper: parse an HTML string into a document
+func parseTestHTML(t *testing.T, page string) *goquery.Document {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(page))
+	require.NoError(t, err)
+	return doc
+}
+
+// TestExtractArticle_AutoWithoutSelectors verifies that an article config
+// without selectors extracts the title, body, authors, and date heuristically
+func TestExtractArticle_AutoWithoutSelectors(t *testing.T) {
+	doc := parseTestHTML(t, readabilityTestPage)
+
+	article, err := ExtractArticle(doc, ArticleConfig{}, "http://example.com/gophers")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Gophers Everywhere", article.Title)
+	assert.Contains(t, article.Content, "Gophers have been spotted")
+	assert.Contains(t, article.Content, "City officials")
+	assert.NotContains(t, article.Content, "Subscribe")
+	assert.NotContains(t, article.Content, "Great article")
+	assert.NotContains(t, article.Content, "Copyright")
+	assert.NotContains(t, article.Content, "tracking")
+	assert.Equal(t, []string{"Ada Lovelace", "Grace Hopper"}, article.Authors)
+	require.NotNil(t, article.PublishedAt)
+	assert.True(t, article.PublishedAt.Equal(time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)))
+}
+
+// TestExtractArticle_AutoDoesNotModifyDocument verifies that boilerplate
+// removal works on a copy of the document
+func TestExtractArticle_AutoDoesNotModifyDocument(t *testing.T) {
+	doc := parseTestHTML(t, readabilityTestPage)
+
+	_, err := ExtractArticle(doc, ArticleConfig{ExtractionMode: "auto"}, "http://example.com/gophers")
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, doc.Find("nav").Length())
+	assert.Equal(t, 1, doc.Find("#sidebar").Length())
+}
+
+// TestExtractArticle_AutoKeepsSelectorOverrides verifies that author and date
+// selectors still apply in auto mode
+func TestExtractArticle_AutoKeepsSelectorOverrides(t *testing.T) {
+	page := `<html><body>
+		<span class="byline">Jane Doe</span>
+		<span class="date">2024-01-15</span>
+		<article><p>Some long enough paragraph of text, to be picked as content.</p></article>
+	</body></html>`
+	doc := parseTestHTML(t, page)
+
+	config := ArticleConfig{
+		ExtractionMode: "auto",
+		AuthorSelector: ".byline",
+		DateSelector:   ".date",
+		DateFormat:     "2006-01-02",
+	}
+	article, err := ExtractArticle(doc, config, "http://example.com/a")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"Jane Doe"}, article.Authors)
+	require.NotNil(t, article.PublishedAt)
+	assert.Equal(t, 15, article.PublishedAt.Day())
+	assert.Contains(t, article.Content, "Some long enough paragraph")
+}
+
+// TestReadableContent_FallsBackWithoutParagraphs verifies that pages without
+// scorable paragraphs fall back to semantic containers
+func TestReadableContent_FallsBackWithoutParagraphs(t *testing.T) {
+	doc := parseTestHTML(t, `<html><body><nav>Menu</nav><main>Short text</main></body></html>`)
+
+	assert.Equal(t, "Short text", strings.TrimSpace(readableContent(doc)))
+}
+
+// TestReadableTitle_Fallbacks verifies the title preference order
+func TestReadableTitle_Fallbacks(t *testing.T) {
+	doc := parseTestHTML(t, `<html><head><title>Doc Title</title></head><body><h1>Heading</h1></body></html>`)
+	assert.Equal(t, "Heading", readableTitle(doc))
+
+	doc = parseTestHTML(t, `<html><head><title>Doc Title</title></head><body></body></html>`)
+	assert.Equal(t, "Doc Title", readableTitle(doc))
+}
+
+// TestArticleConfig_UsesAutoExtraction verifies when auto extraction applies
+func TestArticleConfig_UsesAutoExtraction(t *testing.T) {
+	assert.True(t, ArticleConfig{}.UsesAutoExtraction())
+	assert.True(t, ArticleConfig{ExtractionMode: "auto", TitleSelector: "h1"}.UsesAutoExtraction())
+	assert.False(t, ArticleConfig{TitleSelector: "h1"}.UsesAutoExtraction())
+	assert.False(t, ArticleConfig{ExtractionMode: "selectors"}.UsesAutoExtraction())
+}
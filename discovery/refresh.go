@@ -0,0 +1,190 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/pevans/newsfed/internal/httpjson"
+	"github.com/pevans/newsfed/newsfeed"
+	"github.com/pevans/newsfed/sources"
+)
+
+// ErrRefreshFailed is returned by RefreshItem when the item's page can't be
+// fetched.
+var ErrRefreshFailed = errors.New("failed to fetch the item's page")
+
+// truncationMarks end summaries that were cut short, by a feed or by
+// ScrapedArticleToNewsItem.
+var truncationMarks = []string{"...", "…", "[...]", "[…]"}
+
+// RefreshItem fetches the page of the item in feed with the given ID again
+// and updates the item from it. The page is extracted with the scraper
+// config of the item's source, or with readability heuristics if the
+// source has none or is gone, and fetched with the source's request
+// settings and session.
+//
+// An item from a website source takes the page's title and summary, as
+// the source's next fetch would, with the changes recorded as in Spec 2
+// section 2.3.3. Other items keep what their feed gave, and only have gaps
+// filled: a missing title, an empty or truncated summary that the page
+// gives in full, missing authors, and a missing published date. It reports
+// whether the item changed; an unknown item returns
+// newsfeed.ErrItemNotFound, and a page that can't be fetched
+// ErrRefreshFailed.
+func (ds *DiscoveryService) RefreshItem(ctx context.Context, feed *newsfeed.NewsFeed, id uuid.UUID) (*newsfeed.NewsItem, bool, error) {
+	item, err := feed.Get(id)
+	if err != nil {
+		return nil, false, err
+	}
+	if item == nil {
+		return nil, false, newsfeed.ErrItemNotFound
+	}
+
+	var source sources.Source
+	if item.SourceID != nil && ds.sourceStore != nil {
+		found, err := ds.sourceStore.GetSource(*item.SourceID)
+		if err != nil && !errors.Is(err, sources.ErrSourceNotFound) {
+			return nil, false, err
+		}
+		if found != nil {
+			source = *found
+		}
+	}
+
+	var config ArticleConfig
+	if source.ScraperConfig != nil {
+		config = source.ScraperConfig.ArticleConfig
+	}
+	domain, err := ds.extractDomain(item.URL)
+	if err != nil {
+		return nil, false, fmt.Errorf("%w: invalid URL: %v", ErrRefreshFailed, err)
+	}
+	opts, err := ds.requestOptionsFor(source)
+	if err != nil {
+		return nil, false, fmt.Errorf("%w: %w", ErrRefreshFailed, err)
+	}
+	if source.HasSession {
+		if opts.Jar, err = ds.sessionJar(ctx, source, domain, opts); err != nil {
+			return nil, false, err
+		}
+	}
+	if err := ds.rateLimiter.wait(ctx, domain); err != nil {
+		return nil, false, err
+	}
+	article, err := scrapeArticle(ctx, item.URL, config, articleOptions(opts, config))
+	if err != nil {
+		return nil, false, fmt.Errorf("%w: %v", ErrRefreshFailed, err)
+	}
+
+	fetched := ScrapedArticleToNewsItem(article, "", uuid.Nil)
+	localizePublishedAt(&fetched, sourceLocation(source))
+
+	changed := false
+	if source.SourceType == "website" && ValidateScrapedArticle(article, source.URL) == nil {
+		// The URL the item was found at is kept; the page may be
+		// canonicalized differently from the list it was linked from
+		fetched.URL = item.URL
+		changed = item.ApplyRevision(fetched, time.Now().UTC())
+	} else {
+		changed = fillGaps(item, fetched, article)
+	}
+	if article.PublishedAt != nil && missingPublishedAt(*item) {
+		item.PublishedAt = fetched.PublishedAt
+		raw, _ := fetched.MetadataValue(publishedRawKey)
+		setPublishedRaw(item, raw)
+		changed = true
+	}
+	if len(item.Authors) == 0 && len(fetched.Authors) > 0 {
+		item.Authors = fetched.Authors
+		changed = true
+	}
+	if !changed {
+		return item, false, nil
+	}
+
+	ds.enrich(ctx, item)
+	if err := feed.Update(*item); err != nil {
+		return nil, false, err
+	}
+	item.Version++
+	return item, true, nil
+}
+
+// fillGaps sets the title and summary of item from fetched, the item as
+// read from its page, where item lacks them, and reports whether it
+// changed item. The summary is replaced if it is empty, or if it was cut
+// short and fetched has a longer one.
+func fillGaps(item *newsfeed.NewsItem, fetched newsfeed.NewsItem, article *ScrapedArticle) bool {
+	changed := false
+	title := strings.TrimSpace(item.Title)
+	if (title == "" || title == "(No title)" || title == item.URL) && article.Title != "" && article.Title != "(No title)" {
+		item.Title = article.Title
+		changed = true
+	}
+
+	current := strings.TrimSpace(item.Summary)
+	longer := utf8.RuneCountInString(fetched.Summary) > utf8.RuneCountInString(current)
+	if fetched.Summary != "" && (current == "" || (truncated(current) && longer)) {
+		item.Summary = fetched.Summary
+		item.SummaryHTML = ""
+		changed = true
+	}
+	return changed
+}
+
+// truncated reports whether summary ends the way a summary that was cut
+// short does.
+func truncated(summary string) bool {
+	for _, mark := range truncationMarks {
+		if strings.HasSuffix(summary, mark) {
+			return true
+		}
+	}
+	return false
+}
+
+// missingPublishedAt reports whether item was stored without a published
+// date. Discovery then uses the time the item was found, and records no
+// raw date.
+func missingPublishedAt(item newsfeed.NewsItem) bool {
+	if item.PublishedAt.IsZero() {
+		return true
+	}
+	if _, ok := item.MetadataValue(publishedRawKey); ok {
+		return false
+	}
+	gap := item.PublishedAt.Sub(item.DiscoveredAt)
+	return gap > -time.Second && gap < time.Second
+}
+
+// RefreshHandler refreshes an item in feed, as RefreshItem does, for POST
+// /api/v1/items/{id}/refresh. It responds 200 OK with the item, whether or
+// not it changed, 404 for an unknown item, and 502 Bad Gateway if the
+// item's page can't be fetched.
+func (ds *DiscoveryService) RefreshHandler(feed *newsfeed.NewsFeed) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			httpjson.Error(w, http.StatusBadRequest, "invalid item ID")
+			return
+		}
+
+		item, _, err := ds.RefreshItem(r.Context(), feed, id)
+		switch {
+		case errors.Is(err, newsfeed.ErrItemNotFound):
+			httpjson.Error(w, http.StatusNotFound, err.Error())
+		case errors.Is(err, ErrRefreshFailed):
+			httpjson.Error(w, http.StatusBadGateway, err.Error())
+		case err != nil:
+			httpjson.Error(w, http.StatusInternalServerError, err.Error())
+		default:
+			httpjson.Write(w, http.StatusOK, item)
+		}
+	})
+}
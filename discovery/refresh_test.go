@@ -0,0 +1,156 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pevans/newsfed/newsfeed"
+	"github.com/pevans/newsfed/scraper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// refreshPage is the article page the refresh tests fetch
+const refreshPage = `<html><head>
+	<title>Full Story</title>
+	<meta property="og:description" content="The whole summary, with nothing cut off at the end.">
+	<meta name="author" content="Jane Doe">
+	<meta property="article:published_time" content="2024-03-01T09:00:00Z">
+</head><body><article><h1>Revised Story</h1><p>Body text.</p></article></body></html>`
+
+// TestDiscoveryService_RefreshItem_FillsGaps verifies that refreshing an
+// item from a feed recovers its truncated summary, authors, and published
+// date, and leaves its title alone
+func TestDiscoveryService_RefreshItem_FillsGaps(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, refreshPage)
+	}))
+	defer server.Close()
+
+	feed := newsfeed.NewMemoryNewsFeed()
+	now := time.Now().UTC()
+	item := newsfeed.NewsItem{
+		ID:           uuid.New(),
+		Title:        "Feed Title",
+		Summary:      "The whole summary, with...",
+		URL:          server.URL + "/story",
+		Authors:      []string{},
+		PublishedAt:  now,
+		DiscoveredAt: now,
+	}
+	require.NoError(t, feed.Add(item))
+	service := NewDiscoveryService(nil, feed, nil)
+
+	refreshed, changed, err := service.RefreshItem(context.Background(), feed, item.ID)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "Feed Title", refreshed.Title)
+	assert.Equal(t, "The whole summary, with nothing cut off at the end.", refreshed.Summary)
+	assert.Equal(t, []string{"Jane Doe"}, refreshed.Authors)
+	assert.True(t, refreshed.PublishedAt.Equal(time.Date(2024, time.March, 1, 9, 0, 0, 0, time.UTC)))
+
+	stored, err := feed.Get(item.ID)
+	require.NoError(t, err)
+	assert.Equal(t, refreshed.Summary, stored.Summary)
+
+	// Nothing is left to fill in
+	_, changed, err = service.RefreshItem(context.Background(), feed, item.ID)
+	require.NoError(t, err)
+	assert.False(t, changed)
+}
+
+// TestDiscoveryService_RefreshItem_WebsiteSource verifies that an item from
+// a website source is re-extracted with the source's scraper config and
+// records the publisher's change
+func TestDiscoveryService_RefreshItem_WebsiteSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, refreshPage)
+	}))
+	defer server.Close()
+
+	sourceStore := newTestSourceStore(t)
+
+	config := &scraper.ScraperConfig{
+		DiscoveryMode: "direct",
+		ArticleConfig: scraper.ArticleConfig{
+			TitleSelector:   "h1",
+			ContentSelector: "article p",
+			IgnoreMetadata:  true,
+		},
+	}
+	source, err := sourceStore.CreateSource("website", server.URL+"/story", "Example", config, nil)
+	require.NoError(t, err)
+
+	feed := newsfeed.NewMemoryNewsFeed()
+	item := newsfeed.NewsItem{
+		ID:           uuid.New(),
+		Title:        "Original Story",
+		Summary:      "Body text.",
+		URL:          server.URL + "/story",
+		SourceID:     &source.SourceID,
+		Authors:      []string{},
+		PublishedAt:  time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC),
+		DiscoveredAt: time.Now().UTC(),
+	}
+	require.NoError(t, feed.Add(item))
+	service := NewDiscoveryService(sourceStore, feed, nil)
+
+	refreshed, changed, err := service.RefreshItem(context.Background(), feed, item.ID)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "Revised Story", refreshed.Title)
+	require.Len(t, refreshed.Changes, 1)
+	assert.Equal(t, map[string]string{"title": "Original Story"}, refreshed.Changes[0].Previous)
+}
+
+// TestDiscoveryService_RefreshHandler verifies the statuses the refresh
+// endpoint responds with
+func TestDiscoveryService_RefreshHandler(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/gone") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = fmt.Fprint(w, refreshPage)
+	}))
+	defer server.Close()
+
+	feed := newsfeed.NewMemoryNewsFeed()
+	live, err := feed.Create(newsfeed.NewsItem{URL: server.URL + "/story"})
+	require.NoError(t, err)
+	gone, err := feed.Create(newsfeed.NewsItem{URL: server.URL + "/gone"})
+	require.NoError(t, err)
+	handler := NewDiscoveryService(nil, feed, nil).RefreshHandler(feed)
+
+	tests := []struct {
+		name   string
+		id     string
+		status int
+	}{
+		{"refreshed", live.ID.String(), http.StatusOK},
+		{"page gone", gone.ID.String(), http.StatusBadGateway},
+		{"unknown item", uuid.NewString(), http.StatusNotFound},
+		{"bad id", "nope", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/items/"+tt.id+"/refresh", nil)
+			req.SetPathValue("id", tt.id)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			assert.Equal(t, tt.status, rec.Code, rec.Body.String())
+		})
+	}
+
+	// Created without a title, the item was titled with its URL
+	stored, err := feed.Get(live.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Revised Story", stored.Title)
+}
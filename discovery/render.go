@@ -0,0 +1,352 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/security"
+	"github.com/chromedp/cdproto/target"
+	"github.com/chromedp/chromedp"
+	"github.com/pevans/newsfed/sources"
+)
+
+// ErrRenderUnsupported is wrapped by errors for request options a rendered
+// page can't honor, such as a proxy with credentials or a custom CA.
+var ErrRenderUnsupported = errors.New("not supported when rendering pages")
+
+// Renderer loads pages in a headless Chrome and returns them once their
+// scripts have run, for sites that build their content client-side. It
+// keeps a pool of browser contexts, each with its own cookies and proxy, so
+// that several pages can render at once without sharing state. The browser
+// is started on first use and stopped by Close.
+type Renderer struct {
+	execPath string
+	maxTabs  int
+	timeout  time.Duration
+
+	mu      sync.Mutex
+	browser context.Context // nil until the first render starts the browser
+	stop    context.CancelFunc
+	idle    []*renderTab
+	open    int           // Tabs idle or in use
+	changed chan struct{} // Closed and replaced when a tab is released
+	closed  bool
+}
+
+// renderTab is a tab in its own browser context.
+type renderTab struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	proxy  string
+}
+
+// NewRenderer returns a renderer that runs the browser at execPath,
+// rendering at most maxTabs pages at once and giving up on a page after
+// timeout. An empty execPath looks for Chrome or Chromium on the PATH; a
+// maxTabs or timeout of zero means the default.
+func NewRenderer(execPath string, maxTabs int, timeout time.Duration) *Renderer {
+	if maxTabs <= 0 {
+		maxTabs = defaultRenderTabs
+	}
+	if timeout <= 0 {
+		timeout = defaultRenderTimeout
+	}
+	return &Renderer{
+		execPath: execPath,
+		maxTabs:  maxTabs,
+		timeout:  timeout,
+		changed:  make(chan struct{}),
+	}
+}
+
+// Defaults for NewRenderer.
+const (
+	defaultRenderTabs    = 2
+	defaultRenderTimeout = 30 * time.Second
+)
+
+// Render loads rawURL, waits for opts.WaitSelector to match if it is set,
+// and returns the page as its scripts left it. The page is given the
+// User-Agent, headers, and proxy in opts. A non-200 response is returned as
+// an *HTTPStatusError, as it is by FetchHTMLWithOptions.
+func (r *Renderer) Render(ctx context.Context, rawURL string, opts RequestOptions) (*goquery.Document, error) {
+	if _, err := newRequest(ctx, rawURL); err != nil {
+		return nil, err
+	}
+	proxy, err := renderProxy(opts.ProxyURL)
+	if err != nil {
+		return nil, err
+	}
+	var insecure bool
+	if opts.TLS != nil {
+		if opts.TLS.CAFile != "" || opts.TLS.MinVersion != "" {
+			return nil, fmt.Errorf("custom TLS settings are %w", ErrRenderUnsupported)
+		}
+		insecure = opts.TLS.InsecureSkipVerify
+	}
+
+	tab, err := r.acquire(ctx, proxy)
+	if err != nil {
+		return nil, err
+	}
+
+	// The timeout is counted from when the tab is ours, so waiting for a
+	// free tab doesn't use it up
+	renderCtx, cancel := context.WithTimeout(tab.ctx, r.timeout)
+	defer cancel()
+	stop := context.AfterFunc(ctx, cancel)
+	defer stop()
+
+	html, err := r.load(renderCtx, rawURL, opts, insecure)
+	if err != nil {
+		// A tab that failed may be stuck mid-load or hold the site's
+		// cookies, so it isn't reused
+		r.release(tab, false)
+		switch {
+		case ctx.Err() != nil:
+			return nil, ctx.Err()
+		case renderCtx.Err() != nil:
+			return nil, fmt.Errorf("rendering %s timed out after %s", rawURL, r.timeout)
+		}
+		return nil, err
+	}
+	r.release(tab, true)
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return nil, &ParseError{Format: "HTML", URL: rawURL, Err: err}
+	}
+	return doc, nil
+}
+
+// load renders rawURL in the tab ctx belongs to and returns its HTML.
+func (r *Renderer) load(ctx context.Context, rawURL string, opts RequestOptions, insecure bool) (string, error) {
+	userAgent := opts.UserAgent
+	if userAgent == "" {
+		userAgent = scraperUserAgent
+	}
+	headers := network.Headers{}
+	for name, value := range opts.Headers {
+		if !strings.EqualFold(name, "User-Agent") {
+			headers[name] = value
+		}
+	}
+
+	// Headers and overrides are set on every render, since the tab may have
+	// been used for another source
+	err := chromedp.Run(ctx,
+		network.Enable(),
+		network.SetExtraHTTPHeaders(headers),
+		emulation.SetUserAgentOverride(userAgent),
+		security.SetIgnoreCertificateErrors(insecure),
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to set up page: %w", err)
+	}
+
+	// The page gets the session's cookies, including any a login set
+	if opts.Jar != nil {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return "", fmt.Errorf("%w: %w", ErrInvalidURL, err)
+		}
+		for _, c := range opts.Jar.Cookies(u) {
+			if err := chromedp.Run(ctx, network.SetCookie(c.Name, c.Value).WithURL(rawURL)); err != nil {
+				return "", fmt.Errorf("failed to set cookie: %w", err)
+			}
+		}
+	}
+
+	resp, err := chromedp.RunResponse(ctx, chromedp.Navigate(rawURL))
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch URL: %w", err)
+	}
+	if resp != nil && resp.Status != http.StatusOK {
+		return "", &HTTPStatusError{URL: rawURL, StatusCode: int(resp.Status)}
+	}
+
+	var html string
+	actions := []chromedp.Action{}
+	if opts.WaitSelector != "" {
+		actions = append(actions, chromedp.WaitReady(opts.WaitSelector, chromedp.ByQuery))
+	}
+	actions = append(actions,
+		chromedp.OuterHTML("html", &html, chromedp.ByQuery),
+		// Leave the tab blank and without the site's cookies for the next
+		// render
+		network.ClearBrowserCookies(),
+		chromedp.Navigate("about:blank"),
+	)
+	if err := chromedp.Run(ctx, actions...); err != nil {
+		return "", fmt.Errorf("failed to render page: %w", err)
+	}
+	return html, nil
+}
+
+// acquire returns a tab using proxy, waiting for one to be released if
+// maxTabs are in use. It starts the browser if it isn't running.
+func (r *Renderer) acquire(ctx context.Context, proxy string) (*renderTab, error) {
+	for {
+		r.mu.Lock()
+		if r.closed {
+			r.mu.Unlock()
+			return nil, errors.New("renderer is closed")
+		}
+		if err := r.startLocked(); err != nil {
+			r.mu.Unlock()
+			return nil, err
+		}
+
+		for i, tab := range r.idle {
+			if tab.proxy == proxy {
+				r.idle = append(r.idle[:i], r.idle[i+1:]...)
+				r.mu.Unlock()
+				return tab, nil
+			}
+		}
+
+		// Make room by closing an idle tab that uses another proxy
+		if r.open == r.maxTabs && len(r.idle) > 0 {
+			r.idle[0].cancel()
+			r.idle = r.idle[1:]
+			r.open--
+		}
+		if r.open < r.maxTabs {
+			r.open++
+			browser := r.browser
+			r.mu.Unlock()
+
+			tabCtx, cancel := chromedp.NewContext(browser, chromedp.WithNewBrowserContext(
+				func(p *target.CreateBrowserContextParams) *target.CreateBrowserContextParams {
+					if proxy == "" {
+						return p
+					}
+					return p.WithProxyServer(proxy)
+				}))
+			return &renderTab{ctx: tabCtx, cancel: cancel, proxy: proxy}, nil
+		}
+
+		changed := r.changed
+		r.mu.Unlock()
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// release returns tab to the pool if reuse is true, and closes it
+// otherwise.
+func (r *Renderer) release(tab *renderTab, reuse bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if reuse && !r.closed && tab.ctx.Err() == nil {
+		r.idle = append(r.idle, tab)
+	} else {
+		tab.cancel()
+		r.open--
+	}
+	close(r.changed)
+	r.changed = make(chan struct{})
+}
+
+// startLocked starts the browser if it isn't running, or has crashed since
+// it was started. r.mu must be held.
+func (r *Renderer) startLocked() error {
+	if r.browser != nil && r.browser.Err() == nil {
+		return nil
+	}
+	if r.browser != nil {
+		// The browser died, taking its tabs with it
+		r.stopLocked()
+	}
+
+	opts := append(chromedp.DefaultExecAllocatorOptions[:],
+		chromedp.WSURLReadTimeout(r.timeout),
+	)
+	if r.execPath != "" {
+		opts = append(opts, chromedp.ExecPath(r.execPath))
+	}
+	if os.Geteuid() == 0 {
+		// Chrome refuses to start its sandbox as root
+		opts = append(opts, chromedp.NoSandbox)
+	}
+
+	allocCtx, cancelAlloc := chromedp.NewExecAllocator(context.Background(), opts...)
+	browser, cancelBrowser := chromedp.NewContext(allocCtx)
+	if err := chromedp.Run(browser); err != nil {
+		cancelBrowser()
+		cancelAlloc()
+		return fmt.Errorf("failed to start browser: %w", err)
+	}
+
+	r.browser = browser
+	r.stop = func() {
+		cancelBrowser()
+		cancelAlloc()
+	}
+	return nil
+}
+
+// stopLocked stops the browser and forgets its tabs. Tabs in use fail and
+// are closed when released. r.mu must be held.
+func (r *Renderer) stopLocked() {
+	if r.browser == nil {
+		return
+	}
+	r.stop()
+	r.browser = nil
+	r.stop = nil
+	r.open -= len(r.idle)
+	r.idle = nil
+}
+
+// Close stops the browser. Renders in progress fail, and later ones return
+// an error.
+func (r *Renderer) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	r.stopLocked()
+	close(r.changed)
+	r.changed = make(chan struct{})
+	return nil
+}
+
+// renderProxy converts proxyURL to the form Chrome takes for a browser
+// context's proxy server, or returns an error wrapping ErrRenderUnsupported
+// for a proxy Chrome can't use.
+func renderProxy(proxyURL string) (string, error) {
+	if proxyURL == "" {
+		return "", nil
+	}
+	if err := sources.ValidateProxyURL(proxyURL); err != nil {
+		return "", err
+	}
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return "", sources.ErrInvalidProxyURL
+	}
+	if u.User != nil {
+		return "", fmt.Errorf("proxies with credentials are %w", ErrRenderUnsupported)
+	}
+
+	// Chrome resolves host names through SOCKS5 proxies already
+	scheme := u.Scheme
+	if scheme == "socks5h" {
+		scheme = "socks5"
+	}
+	return scheme + "://" + u.Host, nil
+}
@@ -0,0 +1,170 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy says how often and how patiently a request is retried after a
+// transient failure within one fetch: a network error, 429 Too Many
+// Requests, or a 5xx status other than 501. Only when the retries are used
+// up does the fetch fail and count against the source.
+type RetryPolicy struct {
+	// Attempts is the number of retries after the first request; zero
+	// turns retries off
+	Attempts int
+	// BaseDelay is the delay before the first retry. Each later retry
+	// waits twice as long, less a random jitter of up to half the delay.
+	BaseDelay time.Duration
+	// MaxDelay caps every delay, including one asked for by Retry-After.
+	// Zero means thirty seconds.
+	MaxDelay time.Duration
+}
+
+// maxDelay returns MaxDelay, or its default if unset.
+func (p RetryPolicy) maxDelay() time.Duration {
+	if p.MaxDelay <= 0 {
+		return 30 * time.Second
+	}
+	return p.MaxDelay
+}
+
+// delay returns how long to wait before retry number attempt (from zero),
+// honoring the Retry-After header of resp when it has one.
+func (p RetryPolicy) delay(attempt int, resp *http.Response) time.Duration {
+	maxDelay := p.maxDelay()
+	if resp != nil {
+		if after, ok := retryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+			return min(after, maxDelay)
+		}
+	}
+
+	d := p.BaseDelay
+	for i := 0; i < attempt && d < maxDelay; i++ {
+		d *= 2
+	}
+	d = min(d, maxDelay)
+	if d <= 0 {
+		return 0
+	}
+	// Full delay less up to half of it, so clients retrying together
+	// spread out
+	return d - rand.N(d/2+1)
+}
+
+// retryAfter parses a Retry-After header, which is either a number of
+// seconds or an HTTP date.
+func retryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// retryableStatus reports whether a response status is worth retrying.
+func retryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests ||
+		(code >= 500 && code != http.StatusNotImplemented)
+}
+
+// retryableError reports whether a failed request is worth retrying. The
+// request's own context ending is not, nor are failures that will recur,
+// such as an unknown host or an untrusted certificate.
+func retryableError(err error) bool {
+	// A deadline here is the client's own timeout rather than the
+	// context's, which do checks first, so it is worth another try
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return false
+	}
+	return !isCertificateError(err)
+}
+
+// do sends req with client, retrying transient failures as opts.Retry
+// allows. A retry is skipped when its delay would outlast the request's
+// context, so the last failure is returned in time for the caller to see
+// it. The response of the final attempt is returned, whatever its status.
+// Each attempt is counted against opts's fetch budget, and fails with a
+// *BudgetError once the budget has run out. Each one also waits its turn in
+// opts's fetch queue, keeping its slot until the response body is closed.
+func (opts RequestOptions) do(client *http.Client, req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	for attempt := 0; ; attempt++ {
+		if err := opts.budget.spendRequest(); err != nil {
+			return nil, err
+		}
+		release, err := opts.acquireSlot(ctx, req.URL.Host)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Do(req.Clone(ctx))
+		if err == nil {
+			resp.Body = opts.budget.limitBody(&slotBody{ReadCloser: resp.Body, release: release})
+		} else {
+			release()
+		}
+		if attempt >= opts.Retry.Attempts || ctx.Err() != nil {
+			return resp, err
+		}
+		if err == nil && !retryableStatus(resp.StatusCode) {
+			return resp, nil
+		}
+		if err != nil && !retryableError(err) {
+			return resp, err
+		}
+
+		delay := opts.Retry.delay(attempt, resp)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return resp, err
+		}
+
+		var reason string
+		if err != nil {
+			reason = err.Error()
+		} else {
+			reason = resp.Status
+			// Drain the body so the connection can be reused
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			_ = resp.Body.Close()
+		}
+		log.Printf("INFO: Retrying %s in %v after %s", req.URL.Redacted(), delay.Round(time.Millisecond), reason)
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// slotBody is a response body that gives back its request's fetch queue
+// slot when closed.
+type slotBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *slotBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
@@ -0,0 +1,158 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test helper: start a server that fails the first failures requests with
+// status and then serves a feed, counting every request
+func newFlakyServer(t *testing.T, failures int32, status int, header http.Header) (*httptest.Server, *atomic.Int32) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) <= failures {
+			for key, values := range header {
+				w.Header()[key] = values
+			}
+			w.WriteHeader(status)
+			return
+		}
+		_, _ = fmt.Fprint(w, `<?xml version="1.0"?>
+<rss version="2.0"><channel><title>Flaky</title>
+<item><title>One</title><link>http://feed.invalid/1</link></item>
+</channel></rss>`)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+// TestFetchFeedConditional_RetriesTransientStatus verifies that 503 and 429
+// responses are retried within the fetch until one succeeds
+func TestFetchFeedConditional_RetriesTransientStatus(t *testing.T) {
+	for _, status := range []int{http.StatusServiceUnavailable, http.StatusTooManyRequests} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			server, requests := newFlakyServer(t, 2, status, nil)
+			opts := RequestOptions{Retry: RetryPolicy{Attempts: 2, BaseDelay: time.Millisecond}}
+
+			result, err := FetchFeedConditional(context.Background(), server.URL, nil, nil, opts)
+			require.NoError(t, err)
+			assert.Equal(t, "Flaky", result.Feed.Title)
+			assert.Equal(t, int32(3), requests.Load())
+		})
+	}
+}
+
+// TestFetchFeedConditional_RetryAttemptsBounded verifies that a fetch gives
+// up once its retries are used up
+func TestFetchFeedConditional_RetryAttemptsBounded(t *testing.T) {
+	server, requests := newFlakyServer(t, 10, http.StatusBadGateway, nil)
+	opts := RequestOptions{Retry: RetryPolicy{Attempts: 2, BaseDelay: time.Millisecond}}
+
+	_, err := FetchFeedConditional(context.Background(), server.URL, nil, nil, opts)
+	require.Error(t, err)
+	assert.Equal(t, int32(3), requests.Load())
+}
+
+// TestFetchFeedConditional_NoRetryByDefault verifies that the zero retry
+// policy makes a single request
+func TestFetchFeedConditional_NoRetryByDefault(t *testing.T) {
+	server, requests := newFlakyServer(t, 1, http.StatusServiceUnavailable, nil)
+
+	_, err := FetchFeedConditional(context.Background(), server.URL, nil, nil, RequestOptions{})
+	require.Error(t, err)
+	assert.Equal(t, int32(1), requests.Load())
+}
+
+// TestFetchFeedConditional_NoRetryOnPermanentStatus verifies that statuses
+// that won't change on their own, such as 404, aren't retried
+func TestFetchFeedConditional_NoRetryOnPermanentStatus(t *testing.T) {
+	server, requests := newFlakyServer(t, 1, http.StatusNotFound, nil)
+	opts := RequestOptions{Retry: RetryPolicy{Attempts: 2, BaseDelay: time.Millisecond}}
+
+	_, err := FetchFeedConditional(context.Background(), server.URL, nil, nil, opts)
+	require.Error(t, err)
+	assert.Equal(t, int32(1), requests.Load())
+}
+
+// TestFetchFeedConditional_RetryAfter verifies that a Retry-After header
+// sets the retry delay, capped at the policy's maximum
+func TestFetchFeedConditional_RetryAfter(t *testing.T) {
+	server, requests := newFlakyServer(t, 1, http.StatusTooManyRequests,
+		http.Header{"Retry-After": {"120"}})
+	opts := RequestOptions{Retry: RetryPolicy{
+		Attempts:  1,
+		BaseDelay: time.Millisecond,
+		MaxDelay:  200 * time.Millisecond,
+	}}
+
+	start := time.Now()
+	_, err := FetchFeedConditional(context.Background(), server.URL, nil, nil, opts)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+	assert.Less(t, time.Since(start), 10*time.Second)
+	assert.Equal(t, int32(2), requests.Load())
+}
+
+// TestFetchFeedConditional_RetryStopsAtDeadline verifies that a retry whose
+// delay would outlast the fetch's deadline isn't attempted
+func TestFetchFeedConditional_RetryStopsAtDeadline(t *testing.T) {
+	server, requests := newFlakyServer(t, 1, http.StatusServiceUnavailable,
+		http.Header{"Retry-After": {"30"}})
+	opts := RequestOptions{Retry: RetryPolicy{Attempts: 2, BaseDelay: time.Millisecond}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err := FetchFeedConditional(ctx, server.URL, nil, nil, opts)
+	require.Error(t, err)
+	assert.NoError(t, ctx.Err())
+	assert.Equal(t, int32(1), requests.Load())
+}
+
+// TestRetryPolicy_delay verifies exponential backoff with jitter and its cap
+func TestRetryPolicy_delay(t *testing.T) {
+	policy := RetryPolicy{Attempts: 5, BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+
+	for range 20 {
+		d := policy.delay(0, nil)
+		assert.GreaterOrEqual(t, d, 500*time.Millisecond)
+		assert.LessOrEqual(t, d, time.Second)
+
+		d = policy.delay(2, nil)
+		assert.GreaterOrEqual(t, d, 2*time.Second)
+		assert.LessOrEqual(t, d, 4*time.Second)
+
+		d = policy.delay(10, nil)
+		assert.GreaterOrEqual(t, d, 2500*time.Millisecond)
+		assert.LessOrEqual(t, d, 5*time.Second)
+	}
+}
+
+// TestRetryAfter verifies parsing of both forms of the Retry-After header
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	d, ok := retryAfter("7", now)
+	assert.True(t, ok)
+	assert.Equal(t, 7*time.Second, d)
+
+	d, ok = retryAfter(now.Add(time.Minute).Format(http.TimeFormat), now)
+	assert.True(t, ok)
+	assert.Equal(t, time.Minute, d)
+
+	d, ok = retryAfter(now.Add(-time.Minute).Format(http.TimeFormat), now)
+	assert.True(t, ok)
+	assert.Zero(t, d)
+
+	for _, value := range []string{"", "-1", "soon"} {
+		_, ok = retryAfter(value, now)
+		assert.False(t, ok, value)
+	}
+}
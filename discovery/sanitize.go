@@ -0,0 +1,209 @@
+package discovery
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// summaryTags lists the elements kept in sanitized summary HTML and the
+// attributes each may carry. Other elements are unwrapped: their content is
+// kept but the tags are not.
+var summaryTags = map[atom.Atom][]string{
+	atom.A:          {"href", "title"},
+	atom.B:          nil,
+	atom.Blockquote: nil,
+	atom.Br:         nil,
+	atom.Code:       nil,
+	atom.Em:         nil,
+	atom.Figcaption: nil,
+	atom.Figure:     nil,
+	atom.H1:         nil,
+	atom.H2:         nil,
+	atom.H3:         nil,
+	atom.H4:         nil,
+	atom.H5:         nil,
+	atom.H6:         nil,
+	atom.I:          nil,
+	atom.Img:        {"src", "alt", "title"},
+	atom.Li:         nil,
+	atom.Ol:         nil,
+	atom.P:          nil,
+	atom.Pre:        nil,
+	atom.S:          nil,
+	atom.Strong:     nil,
+	atom.Sub:        nil,
+	atom.Sup:        nil,
+	atom.U:          nil,
+	atom.Ul:         nil,
+}
+
+// droppedSummaryTags lists the elements removed from summaries together
+// with their content, because they run code, embed other documents, or
+// hold nothing a reader should see.
+var droppedSummaryTags = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Iframe:   true,
+	atom.Frame:    true,
+	atom.Object:   true,
+	atom.Embed:    true,
+	atom.Applet:   true,
+	atom.Form:     true,
+	atom.Input:    true,
+	atom.Button:   true,
+	atom.Select:   true,
+	atom.Textarea: true,
+	atom.Noscript: true,
+	atom.Template: true,
+	atom.Svg:      true,
+	atom.Math:     true,
+	atom.Head:     true,
+	atom.Title:    true,
+	atom.Meta:     true,
+	atom.Link:     true,
+	atom.Base:     true,
+}
+
+// blockSummaryTags lists the elements that separate words in the plain-text
+// form of a summary, so "<p>one</p><p>two</p>" reads "one two".
+var blockSummaryTags = map[atom.Atom]bool{
+	atom.Address: true, atom.Article: true, atom.Aside: true,
+	atom.Blockquote: true, atom.Br: true, atom.Dd: true, atom.Div: true,
+	atom.Dl: true, atom.Dt: true, atom.Figcaption: true, atom.Figure: true,
+	atom.Footer: true, atom.H1: true, atom.H2: true, atom.H3: true,
+	atom.H4: true, atom.H5: true, atom.H6: true, atom.Header: true,
+	atom.Hr: true, atom.Li: true, atom.Ol: true, atom.P: true, atom.Pre: true,
+	atom.Section: true, atom.Table: true, atom.Td: true, atom.Th: true,
+	atom.Tr: true, atom.Ul: true,
+}
+
+// cleanSummary turns a summary that may contain HTML into plain text with
+// entities decoded and whitespace normalized, and into sanitized HTML that
+// keeps only harmless formatting and links. The HTML is empty when the
+// summary has no markup worth keeping.
+func cleanSummary(raw string) (text, cleanHTML string) {
+	if strings.TrimSpace(raw) == "" {
+		return "", ""
+	}
+
+	nodes, err := html.ParseFragment(strings.NewReader(raw), &html.Node{
+		Type:     html.ElementNode,
+		Data:     "div",
+		DataAtom: atom.Div,
+	})
+	if err != nil {
+		return strings.Join(strings.Fields(raw), " "), ""
+	}
+
+	var textBuf, htmlBuf strings.Builder
+	for _, n := range nodes {
+		writeSummaryText(&textBuf, n)
+		writeSummaryHTML(&htmlBuf, n)
+	}
+
+	text = strings.Join(strings.Fields(textBuf.String()), " ")
+	cleanHTML = strings.TrimSpace(htmlBuf.String())
+	if !strings.Contains(cleanHTML, "<") {
+		cleanHTML = ""
+	}
+	return text, cleanHTML
+}
+
+// writeSummaryText writes the text of n, skipping dropped elements and
+// separating block elements with spaces.
+func writeSummaryText(b *strings.Builder, n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		b.WriteString(n.Data)
+	case html.ElementNode:
+		if droppedSummaryTags[n.DataAtom] {
+			return
+		}
+		block := blockSummaryTags[n.DataAtom]
+		if block {
+			b.WriteByte(' ')
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			writeSummaryText(b, c)
+		}
+		if block {
+			b.WriteByte(' ')
+		}
+	}
+}
+
+// writeSummaryHTML writes n as sanitized HTML. Comments, dropped elements,
+// and attributes outside the allow list are left out, as are links and
+// images whose URLs are not http, https, or (for links) mailto.
+func writeSummaryHTML(b *strings.Builder, n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		b.WriteString(html.EscapeString(n.Data))
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+
+	if droppedSummaryTags[n.DataAtom] {
+		return
+	}
+
+	allowed, keep := summaryTags[n.DataAtom]
+	if keep {
+		attrs := summaryAttrs(n, allowed)
+		hasSrc := slices.ContainsFunc(attrs, func(a html.Attribute) bool { return a.Key == "src" })
+		if n.DataAtom == atom.Img && !hasSrc {
+			return
+		}
+		b.WriteString("<" + n.Data)
+		for _, attr := range attrs {
+			b.WriteString(" " + attr.Key + `="` + html.EscapeString(attr.Val) + `"`)
+		}
+		b.WriteString(">")
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		writeSummaryHTML(b, c)
+	}
+	if keep && n.DataAtom != atom.Br && n.DataAtom != atom.Img {
+		b.WriteString("</" + n.Data + ">")
+	}
+}
+
+// summaryAttrs returns the attributes of n that are in allowed and, for URL
+// attributes, point somewhere safe.
+func summaryAttrs(n *html.Node, allowed []string) []html.Attribute {
+	var attrs []html.Attribute
+	for _, attr := range n.Attr {
+		if attr.Namespace != "" || !slices.Contains(allowed, attr.Key) {
+			continue
+		}
+		if attr.Key == "href" || attr.Key == "src" {
+			if !isSafeSummaryURL(attr.Val, attr.Key == "href") {
+				continue
+			}
+		}
+		attrs = append(attrs, attr)
+	}
+	return attrs
+}
+
+// isSafeSummaryURL reports whether raw is an absolute http or https URL, or
+// a mailto URL when allowMailto is set.
+func isSafeSummaryURL(raw string, allowMailto bool) bool {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		return u.Host != ""
+	case "mailto":
+		return allowMailto
+	}
+	return false
+}
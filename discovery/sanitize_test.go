@@ -0,0 +1,131 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/mmcdole/gofeed"
+	"github.com/pevans/newsfed/newsfeed"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCleanSummary verifies that summaries are reduced to decoded plain text
+// and to HTML without unsafe elements, attributes, or URLs
+func TestCleanSummary(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		wantText string
+		wantHTML string
+	}{
+		{
+			name:     "plain text",
+			raw:      "Just  a\n summary",
+			wantText: "Just a summary",
+			wantHTML: "",
+		},
+		{
+			name:     "entities",
+			raw:      "Caf&eacute; &amp; bar &#8212; &quot;open&quot;",
+			wantText: `Café & bar — "open"`,
+			wantHTML: "",
+		},
+		{
+			name:     "paragraphs and formatting",
+			raw:      "<p>One <b>bold</b> step.</p><p>Two&nbsp;steps.</p>",
+			wantText: "One bold step. Two steps.",
+			wantHTML: "<p>One <b>bold</b> step.</p><p>Two\u00a0steps.</p>",
+		},
+		{
+			name:     "scripts and styles dropped",
+			raw:      `<p onclick="steal()">Hi</p><script>alert(1)</script><style>p{}</style><iframe src="http://evil.example"></iframe>`,
+			wantText: "Hi",
+			wantHTML: "<p>Hi</p>",
+		},
+		{
+			name:     "unknown elements unwrapped",
+			raw:      `<div class="x"><span style="color:red">Read</span> <font>more</font></div>`,
+			wantText: "Read more",
+			wantHTML: "",
+		},
+		{
+			name:     "unsafe links",
+			raw:      `<a href="javascript:alert(1)">bad</a> <a href="https://example.com/a?b=1&amp;c=2" target="_blank">good</a>`,
+			wantText: "bad good",
+			wantHTML: `<a>bad</a> <a href="https://example.com/a?b=1&amp;c=2">good</a>`,
+		},
+		{
+			name:     "images",
+			raw:      `<img src="https://example.com/i.png" alt="Pic" onerror="x()"><img src="data:image/png;base64,AAAA">`,
+			wantText: "",
+			wantHTML: `<img src="https://example.com/i.png" alt="Pic">`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, cleanHTML := cleanSummary(tt.raw)
+			assert.Equal(t, tt.wantText, text)
+			assert.Equal(t, tt.wantHTML, cleanHTML)
+		})
+	}
+}
+
+// TestFeedItemToNewsItem_HTMLDescription verifies that an HTML description
+// becomes a plain-text summary with the sanitized HTML alongside
+func TestFeedItemToNewsItem_HTMLDescription(t *testing.T) {
+	item := &gofeed.Item{
+		Title:       "Article",
+		Description: `<p>Fish &amp; chips<script>x()</script></p>`,
+		Link:        "http://example.com/article",
+	}
+
+	newsItem := FeedItemToNewsItem(item, "Feed", uuid.New())
+
+	assert.Equal(t, "Fish & chips", newsItem.Summary)
+	assert.Equal(t, "<p>Fish &amp; chips</p>", newsItem.SummaryHTML)
+}
+
+// TestFetchRSSFeed_KeepSummaryHTML verifies that sanitized summary HTML is
+// only stored when the discovery config asks for it
+func TestFetchRSSFeed_KeepSummaryHTML(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `<?xml version="1.0"?>
+<rss version="2.0"><channel><title>Test</title>
+<item><title>One</title><link>http://example.com/1</link>
+<description><![CDATA[<p>Hello <em>world</em></p>]]></description></item>
+</channel></rss>`)
+	}))
+	defer server.Close()
+
+	for _, keep := range []bool{false, true} {
+		t.Run(fmt.Sprintf("keep=%v", keep), func(t *testing.T) {
+			sourceStore := newTestSourceStore(t)
+			newsFeed := newsfeed.NewMemoryNewsFeed()
+
+			config := DefaultDiscoveryConfig()
+			config.KeepSummaryHTML = keep
+			service := NewDiscoveryService(sourceStore, newsFeed, config)
+
+			source, err := sourceStore.CreateSource("rss", server.URL, "Test", nil, nil)
+			require.NoError(t, err)
+			_, _, err = service.fetchRSSFeed(context.Background(), *source, nil)
+			require.NoError(t, err)
+
+			result, err := newsFeed.List()
+			require.NoError(t, err)
+			require.Len(t, result.Items, 1)
+			assert.Equal(t, "Hello world", result.Items[0].Summary)
+			if keep {
+				assert.Equal(t, "<p>Hello <em>world</em></p>", result.Items[0].SummaryHTML)
+			} else {
+				assert.Empty(t, result.Items[0].SummaryHTML)
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/google/uuid"
+	"github.com/pevans/newsfed/internal/httpjson"
+	"github.com/pevans/newsfed/newsfeed"
+	"github.com/pevans/newsfed/sources"
+)
+
+// SavedCategory is the category of items saved with SaveURL, so that saved
+// links can be listed apart from those found by discovery.
+const SavedCategory = "saved"
+
+// SaveURL adds the page at rawURL to feed as a saved item. The page is
+// fetched for its title, description, authors, published date, and site
+// name, taken from JSON-LD, Open Graph, and meta tags as in Spec 3 section
+// 3.4.2. A page that can't be fetched is still saved, titled with its URL,
+// so that a link isn't lost to a site that is down or refuses bots.
+//
+// If the feed already has the page, nothing is added and the existing item
+// is returned with created false. If that item is deleted before it can be
+// read, SaveURL returns ErrItemNotFound rather than a nil item. A URL that
+// points to a loopback, private, or link-local address, or redirects to one,
+// is refused with ErrPrivateAddress, so that clients can't use the server to
+// reach internal services.
+func (ds *DiscoveryService) SaveURL(ctx context.Context, feed *newsfeed.NewsFeed, rawURL string) (item *newsfeed.NewsItem, created bool, err error) {
+	rawURL = strings.TrimSpace(rawURL)
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, false, newsfeed.ErrInvalidItem
+	}
+	if !ds.savePrivate {
+		if err := checkPublicHost(ctx, parsed.Hostname()); err != nil {
+			return nil, false, err
+		}
+	}
+
+	index, err := feed.DedupIndex()
+	if err != nil {
+		return nil, false, err
+	}
+	category := SavedCategory
+	saved := newsfeed.NewsItem{URL: rawURL, Category: &category}
+	if kind, id := index.Match(saved); kind != newsfeed.NotDuplicate {
+		return existingItem(feed, id)
+	}
+
+	opts, err := ds.requestOptionsFor(sources.Source{})
+	opts.PublicOnly = !ds.savePrivate
+	var doc *goquery.Document
+	if err == nil {
+		doc, err = FetchHTMLWithOptions(ctx, rawURL, opts)
+	}
+	if err != nil {
+		log.Printf("WARN: Failed to fetch saved page %s: %v", rawURL, err)
+	} else {
+		meta := pageMetadata(doc)
+		saved.Title = meta.Title
+		if saved.Title == "" {
+			saved.Title = doc.Find("title").First().Text()
+		}
+		saved.Title = strings.Join(strings.Fields(saved.Title), " ")
+		saved.Summary = strings.Join(strings.Fields(meta.Description), " ")
+		saved.Authors = meta.Authors
+		if meta.PublishedAt != nil {
+			saved.PublishedAt = meta.PublishedAt.UTC()
+			setPublishedRaw(&saved, meta.PublishedRaw)
+		}
+		if name := pageTitle(doc); name != "" && name != saved.Title {
+			saved.Publisher = &name
+		}
+
+		// The page may be a copy of one already in the feed under another
+		// URL
+		if kind, id := index.Match(saved); kind != newsfeed.NotDuplicate {
+			return existingItem(feed, id)
+		}
+		ds.enrich(ctx, &saved)
+	}
+
+	item, err = feed.Create(saved)
+	if errors.Is(err, newsfeed.ErrDuplicateURL) {
+		// Saved by another request since the index was read
+		if index, err = feed.DedupIndex(); err != nil {
+			return nil, false, err
+		}
+		kind, id := index.Match(newsfeed.NewsItem{URL: rawURL})
+		if kind == newsfeed.NotDuplicate {
+			return nil, false, newsfeed.ErrItemNotFound
+		}
+		return existingItem(feed, id)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return item, true, nil
+}
+
+// existingItem returns the item SaveURL matched in feed, with created false.
+// An item removed since it was matched is reported as ErrItemNotFound rather
+// than returned as nil.
+func existingItem(feed *newsfeed.NewsFeed, id uuid.UUID) (*newsfeed.NewsItem, bool, error) {
+	existing, err := feed.Get(id)
+	if err != nil {
+		return nil, false, err
+	}
+	if existing == nil {
+		return nil, false, newsfeed.ErrItemNotFound
+	}
+	return existing, false, nil
+}
+
+// saveRequest is the body of a request to save a page.
+type saveRequest struct {
+	URL  string `json:"url"`
+	Text string `json:"text"`
+}
+
+// SaveHandler saves a page to feed, as SaveURL does, for POST
+// /api/v1/items/save. The page is given as {"url": "..."} in a JSON body, or
+// as a url form value so that bookmarklets and share targets can post a
+// plain form. Share targets that put the link in their text, as many mobile
+// apps do, are also accepted: without a url, the first link in text is
+// saved. It responds 201 Created with a new item, 200 OK with the existing
+// item if the page was already in the feed, 400 if no http or https URL is
+// given or it points to a private address, and 409 if the existing item was
+// deleted while the page was being saved.
+func (ds *DiscoveryService) SaveHandler(feed *newsfeed.NewsFeed) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req saveRequest
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if mediaType == "application/json" {
+			if err := json.NewDecoder(io.LimitReader(r.Body, 1<<16)).Decode(&req); err != nil {
+				httpjson.Error(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+				return
+			}
+		} else {
+			r.Body = http.MaxBytesReader(w, r.Body, 1<<16)
+			if err := r.ParseMultipartForm(1 << 16); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+				httpjson.Error(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+				return
+			}
+			req.URL, req.Text = r.FormValue("url"), r.FormValue("text")
+		}
+		if strings.TrimSpace(req.URL) == "" {
+			req.URL = firstLink(req.Text)
+		}
+
+		item, created, err := ds.SaveURL(r.Context(), feed, req.URL)
+		if errors.Is(err, newsfeed.ErrInvalidItem) {
+			httpjson.Error(w, http.StatusBadRequest, "url must be an absolute http or https URL")
+			return
+		}
+		if errors.Is(err, ErrPrivateAddress) {
+			httpjson.Error(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, newsfeed.ErrItemNotFound) {
+			httpjson.Error(w, http.StatusConflict, "the page was deleted while it was being saved")
+			return
+		}
+		if err != nil {
+			httpjson.Error(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if !created {
+			httpjson.Write(w, http.StatusOK, item)
+			return
+		}
+		httpjson.Write(w, http.StatusCreated, item)
+	})
+}
+
+// firstLink returns the first http or https URL in text, or "" if there is
+// none.
+func firstLink(text string) string {
+	for _, field := range strings.Fields(text) {
+		if strings.HasPrefix(field, "http://") || strings.HasPrefix(field, "https://") {
+			return field
+		}
+	}
+	return ""
+}
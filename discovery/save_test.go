@@ -0,0 +1,236 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pevans/newsfed/newsfeed"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDiscoveryService_SaveURL verifies that a saved page is stored with
+// the metadata it declares, tagged as saved, and that saving it again
+// returns the existing item
+func TestDiscoveryService_SaveURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `<html><head>
+			<title>Fallback</title>
+			<meta property="og:title" content="Saved  Article">
+			<meta property="og:description" content="What it's about">
+			<meta property="og:site_name" content="Example News">
+			<meta name="author" content="Jane Doe">
+			<meta property="article:published_time" content="2024-03-01T09:00:00Z">
+		</head><body></body></html>`)
+	}))
+	defer server.Close()
+
+	feed := newsfeed.NewMemoryNewsFeed()
+	service := NewDiscoveryService(nil, feed, nil)
+	service.savePrivate = true
+
+	item, created, err := service.SaveURL(context.Background(), feed, server.URL+"/article")
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.Equal(t, "Saved Article", item.Title)
+	assert.Equal(t, "What it's about", item.Summary)
+	assert.Equal(t, []string{"Jane Doe"}, item.Authors)
+	require.NotNil(t, item.Publisher)
+	assert.Equal(t, "Example News", *item.Publisher)
+	require.NotNil(t, item.Category)
+	assert.Equal(t, SavedCategory, *item.Category)
+	assert.True(t, item.PublishedAt.Equal(time.Date(2024, time.March, 1, 9, 0, 0, 0, time.UTC)))
+
+	again, created, err := service.SaveURL(context.Background(), feed, server.URL+"/article?utm_source=share")
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, item.ID, again.ID)
+
+	_, _, err = service.SaveURL(context.Background(), feed, "ftp://example.com/file")
+	assert.ErrorIs(t, err, newsfeed.ErrInvalidItem)
+}
+
+// TestDiscoveryService_SaveURL_FetchFails verifies that a page that can't
+// be fetched is still saved, titled with its URL
+func TestDiscoveryService_SaveURL_FetchFails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	feed := newsfeed.NewMemoryNewsFeed()
+	service := NewDiscoveryService(nil, feed, nil)
+	service.savePrivate = true
+
+	item, created, err := service.SaveURL(context.Background(), feed, server.URL+"/blocked")
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.Equal(t, server.URL+"/blocked", item.Title)
+}
+
+// TestDiscoveryService_SaveURL_SavedConcurrently verifies that a page saved
+// by another request while it was being fetched is returned as the existing
+// item
+func TestDiscoveryService_SaveURL_SavedConcurrently(t *testing.T) {
+	feed := newsfeed.NewMemoryNewsFeed()
+	var other *newsfeed.NewsItem
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var err error
+		other, err = feed.Create(newsfeed.NewsItem{URL: "http://" + r.Host + r.URL.Path})
+		require.NoError(t, err)
+		_, _ = fmt.Fprint(w, `<html><head><title>Raced</title></head></html>`)
+	}))
+	defer server.Close()
+
+	service := NewDiscoveryService(nil, feed, nil)
+	service.savePrivate = true
+
+	item, created, err := service.SaveURL(context.Background(), feed, server.URL+"/raced")
+	require.NoError(t, err)
+	assert.False(t, created)
+	require.NotNil(t, item)
+	assert.Equal(t, other.ID, item.ID)
+}
+
+// TestDiscoveryService_SaveURL_DeletedConcurrently verifies that a page
+// saved and then deleted by other requests while it was being fetched is
+// reported as ErrItemNotFound instead of a nil item
+func TestDiscoveryService_SaveURL_DeletedConcurrently(t *testing.T) {
+	inner := newsfeed.NewMemoryNewsFeed()
+	store := &vanishingStore{feed: inner}
+	feed := newsfeed.New(store)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+	_, err := inner.Create(newsfeed.NewsItem{URL: server.URL + "/gone"})
+	require.NoError(t, err)
+
+	service := NewDiscoveryService(nil, feed, nil)
+	service.savePrivate = true
+
+	item, created, err := service.SaveURL(context.Background(), feed, server.URL+"/gone")
+	assert.ErrorIs(t, err, newsfeed.ErrItemNotFound)
+	assert.False(t, created)
+	assert.Nil(t, item)
+}
+
+// Test helper: vanishingStore lists its items only on the second call to
+// List, as if another request saved an item after SaveURL read the feed and
+// deleted it again before SaveURL could read it back.
+type vanishingStore struct {
+	feed  *newsfeed.NewsFeed
+	lists int
+}
+
+func (s *vanishingStore) List() (*newsfeed.ListResult, error) {
+	s.lists++
+	if s.lists != 2 {
+		return &newsfeed.ListResult{}, nil
+	}
+	return s.feed.List()
+}
+
+func (s *vanishingStore) Get(id uuid.UUID) (*newsfeed.NewsItem, error) {
+	return nil, nil
+}
+
+func (s *vanishingStore) Add(item newsfeed.NewsItem) error    { return s.feed.Add(item) }
+func (s *vanishingStore) Update(item newsfeed.NewsItem) error { return s.feed.Update(item) }
+func (s *vanishingStore) Delete(id uuid.UUID) error           { return s.feed.Delete(id) }
+func (s *vanishingStore) Close() error                        { return nil }
+
+// TestDiscoveryService_SaveHandler verifies the request bodies the save
+// endpoint accepts and the statuses it responds with
+func TestDiscoveryService_SaveHandler(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, `<html><head><title>Page %s</title></head></html>`, r.URL.Path)
+	}))
+	defer server.Close()
+
+	feed := newsfeed.NewMemoryNewsFeed()
+	service := NewDiscoveryService(nil, feed, nil)
+	service.savePrivate = true
+	handler := service.SaveHandler(feed)
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+		title       string
+	}{
+		{"json", "application/json", `{"url": "` + server.URL + `/json"}`, http.StatusCreated, "Page /json"},
+		{"form", "application/x-www-form-urlencoded", url.Values{"url": {server.URL + "/form"}}.Encode(), http.StatusCreated, "Page /form"},
+		{"share target text", "application/x-www-form-urlencoded", url.Values{"text": {"Worth a read " + server.URL + "/shared"}}.Encode(), http.StatusCreated, "Page /shared"},
+		{"already saved", "application/json", `{"url": "` + server.URL + `/json"}`, http.StatusOK, "Page /json"},
+		{"no url", "application/json", `{}`, http.StatusBadRequest, ""},
+		{"malformed json", "application/json", `{`, http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/items/save", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			require.Equal(t, tt.status, rec.Code, rec.Body.String())
+			if tt.title != "" {
+				var item newsfeed.NewsItem
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &item))
+				assert.Equal(t, tt.title, item.Title)
+			}
+		})
+	}
+}
+
+// TestDiscoveryService_SaveURL_PrivateAddress verifies that pages on
+// loopback and private addresses are refused, whether named directly or
+// reached by a redirect, and that nothing is saved for them
+func TestDiscoveryService_SaveURL_PrivateAddress(t *testing.T) {
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `<html><head><title>Internal</title></head></html>`)
+	}))
+	defer internal.Close()
+
+	feed := newsfeed.NewMemoryNewsFeed()
+	service := NewDiscoveryService(nil, feed, nil)
+
+	for _, rawURL := range []string{internal.URL + "/admin", "http://10.0.0.1/", "http://169.254.169.254/latest/meta-data/", "http://[::1]/"} {
+		_, _, err := service.SaveURL(context.Background(), feed, rawURL)
+		assert.ErrorIs(t, err, ErrPrivateAddress, rawURL)
+	}
+	items, err := feed.List()
+	require.NoError(t, err)
+	assert.Empty(t, items.Items)
+
+	handler := service.SaveHandler(feed)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/items/save", strings.NewReader(`{"url": "`+internal.URL+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+// TestDialPublicOnly verifies that clients made with PublicOnly don't
+// connect to private addresses, such as one a public page redirects to
+func TestDialPublicOnly(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `<html></html>`)
+	}))
+	defer server.Close()
+
+	_, err := FetchHTMLWithOptions(context.Background(), server.URL, RequestOptions{PublicOnly: true})
+	assert.ErrorIs(t, err, ErrPrivateAddress)
+	_, err = FetchHTMLWithOptions(context.Background(), server.URL, RequestOptions{})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,262 @@
+package discovery
+
+import (
+	"encoding/json"
+	"log"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MetricsSnapshot is the cumulative part of DiscoveryMetrics: its counters
+// and histograms, but not the number of enabled sources. The daemon saves a
+// snapshot to the metadata store every metrics interval and restores it at
+// startup, so that the counters keep counting across restarts.
+type MetricsSnapshot struct {
+	Since           time.Time                       `json:"since"` // When counting began
+	SourcesFetched  int                             `json:"sources_fetched_total"`
+	SourcesFailed   int                             `json:"sources_failed_total"`
+	ItemsDiscovered int                             `json:"items_discovered_total"`
+	FetchDuration   HistogramSnapshot               `json:"fetch_duration"`
+	DiscoveryLag    HistogramSnapshot               `json:"discovery_lag"`
+	SourceLags      map[uuid.UUID]SourceLagSnapshot `json:"source_lags,omitempty"`
+}
+
+// HistogramSnapshot is a histogram of durations.
+type HistogramSnapshot struct {
+	Buckets []float64 `json:"buckets"` // Upper bounds, in seconds
+	Counts  []int     `json:"counts"`  // Observations at or below each bound
+	Count   int       `json:"count"`
+	Sum     float64   `json:"sum_seconds"`
+}
+
+// SourceLagSnapshot totals the discovery lag of one source's items.
+type SourceLagSnapshot struct {
+	Name  string  `json:"name"`
+	Count int     `json:"count"`
+	Sum   float64 `json:"sum_seconds"`
+}
+
+// Snapshot returns the cumulative metrics.
+func (m *DiscoveryMetrics) Snapshot() MetricsSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := MetricsSnapshot{
+		Since:           m.since,
+		SourcesFetched:  m.SourcesFetchedTotal,
+		SourcesFailed:   m.SourcesFailedTotal,
+		ItemsDiscovered: m.ItemsDiscoveredTotal,
+		FetchDuration:   newHistogramSnapshot(fetchDurationBuckets, m.durationBucketCounts, m.durationCount, m.durationSum),
+		DiscoveryLag:    newHistogramSnapshot(discoveryLagBuckets, m.lagBucketCounts, m.lagCount, m.lagSum),
+	}
+	if len(m.sourceLags) > 0 {
+		s.SourceLags = make(map[uuid.UUID]SourceLagSnapshot, len(m.sourceLags))
+		for id, totals := range m.sourceLags {
+			s.SourceLags[id] = SourceLagSnapshot{Name: totals.name, Count: totals.count, Sum: totals.sum.Seconds()}
+		}
+	}
+	return s
+}
+
+// newHistogramSnapshot copies a histogram bucketed by buckets.
+func newHistogramSnapshot(buckets []float64, counts []int, count int, sum time.Duration) HistogramSnapshot {
+	return HistogramSnapshot{Buckets: slices.Clone(buckets), Counts: slices.Clone(counts), Count: count, Sum: sum.Seconds()}
+}
+
+// restore adds the counts in s to the metrics and counts them from s.Since.
+// A histogram saved with other buckets than the current ones can't be
+// combined with them, so it is left out.
+func (m *DiscoveryMetrics) restore(s MetricsSnapshot) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !s.Since.IsZero() && s.Since.Before(m.since) {
+		m.since = s.Since
+	}
+	m.SourcesFetchedTotal += s.SourcesFetched
+	m.SourcesFailedTotal += s.SourcesFailed
+	m.ItemsDiscoveredTotal += s.ItemsDiscovered
+
+	if s.FetchDuration.sameBuckets(fetchDurationBuckets) {
+		addCounts(m.durationBucketCounts, s.FetchDuration.Counts)
+		m.durationCount += s.FetchDuration.Count
+		m.durationSum += seconds(s.FetchDuration.Sum)
+	} else {
+		log.Printf("WARN: Saved fetch duration histogram has different buckets; not restoring it")
+	}
+	if s.DiscoveryLag.sameBuckets(discoveryLagBuckets) {
+		addCounts(m.lagBucketCounts, s.DiscoveryLag.Counts)
+		m.lagCount += s.DiscoveryLag.Count
+		m.lagSum += seconds(s.DiscoveryLag.Sum)
+		for id, saved := range s.SourceLags {
+			totals, ok := m.sourceLags[id]
+			if !ok {
+				totals = &sourceLag{name: saved.Name}
+				m.sourceLags[id] = totals
+			}
+			totals.count += saved.Count
+			totals.sum += seconds(saved.Sum)
+		}
+	} else {
+		log.Printf("WARN: Saved discovery lag histogram has different buckets; not restoring it")
+	}
+}
+
+// Add returns the sum of s and o, such as the metrics of two services,
+// counting from the earlier of their Since times.
+func (s MetricsSnapshot) Add(o MetricsSnapshot) MetricsSnapshot {
+	sum := s.combine(o, 1)
+	if sum.Since.IsZero() || (!o.Since.IsZero() && o.Since.Before(sum.Since)) {
+		sum.Since = o.Since
+	}
+	return sum
+}
+
+// Sub returns what was counted between an earlier snapshot, prev, of the
+// same service and s. If s wasn't counted on from prev, because the service
+// started over without restoring it, s is returned unchanged.
+func (s MetricsSnapshot) Sub(prev MetricsSnapshot) MetricsSnapshot {
+	if !s.Since.Equal(prev.Since) || s.SourcesFetched < prev.SourcesFetched || s.SourcesFailed < prev.SourcesFailed {
+		return s
+	}
+	return s.combine(prev, -1)
+}
+
+// combine returns s with sign times the counts in o added.
+func (s MetricsSnapshot) combine(o MetricsSnapshot, sign int) MetricsSnapshot {
+	r := MetricsSnapshot{
+		Since:           s.Since,
+		SourcesFetched:  s.SourcesFetched + sign*o.SourcesFetched,
+		SourcesFailed:   s.SourcesFailed + sign*o.SourcesFailed,
+		ItemsDiscovered: s.ItemsDiscovered + sign*o.ItemsDiscovered,
+		FetchDuration:   s.FetchDuration.combine(o.FetchDuration, sign),
+		DiscoveryLag:    s.DiscoveryLag.combine(o.DiscoveryLag, sign),
+	}
+	if len(s.SourceLags)+len(o.SourceLags) > 0 {
+		r.SourceLags = make(map[uuid.UUID]SourceLagSnapshot, len(s.SourceLags))
+		for id, lag := range s.SourceLags {
+			r.SourceLags[id] = lag
+		}
+		for id, lag := range o.SourceLags {
+			total := r.SourceLags[id]
+			if total.Name == "" {
+				total.Name = lag.Name
+			}
+			total.Count += sign * lag.Count
+			total.Sum += float64(sign) * lag.Sum
+			if total.Count == 0 {
+				delete(r.SourceLags, id)
+			} else {
+				r.SourceLags[id] = total
+			}
+		}
+	}
+	return r
+}
+
+// combine returns h with sign times the counts in o added. If either has no
+// buckets, the other's are used; if they have different buckets, h is
+// returned unchanged.
+func (h HistogramSnapshot) combine(o HistogramSnapshot, sign int) HistogramSnapshot {
+	r := HistogramSnapshot{Buckets: slices.Clone(h.Buckets), Counts: slices.Clone(h.Counts), Count: h.Count, Sum: h.Sum}
+	if len(r.Buckets) == 0 {
+		r.Buckets, r.Counts = slices.Clone(o.Buckets), make([]int, len(o.Counts))
+	}
+	if !o.sameBuckets(r.Buckets) {
+		return r
+	}
+	for i, c := range o.Counts {
+		r.Counts[i] += sign * c
+	}
+	r.Count += sign * o.Count
+	r.Sum += float64(sign) * o.Sum
+	return r
+}
+
+// sameBuckets reports whether h is bucketed by buckets. An empty histogram
+// saved without buckets matches any.
+func (h HistogramSnapshot) sameBuckets(buckets []float64) bool {
+	if len(h.Buckets) == 0 && h.Count == 0 {
+		return true
+	}
+	return slices.Equal(h.Buckets, buckets) && len(h.Counts) == len(buckets)
+}
+
+// Mean returns the mean observation, or zero if there were none.
+func (h HistogramSnapshot) Mean() time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	return seconds(h.Sum / float64(h.Count))
+}
+
+// Quantile returns the upper bound of the bucket holding the q quantile,
+// such as 0.95 for the 95th percentile, or +Inf if it is above every
+// bucket. It returns zero if there were no observations.
+func (h HistogramSnapshot) Quantile(q float64) float64 {
+	if h.Count == 0 {
+		return 0
+	}
+	rank := q * float64(h.Count)
+	for i, c := range h.Counts {
+		if i < len(h.Buckets) && float64(c) >= rank {
+			return h.Buckets[i]
+		}
+	}
+	return math.Inf(1)
+}
+
+// addCounts adds the counts in src to dst.
+func addCounts(dst, src []int) {
+	for i := range min(len(dst), len(src)) {
+		dst[i] += src[i]
+	}
+}
+
+// seconds converts a number of seconds to a time.Duration.
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// metricsWorker names the metrics this service saves: its configured
+// worker ID, or else the host name, which unlike the default worker ID
+// stays the same across restarts.
+func (ds *DiscoveryService) metricsWorker() string {
+	if ds.currentConfig().WorkerID != "" {
+		return ds.workerID
+	}
+	return hostName()
+}
+
+// restoreMetrics adds the metrics this service last saved to its own, so
+// that they carry on counting from where they were before a restart.
+func (ds *DiscoveryService) restoreMetrics() {
+	saved, err := ds.sourceStore.LatestMetrics(ds.metricsWorker())
+	if err != nil {
+		log.Printf("ERROR: Loading saved metrics failed: %v", err)
+		return
+	}
+	if saved == nil {
+		return
+	}
+	var snapshot MetricsSnapshot
+	if err := json.Unmarshal(saved.Metrics, &snapshot); err != nil {
+		log.Printf("ERROR: Saved metrics can't be read: %v", err)
+		return
+	}
+	ds.metrics.restore(snapshot)
+	log.Printf("INFO: Restored metrics saved at %s, counting since %s",
+		saved.SavedAt.Local().Format(time.DateTime), snapshot.Since.Local().Format(time.DateTime))
+}
+
+// saveMetrics saves the service's cumulative metrics to the metadata store.
+func (ds *DiscoveryService) saveMetrics() {
+	data, err := json.Marshal(ds.metrics.Snapshot())
+	if err == nil {
+		err = ds.sourceStore.SaveMetrics(ds.metricsWorker(), data, time.Now())
+	}
+	if err != nil {
+		log.Printf("ERROR: Saving metrics failed: %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package discovery
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pevans/newsfed/newsfeed"
+	"github.com/pevans/newsfed/sources"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDiscoveryService_SaveAndRestoreMetrics verifies that metrics saved by
+// one service are carried on by the next one started with the same store,
+// keeping the time counting began
+func TestDiscoveryService_SaveAndRestoreMetrics(t *testing.T) {
+	sourceStore := newTestSourceStore(t)
+	newsFeed := newsfeed.NewMemoryNewsFeed()
+
+	source := sources.Source{SourceID: uuid.New(), Name: "Daily"}
+	first := NewDiscoveryService(sourceStore, newsFeed, nil)
+	first.metrics.recordFetchSuccess(200 * time.Millisecond)
+	first.metrics.recordFetchFailure(3 * time.Second)
+	first.metrics.recordItemsDiscovered(4)
+	first.metrics.recordDiscoveryLag(source, time.Hour)
+	first.saveMetrics()
+	saved := first.metrics.Snapshot()
+
+	second := NewDiscoveryService(sourceStore, newsFeed, nil)
+	second.metrics.recordFetchSuccess(time.Second)
+	second.restoreMetrics()
+
+	restored := second.metrics.Snapshot()
+	assert.True(t, saved.Since.Equal(restored.Since))
+	assert.Equal(t, 2, restored.SourcesFetched)
+	assert.Equal(t, 1, restored.SourcesFailed)
+	assert.Equal(t, 4, restored.ItemsDiscovered)
+	assert.Equal(t, 3, restored.FetchDuration.Count)
+	assert.InDelta(t, 4.2, restored.FetchDuration.Sum, 1e-9)
+	assert.Equal(t, []int{0, 1, 1, 2, 2, 3, 3, 3, 3}, restored.FetchDuration.Counts)
+	assert.Equal(t, SourceLagSnapshot{Name: "Daily", Count: 1, Sum: 3600}, restored.SourceLags[source.SourceID])
+}
+
+// TestMetricsSnapshot_SubAndAdd verifies that the difference between two
+// snapshots of a service is what was counted between them, unless the
+// service started counting over, and that snapshots add up
+func TestMetricsSnapshot_SubAndAdd(t *testing.T) {
+	metrics := newDiscoveryMetrics()
+	metrics.recordFetchSuccess(time.Second)
+	before := metrics.Snapshot()
+	metrics.recordFetchSuccess(20 * time.Second)
+	metrics.recordItemsDiscovered(5)
+	after := metrics.Snapshot()
+
+	counted := after.Sub(before)
+	assert.Equal(t, 1, counted.SourcesFetched)
+	assert.Equal(t, 5, counted.ItemsDiscovered)
+	assert.Equal(t, 20*time.Second, counted.FetchDuration.Mean())
+
+	restarted := newDiscoveryMetrics().Snapshot()
+	assert.Equal(t, restarted, restarted.Sub(after), "a new count isn't compared with an old one")
+
+	var total MetricsSnapshot
+	total = total.Add(after).Add(before)
+	assert.Equal(t, 3, total.SourcesFetched)
+	assert.Equal(t, 3, total.FetchDuration.Count)
+	assert.True(t, total.Since.Equal(after.Since))
+	assert.Equal(t, 1.0, total.FetchDuration.Quantile(0.5))
+	assert.Equal(t, 30.0, total.FetchDuration.Quantile(1))
+
+	metrics.recordFetchFailure(90 * time.Second)
+	assert.True(t, math.IsInf(metrics.Snapshot().FetchDuration.Quantile(1), 1), "above the last bucket")
+}
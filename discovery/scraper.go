@@ -3,18 +3,25 @@ package discovery
 import (
 	"context"
 	"fmt"
+	"maps"
 	"net/http"
 	"net/url"
 	"os"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
 	"github.com/google/uuid"
 	"github.com/pevans/newsfed/newsfeed"
 	"github.com/pevans/newsfed/scraper"
 )
 
+// scraperUserAgent identifies newsfed when scraping websites per Spec 3
+// section 3.2.
+const scraperUserAgent = "newsfed/1.0 (RSS/Atom aggregator with web scraping)"
+
 // Re-export types for backward compatibility
 type (
 	ScraperConfig = scraper.ScraperConfig
@@ -55,9 +62,13 @@ func NewScraperSource(url, name string, config *scraper.ScraperConfig) *ScraperS
 type ScrapedArticle struct {
 	Title       string
 	Content     string
+	Description string // From the page's metadata; empty if it declares none
 	URL         string
 	Authors     []string
 	PublishedAt *time.Time
+	// PublishedRaw is the text PublishedAt was read from, kept in the news
+	// item's metadata
+	PublishedRaw string
 }
 
 // ScrapedArticleToNewsItem converts scraped article data to a NewsItem.
@@ -72,11 +83,14 @@ func ScrapedArticleToNewsItem(article *ScrapedArticle, publisherName string, sou
 		title = "(No title)"
 	}
 
-	// Summary: truncate content to reasonable length (500 chars per Spec 3
-	// section 3.4)
-	summary := article.Content
-	if len(summary) > 500 {
-		summary = summary[:500] + "..."
+	// Summary: the description the page declares, else content truncated
+	// to reasonable length (500 chars per Spec 3 section 3.4)
+	summary := article.Description
+	if summary == "" {
+		summary = article.Content
+		if runes := []rune(summary); len(runes) > 500 {
+			summary = string(runes[:500]) + "..."
+		}
 	}
 
 	// URL: from the article page URL
@@ -94,21 +108,22 @@ func ScrapedArticleToNewsItem(article *ScrapedArticle, publisherName string, sou
 		authors = []string{}
 	}
 
-	// Published_at: from extracted date or fallback to current time
+	// Discovered_at: set to current time when ingesting
+	discoveredAt := time.Now().UTC()
+
+	// Published_at: from extracted date, in UTC, or fallback to the
+	// discovery time
 	var publishedAt time.Time
 	if article.PublishedAt != nil {
-		publishedAt = *article.PublishedAt
+		publishedAt = article.PublishedAt.UTC()
 	} else {
-		publishedAt = time.Now().UTC()
+		publishedAt = discoveredAt
 	}
 
-	// Discovered_at: set to current time when ingesting
-	discoveredAt := time.Now().UTC()
-
 	// Pinned_at: set to nil (not yet pinned)
 	var pinnedAt *time.Time
 
-	return newsfeed.NewsItem{
+	item := newsfeed.NewsItem{
 		ID:           id,
 		Title:        title,
 		Summary:      summary,
@@ -120,6 +135,10 @@ func ScrapedArticleToNewsItem(article *ScrapedArticle, publisherName string, sou
 		PinnedAt:     pinnedAt,
 		SourceID:     &sourceID,
 	}
+	if article.PublishedAt != nil {
+		setPublishedRaw(&item, article.PublishedRaw)
+	}
+	return item
 }
 
 // ParseAuthors splits a single author string into multiple authors if it
@@ -160,81 +179,60 @@ func ParseAuthors(authorText string) []string {
 	return []string{strings.TrimSpace(authorText)}
 }
 
-// normalizeURL canonicalizes a URL for deduplication. It lowercases the
-// scheme and host, strips fragments, removes default ports (80/443), and
-// removes trailing slashes from the path.
-func normalizeURL(raw string) string {
-	u, err := url.Parse(raw)
-	if err != nil {
-		return raw
-	}
-
-	u.Scheme = strings.ToLower(u.Scheme)
-	u.Host = strings.ToLower(u.Host)
-	u.Fragment = ""
-	u.RawFragment = ""
-
-	// Strip default ports.
-	hostname := u.Hostname()
-	port := u.Port()
-	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
-		u.Host = hostname
-	}
-
-	// Remove trailing slash from path (but keep "/" for root).
-	if len(u.Path) > 1 {
-		u.Path = strings.TrimRight(u.Path, "/")
-	}
-
-	return u.String()
-}
-
-// BuildURLSet reads the feed once and returns a set of normalized URLs for
-// efficient deduplication. Callers should build the set once before a batch
-// of checks rather than calling URLExists per item.
-func BuildURLSet(feed *newsfeed.NewsFeed) (map[string]struct{}, error) {
-	result, err := feed.List()
-	if err != nil {
-		return nil, err
-	}
-
-	set := make(map[string]struct{}, len(result.Items))
-	for _, item := range result.Items {
-		set[normalizeURL(item.URL)] = struct{}{}
-	}
-	return set, nil
-}
-
 // URLExists checks if a NewsItem with the given URL already exists in the
 // feed. Implements Spec 3 section 4.2 deduplication strategy. URLs are
-// compared after normalization (case-insensitive scheme/host, fragments
-// stripped, default ports removed, trailing slashes removed).
+// compared by newsfeed.CanonicalURL, so differences in scheme, case,
+// fragments, default ports, trailing slashes, and tracking parameters are
+// ignored.
 //
-// For batch operations, prefer BuildURLSet to avoid repeated disk reads.
+// For batch operations, build a newsfeed.DedupIndex once instead.
 func URLExists(feed *newsfeed.NewsFeed, rawURL string) (bool, error) {
-	set, err := BuildURLSet(feed)
-	if err != nil {
-		return false, err
-	}
-	_, exists := set[normalizeURL(rawURL)]
-	return exists, nil
+	return feed.HasURL(rawURL)
 }
 
 // FetchHTML fetches HTML content from the given URL. Implements Spec 3
 // section 3.2. The context is used for cancellation; each request is also
 // subject to a 10-second per-request HTTP timeout per Spec 2 section 2.2.1.
 func FetchHTML(ctx context.Context, url string) (*goquery.Document, error) {
+	return FetchHTMLWithOptions(ctx, url, RequestOptions{})
+}
+
+// FetchHTMLWithOptions is FetchHTML with a per-source User-Agent and extra
+// request headers. With opts.Renderer set, the page is rendered in a
+// headless browser instead.
+func FetchHTMLWithOptions(ctx context.Context, url string, opts RequestOptions) (*goquery.Document, error) {
+	if opts.Renderer != nil {
+		if err := opts.budget.spendRequest(); err != nil {
+			return nil, err
+		}
+		req, err := newRequest(ctx, url)
+		if err != nil {
+			return nil, err
+		}
+		release, err := opts.acquireSlot(ctx, req.URL.Host)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+		return opts.Renderer.Render(ctx, url, opts)
+	}
+
 	// Create request with context
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	req, err := newRequest(ctx, url)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
 
-	// Set User-Agent header identifying newsfed per Spec 3 section 3.2
-	req.Header.Set("User-Agent", "newsfed/1.0 (RSS/Atom aggregator with web scraping)")
+	// Set User-Agent header identifying newsfed per Spec 3 section 3.2,
+	// unless the source overrides it
+	opts.apply(req, scraperUserAgent)
 
 	// Perform the request using the shared HTTP client (Spec 2 section 2.2.1)
-	resp, err := httpClient.Do(req)
+	client, err := opts.client()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch URL: %w", err)
+	}
+	resp, err := opts.do(client, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch URL: %w", err)
 	}
@@ -242,27 +240,61 @@ func FetchHTML(ctx context.Context, url string) (*goquery.Document, error) {
 
 	// Check for HTTP errors
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("HTTP error: %d %s", resp.StatusCode, resp.Status)
+		return nil, newHTTPStatusError(resp)
 	}
 
-	// Parse HTML with goquery
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	// Decode to UTF-8 and parse HTML with goquery, reading no more than the
+	// maximum response size
+	body, err := newResponseBody(resp, opts.maxResponseSize())
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+		return nil, fmt.Errorf("failed to read page: %w", err)
 	}
-
-	return doc, nil
+	decoded, err := htmlReader(body, resp.Header.Get("Content-Type"))
+	if err == nil {
+		var doc *goquery.Document
+		if doc, err = goquery.NewDocumentFromReader(decoded); err == nil {
+			return doc, nil
+		}
+		err = &ParseError{Format: "HTML", URL: url, Err: err}
+	}
+	if body.err != nil {
+		return nil, fmt.Errorf("failed to read page: %w", body.err)
+	}
+	return nil, err
 }
 
-// ExtractArticle extracts article data from HTML using the given selectors.
-// Implements Spec 3 section 3.4.
+// ExtractArticle extracts article data from the metadata the page declares,
+// falling back to the given selectors, or to readability heuristics when the
+// config calls for auto extraction. Implements Spec 3 section 3.4.
 func ExtractArticle(doc *goquery.Document, config scraper.ArticleConfig, articleURL string) (*ScrapedArticle, error) {
 	article := &ScrapedArticle{
 		URL: articleURL,
 	}
 
+	// JSON-LD, Open Graph, and meta tags are preferred over selectors
+	var meta articleMetadata
+	if !config.IgnoreMetadata {
+		meta = pageMetadata(doc)
+	}
+	article.Description = meta.Description
+
+	// Without selectors, fall back to readability heuristics for the title
+	// and content
+	titleText := meta.Title
+	var contentText string
+	if config.UsesAutoExtraction() {
+		if titleText == "" {
+			titleText = readableTitle(doc)
+		}
+		contentText = readableContent(doc)
+	} else {
+		if titleText == "" {
+			titleText = doc.Find(config.TitleSelector).First().Text()
+		}
+		contentText = doc.Find(config.ContentSelector).First().Text()
+	}
+
 	// Extract title (required)
-	titleText := doc.Find(config.TitleSelector).First().Text()
 	// Normalize whitespace: replace multiple spaces/newlines with single
 	// space
 	titleText = strings.Join(strings.Fields(titleText), " ")
@@ -272,14 +304,14 @@ func ExtractArticle(doc *goquery.Document, config scraper.ArticleConfig, article
 	article.Title = titleText
 
 	// Extract content (required)
-	contentText := strings.TrimSpace(doc.Find(config.ContentSelector).First().Text())
 	// Normalize whitespace: replace multiple spaces/newlines with single
 	// space
 	contentText = strings.Join(strings.Fields(contentText), " ")
 	article.Content = contentText
 
 	// Extract authors (optional)
-	if config.AuthorSelector != "" {
+	article.Authors = meta.Authors
+	if len(article.Authors) == 0 && config.AuthorSelector != "" {
 		authors := []string{}
 		doc.Find(config.AuthorSelector).Each(func(i int, s *goquery.Selection) {
 			authorText := strings.TrimSpace(s.Text())
@@ -292,18 +324,14 @@ func ExtractArticle(doc *goquery.Document, config scraper.ArticleConfig, article
 		article.Authors = authors
 	}
 
-	// Extract published date (optional)
-	if config.DateSelector != "" && config.DateFormat != "" {
-		dateText := strings.TrimSpace(doc.Find(config.DateSelector).First().Text())
-		if dateText != "" {
-			// Try to parse the date using the provided format
-			publishedAt, err := time.Parse(config.DateFormat, dateText)
-			if err == nil {
-				article.PublishedAt = &publishedAt
-			}
-			// If parsing fails, PublishedAt remains nil (fallback to current
-			// time in ScrapedArticleToNewsItem)
-		}
+	// Extract published date (optional), falling back to the page's first
+	// <time datetime> element when the selector doesn't give one
+	article.PublishedAt, article.PublishedRaw = meta.PublishedAt, meta.PublishedRaw
+	if article.PublishedAt == nil && config.DateSelector != "" {
+		article.PublishedAt, article.PublishedRaw = scrapedDate(doc.Find(config.DateSelector).First(), config, time.Now())
+	}
+	if article.PublishedAt == nil && (config.DateSelector != "" || config.UsesAutoExtraction()) {
+		article.PublishedAt, article.PublishedRaw = timeElementPublishedAt(doc)
 	}
 
 	return article, nil
@@ -312,8 +340,14 @@ func ExtractArticle(doc *goquery.Document, config scraper.ArticleConfig, article
 // ScrapeArticle is a convenience function that fetches and extracts an
 // article in one call. Combines FetchHTML and ExtractArticle.
 func ScrapeArticle(ctx context.Context, url string, config scraper.ArticleConfig) (*ScrapedArticle, error) {
+	return ScrapeArticleWithOptions(ctx, url, config, RequestOptions{})
+}
+
+// ScrapeArticleWithOptions is ScrapeArticle with a per-source User-Agent and
+// extra request headers.
+func ScrapeArticleWithOptions(ctx context.Context, url string, config scraper.ArticleConfig, opts RequestOptions) (*ScrapedArticle, error) {
 	// Fetch HTML
-	doc, err := FetchHTML(ctx, url)
+	doc, err := FetchHTMLWithOptions(ctx, url, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch HTML: %w", err)
 	}
@@ -327,6 +361,54 @@ func ScrapeArticle(ctx context.Context, url string, config scraper.ArticleConfig
 	return article, nil
 }
 
+// ValidateScraperConfig checks a website source's scraper config for
+// mistakes that would make every fetch fail or find nothing: a missing
+// config, an unknown discovery or extraction mode, list mode without an
+// article selector, and selectors that aren't valid CSS.
+func ValidateScraperConfig(config *ScraperConfig) error {
+	if config == nil {
+		return fmt.Errorf("scraper config is missing")
+	}
+
+	selectors := map[string]string{
+		"title_selector":   config.ArticleConfig.TitleSelector,
+		"content_selector": config.ArticleConfig.ContentSelector,
+		"author_selector":  config.ArticleConfig.AuthorSelector,
+		"date_selector":    config.ArticleConfig.DateSelector,
+	}
+	switch config.DiscoveryMode {
+	case "direct":
+	case "list":
+		if config.ListConfig == nil || config.ListConfig.ArticleSelector == "" {
+			return fmt.Errorf("list mode requires list_config.article_selector")
+		}
+		if config.ListConfig.MaxPages < 0 {
+			return fmt.Errorf("list_config.max_pages must not be negative")
+		}
+		selectors["article_selector"] = config.ListConfig.ArticleSelector
+		selectors["pagination_selector"] = config.ListConfig.PaginationSelector
+	default:
+		return fmt.Errorf("unsupported discovery mode: %q (use list or direct)", config.DiscoveryMode)
+	}
+
+	switch config.ArticleConfig.ExtractionMode {
+	case "", scraper.ExtractionSelectors, scraper.ExtractionAuto:
+	default:
+		return fmt.Errorf("unsupported extraction mode: %q (use selectors or auto)", config.ArticleConfig.ExtractionMode)
+	}
+
+	for _, field := range slices.Sorted(maps.Keys(selectors)) {
+		selector := selectors[field]
+		if selector == "" {
+			continue
+		}
+		if _, err := cascadia.Compile(selector); err != nil {
+			return fmt.Errorf("invalid %s %q: %v", field, selector, err)
+		}
+	}
+	return nil
+}
+
 // ValidateScrapedArticle validates a scraped article before storing.
 // Implements Spec 3 section 6.3.
 func ValidateScrapedArticle(article *ScrapedArticle, sourceURL string) error {
@@ -358,7 +440,7 @@ func ValidateScrapedArticle(article *ScrapedArticle, sourceURL string) error {
 	}
 
 	// Validate summary/content: warn if empty but don't reject
-	if article.Content == "" {
+	if article.Content == "" && article.Description == "" {
 		fmt.Fprintf(os.Stderr, "Warning: article has empty content (URL: %s)\n", article.URL)
 	}
 
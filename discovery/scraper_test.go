@@ -1,9 +1,13 @@
 package discovery
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
+	"unicode/utf8"
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/google/uuid"
@@ -94,6 +98,21 @@ func TestScrapedArticleToNewsItem_LongContent(t *testing.T) {
 	assert.True(t, strings.HasSuffix(newsItem.Summary, "..."), "should append ellipsis")
 }
 
+// TestScrapedArticleToNewsItem_LongMultibyteContent verifies summary
+// truncation counts characters and never splits one
+func TestScrapedArticleToNewsItem_LongMultibyteContent(t *testing.T) {
+	article := &ScrapedArticle{
+		Title:   "Test",
+		Content: strings.Repeat("日本語", 200),
+		URL:     "http://example.com",
+	}
+
+	newsItem := ScrapedArticleToNewsItem(article, "Site", uuid.New())
+
+	assert.True(t, utf8.ValidString(newsItem.Summary))
+	assert.Equal(t, 503, utf8.RuneCountInString(newsItem.Summary))
+}
+
 // TestScrapedArticleToNewsItem_ShortContent verifies no truncation
 func TestScrapedArticleToNewsItem_ShortContent(t *testing.T) {
 	shortContent := "Short content"
@@ -208,34 +227,6 @@ func TestParseAuthors_PreferCommaOverAnd(t *testing.T) {
 	assert.Equal(t, "Jane and Bob", authors[1], "should not split by 'and' when comma present")
 }
 
-// TestURLExists_NotFound verifies URL doesn't exist
-func TestNormalizeURL(t *testing.T) {
-	tests := []struct {
-		name     string
-		input    string
-		expected string
-	}{
-		{"strips fragment", "http://example.com/post#comments", "http://example.com/post"},
-		{"strips changing fragment", "http://example.com/post#section-2", "http://example.com/post"},
-		{"lowercases scheme", "HTTP://example.com/post", "http://example.com/post"},
-		{"lowercases host", "http://Example.COM/Post", "http://example.com/Post"},
-		{"removes default http port", "http://example.com:80/post", "http://example.com/post"},
-		{"removes default https port", "https://example.com:443/post", "https://example.com/post"},
-		{"keeps non-default port", "http://example.com:8080/post", "http://example.com:8080/post"},
-		{"removes trailing slash", "http://example.com/post/", "http://example.com/post"},
-		{"keeps root path", "http://example.com/", "http://example.com/"},
-		{"preserves query params", "http://example.com/post?a=1&b=2", "http://example.com/post?a=1&b=2"},
-		{"combined normalization", "HTTPS://Example.COM:443/Blog/Post/#anchor?", "https://example.com/Blog/Post"},
-		{"unparseable returns raw", "://broken", "://broken"},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			assert.Equal(t, tt.expected, normalizeURL(tt.input))
-		})
-	}
-}
-
 func TestURLExists(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -247,6 +238,9 @@ func TestURLExists(t *testing.T) {
 		{"different fragment", "http://example.com/article#section-1", "http://example.com/article#section-2", true},
 		{"trailing slash difference", "http://example.com/article/", "http://example.com/article", true},
 		{"scheme/host case difference", "HTTP://Example.COM/article", "http://example.com/article", true},
+		{"http vs https", "http://example.com/article", "https://example.com/article", true},
+		{"tracking parameters", "http://example.com/article", "http://example.com/article?utm_source=rss&utm_medium=feed", true},
+		{"different query", "http://example.com/article?id=1", "http://example.com/article?id=2", false},
 		{"different path", "http://example.com/article", "http://example.com/other", false},
 		{"not found in empty feed", "", "http://example.com/anything", false},
 	}
@@ -477,6 +471,57 @@ func TestExtractArticle_MissingElements(t *testing.T) {
 	assert.Empty(t, article.Content, "should have empty content if selector doesn't match")
 }
 
+// TestValidateScraperConfig verifies that usable configs pass and that
+// each kind of mistake is reported
+func TestValidateScraperConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *ScraperConfig
+		wantErr string
+	}{
+		{
+			name:   "direct with selectors",
+			config: &ScraperConfig{DiscoveryMode: "direct", ArticleConfig: ArticleConfig{TitleSelector: "h1", ContentSelector: "article > p"}},
+		},
+		{
+			name:   "list with auto extraction",
+			config: &ScraperConfig{DiscoveryMode: "list", ListConfig: NewListConfig("a.story"), ArticleConfig: ArticleConfig{ExtractionMode: "auto"}},
+		},
+		{name: "missing", wantErr: "scraper config is missing"},
+		{
+			name:    "unknown mode",
+			config:  &ScraperConfig{DiscoveryMode: "crawl"},
+			wantErr: "unsupported discovery mode",
+		},
+		{
+			name:    "list without selector",
+			config:  &ScraperConfig{DiscoveryMode: "list"},
+			wantErr: "requires list_config.article_selector",
+		},
+		{
+			name:    "unknown extraction mode",
+			config:  &ScraperConfig{DiscoveryMode: "direct", ArticleConfig: ArticleConfig{ExtractionMode: "magic"}},
+			wantErr: "unsupported extraction mode",
+		},
+		{
+			name:    "invalid selector",
+			config:  &ScraperConfig{DiscoveryMode: "list", ListConfig: NewListConfig("a[href"), ArticleConfig: ArticleConfig{TitleSelector: "h1"}},
+			wantErr: "invalid article_selector",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateScraperConfig(tt.config)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
 // TestValidateScrapedArticle_Valid verifies valid article passes
 func TestValidateScrapedArticle_Valid(t *testing.T) {
 	publishedAt := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
@@ -662,3 +707,42 @@ func TestValidateScrapedArticle_AcceptsSameDomain(t *testing.T) {
 		assert.NoError(t, err, "should accept URL from same domain: %s", articleURL)
 	}
 }
+
+// TestFetchHTMLWithOptions_SendsHeaders verifies that scraping requests carry
+// the source's User-Agent and headers, and the newsfed User-Agent otherwise
+func TestFetchHTMLWithOptions_SendsHeaders(t *testing.T) {
+	var userAgent, apiKey string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+		apiKey = r.Header.Get("X-Api-Key")
+		_, _ = w.Write([]byte("<html><body><h1>Hi</h1></body></html>"))
+	}))
+	defer server.Close()
+
+	opts := RequestOptions{UserAgent: "Browser/1.0", Headers: map[string]string{"X-Api-Key": "k"}}
+	_, err := FetchHTMLWithOptions(context.Background(), server.URL, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "Browser/1.0", userAgent)
+	assert.Equal(t, "k", apiKey)
+
+	_, err = FetchHTML(context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, scraperUserAgent, userAgent)
+}
+
+// TestFetchHTML_ContextCancel verifies that cancelling the context stops
+// FetchHTML and ScrapeArticle while the server is still answering
+func TestFetchHTML_ContextCancel(t *testing.T) {
+	server := newStalledServer(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := FetchHTML(ctx, server.URL)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	_, err = ScrapeArticle(ctx, server.URL, ArticleConfig{TitleSelector: "h1", ContentSelector: "p"})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
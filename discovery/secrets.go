@@ -0,0 +1,43 @@
+package discovery
+
+import (
+	"fmt"
+	"maps"
+
+	"github.com/pevans/newsfed/secrets"
+)
+
+// SetSecrets sets the store that references to secrets in source configs,
+// such as a header value of "secret:api-token", are resolved from. Without
+// one, sources whose configs refer to secrets, or that have a session,
+// fail to fetch.
+func (ds *DiscoveryService) SetSecrets(store *secrets.Store) {
+	ds.secrets.Store(store)
+}
+
+// resolveSecret returns the value of the secret value refers to, or value
+// itself if it isn't a reference.
+func (ds *DiscoveryService) resolveSecret(value string) (string, error) {
+	return ds.secrets.Load().Resolve(value)
+}
+
+// resolveValues returns values, such as a source's headers, with the ones
+// that refer to secrets replaced by the secrets' values. It returns values
+// itself if none do. kind names what the values are in errors.
+func (ds *DiscoveryService) resolveValues(kind string, values map[string]string) (map[string]string, error) {
+	resolved, cloned := values, false
+	for name, value := range values {
+		if _, ok := secrets.Ref(value); !ok {
+			continue
+		}
+		secret, err := ds.resolveSecret(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", kind, name, err)
+		}
+		if !cloned {
+			resolved, cloned = maps.Clone(values), true
+		}
+		resolved[name] = secret
+	}
+	return resolved, nil
+}
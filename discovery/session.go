@@ -0,0 +1,164 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/pevans/newsfed/sources"
+)
+
+// ErrLoginFailed is wrapped by errors for a login form that couldn't be
+// found or whose submission the site didn't accept.
+var ErrLoginFailed = errors.New("login failed")
+
+// sessionJar returns a cookie jar holding the source's session cookies,
+// after submitting its login form if it has one. A fresh login is made for
+// every fetch, so a session that expired on the site is renewed.
+func (ds *DiscoveryService) sessionJar(ctx context.Context, source sources.Source, domain string, opts RequestOptions) (http.CookieJar, error) {
+	session, err := ds.sourceStore.GetSession(source.SourceID, ds.secrets.Load())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load session: %w", err)
+	}
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return jar, nil
+	}
+
+	sourceURL, err := url.Parse(source.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid source URL: %w", err)
+	}
+	cookies := make([]*http.Cookie, 0, len(session.Cookies))
+	for _, c := range session.Cookies {
+		path := c.Path
+		if path == "" {
+			path = "/"
+		}
+		cookies = append(cookies, &http.Cookie{Name: c.Name, Value: c.Value, Domain: c.Domain, Path: path})
+	}
+	jar.SetCookies(sourceURL, cookies)
+
+	if session.Login != nil {
+		login := *session.Login
+		if login.Fields, err = ds.resolveValues("login field", login.Fields); err != nil {
+			return nil, err
+		}
+		if err := ds.rateLimiter.wait(ctx, domain); err != nil {
+			return nil, err
+		}
+		// The login is made over HTTP even for a source that renders its
+		// pages; the renderer is given the cookies it sets
+		opts.Renderer = nil
+		opts.Jar = jar
+		if err := submitLogin(ctx, login, opts); err != nil {
+			return nil, err
+		}
+	}
+	return jar, nil
+}
+
+// submitLogin fills in and submits the login form, keeping the cookies the
+// site sets in opts.Jar. The form is read from its page so that inputs the
+// site fills in, such as CSRF tokens, are submitted too.
+func submitLogin(ctx context.Context, login sources.LoginForm, opts RequestOptions) error {
+	doc, err := FetchHTMLWithOptions(ctx, login.URL, opts)
+	if err != nil {
+		return fmt.Errorf("%w: failed to fetch login page: %w", ErrLoginFailed, err)
+	}
+	form := findLoginForm(doc, login.FormSelector)
+	if form == nil {
+		return fmt.Errorf("%w: no login form found at %s", ErrLoginFailed, login.URL)
+	}
+
+	values := url.Values{}
+	form.Find("input[name], select[name], textarea[name]").Each(func(_ int, field *goquery.Selection) {
+		switch strings.ToLower(field.AttrOr("type", "")) {
+		case "submit", "button", "image", "reset", "file":
+			return
+		case "checkbox", "radio":
+			if _, checked := field.Attr("checked"); !checked {
+				return
+			}
+		}
+		values.Set(field.AttrOr("name", ""), field.AttrOr("value", ""))
+	})
+	for name, value := range login.Fields {
+		values.Set(name, value)
+	}
+
+	base, err := url.Parse(login.URL)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidURL, err)
+	}
+	action, err := base.Parse(form.AttrOr("action", ""))
+	if err != nil {
+		return fmt.Errorf("%w: invalid form action: %w", ErrLoginFailed, err)
+	}
+
+	var req *http.Request
+	if strings.EqualFold(form.AttrOr("method", "post"), "get") {
+		action.RawQuery = values.Encode()
+		req, err = newRequest(ctx, action.String())
+	} else {
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, action.String(), strings.NewReader(values.Encode()))
+		if req != nil {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrLoginFailed, err)
+	}
+	opts.apply(req, scraperUserAgent)
+	req.Header.Set("Referer", login.URL)
+
+	client, err := opts.client()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrLoginFailed, err)
+	}
+	// A login isn't retried, since submitting a form twice may not be safe
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrLoginFailed, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %w", ErrLoginFailed, newHTTPStatusError(resp))
+	}
+
+	// A site that rejects a login usually shows the form again
+	body, err := htmlReader(resp.Body, resp.Header.Get("Content-Type"))
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrLoginFailed, err)
+	}
+	result, err := goquery.NewDocumentFromReader(body)
+	if err == nil && findLoginForm(result, login.FormSelector) != nil {
+		return fmt.Errorf("%w: the site showed its login form again", ErrLoginFailed)
+	}
+	return nil
+}
+
+// findLoginForm returns the form matching selector in doc, or the first
+// form with a password input if selector is empty. It returns nil if there
+// is none.
+func findLoginForm(doc *goquery.Document, selector string) *goquery.Selection {
+	var form *goquery.Selection
+	if selector != "" {
+		form = doc.Find(selector).First()
+	} else {
+		form = doc.Find(`form:has(input[type="password"])`).First()
+	}
+	if form.Length() == 0 {
+		return nil
+	}
+	return form
+}
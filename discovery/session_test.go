@@ -0,0 +1,107 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pevans/newsfed/newsfeed"
+	"github.com/pevans/newsfed/secrets"
+	"github.com/pevans/newsfed/sources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDiscoveryService_fetchSource_Session verifies that a source with a
+// session logs in through its form, including the form's hidden inputs,
+// and is scraped with the cookies the login set alongside its own, and
+// that a rejected login fails the fetch
+func TestDiscoveryService_fetchSource_Session(t *testing.T) {
+	var articles atomic.Int32
+	loggedIn := func(r *http.Request) bool {
+		auth, err := r.Cookie("auth")
+		region, _ := r.Cookie("region")
+		return err == nil && auth.Value == "ok" && region != nil && region.Value == "uk"
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && r.FormValue("csrf") == "token" && r.FormValue("password") == "hunter2" {
+			http.SetCookie(w, &http.Cookie{Name: "auth", Value: "ok", Path: "/"})
+			http.Redirect(w, r, "/account", http.StatusSeeOther)
+			return
+		}
+		_, _ = fmt.Fprint(w, `<html><body><form method="post" action="/login">
+<input type="hidden" name="csrf" value="token">
+<input name="username"><input type="password" name="password">
+<input type="submit" name="go" value="Log in">
+</form></body></html>`)
+	})
+	mux.HandleFunc("/account", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `<html><body>Welcome back</body></html>`)
+	})
+	mux.HandleFunc("/list", func(w http.ResponseWriter, r *http.Request) {
+		if !loggedIn(r) {
+			http.Error(w, "log in first", http.StatusForbidden)
+			return
+		}
+		_, _ = fmt.Fprint(w, `<html><body><a class="post" href="/article/1">Post</a></body></html>`)
+	})
+	mux.HandleFunc("/article/", func(w http.ResponseWriter, r *http.Request) {
+		if !loggedIn(r) {
+			http.Error(w, "log in first", http.StatusForbidden)
+			return
+		}
+		articles.Add(1)
+		_, _ = fmt.Fprint(w, `<html><body><h1>Members only</h1><div class="content">Body</div></body></html>`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tempDir := t.TempDir()
+	sourceStore, err := sources.NewSourceStore(tempDir + "/metadata.db")
+	require.NoError(t, err)
+	defer func() { _ = sourceStore.Close() }()
+	newsFeed, err := newsfeed.NewNewsFeed(tempDir + "/.news")
+	require.NoError(t, err)
+	secretStore, err := secrets.Open(tempDir+"/metadata.db", "test master key")
+	require.NoError(t, err)
+	defer func() { _ = secretStore.Close() }()
+
+	config := DefaultDiscoveryConfig()
+	config.RateLimitInterval = 0
+	service := NewDiscoveryService(sourceStore, newsFeed, config)
+	service.SetSecrets(secretStore)
+
+	now := time.Now()
+	source, err := sourceStore.CreateSource("website", server.URL+"/list", "Members", &ScraperConfig{
+		DiscoveryMode: "list",
+		ListConfig:    &ListConfig{ArticleSelector: "a.post", MaxPages: 1},
+		ArticleConfig: ArticleConfig{TitleSelector: "h1", ContentSelector: ".content"},
+	}, &now)
+	require.NoError(t, err)
+
+	session := &sources.Session{
+		Cookies: []sources.SessionCookie{{Name: "region", Value: "uk"}},
+		Login: &sources.LoginForm{
+			URL:    server.URL + "/login",
+			Fields: map[string]string{"username": "reader", "password": "hunter2"},
+		},
+	}
+	require.NoError(t, sourceStore.SetSession(source.SourceID, session, secretStore))
+	source, err = sourceStore.GetSource(source.SourceID)
+	require.NoError(t, err)
+
+	_, err = service.fetchSource(context.Background(), *source, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), articles.Load())
+
+	session.Login.Fields["password"] = "wrong"
+	require.NoError(t, sourceStore.SetSession(source.SourceID, session, secretStore))
+	_, err = service.fetchSource(context.Background(), *source, nil)
+	assert.ErrorIs(t, err, ErrLoginFailed)
+}
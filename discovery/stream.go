@@ -0,0 +1,264 @@
+package discovery
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+	"github.com/mmcdole/gofeed/atom"
+	jsonfeed "github.com/mmcdole/gofeed/json"
+	"github.com/mmcdole/gofeed/rss"
+	"github.com/pevans/newsfed/sources"
+)
+
+// DefaultMaxResponseSize is the largest feed or page body read when neither
+// the request options nor the discovery config set one.
+const DefaultMaxResponseSize int64 = 20 << 20
+
+// ErrResponseTooLarge is wrapped by errors for responses whose body is
+// larger than the maximum response size. The fetch fails, but isn't
+// permanent: the feed may shrink once old items roll off.
+var ErrResponseTooLarge = errors.New("response too large")
+
+// maxResponseSize returns MaxResponseSize, or its default if unset.
+func (opts RequestOptions) maxResponseSize() int64 {
+	if opts.MaxResponseSize <= 0 {
+		return DefaultMaxResponseSize
+	}
+	return opts.MaxResponseSize
+}
+
+// responseBody reads a response body up to a maximum size, keeping the
+// first error reading it. Parsers given a body that fails partway report a
+// parse error; the kept error says what actually went wrong.
+type responseBody struct {
+	r    io.Reader
+	max  int64
+	read int64
+	err  error // First error reading, other than io.EOF
+}
+
+// newResponseBody returns resp's body limited to max bytes, or an error
+// wrapping ErrResponseTooLarge if its Content-Length is already past it.
+func newResponseBody(resp *http.Response, max int64) (*responseBody, error) {
+	if resp.ContentLength > max {
+		return nil, responseTooLarge(max)
+	}
+	return &responseBody{r: io.LimitReader(resp.Body, max+1), max: max}, nil
+}
+
+func (b *responseBody) Read(p []byte) (int, error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+	n, err := b.r.Read(p)
+	b.read += int64(n)
+	if b.read > b.max {
+		n -= int(b.read - b.max)
+		err = responseTooLarge(b.max)
+	}
+	if err != nil && err != io.EOF {
+		b.err = err
+	}
+	return n, err
+}
+
+// responseTooLarge returns the error for a body larger than max bytes.
+func responseTooLarge(max int64) error {
+	return fmt.Errorf("%w: more than %s", ErrResponseTooLarge, sources.FormatByteSize(max))
+}
+
+// feedFormat is the format of a feed, as told by its first element.
+type feedFormat int
+
+const (
+	formatUnknown feedFormat = iota
+	formatRSS                // RSS 2.0, or earlier with an <rss> root
+	formatRDF                // RSS 1.0 and 0.90, with an <rdf:RDF> root
+	formatAtom
+	formatJSON
+)
+
+// feedPeekSize is how much of a feed is read ahead to find its format.
+const feedPeekSize = 4096
+
+// xmlStartTag matches an element's start tag, but not a declaration,
+// processing instruction, or comment, capturing its name.
+var xmlStartTag = regexp.MustCompile(`<([A-Za-z_][-\w.:]*)`)
+
+// detectFeedFormat returns the format of a feed starting with head, and the
+// name of its root element as written, namespace prefix included.
+func detectFeedFormat(head []byte) (feedFormat, string) {
+	trimmed := bytes.TrimLeft(bytes.TrimPrefix(head, []byte("\xef\xbb\xbf")), " \t\r\n")
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		return formatJSON, ""
+	}
+	m := xmlStartTag.FindSubmatch(trimmed)
+	if m == nil {
+		return formatUnknown, ""
+	}
+	root := string(m[1])
+	_, local, found := strings.Cut(root, ":")
+	if !found {
+		local = root
+	}
+	switch strings.ToLower(local) {
+	case "rss":
+		return formatRSS, root
+	case "rdf":
+		return formatRDF, root
+	case "feed":
+		return formatAtom, root
+	}
+	return formatUnknown, root
+}
+
+// parseFeed parses an RSS, Atom, or JSON feed as it is read from r, without
+// holding the whole body in memory. When maxItems is positive, an RSS or
+// Atom feed stops being read after that many items if they are newest first,
+// as feeds almost always list them; truncated reports whether it was. A
+// feed whose first items are out of order is parsed in full, so that the
+// most recent items aren't missed.
+func parseFeed(r io.Reader, maxItems int) (feed *gofeed.Feed, truncated bool, err error) {
+	br := bufio.NewReaderSize(r, feedPeekSize)
+	head, _ := br.Peek(feedPeekSize) // Short feeds and read errors show up when parsing
+	format, root := detectFeedFormat(head)
+
+	switch format {
+	case formatJSON:
+		jf, err := (&jsonfeed.Parser{}).Parse(br)
+		if err != nil {
+			return nil, false, err
+		}
+		feed, err := (&gofeed.DefaultJSONTranslator{}).Translate(jf)
+		return feed, false, err
+	case formatUnknown:
+		// Leave the error for a body that isn't a feed to gofeed
+		feed, err := gofeed.NewParser().Parse(br)
+		return feed, false, err
+	}
+
+	if maxItems <= 0 {
+		feed, err := parseXMLFeed(format, br)
+		return feed, false, err
+	}
+
+	limited := newItemLimitReader(br, format, root, maxItems)
+	feed, err = parseXMLFeed(format, limited)
+	if !limited.cut {
+		return feed, false, err
+	}
+	if err == nil && newestFirst(feed.Items) {
+		return feed, true, nil
+	}
+
+	// Parse again from the start, without reading what was read twice
+	feed, err = parseXMLFeed(format, io.MultiReader(&limited.raw, br))
+	return feed, false, err
+}
+
+// parseXMLFeed parses an RSS or Atom feed read from r.
+func parseXMLFeed(format feedFormat, r io.Reader) (*gofeed.Feed, error) {
+	if format == formatAtom {
+		af, err := (&atom.Parser{}).Parse(r)
+		if err != nil {
+			return nil, err
+		}
+		return (&gofeed.DefaultAtomTranslator{}).Translate(af)
+	}
+	rf, err := (&rss.Parser{}).Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	return (&gofeed.DefaultRSSTranslator{}).Translate(rf)
+}
+
+// newestFirst reports whether items' dates, where they have them, never
+// increase.
+func newestFirst(items []*gofeed.Item) bool {
+	var last *time.Time
+	for _, item := range items {
+		date := item.UpdatedParsed
+		if date == nil {
+			date = item.PublishedParsed
+		}
+		if date == nil {
+			continue
+		}
+		if last != nil && date.After(*last) {
+			return false
+		}
+		last = date
+	}
+	return true
+}
+
+// itemLimitReader reads an RSS or Atom feed up to the end of its Nth item,
+// then closes the elements still open, so that parsing stops there. It
+// keeps what it has read, so that the feed can be parsed again in full.
+type itemLimitReader struct {
+	r         io.Reader
+	end       []byte // An item's end tag
+	closing   []byte // End tags for the elements that contain items
+	remaining int    // Items left to read
+	tail      []byte // The end of the last read, for an end tag split between reads
+	raw       bytes.Buffer
+	cut       bool
+}
+
+// newItemLimitReader returns a reader of r that stops after maxItems items
+// of a feed in format, whose root element is root.
+func newItemLimitReader(r io.Reader, format feedFormat, root string, maxItems int) *itemLimitReader {
+	l := &itemLimitReader{r: r, remaining: maxItems}
+	switch format {
+	case formatAtom:
+		l.end, l.closing = []byte("</entry>"), []byte("</"+root+">")
+	case formatRDF:
+		// RSS 1.0 items sit beside the channel, not inside it
+		l.end, l.closing = []byte("</item>"), []byte("</"+root+">")
+	default:
+		l.end, l.closing = []byte("</item>"), []byte("</channel></"+root+">")
+	}
+	return l
+}
+
+func (l *itemLimitReader) Read(p []byte) (int, error) {
+	if l.cut {
+		if len(l.closing) == 0 {
+			return 0, io.EOF
+		}
+		n := copy(p, l.closing)
+		l.closing = l.closing[n:]
+		return n, nil
+	}
+
+	n, err := l.r.Read(p)
+	l.raw.Write(p[:n])
+
+	// Look for end tags in what was just read, and the bytes before it
+	// that could start one
+	window := append(append([]byte(nil), l.tail...), p[:n]...)
+	offset := 0
+	for {
+		i := bytes.Index(window[offset:], l.end)
+		if i < 0 {
+			break
+		}
+		offset += i + len(l.end)
+		l.remaining--
+		if l.remaining == 0 {
+			l.cut = true
+			return offset - len(l.tail), nil
+		}
+	}
+	keep := min(len(window), len(l.end)-1)
+	l.tail = window[len(window)-keep:]
+	return n, err
+}
@@ -0,0 +1,132 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test helper: an RSS 2.0 feed of n items, dated an hour apart, newest first
+// unless oldestFirst is set, followed by trailer
+func rssWithItems(n int, oldestFirst bool, trailer string) string {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?><rss version="2.0"><channel><title>Big</title>`)
+	for i := range n {
+		hour := n - 1 - i
+		if oldestFirst {
+			hour = i
+		}
+		fmt.Fprintf(&b, "<item><title>Item %d</title><link>http://example.com/%d</link><pubDate>%s</pubDate></item>\n",
+			hour, hour, start.Add(time.Duration(hour)*time.Hour).Format(time.RFC1123Z))
+	}
+	b.WriteString(trailer)
+	b.WriteString("</channel></rss>")
+	return b.String()
+}
+
+// TestFetchFeedConditional_TooLarge verifies that a feed larger than the
+// maximum response size fails with ErrResponseTooLarge, whether or not it
+// gives its length up front, rather than as a parse error
+func TestFetchFeedConditional_TooLarge(t *testing.T) {
+	body := rssWithItems(200, false, "")
+	for _, chunked := range []bool{false, true} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if chunked {
+				w.(http.Flusher).Flush()
+			} else {
+				w.Header().Set("Content-Length", fmt.Sprint(len(body)))
+			}
+			_, _ = w.Write([]byte(body))
+		}))
+
+		_, err := FetchFeedConditional(context.Background(), server.URL, nil, nil, RequestOptions{MaxResponseSize: 4 << 10})
+		server.Close()
+		require.ErrorIs(t, err, ErrResponseTooLarge, "chunked=%v", chunked)
+		assert.ErrorContains(t, err, "more than 4KB")
+		var parseErr *ParseError
+		assert.False(t, errors.As(err, &parseErr), "chunked=%v", chunked)
+	}
+
+	server := serveBytes(t, "application/rss+xml", body)
+	result, err := FetchFeedConditional(context.Background(), server.URL, nil, nil, RequestOptions{})
+	require.NoError(t, err)
+	assert.Len(t, result.Feed.Items, 200, "the default limit allows it")
+}
+
+// TestFetchFeedConditional_MaxFeedItems verifies that a newest-first feed
+// stops being read after MaxFeedItems items, so that what follows them is
+// never parsed, in RSS 2.0, RSS 1.0, and Atom
+func TestFetchFeedConditional_MaxFeedItems(t *testing.T) {
+	const junk = "<item><title>Never read</title></item><<<not xml"
+	atomEntries := func(n int) string {
+		var b strings.Builder
+		for i := range n {
+			fmt.Fprintf(&b, `<entry><title>Entry %d</title><id>urn:%d</id><link href="http://example.com/%d"/><updated>2026-01-01T%02d:00:00Z</updated></entry>`, i, i, i, 23-i)
+		}
+		return b.String()
+	}
+	rdfItems := func(n int) string {
+		var b strings.Builder
+		for i := range n {
+			fmt.Fprintf(&b, `<item rdf:about="http://example.com/%d"><title>Item %d</title><link>http://example.com/%d</link></item>`, i, i, i)
+		}
+		return b.String()
+	}
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"rss", rssWithItems(30, false, junk)},
+		{"rdf", `<?xml version="1.0"?><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/"><channel rdf:about="http://example.com/"><title>RDF</title></channel>` + rdfItems(30) + junk + `</rdf:RDF>`},
+		{"atom", `<?xml version="1.0" encoding="utf-8"?><feed xmlns="http://www.w3.org/2005/Atom"><title>Atom</title>` + atomEntries(24) + strings.ReplaceAll(junk, "item", "entry") + `</feed>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := serveBytes(t, "application/xml", tt.body)
+			result, err := FetchFeedConditional(context.Background(), server.URL, nil, nil, RequestOptions{MaxFeedItems: 20})
+			require.NoError(t, err)
+			assert.True(t, result.Truncated)
+			assert.Len(t, result.Feed.Items, 20)
+
+			_, err = FetchFeedConditional(context.Background(), server.URL, nil, nil, RequestOptions{})
+			assert.Error(t, err, "the full feed doesn't parse")
+		})
+	}
+}
+
+// TestFetchFeedConditional_MaxFeedItemsOutOfOrder verifies that a feed
+// whose items aren't newest first is read in full despite MaxFeedItems, so
+// that its most recent items are found
+func TestFetchFeedConditional_MaxFeedItemsOutOfOrder(t *testing.T) {
+	server := serveBytes(t, "application/rss+xml", rssWithItems(30, true, ""))
+
+	result, err := FetchFeedConditional(context.Background(), server.URL, nil, nil, RequestOptions{MaxFeedItems: 20})
+	require.NoError(t, err)
+	assert.False(t, result.Truncated)
+	assert.Len(t, result.Feed.Items, 30)
+	assert.Equal(t, "Item 29", result.Feed.Items[29].Title)
+}
+
+// TestFetchHTMLWithOptions_TooLarge verifies that a page larger than the
+// maximum response size fails with ErrResponseTooLarge
+func TestFetchHTMLWithOptions_TooLarge(t *testing.T) {
+	server := serveBytes(t, "text/html", "<html><body><p>"+strings.Repeat("x", 8<<10)+"</p></body></html>")
+
+	_, err := FetchHTMLWithOptions(context.Background(), server.URL, RequestOptions{MaxResponseSize: 4 << 10})
+	assert.ErrorIs(t, err, ErrResponseTooLarge)
+
+	doc, err := FetchHTMLWithOptions(context.Background(), server.URL, RequestOptions{MaxResponseSize: 16 << 10})
+	require.NoError(t, err)
+	assert.Len(t, doc.Find("p").Text(), 8<<10)
+}
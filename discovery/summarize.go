@@ -0,0 +1,100 @@
+package discovery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/pevans/newsfed/newsfeed"
+)
+
+// Summarizer writes a short summary, two or three sentences long, of a news
+// item. Implementations may call a local model or an external API.
+type Summarizer interface {
+	Summarize(ctx context.Context, item newsfeed.NewsItem) (string, error)
+}
+
+// SummarizerFunc adapts an ordinary function to the Summarizer interface.
+type SummarizerFunc func(ctx context.Context, item newsfeed.NewsItem) (string, error)
+
+// Summarize calls f(ctx, item).
+func (f SummarizerFunc) Summarize(ctx context.Context, item newsfeed.NewsItem) (string, error) {
+	return f(ctx, item)
+}
+
+// SummaryEnricher is an Enricher that asks a Summarizer for a summary of
+// each item whose source provided none, and stores it in the item's
+// GeneratedSummary. Items that have a summary are left alone, so publisher
+// summaries are never replaced.
+type SummaryEnricher struct {
+	Summarizer Summarizer
+	// Longest time to wait for one summary; zero means thirty seconds
+	Timeout time.Duration
+}
+
+// NewSummaryEnricher returns a SummaryEnricher that uses summarizer.
+func NewSummaryEnricher(summarizer Summarizer) *SummaryEnricher {
+	return &SummaryEnricher{Summarizer: summarizer}
+}
+
+// Enrich implements Enricher.
+func (e *SummaryEnricher) Enrich(ctx context.Context, item *newsfeed.NewsItem) error {
+	if item.Summary != "" || item.GeneratedSummary != "" {
+		return nil
+	}
+
+	timeout := e.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	summary, err := e.Summarizer.Summarize(ctx, *item)
+	if err != nil {
+		return fmt.Errorf("failed to summarize: %w", err)
+	}
+	item.GeneratedSummary = strings.Join(strings.Fields(summary), " ")
+	return nil
+}
+
+// CommandSummarizer is a Summarizer that runs an external program, such as
+// a script that calls a local model or a hosted API. The program receives
+// the item as JSON on its standard input and prints the summary on its
+// standard output.
+type CommandSummarizer struct {
+	// Command is the program and its arguments; it is run directly, not
+	// through a shell
+	Command []string
+}
+
+// Summarize implements Summarizer.
+func (s *CommandSummarizer) Summarize(ctx context.Context, item newsfeed.NewsItem) (string, error) {
+	if len(s.Command) == 0 {
+		return "", errors.New("no summarizer command configured")
+	}
+
+	input, err := json.Marshal(item)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode item: %w", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, s.Command[0], s.Command[1:]...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s: %w: %s", s.Command[0], err, msg)
+		}
+		return "", fmt.Errorf("%s: %w", s.Command[0], err)
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
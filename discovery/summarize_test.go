@@ -0,0 +1,68 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pevans/newsfed/newsfeed"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSummaryEnricher verifies that only items without a summary are
+// summarized, into GeneratedSummary, and that summarizer failures and
+// timeouts are reported
+func TestSummaryEnricher(t *testing.T) {
+	calls := 0
+	enricher := NewSummaryEnricher(SummarizerFunc(func(ctx context.Context, item newsfeed.NewsItem) (string, error) {
+		calls++
+		return "  A generated\nsummary of " + item.Title + ".  ", nil
+	}))
+	ctx := context.Background()
+
+	withSummary := newsfeed.NewsItem{Title: "One", Summary: "From the publisher."}
+	require.NoError(t, enricher.Enrich(ctx, &withSummary))
+	assert.Empty(t, withSummary.GeneratedSummary)
+	assert.Equal(t, 0, calls)
+
+	item := newsfeed.NewsItem{Title: "Two"}
+	require.NoError(t, enricher.Enrich(ctx, &item))
+	assert.Equal(t, "A generated summary of Two.", item.GeneratedSummary)
+	assert.Empty(t, item.Summary)
+	assert.Equal(t, "A generated summary of Two.", item.DisplaySummary())
+
+	failing := NewSummaryEnricher(SummarizerFunc(func(ctx context.Context, item newsfeed.NewsItem) (string, error) {
+		return "", errors.New("model unavailable")
+	}))
+	assert.ErrorContains(t, failing.Enrich(ctx, &newsfeed.NewsItem{}), "model unavailable")
+
+	slow := &SummaryEnricher{
+		Summarizer: SummarizerFunc(func(ctx context.Context, item newsfeed.NewsItem) (string, error) {
+			<-ctx.Done()
+			return "", ctx.Err()
+		}),
+		Timeout: 10 * time.Millisecond,
+	}
+	assert.ErrorIs(t, slow.Enrich(ctx, &newsfeed.NewsItem{}), context.DeadlineExceeded)
+}
+
+// TestCommandSummarizer verifies that the command receives the item as JSON
+// and that its output, or its error message, is returned
+func TestCommandSummarizer(t *testing.T) {
+	ctx := context.Background()
+	item := newsfeed.NewsItem{Title: "Launch", URL: "http://example.com/launch"}
+
+	summarizer := &CommandSummarizer{Command: []string{"sh", "-c", `grep -o '"title":"[^"]*"'`}}
+	summary, err := summarizer.Summarize(ctx, item)
+	require.NoError(t, err)
+	assert.Equal(t, `"title":"Launch"`, summary)
+
+	failing := &CommandSummarizer{Command: []string{"sh", "-c", "echo quota exceeded >&2; exit 3"}}
+	_, err = failing.Summarize(ctx, item)
+	assert.ErrorContains(t, err, "quota exceeded")
+
+	_, err = (&CommandSummarizer{}).Summarize(ctx, item)
+	assert.Error(t, err)
+}
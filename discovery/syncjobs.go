@@ -0,0 +1,281 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pevans/newsfed/internal/httpjson"
+	"github.com/pevans/newsfed/sources"
+)
+
+// SyncJobStatus is the state of a background sync job.
+type SyncJobStatus string
+
+const (
+	SyncJobRunning   SyncJobStatus = "running"
+	SyncJobCompleted SyncJobStatus = "completed" // Finished, though sources may have failed
+	SyncJobFailed    SyncJobStatus = "failed"    // Stopped before fetching every source
+)
+
+// maxSyncJobs is how many jobs are remembered; the oldest finished jobs are
+// forgotten first.
+const maxSyncJobs = 50
+
+// ErrSyncJobRunning is returned when a sync job is started while another is
+// still running.
+var ErrSyncJobRunning = errors.New("a sync job is already running")
+
+// SyncJob is a manual sync running in the background, started with
+// StartSyncJob. Its counts are updated as each source finishes.
+type SyncJob struct {
+	ID              uuid.UUID       `json:"id"`
+	Status          SyncJobStatus   `json:"status"`
+	SourceID        *uuid.UUID      `json:"source_id,omitempty"` // Set when one source is synced
+	StartedAt       time.Time       `json:"started_at"`
+	FinishedAt      *time.Time      `json:"finished_at,omitempty"`
+	SourcesTotal    int             `json:"sources_total"`
+	SourcesSynced   int             `json:"sources_synced"`
+	SourcesFailed   int             `json:"sources_failed"`
+	ItemsDiscovered int             `json:"items_discovered"`
+	Errors          []SyncJobError  `json:"errors"`
+	BudgetsReached  []SyncJobError  `json:"budgets_reached,omitempty"` // Sources that stopped at their fetch budget
+	Error           string          `json:"error,omitempty"`           // Why the job failed
+	Fetching        []SyncJobSource `json:"fetching"`                  // Sources being fetched now
+}
+
+// SyncJobSource identifies a source in a sync job.
+type SyncJobSource struct {
+	SourceID uuid.UUID `json:"source_id"`
+	Name     string    `json:"name"`
+}
+
+// SyncJobError is a source that failed to sync in a sync job, or that
+// stopped at its fetch budget.
+type SyncJobError struct {
+	SourceID uuid.UUID `json:"source_id"`
+	Name     string    `json:"name"`
+	Error    string    `json:"error"`
+}
+
+// syncJobs tracks the service's sync jobs.
+type syncJobs struct {
+	mu    sync.Mutex
+	jobs  map[uuid.UUID]*SyncJob
+	order []uuid.UUID // Oldest first
+}
+
+// add records a new job, forgetting the oldest finished jobs beyond
+// maxSyncJobs. It returns ErrSyncJobRunning if a job is running.
+func (j *syncJobs) add(job *SyncJob) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.jobs == nil {
+		j.jobs = map[uuid.UUID]*SyncJob{}
+	}
+	for _, id := range j.order {
+		if j.jobs[id].Status == SyncJobRunning {
+			return ErrSyncJobRunning
+		}
+	}
+
+	j.jobs[job.ID] = job
+	j.order = append(j.order, job.ID)
+	for len(j.order) > maxSyncJobs {
+		delete(j.jobs, j.order[0])
+		j.order = j.order[1:]
+	}
+	return nil
+}
+
+// update applies fn to the job with the given ID while holding the lock.
+func (j *syncJobs) update(id uuid.UUID, fn func(job *SyncJob)) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if job, ok := j.jobs[id]; ok {
+		fn(job)
+	}
+}
+
+// get returns a copy of the job with the given ID.
+func (j *syncJobs) get(id uuid.UUID) (SyncJob, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	job, ok := j.jobs[id]
+	if !ok {
+		return SyncJob{}, false
+	}
+	return job.copy(), true
+}
+
+// copy returns a copy of job that shares no slices with it.
+func (job *SyncJob) copy() SyncJob {
+	c := *job
+	c.Errors = append([]SyncJobError{}, job.Errors...)
+	c.BudgetsReached = append([]SyncJobError(nil), job.BudgetsReached...)
+	c.Fetching = append([]SyncJobSource{}, job.Fetching...)
+	return c
+}
+
+// StartSyncJob starts a manual sync of the sources selected by opts in the
+// background and returns the job, whose progress SyncJob reports. The job
+// stops early if ctx is cancelled. Only one job runs at a time; starting
+// another returns ErrSyncJobRunning.
+func (ds *DiscoveryService) StartSyncJob(ctx context.Context, opts SyncOptions) (SyncJob, error) {
+	job := &SyncJob{
+		ID:        uuid.New(),
+		Status:    SyncJobRunning,
+		SourceID:  opts.SourceID,
+		StartedAt: time.Now().UTC(),
+	}
+	if err := ds.syncJobs.add(job); err != nil {
+		return SyncJob{}, err
+	}
+	started := job.copy()
+
+	ds.wg.Add(1)
+	go func() {
+		defer ds.wg.Done()
+
+		progressCh := make(chan SourceProgress)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for p := range progressCh {
+				ds.syncJobs.update(job.ID, func(job *SyncJob) { job.record(p) })
+			}
+		}()
+
+		result, err := ds.SyncSources(ctx, opts, progressCh)
+		<-done
+
+		ds.syncJobs.update(job.ID, func(job *SyncJob) {
+			now := time.Now().UTC()
+			job.FinishedAt = &now
+			job.Fetching = nil
+			if err != nil {
+				job.Status = SyncJobFailed
+				job.Error = err.Error()
+				return
+			}
+			job.Status = SyncJobCompleted
+			job.SourcesSynced = result.SourcesSynced
+			job.SourcesFailed = result.SourcesFailed
+			job.ItemsDiscovered = result.ItemsDiscovered
+		})
+	}()
+
+	return started, nil
+}
+
+// record updates job with a progress update from SyncSources.
+func (job *SyncJob) record(p SourceProgress) {
+	source := SyncJobSource{SourceID: p.Source.SourceID, Name: p.Source.Name}
+	switch p.Status {
+	case ProgressQueued:
+		job.SourcesTotal++
+	case ProgressFetching:
+		job.Fetching = append(job.Fetching, source)
+	case ProgressDone:
+		job.removeFetching(source.SourceID)
+		job.SourcesSynced++
+		job.ItemsDiscovered += p.NewItems
+		if p.Budget != nil {
+			job.BudgetsReached = append(job.BudgetsReached, SyncJobError{
+				SourceID: source.SourceID,
+				Name:     source.Name,
+				Error:    p.Budget.Error(),
+			})
+		}
+	case ProgressError:
+		job.removeFetching(source.SourceID)
+		job.SourcesFailed++
+		job.Errors = append(job.Errors, SyncJobError{
+			SourceID: source.SourceID,
+			Name:     source.Name,
+			Error:    p.Error.Error(),
+		})
+	}
+}
+
+// removeFetching removes a source from the job's sources being fetched.
+func (job *SyncJob) removeFetching(sourceID uuid.UUID) {
+	for i, s := range job.Fetching {
+		if s.SourceID == sourceID {
+			job.Fetching = append(job.Fetching[:i], job.Fetching[i+1:]...)
+			return
+		}
+	}
+}
+
+// SyncJob returns the sync job with the given ID. Only the most recent jobs
+// are remembered.
+func (ds *DiscoveryService) SyncJob(id uuid.UUID) (SyncJob, bool) {
+	return ds.syncJobs.get(id)
+}
+
+// syncJobRequest is the optional body of a request to start a sync job.
+type syncJobRequest struct {
+	SourceID *uuid.UUID `json:"source_id"`
+}
+
+// StartSyncHandler starts a sync job for POST /api/v1/meta/sync and
+// responds 202 Accepted with the job, or 409 Conflict with an error if a
+// job is already running. The body may name one source to sync as
+// {"source_id": "..."}; otherwise all enabled sources are synced. Jobs stop
+// early if ctx is cancelled.
+func (ds *DiscoveryService) StartSyncHandler(ctx context.Context) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req syncJobRequest
+		if err := json.NewDecoder(io.LimitReader(r.Body, 1<<16)).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			httpjson.Error(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+			return
+		}
+		if req.SourceID != nil {
+			if _, err := ds.sourceStore.GetSource(*req.SourceID); err != nil {
+				if errors.Is(err, sources.ErrSourceNotFound) {
+					httpjson.Error(w, http.StatusNotFound, err.Error())
+				} else {
+					httpjson.Error(w, http.StatusInternalServerError, err.Error())
+				}
+				return
+			}
+		}
+
+		job, err := ds.StartSyncJob(ctx, SyncOptions{SourceID: req.SourceID})
+		if errors.Is(err, ErrSyncJobRunning) {
+			httpjson.Error(w, http.StatusConflict, err.Error())
+			return
+		}
+		if err != nil {
+			httpjson.Error(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		w.Header().Set("Location", r.URL.Path+"/"+job.ID.String())
+		httpjson.Write(w, http.StatusAccepted, job)
+	})
+}
+
+// SyncJobHandler reports a sync job for GET /api/v1/meta/sync/{id}.
+func (ds *DiscoveryService) SyncJobHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			httpjson.Error(w, http.StatusBadRequest, "invalid job ID")
+			return
+		}
+		job, ok := ds.SyncJob(id)
+		if !ok {
+			httpjson.Error(w, http.StatusNotFound, "sync job not found")
+			return
+		}
+		httpjson.Write(w, http.StatusOK, job)
+	})
+}
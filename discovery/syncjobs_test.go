@@ -0,0 +1,153 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pevans/newsfed/newsfeed"
+	"github.com/pevans/newsfed/sources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test helper: create a discovery service with one working and one broken
+// source
+func newSyncJobTestService(t *testing.T) (*DiscoveryService, *sources.SourceStore) {
+	feed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `<?xml version="1.0"?>
+<rss version="2.0"><channel><title>Jobs</title>
+<item><title>One</title><link>http://feed.invalid/1</link></item>
+<item><title>Two</title><link>http://feed.invalid/2</link></item>
+</channel></rss>`)
+	}))
+	t.Cleanup(feed.Close)
+
+	tempDir := t.TempDir()
+	sourceStore, err := sources.NewSourceStore(tempDir + "/metadata.db")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = sourceStore.Close() })
+	newsFeed, err := newsfeed.NewNewsFeed(tempDir + "/.news")
+	require.NoError(t, err)
+
+	config := DefaultDiscoveryConfig()
+	config.RateLimitInterval = 0
+	config.RetryAttempts = 0
+	service := NewDiscoveryService(sourceStore, newsFeed, config)
+
+	now := time.Now()
+	_, err = sourceStore.CreateSource("rss", feed.URL, "Working", nil, &now)
+	require.NoError(t, err)
+	_, err = sourceStore.CreateSource("rss", "http://127.0.0.1:1/feed", "Broken", nil, &now)
+	require.NoError(t, err)
+
+	return service, sourceStore
+}
+
+// Test helper: wait for a sync job to finish
+func waitForSyncJob(t *testing.T, service *DiscoveryService, id uuid.UUID) SyncJob {
+	var job SyncJob
+	require.Eventually(t, func() bool {
+		var ok bool
+		job, ok = service.SyncJob(id)
+		require.True(t, ok)
+		return job.Status != SyncJobRunning
+	}, 10*time.Second, 10*time.Millisecond)
+	return job
+}
+
+// TestStartSyncJob verifies that a sync job runs in the background and
+// reports its results once finished
+func TestStartSyncJob(t *testing.T) {
+	service, _ := newSyncJobTestService(t)
+
+	job, err := service.StartSyncJob(context.Background(), SyncOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, SyncJobRunning, job.Status)
+
+	job = waitForSyncJob(t, service, job.ID)
+	assert.Equal(t, SyncJobCompleted, job.Status)
+	require.NotNil(t, job.FinishedAt)
+	assert.Equal(t, 2, job.SourcesTotal)
+	assert.Equal(t, 1, job.SourcesSynced)
+	assert.Equal(t, 1, job.SourcesFailed)
+	assert.Equal(t, 2, job.ItemsDiscovered)
+	assert.Empty(t, job.Fetching)
+	require.Len(t, job.Errors, 1)
+	assert.Equal(t, "Broken", job.Errors[0].Name)
+}
+
+// TestStartSyncJob_OneAtATime verifies that a second job can't start while
+// one is running
+func TestStartSyncJob_OneAtATime(t *testing.T) {
+	server := newStalledServer(t)
+	service, sourceStore := newSyncJobTestService(t)
+	now := time.Now()
+	_, err := sourceStore.CreateSource("rss", server.URL, "Stalled", nil, &now)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	job, err := service.StartSyncJob(ctx, SyncOptions{})
+	require.NoError(t, err)
+
+	_, err = service.StartSyncJob(ctx, SyncOptions{})
+	assert.ErrorIs(t, err, ErrSyncJobRunning)
+
+	// Cancelling stops the job, after which another may start
+	cancel()
+	job = waitForSyncJob(t, service, job.ID)
+	assert.Equal(t, SyncJobFailed, job.Status)
+	assert.NotEmpty(t, job.Error)
+
+	job, err = service.StartSyncJob(ctx, SyncOptions{})
+	require.NoError(t, err)
+	waitForSyncJob(t, service, job.ID)
+}
+
+// TestSyncJobHandlers verifies starting a job over HTTP and polling it
+func TestSyncJobHandlers(t *testing.T) {
+	service, _ := newSyncJobTestService(t)
+	mux := http.NewServeMux()
+	mux.Handle("POST /api/v1/meta/sync", service.StartSyncHandler(context.Background()))
+	mux.Handle("GET /api/v1/meta/sync/{id}", service.SyncJobHandler())
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("POST", "/api/v1/meta/sync", nil))
+	require.Equal(t, http.StatusAccepted, rec.Code)
+	var job SyncJob
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&job))
+	assert.Equal(t, "/api/v1/meta/sync/"+job.ID.String(), rec.Header().Get("Location"))
+	waitForSyncJob(t, service, job.ID)
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/meta/sync/"+job.ID.String(), nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&job))
+	assert.Equal(t, SyncJobCompleted, job.Status)
+	assert.Equal(t, 2, job.SourcesTotal)
+
+	// Unknown and malformed job IDs
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/meta/sync/"+uuid.NewString(), nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/meta/sync/nope", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// A job for a source that doesn't exist isn't started
+	body := `{"source_id": "` + uuid.NewString() + `"}`
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("POST", "/api/v1/meta/sync", strings.NewReader(body)))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("POST", "/api/v1/meta/sync", strings.NewReader("{")))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -0,0 +1,57 @@
+package discovery
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/pevans/newsfed/newsfeed"
+	"github.com/pevans/newsfed/sources"
+)
+
+// publishedRawKey is the metadata key holding an item's published date as
+// its source gave it, before it was read and normalized to UTC.
+const publishedRawKey = "published_raw"
+
+// zoneSuffix matches a date that ends with a time zone, as an offset after
+// the time ("10:00Z", "10:00:00 -0700") or an abbreviation ("GMT", "EST").
+var zoneSuffix = regexp.MustCompile(`(:\d{2}(\.\d+)?\s*([Zz]|[+-]\d{2}:?\d{2})|\b([A-Z]{3,5}|UT))\s*$`)
+
+// localizePublishedAt normalizes an item's published date to UTC. When loc
+// is given, a date whose raw text names no time zone is read as wall clock
+// time in loc rather than UTC; dates relative to now, such as "2 hours
+// ago", are left as they are.
+func localizePublishedAt(item *newsfeed.NewsItem, loc *time.Location) {
+	raw, _ := item.MetadataValue(publishedRawKey)
+	if loc != nil && raw != "" && !zoneSuffix.MatchString(raw) {
+		if _, relative := parseRelativeDate(raw, time.Now()); !relative {
+			t := item.PublishedAt
+			item.PublishedAt = time.Date(t.Year(), t.Month(), t.Day(),
+				t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+		}
+	}
+	item.PublishedAt = item.PublishedAt.UTC()
+}
+
+// setPublishedRaw records the text an item's published date was read from.
+func setPublishedRaw(item *newsfeed.NewsItem, raw string) {
+	if raw == "" {
+		return
+	}
+	if item.Metadata == nil {
+		item.Metadata = map[string]any{}
+	}
+	item.Metadata[publishedRawKey] = raw
+}
+
+// sourceLocation returns the time zone a source's zoneless dates are read
+// in, or nil if it has none.
+func sourceLocation(source sources.Source) *time.Location {
+	if source.TimeZone == nil {
+		return nil
+	}
+	loc, err := time.LoadLocation(*source.TimeZone)
+	if err != nil {
+		return nil
+	}
+	return loc
+}
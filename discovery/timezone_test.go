@@ -0,0 +1,65 @@
+package discovery
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mmcdole/gofeed"
+	"github.com/pevans/newsfed/newsfeed"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLocalizePublishedAt verifies that only dates whose raw text gives no
+// time zone are read in the source's time zone, and that every date ends up
+// in UTC
+func TestLocalizePublishedAt(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	wallClock := time.Date(2024, time.March, 3, 10, 15, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		raw  string
+		loc  *time.Location
+		want time.Time
+	}{
+		{"zoneless in source zone", "March 3, 2024 10:15 am", newYork, time.Date(2024, time.March, 3, 15, 15, 0, 0, time.UTC)},
+		{"zoneless without source zone", "March 3, 2024 10:15 am", nil, wallClock},
+		{"offset", "2024-03-03T10:15:00Z", newYork, wallClock},
+		{"numeric offset", "Sun, 03 Mar 2024 10:15:00 +0000", newYork, wallClock},
+		{"abbreviation", "Sun, 03 Mar 2024 10:15:00 GMT", newYork, wallClock},
+		{"relative", "2 hours ago", newYork, wallClock},
+		{"no raw date", "", newYork, wallClock},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := newsfeed.NewsItem{PublishedAt: wallClock}
+			setPublishedRaw(&item, tt.raw)
+			localizePublishedAt(&item, tt.loc)
+			assert.Equal(t, tt.want, item.PublishedAt)
+			assert.Equal(t, time.UTC, item.PublishedAt.Location())
+		})
+	}
+}
+
+// TestFeedItemToNewsItem_PublishedRaw verifies that a feed item's date is
+// stored in UTC with the date as the feed gave it kept in metadata
+func TestFeedItemToNewsItem_PublishedRaw(t *testing.T) {
+	published := time.Date(2024, time.March, 3, 10, 15, 0, 0, time.FixedZone("CET", 3600))
+	item := FeedItemToNewsItem(&gofeed.Item{
+		Title:           "Example",
+		Published:       "Sun, 03 Mar 2024 10:15:00 +0100",
+		PublishedParsed: &published,
+	}, "Example Feed", uuid.Nil)
+
+	assert.Equal(t, time.Date(2024, time.March, 3, 9, 15, 0, 0, time.UTC), item.PublishedAt)
+	raw, ok := item.MetadataValue(publishedRawKey)
+	require.True(t, ok)
+	assert.Equal(t, "Sun, 03 Mar 2024 10:15:00 +0100", raw)
+
+	item = FeedItemToNewsItem(&gofeed.Item{Title: "Undated"}, "Example Feed", uuid.Nil)
+	assert.Nil(t, item.Metadata)
+}
@@ -0,0 +1,66 @@
+package discovery
+
+import (
+	"context"
+
+	"github.com/pevans/newsfed/newsfeed"
+	"github.com/pevans/newsfed/sources"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer creates discovery's spans. Until the program installs a tracer
+// provider (see cmd/newsfed), spans are no-ops and cost next to nothing.
+var tracer = otel.Tracer("github.com/pevans/newsfed/discovery")
+
+// startFetchSpan starts the span covering one fetch of source.
+func startFetchSpan(ctx context.Context, source sources.Source) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "discovery.fetch_source", trace.WithAttributes(
+		attribute.String("source.id", source.SourceID.String()),
+		attribute.String("source.name", source.Name),
+		attribute.String("source.type", source.SourceType),
+		attribute.String("source.url", source.URL),
+	))
+}
+
+// endFetchSpan records the outcome of a fetch on its span and ends it.
+func endFetchSpan(span trace.Span, newItems int, dedup DedupReport, err error) {
+	span.SetAttributes(
+		attribute.Int("items.new", newItems),
+		attribute.Int("items.duplicate_guid", dedup.GUIDDuplicates),
+		attribute.Int("items.duplicate_url", dedup.URLDuplicates),
+		attribute.Int("items.duplicate_content", dedup.ContentDuplicates),
+		attribute.Int("items.updated", dedup.Updated),
+	)
+	endSpan(span, err)
+}
+
+// endSpan marks span as failed when err is non-nil, then ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// traceDuplicate adds an event to the current span recording that the item
+// at url was skipped as a duplicate, and why.
+func traceDuplicate(ctx context.Context, url string, kind newsfeed.DuplicateKind) {
+	trace.SpanFromContext(ctx).AddEvent("duplicate", trace.WithAttributes(
+		attribute.String("item.url", url),
+		attribute.String("duplicate.kind", string(kind)),
+	))
+}
+
+// scrapeArticle scrapes one article page inside its own span.
+func scrapeArticle(ctx context.Context, url string, config ArticleConfig, opts RequestOptions) (*ScrapedArticle, error) {
+	ctx, span := tracer.Start(ctx, "discovery.scrape_article", trace.WithAttributes(
+		attribute.String("article.url", url),
+	))
+	article, err := ScrapeArticleWithOptions(ctx, url, config, opts)
+	endSpan(span, err)
+	return article, err
+}
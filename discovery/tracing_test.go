@@ -0,0 +1,99 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pevans/newsfed/newsfeed"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// spanAttr returns the value of a span attribute, or an invalid value when it
+// isn't set.
+func spanAttr(span sdktrace.ReadOnlySpan, key string) attribute.Value {
+	for _, kv := range span.Attributes() {
+		if string(kv.Key) == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+// TestSyncSources_Tracing verifies that a sync produces a span for the sync,
+// a child span for the source fetch, a child span for each article scraped,
+// and an event for each duplicate skipped.
+func TestSyncSources_Tracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	defer func() { _ = provider.Shutdown(context.Background()) }()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/list", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `<html><body>
+<a class="post" href="/article/1">One</a>
+<a class="post" href="/article/2">Two</a>
+</body></html>`)
+	})
+	mux.HandleFunc("/article/", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, `<html><head><title>%s</title></head><body><h1>%s</h1><div class="content">Body</div></body></html>`, r.URL.Path, r.URL.Path)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	sourceStore := newTestSourceStore(t)
+	newsFeed := newsfeed.NewMemoryNewsFeed()
+
+	now := time.Now()
+	require.NoError(t, newsFeed.Add(newsfeed.NewsItem{
+		ID: uuid.New(), Title: "One", URL: server.URL + "/article/1", Authors: []string{}, PublishedAt: now, DiscoveredAt: now,
+	}))
+
+	config := DefaultDiscoveryConfig()
+	config.RateLimitInterval = 0
+	service := NewDiscoveryService(sourceStore, newsFeed, config)
+
+	source, err := sourceStore.CreateSource("website", server.URL+"/list", "Test Site", &ScraperConfig{
+		DiscoveryMode: "list",
+		ListConfig:    &ListConfig{ArticleSelector: "a.post", MaxPages: 1},
+		ArticleConfig: ArticleConfig{TitleSelector: "h1", ContentSelector: ".content"},
+	}, &now)
+	require.NoError(t, err)
+
+	_, err = service.SyncSources(context.Background(), SyncOptions{SourceID: &source.SourceID}, nil)
+	require.NoError(t, err)
+
+	byName := map[string][]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		byName[span.Name()] = append(byName[span.Name()], span)
+	}
+
+	require.Len(t, byName["discovery.sync_sources"], 1)
+	sync := byName["discovery.sync_sources"][0]
+	assert.Equal(t, int64(1), spanAttr(sync, "items.new").AsInt64())
+	assert.Equal(t, int64(1), spanAttr(sync, "items.duplicate_url").AsInt64())
+
+	require.Len(t, byName["discovery.fetch_source"], 1)
+	fetch := byName["discovery.fetch_source"][0]
+	assert.Equal(t, sync.SpanContext().SpanID(), fetch.Parent().SpanID())
+	assert.Equal(t, "Test Site", spanAttr(fetch, "source.name").AsString())
+	assert.Equal(t, int64(1), spanAttr(fetch, "items.new").AsInt64())
+	require.Len(t, fetch.Events(), 1)
+	assert.Equal(t, "duplicate", fetch.Events()[0].Name)
+
+	// The duplicate is skipped by URL before it is scraped
+	require.Len(t, byName["discovery.scrape_article"], 1)
+	scrape := byName["discovery.scrape_article"][0]
+	assert.Equal(t, fetch.SpanContext().SpanID(), scrape.Parent().SpanID())
+	assert.Equal(t, server.URL+"/article/2", spanAttr(scrape, "article.url").AsString())
+}
@@ -0,0 +1,169 @@
+package grpcapi
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pevans/newsfed/audit"
+	"github.com/pevans/newsfed/collections"
+	"github.com/pevans/newsfed/discovery"
+	"github.com/pevans/newsfed/grpcapi/newsfedpb"
+	"github.com/pevans/newsfed/newsfeed"
+	"github.com/pevans/newsfed/sources"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// toNewsItem converts item to its protobuf message.
+func toNewsItem(item *newsfeed.NewsItem) (*newsfedpb.NewsItem, error) {
+	msg := &newsfedpb.NewsItem{
+		Id:               item.ID.String(),
+		Title:            item.Title,
+		Summary:          item.Summary,
+		SummaryHtml:      item.SummaryHTML,
+		GeneratedSummary: item.GeneratedSummary,
+		Url:              item.URL,
+		Publisher:        item.Publisher,
+		Authors:          item.Authors,
+		PublishedAt:      timestamp(&item.PublishedAt),
+		DiscoveredAt:     timestamp(&item.DiscoveredAt),
+		PinnedAt:         timestamp(item.PinnedAt),
+		PinExpiresAt:     timestamp(item.PinExpiresAt),
+		ReadAt:           timestamp(item.ReadAt),
+		ArchivedAt:       timestamp(item.ArchivedAt),
+		StarredAt:        timestamp(item.StarredAt),
+		SnoozedUntil:     timestamp(item.SnoozedUntil),
+		Notes:            item.Notes,
+		SourceId:         uuidString(item.SourceID),
+		Guid:             item.GUID,
+		Category:         item.Category,
+		Language:         item.Language,
+		UpdatedAt:        timestamp(item.UpdatedAt),
+	}
+	if len(item.Metadata) > 0 {
+		metadata, err := structpb.NewStruct(item.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		msg.Metadata = metadata
+	}
+	return msg, nil
+}
+
+// toSource converts source to its protobuf message, leaving out settings
+// that may hold credentials.
+func toSource(source *sources.Source) *newsfedpb.Source {
+	return &newsfedpb.Source{
+		SourceId:        source.SourceID.String(),
+		SourceType:      source.SourceType,
+		Url:             source.URL,
+		Name:            source.Name,
+		EnabledAt:       timestamp(source.EnabledAt),
+		CreatedAt:       timestamp(&source.CreatedAt),
+		UpdatedAt:       timestamp(&source.UpdatedAt),
+		PollingInterval: source.PollingInterval,
+		LastFetchedAt:   timestamp(source.LastFetchedAt),
+		NextFetchAt:     timestamp(source.NextFetchAt),
+		FetchErrorCount: int32(source.FetchErrorCount),
+		LastError:       source.LastError,
+		Category:        source.Category,
+		Language:        source.Language,
+		UserAgent:       source.UserAgent,
+		ReprobeAt:       timestamp(source.ReprobeAt),
+	}
+}
+
+// toSyncJob converts job to its protobuf message.
+func toSyncJob(job discovery.SyncJob) *newsfedpb.SyncJob {
+	msg := &newsfedpb.SyncJob{
+		Id:              job.ID.String(),
+		Status:          string(job.Status),
+		SourceId:        uuidString(job.SourceID),
+		StartedAt:       timestamp(&job.StartedAt),
+		FinishedAt:      timestamp(job.FinishedAt),
+		SourcesTotal:    int32(job.SourcesTotal),
+		SourcesSynced:   int32(job.SourcesSynced),
+		SourcesFailed:   int32(job.SourcesFailed),
+		ItemsDiscovered: int32(job.ItemsDiscovered),
+		Error:           job.Error,
+	}
+	for _, e := range job.Errors {
+		msg.Errors = append(msg.Errors, &newsfedpb.SyncJobError{
+			SourceId: e.SourceID.String(),
+			Name:     e.Name,
+			Error:    e.Error,
+		})
+	}
+	for _, source := range job.Fetching {
+		msg.Fetching = append(msg.Fetching, &newsfedpb.SyncJobSource{
+			SourceId: source.SourceID.String(),
+			Name:     source.Name,
+		})
+	}
+	return msg
+}
+
+// toAuditEntry converts entry to its protobuf message.
+func toAuditEntry(entry *audit.Entry) (*newsfedpb.AuditEntry, error) {
+	msg := &newsfedpb.AuditEntry{
+		Id:     entry.ID,
+		At:     timestamp(&entry.At),
+		Actor:  entry.Actor,
+		Action: entry.Action,
+		Target: entry.Target,
+	}
+	if len(entry.Changes) > 0 {
+		msg.Changes = make(map[string]*newsfedpb.AuditChange, len(entry.Changes))
+	}
+	for field, change := range entry.Changes {
+		old, err := value(change.Old)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := value(change.New)
+		if err != nil {
+			return nil, err
+		}
+		msg.Changes[field] = &newsfedpb.AuditChange{Old: old, New: updated}
+	}
+	return msg, nil
+}
+
+// toCollection converts c to its protobuf message.
+func toCollection(c *collections.Collection) *newsfedpb.Collection {
+	msg := &newsfedpb.Collection{
+		Name:        c.Name,
+		Description: c.Description,
+		CreatedAt:   timestamp(&c.CreatedAt),
+		UpdatedAt:   timestamp(&c.UpdatedAt),
+	}
+	for _, id := range c.ItemIDs {
+		msg.ItemIds = append(msg.ItemIds, id.String())
+	}
+	return msg
+}
+
+// timestamp converts t to a protobuf timestamp, or nil if t is nil.
+func timestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+// uuidString returns id as a string, or nil if id is nil.
+func uuidString(id *uuid.UUID) *string {
+	if id == nil {
+		return nil
+	}
+	s := id.String()
+	return &s
+}
+
+// value converts v to a protobuf value, or nil if v is nil.
+func value(v any) (*structpb.Value, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return structpb.NewValue(v)
+}
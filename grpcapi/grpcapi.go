@@ -0,0 +1,501 @@
+// Package grpcapi serves newsfed's gRPC API, defined in newsfedpb. It
+// mirrors the REST API the daemon serves under /api/v1 and adds read access
+// to news items and sources, so that other services can use generated,
+// strongly typed clients.
+package grpcapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/pevans/newsfed/audit"
+	"github.com/pevans/newsfed/collections"
+	"github.com/pevans/newsfed/discovery"
+	"github.com/pevans/newsfed/grpcapi/newsfedpb"
+	"github.com/pevans/newsfed/newsfeed"
+	"github.com/pevans/newsfed/ratelimit"
+	"github.com/pevans/newsfed/sources"
+	"github.com/pevans/newsfed/users"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// defaultAuditLimit is how many entries ListAuditEntries returns when no
+// limit is given, as for GET /api/v1/meta/audit.
+const defaultAuditLimit = 100
+
+// Services are the stores and the discovery service that a Server serves.
+type Services struct {
+	Feed        *newsfeed.NewsFeed
+	Sources     *sources.SourceStore
+	Collections *collections.CollectionStore
+	Users       *users.UserStore
+	Audit       *audit.Log
+	Discovery   *discovery.DiscoveryService
+}
+
+// Server implements the Newsfed gRPC service.
+type Server struct {
+	newsfedpb.UnimplementedNewsfedServer
+
+	ctx      context.Context
+	services Services
+}
+
+// NewServer returns a Server for services. Sync jobs it starts stop early if
+// ctx is cancelled.
+func NewServer(ctx context.Context, services Services) *Server {
+	return &Server{ctx: ctx, services: services}
+}
+
+// Register registers s with g.
+func (s *Server) Register(g *grpc.Server) {
+	newsfedpb.RegisterNewsfedServer(g, s)
+}
+
+// Authenticate is a unary interceptor that identifies the user making each
+// call from an "authorization: Bearer <token>" metadata entry, as the REST
+// API does from the Authorization header. While there are no users, a call
+// without one is made as the default user; once there are, it fails with
+// UNAUTHENTICATED, as does one with a token that matches no user.
+func (s *Server) Authenticate(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	header := authorization(ctx)
+	if header == "" {
+		required, err := s.services.Users.Any()
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		if required {
+			return nil, status.Error(codes.Unauthenticated, "a bearer token is required")
+		}
+		return handler(ctx, req)
+	}
+
+	token, ok := strings.CutPrefix(header, "Bearer ")
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "authorization must be a bearer token")
+	}
+	user, err := s.services.Users.Authenticate(strings.TrimSpace(token))
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	return handler(users.NewContext(ctx, user), req)
+}
+
+// RateLimitAddress returns a unary interceptor that fails calls from
+// addresses that are over their limit with RESOURCE_EXHAUSTED, setting a
+// "retry-after" header to the seconds until they may try again. Addresses
+// share their buckets with the REST API's ratelimit.AddressMiddleware. It
+// must precede Authenticate in the interceptor chain, so that calls whose
+// token is refused still count.
+func RateLimitAddress(l *ratelimit.Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := admit(ctx, l, addressKey(ctx)); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// RateLimit returns a unary interceptor that fails calls from users that are
+// over their limit, as RateLimitAddress does for addresses. Users share
+// their buckets with the REST API's ratelimit.Limiter.Middleware, so each
+// user has one allowance across both. It must follow Authenticate in the
+// interceptor chain; calls made as the default user pass through.
+func RateLimit(l *ratelimit.Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if user := users.FromContext(ctx); user != nil {
+			if err := admit(ctx, l, "user:"+user.Name); err != nil {
+				return nil, err
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+// admit takes a token from key's bucket, returning RESOURCE_EXHAUSTED when
+// it is empty.
+func admit(ctx context.Context, l *ratelimit.Limiter, key string) error {
+	allowed, wait := l.Allow(key)
+	if !allowed {
+		seconds := max(int(math.Ceil(wait.Seconds())), 1)
+		_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", fmt.Sprint(seconds)))
+		return status.Error(codes.ResourceExhausted, "rate limit exceeded")
+	}
+	return nil
+}
+
+// authorization returns the call's authorization metadata, if any.
+func authorization(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get("authorization"); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// addressKey identifies the IP address a call was made from, as
+// ratelimit.AddressKey does for REST requests.
+func addressKey(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "ip:"
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		host = p.Addr.String()
+	}
+	return "ip:" + host
+}
+
+// feed returns the news feed as seen by the user making the call.
+func (s *Server) feed(ctx context.Context) *newsfeed.NewsFeed {
+	if user := users.FromContext(ctx); user != nil {
+		return s.services.Feed.ForUser(user.Name, s.services.Users)
+	}
+	return s.services.Feed
+}
+
+// ListItems returns one page of the items matching the request.
+func (s *Server) ListItems(ctx context.Context, req *newsfedpb.ListItemsRequest) (*newsfedpb.ListItemsResponse, error) {
+	opts := newsfeed.ListOptions{
+		Pinned:        req.Pinned,
+		Unread:        req.Unread,
+		Starred:       req.Starred,
+		Archived:      req.Archived,
+		Snoozed:       req.Snoozed,
+		Publisher:     req.Publisher,
+		Category:      req.Category,
+		Language:      req.Language,
+		Metadata:      req.Metadata,
+		IncludePinned: req.IncludePinned,
+		SortBy:        req.SortBy,
+		Limit:         int(req.Limit),
+		Offset:        int(req.Offset),
+		Cursor:        req.Cursor,
+	}
+	if req.DiscoveredSince != nil {
+		since := req.DiscoveredSince.AsTime()
+		opts.DiscoveredSince = &since
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	result, err := s.feed(ctx).Query(opts)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &newsfedpb.ListItemsResponse{
+		Total:      int32(result.Total),
+		NextCursor: result.NextCursor,
+	}
+	for i := range result.Items {
+		item, err := toNewsItem(&result.Items[i])
+		if err != nil {
+			return nil, toStatus(err)
+		}
+		resp.Items = append(resp.Items, item)
+	}
+	if len(result.Scores) > 0 {
+		resp.Scores = make(map[string]float64, len(result.Scores))
+		for id, score := range result.Scores {
+			resp.Scores[id.String()] = score
+		}
+	}
+	return resp, nil
+}
+
+// GetItem returns one item.
+func (s *Server) GetItem(ctx context.Context, req *newsfedpb.GetItemRequest) (*newsfedpb.NewsItem, error) {
+	id, err := parseID(req.Id, "item")
+	if err != nil {
+		return nil, err
+	}
+	item, err := s.feed(ctx).Get(id)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	if item == nil {
+		return nil, status.Error(codes.NotFound, newsfeed.ErrItemNotFound.Error())
+	}
+	msg, err := toNewsItem(item)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return msg, nil
+}
+
+// ListSources returns the sources matching the request.
+func (s *Server) ListSources(ctx context.Context, req *newsfedpb.ListSourcesRequest) (*newsfedpb.ListSourcesResponse, error) {
+	if req.Limit < 0 || req.Offset < 0 {
+		return nil, status.Error(codes.InvalidArgument, "limit and offset must not be negative")
+	}
+	list, err := s.services.Sources.ListSources(sources.SourceFilter{
+		Type:     req.Type,
+		Enabled:  req.Enabled,
+		Failing:  req.Failing,
+		Category: req.Category,
+		Limit:    int(req.Limit),
+		Offset:   int(req.Offset),
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &newsfedpb.ListSourcesResponse{}
+	for i := range list {
+		resp.Sources = append(resp.Sources, toSource(&list[i]))
+	}
+	return resp, nil
+}
+
+// GetSource returns one source.
+func (s *Server) GetSource(ctx context.Context, req *newsfedpb.GetSourceRequest) (*newsfedpb.Source, error) {
+	id, err := parseID(req.SourceId, "source")
+	if err != nil {
+		return nil, err
+	}
+	source, err := s.services.Sources.GetSource(id)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toSource(source), nil
+}
+
+// StartSync starts a sync job, of one source if the request names one and
+// of every enabled source otherwise.
+func (s *Server) StartSync(ctx context.Context, req *newsfedpb.StartSyncRequest) (*newsfedpb.SyncJob, error) {
+	var opts discovery.SyncOptions
+	if req.SourceId != nil {
+		id, err := parseID(*req.SourceId, "source")
+		if err != nil {
+			return nil, err
+		}
+		if _, err := s.services.Sources.GetSource(id); err != nil {
+			return nil, toStatus(err)
+		}
+		opts.SourceID = &id
+	}
+
+	job, err := s.services.Discovery.StartSyncJob(s.ctx, opts)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toSyncJob(job), nil
+}
+
+// GetSyncJob reports a sync job.
+func (s *Server) GetSyncJob(ctx context.Context, req *newsfedpb.GetSyncJobRequest) (*newsfedpb.SyncJob, error) {
+	id, err := parseID(req.Id, "job")
+	if err != nil {
+		return nil, err
+	}
+	job, ok := s.services.Discovery.SyncJob(id)
+	if !ok {
+		return nil, status.Error(codes.NotFound, "sync job not found")
+	}
+	return toSyncJob(job), nil
+}
+
+// ListAuditEntries lists the audit entries matching the request, newest
+// first.
+func (s *Server) ListAuditEntries(ctx context.Context, req *newsfedpb.ListAuditEntriesRequest) (*newsfedpb.ListAuditEntriesResponse, error) {
+	if req.Limit < 0 {
+		return nil, status.Error(codes.InvalidArgument, "limit must not be negative")
+	}
+	filter := audit.Filter{
+		Actor:  req.Actor,
+		Action: req.Action,
+		Target: req.Target,
+		Limit:  int(req.Limit),
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultAuditLimit
+	}
+	if req.Since != nil {
+		filter.Since = req.Since.AsTime()
+	}
+
+	entries, err := s.services.Audit.List(filter)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	resp := &newsfedpb.ListAuditEntriesResponse{}
+	for i := range entries {
+		entry, err := toAuditEntry(&entries[i])
+		if err != nil {
+			return nil, toStatus(err)
+		}
+		resp.Entries = append(resp.Entries, entry)
+	}
+	return resp, nil
+}
+
+// ListCollections lists every collection.
+func (s *Server) ListCollections(ctx context.Context, req *newsfedpb.ListCollectionsRequest) (*newsfedpb.ListCollectionsResponse, error) {
+	list, err := s.services.Collections.List()
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	resp := &newsfedpb.ListCollectionsResponse{}
+	for i := range list {
+		resp.Collections = append(resp.Collections, toCollection(&list[i]))
+	}
+	return resp, nil
+}
+
+// CreateCollection creates a collection.
+func (s *Server) CreateCollection(ctx context.Context, req *newsfedpb.CreateCollectionRequest) (*newsfedpb.Collection, error) {
+	c, err := s.services.Collections.Create(req.Name, req.Description)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toCollection(c), nil
+}
+
+// GetCollection reports a collection.
+func (s *Server) GetCollection(ctx context.Context, req *newsfedpb.GetCollectionRequest) (*newsfedpb.Collection, error) {
+	c, err := s.services.Collections.Get(req.Name)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toCollection(c), nil
+}
+
+// UpdateCollection renames a collection or changes its description.
+func (s *Server) UpdateCollection(ctx context.Context, req *newsfedpb.UpdateCollectionRequest) (*newsfedpb.Collection, error) {
+	c, err := s.services.Collections.Update(req.Name, collections.CollectionUpdate{
+		Name:        req.NewName,
+		Description: req.Description,
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toCollection(c), nil
+}
+
+// DeleteCollection deletes a collection.
+func (s *Server) DeleteCollection(ctx context.Context, req *newsfedpb.DeleteCollectionRequest) (*newsfedpb.DeleteCollectionResponse, error) {
+	if err := s.services.Collections.Delete(req.Name); err != nil {
+		return nil, toStatus(err)
+	}
+	return &newsfedpb.DeleteCollectionResponse{}, nil
+}
+
+// AddCollectionItem adds an item to a collection, or moves it there if it
+// is already in the collection and a position is given.
+func (s *Server) AddCollectionItem(ctx context.Context, req *newsfedpb.AddCollectionItemRequest) (*newsfedpb.Collection, error) {
+	id, err := parseID(req.ItemId, "item")
+	if err != nil {
+		return nil, err
+	}
+	if req.Position < 0 {
+		return nil, status.Error(codes.InvalidArgument, "position must be at least 1")
+	}
+	item, err := s.services.Feed.Get(id)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	if item == nil {
+		return nil, status.Error(codes.NotFound, newsfeed.ErrItemNotFound.Error())
+	}
+
+	if _, err := s.services.Collections.AddItem(req.Name, id); err != nil {
+		return nil, toStatus(err)
+	}
+	if req.Position > 0 {
+		if err := s.services.Collections.MoveItem(req.Name, id, int(req.Position)); err != nil {
+			return nil, toStatus(err)
+		}
+	}
+	return s.GetCollection(ctx, &newsfedpb.GetCollectionRequest{Name: req.Name})
+}
+
+// RemoveCollectionItem takes an item out of a collection.
+func (s *Server) RemoveCollectionItem(ctx context.Context, req *newsfedpb.RemoveCollectionItemRequest) (*newsfedpb.RemoveCollectionItemResponse, error) {
+	id, err := parseID(req.ItemId, "item")
+	if err != nil {
+		return nil, err
+	}
+	if err := s.services.Collections.RemoveItem(req.Name, id); err != nil {
+		return nil, toStatus(err)
+	}
+	return &newsfedpb.RemoveCollectionItemResponse{}, nil
+}
+
+// ExportCollection renders a collection's items, with the state of the user
+// making the call, in an export format (Markdown by default).
+func (s *Server) ExportCollection(ctx context.Context, req *newsfedpb.ExportCollectionRequest) (*newsfedpb.ExportCollectionResponse, error) {
+	format := req.Format
+	if format == "" {
+		format = newsfeed.ExportMarkdown
+	}
+	contentType, ok := newsfeed.ExportContentType(format)
+	if !ok {
+		return nil, status.Error(codes.InvalidArgument, "invalid format: "+format)
+	}
+
+	c, err := s.services.Collections.Get(req.Name)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	items, _, err := c.Items(s.feed(ctx))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	var buf bytes.Buffer
+	if err := newsfeed.Export(&buf, format, c.ExportInfo(), items); err != nil {
+		return nil, toStatus(err)
+	}
+	return &newsfedpb.ExportCollectionResponse{ContentType: contentType, Data: buf.Bytes()}, nil
+}
+
+// WhoAmI reports the user making the call; the name is unset for the
+// default user.
+func (s *Server) WhoAmI(ctx context.Context, req *newsfedpb.WhoAmIRequest) (*newsfedpb.WhoAmIResponse, error) {
+	user := users.FromContext(ctx)
+	if user == nil {
+		return &newsfedpb.WhoAmIResponse{}, nil
+	}
+	return &newsfedpb.WhoAmIResponse{Name: &user.Name, CreatedAt: timestamp(&user.CreatedAt)}, nil
+}
+
+// parseID parses the ID of what, failing with INVALID_ARGUMENT if it isn't
+// one.
+func parseID(s, what string) (uuid.UUID, error) {
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return uuid.Nil, status.Errorf(codes.InvalidArgument, "invalid %s ID", what)
+	}
+	return id, nil
+}
+
+// toStatus converts err to a status with the code that fits it, as the REST
+// API picks an HTTP status.
+func toStatus(err error) error {
+	code := codes.Internal
+	switch {
+	case errors.Is(err, newsfeed.ErrItemNotFound),
+		errors.Is(err, sources.ErrSourceNotFound),
+		errors.Is(err, collections.ErrCollectionNotFound),
+		errors.Is(err, collections.ErrItemNotInList):
+		code = codes.NotFound
+	case errors.Is(err, collections.ErrCollectionExists),
+		errors.Is(err, discovery.ErrSyncJobRunning):
+		code = codes.AlreadyExists
+	case errors.Is(err, collections.ErrInvalidName):
+		code = codes.InvalidArgument
+	}
+	return status.Error(code, err.Error())
+}
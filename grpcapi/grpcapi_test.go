@@ -0,0 +1,252 @@
+package grpcapi
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pevans/newsfed/audit"
+	"github.com/pevans/newsfed/collections"
+	"github.com/pevans/newsfed/discovery"
+	"github.com/pevans/newsfed/grpcapi/newsfedpb"
+	"github.com/pevans/newsfed/newsfeed"
+	"github.com/pevans/newsfed/ratelimit"
+	"github.com/pevans/newsfed/sources"
+	"github.com/pevans/newsfed/users"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// Test helper: serve the API over an in-memory connection and return a
+// client for it along with the services it serves
+func createTestClient(t *testing.T, opts ...grpc.ServerOption) (newsfedpb.NewsfedClient, Services) {
+	dir := t.TempDir()
+	metadataPath := filepath.Join(dir, "metadata.db")
+
+	feed, err := newsfeed.Open(filepath.Join(dir, "feed"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = feed.Close() })
+	sourceStore, err := sources.NewSourceStore(metadataPath)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = sourceStore.Close() })
+	collectionStore, err := collections.NewCollectionStore(metadataPath)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = collectionStore.Close() })
+	userStore, err := users.NewUserStore(metadataPath)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = userStore.Close() })
+	auditLog, err := audit.NewLog(metadataPath)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = auditLog.Close() })
+
+	services := Services{
+		Feed:        feed,
+		Sources:     sourceStore,
+		Collections: collectionStore,
+		Users:       userStore,
+		Audit:       auditLog,
+		Discovery:   discovery.NewDiscoveryService(sourceStore, feed, nil),
+	}
+	api := NewServer(context.Background(), services)
+	if len(opts) == 0 {
+		opts = []grpc.ServerOption{grpc.UnaryInterceptor(api.Authenticate)}
+	}
+	server := grpc.NewServer(opts...)
+	api.Register(server)
+
+	lis := bufconn.Listen(1 << 20)
+	go func() { _ = server.Serve(lis) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return newsfedpb.NewNewsfedClient(conn), services
+}
+
+// TestListItems_FiltersAndPages verifies that items are listed with the
+// request's filters and that a page's cursor continues the listing
+func TestListItems_FiltersAndPages(t *testing.T) {
+	client, services := createTestClient(t)
+	ctx := context.Background()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	for i, title := range []string{"first", "second", "third"} {
+		publisher := "Example"
+		require.NoError(t, services.Feed.Add(newsfeed.NewsItem{
+			ID:           uuid.New(),
+			Title:        title,
+			URL:          "https://example.com/" + title,
+			Publisher:    &publisher,
+			Authors:      []string{},
+			PublishedAt:  now.Add(time.Duration(i) * time.Hour),
+			DiscoveredAt: now,
+			Metadata:     map[string]any{"score": float64(i)},
+		}))
+	}
+
+	resp, err := client.ListItems(ctx, &newsfedpb.ListItemsRequest{Publisher: "example", Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), resp.Total)
+	require.Len(t, resp.Items, 2)
+	assert.Equal(t, "third", resp.Items[0].Title)
+	assert.Equal(t, "Example", resp.Items[0].GetPublisher())
+	assert.True(t, now.Add(2*time.Hour).Equal(resp.Items[0].PublishedAt.AsTime()))
+	assert.Equal(t, 2.0, resp.Items[0].Metadata.AsMap()["score"])
+	require.NotEmpty(t, resp.NextCursor)
+
+	resp, err = client.ListItems(ctx, &newsfedpb.ListItemsRequest{Limit: 2, Cursor: resp.NextCursor})
+	require.NoError(t, err)
+	require.Len(t, resp.Items, 1)
+	assert.Equal(t, "first", resp.Items[0].Title)
+
+	item, err := client.GetItem(ctx, &newsfedpb.GetItemRequest{Id: resp.Items[0].Id})
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/first", item.Url)
+
+	_, err = client.ListItems(ctx, &newsfedpb.ListItemsRequest{SortBy: "popularity"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = client.GetItem(ctx, &newsfedpb.GetItemRequest{Id: uuid.New().String()})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = client.GetItem(ctx, &newsfedpb.GetItemRequest{Id: "not-an-id"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+// TestAuthenticate_SelectsUser verifies that a bearer token selects its
+// user's item state, that no token selects the default user only while
+// there are no users, and that a bad token is refused
+func TestAuthenticate_SelectsUser(t *testing.T) {
+	client, services := createTestClient(t)
+	me, err := client.WhoAmI(context.Background(), &newsfedpb.WhoAmIRequest{})
+	require.NoError(t, err)
+	assert.Nil(t, me.Name)
+
+	_, token, err := services.Users.Create("alice")
+	require.NoError(t, err)
+
+	item := newsfeed.NewsItem{
+		ID:           uuid.New(),
+		Title:        "shared",
+		URL:          "https://example.com/shared",
+		Authors:      []string{},
+		PublishedAt:  time.Now(),
+		DiscoveredAt: time.Now(),
+	}
+	require.NoError(t, services.Feed.Add(item))
+	_, err = services.Feed.ForUser("alice", services.Users).Star(item.ID)
+	require.NoError(t, err)
+
+	alice := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+	me, err = client.WhoAmI(alice, &newsfedpb.WhoAmIRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "alice", me.GetName())
+	got, err := client.GetItem(alice, &newsfedpb.GetItemRequest{Id: item.ID.String()})
+	require.NoError(t, err)
+	assert.NotNil(t, got.StarredAt)
+
+	_, err = client.WhoAmI(context.Background(), &newsfedpb.WhoAmIRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err), "a token is required once there are users")
+
+	wrong := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer wrong")
+	_, err = client.WhoAmI(wrong, &newsfedpb.WhoAmIRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+// TestCollections_RoundTrip verifies that collections are created, filled,
+// exported, and deleted, with errors mapped to the codes that fit them
+func TestCollections_RoundTrip(t *testing.T) {
+	client, services := createTestClient(t)
+	ctx := context.Background()
+
+	item := newsfeed.NewsItem{
+		ID:           uuid.New(),
+		Title:        "worth keeping",
+		URL:          "https://example.com/keep",
+		Authors:      []string{},
+		PublishedAt:  time.Now(),
+		DiscoveredAt: time.Now(),
+	}
+	require.NoError(t, services.Feed.Add(item))
+
+	_, err := client.CreateCollection(ctx, &newsfedpb.CreateCollectionRequest{Name: "reading"})
+	require.NoError(t, err)
+	_, err = client.CreateCollection(ctx, &newsfedpb.CreateCollectionRequest{Name: "reading"})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	c, err := client.AddCollectionItem(ctx, &newsfedpb.AddCollectionItemRequest{Name: "reading", ItemId: item.ID.String()})
+	require.NoError(t, err)
+	assert.Equal(t, []string{item.ID.String()}, c.ItemIds)
+
+	export, err := client.ExportCollection(ctx, &newsfedpb.ExportCollectionRequest{Name: "reading"})
+	require.NoError(t, err)
+	assert.Contains(t, export.ContentType, "text/markdown")
+	assert.Contains(t, string(export.Data), "worth keeping")
+
+	_, err = client.DeleteCollection(ctx, &newsfedpb.DeleteCollectionRequest{Name: "reading"})
+	require.NoError(t, err)
+	_, err = client.GetCollection(ctx, &newsfedpb.GetCollectionRequest{Name: "reading"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+// TestSources_AuditedAndSynced verifies that sources are listed, that their
+// changes show in the audit log, and that a sync job can be started and
+// followed
+func TestSources_AuditedAndSynced(t *testing.T) {
+	client, services := createTestClient(t)
+	ctx := context.Background()
+
+	now := time.Now()
+	source, err := services.Sources.CreateSource("rss", "http://127.0.0.1:1/feed", "Local", nil, &now)
+	require.NoError(t, err)
+
+	list, err := client.ListSources(ctx, &newsfedpb.ListSourcesRequest{})
+	require.NoError(t, err)
+	require.Len(t, list.Sources, 1)
+	assert.Equal(t, "Local", list.Sources[0].Name)
+	assert.NotNil(t, list.Sources[0].EnabledAt)
+
+	entries, err := client.ListAuditEntries(ctx, &newsfedpb.ListAuditEntriesRequest{Target: source.SourceID.String()})
+	require.NoError(t, err)
+	require.Len(t, entries.Entries, 1)
+	assert.Equal(t, audit.SourceCreated, entries.Entries[0].Action)
+	assert.Equal(t, "Local", entries.Entries[0].Changes["name"].New.GetStringValue())
+
+	sourceID := source.SourceID.String()
+	job, err := client.StartSync(ctx, &newsfedpb.StartSyncRequest{SourceId: &sourceID})
+	require.NoError(t, err)
+	assert.Equal(t, sourceID, job.GetSourceId())
+	require.Eventually(t, func() bool {
+		job, err = client.GetSyncJob(ctx, &newsfedpb.GetSyncJobRequest{Id: job.Id})
+		return err == nil && job.Status != string(discovery.SyncJobRunning)
+	}, 10*time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(1), job.SourcesFailed)
+
+	_, err = client.GetSource(ctx, &newsfedpb.GetSourceRequest{SourceId: uuid.New().String()})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+// TestRateLimitAddress_RefusesExcess verifies that calls beyond an
+// address's burst fail with RESOURCE_EXHAUSTED
+func TestRateLimitAddress_RefusesExcess(t *testing.T) {
+	limiter := ratelimit.New(ratelimit.Config{Rate: 0.001, Burst: 1})
+	client, _ := createTestClient(t, grpc.UnaryInterceptor(RateLimitAddress(limiter)))
+
+	_, err := client.WhoAmI(context.Background(), &newsfedpb.WhoAmIRequest{})
+	require.NoError(t, err)
+	_, err = client.WhoAmI(context.Background(), &newsfedpb.WhoAmIRequest{})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
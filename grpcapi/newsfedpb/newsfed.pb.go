@@ -0,0 +1,2589 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: newsfed.proto
+
+package newsfedpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type NewsItem struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Id               string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Title            string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Summary          string                 `protobuf:"bytes,3,opt,name=summary,proto3" json:"summary,omitempty"`
+	SummaryHtml      string                 `protobuf:"bytes,4,opt,name=summary_html,json=summaryHtml,proto3" json:"summary_html,omitempty"`
+	GeneratedSummary string                 `protobuf:"bytes,5,opt,name=generated_summary,json=generatedSummary,proto3" json:"generated_summary,omitempty"`
+	Url              string                 `protobuf:"bytes,6,opt,name=url,proto3" json:"url,omitempty"`
+	Publisher        *string                `protobuf:"bytes,7,opt,name=publisher,proto3,oneof" json:"publisher,omitempty"`
+	Authors          []string               `protobuf:"bytes,8,rep,name=authors,proto3" json:"authors,omitempty"`
+	PublishedAt      *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=published_at,json=publishedAt,proto3" json:"published_at,omitempty"`
+	DiscoveredAt     *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=discovered_at,json=discoveredAt,proto3" json:"discovered_at,omitempty"`
+	PinnedAt         *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=pinned_at,json=pinnedAt,proto3" json:"pinned_at,omitempty"`
+	PinExpiresAt     *timestamppb.Timestamp `protobuf:"bytes,12,opt,name=pin_expires_at,json=pinExpiresAt,proto3" json:"pin_expires_at,omitempty"`
+	ReadAt           *timestamppb.Timestamp `protobuf:"bytes,13,opt,name=read_at,json=readAt,proto3" json:"read_at,omitempty"`
+	ArchivedAt       *timestamppb.Timestamp `protobuf:"bytes,14,opt,name=archived_at,json=archivedAt,proto3" json:"archived_at,omitempty"`
+	StarredAt        *timestamppb.Timestamp `protobuf:"bytes,15,opt,name=starred_at,json=starredAt,proto3" json:"starred_at,omitempty"`
+	SnoozedUntil     *timestamppb.Timestamp `protobuf:"bytes,16,opt,name=snoozed_until,json=snoozedUntil,proto3" json:"snoozed_until,omitempty"`
+	Notes            string                 `protobuf:"bytes,17,opt,name=notes,proto3" json:"notes,omitempty"`
+	SourceId         *string                `protobuf:"bytes,18,opt,name=source_id,json=sourceId,proto3,oneof" json:"source_id,omitempty"`
+	Guid             string                 `protobuf:"bytes,19,opt,name=guid,proto3" json:"guid,omitempty"`
+	Category         *string                `protobuf:"bytes,20,opt,name=category,proto3,oneof" json:"category,omitempty"`
+	Language         string                 `protobuf:"bytes,21,opt,name=language,proto3" json:"language,omitempty"`
+	UpdatedAt        *timestamppb.Timestamp `protobuf:"bytes,22,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	Metadata         *structpb.Struct       `protobuf:"bytes,23,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *NewsItem) Reset() {
+	*x = NewsItem{}
+	mi := &file_newsfed_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NewsItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NewsItem) ProtoMessage() {}
+
+func (x *NewsItem) ProtoReflect() protoreflect.Message {
+	mi := &file_newsfed_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NewsItem.ProtoReflect.Descriptor instead.
+func (*NewsItem) Descriptor() ([]byte, []int) {
+	return file_newsfed_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *NewsItem) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *NewsItem) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *NewsItem) GetSummary() string {
+	if x != nil {
+		return x.Summary
+	}
+	return ""
+}
+
+func (x *NewsItem) GetSummaryHtml() string {
+	if x != nil {
+		return x.SummaryHtml
+	}
+	return ""
+}
+
+func (x *NewsItem) GetGeneratedSummary() string {
+	if x != nil {
+		return x.GeneratedSummary
+	}
+	return ""
+}
+
+func (x *NewsItem) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *NewsItem) GetPublisher() string {
+	if x != nil && x.Publisher != nil {
+		return *x.Publisher
+	}
+	return ""
+}
+
+func (x *NewsItem) GetAuthors() []string {
+	if x != nil {
+		return x.Authors
+	}
+	return nil
+}
+
+func (x *NewsItem) GetPublishedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.PublishedAt
+	}
+	return nil
+}
+
+func (x *NewsItem) GetDiscoveredAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DiscoveredAt
+	}
+	return nil
+}
+
+func (x *NewsItem) GetPinnedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.PinnedAt
+	}
+	return nil
+}
+
+func (x *NewsItem) GetPinExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.PinExpiresAt
+	}
+	return nil
+}
+
+func (x *NewsItem) GetReadAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ReadAt
+	}
+	return nil
+}
+
+func (x *NewsItem) GetArchivedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ArchivedAt
+	}
+	return nil
+}
+
+func (x *NewsItem) GetStarredAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StarredAt
+	}
+	return nil
+}
+
+func (x *NewsItem) GetSnoozedUntil() *timestamppb.Timestamp {
+	if x != nil {
+		return x.SnoozedUntil
+	}
+	return nil
+}
+
+func (x *NewsItem) GetNotes() string {
+	if x != nil {
+		return x.Notes
+	}
+	return ""
+}
+
+func (x *NewsItem) GetSourceId() string {
+	if x != nil && x.SourceId != nil {
+		return *x.SourceId
+	}
+	return ""
+}
+
+func (x *NewsItem) GetGuid() string {
+	if x != nil {
+		return x.Guid
+	}
+	return ""
+}
+
+func (x *NewsItem) GetCategory() string {
+	if x != nil && x.Category != nil {
+		return *x.Category
+	}
+	return ""
+}
+
+func (x *NewsItem) GetLanguage() string {
+	if x != nil {
+		return x.Language
+	}
+	return ""
+}
+
+func (x *NewsItem) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *NewsItem) GetMetadata() *structpb.Struct {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+type ListItemsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// When set, keeps only pinned (true) or unpinned (false) items.
+	Pinned  *bool `protobuf:"varint,1,opt,name=pinned,proto3,oneof" json:"pinned,omitempty"`
+	Unread  bool  `protobuf:"varint,2,opt,name=unread,proto3" json:"unread,omitempty"`
+	Starred bool  `protobuf:"varint,3,opt,name=starred,proto3" json:"starred,omitempty"`
+	// Archived and snoozed items are otherwise left out.
+	Archived        bool                   `protobuf:"varint,4,opt,name=archived,proto3" json:"archived,omitempty"`
+	Snoozed         bool                   `protobuf:"varint,5,opt,name=snoozed,proto3" json:"snoozed,omitempty"`
+	Publisher       string                 `protobuf:"bytes,6,opt,name=publisher,proto3" json:"publisher,omitempty"`
+	Category        string                 `protobuf:"bytes,7,opt,name=category,proto3" json:"category,omitempty"`
+	Language        string                 `protobuf:"bytes,8,opt,name=language,proto3" json:"language,omitempty"`
+	Metadata        map[string]string      `protobuf:"bytes,9,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	DiscoveredSince *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=discovered_since,json=discoveredSince,proto3" json:"discovered_since,omitempty"`
+	IncludePinned   bool                   `protobuf:"varint,11,opt,name=include_pinned,json=includePinned,proto3" json:"include_pinned,omitempty"`
+	// One of "published" (default), "discovered", "pinned", or "score".
+	SortBy string `protobuf:"bytes,12,opt,name=sort_by,json=sortBy,proto3" json:"sort_by,omitempty"`
+	Limit  int32  `protobuf:"varint,13,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset int32  `protobuf:"varint,14,opt,name=offset,proto3" json:"offset,omitempty"`
+	// The next_cursor of the previous page.
+	Cursor        string `protobuf:"bytes,15,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListItemsRequest) Reset() {
+	*x = ListItemsRequest{}
+	mi := &file_newsfed_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListItemsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListItemsRequest) ProtoMessage() {}
+
+func (x *ListItemsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_newsfed_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListItemsRequest.ProtoReflect.Descriptor instead.
+func (*ListItemsRequest) Descriptor() ([]byte, []int) {
+	return file_newsfed_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ListItemsRequest) GetPinned() bool {
+	if x != nil && x.Pinned != nil {
+		return *x.Pinned
+	}
+	return false
+}
+
+func (x *ListItemsRequest) GetUnread() bool {
+	if x != nil {
+		return x.Unread
+	}
+	return false
+}
+
+func (x *ListItemsRequest) GetStarred() bool {
+	if x != nil {
+		return x.Starred
+	}
+	return false
+}
+
+func (x *ListItemsRequest) GetArchived() bool {
+	if x != nil {
+		return x.Archived
+	}
+	return false
+}
+
+func (x *ListItemsRequest) GetSnoozed() bool {
+	if x != nil {
+		return x.Snoozed
+	}
+	return false
+}
+
+func (x *ListItemsRequest) GetPublisher() string {
+	if x != nil {
+		return x.Publisher
+	}
+	return ""
+}
+
+func (x *ListItemsRequest) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *ListItemsRequest) GetLanguage() string {
+	if x != nil {
+		return x.Language
+	}
+	return ""
+}
+
+func (x *ListItemsRequest) GetMetadata() map[string]string {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *ListItemsRequest) GetDiscoveredSince() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DiscoveredSince
+	}
+	return nil
+}
+
+func (x *ListItemsRequest) GetIncludePinned() bool {
+	if x != nil {
+		return x.IncludePinned
+	}
+	return false
+}
+
+func (x *ListItemsRequest) GetSortBy() string {
+	if x != nil {
+		return x.SortBy
+	}
+	return ""
+}
+
+func (x *ListItemsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListItemsRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *ListItemsRequest) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+type ListItemsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Items []*NewsItem            `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	// The number of matching items before pagination.
+	Total int32 `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+	// Empty on the last page and when sorting by score.
+	NextCursor string `protobuf:"bytes,3,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"`
+	// Each item's score, by item ID, when sorting by score.
+	Scores        map[string]float64 `protobuf:"bytes,4,rep,name=scores,proto3" json:"scores,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListItemsResponse) Reset() {
+	*x = ListItemsResponse{}
+	mi := &file_newsfed_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListItemsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListItemsResponse) ProtoMessage() {}
+
+func (x *ListItemsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_newsfed_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListItemsResponse.ProtoReflect.Descriptor instead.
+func (*ListItemsResponse) Descriptor() ([]byte, []int) {
+	return file_newsfed_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListItemsResponse) GetItems() []*NewsItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+func (x *ListItemsResponse) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *ListItemsResponse) GetNextCursor() string {
+	if x != nil {
+		return x.NextCursor
+	}
+	return ""
+}
+
+func (x *ListItemsResponse) GetScores() map[string]float64 {
+	if x != nil {
+		return x.Scores
+	}
+	return nil
+}
+
+type GetItemRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetItemRequest) Reset() {
+	*x = GetItemRequest{}
+	mi := &file_newsfed_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetItemRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetItemRequest) ProtoMessage() {}
+
+func (x *GetItemRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_newsfed_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetItemRequest.ProtoReflect.Descriptor instead.
+func (*GetItemRequest) Descriptor() ([]byte, []int) {
+	return file_newsfed_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetItemRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+// Source leaves out settings that may hold credentials, such as HTTP
+// headers and proxy URLs.
+type Source struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	SourceId        string                 `protobuf:"bytes,1,opt,name=source_id,json=sourceId,proto3" json:"source_id,omitempty"`
+	SourceType      string                 `protobuf:"bytes,2,opt,name=source_type,json=sourceType,proto3" json:"source_type,omitempty"`
+	Url             string                 `protobuf:"bytes,3,opt,name=url,proto3" json:"url,omitempty"`
+	Name            string                 `protobuf:"bytes,4,opt,name=name,proto3" json:"name,omitempty"`
+	EnabledAt       *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=enabled_at,json=enabledAt,proto3" json:"enabled_at,omitempty"`
+	CreatedAt       *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt       *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	PollingInterval *string                `protobuf:"bytes,8,opt,name=polling_interval,json=pollingInterval,proto3,oneof" json:"polling_interval,omitempty"`
+	LastFetchedAt   *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=last_fetched_at,json=lastFetchedAt,proto3" json:"last_fetched_at,omitempty"`
+	NextFetchAt     *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=next_fetch_at,json=nextFetchAt,proto3" json:"next_fetch_at,omitempty"`
+	FetchErrorCount int32                  `protobuf:"varint,11,opt,name=fetch_error_count,json=fetchErrorCount,proto3" json:"fetch_error_count,omitempty"`
+	LastError       *string                `protobuf:"bytes,12,opt,name=last_error,json=lastError,proto3,oneof" json:"last_error,omitempty"`
+	Category        *string                `protobuf:"bytes,13,opt,name=category,proto3,oneof" json:"category,omitempty"`
+	Language        *string                `protobuf:"bytes,14,opt,name=language,proto3,oneof" json:"language,omitempty"`
+	UserAgent       *string                `protobuf:"bytes,15,opt,name=user_agent,json=userAgent,proto3,oneof" json:"user_agent,omitempty"`
+	ReprobeAt       *timestamppb.Timestamp `protobuf:"bytes,16,opt,name=reprobe_at,json=reprobeAt,proto3" json:"reprobe_at,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *Source) Reset() {
+	*x = Source{}
+	mi := &file_newsfed_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Source) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Source) ProtoMessage() {}
+
+func (x *Source) ProtoReflect() protoreflect.Message {
+	mi := &file_newsfed_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Source.ProtoReflect.Descriptor instead.
+func (*Source) Descriptor() ([]byte, []int) {
+	return file_newsfed_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Source) GetSourceId() string {
+	if x != nil {
+		return x.SourceId
+	}
+	return ""
+}
+
+func (x *Source) GetSourceType() string {
+	if x != nil {
+		return x.SourceType
+	}
+	return ""
+}
+
+func (x *Source) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *Source) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Source) GetEnabledAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.EnabledAt
+	}
+	return nil
+}
+
+func (x *Source) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Source) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *Source) GetPollingInterval() string {
+	if x != nil && x.PollingInterval != nil {
+		return *x.PollingInterval
+	}
+	return ""
+}
+
+func (x *Source) GetLastFetchedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastFetchedAt
+	}
+	return nil
+}
+
+func (x *Source) GetNextFetchAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.NextFetchAt
+	}
+	return nil
+}
+
+func (x *Source) GetFetchErrorCount() int32 {
+	if x != nil {
+		return x.FetchErrorCount
+	}
+	return 0
+}
+
+func (x *Source) GetLastError() string {
+	if x != nil && x.LastError != nil {
+		return *x.LastError
+	}
+	return ""
+}
+
+func (x *Source) GetCategory() string {
+	if x != nil && x.Category != nil {
+		return *x.Category
+	}
+	return ""
+}
+
+func (x *Source) GetLanguage() string {
+	if x != nil && x.Language != nil {
+		return *x.Language
+	}
+	return ""
+}
+
+func (x *Source) GetUserAgent() string {
+	if x != nil && x.UserAgent != nil {
+		return *x.UserAgent
+	}
+	return ""
+}
+
+func (x *Source) GetReprobeAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ReprobeAt
+	}
+	return nil
+}
+
+type ListSourcesRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Type    *string                `protobuf:"bytes,1,opt,name=type,proto3,oneof" json:"type,omitempty"`
+	Enabled *bool                  `protobuf:"varint,2,opt,name=enabled,proto3,oneof" json:"enabled,omitempty"`
+	// Keeps only sources whose last fetch failed.
+	Failing bool `protobuf:"varint,3,opt,name=failing,proto3" json:"failing,omitempty"`
+	// Compared ignoring case; "" keeps uncategorized sources.
+	Category      *string `protobuf:"bytes,4,opt,name=category,proto3,oneof" json:"category,omitempty"`
+	Limit         int32   `protobuf:"varint,5,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset        int32   `protobuf:"varint,6,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSourcesRequest) Reset() {
+	*x = ListSourcesRequest{}
+	mi := &file_newsfed_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSourcesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSourcesRequest) ProtoMessage() {}
+
+func (x *ListSourcesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_newsfed_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSourcesRequest.ProtoReflect.Descriptor instead.
+func (*ListSourcesRequest) Descriptor() ([]byte, []int) {
+	return file_newsfed_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListSourcesRequest) GetType() string {
+	if x != nil && x.Type != nil {
+		return *x.Type
+	}
+	return ""
+}
+
+func (x *ListSourcesRequest) GetEnabled() bool {
+	if x != nil && x.Enabled != nil {
+		return *x.Enabled
+	}
+	return false
+}
+
+func (x *ListSourcesRequest) GetFailing() bool {
+	if x != nil {
+		return x.Failing
+	}
+	return false
+}
+
+func (x *ListSourcesRequest) GetCategory() string {
+	if x != nil && x.Category != nil {
+		return *x.Category
+	}
+	return ""
+}
+
+func (x *ListSourcesRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListSourcesRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type ListSourcesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sources       []*Source              `protobuf:"bytes,1,rep,name=sources,proto3" json:"sources,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSourcesResponse) Reset() {
+	*x = ListSourcesResponse{}
+	mi := &file_newsfed_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSourcesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSourcesResponse) ProtoMessage() {}
+
+func (x *ListSourcesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_newsfed_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSourcesResponse.ProtoReflect.Descriptor instead.
+func (*ListSourcesResponse) Descriptor() ([]byte, []int) {
+	return file_newsfed_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListSourcesResponse) GetSources() []*Source {
+	if x != nil {
+		return x.Sources
+	}
+	return nil
+}
+
+type GetSourceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SourceId      string                 `protobuf:"bytes,1,opt,name=source_id,json=sourceId,proto3" json:"source_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSourceRequest) Reset() {
+	*x = GetSourceRequest{}
+	mi := &file_newsfed_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSourceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSourceRequest) ProtoMessage() {}
+
+func (x *GetSourceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_newsfed_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSourceRequest.ProtoReflect.Descriptor instead.
+func (*GetSourceRequest) Descriptor() ([]byte, []int) {
+	return file_newsfed_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetSourceRequest) GetSourceId() string {
+	if x != nil {
+		return x.SourceId
+	}
+	return ""
+}
+
+type StartSyncRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Syncs only this source, even if it is disabled; otherwise every enabled
+	// source is synced.
+	SourceId      *string `protobuf:"bytes,1,opt,name=source_id,json=sourceId,proto3,oneof" json:"source_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StartSyncRequest) Reset() {
+	*x = StartSyncRequest{}
+	mi := &file_newsfed_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartSyncRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartSyncRequest) ProtoMessage() {}
+
+func (x *StartSyncRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_newsfed_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartSyncRequest.ProtoReflect.Descriptor instead.
+func (*StartSyncRequest) Descriptor() ([]byte, []int) {
+	return file_newsfed_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *StartSyncRequest) GetSourceId() string {
+	if x != nil && x.SourceId != nil {
+		return *x.SourceId
+	}
+	return ""
+}
+
+type GetSyncJobRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSyncJobRequest) Reset() {
+	*x = GetSyncJobRequest{}
+	mi := &file_newsfed_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSyncJobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSyncJobRequest) ProtoMessage() {}
+
+func (x *GetSyncJobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_newsfed_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSyncJobRequest.ProtoReflect.Descriptor instead.
+func (*GetSyncJobRequest) Descriptor() ([]byte, []int) {
+	return file_newsfed_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *GetSyncJobRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type SyncJob struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// "running", "completed", or "failed".
+	Status          string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	SourceId        *string                `protobuf:"bytes,3,opt,name=source_id,json=sourceId,proto3,oneof" json:"source_id,omitempty"`
+	StartedAt       *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	FinishedAt      *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=finished_at,json=finishedAt,proto3" json:"finished_at,omitempty"`
+	SourcesTotal    int32                  `protobuf:"varint,6,opt,name=sources_total,json=sourcesTotal,proto3" json:"sources_total,omitempty"`
+	SourcesSynced   int32                  `protobuf:"varint,7,opt,name=sources_synced,json=sourcesSynced,proto3" json:"sources_synced,omitempty"`
+	SourcesFailed   int32                  `protobuf:"varint,8,opt,name=sources_failed,json=sourcesFailed,proto3" json:"sources_failed,omitempty"`
+	ItemsDiscovered int32                  `protobuf:"varint,9,opt,name=items_discovered,json=itemsDiscovered,proto3" json:"items_discovered,omitempty"`
+	Errors          []*SyncJobError        `protobuf:"bytes,10,rep,name=errors,proto3" json:"errors,omitempty"`
+	// Why the job failed.
+	Error string `protobuf:"bytes,11,opt,name=error,proto3" json:"error,omitempty"`
+	// Sources being fetched now.
+	Fetching      []*SyncJobSource `protobuf:"bytes,12,rep,name=fetching,proto3" json:"fetching,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SyncJob) Reset() {
+	*x = SyncJob{}
+	mi := &file_newsfed_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SyncJob) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SyncJob) ProtoMessage() {}
+
+func (x *SyncJob) ProtoReflect() protoreflect.Message {
+	mi := &file_newsfed_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SyncJob.ProtoReflect.Descriptor instead.
+func (*SyncJob) Descriptor() ([]byte, []int) {
+	return file_newsfed_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *SyncJob) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *SyncJob) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *SyncJob) GetSourceId() string {
+	if x != nil && x.SourceId != nil {
+		return *x.SourceId
+	}
+	return ""
+}
+
+func (x *SyncJob) GetStartedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartedAt
+	}
+	return nil
+}
+
+func (x *SyncJob) GetFinishedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.FinishedAt
+	}
+	return nil
+}
+
+func (x *SyncJob) GetSourcesTotal() int32 {
+	if x != nil {
+		return x.SourcesTotal
+	}
+	return 0
+}
+
+func (x *SyncJob) GetSourcesSynced() int32 {
+	if x != nil {
+		return x.SourcesSynced
+	}
+	return 0
+}
+
+func (x *SyncJob) GetSourcesFailed() int32 {
+	if x != nil {
+		return x.SourcesFailed
+	}
+	return 0
+}
+
+func (x *SyncJob) GetItemsDiscovered() int32 {
+	if x != nil {
+		return x.ItemsDiscovered
+	}
+	return 0
+}
+
+func (x *SyncJob) GetErrors() []*SyncJobError {
+	if x != nil {
+		return x.Errors
+	}
+	return nil
+}
+
+func (x *SyncJob) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *SyncJob) GetFetching() []*SyncJobSource {
+	if x != nil {
+		return x.Fetching
+	}
+	return nil
+}
+
+type SyncJobSource struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SourceId      string                 `protobuf:"bytes,1,opt,name=source_id,json=sourceId,proto3" json:"source_id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SyncJobSource) Reset() {
+	*x = SyncJobSource{}
+	mi := &file_newsfed_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SyncJobSource) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SyncJobSource) ProtoMessage() {}
+
+func (x *SyncJobSource) ProtoReflect() protoreflect.Message {
+	mi := &file_newsfed_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SyncJobSource.ProtoReflect.Descriptor instead.
+func (*SyncJobSource) Descriptor() ([]byte, []int) {
+	return file_newsfed_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *SyncJobSource) GetSourceId() string {
+	if x != nil {
+		return x.SourceId
+	}
+	return ""
+}
+
+func (x *SyncJobSource) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type SyncJobError struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SourceId      string                 `protobuf:"bytes,1,opt,name=source_id,json=sourceId,proto3" json:"source_id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SyncJobError) Reset() {
+	*x = SyncJobError{}
+	mi := &file_newsfed_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SyncJobError) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SyncJobError) ProtoMessage() {}
+
+func (x *SyncJobError) ProtoReflect() protoreflect.Message {
+	mi := &file_newsfed_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SyncJobError.ProtoReflect.Descriptor instead.
+func (*SyncJobError) Descriptor() ([]byte, []int) {
+	return file_newsfed_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *SyncJobError) GetSourceId() string {
+	if x != nil {
+		return x.SourceId
+	}
+	return ""
+}
+
+func (x *SyncJobError) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *SyncJobError) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type ListAuditEntriesRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Actor  string                 `protobuf:"bytes,1,opt,name=actor,proto3" json:"actor,omitempty"`
+	Action string                 `protobuf:"bytes,2,opt,name=action,proto3" json:"action,omitempty"`
+	Target string                 `protobuf:"bytes,3,opt,name=target,proto3" json:"target,omitempty"`
+	Since  *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=since,proto3" json:"since,omitempty"`
+	// Defaults to 100.
+	Limit         int32 `protobuf:"varint,5,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAuditEntriesRequest) Reset() {
+	*x = ListAuditEntriesRequest{}
+	mi := &file_newsfed_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAuditEntriesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAuditEntriesRequest) ProtoMessage() {}
+
+func (x *ListAuditEntriesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_newsfed_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAuditEntriesRequest.ProtoReflect.Descriptor instead.
+func (*ListAuditEntriesRequest) Descriptor() ([]byte, []int) {
+	return file_newsfed_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ListAuditEntriesRequest) GetActor() string {
+	if x != nil {
+		return x.Actor
+	}
+	return ""
+}
+
+func (x *ListAuditEntriesRequest) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+func (x *ListAuditEntriesRequest) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+func (x *ListAuditEntriesRequest) GetSince() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Since
+	}
+	return nil
+}
+
+func (x *ListAuditEntriesRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type ListAuditEntriesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Entries       []*AuditEntry          `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAuditEntriesResponse) Reset() {
+	*x = ListAuditEntriesResponse{}
+	mi := &file_newsfed_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAuditEntriesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAuditEntriesResponse) ProtoMessage() {}
+
+func (x *ListAuditEntriesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_newsfed_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAuditEntriesResponse.ProtoReflect.Descriptor instead.
+func (*ListAuditEntriesResponse) Descriptor() ([]byte, []int) {
+	return file_newsfed_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ListAuditEntriesResponse) GetEntries() []*AuditEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+type AuditEntry struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Id     int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	At     *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=at,proto3" json:"at,omitempty"`
+	Actor  string                 `protobuf:"bytes,3,opt,name=actor,proto3" json:"actor,omitempty"`
+	Action string                 `protobuf:"bytes,4,opt,name=action,proto3" json:"action,omitempty"`
+	// The source ID, or "config".
+	Target        string                  `protobuf:"bytes,5,opt,name=target,proto3" json:"target,omitempty"`
+	Changes       map[string]*AuditChange `protobuf:"bytes,6,rep,name=changes,proto3" json:"changes,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AuditEntry) Reset() {
+	*x = AuditEntry{}
+	mi := &file_newsfed_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AuditEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuditEntry) ProtoMessage() {}
+
+func (x *AuditEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_newsfed_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuditEntry.ProtoReflect.Descriptor instead.
+func (*AuditEntry) Descriptor() ([]byte, []int) {
+	return file_newsfed_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *AuditEntry) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *AuditEntry) GetAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.At
+	}
+	return nil
+}
+
+func (x *AuditEntry) GetActor() string {
+	if x != nil {
+		return x.Actor
+	}
+	return ""
+}
+
+func (x *AuditEntry) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+func (x *AuditEntry) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+func (x *AuditEntry) GetChanges() map[string]*AuditChange {
+	if x != nil {
+		return x.Changes
+	}
+	return nil
+}
+
+// AuditChange holds a field's value before and after a change; an unset
+// value means the field was unset.
+type AuditChange struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Old           *structpb.Value        `protobuf:"bytes,1,opt,name=old,proto3" json:"old,omitempty"`
+	New           *structpb.Value        `protobuf:"bytes,2,opt,name=new,proto3" json:"new,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AuditChange) Reset() {
+	*x = AuditChange{}
+	mi := &file_newsfed_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AuditChange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuditChange) ProtoMessage() {}
+
+func (x *AuditChange) ProtoReflect() protoreflect.Message {
+	mi := &file_newsfed_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuditChange.ProtoReflect.Descriptor instead.
+func (*AuditChange) Descriptor() ([]byte, []int) {
+	return file_newsfed_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *AuditChange) GetOld() *structpb.Value {
+	if x != nil {
+		return x.Old
+	}
+	return nil
+}
+
+func (x *AuditChange) GetNew() *structpb.Value {
+	if x != nil {
+		return x.New
+	}
+	return nil
+}
+
+type Collection struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Description   string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	ItemIds       []string               `protobuf:"bytes,5,rep,name=item_ids,json=itemIds,proto3" json:"item_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Collection) Reset() {
+	*x = Collection{}
+	mi := &file_newsfed_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Collection) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Collection) ProtoMessage() {}
+
+func (x *Collection) ProtoReflect() protoreflect.Message {
+	mi := &file_newsfed_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Collection.ProtoReflect.Descriptor instead.
+func (*Collection) Descriptor() ([]byte, []int) {
+	return file_newsfed_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *Collection) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Collection) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Collection) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Collection) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *Collection) GetItemIds() []string {
+	if x != nil {
+		return x.ItemIds
+	}
+	return nil
+}
+
+type ListCollectionsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCollectionsRequest) Reset() {
+	*x = ListCollectionsRequest{}
+	mi := &file_newsfed_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCollectionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCollectionsRequest) ProtoMessage() {}
+
+func (x *ListCollectionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_newsfed_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCollectionsRequest.ProtoReflect.Descriptor instead.
+func (*ListCollectionsRequest) Descriptor() ([]byte, []int) {
+	return file_newsfed_proto_rawDescGZIP(), []int{18}
+}
+
+type ListCollectionsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Collections   []*Collection          `protobuf:"bytes,1,rep,name=collections,proto3" json:"collections,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCollectionsResponse) Reset() {
+	*x = ListCollectionsResponse{}
+	mi := &file_newsfed_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCollectionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCollectionsResponse) ProtoMessage() {}
+
+func (x *ListCollectionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_newsfed_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCollectionsResponse.ProtoReflect.Descriptor instead.
+func (*ListCollectionsResponse) Descriptor() ([]byte, []int) {
+	return file_newsfed_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *ListCollectionsResponse) GetCollections() []*Collection {
+	if x != nil {
+		return x.Collections
+	}
+	return nil
+}
+
+type CreateCollectionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Description   string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateCollectionRequest) Reset() {
+	*x = CreateCollectionRequest{}
+	mi := &file_newsfed_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateCollectionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateCollectionRequest) ProtoMessage() {}
+
+func (x *CreateCollectionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_newsfed_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateCollectionRequest.ProtoReflect.Descriptor instead.
+func (*CreateCollectionRequest) Descriptor() ([]byte, []int) {
+	return file_newsfed_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *CreateCollectionRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CreateCollectionRequest) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+type GetCollectionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCollectionRequest) Reset() {
+	*x = GetCollectionRequest{}
+	mi := &file_newsfed_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCollectionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCollectionRequest) ProtoMessage() {}
+
+func (x *GetCollectionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_newsfed_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCollectionRequest.ProtoReflect.Descriptor instead.
+func (*GetCollectionRequest) Descriptor() ([]byte, []int) {
+	return file_newsfed_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *GetCollectionRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type UpdateCollectionRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Renames the collection.
+	NewName       *string `protobuf:"bytes,2,opt,name=new_name,json=newName,proto3,oneof" json:"new_name,omitempty"`
+	Description   *string `protobuf:"bytes,3,opt,name=description,proto3,oneof" json:"description,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateCollectionRequest) Reset() {
+	*x = UpdateCollectionRequest{}
+	mi := &file_newsfed_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateCollectionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateCollectionRequest) ProtoMessage() {}
+
+func (x *UpdateCollectionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_newsfed_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateCollectionRequest.ProtoReflect.Descriptor instead.
+func (*UpdateCollectionRequest) Descriptor() ([]byte, []int) {
+	return file_newsfed_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *UpdateCollectionRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *UpdateCollectionRequest) GetNewName() string {
+	if x != nil && x.NewName != nil {
+		return *x.NewName
+	}
+	return ""
+}
+
+func (x *UpdateCollectionRequest) GetDescription() string {
+	if x != nil && x.Description != nil {
+		return *x.Description
+	}
+	return ""
+}
+
+type DeleteCollectionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteCollectionRequest) Reset() {
+	*x = DeleteCollectionRequest{}
+	mi := &file_newsfed_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteCollectionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteCollectionRequest) ProtoMessage() {}
+
+func (x *DeleteCollectionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_newsfed_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteCollectionRequest.ProtoReflect.Descriptor instead.
+func (*DeleteCollectionRequest) Descriptor() ([]byte, []int) {
+	return file_newsfed_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *DeleteCollectionRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type DeleteCollectionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteCollectionResponse) Reset() {
+	*x = DeleteCollectionResponse{}
+	mi := &file_newsfed_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteCollectionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteCollectionResponse) ProtoMessage() {}
+
+func (x *DeleteCollectionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_newsfed_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteCollectionResponse.ProtoReflect.Descriptor instead.
+func (*DeleteCollectionResponse) Descriptor() ([]byte, []int) {
+	return file_newsfed_proto_rawDescGZIP(), []int{24}
+}
+
+type AddCollectionItemRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Name   string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	ItemId string                 `protobuf:"bytes,2,opt,name=item_id,json=itemId,proto3" json:"item_id,omitempty"`
+	// Places the item, counting from 1; otherwise it is appended. Adding an
+	// item that is already in the collection moves it.
+	Position      int32 `protobuf:"varint,3,opt,name=position,proto3" json:"position,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddCollectionItemRequest) Reset() {
+	*x = AddCollectionItemRequest{}
+	mi := &file_newsfed_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddCollectionItemRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddCollectionItemRequest) ProtoMessage() {}
+
+func (x *AddCollectionItemRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_newsfed_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddCollectionItemRequest.ProtoReflect.Descriptor instead.
+func (*AddCollectionItemRequest) Descriptor() ([]byte, []int) {
+	return file_newsfed_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *AddCollectionItemRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *AddCollectionItemRequest) GetItemId() string {
+	if x != nil {
+		return x.ItemId
+	}
+	return ""
+}
+
+func (x *AddCollectionItemRequest) GetPosition() int32 {
+	if x != nil {
+		return x.Position
+	}
+	return 0
+}
+
+type RemoveCollectionItemRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	ItemId        string                 `protobuf:"bytes,2,opt,name=item_id,json=itemId,proto3" json:"item_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoveCollectionItemRequest) Reset() {
+	*x = RemoveCollectionItemRequest{}
+	mi := &file_newsfed_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoveCollectionItemRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveCollectionItemRequest) ProtoMessage() {}
+
+func (x *RemoveCollectionItemRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_newsfed_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoveCollectionItemRequest.ProtoReflect.Descriptor instead.
+func (*RemoveCollectionItemRequest) Descriptor() ([]byte, []int) {
+	return file_newsfed_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *RemoveCollectionItemRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *RemoveCollectionItemRequest) GetItemId() string {
+	if x != nil {
+		return x.ItemId
+	}
+	return ""
+}
+
+type RemoveCollectionItemResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoveCollectionItemResponse) Reset() {
+	*x = RemoveCollectionItemResponse{}
+	mi := &file_newsfed_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoveCollectionItemResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveCollectionItemResponse) ProtoMessage() {}
+
+func (x *RemoveCollectionItemResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_newsfed_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoveCollectionItemResponse.ProtoReflect.Descriptor instead.
+func (*RemoveCollectionItemResponse) Descriptor() ([]byte, []int) {
+	return file_newsfed_proto_rawDescGZIP(), []int{27}
+}
+
+type ExportCollectionRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// One of "markdown" (default), "rss", "atom", "json", or "csv".
+	Format        string `protobuf:"bytes,2,opt,name=format,proto3" json:"format,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExportCollectionRequest) Reset() {
+	*x = ExportCollectionRequest{}
+	mi := &file_newsfed_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportCollectionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportCollectionRequest) ProtoMessage() {}
+
+func (x *ExportCollectionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_newsfed_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportCollectionRequest.ProtoReflect.Descriptor instead.
+func (*ExportCollectionRequest) Descriptor() ([]byte, []int) {
+	return file_newsfed_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *ExportCollectionRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ExportCollectionRequest) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+type ExportCollectionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ContentType   string                 `protobuf:"bytes,1,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	Data          []byte                 `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExportCollectionResponse) Reset() {
+	*x = ExportCollectionResponse{}
+	mi := &file_newsfed_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportCollectionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportCollectionResponse) ProtoMessage() {}
+
+func (x *ExportCollectionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_newsfed_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportCollectionResponse.ProtoReflect.Descriptor instead.
+func (*ExportCollectionResponse) Descriptor() ([]byte, []int) {
+	return file_newsfed_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *ExportCollectionResponse) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *ExportCollectionResponse) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type WhoAmIRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WhoAmIRequest) Reset() {
+	*x = WhoAmIRequest{}
+	mi := &file_newsfed_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WhoAmIRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WhoAmIRequest) ProtoMessage() {}
+
+func (x *WhoAmIRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_newsfed_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WhoAmIRequest.ProtoReflect.Descriptor instead.
+func (*WhoAmIRequest) Descriptor() ([]byte, []int) {
+	return file_newsfed_proto_rawDescGZIP(), []int{30}
+}
+
+type WhoAmIResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Unset for the default user.
+	Name          *string                `protobuf:"bytes,1,opt,name=name,proto3,oneof" json:"name,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WhoAmIResponse) Reset() {
+	*x = WhoAmIResponse{}
+	mi := &file_newsfed_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WhoAmIResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WhoAmIResponse) ProtoMessage() {}
+
+func (x *WhoAmIResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_newsfed_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WhoAmIResponse.ProtoReflect.Descriptor instead.
+func (*WhoAmIResponse) Descriptor() ([]byte, []int) {
+	return file_newsfed_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *WhoAmIResponse) GetName() string {
+	if x != nil && x.Name != nil {
+		return *x.Name
+	}
+	return ""
+}
+
+func (x *WhoAmIResponse) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+var File_newsfed_proto protoreflect.FileDescriptor
+
+const file_newsfed_proto_rawDesc = "" +
+	"\n" +
+	"\rnewsfed.proto\x12\n" +
+	"newsfed.v1\x1a\x1cgoogle/protobuf/struct.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xf4\a\n" +
+	"\bNewsItem\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12\x18\n" +
+	"\asummary\x18\x03 \x01(\tR\asummary\x12!\n" +
+	"\fsummary_html\x18\x04 \x01(\tR\vsummaryHtml\x12+\n" +
+	"\x11generated_summary\x18\x05 \x01(\tR\x10generatedSummary\x12\x10\n" +
+	"\x03url\x18\x06 \x01(\tR\x03url\x12!\n" +
+	"\tpublisher\x18\a \x01(\tH\x00R\tpublisher\x88\x01\x01\x12\x18\n" +
+	"\aauthors\x18\b \x03(\tR\aauthors\x12=\n" +
+	"\fpublished_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\vpublishedAt\x12?\n" +
+	"\rdiscovered_at\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\fdiscoveredAt\x127\n" +
+	"\tpinned_at\x18\v \x01(\v2\x1a.google.protobuf.TimestampR\bpinnedAt\x12@\n" +
+	"\x0epin_expires_at\x18\f \x01(\v2\x1a.google.protobuf.TimestampR\fpinExpiresAt\x123\n" +
+	"\aread_at\x18\r \x01(\v2\x1a.google.protobuf.TimestampR\x06readAt\x12;\n" +
+	"\varchived_at\x18\x0e \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"archivedAt\x129\n" +
+	"\n" +
+	"starred_at\x18\x0f \x01(\v2\x1a.google.protobuf.TimestampR\tstarredAt\x12?\n" +
+	"\rsnoozed_until\x18\x10 \x01(\v2\x1a.google.protobuf.TimestampR\fsnoozedUntil\x12\x14\n" +
+	"\x05notes\x18\x11 \x01(\tR\x05notes\x12 \n" +
+	"\tsource_id\x18\x12 \x01(\tH\x01R\bsourceId\x88\x01\x01\x12\x12\n" +
+	"\x04guid\x18\x13 \x01(\tR\x04guid\x12\x1f\n" +
+	"\bcategory\x18\x14 \x01(\tH\x02R\bcategory\x88\x01\x01\x12\x1a\n" +
+	"\blanguage\x18\x15 \x01(\tR\blanguage\x129\n" +
+	"\n" +
+	"updated_at\x18\x16 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x123\n" +
+	"\bmetadata\x18\x17 \x01(\v2\x17.google.protobuf.StructR\bmetadataB\f\n" +
+	"\n" +
+	"_publisherB\f\n" +
+	"\n" +
+	"_source_idB\v\n" +
+	"\t_category\"\xca\x04\n" +
+	"\x10ListItemsRequest\x12\x1b\n" +
+	"\x06pinned\x18\x01 \x01(\bH\x00R\x06pinned\x88\x01\x01\x12\x16\n" +
+	"\x06unread\x18\x02 \x01(\bR\x06unread\x12\x18\n" +
+	"\astarred\x18\x03 \x01(\bR\astarred\x12\x1a\n" +
+	"\barchived\x18\x04 \x01(\bR\barchived\x12\x18\n" +
+	"\asnoozed\x18\x05 \x01(\bR\asnoozed\x12\x1c\n" +
+	"\tpublisher\x18\x06 \x01(\tR\tpublisher\x12\x1a\n" +
+	"\bcategory\x18\a \x01(\tR\bcategory\x12\x1a\n" +
+	"\blanguage\x18\b \x01(\tR\blanguage\x12F\n" +
+	"\bmetadata\x18\t \x03(\v2*.newsfed.v1.ListItemsRequest.MetadataEntryR\bmetadata\x12E\n" +
+	"\x10discovered_since\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\x0fdiscoveredSince\x12%\n" +
+	"\x0einclude_pinned\x18\v \x01(\bR\rincludePinned\x12\x17\n" +
+	"\asort_by\x18\f \x01(\tR\x06sortBy\x12\x14\n" +
+	"\x05limit\x18\r \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\x0e \x01(\x05R\x06offset\x12\x16\n" +
+	"\x06cursor\x18\x0f \x01(\tR\x06cursor\x1a;\n" +
+	"\rMetadataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01B\t\n" +
+	"\a_pinned\"\xf4\x01\n" +
+	"\x11ListItemsResponse\x12*\n" +
+	"\x05items\x18\x01 \x03(\v2\x14.newsfed.v1.NewsItemR\x05items\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\x05R\x05total\x12\x1f\n" +
+	"\vnext_cursor\x18\x03 \x01(\tR\n" +
+	"nextCursor\x12A\n" +
+	"\x06scores\x18\x04 \x03(\v2).newsfed.v1.ListItemsResponse.ScoresEntryR\x06scores\x1a9\n" +
+	"\vScoresEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x01R\x05value:\x028\x01\" \n" +
+	"\x0eGetItemRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x8f\x06\n" +
+	"\x06Source\x12\x1b\n" +
+	"\tsource_id\x18\x01 \x01(\tR\bsourceId\x12\x1f\n" +
+	"\vsource_type\x18\x02 \x01(\tR\n" +
+	"sourceType\x12\x10\n" +
+	"\x03url\x18\x03 \x01(\tR\x03url\x12\x12\n" +
+	"\x04name\x18\x04 \x01(\tR\x04name\x129\n" +
+	"\n" +
+	"enabled_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tenabledAt\x129\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12.\n" +
+	"\x10polling_interval\x18\b \x01(\tH\x00R\x0fpollingInterval\x88\x01\x01\x12B\n" +
+	"\x0flast_fetched_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\rlastFetchedAt\x12>\n" +
+	"\rnext_fetch_at\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\vnextFetchAt\x12*\n" +
+	"\x11fetch_error_count\x18\v \x01(\x05R\x0ffetchErrorCount\x12\"\n" +
+	"\n" +
+	"last_error\x18\f \x01(\tH\x01R\tlastError\x88\x01\x01\x12\x1f\n" +
+	"\bcategory\x18\r \x01(\tH\x02R\bcategory\x88\x01\x01\x12\x1f\n" +
+	"\blanguage\x18\x0e \x01(\tH\x03R\blanguage\x88\x01\x01\x12\"\n" +
+	"\n" +
+	"user_agent\x18\x0f \x01(\tH\x04R\tuserAgent\x88\x01\x01\x129\n" +
+	"\n" +
+	"reprobe_at\x18\x10 \x01(\v2\x1a.google.protobuf.TimestampR\treprobeAtB\x13\n" +
+	"\x11_polling_intervalB\r\n" +
+	"\v_last_errorB\v\n" +
+	"\t_categoryB\v\n" +
+	"\t_languageB\r\n" +
+	"\v_user_agent\"\xd7\x01\n" +
+	"\x12ListSourcesRequest\x12\x17\n" +
+	"\x04type\x18\x01 \x01(\tH\x00R\x04type\x88\x01\x01\x12\x1d\n" +
+	"\aenabled\x18\x02 \x01(\bH\x01R\aenabled\x88\x01\x01\x12\x18\n" +
+	"\afailing\x18\x03 \x01(\bR\afailing\x12\x1f\n" +
+	"\bcategory\x18\x04 \x01(\tH\x02R\bcategory\x88\x01\x01\x12\x14\n" +
+	"\x05limit\x18\x05 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\x06 \x01(\x05R\x06offsetB\a\n" +
+	"\x05_typeB\n" +
+	"\n" +
+	"\b_enabledB\v\n" +
+	"\t_category\"C\n" +
+	"\x13ListSourcesResponse\x12,\n" +
+	"\asources\x18\x01 \x03(\v2\x12.newsfed.v1.SourceR\asources\"/\n" +
+	"\x10GetSourceRequest\x12\x1b\n" +
+	"\tsource_id\x18\x01 \x01(\tR\bsourceId\"B\n" +
+	"\x10StartSyncRequest\x12 \n" +
+	"\tsource_id\x18\x01 \x01(\tH\x00R\bsourceId\x88\x01\x01B\f\n" +
+	"\n" +
+	"_source_id\"#\n" +
+	"\x11GetSyncJobRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\xf6\x03\n" +
+	"\aSyncJob\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\x12 \n" +
+	"\tsource_id\x18\x03 \x01(\tH\x00R\bsourceId\x88\x01\x01\x129\n" +
+	"\n" +
+	"started_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tstartedAt\x12;\n" +
+	"\vfinished_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"finishedAt\x12#\n" +
+	"\rsources_total\x18\x06 \x01(\x05R\fsourcesTotal\x12%\n" +
+	"\x0esources_synced\x18\a \x01(\x05R\rsourcesSynced\x12%\n" +
+	"\x0esources_failed\x18\b \x01(\x05R\rsourcesFailed\x12)\n" +
+	"\x10items_discovered\x18\t \x01(\x05R\x0fitemsDiscovered\x120\n" +
+	"\x06errors\x18\n" +
+	" \x03(\v2\x18.newsfed.v1.SyncJobErrorR\x06errors\x12\x14\n" +
+	"\x05error\x18\v \x01(\tR\x05error\x125\n" +
+	"\bfetching\x18\f \x03(\v2\x19.newsfed.v1.SyncJobSourceR\bfetchingB\f\n" +
+	"\n" +
+	"_source_id\"@\n" +
+	"\rSyncJobSource\x12\x1b\n" +
+	"\tsource_id\x18\x01 \x01(\tR\bsourceId\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\"U\n" +
+	"\fSyncJobError\x12\x1b\n" +
+	"\tsource_id\x18\x01 \x01(\tR\bsourceId\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\"\xa7\x01\n" +
+	"\x17ListAuditEntriesRequest\x12\x14\n" +
+	"\x05actor\x18\x01 \x01(\tR\x05actor\x12\x16\n" +
+	"\x06action\x18\x02 \x01(\tR\x06action\x12\x16\n" +
+	"\x06target\x18\x03 \x01(\tR\x06target\x120\n" +
+	"\x05since\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\x05since\x12\x14\n" +
+	"\x05limit\x18\x05 \x01(\x05R\x05limit\"L\n" +
+	"\x18ListAuditEntriesResponse\x120\n" +
+	"\aentries\x18\x01 \x03(\v2\x16.newsfed.v1.AuditEntryR\aentries\"\xa2\x02\n" +
+	"\n" +
+	"AuditEntry\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12*\n" +
+	"\x02at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x02at\x12\x14\n" +
+	"\x05actor\x18\x03 \x01(\tR\x05actor\x12\x16\n" +
+	"\x06action\x18\x04 \x01(\tR\x06action\x12\x16\n" +
+	"\x06target\x18\x05 \x01(\tR\x06target\x12=\n" +
+	"\achanges\x18\x06 \x03(\v2#.newsfed.v1.AuditEntry.ChangesEntryR\achanges\x1aS\n" +
+	"\fChangesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12-\n" +
+	"\x05value\x18\x02 \x01(\v2\x17.newsfed.v1.AuditChangeR\x05value:\x028\x01\"a\n" +
+	"\vAuditChange\x12(\n" +
+	"\x03old\x18\x01 \x01(\v2\x16.google.protobuf.ValueR\x03old\x12(\n" +
+	"\x03new\x18\x02 \x01(\v2\x16.google.protobuf.ValueR\x03new\"\xd3\x01\n" +
+	"\n" +
+	"Collection\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12 \n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\x129\n" +
+	"\n" +
+	"created_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12\x19\n" +
+	"\bitem_ids\x18\x05 \x03(\tR\aitemIds\"\x18\n" +
+	"\x16ListCollectionsRequest\"S\n" +
+	"\x17ListCollectionsResponse\x128\n" +
+	"\vcollections\x18\x01 \x03(\v2\x16.newsfed.v1.CollectionR\vcollections\"O\n" +
+	"\x17CreateCollectionRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12 \n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\"*\n" +
+	"\x14GetCollectionRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"\x91\x01\n" +
+	"\x17UpdateCollectionRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1e\n" +
+	"\bnew_name\x18\x02 \x01(\tH\x00R\anewName\x88\x01\x01\x12%\n" +
+	"\vdescription\x18\x03 \x01(\tH\x01R\vdescription\x88\x01\x01B\v\n" +
+	"\t_new_nameB\x0e\n" +
+	"\f_description\"-\n" +
+	"\x17DeleteCollectionRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"\x1a\n" +
+	"\x18DeleteCollectionResponse\"c\n" +
+	"\x18AddCollectionItemRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x17\n" +
+	"\aitem_id\x18\x02 \x01(\tR\x06itemId\x12\x1a\n" +
+	"\bposition\x18\x03 \x01(\x05R\bposition\"J\n" +
+	"\x1bRemoveCollectionItemRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x17\n" +
+	"\aitem_id\x18\x02 \x01(\tR\x06itemId\"\x1e\n" +
+	"\x1cRemoveCollectionItemResponse\"E\n" +
+	"\x17ExportCollectionRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x16\n" +
+	"\x06format\x18\x02 \x01(\tR\x06format\"Q\n" +
+	"\x18ExportCollectionResponse\x12!\n" +
+	"\fcontent_type\x18\x01 \x01(\tR\vcontentType\x12\x12\n" +
+	"\x04data\x18\x02 \x01(\fR\x04data\"\x0f\n" +
+	"\rWhoAmIRequest\"m\n" +
+	"\x0eWhoAmIResponse\x12\x17\n" +
+	"\x04name\x18\x01 \x01(\tH\x00R\x04name\x88\x01\x01\x129\n" +
+	"\n" +
+	"created_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAtB\a\n" +
+	"\x05_name2\x86\n" +
+	"\n" +
+	"\aNewsfed\x12H\n" +
+	"\tListItems\x12\x1c.newsfed.v1.ListItemsRequest\x1a\x1d.newsfed.v1.ListItemsResponse\x12;\n" +
+	"\aGetItem\x12\x1a.newsfed.v1.GetItemRequest\x1a\x14.newsfed.v1.NewsItem\x12N\n" +
+	"\vListSources\x12\x1e.newsfed.v1.ListSourcesRequest\x1a\x1f.newsfed.v1.ListSourcesResponse\x12=\n" +
+	"\tGetSource\x12\x1c.newsfed.v1.GetSourceRequest\x1a\x12.newsfed.v1.Source\x12>\n" +
+	"\tStartSync\x12\x1c.newsfed.v1.StartSyncRequest\x1a\x13.newsfed.v1.SyncJob\x12@\n" +
+	"\n" +
+	"GetSyncJob\x12\x1d.newsfed.v1.GetSyncJobRequest\x1a\x13.newsfed.v1.SyncJob\x12]\n" +
+	"\x10ListAuditEntries\x12#.newsfed.v1.ListAuditEntriesRequest\x1a$.newsfed.v1.ListAuditEntriesResponse\x12Z\n" +
+	"\x0fListCollections\x12\".newsfed.v1.ListCollectionsRequest\x1a#.newsfed.v1.ListCollectionsResponse\x12O\n" +
+	"\x10CreateCollection\x12#.newsfed.v1.CreateCollectionRequest\x1a\x16.newsfed.v1.Collection\x12I\n" +
+	"\rGetCollection\x12 .newsfed.v1.GetCollectionRequest\x1a\x16.newsfed.v1.Collection\x12O\n" +
+	"\x10UpdateCollection\x12#.newsfed.v1.UpdateCollectionRequest\x1a\x16.newsfed.v1.Collection\x12]\n" +
+	"\x10DeleteCollection\x12#.newsfed.v1.DeleteCollectionRequest\x1a$.newsfed.v1.DeleteCollectionResponse\x12Q\n" +
+	"\x11AddCollectionItem\x12$.newsfed.v1.AddCollectionItemRequest\x1a\x16.newsfed.v1.Collection\x12i\n" +
+	"\x14RemoveCollectionItem\x12'.newsfed.v1.RemoveCollectionItemRequest\x1a(.newsfed.v1.RemoveCollectionItemResponse\x12]\n" +
+	"\x10ExportCollection\x12#.newsfed.v1.ExportCollectionRequest\x1a$.newsfed.v1.ExportCollectionResponse\x12?\n" +
+	"\x06WhoAmI\x12\x19.newsfed.v1.WhoAmIRequest\x1a\x1a.newsfed.v1.WhoAmIResponseB-Z+github.com/pevans/newsfed/grpcapi/newsfedpbb\x06proto3"
+
+var (
+	file_newsfed_proto_rawDescOnce sync.Once
+	file_newsfed_proto_rawDescData []byte
+)
+
+func file_newsfed_proto_rawDescGZIP() []byte {
+	file_newsfed_proto_rawDescOnce.Do(func() {
+		file_newsfed_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_newsfed_proto_rawDesc), len(file_newsfed_proto_rawDesc)))
+	})
+	return file_newsfed_proto_rawDescData
+}
+
+var file_newsfed_proto_msgTypes = make([]protoimpl.MessageInfo, 35)
+var file_newsfed_proto_goTypes = []any{
+	(*NewsItem)(nil),                     // 0: newsfed.v1.NewsItem
+	(*ListItemsRequest)(nil),             // 1: newsfed.v1.ListItemsRequest
+	(*ListItemsResponse)(nil),            // 2: newsfed.v1.ListItemsResponse
+	(*GetItemRequest)(nil),               // 3: newsfed.v1.GetItemRequest
+	(*Source)(nil),                       // 4: newsfed.v1.Source
+	(*ListSourcesRequest)(nil),           // 5: newsfed.v1.ListSourcesRequest
+	(*ListSourcesResponse)(nil),          // 6: newsfed.v1.ListSourcesResponse
+	(*GetSourceRequest)(nil),             // 7: newsfed.v1.GetSourceRequest
+	(*StartSyncRequest)(nil),             // 8: newsfed.v1.StartSyncRequest
+	(*GetSyncJobRequest)(nil),            // 9: newsfed.v1.GetSyncJobRequest
+	(*SyncJob)(nil),                      // 10: newsfed.v1.SyncJob
+	(*SyncJobSource)(nil),                // 11: newsfed.v1.SyncJobSource
+	(*SyncJobError)(nil),                 // 12: newsfed.v1.SyncJobError
+	(*ListAuditEntriesRequest)(nil),      // 13: newsfed.v1.ListAuditEntriesRequest
+	(*ListAuditEntriesResponse)(nil),     // 14: newsfed.v1.ListAuditEntriesResponse
+	(*AuditEntry)(nil),                   // 15: newsfed.v1.AuditEntry
+	(*AuditChange)(nil),                  // 16: newsfed.v1.AuditChange
+	(*Collection)(nil),                   // 17: newsfed.v1.Collection
+	(*ListCollectionsRequest)(nil),       // 18: newsfed.v1.ListCollectionsRequest
+	(*ListCollectionsResponse)(nil),      // 19: newsfed.v1.ListCollectionsResponse
+	(*CreateCollectionRequest)(nil),      // 20: newsfed.v1.CreateCollectionRequest
+	(*GetCollectionRequest)(nil),         // 21: newsfed.v1.GetCollectionRequest
+	(*UpdateCollectionRequest)(nil),      // 22: newsfed.v1.UpdateCollectionRequest
+	(*DeleteCollectionRequest)(nil),      // 23: newsfed.v1.DeleteCollectionRequest
+	(*DeleteCollectionResponse)(nil),     // 24: newsfed.v1.DeleteCollectionResponse
+	(*AddCollectionItemRequest)(nil),     // 25: newsfed.v1.AddCollectionItemRequest
+	(*RemoveCollectionItemRequest)(nil),  // 26: newsfed.v1.RemoveCollectionItemRequest
+	(*RemoveCollectionItemResponse)(nil), // 27: newsfed.v1.RemoveCollectionItemResponse
+	(*ExportCollectionRequest)(nil),      // 28: newsfed.v1.ExportCollectionRequest
+	(*ExportCollectionResponse)(nil),     // 29: newsfed.v1.ExportCollectionResponse
+	(*WhoAmIRequest)(nil),                // 30: newsfed.v1.WhoAmIRequest
+	(*WhoAmIResponse)(nil),               // 31: newsfed.v1.WhoAmIResponse
+	nil,                                  // 32: newsfed.v1.ListItemsRequest.MetadataEntry
+	nil,                                  // 33: newsfed.v1.ListItemsResponse.ScoresEntry
+	nil,                                  // 34: newsfed.v1.AuditEntry.ChangesEntry
+	(*timestamppb.Timestamp)(nil),        // 35: google.protobuf.Timestamp
+	(*structpb.Struct)(nil),              // 36: google.protobuf.Struct
+	(*structpb.Value)(nil),               // 37: google.protobuf.Value
+}
+var file_newsfed_proto_depIdxs = []int32{
+	35, // 0: newsfed.v1.NewsItem.published_at:type_name -> google.protobuf.Timestamp
+	35, // 1: newsfed.v1.NewsItem.discovered_at:type_name -> google.protobuf.Timestamp
+	35, // 2: newsfed.v1.NewsItem.pinned_at:type_name -> google.protobuf.Timestamp
+	35, // 3: newsfed.v1.NewsItem.pin_expires_at:type_name -> google.protobuf.Timestamp
+	35, // 4: newsfed.v1.NewsItem.read_at:type_name -> google.protobuf.Timestamp
+	35, // 5: newsfed.v1.NewsItem.archived_at:type_name -> google.protobuf.Timestamp
+	35, // 6: newsfed.v1.NewsItem.starred_at:type_name -> google.protobuf.Timestamp
+	35, // 7: newsfed.v1.NewsItem.snoozed_until:type_name -> google.protobuf.Timestamp
+	35, // 8: newsfed.v1.NewsItem.updated_at:type_name -> google.protobuf.Timestamp
+	36, // 9: newsfed.v1.NewsItem.metadata:type_name -> google.protobuf.Struct
+	32, // 10: newsfed.v1.ListItemsRequest.metadata:type_name -> newsfed.v1.ListItemsRequest.MetadataEntry
+	35, // 11: newsfed.v1.ListItemsRequest.discovered_since:type_name -> google.protobuf.Timestamp
+	0,  // 12: newsfed.v1.ListItemsResponse.items:type_name -> newsfed.v1.NewsItem
+	33, // 13: newsfed.v1.ListItemsResponse.scores:type_name -> newsfed.v1.ListItemsResponse.ScoresEntry
+	35, // 14: newsfed.v1.Source.enabled_at:type_name -> google.protobuf.Timestamp
+	35, // 15: newsfed.v1.Source.created_at:type_name -> google.protobuf.Timestamp
+	35, // 16: newsfed.v1.Source.updated_at:type_name -> google.protobuf.Timestamp
+	35, // 17: newsfed.v1.Source.last_fetched_at:type_name -> google.protobuf.Timestamp
+	35, // 18: newsfed.v1.Source.next_fetch_at:type_name -> google.protobuf.Timestamp
+	35, // 19: newsfed.v1.Source.reprobe_at:type_name -> google.protobuf.Timestamp
+	4,  // 20: newsfed.v1.ListSourcesResponse.sources:type_name -> newsfed.v1.Source
+	35, // 21: newsfed.v1.SyncJob.started_at:type_name -> google.protobuf.Timestamp
+	35, // 22: newsfed.v1.SyncJob.finished_at:type_name -> google.protobuf.Timestamp
+	12, // 23: newsfed.v1.SyncJob.errors:type_name -> newsfed.v1.SyncJobError
+	11, // 24: newsfed.v1.SyncJob.fetching:type_name -> newsfed.v1.SyncJobSource
+	35, // 25: newsfed.v1.ListAuditEntriesRequest.since:type_name -> google.protobuf.Timestamp
+	15, // 26: newsfed.v1.ListAuditEntriesResponse.entries:type_name -> newsfed.v1.AuditEntry
+	35, // 27: newsfed.v1.AuditEntry.at:type_name -> google.protobuf.Timestamp
+	34, // 28: newsfed.v1.AuditEntry.changes:type_name -> newsfed.v1.AuditEntry.ChangesEntry
+	37, // 29: newsfed.v1.AuditChange.old:type_name -> google.protobuf.Value
+	37, // 30: newsfed.v1.AuditChange.new:type_name -> google.protobuf.Value
+	35, // 31: newsfed.v1.Collection.created_at:type_name -> google.protobuf.Timestamp
+	35, // 32: newsfed.v1.Collection.updated_at:type_name -> google.protobuf.Timestamp
+	17, // 33: newsfed.v1.ListCollectionsResponse.collections:type_name -> newsfed.v1.Collection
+	35, // 34: newsfed.v1.WhoAmIResponse.created_at:type_name -> google.protobuf.Timestamp
+	16, // 35: newsfed.v1.AuditEntry.ChangesEntry.value:type_name -> newsfed.v1.AuditChange
+	1,  // 36: newsfed.v1.Newsfed.ListItems:input_type -> newsfed.v1.ListItemsRequest
+	3,  // 37: newsfed.v1.Newsfed.GetItem:input_type -> newsfed.v1.GetItemRequest
+	5,  // 38: newsfed.v1.Newsfed.ListSources:input_type -> newsfed.v1.ListSourcesRequest
+	7,  // 39: newsfed.v1.Newsfed.GetSource:input_type -> newsfed.v1.GetSourceRequest
+	8,  // 40: newsfed.v1.Newsfed.StartSync:input_type -> newsfed.v1.StartSyncRequest
+	9,  // 41: newsfed.v1.Newsfed.GetSyncJob:input_type -> newsfed.v1.GetSyncJobRequest
+	13, // 42: newsfed.v1.Newsfed.ListAuditEntries:input_type -> newsfed.v1.ListAuditEntriesRequest
+	18, // 43: newsfed.v1.Newsfed.ListCollections:input_type -> newsfed.v1.ListCollectionsRequest
+	20, // 44: newsfed.v1.Newsfed.CreateCollection:input_type -> newsfed.v1.CreateCollectionRequest
+	21, // 45: newsfed.v1.Newsfed.GetCollection:input_type -> newsfed.v1.GetCollectionRequest
+	22, // 46: newsfed.v1.Newsfed.UpdateCollection:input_type -> newsfed.v1.UpdateCollectionRequest
+	23, // 47: newsfed.v1.Newsfed.DeleteCollection:input_type -> newsfed.v1.DeleteCollectionRequest
+	25, // 48: newsfed.v1.Newsfed.AddCollectionItem:input_type -> newsfed.v1.AddCollectionItemRequest
+	26, // 49: newsfed.v1.Newsfed.RemoveCollectionItem:input_type -> newsfed.v1.RemoveCollectionItemRequest
+	28, // 50: newsfed.v1.Newsfed.ExportCollection:input_type -> newsfed.v1.ExportCollectionRequest
+	30, // 51: newsfed.v1.Newsfed.WhoAmI:input_type -> newsfed.v1.WhoAmIRequest
+	2,  // 52: newsfed.v1.Newsfed.ListItems:output_type -> newsfed.v1.ListItemsResponse
+	0,  // 53: newsfed.v1.Newsfed.GetItem:output_type -> newsfed.v1.NewsItem
+	6,  // 54: newsfed.v1.Newsfed.ListSources:output_type -> newsfed.v1.ListSourcesResponse
+	4,  // 55: newsfed.v1.Newsfed.GetSource:output_type -> newsfed.v1.Source
+	10, // 56: newsfed.v1.Newsfed.StartSync:output_type -> newsfed.v1.SyncJob
+	10, // 57: newsfed.v1.Newsfed.GetSyncJob:output_type -> newsfed.v1.SyncJob
+	14, // 58: newsfed.v1.Newsfed.ListAuditEntries:output_type -> newsfed.v1.ListAuditEntriesResponse
+	19, // 59: newsfed.v1.Newsfed.ListCollections:output_type -> newsfed.v1.ListCollectionsResponse
+	17, // 60: newsfed.v1.Newsfed.CreateCollection:output_type -> newsfed.v1.Collection
+	17, // 61: newsfed.v1.Newsfed.GetCollection:output_type -> newsfed.v1.Collection
+	17, // 62: newsfed.v1.Newsfed.UpdateCollection:output_type -> newsfed.v1.Collection
+	24, // 63: newsfed.v1.Newsfed.DeleteCollection:output_type -> newsfed.v1.DeleteCollectionResponse
+	17, // 64: newsfed.v1.Newsfed.AddCollectionItem:output_type -> newsfed.v1.Collection
+	27, // 65: newsfed.v1.Newsfed.RemoveCollectionItem:output_type -> newsfed.v1.RemoveCollectionItemResponse
+	29, // 66: newsfed.v1.Newsfed.ExportCollection:output_type -> newsfed.v1.ExportCollectionResponse
+	31, // 67: newsfed.v1.Newsfed.WhoAmI:output_type -> newsfed.v1.WhoAmIResponse
+	52, // [52:68] is the sub-list for method output_type
+	36, // [36:52] is the sub-list for method input_type
+	36, // [36:36] is the sub-list for extension type_name
+	36, // [36:36] is the sub-list for extension extendee
+	0,  // [0:36] is the sub-list for field type_name
+}
+
+func init() { file_newsfed_proto_init() }
+func file_newsfed_proto_init() {
+	if File_newsfed_proto != nil {
+		return
+	}
+	file_newsfed_proto_msgTypes[0].OneofWrappers = []any{}
+	file_newsfed_proto_msgTypes[1].OneofWrappers = []any{}
+	file_newsfed_proto_msgTypes[4].OneofWrappers = []any{}
+	file_newsfed_proto_msgTypes[5].OneofWrappers = []any{}
+	file_newsfed_proto_msgTypes[8].OneofWrappers = []any{}
+	file_newsfed_proto_msgTypes[10].OneofWrappers = []any{}
+	file_newsfed_proto_msgTypes[22].OneofWrappers = []any{}
+	file_newsfed_proto_msgTypes[31].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_newsfed_proto_rawDesc), len(file_newsfed_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   35,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_newsfed_proto_goTypes,
+		DependencyIndexes: file_newsfed_proto_depIdxs,
+		MessageInfos:      file_newsfed_proto_msgTypes,
+	}.Build()
+	File_newsfed_proto = out.File
+	file_newsfed_proto_goTypes = nil
+	file_newsfed_proto_depIdxs = nil
+}
@@ -0,0 +1,731 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.0
+// - protoc             (unknown)
+// source: newsfed.proto
+
+package newsfedpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Newsfed_ListItems_FullMethodName            = "/newsfed.v1.Newsfed/ListItems"
+	Newsfed_GetItem_FullMethodName              = "/newsfed.v1.Newsfed/GetItem"
+	Newsfed_ListSources_FullMethodName          = "/newsfed.v1.Newsfed/ListSources"
+	Newsfed_GetSource_FullMethodName            = "/newsfed.v1.Newsfed/GetSource"
+	Newsfed_StartSync_FullMethodName            = "/newsfed.v1.Newsfed/StartSync"
+	Newsfed_GetSyncJob_FullMethodName           = "/newsfed.v1.Newsfed/GetSyncJob"
+	Newsfed_ListAuditEntries_FullMethodName     = "/newsfed.v1.Newsfed/ListAuditEntries"
+	Newsfed_ListCollections_FullMethodName      = "/newsfed.v1.Newsfed/ListCollections"
+	Newsfed_CreateCollection_FullMethodName     = "/newsfed.v1.Newsfed/CreateCollection"
+	Newsfed_GetCollection_FullMethodName        = "/newsfed.v1.Newsfed/GetCollection"
+	Newsfed_UpdateCollection_FullMethodName     = "/newsfed.v1.Newsfed/UpdateCollection"
+	Newsfed_DeleteCollection_FullMethodName     = "/newsfed.v1.Newsfed/DeleteCollection"
+	Newsfed_AddCollectionItem_FullMethodName    = "/newsfed.v1.Newsfed/AddCollectionItem"
+	Newsfed_RemoveCollectionItem_FullMethodName = "/newsfed.v1.Newsfed/RemoveCollectionItem"
+	Newsfed_ExportCollection_FullMethodName     = "/newsfed.v1.Newsfed/ExportCollection"
+	Newsfed_WhoAmI_FullMethodName               = "/newsfed.v1.Newsfed/WhoAmI"
+)
+
+// NewsfedClient is the client API for Newsfed service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// The newsfed gRPC API. It mirrors the REST API under /api/v1 and adds
+// read access to news items and sources, for services that would rather use
+// generated clients than HTTP.
+//
+// Calls are made as the default user unless they carry an
+// "authorization: Bearer <token>" metadata entry with a user's API token, in
+// which case item state (read, starred, pinned, and so on) is that user's.
+type NewsfedClient interface {
+	// ListItems returns one page of the news items matching a filter.
+	ListItems(ctx context.Context, in *ListItemsRequest, opts ...grpc.CallOption) (*ListItemsResponse, error)
+	// GetItem returns one news item.
+	GetItem(ctx context.Context, in *GetItemRequest, opts ...grpc.CallOption) (*NewsItem, error)
+	// ListSources returns the sources matching a filter.
+	ListSources(ctx context.Context, in *ListSourcesRequest, opts ...grpc.CallOption) (*ListSourcesResponse, error)
+	// GetSource returns one source.
+	GetSource(ctx context.Context, in *GetSourceRequest, opts ...grpc.CallOption) (*Source, error)
+	// StartSync starts a manual sync in the background, like
+	// POST /api/v1/meta/sync. It fails with ALREADY_EXISTS if a sync is
+	// running.
+	StartSync(ctx context.Context, in *StartSyncRequest, opts ...grpc.CallOption) (*SyncJob, error)
+	// GetSyncJob reports a sync job, like GET /api/v1/meta/sync/{id}.
+	GetSyncJob(ctx context.Context, in *GetSyncJobRequest, opts ...grpc.CallOption) (*SyncJob, error)
+	// ListAuditEntries lists recorded changes, newest first, like
+	// GET /api/v1/meta/audit.
+	ListAuditEntries(ctx context.Context, in *ListAuditEntriesRequest, opts ...grpc.CallOption) (*ListAuditEntriesResponse, error)
+	// The collection calls mirror /api/v1/collections.
+	ListCollections(ctx context.Context, in *ListCollectionsRequest, opts ...grpc.CallOption) (*ListCollectionsResponse, error)
+	CreateCollection(ctx context.Context, in *CreateCollectionRequest, opts ...grpc.CallOption) (*Collection, error)
+	GetCollection(ctx context.Context, in *GetCollectionRequest, opts ...grpc.CallOption) (*Collection, error)
+	UpdateCollection(ctx context.Context, in *UpdateCollectionRequest, opts ...grpc.CallOption) (*Collection, error)
+	DeleteCollection(ctx context.Context, in *DeleteCollectionRequest, opts ...grpc.CallOption) (*DeleteCollectionResponse, error)
+	AddCollectionItem(ctx context.Context, in *AddCollectionItemRequest, opts ...grpc.CallOption) (*Collection, error)
+	RemoveCollectionItem(ctx context.Context, in *RemoveCollectionItemRequest, opts ...grpc.CallOption) (*RemoveCollectionItemResponse, error)
+	ExportCollection(ctx context.Context, in *ExportCollectionRequest, opts ...grpc.CallOption) (*ExportCollectionResponse, error)
+	// WhoAmI reports the user making the call, like GET /api/v1/users/me.
+	WhoAmI(ctx context.Context, in *WhoAmIRequest, opts ...grpc.CallOption) (*WhoAmIResponse, error)
+}
+
+type newsfedClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewNewsfedClient(cc grpc.ClientConnInterface) NewsfedClient {
+	return &newsfedClient{cc}
+}
+
+func (c *newsfedClient) ListItems(ctx context.Context, in *ListItemsRequest, opts ...grpc.CallOption) (*ListItemsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListItemsResponse)
+	err := c.cc.Invoke(ctx, Newsfed_ListItems_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *newsfedClient) GetItem(ctx context.Context, in *GetItemRequest, opts ...grpc.CallOption) (*NewsItem, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(NewsItem)
+	err := c.cc.Invoke(ctx, Newsfed_GetItem_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *newsfedClient) ListSources(ctx context.Context, in *ListSourcesRequest, opts ...grpc.CallOption) (*ListSourcesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListSourcesResponse)
+	err := c.cc.Invoke(ctx, Newsfed_ListSources_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *newsfedClient) GetSource(ctx context.Context, in *GetSourceRequest, opts ...grpc.CallOption) (*Source, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Source)
+	err := c.cc.Invoke(ctx, Newsfed_GetSource_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *newsfedClient) StartSync(ctx context.Context, in *StartSyncRequest, opts ...grpc.CallOption) (*SyncJob, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SyncJob)
+	err := c.cc.Invoke(ctx, Newsfed_StartSync_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *newsfedClient) GetSyncJob(ctx context.Context, in *GetSyncJobRequest, opts ...grpc.CallOption) (*SyncJob, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SyncJob)
+	err := c.cc.Invoke(ctx, Newsfed_GetSyncJob_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *newsfedClient) ListAuditEntries(ctx context.Context, in *ListAuditEntriesRequest, opts ...grpc.CallOption) (*ListAuditEntriesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListAuditEntriesResponse)
+	err := c.cc.Invoke(ctx, Newsfed_ListAuditEntries_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *newsfedClient) ListCollections(ctx context.Context, in *ListCollectionsRequest, opts ...grpc.CallOption) (*ListCollectionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListCollectionsResponse)
+	err := c.cc.Invoke(ctx, Newsfed_ListCollections_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *newsfedClient) CreateCollection(ctx context.Context, in *CreateCollectionRequest, opts ...grpc.CallOption) (*Collection, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Collection)
+	err := c.cc.Invoke(ctx, Newsfed_CreateCollection_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *newsfedClient) GetCollection(ctx context.Context, in *GetCollectionRequest, opts ...grpc.CallOption) (*Collection, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Collection)
+	err := c.cc.Invoke(ctx, Newsfed_GetCollection_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *newsfedClient) UpdateCollection(ctx context.Context, in *UpdateCollectionRequest, opts ...grpc.CallOption) (*Collection, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Collection)
+	err := c.cc.Invoke(ctx, Newsfed_UpdateCollection_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *newsfedClient) DeleteCollection(ctx context.Context, in *DeleteCollectionRequest, opts ...grpc.CallOption) (*DeleteCollectionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteCollectionResponse)
+	err := c.cc.Invoke(ctx, Newsfed_DeleteCollection_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *newsfedClient) AddCollectionItem(ctx context.Context, in *AddCollectionItemRequest, opts ...grpc.CallOption) (*Collection, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Collection)
+	err := c.cc.Invoke(ctx, Newsfed_AddCollectionItem_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *newsfedClient) RemoveCollectionItem(ctx context.Context, in *RemoveCollectionItemRequest, opts ...grpc.CallOption) (*RemoveCollectionItemResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RemoveCollectionItemResponse)
+	err := c.cc.Invoke(ctx, Newsfed_RemoveCollectionItem_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *newsfedClient) ExportCollection(ctx context.Context, in *ExportCollectionRequest, opts ...grpc.CallOption) (*ExportCollectionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ExportCollectionResponse)
+	err := c.cc.Invoke(ctx, Newsfed_ExportCollection_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *newsfedClient) WhoAmI(ctx context.Context, in *WhoAmIRequest, opts ...grpc.CallOption) (*WhoAmIResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WhoAmIResponse)
+	err := c.cc.Invoke(ctx, Newsfed_WhoAmI_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// NewsfedServer is the server API for Newsfed service.
+// All implementations must embed UnimplementedNewsfedServer
+// for forward compatibility.
+//
+// The newsfed gRPC API. It mirrors the REST API under /api/v1 and adds
+// read access to news items and sources, for services that would rather use
+// generated clients than HTTP.
+//
+// Calls are made as the default user unless they carry an
+// "authorization: Bearer <token>" metadata entry with a user's API token, in
+// which case item state (read, starred, pinned, and so on) is that user's.
+type NewsfedServer interface {
+	// ListItems returns one page of the news items matching a filter.
+	ListItems(context.Context, *ListItemsRequest) (*ListItemsResponse, error)
+	// GetItem returns one news item.
+	GetItem(context.Context, *GetItemRequest) (*NewsItem, error)
+	// ListSources returns the sources matching a filter.
+	ListSources(context.Context, *ListSourcesRequest) (*ListSourcesResponse, error)
+	// GetSource returns one source.
+	GetSource(context.Context, *GetSourceRequest) (*Source, error)
+	// StartSync starts a manual sync in the background, like
+	// POST /api/v1/meta/sync. It fails with ALREADY_EXISTS if a sync is
+	// running.
+	StartSync(context.Context, *StartSyncRequest) (*SyncJob, error)
+	// GetSyncJob reports a sync job, like GET /api/v1/meta/sync/{id}.
+	GetSyncJob(context.Context, *GetSyncJobRequest) (*SyncJob, error)
+	// ListAuditEntries lists recorded changes, newest first, like
+	// GET /api/v1/meta/audit.
+	ListAuditEntries(context.Context, *ListAuditEntriesRequest) (*ListAuditEntriesResponse, error)
+	// The collection calls mirror /api/v1/collections.
+	ListCollections(context.Context, *ListCollectionsRequest) (*ListCollectionsResponse, error)
+	CreateCollection(context.Context, *CreateCollectionRequest) (*Collection, error)
+	GetCollection(context.Context, *GetCollectionRequest) (*Collection, error)
+	UpdateCollection(context.Context, *UpdateCollectionRequest) (*Collection, error)
+	DeleteCollection(context.Context, *DeleteCollectionRequest) (*DeleteCollectionResponse, error)
+	AddCollectionItem(context.Context, *AddCollectionItemRequest) (*Collection, error)
+	RemoveCollectionItem(context.Context, *RemoveCollectionItemRequest) (*RemoveCollectionItemResponse, error)
+	ExportCollection(context.Context, *ExportCollectionRequest) (*ExportCollectionResponse, error)
+	// WhoAmI reports the user making the call, like GET /api/v1/users/me.
+	WhoAmI(context.Context, *WhoAmIRequest) (*WhoAmIResponse, error)
+	mustEmbedUnimplementedNewsfedServer()
+}
+
+// UnimplementedNewsfedServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedNewsfedServer struct{}
+
+func (UnimplementedNewsfedServer) ListItems(context.Context, *ListItemsRequest) (*ListItemsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListItems not implemented")
+}
+func (UnimplementedNewsfedServer) GetItem(context.Context, *GetItemRequest) (*NewsItem, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetItem not implemented")
+}
+func (UnimplementedNewsfedServer) ListSources(context.Context, *ListSourcesRequest) (*ListSourcesResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListSources not implemented")
+}
+func (UnimplementedNewsfedServer) GetSource(context.Context, *GetSourceRequest) (*Source, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetSource not implemented")
+}
+func (UnimplementedNewsfedServer) StartSync(context.Context, *StartSyncRequest) (*SyncJob, error) {
+	return nil, status.Error(codes.Unimplemented, "method StartSync not implemented")
+}
+func (UnimplementedNewsfedServer) GetSyncJob(context.Context, *GetSyncJobRequest) (*SyncJob, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetSyncJob not implemented")
+}
+func (UnimplementedNewsfedServer) ListAuditEntries(context.Context, *ListAuditEntriesRequest) (*ListAuditEntriesResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListAuditEntries not implemented")
+}
+func (UnimplementedNewsfedServer) ListCollections(context.Context, *ListCollectionsRequest) (*ListCollectionsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListCollections not implemented")
+}
+func (UnimplementedNewsfedServer) CreateCollection(context.Context, *CreateCollectionRequest) (*Collection, error) {
+	return nil, status.Error(codes.Unimplemented, "method CreateCollection not implemented")
+}
+func (UnimplementedNewsfedServer) GetCollection(context.Context, *GetCollectionRequest) (*Collection, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetCollection not implemented")
+}
+func (UnimplementedNewsfedServer) UpdateCollection(context.Context, *UpdateCollectionRequest) (*Collection, error) {
+	return nil, status.Error(codes.Unimplemented, "method UpdateCollection not implemented")
+}
+func (UnimplementedNewsfedServer) DeleteCollection(context.Context, *DeleteCollectionRequest) (*DeleteCollectionResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method DeleteCollection not implemented")
+}
+func (UnimplementedNewsfedServer) AddCollectionItem(context.Context, *AddCollectionItemRequest) (*Collection, error) {
+	return nil, status.Error(codes.Unimplemented, "method AddCollectionItem not implemented")
+}
+func (UnimplementedNewsfedServer) RemoveCollectionItem(context.Context, *RemoveCollectionItemRequest) (*RemoveCollectionItemResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method RemoveCollectionItem not implemented")
+}
+func (UnimplementedNewsfedServer) ExportCollection(context.Context, *ExportCollectionRequest) (*ExportCollectionResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ExportCollection not implemented")
+}
+func (UnimplementedNewsfedServer) WhoAmI(context.Context, *WhoAmIRequest) (*WhoAmIResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method WhoAmI not implemented")
+}
+func (UnimplementedNewsfedServer) mustEmbedUnimplementedNewsfedServer() {}
+func (UnimplementedNewsfedServer) testEmbeddedByValue()                 {}
+
+// UnsafeNewsfedServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to NewsfedServer will
+// result in compilation errors.
+type UnsafeNewsfedServer interface {
+	mustEmbedUnimplementedNewsfedServer()
+}
+
+func RegisterNewsfedServer(s grpc.ServiceRegistrar, srv NewsfedServer) {
+	// If the following call panics, it indicates UnimplementedNewsfedServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Newsfed_ServiceDesc, srv)
+}
+
+func _Newsfed_ListItems_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListItemsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NewsfedServer).ListItems(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Newsfed_ListItems_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NewsfedServer).ListItems(ctx, req.(*ListItemsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Newsfed_GetItem_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetItemRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NewsfedServer).GetItem(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Newsfed_GetItem_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NewsfedServer).GetItem(ctx, req.(*GetItemRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Newsfed_ListSources_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSourcesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NewsfedServer).ListSources(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Newsfed_ListSources_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NewsfedServer).ListSources(ctx, req.(*ListSourcesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Newsfed_GetSource_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSourceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NewsfedServer).GetSource(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Newsfed_GetSource_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NewsfedServer).GetSource(ctx, req.(*GetSourceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Newsfed_StartSync_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StartSyncRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NewsfedServer).StartSync(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Newsfed_StartSync_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NewsfedServer).StartSync(ctx, req.(*StartSyncRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Newsfed_GetSyncJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSyncJobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NewsfedServer).GetSyncJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Newsfed_GetSyncJob_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NewsfedServer).GetSyncJob(ctx, req.(*GetSyncJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Newsfed_ListAuditEntries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAuditEntriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NewsfedServer).ListAuditEntries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Newsfed_ListAuditEntries_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NewsfedServer).ListAuditEntries(ctx, req.(*ListAuditEntriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Newsfed_ListCollections_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListCollectionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NewsfedServer).ListCollections(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Newsfed_ListCollections_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NewsfedServer).ListCollections(ctx, req.(*ListCollectionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Newsfed_CreateCollection_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateCollectionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NewsfedServer).CreateCollection(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Newsfed_CreateCollection_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NewsfedServer).CreateCollection(ctx, req.(*CreateCollectionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Newsfed_GetCollection_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCollectionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NewsfedServer).GetCollection(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Newsfed_GetCollection_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NewsfedServer).GetCollection(ctx, req.(*GetCollectionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Newsfed_UpdateCollection_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateCollectionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NewsfedServer).UpdateCollection(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Newsfed_UpdateCollection_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NewsfedServer).UpdateCollection(ctx, req.(*UpdateCollectionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Newsfed_DeleteCollection_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteCollectionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NewsfedServer).DeleteCollection(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Newsfed_DeleteCollection_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NewsfedServer).DeleteCollection(ctx, req.(*DeleteCollectionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Newsfed_AddCollectionItem_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddCollectionItemRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NewsfedServer).AddCollectionItem(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Newsfed_AddCollectionItem_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NewsfedServer).AddCollectionItem(ctx, req.(*AddCollectionItemRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Newsfed_RemoveCollectionItem_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveCollectionItemRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NewsfedServer).RemoveCollectionItem(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Newsfed_RemoveCollectionItem_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NewsfedServer).RemoveCollectionItem(ctx, req.(*RemoveCollectionItemRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Newsfed_ExportCollection_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExportCollectionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NewsfedServer).ExportCollection(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Newsfed_ExportCollection_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NewsfedServer).ExportCollection(ctx, req.(*ExportCollectionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Newsfed_WhoAmI_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WhoAmIRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NewsfedServer).WhoAmI(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Newsfed_WhoAmI_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NewsfedServer).WhoAmI(ctx, req.(*WhoAmIRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Newsfed_ServiceDesc is the grpc.ServiceDesc for Newsfed service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Newsfed_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "newsfed.v1.Newsfed",
+	HandlerType: (*NewsfedServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListItems",
+			Handler:    _Newsfed_ListItems_Handler,
+		},
+		{
+			MethodName: "GetItem",
+			Handler:    _Newsfed_GetItem_Handler,
+		},
+		{
+			MethodName: "ListSources",
+			Handler:    _Newsfed_ListSources_Handler,
+		},
+		{
+			MethodName: "GetSource",
+			Handler:    _Newsfed_GetSource_Handler,
+		},
+		{
+			MethodName: "StartSync",
+			Handler:    _Newsfed_StartSync_Handler,
+		},
+		{
+			MethodName: "GetSyncJob",
+			Handler:    _Newsfed_GetSyncJob_Handler,
+		},
+		{
+			MethodName: "ListAuditEntries",
+			Handler:    _Newsfed_ListAuditEntries_Handler,
+		},
+		{
+			MethodName: "ListCollections",
+			Handler:    _Newsfed_ListCollections_Handler,
+		},
+		{
+			MethodName: "CreateCollection",
+			Handler:    _Newsfed_CreateCollection_Handler,
+		},
+		{
+			MethodName: "GetCollection",
+			Handler:    _Newsfed_GetCollection_Handler,
+		},
+		{
+			MethodName: "UpdateCollection",
+			Handler:    _Newsfed_UpdateCollection_Handler,
+		},
+		{
+			MethodName: "DeleteCollection",
+			Handler:    _Newsfed_DeleteCollection_Handler,
+		},
+		{
+			MethodName: "AddCollectionItem",
+			Handler:    _Newsfed_AddCollectionItem_Handler,
+		},
+		{
+			MethodName: "RemoveCollectionItem",
+			Handler:    _Newsfed_RemoveCollectionItem_Handler,
+		},
+		{
+			MethodName: "ExportCollection",
+			Handler:    _Newsfed_ExportCollection_Handler,
+		},
+		{
+			MethodName: "WhoAmI",
+			Handler:    _Newsfed_WhoAmI_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "newsfed.proto",
+}
@@ -0,0 +1,63 @@
+// Package httpjson writes the JSON responses of newsfed's HTTP APIs, so that
+// every endpoint answers, and reports errors, the same way.
+package httpjson
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// Write writes v as a JSON response with the given status. Responses hold
+// the feed's current state, so they aren't to be cached.
+func Write(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// Error writes {"error": message} with the given status.
+func Error(w http.ResponseWriter, status int, message string) {
+	Write(w, status, map[string]string{"error": message})
+}
+
+// WriteCached writes v as a 200 OK JSON response with an ETag derived from
+// its encoding, or 304 Not Modified without a body if r's If-None-Match
+// already names that ETag. Clients may keep the response but must
+// revalidate it before each use, so that polling an unchanged resource
+// costs a round trip rather than a transfer.
+func WriteCached(w http.ResponseWriter, r *http.Request, v any) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	body = append(body, '\n')
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	if matchesETag(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(body)
+}
+
+// matchesETag reports whether an If-None-Match header names etag, comparing
+// weakly as RFC 9110 section 13.1.2 asks.
+func matchesETag(header, etag string) bool {
+	for tag := range strings.SplitSeq(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+package httpjson
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestError verifies that errors are written as {"error": message} with the
+// given status and uncached
+func TestError(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Error(rec, http.StatusNotFound, "item not found")
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+	assert.JSONEq(t, `{"error": "item not found"}`, rec.Body.String())
+}
+
+// TestWriteCached verifies that a response is sent with an ETag, that a
+// request naming it gets 304 without a body, and that the ETag changes with
+// the response
+func TestWriteCached(t *testing.T) {
+	get := func(v any, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/", nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		WriteCached(rec, req, v)
+		return rec
+	}
+
+	rec := get(map[string]int{"total": 1}, "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"total": 1}`, rec.Body.String())
+	etag := rec.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	for _, header := range []string{etag, "W/" + etag, `"other", ` + etag, "*"} {
+		rec = get(map[string]int{"total": 1}, header)
+		assert.Equal(t, http.StatusNotModified, rec.Code, header)
+		assert.Empty(t, rec.Body.String(), header)
+	}
+
+	rec = get(map[string]int{"total": 2}, etag)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotEqual(t, etag, rec.Header().Get("ETag"))
+}
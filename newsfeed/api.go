@@ -0,0 +1,542 @@
+package newsfeed
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pevans/newsfed/internal/httpjson"
+)
+
+// ParseListQuery reads the filters of ListOptions from URL query
+// parameters named as in the gRPC ListItems request: pinned, unread,
+// starred, archived, snoozed, and include_pinned take true or false;
+// publisher, category, and language take text; source_id takes a source ID;
+// metadata takes key=value and may be repeated; and discovered_since takes
+// an RFC 3339 time.
+func ParseListQuery(query url.Values) (ListOptions, error) {
+	var opts ListOptions
+	flags := []struct {
+		name string
+		dest *bool
+	}{
+		{"unread", &opts.Unread},
+		{"starred", &opts.Starred},
+		{"archived", &opts.Archived},
+		{"snoozed", &opts.Snoozed},
+		{"include_pinned", &opts.IncludePinned},
+	}
+	for _, flag := range flags {
+		if value := query.Get(flag.name); value != "" {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return opts, fmt.Errorf("%s must be true or false", flag.name)
+			}
+			*flag.dest = b
+		}
+	}
+	if value := query.Get("pinned"); value != "" {
+		pinned, err := strconv.ParseBool(value)
+		if err != nil {
+			return opts, fmt.Errorf("pinned must be true or false")
+		}
+		opts.Pinned = &pinned
+	}
+
+	opts.Publisher = query.Get("publisher")
+	opts.Category = query.Get("category")
+	// lang matches newsfed list --lang; language, the stored field's name,
+	// is accepted too
+	opts.Language = query.Get("lang")
+	if opts.Language == "" {
+		opts.Language = query.Get("language")
+	}
+	if value := query.Get("source_id"); value != "" {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return opts, fmt.Errorf("source_id must be a source ID")
+		}
+		opts.SourceID = &id
+	}
+	for _, pair := range query["metadata"] {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return opts, fmt.Errorf("metadata must be given as key=value")
+		}
+		if opts.Metadata == nil {
+			opts.Metadata = map[string]string{}
+		}
+		opts.Metadata[key] = value
+	}
+	if value := query.Get("discovered_since"); value != "" {
+		since, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return opts, fmt.Errorf("discovered_since must be an RFC 3339 time")
+		}
+		opts.DiscoveredSince = &since
+	}
+	return opts, nil
+}
+
+// defaultAPIListLimit is how many items ListHandler returns when no limit
+// is given, and maxAPIListLimit the most it returns at once.
+const (
+	defaultAPIListLimit = 50
+	maxAPIListLimit     = 500
+)
+
+// listResponse is the body of a response to GET /api/v1/items.
+type listResponse struct {
+	Items      []NewsItem `json:"items"`
+	Total      int        `json:"total"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+	// Deleted holds the items deleted since deleted_since, if it was given
+	Deleted []Tombstone `json:"deleted,omitempty"`
+}
+
+// ListHandler returns one page of the matching items for GET /api/v1/items.
+// The query parameters filter the items as ParseListQuery describes; sort
+// takes a ListOptions sort, and limit (default 50, at most 500), offset, and
+// cursor page through the results. With deleted_since, an RFC 3339 time,
+// the response also lists the items deleted since then, so that a client
+// caching the feed can remove them. A malformed parameter responds 400. The
+// response has an ETag, and a request whose If-None-Match names it responds
+// 304 Not Modified, so that polling clients don't transfer an unchanged
+// page again.
+func (nf *NewsFeed) ListHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		opts, err := ParseListQuery(query)
+		if err != nil {
+			httpjson.Error(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		opts.SortBy = query.Get("sort")
+		opts.Cursor = query.Get("cursor")
+		opts.Limit = defaultAPIListLimit
+		if value := query.Get("limit"); value != "" {
+			if opts.Limit, err = strconv.Atoi(value); err != nil || opts.Limit < 1 || opts.Limit > maxAPIListLimit {
+				httpjson.Error(w, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxAPIListLimit))
+				return
+			}
+		}
+		if value := query.Get("offset"); value != "" {
+			if opts.Offset, err = strconv.Atoi(value); err != nil {
+				httpjson.Error(w, http.StatusBadRequest, "offset must be a number")
+				return
+			}
+		}
+		var deletedSince *time.Time
+		if value := query.Get("deleted_since"); value != "" {
+			since, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				httpjson.Error(w, http.StatusBadRequest, "deleted_since must be an RFC 3339 time")
+				return
+			}
+			deletedSince = &since
+		}
+		if err := opts.Validate(); err != nil {
+			httpjson.Error(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		result, err := nf.Query(opts)
+		if err != nil {
+			httpjson.Error(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		resp := listResponse{Items: result.Items, Total: result.Total, NextCursor: result.NextCursor}
+		if resp.Items == nil {
+			resp.Items = []NewsItem{}
+		}
+		if deletedSince != nil {
+			if resp.Deleted, err = nf.Tombstones(*deletedSince); err != nil {
+				httpjson.Error(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+		}
+		httpjson.WriteCached(w, r, resp)
+	})
+}
+
+// GetHandler returns an item for GET /api/v1/items/{id}, or 404 for an
+// unknown item. Like ListHandler, it responds with an ETag and answers a
+// matching If-None-Match with 304 Not Modified.
+func (nf *NewsFeed) GetHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			httpjson.Error(w, http.StatusBadRequest, "invalid item ID")
+			return
+		}
+		item, err := nf.Get(id)
+		if err != nil {
+			httpjson.Error(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if item == nil {
+			httpjson.Error(w, http.StatusNotFound, ErrItemNotFound.Error())
+			return
+		}
+		httpjson.WriteCached(w, r, item)
+	})
+}
+
+// FeedHandler publishes the matching items as an RSS 2.0 feed, or an Atom
+// feed with format=atom, for GET /api/v1/feed.xml, so that other feed
+// readers can subscribe to the feed. The query parameters filter the items
+// as ParseListQuery describes, limit (default 50, at most 500) caps how
+// many are included, newest first, and title, link, and description
+// describe the feed. A malformed parameter responds 400.
+func (nf *NewsFeed) FeedHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = ExportRSS
+		}
+		if format != ExportRSS && format != ExportAtom {
+			httpjson.Error(w, http.StatusBadRequest, "format must be rss or atom")
+			return
+		}
+		nf.serveExport(w, r, format, ExportInfo{})
+	})
+}
+
+// ExportHandler writes the matching items for GET /api/v1/items/export, as
+// JSON unless format names another export format, so that scripts can
+// collect items without paging through the list. fields selects the fields
+// of JSON, CSV, and Markdown records as ParseExportFields reads them, and
+// the other parameters are those of FeedHandler. A malformed parameter
+// responds 400.
+func (nf *NewsFeed) ExportHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		format := query.Get("format")
+		if format == "" {
+			format = ExportJSON
+		}
+		if _, ok := ExportContentType(format); !ok {
+			httpjson.Error(w, http.StatusBadRequest, "invalid format: "+format)
+			return
+		}
+		var info ExportInfo
+		if value := query.Get("fields"); value != "" {
+			if format == ExportRSS || format == ExportAtom {
+				httpjson.Error(w, http.StatusBadRequest, "fields can only be selected for json, csv, and markdown exports")
+				return
+			}
+			fields, err := ParseExportFields(value)
+			if err != nil {
+				httpjson.Error(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			info.Fields = fields
+		}
+		nf.serveExport(w, r, format, info)
+	})
+}
+
+// serveExport writes the items matching the request's query with Export,
+// rendered first so that a failure can still be reported as JSON.
+func (nf *NewsFeed) serveExport(w http.ResponseWriter, r *http.Request, format string, info ExportInfo) {
+	query := r.URL.Query()
+	opts, err := ParseListQuery(query)
+	if err != nil {
+		httpjson.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	opts.Limit = defaultAPIListLimit
+	if value := query.Get("limit"); value != "" {
+		if opts.Limit, err = strconv.Atoi(value); err != nil || opts.Limit < 1 || opts.Limit > maxAPIListLimit {
+			httpjson.Error(w, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxAPIListLimit))
+			return
+		}
+	}
+	if err := opts.Validate(); err != nil {
+		httpjson.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	info.Title, info.Link, info.Description = query.Get("title"), query.Get("link"), query.Get("description")
+	if info.Title == "" {
+		info.Title = "newsfed"
+	}
+	if info.Description == "" {
+		info.Description = "Items collected by newsfed"
+	}
+
+	result, err := nf.Query(opts)
+	if err != nil {
+		httpjson.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var buf bytes.Buffer
+	if err := Export(&buf, format, info, result.Items); err != nil {
+		httpjson.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	contentType, _ := ExportContentType(format)
+	w.Header().Set("Content-Type", contentType)
+	_, _ = buf.WriteTo(w)
+}
+
+// ChangesHandler returns the changes made to the feed after a cursor, as
+// Changes does, for GET /api/v1/items/changes. since takes the cursor of
+// the last response, or is left out to get a cursor to start from, and
+// limit (default 50, at most 500) caps the changes read. It responds 200 OK
+// with a ChangeSet, 400 for a malformed parameter, and 410 Gone if the
+// changes since the cursor were pruned, when the client must read the whole
+// feed again.
+func (nf *NewsFeed) ChangesHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		limit := defaultAPIListLimit
+		if value := query.Get("limit"); value != "" {
+			var err error
+			if limit, err = strconv.Atoi(value); err != nil || limit < 1 || limit > maxAPIListLimit {
+				httpjson.Error(w, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxAPIListLimit))
+				return
+			}
+		}
+
+		set, err := nf.Changes(query.Get("since"), limit)
+		switch {
+		case errors.Is(err, ErrInvalidCursor):
+			httpjson.Error(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, ErrCursorExpired):
+			httpjson.Error(w, http.StatusGone, err.Error())
+		case errors.Is(err, ErrNoChangeLog):
+			httpjson.Error(w, http.StatusNotImplemented, err.Error())
+		case err != nil:
+			httpjson.Error(w, http.StatusInternalServerError, err.Error())
+		default:
+			httpjson.Write(w, http.StatusOK, set)
+		}
+	})
+}
+
+// FacetsHandler reports the counts of matching items by publisher,
+// category, source, and day for GET /api/v1/items/facets. The query
+// parameters filter the items as ParseListQuery describes.
+func (nf *NewsFeed) FacetsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		opts, err := ParseListQuery(r.URL.Query())
+		if err != nil {
+			httpjson.Error(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		facets, err := nf.Facets(opts)
+		if err != nil {
+			httpjson.Error(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		httpjson.Write(w, http.StatusOK, facets)
+	})
+}
+
+// OpenHandler records that an item was opened, as MarkOpened does, and
+// redirects to its URL with 302 Found for GET /api/v1/items/{id}/open. An
+// unknown item responds 404, and an item whose URL isn't an http or https
+// link 422.
+func (nf *NewsFeed) OpenHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			httpjson.Error(w, http.StatusBadRequest, "invalid item ID")
+			return
+		}
+		item, err := nf.Get(id)
+		if err != nil {
+			httpjson.Error(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if item == nil {
+			httpjson.Error(w, http.StatusNotFound, ErrItemNotFound.Error())
+			return
+		}
+		if u, err := url.Parse(item.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			httpjson.Error(w, http.StatusUnprocessableEntity, "item URL is not an http or https link")
+			return
+		}
+
+		if _, err := nf.MarkOpened(id); err != nil {
+			httpjson.Error(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		http.Redirect(w, r, item.URL, http.StatusFound)
+	})
+}
+
+// PatchHandler changes an item's state, as Patch does, for PATCH
+// /api/v1/items/{id}, from the JSON of an ItemPatch such as {"version": 3,
+// "pinned": true}. It responds 200 OK with the item as saved, 404 for an
+// unknown item, and 409 Conflict if the item was changed since the given
+// version was read, or while it was being patched; the client should read
+// the item again and retry.
+func (nf *NewsFeed) PatchHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			httpjson.Error(w, http.StatusBadRequest, "invalid item ID")
+			return
+		}
+		var patch ItemPatch
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&patch); err != nil {
+			httpjson.Error(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+			return
+		}
+
+		item, err := nf.Patch(id, patch)
+		switch {
+		case errors.Is(err, ErrItemNotFound):
+			httpjson.Error(w, http.StatusNotFound, err.Error())
+		case errors.Is(err, ErrVersionConflict):
+			httpjson.Error(w, http.StatusConflict, err.Error())
+		case err != nil:
+			httpjson.Error(w, http.StatusInternalServerError, err.Error())
+		default:
+			httpjson.Write(w, http.StatusOK, item)
+		}
+	})
+}
+
+// CreateHandler adds an item entered by hand, as Create does, for POST
+// /api/v1/items, from the JSON of a NewsItem such as {"url": "...",
+// "title": "..."}. It responds 201 Created with the item as stored, 400 if
+// the item is invalid, and 409 Conflict if the feed already has its URL.
+func (nf *NewsFeed) CreateHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var item NewsItem
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&item); err != nil {
+			httpjson.Error(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+			return
+		}
+
+		created, err := nf.Create(item)
+		switch {
+		case errors.Is(err, ErrInvalidItem):
+			httpjson.Error(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, ErrDuplicateURL):
+			httpjson.Error(w, http.StatusConflict, err.Error())
+		case err != nil:
+			httpjson.Error(w, http.StatusInternalServerError, err.Error())
+		default:
+			httpjson.Write(w, http.StatusCreated, created)
+		}
+	})
+}
+
+// EditHandler changes an item's title, summary, or category, as Edit does,
+// for PUT /api/v1/items/{id}, from the JSON of an ItemEdit such as
+// {"title": "...", "category": "golang"}. It responds 200 OK with the item
+// as saved, 400 if the edit is invalid, and 404 for an unknown item.
+func (nf *NewsFeed) EditHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			httpjson.Error(w, http.StatusBadRequest, "invalid item ID")
+			return
+		}
+		var edit ItemEdit
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&edit); err != nil {
+			httpjson.Error(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+			return
+		}
+
+		item, err := nf.Edit(id, edit)
+		switch {
+		case errors.Is(err, ErrInvalidItem):
+			httpjson.Error(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, ErrItemNotFound):
+			httpjson.Error(w, http.StatusNotFound, err.Error())
+		case err != nil:
+			httpjson.Error(w, http.StatusInternalServerError, err.Error())
+		default:
+			httpjson.Write(w, http.StatusOK, item)
+		}
+	})
+}
+
+// DeleteHandler removes an item from the feed, as Delete does, for DELETE
+// /api/v1/items/{id}. It responds 204 No Content, or 404 for an unknown
+// item.
+func (nf *NewsFeed) DeleteHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			httpjson.Error(w, http.StatusBadRequest, "invalid item ID")
+			return
+		}
+
+		err = nf.Delete(id)
+		switch {
+		case errors.Is(err, ErrItemNotFound):
+			httpjson.Error(w, http.StatusNotFound, err.Error())
+		case err != nil:
+			httpjson.Error(w, http.StatusInternalServerError, err.Error())
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+}
+
+// batchRequest is the body of a request to create a batch of items.
+type batchRequest struct {
+	Items  []NewsItem `json:"items"`
+	Atomic bool       `json:"atomic"`
+}
+
+// batchResponse reports the outcome of creating a batch of items.
+type batchResponse struct {
+	Created int           `json:"created"`
+	Failed  int           `json:"failed"`
+	Results []BatchResult `json:"results"`
+}
+
+// BatchHandler creates items for POST /api/v1/items/batch, as CreateBatch
+// does, from {"items": [...], "atomic": false}, where each item is given as
+// the JSON of a NewsItem. It responds 200 OK with the number of items
+// created and failed and each item's result, even if some failed, and 413
+// if given more than MaxBatchSize items.
+func (nf *NewsFeed) BatchHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req batchRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 32<<20)).Decode(&req); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				httpjson.Error(w, http.StatusRequestEntityTooLarge, "request body too large")
+				return
+			}
+			httpjson.Error(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+			return
+		}
+
+		results, err := nf.CreateBatch(req.Items, req.Atomic)
+		if errors.Is(err, ErrBatchTooLarge) {
+			httpjson.Error(w, http.StatusRequestEntityTooLarge, err.Error())
+			return
+		}
+		if err != nil {
+			httpjson.Error(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		resp := batchResponse{Results: results}
+		for _, result := range results {
+			if result.ID != nil {
+				resp.Created++
+			} else {
+				resp.Failed++
+			}
+		}
+		httpjson.Write(w, http.StatusOK, resp)
+	})
+}
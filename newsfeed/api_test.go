@@ -0,0 +1,484 @@
+package newsfeed
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseListQuery verifies that list filters are read from query
+// parameters and that malformed values are rejected
+func TestParseListQuery(t *testing.T) {
+	opts, err := ParseListQuery(url.Values{
+		"pinned":           {"false"},
+		"unread":           {"true"},
+		"publisher":        {"go"},
+		"category":         {"golang"},
+		"source_id":        {"550e8400-e29b-41d4-a716-446655440000"},
+		"metadata":         {"kind=story", "score=40"},
+		"discovered_since": {"2024-03-01T00:00:00Z"},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, opts.Pinned)
+	assert.False(t, *opts.Pinned)
+	assert.True(t, opts.Unread)
+	assert.False(t, opts.Starred)
+	assert.Equal(t, "go", opts.Publisher)
+	assert.Equal(t, "golang", opts.Category)
+	require.NotNil(t, opts.SourceID)
+	assert.Equal(t, "550e8400-e29b-41d4-a716-446655440000", opts.SourceID.String())
+	assert.Equal(t, map[string]string{"kind": "story", "score": "40"}, opts.Metadata)
+	require.NotNil(t, opts.DiscoveredSince)
+	assert.Equal(t, time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC), *opts.DiscoveredSince)
+
+	tests := []struct {
+		name  string
+		query url.Values
+	}{
+		{"bad flag", url.Values{"starred": {"maybe"}}},
+		{"bad pinned", url.Values{"pinned": {"yes please"}}},
+		{"metadata without value", url.Values{"metadata": {"kind"}}},
+		{"bad time", url.Values{"discovered_since": {"yesterday"}}},
+		{"bad source", url.Values{"source_id": {"feed-1"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseListQuery(tt.query)
+			assert.Error(t, err)
+		})
+	}
+}
+
+// TestListHandler verifies that the list endpoint responds with a page of
+// the filtered items, and 400 for malformed parameters
+func TestListHandler(t *testing.T) {
+	feed := NewMemoryNewsFeed()
+	sourceID := uuid.New()
+	for i := range 3 {
+		item := createTestItem(fmt.Sprintf("item-%d", i))
+		item.SourceID = &sourceID
+		require.NoError(t, feed.Add(item))
+	}
+	require.NoError(t, feed.Add(createTestItem("hand-added")))
+
+	rec := httptest.NewRecorder()
+	feed.ListHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/items?source_id="+sourceID.String()+"&limit=2", nil))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var resp struct {
+		Items      []NewsItem `json:"items"`
+		Total      int        `json:"total"`
+		NextCursor string     `json:"next_cursor"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, 3, resp.Total)
+	assert.Len(t, resp.Items, 2)
+	assert.NotEmpty(t, resp.NextCursor)
+	for _, item := range resp.Items {
+		assert.Equal(t, sourceID, *item.SourceID)
+	}
+
+	// Deletions are listed only when asked for
+	assert.NotContains(t, rec.Body.String(), `"deleted"`)
+	since := time.Now().UTC().Add(-time.Minute)
+	gone := resp.Items[0].ID
+	require.NoError(t, feed.Delete(gone))
+	rec = httptest.NewRecorder()
+	feed.ListHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/items?deleted_since="+since.Format(time.RFC3339), nil))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var withDeleted struct {
+		Deleted []Tombstone `json:"deleted"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &withDeleted))
+	require.Len(t, withDeleted.Deleted, 1)
+	assert.Equal(t, gone, withDeleted.Deleted[0].ID)
+
+	for _, query := range []string{"limit=0", "limit=many", "sort=popularity", "source_id=feed-1", "offset=-1", "deleted_since=yesterday"} {
+		rec = httptest.NewRecorder()
+		feed.ListHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/items?"+query, nil))
+		assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+}
+
+// TestListHandler_Language verifies that items are filtered by language
+// given as lang, or as language
+func TestListHandler_Language(t *testing.T) {
+	feed := NewMemoryNewsFeed()
+	for _, lang := range []string{"en", "de", "de"} {
+		item := createTestItem("in " + lang)
+		item.Language = lang
+		require.NoError(t, feed.Add(item))
+	}
+
+	for _, param := range []string{"lang", "language"} {
+		rec := httptest.NewRecorder()
+		feed.ListHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/items?"+param+"=de", nil))
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var resp struct {
+			Items []NewsItem `json:"items"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.Len(t, resp.Items, 2, param)
+		for _, item := range resp.Items {
+			assert.Equal(t, "de", item.Language)
+		}
+	}
+}
+
+// TestGetHandler_ETag verifies that an item and the list are sent with
+// ETags, that requests naming them get 304 Not Modified until the feed
+// changes, and that unknown items are refused
+func TestGetHandler_ETag(t *testing.T) {
+	feed := NewMemoryNewsFeed()
+	item := createTestItem("item")
+	require.NoError(t, feed.Add(item))
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /api/v1/items", feed.ListHandler())
+	mux.Handle("GET /api/v1/items/{id}", feed.GetHandler())
+	get := func(path, etag string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for _, path := range []string{"/api/v1/items/" + item.ID.String(), "/api/v1/items"} {
+		rec := get(path, "")
+		require.Equal(t, http.StatusOK, rec.Code, path)
+		etag := rec.Header().Get("ETag")
+		require.NotEmpty(t, etag, path)
+		assert.Equal(t, http.StatusNotModified, get(path, etag).Code, path)
+	}
+
+	itemPath := "/api/v1/items/" + item.ID.String()
+	etag := get(itemPath, "").Header().Get("ETag")
+	pinned := true
+	_, err := feed.Patch(item.ID, ItemPatch{Pinned: &pinned})
+	require.NoError(t, err)
+	rec := get(itemPath, etag)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var got NewsItem
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+	assert.NotNil(t, got.PinnedAt)
+
+	assert.Equal(t, http.StatusNotFound, get("/api/v1/items/"+uuid.NewString(), "").Code)
+	assert.Equal(t, http.StatusBadRequest, get("/api/v1/items/not-an-id", "").Code)
+}
+
+// TestChangesHandler verifies that a client can take a cursor, follow the
+// changes made after it, and is told to start over once they are pruned
+func TestChangesHandler(t *testing.T) {
+	feed := NewMemoryNewsFeed()
+	get := func(query string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		feed.ChangesHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/items/changes?"+query, nil))
+		return rec
+	}
+	decode := func(rec *httptest.ResponseRecorder) ChangeSet {
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var set ChangeSet
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &set))
+		return set
+	}
+
+	start := decode(get(""))
+	assert.Empty(t, start.Changes)
+
+	item := createTestItem("item")
+	require.NoError(t, feed.Add(item))
+	set := decode(get("since=" + start.Cursor))
+	require.Len(t, set.Changes, 1)
+	assert.Equal(t, ChangeCreated, set.Changes[0].Kind)
+	require.NotNil(t, set.Changes[0].Item)
+	assert.Equal(t, "item", set.Changes[0].Item.Title)
+
+	require.NoError(t, feed.Delete(item.ID))
+	_, err := feed.PruneChanges(time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusGone, get("since="+start.Cursor).Code)
+
+	for _, query := range []string{"since=bogus", "limit=0", "limit=1000"} {
+		assert.Equal(t, http.StatusBadRequest, get(query).Code, query)
+	}
+}
+
+// TestFacetsHandler verifies that the facets endpoint responds with the
+// counts of the filtered items by publisher and category, and 400 for a
+// malformed filter
+func TestFacetsHandler(t *testing.T) {
+	feed := NewMemoryNewsFeed()
+	goBlog := "Go Blog"
+	golang := "golang"
+	item := createTestItem("item")
+	item.Publisher = &goBlog
+	item.Category = &golang
+	require.NoError(t, feed.Add(item))
+	other := createTestItem("other")
+	other.Category = &golang
+	require.NoError(t, feed.Add(other))
+	read := createTestItem("read")
+	read.Category = &golang
+	now := time.Now()
+	read.ReadAt = &now
+	require.NoError(t, feed.Add(read))
+
+	rec := httptest.NewRecorder()
+	feed.FacetsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/items/facets?unread=true", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var facets Facets
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &facets))
+	assert.Equal(t, 2, facets.Total)
+	assert.Equal(t, []FacetCount{{"Go Blog", 1}, {"Test Publisher", 1}}, facets.Publishers)
+	assert.Equal(t, []FacetCount{{"golang", 2}}, facets.Categories)
+
+	rec = httptest.NewRecorder()
+	feed.FacetsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/items/facets?unread=sometimes", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+// TestOpenHandler verifies that opening an item records the open and
+// redirects to its URL, and that unknown items and non-web links are
+// refused
+func TestOpenHandler(t *testing.T) {
+	feed := NewMemoryNewsFeed()
+	item := createTestItem("item")
+	require.NoError(t, feed.Add(item))
+	script := createTestItem("script")
+	script.URL = "javascript:alert(1)"
+	require.NoError(t, feed.Add(script))
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /api/v1/items/{id}/open", feed.OpenHandler())
+	open := func(id string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/items/"+id+"/open", nil))
+		return rec
+	}
+
+	rec := open(item.ID.String())
+	assert.Equal(t, http.StatusFound, rec.Code)
+	assert.Equal(t, item.URL, rec.Header().Get("Location"))
+	opened, err := feed.Get(item.ID)
+	require.NoError(t, err)
+	assert.NotNil(t, opened.OpenedAt)
+	assert.True(t, opened.IsRead())
+
+	assert.Equal(t, http.StatusNotFound, open(uuid.NewString()).Code)
+	assert.Equal(t, http.StatusBadRequest, open("not-an-id").Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, open(script.ID.String()).Code)
+}
+
+// TestPatchHandler verifies that a patch responds with the saved item, and
+// 409 Conflict when made with a stale version
+func TestPatchHandler(t *testing.T) {
+	feed := NewMemoryNewsFeed()
+	item := createTestItem("item")
+	require.NoError(t, feed.Add(item))
+
+	mux := http.NewServeMux()
+	mux.Handle("PATCH /api/v1/items/{id}", feed.PatchHandler())
+	patch := func(id, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPatch, "/api/v1/items/"+id, strings.NewReader(body)))
+		return rec
+	}
+
+	rec := patch(item.ID.String(), `{"version": 0, "pinned": true}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var saved NewsItem
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &saved))
+	assert.NotNil(t, saved.PinnedAt)
+	assert.Equal(t, 1, saved.Version)
+
+	assert.Equal(t, http.StatusConflict, patch(item.ID.String(), `{"version": 0, "pinned": false}`).Code)
+	assert.Equal(t, http.StatusNotFound, patch(uuid.NewString(), `{"read": true}`).Code)
+	assert.Equal(t, http.StatusBadRequest, patch("not-an-id", `{}`).Code)
+	assert.Equal(t, http.StatusBadRequest, patch(item.ID.String(), `{"pinned": "yes"}`).Code)
+}
+
+// TestCreateHandler verifies that an item is created from its JSON, and
+// that invalid items and URLs already in the feed are refused
+func TestCreateHandler(t *testing.T) {
+	feed := NewMemoryNewsFeed()
+	post := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		feed.CreateHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/items", strings.NewReader(body)))
+		return rec
+	}
+
+	rec := post(`{"url": "https://example.com/saved", "title": "Saved"}`)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	var created NewsItem
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	assert.Equal(t, "Saved", created.Title)
+	got, err := feed.Get(created.ID)
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Equal(t, "https://example.com/saved", got.URL)
+
+	assert.Equal(t, http.StatusConflict, post(`{"url": "https://example.com/saved?utm_source=x"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, post(`{"url": "ftp://example.com/file"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, post(`{"url": 3}`).Code)
+}
+
+// TestEditHandler verifies that an item's title, summary, and category are
+// changed, and that empty titles and unknown items are refused
+func TestEditHandler(t *testing.T) {
+	feed := NewMemoryNewsFeed()
+	item := createTestItem("item")
+	require.NoError(t, feed.Add(item))
+
+	mux := http.NewServeMux()
+	mux.Handle("PUT /api/v1/items/{id}", feed.EditHandler())
+	put := func(id, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/api/v1/items/"+id, strings.NewReader(body)))
+		return rec
+	}
+
+	rec := put(item.ID.String(), `{"title": " Edited ", "summary": "New summary", "category": "golang"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var edited NewsItem
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &edited))
+	assert.Equal(t, "Edited", edited.Title)
+	assert.Equal(t, "New summary", edited.Summary)
+	require.NotNil(t, edited.Category)
+	assert.Equal(t, "golang", *edited.Category)
+
+	rec = put(item.ID.String(), `{"category": ""}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var uncategorized NewsItem
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &uncategorized))
+	assert.Nil(t, uncategorized.Category)
+	assert.Equal(t, "Edited", uncategorized.Title)
+
+	assert.Equal(t, http.StatusBadRequest, put(item.ID.String(), `{"title": "  "}`).Code)
+	assert.Equal(t, http.StatusNotFound, put(uuid.NewString(), `{"title": "x"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, put("not-an-id", `{}`).Code)
+}
+
+// TestDeleteHandler verifies that an item is deleted, and that unknown and
+// malformed IDs are refused
+func TestDeleteHandler(t *testing.T) {
+	feed := NewMemoryNewsFeed()
+	item := createTestItem("item")
+	require.NoError(t, feed.Add(item))
+
+	mux := http.NewServeMux()
+	mux.Handle("DELETE /api/v1/items/{id}", feed.DeleteHandler())
+	del := func(id string) int {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/api/v1/items/"+id, nil))
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusNoContent, del(item.ID.String()))
+	got, err := feed.Get(item.ID)
+	require.NoError(t, err)
+	assert.Nil(t, got)
+
+	assert.Equal(t, http.StatusNotFound, del(item.ID.String()))
+	assert.Equal(t, http.StatusBadRequest, del("not-an-id"))
+}
+
+// TestBatchHandler verifies that a batch responds with each item's result
+// and the counts of created and failed items, and that malformed bodies are
+// refused
+func TestBatchHandler(t *testing.T) {
+	feed := createTestSQLiteFeed(t)
+	post := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		feed.BatchHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/items/batch", strings.NewReader(body)))
+		return rec
+	}
+
+	rec := post(`{"items": [{"url": "https://example.com/a", "title": "A"}, {"url": "mailto:someone@example.com"}]}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp batchResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, 1, resp.Created)
+	assert.Equal(t, 1, resp.Failed)
+	require.Len(t, resp.Results, 2)
+	require.NotNil(t, resp.Results[0].ID)
+	item, err := feed.Get(*resp.Results[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, "A", item.Title)
+
+	assert.Equal(t, http.StatusBadRequest, post(`{"items": `).Code)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, post(`{"items": [`+strings.Repeat(`{},`, MaxBatchSize)+`{}]}`).Code)
+}
+
+// TestFeedHandler verifies that the feed is served as RSS by default or as
+// Atom, with only the matching items, and that a bad format is rejected
+func TestFeedHandler(t *testing.T) {
+	feed := NewMemoryNewsFeed()
+	pinnedItem := createTestItem("Pinned story")
+	now := time.Now()
+	pinnedItem.PinnedAt = &now
+	require.NoError(t, feed.Add(pinnedItem))
+	require.NoError(t, feed.Add(createTestItem("Other story")))
+
+	get := func(query string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		feed.FeedHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/feed.xml"+query, nil))
+		return rec
+	}
+
+	rec := get("?title=Mine")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Header().Get("Content-Type"), "application/rss+xml")
+	assert.Contains(t, rec.Body.String(), "<title>Mine</title>")
+	assert.Contains(t, rec.Body.String(), "Other story")
+
+	rec = get("?format=atom&pinned=true")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Header().Get("Content-Type"), "application/atom+xml")
+	assert.Contains(t, rec.Body.String(), "Pinned story")
+	assert.NotContains(t, rec.Body.String(), "Other story")
+
+	assert.Equal(t, http.StatusBadRequest, get("?format=csv").Code)
+	assert.Equal(t, http.StatusBadRequest, get("?limit=0").Code)
+}
+
+// TestExportHandler verifies that items are exported as JSON by default,
+// with only the selected fields, and that bad formats and fields are
+// rejected
+func TestExportHandler(t *testing.T) {
+	feed := NewMemoryNewsFeed()
+	require.NoError(t, feed.Add(createTestItem("story")))
+
+	get := func(query string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		feed.ExportHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/items/export"+query, nil))
+		return rec
+	}
+
+	rec := get("?fields=title,url")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	var records []map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &records))
+	require.Len(t, records, 1)
+	assert.Equal(t, map[string]any{"title": "story", "url": "http://example.com/story"}, records[0])
+
+	rec = get("?format=csv")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Header().Get("Content-Type"), "text/csv")
+	assert.Contains(t, rec.Body.String(), "story")
+
+	assert.Equal(t, http.StatusBadRequest, get("?format=ical").Code)
+	assert.Equal(t, http.StatusBadRequest, get("?fields=nope").Code)
+	assert.Equal(t, http.StatusBadRequest, get("?format=rss&fields=title").Code)
+}
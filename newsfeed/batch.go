@@ -0,0 +1,146 @@
+package newsfeed
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// MaxBatchSize is the most items CreateBatch takes at once.
+const MaxBatchSize = 1000
+
+// ErrBatchTooLarge is returned by CreateBatch when given more than
+// MaxBatchSize items.
+var ErrBatchTooLarge = fmt.Errorf("a batch may hold at most %d items", MaxBatchSize)
+
+// errBatchRejected is the error of an item that was valid but not saved
+// because another item of an atomic batch failed.
+var errBatchRejected = errors.New("not saved: another item in the batch failed")
+
+// errDuplicateID is the error of an item given an ID already in the feed
+// or the batch.
+var errDuplicateID = errors.New("an item with this ID already exists")
+
+// errNoBatch is returned by a store's AddAll when it can't save items in a
+// single transaction after all.
+var errNoBatch = errors.New("store does not support batch writes")
+
+// batchAdder is implemented by stores that can save several items in a
+// single transaction.
+type batchAdder interface {
+	AddAll(items []NewsItem) error
+}
+
+// BatchResult is the outcome of creating one item of a batch: the ID it was
+// saved with, or why it wasn't saved.
+type BatchResult struct {
+	Index int        `json:"index"` // Position of the item in the batch
+	ID    *uuid.UUID `json:"id,omitempty"`
+	Error string     `json:"error,omitempty"`
+}
+
+// CreateBatch creates each of items as Create does and reports the outcome
+// of each, in the order given. An item that is invalid, or whose URL or ID
+// is already in the feed or in an earlier item of the batch, isn't saved,
+// and its result holds the error; the other items are saved regardless,
+// unless atomic is set, in which case none are saved if any item fails.
+//
+// Stores that can (SQLite, PostgreSQL, and memory) save the items in a
+// single transaction, so that a storage failure saves none of them and is
+// returned as an error. Other stores save the items one at a time and report
+// a failure to save one in its result.
+func (nf *NewsFeed) CreateBatch(items []NewsItem, atomic bool) ([]BatchResult, error) {
+	if len(items) > MaxBatchSize {
+		return nil, ErrBatchTooLarge
+	}
+
+	index, err := nf.DedupIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]BatchResult, len(items))
+	ids := map[uuid.UUID]bool{}
+	var accepted []NewsItem
+	var acceptedAt []int
+	failed := false
+	for i, item := range items {
+		results[i].Index = i
+		given := item.ID
+		item, err := prepareNew(item)
+		if err == nil && index.HasURL(item.URL) {
+			err = ErrDuplicateURL
+		}
+		if err == nil && given != uuid.Nil {
+			// Saving would replace the item that has this ID
+			err = nf.checkNewID(given, ids)
+		}
+		if err != nil {
+			results[i].Error = err.Error()
+			failed = true
+			continue
+		}
+		index.Add(item)
+		ids[item.ID] = true
+		accepted = append(accepted, item)
+		acceptedAt = append(acceptedAt, i)
+	}
+
+	if atomic && failed {
+		for _, i := range acceptedAt {
+			results[i].Error = errBatchRejected.Error()
+		}
+		return results, nil
+	}
+	if len(accepted) == 0 {
+		return results, nil
+	}
+
+	saved := make([]bool, len(accepted))
+	if b, ok := nf.store.(batchAdder); ok {
+		err := b.AddAll(accepted)
+		if err != nil && !errors.Is(err, errNoBatch) {
+			return nil, err
+		}
+		if err == nil {
+			for j := range saved {
+				saved[j] = true
+			}
+		}
+	}
+	for j, item := range accepted {
+		if saved[j] {
+			continue
+		}
+		if err := nf.store.Add(item); err != nil {
+			results[acceptedAt[j]].Error = err.Error()
+			continue
+		}
+		saved[j] = true
+	}
+
+	for j, item := range accepted {
+		if saved[j] {
+			id := item.ID
+			results[acceptedAt[j]].ID = &id
+		}
+	}
+	return results, nil
+}
+
+// checkNewID returns errDuplicateID if the feed or a batch, whose IDs are in
+// batchIDs, already has an item with the given ID.
+func (nf *NewsFeed) checkNewID(id uuid.UUID, batchIDs map[uuid.UUID]bool) error {
+	if batchIDs[id] {
+		return errDuplicateID
+	}
+	existing, err := nf.store.Get(id)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return errDuplicateID
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package newsfeed
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCreateBatch verifies that valid items are saved and that invalid and
+// duplicate items are reported in their results, for each store
+func TestCreateBatch(t *testing.T) {
+	feeds := map[string]func(t *testing.T) *NewsFeed{
+		"directory": func(t *testing.T) *NewsFeed {
+			feed, err := NewNewsFeed(t.TempDir())
+			require.NoError(t, err)
+			return feed
+		},
+		"sqlite": createTestSQLiteFeed,
+		"memory": func(t *testing.T) *NewsFeed { return NewMemoryNewsFeed() },
+	}
+
+	for name, newFeed := range feeds {
+		t.Run(name, func(t *testing.T) {
+			feed := newFeed(t)
+			existing := createTestItem("existing")
+			require.NoError(t, feed.Add(existing))
+
+			results, err := feed.CreateBatch([]NewsItem{
+				{URL: "https://example.com/one", Title: "One"},
+				{URL: "ftp://example.com/two"},
+				{URL: existing.URL},
+				{URL: "https://example.com/one?utm_source=feed"},
+				{ID: existing.ID, URL: "https://example.com/three"},
+				{URL: "https://example.com/four"},
+			}, false)
+			require.NoError(t, err)
+			require.Len(t, results, 6)
+
+			for i, result := range results {
+				assert.Equal(t, i, result.Index)
+			}
+			require.NotNil(t, results[0].ID)
+			assert.Empty(t, results[0].Error)
+			assert.Contains(t, results[1].Error, "absolute http or https URL")
+			assert.Equal(t, ErrDuplicateURL.Error(), results[2].Error)
+			assert.Equal(t, ErrDuplicateURL.Error(), results[3].Error, "duplicates within the batch are caught")
+			assert.Equal(t, errDuplicateID.Error(), results[4].Error)
+			require.NotNil(t, results[5].ID)
+
+			item, err := feed.Get(*results[5].ID)
+			require.NoError(t, err)
+			require.NotNil(t, item)
+			assert.Equal(t, "https://example.com/four", item.Title, "missing titles default to the URL")
+
+			result, err := feed.List()
+			require.NoError(t, err)
+			assert.Len(t, result.Items, 3)
+		})
+	}
+}
+
+// TestCreateBatch_Atomic verifies that an atomic batch saves nothing if any
+// item fails
+func TestCreateBatch_Atomic(t *testing.T) {
+	feed := createTestSQLiteFeed(t)
+
+	results, err := feed.CreateBatch([]NewsItem{
+		{URL: "https://example.com/one"},
+		{URL: "not a url"},
+	}, true)
+	require.NoError(t, err)
+	assert.Nil(t, results[0].ID)
+	assert.Equal(t, errBatchRejected.Error(), results[0].Error)
+	assert.NotEmpty(t, results[1].Error)
+
+	result, err := feed.List()
+	require.NoError(t, err)
+	assert.Empty(t, result.Items)
+}
+
+// TestCreateBatch_SQLiteTransaction verifies that a storage failure on one
+// item of a batch saves none of them in SQLite
+func TestCreateBatch_SQLiteTransaction(t *testing.T) {
+	feed := createTestSQLiteFeed(t)
+	_, err := feed.store.(*sqliteStore).db.Exec(`
+		CREATE TRIGGER fail_insert BEFORE INSERT ON items
+		WHEN NEW.url LIKE '%fail%'
+		BEGIN SELECT RAISE(ABORT, 'insert failed'); END`)
+	require.NoError(t, err)
+
+	_, err = feed.CreateBatch([]NewsItem{
+		{URL: "https://example.com/one"},
+		{URL: "https://example.com/fail"},
+	}, false)
+	require.Error(t, err)
+
+	result, err := feed.List()
+	require.NoError(t, err)
+	assert.Empty(t, result.Items)
+}
+
+// TestCreateBatch_TooLarge verifies that batches over MaxBatchSize are
+// refused
+func TestCreateBatch_TooLarge(t *testing.T) {
+	feed := createTestSQLiteFeed(t)
+
+	items := make([]NewsItem, MaxBatchSize+1)
+	for i := range items {
+		items[i] = NewsItem{ID: uuid.New(), URL: "https://example.com/item"}
+	}
+	_, err := feed.CreateBatch(items, false)
+	assert.ErrorIs(t, err, ErrBatchTooLarge)
+}
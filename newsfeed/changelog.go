@@ -0,0 +1,540 @@
+package newsfeed
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// The kinds of change recorded in a feed's change log.
+const (
+	ChangeCreated = "created" // The item was added
+	ChangeUpdated = "updated" // The item was changed, or replaced as a whole
+	ChangeDeleted = "deleted" // The item was deleted, by hand or by pruning
+)
+
+// ChangeRetention is how long changes are kept in the change log. A cursor
+// older than this has expired, whether or not the changes made since have
+// been pruned yet.
+const ChangeRetention = 30 * 24 * time.Hour
+
+// ErrCursorExpired is returned by Changes when changes made since the cursor
+// are older than ChangeRetention or have been pruned from the change log.
+// The client must read the whole feed again.
+var ErrCursorExpired = errors.New("change cursor has expired")
+
+// ErrNoChangeLog is returned by Changes for a store that doesn't record its
+// changes.
+var ErrNoChangeLog = errors.New("feed does not record changes")
+
+// Tombstone records that an item was deleted, by hand or by pruning, so
+// that clients caching the feed can remove it too.
+type Tombstone struct {
+	ID        uuid.UUID `json:"id"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// FeedChange is an item's latest change in a page of Changes. Item is the
+// item as it is now, and is nil for a deleted item.
+type FeedChange struct {
+	ID        uuid.UUID `json:"id"`
+	Kind      string    `json:"kind"`
+	ChangedAt time.Time `json:"changed_at"`
+	Item      *NewsItem `json:"item,omitempty"`
+}
+
+// ChangeSet is a page of the changes made to a feed since a cursor.
+type ChangeSet struct {
+	Changes []FeedChange `json:"changes"`
+	// Cursor is given to the next call to Changes to continue after this
+	// page
+	Cursor string `json:"cursor"`
+	// More reports whether changes beyond this page are waiting
+	More bool `json:"more"`
+}
+
+// changeRecord is one entry of a store's change log. Seq numbers the
+// entries in the order the changes were made.
+type changeRecord struct {
+	Seq       int64     `json:"seq"`
+	ID        uuid.UUID `json:"id"`
+	Kind      string    `json:"kind"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// changeLogger is implemented by stores that record each item they add,
+// update, or delete.
+type changeLogger interface {
+	// ChangesAfter returns up to limit changes numbered after seq, oldest
+	// first.
+	ChangesAfter(seq int64, limit int) ([]changeRecord, error)
+	// ChangeBounds returns the numbers of the oldest and newest changes
+	// kept, or zeros if none have been recorded.
+	ChangeBounds() (oldest, newest int64, err error)
+	// DeletedSince returns the deletions recorded at or after since, oldest
+	// first.
+	DeletedSince(since time.Time) ([]changeRecord, error)
+	// PruneChanges forgets changes made before the given time, except the
+	// newest, and returns how many were removed.
+	PruneChanges(before time.Time) (int, error)
+}
+
+// Changes returns up to limit of the changes made to the feed after cursor,
+// oldest first, with each item's changes in the page folded into its
+// latest. An empty cursor returns no changes, only a cursor for the newest
+// change: a client starting to sync takes it before reading the whole feed,
+// and then follows the changes made since. It returns ErrInvalidCursor for
+// a cursor it didn't make, and ErrCursorExpired if changes made since the
+// cursor are older than ChangeRetention or have been pruned.
+func (nf *NewsFeed) Changes(cursor string, limit int) (*ChangeSet, error) {
+	log, ok := changeLogOf(nf.store)
+	if !ok {
+		return nil, ErrNoChangeLog
+	}
+	oldest, newest, err := log.ChangeBounds()
+	if err != nil {
+		return nil, err
+	}
+	if cursor == "" {
+		return &ChangeSet{Changes: []FeedChange{}, Cursor: encodeChangeCursor(newest)}, nil
+	}
+
+	after, err := decodeChangeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	// A cursor ahead of the log was made before the log was lost, as when
+	// the feed was restored from a backup
+	if after > newest || (oldest > 0 && after < oldest-1) {
+		return nil, ErrCursorExpired
+	}
+
+	records, err := log.ChangesAfter(after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) > 0 && records[0].ChangedAt.Before(time.Now().Add(-ChangeRetention)) {
+		return nil, ErrCursorExpired
+	}
+	set := &ChangeSet{Changes: []FeedChange{}, Cursor: cursor}
+	if len(records) > limit {
+		records = records[:limit]
+		set.More = true
+	}
+	if len(records) == 0 {
+		return set, nil
+	}
+	set.Cursor = encodeChangeCursor(records[len(records)-1].Seq)
+
+	// Each item is reported once, at its latest change, as it is now. An
+	// item created in the page is reported as created, however it changed
+	// after
+	latest := map[uuid.UUID]int{}
+	created := map[uuid.UUID]bool{}
+	for i, record := range records {
+		latest[record.ID] = i
+		if record.Kind == ChangeCreated {
+			created[record.ID] = true
+		}
+	}
+	for i, record := range records {
+		if latest[record.ID] != i {
+			continue
+		}
+		change := FeedChange{ID: record.ID, Kind: record.Kind, ChangedAt: record.ChangedAt}
+		if record.Kind == ChangeUpdated && created[record.ID] {
+			change.Kind = ChangeCreated
+		}
+		if record.Kind != ChangeDeleted {
+			if change.Item, err = nf.Get(record.ID); err != nil {
+				return nil, err
+			}
+			// Deleted by a change still being made
+			if change.Item == nil {
+				change.Kind = ChangeDeleted
+			}
+		}
+		set.Changes = append(set.Changes, change)
+	}
+	return set, nil
+}
+
+// Tombstones returns the items deleted from the feed at or after since,
+// oldest first. Stores that don't record their changes return none.
+func (nf *NewsFeed) Tombstones(since time.Time) ([]Tombstone, error) {
+	log, ok := changeLogOf(nf.store)
+	if !ok {
+		return nil, nil
+	}
+	records, err := log.DeletedSince(since)
+	if err != nil {
+		return nil, err
+	}
+	tombstones := make([]Tombstone, 0, len(records))
+	for _, record := range records {
+		tombstones = append(tombstones, Tombstone{ID: record.ID, DeletedAt: record.ChangedAt})
+	}
+	return tombstones, nil
+}
+
+// PruneChanges forgets the changes made before the given time, so that the
+// change log doesn't grow without bound. Clients that last synced before
+// then must re-read the whole feed.
+func (nf *NewsFeed) PruneChanges(before time.Time) (int, error) {
+	if log, ok := changeLogOf(nf.store); ok {
+		return log.PruneChanges(before)
+	}
+	return 0, nil
+}
+
+// changeLogOf returns the change log of store. A user's view of a feed
+// shares the change log of the feed it views; changes to a user's state are
+// logged as updates to the item.
+func changeLogOf(store Store) (changeLogger, bool) {
+	if user, ok := store.(*userStore); ok {
+		store = user.base
+	}
+	log, ok := store.(changeLogger)
+	return log, ok
+}
+
+// encodeChangeCursor returns an opaque cursor pointing just after the
+// change numbered seq.
+func encodeChangeCursor(seq int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("c" + strconv.FormatInt(seq, 10)))
+}
+
+// decodeChangeCursor returns the number of the change a cursor made by
+// encodeChangeCursor points after.
+func decodeChangeCursor(cursor string) (int64, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+	digits, ok := strings.CutPrefix(string(data), "c")
+	if !ok {
+		return 0, ErrInvalidCursor
+	}
+	seq, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil || seq < 0 {
+		return 0, ErrInvalidCursor
+	}
+	return seq, nil
+}
+
+// keptChanges returns the changes in records, which are oldest first, made
+// at or after before, and always the newest change so that its number is
+// never forgotten.
+func keptChanges(records []changeRecord, before time.Time) []changeRecord {
+	for i, record := range records {
+		if !record.ChangedAt.Before(before) {
+			return records[i:]
+		}
+	}
+	if len(records) == 0 {
+		return nil
+	}
+	return records[len(records)-1:]
+}
+
+// deletedSince returns the deletions in records made at or after since.
+func deletedSince(records []changeRecord, since time.Time) []changeRecord {
+	var deleted []changeRecord
+	for _, record := range records {
+		if record.Kind == ChangeDeleted && !record.ChangedAt.Before(since) {
+			deleted = append(deleted, record)
+		}
+	}
+	return deleted
+}
+
+// changesAfter returns up to limit of the changes in records, which are
+// oldest first, numbered after seq.
+func changesAfter(records []changeRecord, seq int64, limit int) []changeRecord {
+	var after []changeRecord
+	for _, record := range records {
+		if record.Seq > seq {
+			after = append(after, record)
+			if len(after) == limit {
+				break
+			}
+		}
+	}
+	return after
+}
+
+// changesFile is the file in a directory store's storage directory that
+// holds its change log, one JSON changeRecord per line. It doesn't end in
+// .json, so List never mistakes it for an item.
+const changesFile = "changes.jsonl"
+
+// changesLockFile is the file in a directory store's storage directory
+// that processes sharing the directory lock while they number and write
+// changes. It is never replaced, unlike the change log itself.
+const changesLockFile = "changes.lock"
+
+// lockChanges takes the change log's lock, waiting for other processes to
+// release it, and returns a function that releases it. The caller must hold
+// ds.mu.
+func (ds *dirStore) lockChanges() (func(), error) {
+	file, err := os.OpenFile(filepath.Join(ds.storageDir, changesLockFile), os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock changes: %w", err)
+	}
+	if err := lockFile(file); err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("failed to lock changes: %w", err)
+	}
+	return func() { _ = file.Close() }, nil
+}
+
+// logChange appends a change of the given kind to the item with the given
+// ID to the change log. Changes are numbered after the last one in the
+// file, which is read again whenever another process may have added to it;
+// the change log's lock is held from reading the number to writing the
+// change, so that two processes never give out the same one. The caller
+// must hold ds.mu.
+func (ds *dirStore) logChange(id uuid.UUID, kind string) error {
+	unlock, err := ds.lockChanges()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	path := filepath.Join(ds.storageDir, changesFile)
+	var size int64
+	if info, err := os.Stat(path); err == nil {
+		size = info.Size()
+	}
+	if size != ds.changesSize || ds.changeSeq == 0 {
+		records, err := ds.readChanges()
+		if err != nil {
+			return err
+		}
+		ds.changeSeq = 0
+		if len(records) > 0 {
+			ds.changeSeq = records[len(records)-1].Seq
+		}
+	}
+
+	record := changeRecord{Seq: ds.changeSeq + 1, ID: id, Kind: kind, ChangedAt: time.Now().UTC()}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to record change: %w", err)
+	}
+	data = append(data, '\n')
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to record change: %w", err)
+	}
+	if _, err := file.Write(data); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to record change: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to record change: %w", err)
+	}
+	ds.changeSeq = record.Seq
+	ds.changesSize = size + int64(len(data))
+	return nil
+}
+
+// readChanges returns every change in the change log, oldest first. Lines
+// that can't be read, such as one cut short by a crash, are skipped. The
+// caller must hold ds.mu.
+func (ds *dirStore) readChanges() ([]changeRecord, error) {
+	file, err := os.Open(filepath.Join(ds.storageDir, changesFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read changes: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	var records []changeRecord
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record changeRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			continue
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read changes: %w", err)
+	}
+	return records, nil
+}
+
+// ChangesAfter returns up to limit changes numbered after seq.
+func (ds *dirStore) ChangesAfter(seq int64, limit int) ([]changeRecord, error) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	records, err := ds.readChanges()
+	if err != nil {
+		return nil, err
+	}
+	return changesAfter(records, seq, limit), nil
+}
+
+// ChangeBounds returns the numbers of the oldest and newest changes kept.
+func (ds *dirStore) ChangeBounds() (int64, int64, error) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	records, err := ds.readChanges()
+	if err != nil || len(records) == 0 {
+		return 0, 0, err
+	}
+	return records[0].Seq, records[len(records)-1].Seq, nil
+}
+
+// DeletedSince returns the deletions recorded at or after since.
+func (ds *dirStore) DeletedSince(since time.Time) ([]changeRecord, error) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	records, err := ds.readChanges()
+	if err != nil {
+		return nil, err
+	}
+	return deletedSince(records, since), nil
+}
+
+// PruneChanges rewrites the change log without the changes made before the
+// given time.
+func (ds *dirStore) PruneChanges(before time.Time) (int, error) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	unlock, err := ds.lockChanges()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	records, err := ds.readChanges()
+	if err != nil {
+		return 0, err
+	}
+	keep := keptChanges(records, before)
+	pruned := len(records) - len(keep)
+	if pruned == 0 {
+		return 0, nil
+	}
+	if err := ds.writeChanges(keep); err != nil {
+		return 0, fmt.Errorf("failed to prune changes: %w", err)
+	}
+	return pruned, nil
+}
+
+// writeChanges replaces the change log with records. The caller must hold
+// ds.mu and the change log's lock.
+func (ds *dirStore) writeChanges(records []changeRecord) error {
+	var data []byte
+	for _, record := range records {
+		line, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		data = append(append(data, line...), '\n')
+	}
+	path := filepath.Join(ds.storageDir, changesFile)
+	tmp := path + tempSuffix
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	ds.changeSeq = 0
+	return nil
+}
+
+// changeLogDB is the part of *sql.DB and *sqldb.DB that sqlChangeLog uses.
+type changeLogDB interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// sqlChangeLog reads and prunes the changes table of a SQLite or
+// PostgreSQL store. The stores record the changes themselves.
+type sqlChangeLog struct {
+	db changeLogDB
+}
+
+// ChangesAfter returns up to limit changes numbered after seq.
+func (l sqlChangeLog) ChangesAfter(seq int64, limit int) ([]changeRecord, error) {
+	return l.query("SELECT seq, id, kind, changed_at FROM changes WHERE seq > ? ORDER BY seq LIMIT ?", seq, limit)
+}
+
+// ChangeBounds returns the numbers of the oldest and newest changes kept.
+func (l sqlChangeLog) ChangeBounds() (int64, int64, error) {
+	var oldest, newest int64
+	err := l.db.QueryRow("SELECT COALESCE(MIN(seq), 0), COALESCE(MAX(seq), 0) FROM changes").Scan(&oldest, &newest)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read changes: %w", err)
+	}
+	return oldest, newest, nil
+}
+
+// DeletedSince returns the deletions recorded at or after since.
+func (l sqlChangeLog) DeletedSince(since time.Time) ([]changeRecord, error) {
+	return l.query("SELECT seq, id, kind, changed_at FROM changes WHERE kind = ? AND changed_at >= ? ORDER BY seq",
+		ChangeDeleted, formatTime(&since))
+}
+
+// PruneChanges forgets the changes made before the given time, except the
+// newest.
+func (l sqlChangeLog) PruneChanges(before time.Time) (int, error) {
+	result, err := l.db.Exec("DELETE FROM changes WHERE changed_at < ? AND seq < (SELECT MAX(seq) FROM changes)", formatTime(&before))
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune changes: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return int(rows), nil
+}
+
+// query reads the changes selected by a query of seq, id, kind, and
+// changed_at.
+func (l sqlChangeLog) query(query string, args ...any) ([]changeRecord, error) {
+	rows, err := l.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read changes: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var records []changeRecord
+	for rows.Next() {
+		var record changeRecord
+		var id, changedAt string
+		if err := rows.Scan(&record.Seq, &id, &record.Kind, &changedAt); err != nil {
+			return nil, fmt.Errorf("failed to read changes: %w", err)
+		}
+		if record.ID, err = uuid.Parse(id); err != nil {
+			return nil, fmt.Errorf("failed to read changes: %w", err)
+		}
+		if record.ChangedAt, err = time.Parse(timeLayout, changedAt); err != nil {
+			return nil, fmt.Errorf("failed to read changes: %w", err)
+		}
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read changes: %w", err)
+	}
+	return records, nil
+}
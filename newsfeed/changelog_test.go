@@ -0,0 +1,225 @@
+package newsfeed
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// changeLogBackends opens an empty feed on each backend that logs changes
+var changeLogBackends = map[string]func(t *testing.T) *NewsFeed{
+	"directory": func(t *testing.T) *NewsFeed {
+		feed, err := NewNewsFeed(t.TempDir())
+		require.NoError(t, err)
+		return feed
+	},
+	"sqlite":     createTestSQLiteFeed,
+	"memory":     func(t *testing.T) *NewsFeed { return NewMemoryNewsFeed() },
+	"postgresql": createTestPostgresFeed,
+}
+
+// TestTombstones verifies that every backend records the items it deletes,
+// lists the deletions made since a given time, and forgets old ones when
+// pruned
+func TestTombstones(t *testing.T) {
+	for name, open := range changeLogBackends {
+		t.Run(name, func(t *testing.T) {
+			feed := open(t)
+			first := createTestItem("First")
+			second := createTestItem("Second")
+			kept := createTestItem("Kept")
+			for _, item := range []NewsItem{first, second, kept} {
+				require.NoError(t, feed.Add(item))
+			}
+
+			start := time.Now().Add(-time.Second)
+			require.NoError(t, feed.Delete(first.ID))
+			// SQLite records the time of a change to the millisecond
+			time.Sleep(10 * time.Millisecond)
+			between := time.Now()
+			time.Sleep(10 * time.Millisecond)
+			require.NoError(t, feed.Delete(second.ID))
+
+			tombstones, err := feed.Tombstones(start)
+			require.NoError(t, err)
+			require.Len(t, tombstones, 2)
+			assert.Equal(t, first.ID, tombstones[0].ID)
+			assert.Equal(t, second.ID, tombstones[1].ID)
+
+			tombstones, err = feed.Tombstones(between)
+			require.NoError(t, err)
+			require.Len(t, tombstones, 1)
+			assert.Equal(t, second.ID, tombstones[0].ID)
+
+			// The three additions and the first deletion are forgotten
+			pruned, err := feed.PruneChanges(between)
+			require.NoError(t, err)
+			assert.Equal(t, 4, pruned)
+			tombstones, err = feed.Tombstones(start)
+			require.NoError(t, err)
+			require.Len(t, tombstones, 1)
+			assert.Equal(t, second.ID, tombstones[0].ID)
+
+			// The change log is never listed as items
+			result, err := feed.List()
+			require.NoError(t, err)
+			assert.Len(t, result.Items, 1)
+			assert.Empty(t, result.Errors)
+		})
+	}
+}
+
+// TestChanges verifies that every backend reports the items created,
+// updated, and deleted after a cursor, folds each item's changes into its
+// latest, pages through long runs of changes, and refuses cursors it can't
+// follow
+func TestChanges(t *testing.T) {
+	for name, open := range changeLogBackends {
+		t.Run(name, func(t *testing.T) {
+			feed := open(t)
+			before := createTestItem("Before")
+			require.NoError(t, feed.Add(before))
+
+			start, err := feed.Changes("", 10)
+			require.NoError(t, err)
+			assert.Empty(t, start.Changes, "an empty cursor should only give the newest change")
+
+			created := createTestItem("Created")
+			require.NoError(t, feed.Add(created))
+			_, err = feed.Star(created.ID)
+			require.NoError(t, err)
+			_, err = feed.MarkRead(before.ID)
+			require.NoError(t, err)
+			gone := createTestItem("Gone")
+			require.NoError(t, feed.Add(gone))
+			require.NoError(t, feed.Delete(gone.ID))
+
+			set, err := feed.Changes(start.Cursor, 10)
+			require.NoError(t, err)
+			assert.False(t, set.More)
+			require.Len(t, set.Changes, 3)
+			assert.Equal(t, created.ID, set.Changes[0].ID)
+			assert.Equal(t, ChangeCreated, set.Changes[0].Kind, "an item created and then starred is new to the client")
+			require.NotNil(t, set.Changes[0].Item)
+			assert.True(t, set.Changes[0].Item.IsStarred())
+			assert.Equal(t, before.ID, set.Changes[1].ID)
+			assert.Equal(t, ChangeUpdated, set.Changes[1].Kind)
+			assert.Equal(t, gone.ID, set.Changes[2].ID)
+			assert.Equal(t, ChangeDeleted, set.Changes[2].Kind)
+			assert.Nil(t, set.Changes[2].Item)
+
+			// Nothing has changed since the last page
+			again, err := feed.Changes(set.Cursor, 10)
+			require.NoError(t, err)
+			assert.Empty(t, again.Changes)
+			assert.Equal(t, set.Cursor, again.Cursor)
+
+			// Five changes, two at a time
+			page, err := feed.Changes(start.Cursor, 2)
+			require.NoError(t, err)
+			assert.True(t, page.More)
+			require.Len(t, page.Changes, 1, "the page's two changes are both to the created item")
+			assert.Equal(t, ChangeCreated, page.Changes[0].Kind)
+			page, err = feed.Changes(page.Cursor, 2)
+			require.NoError(t, err)
+			assert.True(t, page.More)
+			page, err = feed.Changes(page.Cursor, 2)
+			require.NoError(t, err)
+			assert.False(t, page.More)
+			require.Len(t, page.Changes, 1)
+			assert.Equal(t, gone.ID, page.Changes[0].ID)
+
+			_, err = feed.Changes("not a cursor", 10)
+			assert.ErrorIs(t, err, ErrInvalidCursor)
+
+			// Pruning the changes after the start leaves the start cursor
+			// unable to follow them
+			_, err = feed.PruneChanges(time.Now().Add(time.Hour))
+			require.NoError(t, err)
+			_, err = feed.Changes(start.Cursor, 10)
+			assert.ErrorIs(t, err, ErrCursorExpired)
+			_, err = feed.Changes(set.Cursor, 10)
+			assert.NoError(t, err, "the newest change is always kept")
+		})
+	}
+}
+
+// TestChanges_ReplacedItemIsUpdated verifies that every backend logs an
+// item saved over one with the same ID as updated, not created
+func TestChanges_ReplacedItemIsUpdated(t *testing.T) {
+	for name, open := range changeLogBackends {
+		t.Run(name, func(t *testing.T) {
+			feed := open(t)
+			item := createTestItem("Original")
+			require.NoError(t, feed.Add(item))
+			start, err := feed.Changes("", 10)
+			require.NoError(t, err)
+
+			item.Title = "Replaced"
+			require.NoError(t, feed.Add(item))
+
+			set, err := feed.Changes(start.Cursor, 10)
+			require.NoError(t, err)
+			require.Len(t, set.Changes, 1)
+			assert.Equal(t, ChangeUpdated, set.Changes[0].Kind)
+			require.NotNil(t, set.Changes[0].Item)
+			assert.Equal(t, "Replaced", set.Changes[0].Item.Title)
+		})
+	}
+}
+
+// TestChanges_ExpiresOldCursors verifies that a cursor is refused once the
+// changes made since it are older than ChangeRetention, even though they
+// haven't been pruned
+func TestChanges_ExpiresOldCursors(t *testing.T) {
+	feed := NewMemoryNewsFeed()
+	require.NoError(t, feed.Add(createTestItem("Before")))
+	start, err := feed.Changes("", 10)
+	require.NoError(t, err)
+	require.NoError(t, feed.Add(createTestItem("After")))
+
+	_, err = feed.Changes(start.Cursor, 10)
+	require.NoError(t, err)
+
+	store := feed.store.(*memoryStore)
+	store.changes[1].ChangedAt = time.Now().Add(-ChangeRetention - time.Hour)
+	_, err = feed.Changes(start.Cursor, 10)
+	assert.ErrorIs(t, err, ErrCursorExpired)
+}
+
+// TestChanges_SharedDirectory verifies that directory stores sharing a
+// storage directory never give two changes the same number
+func TestChanges_SharedDirectory(t *testing.T) {
+	dir := t.TempDir()
+	feeds := make([]*NewsFeed, 2)
+	for i := range feeds {
+		feed, err := NewNewsFeed(dir)
+		require.NoError(t, err)
+		feeds[i] = feed
+	}
+
+	var wg sync.WaitGroup
+	for _, feed := range feeds {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 20 {
+				assert.NoError(t, feed.Add(createTestItem("Shared")))
+			}
+		}()
+	}
+	wg.Wait()
+
+	store := feeds[0].store.(*dirStore)
+	records, err := store.readChanges()
+	require.NoError(t, err)
+	require.Len(t, records, 40)
+	seen := map[int64]bool{}
+	for _, record := range records {
+		assert.False(t, seen[record.Seq], "change %d was numbered twice", record.Seq)
+		seen[record.Seq] = true
+	}
+}
@@ -0,0 +1,122 @@
+package newsfeed
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidCursor is returned by Query when a cursor cannot be decoded or
+// was returned for a different sort order.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// pageCursor marks a position in a sorted query: the sort key and ID of the
+// last item on the previous page. Unlike an offset, it still points at the
+// same place after new items are added ahead of it.
+type pageCursor struct {
+	Sort string     `json:"s"`
+	Key  *time.Time `json:"k,omitempty"`
+	ID   uuid.UUID  `json:"i"`
+}
+
+// sortOrder returns the sort order named by sortBy, resolving the default.
+func sortOrder(sortBy string) string {
+	if sortBy == "" {
+		return SortPublished
+	}
+	return sortBy
+}
+
+// sortKey returns the time an item is sorted by. Unpinned items have no key
+// when sorting by pin date.
+func sortKey(item NewsItem, sortBy string) *time.Time {
+	switch sortOrder(sortBy) {
+	case SortDiscovered:
+		return &item.DiscoveredAt
+	case SortPinned:
+		return item.PinnedAt
+	default:
+		return &item.PublishedAt
+	}
+}
+
+// encodeCursor returns an opaque cursor pointing just after item.
+func encodeCursor(item NewsItem, sortBy string) string {
+	c := pageCursor{Sort: sortOrder(sortBy), Key: sortKey(item, sortBy), ID: item.ID}
+	if c.Key != nil {
+		key := c.Key.UTC()
+		c.Key = &key
+	}
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor parses a cursor made by encodeCursor for the same sort order.
+func decodeCursor(s, sortBy string) (*pageCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c pageCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == uuid.Nil {
+		return nil, ErrInvalidCursor
+	}
+	if c.Sort != sortOrder(sortBy) {
+		return nil, fmt.Errorf("%w: cursor is for sorting by %s", ErrInvalidCursor, c.Sort)
+	}
+	if c.Sort != SortPinned && c.Key == nil {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// item returns a stand-in item that sorts exactly where the cursor points.
+func (c *pageCursor) item() NewsItem {
+	item := NewsItem{ID: c.ID}
+	switch c.Sort {
+	case SortDiscovered:
+		item.DiscoveredAt = *c.Key
+	case SortPinned:
+		item.PinnedAt = c.Key
+	default:
+		item.PublishedAt = *c.Key
+	}
+	return item
+}
+
+// pageItems returns the page of sorted items selected by opts, and a cursor
+// for the next page when more items follow it. The cursor, when given,
+// takes the place of the offset.
+func pageItems(items []NewsItem, opts ListOptions) ([]NewsItem, string, error) {
+	start := opts.Offset
+	if opts.Cursor != "" {
+		c, err := decodeCursor(opts.Cursor, opts.SortBy)
+		if err != nil {
+			return nil, "", err
+		}
+		after := c.item()
+		start = sort.Search(len(items), func(i int) bool {
+			return lessItems(after, items[i], opts.SortBy)
+		})
+	}
+
+	if start >= len(items) {
+		return nil, "", nil
+	}
+	end := len(items)
+	if opts.Limit > 0 && start+opts.Limit < end {
+		end = start + opts.Limit
+	}
+
+	// Scores change over time, so scored queries can only be paged by offset
+	next := ""
+	if end < len(items) && opts.SortBy != SortScore {
+		next = encodeCursor(items[end-1], opts.SortBy)
+	}
+	return items[start:end], next, nil
+}
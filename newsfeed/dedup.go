@@ -0,0 +1,326 @@
+package newsfeed
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// DuplicateKind describes why an item was considered a duplicate.
+type DuplicateKind string
+
+const (
+	// NotDuplicate means no existing item matched.
+	NotDuplicate DuplicateKind = ""
+	// DuplicateGUID means an existing item from the same source has the same
+	// feed GUID, even if its URL or content has since changed.
+	DuplicateGUID DuplicateKind = "guid"
+	// DuplicateURL means an existing item has the same canonical URL.
+	DuplicateURL DuplicateKind = "url"
+	// DuplicateContent means an existing item has the same title and summary
+	// fingerprint, even though its URL differs.
+	DuplicateContent DuplicateKind = "content"
+)
+
+// DedupScope sets which indexed items MatchScope compares an item with.
+type DedupScope int
+
+const (
+	// ScopeGlobal compares an item with every indexed item.
+	ScopeGlobal DedupScope = iota
+	// ScopeSource compares an item only with indexed items from its own
+	// source, so that sources that post the same article each keep a copy.
+	ScopeSource
+	// ScopeOff only matches the copy of an item its own source already
+	// gave, by GUID or URL, so that fetching the source again doesn't add
+	// the item twice. Items with the same content are all kept.
+	ScopeOff
+)
+
+// trackingParams are query parameters that identify how a reader arrived at
+// an article rather than which article it is.
+var trackingParams = map[string]bool{
+	"fbclid":  true,
+	"gclid":   true,
+	"dclid":   true,
+	"msclkid": true,
+	"mc_cid":  true,
+	"mc_eid":  true,
+	"igshid":  true,
+	"ref_src": true,
+	"_hsenc":  true,
+	"_hsmi":   true,
+}
+
+// NormalizeURL cleans up a URL for comparison. It lowercases the scheme and
+// host, strips fragments, removes default ports (80/443), and removes
+// trailing slashes from the path. Unparseable URLs are returned unchanged.
+func NormalizeURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	u.Fragment = ""
+	u.RawFragment = ""
+
+	// Strip default ports.
+	hostname := u.Hostname()
+	port := u.Port()
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		u.Host = hostname
+	}
+
+	// Remove trailing slash from path (but keep "/" for root).
+	if len(u.Path) > 1 {
+		u.Path = strings.TrimRight(u.Path, "/")
+	}
+
+	return u.String()
+}
+
+// CanonicalURL returns the key used to decide whether two URLs refer to the
+// same article. On top of NormalizeURL it drops tracking parameters (utm_*,
+// fbclid, and similar), sorts the remaining query parameters, and ignores
+// whether the scheme is http or https. The result is a comparison key, not a
+// fetchable URL.
+func CanonicalURL(raw string) string {
+	normalized := NormalizeURL(raw)
+	u, err := url.Parse(normalized)
+	if err != nil || u.Host == "" {
+		return normalized
+	}
+
+	if u.Scheme == "http" || u.Scheme == "https" {
+		u.Scheme = ""
+	}
+
+	if u.RawQuery != "" {
+		query := u.Query()
+		for name := range query {
+			if strings.HasPrefix(strings.ToLower(name), "utm_") || trackingParams[strings.ToLower(name)] {
+				query.Del(name)
+			}
+		}
+		// Encode sorts by key, so parameter order no longer matters
+		u.RawQuery = query.Encode()
+	}
+	u.ForceQuery = false
+
+	return u.String()
+}
+
+// Fingerprint returns a hash of an item's title and summary, ignoring case,
+// punctuation, and whitespace. Items without both a title and a summary have
+// no fingerprint, since a bare title such as "Weekly Update" is too generic to
+// identify an article.
+func Fingerprint(item NewsItem) string {
+	title := fingerprintText(item.Title)
+	summary := fingerprintText(item.Summary)
+	if title == "" || summary == "" {
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(title + "\n" + summary))
+	return hex.EncodeToString(sum[:16])
+}
+
+// fingerprintText lowercases s and reduces it to words separated by single
+// spaces.
+func fingerprintText(s string) string {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	return strings.Join(words, " ")
+}
+
+// guidKey returns the key an item's GUID is indexed under, or "" if it has
+// none. GUIDs are only unique within a source.
+func guidKey(item NewsItem) string {
+	if item.GUID == "" || item.SourceID == nil {
+		return ""
+	}
+	return item.SourceID.String() + " " + item.GUID
+}
+
+// DedupIndex finds items that duplicate ones already in a feed, by source
+// and feed GUID, canonical URL, or content fingerprint. Build it once before
+// adding a batch of items and record each added item so later items in the
+// same batch are also checked.
+type DedupIndex struct {
+	guids        map[string]uuid.UUID
+	urls         map[string]uuid.UUID
+	fingerprints map[string]uuid.UUID
+	content      map[uuid.UUID]indexedContent
+
+	// sourceURLs and sourceFingerprints are keyed by source as well, for
+	// scoped matches
+	sourceURLs         map[string]uuid.UUID
+	sourceFingerprints map[string]uuid.UUID
+}
+
+// indexedContent is what a DedupIndex needs to tell whether an indexed item
+// has been revised by its publisher.
+type indexedContent struct {
+	sourceID uuid.UUID
+	hash     string // publisherHash of the item
+}
+
+// NewDedupIndex returns an index containing the given items.
+func NewDedupIndex(items []NewsItem) *DedupIndex {
+	idx := newDedupIndex(len(items))
+	for _, item := range items {
+		idx.Add(item)
+	}
+	return idx
+}
+
+// newDedupIndex returns an empty index with room for size items.
+func newDedupIndex(size int) *DedupIndex {
+	return &DedupIndex{
+		guids:              make(map[string]uuid.UUID, size),
+		urls:               make(map[string]uuid.UUID, size),
+		fingerprints:       make(map[string]uuid.UUID, size),
+		content:            make(map[uuid.UUID]indexedContent, size),
+		sourceURLs:         make(map[string]uuid.UUID, size),
+		sourceFingerprints: make(map[string]uuid.UUID, size),
+	}
+}
+
+// scopedKey returns key qualified by the source it came from, or "" if
+// there is no source or no key.
+func scopedKey(sourceID *uuid.UUID, key string) string {
+	if sourceID == nil || key == "" {
+		return ""
+	}
+	return sourceID.String() + " " + key
+}
+
+// dedupIndexer is implemented by stores that can build a DedupIndex without
+// reading every item.
+type dedupIndexer interface {
+	DedupIndex() (*DedupIndex, error)
+	HasURL(rawURL string) (bool, error)
+}
+
+// DedupIndex returns an index of the feed's items. Stores that keep their own
+// index build it directly; otherwise the feed is read once.
+func (nf *NewsFeed) DedupIndex() (*DedupIndex, error) {
+	if d, ok := nf.store.(dedupIndexer); ok {
+		return d.DedupIndex()
+	}
+	result, err := nf.List()
+	if err != nil {
+		return nil, err
+	}
+	return NewDedupIndex(result.Items), nil
+}
+
+// HasURL reports whether the feed has an item with the same canonical URL as
+// rawURL.
+func (nf *NewsFeed) HasURL(rawURL string) (bool, error) {
+	if d, ok := nf.store.(dedupIndexer); ok {
+		return d.HasURL(rawURL)
+	}
+	idx, err := nf.DedupIndex()
+	if err != nil {
+		return false, err
+	}
+	return idx.HasURL(rawURL), nil
+}
+
+// Add records an item in the index.
+func (idx *DedupIndex) Add(item NewsItem) {
+	canonical := ""
+	if item.URL != "" {
+		canonical = CanonicalURL(item.URL)
+	}
+	idx.add(item.ID, item, canonical, Fingerprint(item), publisherHash(item))
+}
+
+// add records the item with the given ID under keys already worked out
+// from item, which need only carry its GUID and source.
+func (idx *DedupIndex) add(id uuid.UUID, item NewsItem, canonical, fingerprint, hash string) {
+	if key := guidKey(item); key != "" {
+		idx.guids[key] = id
+	}
+	if canonical != "" {
+		idx.urls[canonical] = id
+		if key := scopedKey(item.SourceID, canonical); key != "" {
+			idx.sourceURLs[key] = id
+		}
+	}
+	if fingerprint != "" {
+		idx.fingerprints[fingerprint] = id
+		if key := scopedKey(item.SourceID, fingerprint); key != "" {
+			idx.sourceFingerprints[key] = id
+		}
+	}
+	if item.SourceID != nil {
+		idx.content[id] = indexedContent{sourceID: *item.SourceID, hash: hash}
+	}
+}
+
+// HasURL reports whether an item with the same canonical URL is indexed. It
+// lets callers skip fetching an article they already have.
+func (idx *DedupIndex) HasURL(rawURL string) bool {
+	_, ok := idx.urls[CanonicalURL(rawURL)]
+	return ok
+}
+
+// Match reports whether item duplicates an indexed item, and if so, which
+// one. GUID matches take precedence over URL matches, and URL matches over
+// content matches.
+func (idx *DedupIndex) Match(item NewsItem) (DuplicateKind, uuid.UUID) {
+	return idx.MatchScope(item, ScopeGlobal)
+}
+
+// MatchScope is Match limited to the indexed items scope covers. An item
+// without a source is always matched globally.
+func (idx *DedupIndex) MatchScope(item NewsItem, scope DedupScope) (DuplicateKind, uuid.UUID) {
+	if item.SourceID == nil {
+		scope = ScopeGlobal
+	}
+	if key := guidKey(item); key != "" {
+		if id, ok := idx.guids[key]; ok {
+			return DuplicateGUID, id
+		}
+	}
+	if item.URL != "" {
+		canonical := CanonicalURL(item.URL)
+		urls := idx.urls
+		if scope != ScopeGlobal {
+			urls, canonical = idx.sourceURLs, scopedKey(item.SourceID, canonical)
+		}
+		if id, ok := urls[canonical]; ok {
+			return DuplicateURL, id
+		}
+	}
+	if fp := Fingerprint(item); fp != "" && scope != ScopeOff {
+		fingerprints := idx.fingerprints
+		if scope == ScopeSource {
+			fingerprints, fp = idx.sourceFingerprints, scopedKey(item.SourceID, fp)
+		}
+		if id, ok := fingerprints[fp]; ok {
+			return DuplicateContent, id
+		}
+	}
+	return NotDuplicate, uuid.Nil
+}
+
+// Revised reports whether item is a newer copy of the indexed item with the
+// given ID: one from the same source whose title, summary, or URL differ
+// from what the publisher last gave.
+func (idx *DedupIndex) Revised(id uuid.UUID, item NewsItem) bool {
+	content, ok := idx.content[id]
+	if !ok || item.SourceID == nil || *item.SourceID != content.sourceID {
+		return false
+	}
+	return HashContent(item) != content.hash
+}
@@ -0,0 +1,198 @@
+package newsfeed
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNormalizeURL verifies URL normalization rules
+func TestNormalizeURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"strips fragment", "http://example.com/post#comments", "http://example.com/post"},
+		{"strips changing fragment", "http://example.com/post#section-2", "http://example.com/post"},
+		{"lowercases scheme", "HTTP://example.com/post", "http://example.com/post"},
+		{"lowercases host", "http://Example.COM/Post", "http://example.com/Post"},
+		{"removes default http port", "http://example.com:80/post", "http://example.com/post"},
+		{"removes default https port", "https://example.com:443/post", "https://example.com/post"},
+		{"keeps non-default port", "http://example.com:8080/post", "http://example.com:8080/post"},
+		{"removes trailing slash", "http://example.com/post/", "http://example.com/post"},
+		{"keeps root path", "http://example.com/", "http://example.com/"},
+		{"preserves query params", "http://example.com/post?a=1&b=2", "http://example.com/post?a=1&b=2"},
+		{"combined normalization", "HTTPS://Example.COM:443/Blog/Post/#anchor?", "https://example.com/Blog/Post"},
+		{"unparseable returns raw", "://broken", "://broken"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, NormalizeURL(tt.input))
+		})
+	}
+}
+
+// TestCanonicalURL verifies scheme and tracking parameters are ignored while
+// meaningful query parameters are kept
+func TestCanonicalURL(t *testing.T) {
+	same := [][2]string{
+		{"http://example.com/post", "https://example.com/post/"},
+		{"https://example.com/post", "https://example.com/post?utm_source=rss&utm_campaign=x"},
+		{"https://example.com/post?id=1", "https://example.com/post?fbclid=abc&id=1"},
+		{"https://example.com/post?a=1&b=2", "https://example.com/post?b=2&a=1"},
+		{"https://example.com/post", "https://EXAMPLE.com/post#top"},
+	}
+	for _, pair := range same {
+		assert.Equal(t, CanonicalURL(pair[0]), CanonicalURL(pair[1]), "%s vs %s", pair[0], pair[1])
+	}
+
+	different := [][2]string{
+		{"https://example.com/post?id=1", "https://example.com/post?id=2"},
+		{"https://example.com/post", "https://example.org/post"},
+		{"https://example.com/Post", "https://example.com/post"},
+	}
+	for _, pair := range different {
+		assert.NotEqual(t, CanonicalURL(pair[0]), CanonicalURL(pair[1]), "%s vs %s", pair[0], pair[1])
+	}
+}
+
+// TestFingerprint verifies fingerprints ignore case, punctuation, and
+// whitespace and require both a title and a summary
+func TestFingerprint(t *testing.T) {
+	a := NewsItem{Title: "Go 1.25 Released!", Summary: "The Go team is happy to announce..."}
+	b := NewsItem{Title: "go 1.25 released", Summary: "The Go team is happy   to announce"}
+	assert.NotEmpty(t, Fingerprint(a))
+	assert.Equal(t, Fingerprint(a), Fingerprint(b))
+
+	c := NewsItem{Title: "Go 1.25 Released!", Summary: "A different story"}
+	assert.NotEqual(t, Fingerprint(a), Fingerprint(c))
+
+	assert.Empty(t, Fingerprint(NewsItem{Title: "Weekly Update"}))
+	assert.Empty(t, Fingerprint(NewsItem{Summary: "No title"}))
+}
+
+// TestDedupIndex_Match verifies URL matches, content matches, and misses
+func TestDedupIndex_Match(t *testing.T) {
+	existing := NewsItem{
+		ID:           uuid.New(),
+		Title:        "Big News",
+		Summary:      "Something happened today.",
+		URL:          "https://example.com/big-news",
+		Authors:      []string{},
+		PublishedAt:  time.Now(),
+		DiscoveredAt: time.Now(),
+	}
+	idx := NewDedupIndex([]NewsItem{existing})
+
+	kind, id := idx.Match(NewsItem{URL: "http://example.com/big-news/?utm_source=rss", Title: "Other"})
+	assert.Equal(t, DuplicateURL, kind)
+	assert.Equal(t, existing.ID, id)
+
+	kind, id = idx.Match(NewsItem{URL: "https://mirror.example.org/1234", Title: "Big news", Summary: "Something happened today"})
+	assert.Equal(t, DuplicateContent, kind)
+	assert.Equal(t, existing.ID, id)
+
+	kind, _ = idx.Match(NewsItem{URL: "https://example.com/other", Title: "Big News", Summary: "Something else happened."})
+	assert.Equal(t, NotDuplicate, kind)
+
+	assert.True(t, idx.HasURL("https://example.com/big-news#comments"))
+	assert.False(t, idx.HasURL("https://example.com/other"))
+}
+
+// TestDedupIndex_MatchGUID verifies GUID matches take precedence and are
+// scoped to the item's source
+func TestDedupIndex_MatchGUID(t *testing.T) {
+	sourceID := uuid.New()
+	existing := NewsItem{
+		ID:       uuid.New(),
+		Title:    "Big News",
+		URL:      "https://example.com/big-news",
+		SourceID: &sourceID,
+		GUID:     "tag:example.com,2025:42",
+	}
+	other := NewsItem{ID: uuid.New(), URL: "https://example.com/other"}
+	idx := NewDedupIndex([]NewsItem{existing, other})
+
+	// A moved article is still found by its GUID, even where its new URL
+	// belongs to another item
+	kind, id := idx.Match(NewsItem{URL: "https://example.com/other", SourceID: &sourceID, GUID: existing.GUID})
+	assert.Equal(t, DuplicateGUID, kind)
+	assert.Equal(t, existing.ID, id)
+
+	otherSource := uuid.New()
+	kind, _ = idx.Match(NewsItem{URL: "https://example.com/new", SourceID: &otherSource, GUID: existing.GUID})
+	assert.Equal(t, NotDuplicate, kind)
+}
+
+// TestDedupIndex_MatchScope verifies that scoped matches only find items
+// from the same source, and that with dedup off only the source's own copy
+// of an item is found
+func TestDedupIndex_MatchScope(t *testing.T) {
+	sourceA, sourceB := uuid.New(), uuid.New()
+	existing := NewsItem{
+		ID:       uuid.New(),
+		Title:    "Big News",
+		Summary:  "Something happened today.",
+		URL:      "https://example.com/big-news",
+		SourceID: &sourceA,
+	}
+	idx := NewDedupIndex([]NewsItem{existing})
+
+	sameURL := NewsItem{URL: "https://example.com/big-news?utm_source=rss"}
+	sameContent := NewsItem{URL: "https://mirror.example.org/1", Title: "Big news", Summary: "Something happened today"}
+
+	tests := []struct {
+		name   string
+		item   NewsItem
+		source uuid.UUID
+		scope  DedupScope
+		kind   DuplicateKind
+	}{
+		{"global url from another source", sameURL, sourceB, ScopeGlobal, DuplicateURL},
+		{"source url from another source", sameURL, sourceB, ScopeSource, NotDuplicate},
+		{"source url from same source", sameURL, sourceA, ScopeSource, DuplicateURL},
+		{"source content from another source", sameContent, sourceB, ScopeSource, NotDuplicate},
+		{"source content from same source", sameContent, sourceA, ScopeSource, DuplicateContent},
+		{"off url from same source", sameURL, sourceA, ScopeOff, DuplicateURL},
+		{"off content from same source", sameContent, sourceA, ScopeOff, NotDuplicate},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := tt.item
+			item.SourceID = &tt.source
+			kind, id := idx.MatchScope(item, tt.scope)
+			assert.Equal(t, tt.kind, kind)
+			if tt.kind != NotDuplicate {
+				assert.Equal(t, existing.ID, id)
+			}
+		})
+	}
+
+	// Items without a source are matched globally
+	kind, _ := idx.MatchScope(sameURL, ScopeSource)
+	assert.Equal(t, DuplicateURL, kind)
+}
+
+// TestDirStore_DedupIndexGUID verifies the directory store indexes GUIDs
+func TestDirStore_DedupIndexGUID(t *testing.T) {
+	feed, err := NewNewsFeed(t.TempDir())
+	require.NoError(t, err)
+
+	sourceID := uuid.New()
+	item := createTestItem("Article")
+	item.SourceID = &sourceID
+	item.GUID = "42"
+	require.NoError(t, feed.Add(item))
+
+	idx, err := feed.DedupIndex()
+	require.NoError(t, err)
+	kind, id := idx.Match(NewsItem{URL: "https://example.com/moved", SourceID: &sourceID, GUID: "42"})
+	assert.Equal(t, DuplicateGUID, kind)
+	assert.Equal(t, item.ID, id)
+}
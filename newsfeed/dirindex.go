@@ -0,0 +1,230 @@
+package newsfeed
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// dirIndex is an in-memory index of a directory store. It holds the fields
+// needed to filter, sort, and deduplicate items so that queries only read the
+// files for the page they return.
+//
+// Other processes may change the directory while the index is held. Every
+// change renames or removes a file in the directory, which updates the
+// directory's modification time, so the index records the time it was built
+// from and is rebuilt when the directory has changed since.
+type dirIndex struct {
+	modTime time.Time
+	items   map[uuid.UUID]indexEntry
+	urls    map[string]uuid.UUID
+}
+
+// indexEntry is one item in a dirIndex.
+type indexEntry struct {
+	// stub is the item without its title, summary, authors, and notes
+	stub         NewsItem
+	canonicalURL string
+	fingerprint  string
+	contentHash  string // publisherHash of the item
+}
+
+// newDirIndex builds an index of items as of the directory modification
+// time modTime.
+func newDirIndex(items []NewsItem, modTime time.Time) *dirIndex {
+	idx := &dirIndex{
+		modTime: modTime,
+		items:   make(map[uuid.UUID]indexEntry, len(items)),
+		urls:    make(map[string]uuid.UUID, len(items)),
+	}
+	for _, item := range items {
+		idx.put(item)
+	}
+	return idx
+}
+
+// put adds or replaces an item in the index.
+func (idx *dirIndex) put(item NewsItem) {
+	idx.remove(item.ID)
+
+	stub := item
+	stub.Title = ""
+	stub.Summary = ""
+	stub.Authors = nil
+	stub.Notes = ""
+
+	entry := indexEntry{
+		stub:         stub,
+		canonicalURL: CanonicalURL(item.URL),
+		fingerprint:  Fingerprint(item),
+		contentHash:  publisherHash(item),
+	}
+	idx.items[item.ID] = entry
+	if item.URL != "" {
+		idx.urls[entry.canonicalURL] = item.ID
+	}
+}
+
+// remove drops an item from the index.
+func (idx *dirIndex) remove(id uuid.UUID) {
+	entry, ok := idx.items[id]
+	if !ok {
+		return
+	}
+	delete(idx.items, id)
+	if idx.urls[entry.canonicalURL] == id {
+		delete(idx.urls, entry.canonicalURL)
+	}
+}
+
+// dirModTime returns the storage directory's modification time.
+func (ds *dirStore) dirModTime() (time.Time, error) {
+	info, err := os.Stat(ds.storageDir)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
+
+// Revision returns the directory's modification time and item count. The
+// directory changes whenever an item file is written, renamed into place, or
+// removed, though changes within the filesystem's timestamp resolution that
+// keep the item count may share a revision.
+func (ds *dirStore) Revision() (string, error) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	modTime, err := ds.dirModTime()
+	if err != nil {
+		return "", err
+	}
+	idx, _, err := ds.loadIndex()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x-%d", modTime.UnixNano(), len(idx.items)), nil
+}
+
+// indexFresh reports whether the index reflects the directory as it is now.
+// The caller must hold ds.mu.
+func (ds *dirStore) indexFresh() bool {
+	if ds.index == nil {
+		return false
+	}
+	modTime, err := ds.dirModTime()
+	return err == nil && modTime.Equal(ds.index.modTime)
+}
+
+// afterWrite applies a change this store made to the index. If the index
+// was fresh before the change, it stays fresh; otherwise it is dropped and
+// rebuilt on next use. The caller must hold ds.mu.
+func (ds *dirStore) afterWrite(wasFresh bool, apply func(idx *dirIndex)) {
+	if !wasFresh {
+		ds.index = nil
+		return
+	}
+	modTime, err := ds.dirModTime()
+	if err != nil {
+		ds.index = nil
+		return
+	}
+	apply(ds.index)
+	ds.index.modTime = modTime
+}
+
+// loadIndex returns a fresh index, rebuilding it if the directory has
+// changed. Read errors found while rebuilding are returned so they can be
+// reported to the caller. The caller must hold ds.mu.
+func (ds *dirStore) loadIndex() (*dirIndex, []ReadError, error) {
+	if ds.indexFresh() {
+		return ds.index, nil, nil
+	}
+	result, err := ds.scan()
+	if err != nil {
+		return nil, nil, err
+	}
+	return ds.index, result.Errors, nil
+}
+
+// Query evaluates opts against the index and reads only the files for the
+// returned page.
+func (ds *dirStore) Query(opts ListOptions) (*QueryResult, error) {
+	ds.mu.Lock()
+	idx, readErrors, err := ds.loadIndex()
+	if err != nil {
+		ds.mu.Unlock()
+		return nil, err
+	}
+	stubs := make([]NewsItem, 0, len(idx.items))
+	for _, entry := range idx.items {
+		if opts.Matches(entry.stub) {
+			stubs = append(stubs, entry.stub)
+		}
+	}
+	ds.mu.Unlock()
+
+	sortItems(stubs, opts.SortBy)
+	page, next, err := pageItems(stubs, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &QueryResult{
+		Total:      len(stubs),
+		Errors:     readErrors,
+		NextCursor: next,
+	}
+	for _, stub := range page {
+		item, err := ds.Get(stub.ID)
+		if err != nil {
+			result.Errors = append(result.Errors, ReadError{
+				Filename: stub.ID.String() + ".json",
+				Err:      err,
+			})
+			continue
+		}
+		if item == nil {
+			// Deleted by another process since the index was built
+			continue
+		}
+		result.Items = append(result.Items, *item)
+	}
+
+	return result, nil
+}
+
+// HasURL reports whether an item with the same canonical URL is stored.
+func (ds *dirStore) HasURL(rawURL string) (bool, error) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	idx, _, err := ds.loadIndex()
+	if err != nil {
+		return false, err
+	}
+	_, ok := idx.urls[CanonicalURL(rawURL)]
+	return ok, nil
+}
+
+// DedupIndex builds a deduplication index from the in-memory index without
+// reading item files.
+func (ds *dirStore) DedupIndex() (*DedupIndex, error) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	idx, _, err := ds.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+	dedup := newDedupIndex(len(idx.items))
+	for id, entry := range idx.items {
+		canonical := ""
+		if entry.stub.URL != "" {
+			canonical = entry.canonicalURL
+		}
+		dedup.add(id, entry.stub, canonical, entry.fingerprint, entry.contentHash)
+	}
+	return dedup, nil
+}
@@ -0,0 +1,92 @@
+package newsfeed
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDirStore_QueryReadsOnlyPage verifies items outside the requested page
+// are served from the index without reading their files
+func TestDirStore_QueryReadsOnlyPage(t *testing.T) {
+	tempDir := t.TempDir()
+	feed, err := NewNewsFeed(tempDir)
+	require.NoError(t, err)
+
+	base := time.Now().Add(-time.Hour)
+	var items []NewsItem
+	for i := range 5 {
+		item := createTestItem(fmt.Sprintf("Article %d", i))
+		item.PublishedAt = base.Add(time.Duration(i) * time.Minute)
+		require.NoError(t, feed.Add(item))
+		items = append(items, item)
+	}
+
+	// Build the index
+	_, err = feed.Query(ListOptions{})
+	require.NoError(t, err)
+
+	// Overwrite the oldest item in place; this doesn't change the directory,
+	// so the index stays fresh and a query that reads the file would fail
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, items[0].ID.String()+".json"), []byte("{"), 0o600))
+
+	result, err := feed.Query(ListOptions{Limit: 2})
+	require.NoError(t, err)
+	assert.Empty(t, result.Errors)
+	assert.Equal(t, 5, result.Total)
+	require.Len(t, result.Items, 2)
+	assert.Equal(t, "Article 4", result.Items[0].Title)
+	assert.Equal(t, "Article 3", result.Items[1].Title)
+}
+
+// TestDirStore_IndexTracksChanges verifies the index follows changes made
+// through the same store and through another store on the same directory
+func TestDirStore_IndexTracksChanges(t *testing.T) {
+	tempDir := t.TempDir()
+	feed, err := NewNewsFeed(tempDir)
+	require.NoError(t, err)
+
+	item := createTestItem("Article 1")
+	require.NoError(t, feed.Add(item))
+
+	exists, err := feed.HasURL(item.URL + "?utm_source=rss")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	pinned := true
+	result, err := feed.Query(ListOptions{Pinned: &pinned})
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.Total)
+
+	now := time.Now()
+	item.PinnedAt = &now
+	require.NoError(t, feed.Update(item))
+
+	result, err = feed.Query(ListOptions{Pinned: &pinned})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Total)
+
+	// Another process adds an item
+	other, err := NewNewsFeed(tempDir)
+	require.NoError(t, err)
+	added := createTestItem("Article 2")
+	require.NoError(t, other.Add(added))
+
+	exists, err = feed.HasURL(added.URL)
+	require.NoError(t, err)
+	assert.True(t, exists, "index should be rebuilt after an external change")
+
+	require.NoError(t, feed.Delete(item.ID))
+	exists, err = feed.HasURL(item.URL)
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	result, err = feed.Query(ListOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Total)
+}
@@ -0,0 +1,372 @@
+package newsfeed
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// QuarantineDir is the subdirectory of a directory store that holds item
+// files which could not be parsed.
+const QuarantineDir = "quarantine"
+
+// tempSuffix marks files that are still being written. They don't end in
+// .json, so List never sees a partial write.
+const tempSuffix = ".tmp"
+
+// staleTempAge is how old a temp file must be before Check treats it as left
+// behind by an interrupted write rather than one in progress.
+const staleTempAge = time.Minute
+
+// dirStore stores each news item as a JSON file in a directory.
+type dirStore struct {
+	storageDir string
+	mu         sync.Mutex // Guards index and the change log
+	index      *dirIndex  // Built on first use; nil when it must be rebuilt
+	// changeSeq is the number of the last change logged, read from the
+	// change log when it was changesSize bytes long
+	changeSeq   int64
+	changesSize int64
+}
+
+// newDirStore creates a directory store, creating the storage directory if
+// needed.
+func newDirStore(storageDir string) (*dirStore, error) {
+	// Create the storage directory if it doesn't exist (0700: owner-only
+	// access)
+	if err := os.MkdirAll(storageDir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+
+	return &dirStore{
+		storageDir: storageDir,
+	}, nil
+}
+
+// Add saves a news item to the directory
+func (ds *dirStore) Add(item NewsItem) error {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	kind := ChangeCreated
+	if _, err := os.Stat(ds.itemPath(item.ID)); err == nil {
+		kind = ChangeUpdated
+	}
+
+	wasFresh := ds.indexFresh()
+	if err := ds.writeItem(item); err != nil {
+		return err
+	}
+	ds.afterWrite(wasFresh, func(idx *dirIndex) { idx.put(item) })
+	return ds.logChange(item.ID, kind)
+}
+
+// itemPath returns the path of the file holding the item with the given ID.
+func (ds *dirStore) itemPath(id uuid.UUID) string {
+	return filepath.Join(ds.storageDir, id.String()+".json")
+}
+
+// writeItem writes an item to its file atomically: the JSON is written to a
+// temp file in the same directory, synced, and renamed over the item file, so
+// a crash leaves either the old contents or the new ones.
+func (ds *dirStore) writeItem(item NewsItem) error {
+	// Marshal the item to JSON
+	data, err := json.MarshalIndent(item, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal news item: %w", err)
+	}
+
+	// CreateTemp uses 0600 (owner-only read/write), which the rename keeps
+	tmp, err := os.CreateTemp(ds.storageDir, item.ID.String()+".*"+tempSuffix)
+	if err != nil {
+		return fmt.Errorf("failed to write news item: %w", err)
+	}
+	tmpName := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmpName)
+		return fmt.Errorf("failed to write news item: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmpName)
+		return fmt.Errorf("failed to write news item: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmpName)
+		return fmt.Errorf("failed to write news item: %w", err)
+	}
+
+	if err := os.Rename(tmpName, ds.itemPath(item.ID)); err != nil {
+		_ = os.Remove(tmpName)
+		return fmt.Errorf("failed to write news item: %w", err)
+	}
+
+	return nil
+}
+
+// quarantine moves a file that can't be parsed into the quarantine
+// directory and returns its new path.
+func (ds *dirStore) quarantine(name string) (string, error) {
+	dir := filepath.Join(ds.storageDir, QuarantineDir)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	dest := filepath.Join(dir, name)
+	if err := os.Rename(filepath.Join(ds.storageDir, name), dest); err != nil {
+		return "", err
+	}
+	return dest, nil
+}
+
+// List returns all news items in the directory.
+func (ds *dirStore) List() (*ListResult, error) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	return ds.scan()
+}
+
+// scan reads every item file and rebuilds the index from what it finds. The
+// caller must hold ds.mu.
+func (ds *dirStore) scan() (*ListResult, error) {
+	// Taken before reading so that a change made during the scan leaves the
+	// index stale rather than silently missing it
+	modTime, err := ds.dirModTime()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read storage directory: %w", err)
+	}
+
+	entries, err := os.ReadDir(ds.storageDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read storage directory: %w", err)
+	}
+
+	result := &ListResult{}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+
+		// Read the file
+		filename := filepath.Join(ds.storageDir, entry.Name())
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			result.Errors = append(result.Errors, ReadError{
+				Filename: entry.Name(),
+				Err:      err,
+			})
+			continue
+		}
+
+		// Unmarshal the news item; a file that isn't valid JSON is moved
+		// aside so it stops being reported on every read
+		var item NewsItem
+		if err := json.Unmarshal(data, &item); err != nil {
+			readErr := ReadError{
+				Filename: entry.Name(),
+				Err:      err,
+			}
+			if dest, qErr := ds.quarantine(entry.Name()); qErr == nil {
+				readErr.QuarantinedTo = dest
+			}
+			result.Errors = append(result.Errors, readErr)
+			continue
+		}
+
+		result.Items = append(result.Items, item)
+	}
+
+	ds.index = newDirIndex(result.Items, modTime)
+	return result, nil
+}
+
+// Get retrieves a news item by its ID.
+func (ds *dirStore) Get(id uuid.UUID) (*NewsItem, error) {
+	filename := ds.itemPath(id)
+
+	// Read the file
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil // Item not found (not an error)
+		}
+		return nil, fmt.Errorf("failed to read news item: %w", err)
+	}
+
+	// Unmarshal the news item
+	var item NewsItem
+	if err := json.Unmarshal(data, &item); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal news item: %w", err)
+	}
+
+	return &item, nil
+}
+
+// Delete removes a news item file by its ID.
+func (ds *dirStore) Delete(id uuid.UUID) error {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	wasFresh := ds.indexFresh()
+	filename := ds.itemPath(id)
+	if err := os.Remove(filename); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("failed to delete news item: %w", ErrItemNotFound)
+		}
+		return fmt.Errorf("failed to delete news item: %w", err)
+	}
+	ds.afterWrite(wasFresh, func(idx *dirIndex) { idx.remove(id) })
+	return ds.logChange(id, ChangeDeleted)
+}
+
+// Update updates an existing news item file if its version is the stored
+// one. Only writers in this process are excluded while the versions are
+// compared.
+func (ds *dirStore) Update(item NewsItem) error {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	// Check if the item exists, and hasn't been saved since it was read
+	data, err := os.ReadFile(ds.itemPath(item.ID))
+	if os.IsNotExist(err) {
+		return ErrItemNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read news item: %w", err)
+	}
+	version, err := storedVersion(data)
+	if err != nil {
+		return err
+	}
+	if version != item.Version {
+		return ErrVersionConflict
+	}
+	item.Version++
+
+	wasFresh := ds.indexFresh()
+	if err := ds.writeItem(item); err != nil {
+		return err
+	}
+	ds.afterWrite(wasFresh, func(idx *dirIndex) { idx.put(item) })
+	return ds.logChange(item.ID, ChangeUpdated)
+}
+
+// Check scans every file in the directory for problems: leftover temp files
+// from interrupted writes, files that aren't valid JSON, files whose name
+// doesn't match the item ID inside them, and loose permissions. With repair
+// set, temp files are removed, unparseable files are quarantined, misnamed
+// files are renamed (or quarantined if the correct name is taken), and
+// permissions are set to 0600.
+func (ds *dirStore) Check(repair bool) (*CheckReport, error) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	// Repairs rename and remove files, so rebuild the index afterward
+	if repair {
+		defer func() { ds.index = nil }()
+	}
+
+	entries, err := os.ReadDir(ds.storageDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read storage directory: %w", err)
+	}
+
+	report := &CheckReport{}
+	addIssue := func(name, problem string, fix func() error) {
+		issue := CheckIssue{Filename: name, Problem: problem}
+		if repair {
+			if err := fix(); err != nil {
+				issue.Problem += fmt.Sprintf(" (repair failed: %v)", err)
+			} else {
+				issue.Repaired = true
+			}
+		}
+		report.Issues = append(report.Issues, issue)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		path := filepath.Join(ds.storageDir, name)
+
+		if strings.HasSuffix(name, tempSuffix) {
+			info, err := entry.Info()
+			if err != nil || time.Since(info.ModTime()) < staleTempAge {
+				continue
+			}
+			addIssue(name, "leftover temp file from an interrupted write", func() error {
+				return os.Remove(path)
+			})
+			continue
+		}
+		if filepath.Ext(name) != ".json" {
+			continue
+		}
+		report.Checked++
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			report.Issues = append(report.Issues, CheckIssue{Filename: name, Problem: err.Error()})
+			continue
+		}
+
+		var item NewsItem
+		if err := json.Unmarshal(data, &item); err != nil {
+			addIssue(name, fmt.Sprintf("invalid JSON: %v", err), func() error {
+				_, err := ds.quarantine(name)
+				return err
+			})
+			continue
+		}
+
+		if want := item.ID.String() + ".json"; name != want {
+			if _, err := os.Stat(ds.itemPath(item.ID)); err == nil {
+				addIssue(name, fmt.Sprintf("holds item %s, which already has its own file", item.ID), func() error {
+					_, err := ds.quarantine(name)
+					return err
+				})
+				continue
+			}
+			addIssue(name, fmt.Sprintf("holds item %s; should be named %s", item.ID, want), func() error {
+				if err := os.Rename(path, ds.itemPath(item.ID)); err != nil {
+					return err
+				}
+				path = ds.itemPath(item.ID)
+				return nil
+			})
+		}
+
+		if info, err := os.Stat(path); err == nil && info.Mode().Perm()&0o077 != 0 {
+			addIssue(name, fmt.Sprintf("permissions are %o, expected 600", info.Mode().Perm()), func() error {
+				return os.Chmod(path, 0o600)
+			})
+		}
+	}
+
+	return report, nil
+}
+
+// Ping checks that the storage directory is writable by creating and
+// removing a temporary file in it.
+func (ds *dirStore) Ping() error {
+	f, err := os.CreateTemp(ds.storageDir, ".ping-*")
+	if err != nil {
+		return fmt.Errorf("feed directory is not writable: %w", err)
+	}
+	_ = f.Close()
+	return os.Remove(f.Name())
+}
+
+// Close is a no-op for the directory store.
+func (ds *dirStore) Close() error {
+	return nil
+}
@@ -0,0 +1,226 @@
+package newsfeed
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Formats accepted by Export. RSS and Atom are feeds for other readers;
+// JSON, CSV, and Markdown are records for scripts and note-taking tools.
+const (
+	ExportRSS      = "rss"
+	ExportAtom     = "atom"
+	ExportJSON     = "json"
+	ExportCSV      = "csv"
+	ExportMarkdown = "markdown"
+)
+
+// exportContentTypes maps each export format to its media type.
+var exportContentTypes = map[string]string{
+	ExportRSS:      "application/rss+xml",
+	ExportAtom:     "application/atom+xml",
+	ExportJSON:     "application/json",
+	ExportCSV:      "text/csv; charset=utf-8",
+	ExportMarkdown: "text/markdown; charset=utf-8",
+}
+
+// ExportContentType returns the media type of the export format,
+// or false if there is no such format.
+func ExportContentType(format string) (string, bool) {
+	contentType, ok := exportContentTypes[format]
+	return contentType, ok
+}
+
+// ExportInfo describes the feed that exported items are published in.
+type ExportInfo struct {
+	Title       string
+	Link        string // Address of the site or page the feed describes
+	Description string
+	// Updated is the time the feed last changed. When zero, the most recent
+	// discovery time of the exported items is used.
+	Updated time.Time
+	// Fields names the item fields written by the JSON, CSV, and Markdown
+	// formats, in order. When empty, DefaultExportFields is used.
+	Fields []string
+}
+
+// Export writes items as an RSS 2.0 or Atom feed so that other feed readers
+// can subscribe to them, or as JSON, CSV, or Markdown records of the fields
+// in info.Fields. Items are written in the order given.
+func Export(w io.Writer, format string, info ExportInfo, items []NewsItem) error {
+	switch format {
+	case ExportJSON, ExportCSV, ExportMarkdown:
+		return exportRecords(w, format, info, items)
+	}
+	if len(info.Fields) > 0 {
+		return fmt.Errorf("fields can only be selected for json, csv, and markdown exports")
+	}
+
+	if info.Updated.IsZero() {
+		for _, item := range items {
+			if item.DiscoveredAt.After(info.Updated) {
+				info.Updated = item.DiscoveredAt
+			}
+		}
+		if info.Updated.IsZero() {
+			info.Updated = time.Now()
+		}
+	}
+
+	var doc any
+	switch format {
+	case ExportRSS:
+		doc = rssDocument(info, items)
+	case ExportAtom:
+		doc = atomDocument(info, items)
+	default:
+		return fmt.Errorf("invalid export format: %s (must be rss, atom, json, csv, or markdown)", format)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("failed to encode feed: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	DCNS    string     `xml:"xmlns:dc,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Generator     string    `xml:"generator"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link,omitempty"`
+	Description string   `xml:"description,omitempty"`
+	Creators    []string `xml:"dc:creator"`
+	PubDate     string   `xml:"pubDate"`
+	GUID        rssGUID  `xml:"guid"`
+	Publisher   string   `xml:"dc:publisher,omitempty"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// rssDocument builds an RSS 2.0 feed. Authors are written as dc:creator
+// because RSS's own author element must be an email address.
+func rssDocument(info ExportInfo, items []NewsItem) rssFeed {
+	feed := rssFeed{
+		Version: "2.0",
+		DCNS:    "http://purl.org/dc/elements/1.1/",
+		Channel: rssChannel{
+			Title:         info.Title,
+			Link:          info.Link,
+			Description:   info.Description,
+			LastBuildDate: info.Updated.UTC().Format(time.RFC1123Z),
+			Generator:     "newsfed",
+		},
+	}
+
+	for _, item := range items {
+		entry := rssItem{
+			Title:       item.Title,
+			Link:        item.URL,
+			Description: item.Summary,
+			Creators:    item.Authors,
+			PubDate:     item.PublishedAt.UTC().Format(time.RFC1123Z),
+			GUID:        rssGUID{Value: "urn:uuid:" + item.ID.String()},
+		}
+		if item.Publisher != nil {
+			entry.Publisher = *item.Publisher
+		}
+		feed.Channel.Items = append(feed.Channel.Items, entry)
+	}
+
+	return feed
+}
+
+type atomFeed struct {
+	XMLName   xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title     string      `xml:"title"`
+	Subtitle  string      `xml:"subtitle,omitempty"`
+	ID        string      `xml:"id"`
+	Updated   string      `xml:"updated"`
+	Links     []atomLink  `xml:"link"`
+	Author    atomAuthor  `xml:"author"`
+	Generator string      `xml:"generator"`
+	Entries   []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	Title     string       `xml:"title"`
+	ID        string       `xml:"id"`
+	Updated   string       `xml:"updated"`
+	Published string       `xml:"published"`
+	Links     []atomLink   `xml:"link"`
+	Authors   []atomAuthor `xml:"author"`
+	Summary   string       `xml:"summary,omitempty"`
+}
+
+// atomDocument builds an Atom feed. Atom requires an author for every entry,
+// so the feed title is given as the feed-level author that entries without
+// their own authors inherit.
+func atomDocument(info ExportInfo, items []NewsItem) atomFeed {
+	updated := info.Updated.UTC().Format(time.RFC3339)
+	feed := atomFeed{
+		Title:     info.Title,
+		Subtitle:  info.Description,
+		ID:        info.Link,
+		Updated:   updated,
+		Author:    atomAuthor{Name: info.Title},
+		Generator: "newsfed",
+	}
+	if info.Link != "" {
+		feed.Links = []atomLink{{Href: info.Link}}
+	} else {
+		feed.ID = "urn:newsfed:export"
+	}
+
+	for _, item := range items {
+		entry := atomEntry{
+			Title:     item.Title,
+			ID:        "urn:uuid:" + item.ID.String(),
+			Updated:   item.DiscoveredAt.UTC().Format(time.RFC3339),
+			Published: item.PublishedAt.UTC().Format(time.RFC3339),
+			Summary:   item.Summary,
+		}
+		if item.URL != "" {
+			entry.Links = []atomLink{{Href: item.URL, Rel: "alternate"}}
+		}
+		for _, author := range item.Authors {
+			entry.Authors = append(entry.Authors, atomAuthor{Name: author})
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	return feed
+}
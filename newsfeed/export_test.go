@@ -0,0 +1,59 @@
+package newsfeed
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExport_RoundTrips verifies that exported RSS and Atom feeds parse back
+// into the same items
+func TestExport_RoundTrips(t *testing.T) {
+	item := createTestItem("Exported & <escaped>")
+	item.URL = "https://example.com/posts/1?a=1&b=2"
+	item.PublishedAt = time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+	info := ExportInfo{Title: "My feed", Link: "https://example.com/", Description: "Things I read"}
+
+	for _, format := range []string{ExportRSS, ExportAtom} {
+		var buf bytes.Buffer
+		require.NoError(t, Export(&buf, format, info, []NewsItem{item}))
+
+		parsed, err := gofeed.NewParser().ParseString(buf.String())
+		require.NoError(t, err, "%s output should parse", format)
+		assert.Equal(t, format, parsed.FeedType)
+		assert.Equal(t, "My feed", parsed.Title)
+
+		require.Len(t, parsed.Items, 1)
+		got := parsed.Items[0]
+		assert.Equal(t, item.Title, got.Title)
+		assert.Equal(t, item.URL, got.Link)
+		assert.Equal(t, item.Summary, got.Description)
+		assert.Equal(t, "urn:uuid:"+item.ID.String(), got.GUID)
+		require.NotNil(t, got.PublishedParsed)
+		assert.True(t, item.PublishedAt.Equal(*got.PublishedParsed))
+
+		// gofeed keeps every dc:creator only in the extension data
+		var authors []string
+		if format == ExportRSS {
+			require.NotNil(t, got.DublinCoreExt)
+			authors = got.DublinCoreExt.Creator
+		} else {
+			for _, author := range got.Authors {
+				authors = append(authors, author.Name)
+			}
+		}
+		assert.Equal(t, item.Authors, authors)
+	}
+}
+
+// TestExport_InvalidFormat verifies that unknown formats are rejected
+func TestExport_InvalidFormat(t *testing.T) {
+	var buf bytes.Buffer
+	err := Export(&buf, "yaml", ExportInfo{}, nil)
+	assert.Error(t, err)
+	assert.Zero(t, buf.Len())
+}
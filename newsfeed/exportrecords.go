@@ -0,0 +1,256 @@
+package newsfeed
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DefaultExportFields are the fields written by record exports when none
+// are selected.
+var DefaultExportFields = []string{
+	"id", "title", "url", "publisher", "authors", "published_at", "pinned_at",
+	"summary", "notes",
+}
+
+// exportField is an item field that record exports can write. Its name is
+// the field's JSON name in stored items.
+type exportField struct {
+	name  string
+	value func(item NewsItem) any // string, []string, or nil
+}
+
+// exportFields lists the fields record exports can write, in the order
+// ExportFieldNames returns them.
+var exportFields = []exportField{
+	{"id", func(item NewsItem) any { return item.ID.String() }},
+	{"title", func(item NewsItem) any { return item.Title }},
+	{"url", func(item NewsItem) any { return item.URL }},
+	{"summary", func(item NewsItem) any { return item.DisplaySummary() }},
+	{"publisher", func(item NewsItem) any { return optionalString(item.Publisher) }},
+	{"authors", func(item NewsItem) any { return item.Authors }},
+	{"category", func(item NewsItem) any { return optionalString(item.Category) }},
+	{"language", func(item NewsItem) any { return item.Language }},
+	{"published_at", func(item NewsItem) any { return exportTime(&item.PublishedAt) }},
+	{"discovered_at", func(item NewsItem) any { return exportTime(&item.DiscoveredAt) }},
+	{"pinned_at", func(item NewsItem) any { return exportTime(item.PinnedAt) }},
+	{"read_at", func(item NewsItem) any { return exportTime(item.ReadAt) }},
+	{"starred_at", func(item NewsItem) any { return exportTime(item.StarredAt) }},
+	{"notes", func(item NewsItem) any { return item.Notes }},
+	{"source_id", func(item NewsItem) any {
+		if item.SourceID == nil {
+			return nil
+		}
+		return item.SourceID.String()
+	}},
+}
+
+// ExportFieldNames returns the names of the fields record exports can
+// write.
+func ExportFieldNames() []string {
+	names := make([]string, len(exportFields))
+	for i, f := range exportFields {
+		names[i] = f.name
+	}
+	return names
+}
+
+// ParseExportFields splits a comma-separated list of field names and checks
+// that each is one ExportFieldNames returns.
+func ParseExportFields(list string) ([]string, error) {
+	var fields []string
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if lookupExportField(name) == nil {
+			return nil, fmt.Errorf("unknown export field: %s (must be one of %s)", name, strings.Join(ExportFieldNames(), ", "))
+		}
+		if !slices.Contains(fields, name) {
+			fields = append(fields, name)
+		}
+	}
+	return fields, nil
+}
+
+// lookupExportField returns the export field called name, or nil.
+func lookupExportField(name string) *exportField {
+	for i := range exportFields {
+		if exportFields[i].name == name {
+			return &exportFields[i]
+		}
+	}
+	return nil
+}
+
+// optionalString returns *s, or nil if s is nil.
+func optionalString(s *string) any {
+	if s == nil {
+		return nil
+	}
+	return *s
+}
+
+// exportTime formats t in RFC 3339, or returns nil if t is unset.
+func exportTime(t *time.Time) any {
+	if t == nil || t.IsZero() {
+		return nil
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// exportRecords writes the selected fields of items as JSON, CSV, or
+// Markdown.
+func exportRecords(w io.Writer, format string, info ExportInfo, items []NewsItem) error {
+	names := info.Fields
+	if len(names) == 0 {
+		names = DefaultExportFields
+	}
+	fields := make([]*exportField, len(names))
+	for i, name := range names {
+		if fields[i] = lookupExportField(name); fields[i] == nil {
+			return fmt.Errorf("unknown export field: %s", name)
+		}
+	}
+
+	switch format {
+	case ExportJSON:
+		return exportJSON(w, fields, items)
+	case ExportCSV:
+		return exportCSV(w, fields, items)
+	default:
+		return exportMarkdown(w, info, fields, items)
+	}
+}
+
+// exportJSON writes items as an array of objects whose keys are in the
+// order the fields were selected.
+func exportJSON(w io.Writer, fields []*exportField, items []NewsItem) error {
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i, item := range items {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('{')
+		for j, f := range fields {
+			if j > 0 {
+				buf.WriteByte(',')
+			}
+			key, _ := json.Marshal(f.name)
+			value, err := json.Marshal(f.value(item))
+			if err != nil {
+				return fmt.Errorf("failed to encode %s: %w", f.name, err)
+			}
+			buf.Write(key)
+			buf.WriteByte(':')
+			buf.Write(value)
+		}
+		buf.WriteByte('}')
+	}
+	buf.WriteByte(']')
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, buf.Bytes(), "", "  "); err != nil {
+		return fmt.Errorf("failed to encode items: %w", err)
+	}
+	out.WriteByte('\n')
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+// exportCSV writes a header row of field names and then a row per item.
+// Lists are joined with "; " and unset values are empty.
+func exportCSV(w io.Writer, fields []*exportField, items []NewsItem) error {
+	cw := csv.NewWriter(w)
+	header := make([]string, len(fields))
+	for i, f := range fields {
+		header[i] = f.name
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, item := range items {
+		row := make([]string, len(fields))
+		for i, f := range fields {
+			row[i] = exportText(f.value(item))
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// exportMarkdown writes a section per item headed by its title, linked to
+// its URL, with the other fields as a list beneath. Summaries and notes
+// are written as paragraphs.
+func exportMarkdown(w io.Writer, info ExportInfo, fields []*exportField, items []NewsItem) error {
+	var b strings.Builder
+	if info.Title != "" {
+		fmt.Fprintf(&b, "# %s\n", info.Title)
+	}
+
+	for _, item := range items {
+		var list, paragraphs []string
+		hasTitle, hasURL := false, false
+		for _, f := range fields {
+			switch f.name {
+			case "title":
+				hasTitle = true
+				continue
+			case "url":
+				hasURL = true
+				continue
+			}
+			text := exportText(f.value(item))
+			if text == "" {
+				continue
+			}
+			if f.name == "summary" || f.name == "notes" {
+				paragraphs = append(paragraphs, text)
+			} else {
+				list = append(list, fmt.Sprintf("- %s: %s", f.name, text))
+			}
+		}
+
+		heading := item.ID.String()
+		if hasTitle && item.Title != "" {
+			heading = item.Title
+		}
+		if hasURL && item.URL != "" {
+			heading = fmt.Sprintf("[%s](%s)", heading, item.URL)
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "## %s\n", heading)
+		if len(list) > 0 {
+			b.WriteString("\n" + strings.Join(list, "\n") + "\n")
+		}
+		for _, p := range paragraphs {
+			b.WriteString("\n" + p + "\n")
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// exportText formats a field value as plain text.
+func exportText(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []string:
+		return strings.Join(v, "; ")
+	}
+	return ""
+}
@@ -0,0 +1,107 @@
+package newsfeed
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createPinnedExportItem returns a pinned item with fixed times
+func createPinnedExportItem(title string) NewsItem {
+	item := createTestItem(title)
+	item.PublishedAt = time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+	pinnedAt := time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC)
+	item.PinnedAt = &pinnedAt
+	item.Notes = "Read before Friday"
+	return item
+}
+
+// TestExport_JSONFields verifies that JSON exports write the selected fields
+// in order, with unset values as null
+func TestExport_JSONFields(t *testing.T) {
+	item := createPinnedExportItem("First")
+	info := ExportInfo{Fields: []string{"title", "url", "pinned_at", "read_at", "authors"}}
+
+	var buf bytes.Buffer
+	require.NoError(t, Export(&buf, ExportJSON, info, []NewsItem{item}))
+
+	var got []map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+	require.Len(t, got, 1)
+	assert.Equal(t, map[string]any{
+		"title":     "First",
+		"url":       item.URL,
+		"pinned_at": "2026-03-05T00:00:00Z",
+		"read_at":   nil,
+		"authors":   []any{"Author 1", "Author 2"},
+	}, got[0])
+
+	out := buf.String()
+	assert.Less(t, strings.Index(out, `"title"`), strings.Index(out, `"url"`))
+	assert.Less(t, strings.Index(out, `"pinned_at"`), strings.Index(out, `"authors"`))
+}
+
+// TestExport_JSONEmpty verifies that exporting no items writes an empty
+// array
+func TestExport_JSONEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Export(&buf, ExportJSON, ExportInfo{}, nil))
+	assert.Equal(t, "[]\n", buf.String())
+}
+
+// TestExport_CSVDefaultFields verifies the CSV header and the formatting of
+// lists and unset values
+func TestExport_CSVDefaultFields(t *testing.T) {
+	item := createPinnedExportItem("Quoted, \"title\"")
+	item.Publisher = nil
+
+	var buf bytes.Buffer
+	require.NoError(t, Export(&buf, ExportCSV, ExportInfo{}, []NewsItem{item}))
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, DefaultExportFields, rows[0])
+	assert.Equal(t, []string{
+		item.ID.String(), item.Title, item.URL, "", "Author 1; Author 2",
+		"2026-03-04T05:06:07Z", "2026-03-05T00:00:00Z", item.Summary, "Read before Friday",
+	}, rows[1])
+}
+
+// TestExport_Markdown verifies that Markdown exports head each item with its
+// linked title and list the other fields beneath
+func TestExport_Markdown(t *testing.T) {
+	item := createPinnedExportItem("First")
+	info := ExportInfo{Title: "Pinned", Fields: []string{"title", "url", "publisher", "pinned_at", "notes"}}
+
+	var buf bytes.Buffer
+	require.NoError(t, Export(&buf, ExportMarkdown, info, []NewsItem{item}))
+	assert.Equal(t, "# Pinned\n\n## [First]("+item.URL+")\n\n"+
+		"- publisher: Test Publisher\n- pinned_at: 2026-03-05T00:00:00Z\n\n"+
+		"Read before Friday\n", buf.String())
+}
+
+// TestExport_FieldErrors verifies that unknown fields are rejected and that
+// feeds don't accept a field selection
+func TestExport_FieldErrors(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Error(t, Export(&buf, ExportJSON, ExportInfo{Fields: []string{"bogus"}}, nil))
+	assert.Error(t, Export(&buf, ExportRSS, ExportInfo{Fields: []string{"title"}}, nil))
+}
+
+// TestParseExportFields verifies that field lists are split, trimmed,
+// deduplicated, and checked
+func TestParseExportFields(t *testing.T) {
+	fields, err := ParseExportFields(" title, url,,title ")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"title", "url"}, fields)
+
+	_, err = ParseExportFields("title,bogus")
+	assert.ErrorContains(t, err, "bogus")
+}
@@ -0,0 +1,78 @@
+package newsfeed
+
+import (
+	"cmp"
+	"slices"
+	"time"
+)
+
+// FacetCount is the number of items that share one value of a field.
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// Facets counts the items matching a query by publisher, category, source,
+// and the day they were published. Items without a publisher, category, or
+// source aren't counted under those fields.
+type Facets struct {
+	Total      int          `json:"total"`
+	Publishers []FacetCount `json:"publishers"`
+	Categories []FacetCount `json:"categories"`
+	Sources    []FacetCount `json:"sources"` // By source ID
+	Days       []FacetCount `json:"days"`    // By published date in UTC, as YYYY-MM-DD
+}
+
+// Facets returns the counts of the items matching the filters in opts.
+// Sorting and pagination are ignored. Publishers, categories, and sources
+// are ordered by count, highest first, and days most recent first.
+func (nf *NewsFeed) Facets(opts ListOptions) (*Facets, error) {
+	opts.SortBy, opts.Limit, opts.Offset, opts.Cursor = "", 0, 0, ""
+	result, err := nf.Query(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	publishers := map[string]int{}
+	categories := map[string]int{}
+	sources := map[string]int{}
+	days := map[string]int{}
+	for _, item := range result.Items {
+		if item.Publisher != nil && *item.Publisher != "" {
+			publishers[*item.Publisher]++
+		}
+		if item.Category != nil && *item.Category != "" {
+			categories[*item.Category]++
+		}
+		if item.SourceID != nil {
+			sources[item.SourceID.String()]++
+		}
+		days[item.PublishedAt.UTC().Format(time.DateOnly)]++
+	}
+
+	dayCounts := facetCounts(days)
+	slices.SortFunc(dayCounts, func(a, b FacetCount) int { return cmp.Compare(b.Value, a.Value) })
+	return &Facets{
+		Total:      result.Total,
+		Publishers: facetCounts(publishers),
+		Categories: facetCounts(categories),
+		Sources:    facetCounts(sources),
+		Days:       dayCounts,
+	}, nil
+}
+
+// facetCounts returns counts as a list, highest count first and then by
+// value.
+func facetCounts(counts map[string]int) []FacetCount {
+	list := make([]FacetCount, 0, len(counts))
+	for value, count := range counts {
+		list = append(list, FacetCount{Value: value, Count: count})
+	}
+	slices.SortFunc(list, func(a, b FacetCount) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Value, b.Value)
+	})
+	return list
+}
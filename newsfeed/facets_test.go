@@ -0,0 +1,51 @@
+package newsfeed
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNewsFeed_Facets verifies that matching items are counted by
+// publisher, category, source, and published day, and that pagination is
+// ignored
+func TestNewsFeed_Facets(t *testing.T) {
+	feed := NewMemoryNewsFeed()
+
+	goBlog, daily := "Go Blog", "The Daily"
+	golang := "golang"
+	sourceID := uuid.New()
+	day := time.Date(2024, time.March, 3, 23, 30, 0, 0, time.FixedZone("", -2*60*60))
+	for i, publisher := range []*string{&goBlog, &goBlog, &daily, nil} {
+		item := createTestItem("item")
+		item.Publisher = publisher
+		item.PublishedAt = day.AddDate(0, 0, -i/2)
+		if i < 3 {
+			item.Category = &golang
+			item.SourceID = &sourceID
+		}
+		require.NoError(t, feed.Add(item))
+	}
+	archived := createTestItem("archived")
+	archived.Publisher = &daily
+	now := time.Now()
+	archived.ArchivedAt = &now
+	require.NoError(t, feed.Add(archived))
+
+	facets, err := feed.Facets(ListOptions{Limit: 1, Offset: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 4, facets.Total)
+	assert.Equal(t, []FacetCount{{"Go Blog", 2}, {"The Daily", 1}}, facets.Publishers)
+	assert.Equal(t, []FacetCount{{"golang", 3}}, facets.Categories)
+	assert.Equal(t, []FacetCount{{sourceID.String(), 3}}, facets.Sources)
+	assert.Equal(t, []FacetCount{{"2024-03-04", 2}, {"2024-03-03", 2}}, facets.Days, "days are in UTC, most recent first")
+
+	facets, err = feed.Facets(ListOptions{Publisher: "daily", Archived: true})
+	require.NoError(t, err)
+	assert.Equal(t, 1, facets.Total)
+	assert.Equal(t, []FacetCount{{"The Daily", 1}}, facets.Publishers)
+	assert.Empty(t, facets.Categories)
+}
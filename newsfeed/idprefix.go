@@ -0,0 +1,183 @@
+package newsfeed
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// ErrAmbiguousID is returned by ResolveID when an ID prefix matches more
+// than one item.
+var ErrAmbiguousID = errors.New("item ID prefix matches more than one item")
+
+// ErrInvalidIDPrefix is returned by ResolveID for a string that is neither
+// an item ID nor a prefix of one.
+var ErrInvalidIDPrefix = errors.New("invalid item ID")
+
+// MinIDPrefix is the shortest ID prefix ResolveID looks up. Shorter ones
+// would match too many items to be worth the lookup.
+const MinIDPrefix = 4
+
+// idMatcher is implemented by stores that can find the items whose IDs
+// start with a prefix without reading every item.
+type idMatcher interface {
+	// MatchIDs returns up to limit IDs starting with prefix, which is
+	// lowercase and made of hex digits and dashes.
+	MatchIDs(prefix string, limit int) ([]uuid.UUID, error)
+}
+
+// ResolveID returns the ID of the item whose ID is s, or starts with s, so
+// that an item can be named by the first few characters of its ID as it's
+// printed. A trailing "..." is ignored, since compact listings print IDs
+// cut short with one. A prefix matching no item returns ErrItemNotFound,
+// and one matching several ErrAmbiguousID.
+func (nf *NewsFeed) ResolveID(s string) (uuid.UUID, error) {
+	if id, err := uuid.Parse(s); err == nil {
+		return id, nil
+	}
+
+	prefix, err := NormalizeIDPrefix(s)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	var matches []uuid.UUID
+	if matcher, ok := idMatcherOf(nf.store); ok {
+		matches, err = matcher.MatchIDs(prefix, 2)
+	} else {
+		matches, err = nf.matchListedIDs(prefix)
+	}
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to look up item ID: %w", err)
+	}
+
+	switch len(matches) {
+	case 0:
+		return uuid.Nil, fmt.Errorf("%w: %s", ErrItemNotFound, s)
+	case 1:
+		return matches[0], nil
+	default:
+		return uuid.Nil, fmt.Errorf("%w: %s", ErrAmbiguousID, s)
+	}
+}
+
+// NormalizeIDPrefix returns s as a lowercase ID prefix, without the "..."
+// that compact listings end IDs with. It returns ErrInvalidIDPrefix if s is
+// shorter than MinIDPrefix or has anything but hex digits and dashes.
+func NormalizeIDPrefix(s string) (string, error) {
+	prefix := strings.ToLower(strings.TrimSuffix(strings.TrimSpace(s), "..."))
+	if len(prefix) < MinIDPrefix || len(prefix) > 36 {
+		return "", fmt.Errorf("%w: %s", ErrInvalidIDPrefix, s)
+	}
+	for _, r := range prefix {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') && r != '-' {
+			return "", fmt.Errorf("%w: %s", ErrInvalidIDPrefix, s)
+		}
+	}
+	return prefix, nil
+}
+
+// matchListedIDs finds the IDs starting with prefix by listing every item,
+// for stores that can't match IDs themselves.
+func (nf *NewsFeed) matchListedIDs(prefix string) ([]uuid.UUID, error) {
+	result, err := nf.store.List()
+	if err != nil {
+		return nil, err
+	}
+	var matches []uuid.UUID
+	for _, item := range result.Items {
+		if strings.HasPrefix(item.ID.String(), prefix) {
+			matches = append(matches, item.ID)
+		}
+	}
+	return matches, nil
+}
+
+// idMatcherOf returns store, or the base store of a per-user view, as an
+// idMatcher if it is one. Item IDs are the same in every user's view.
+func idMatcherOf(store Store) (idMatcher, bool) {
+	if user, ok := store.(*userStore); ok {
+		store = user.base
+	}
+	matcher, ok := store.(idMatcher)
+	return matcher, ok
+}
+
+// MatchIDs returns up to limit IDs of stored items starting with prefix.
+func (s *memoryStore) MatchIDs(prefix string, limit int) ([]uuid.UUID, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var matches []uuid.UUID
+	for _, id := range s.order {
+		if len(matches) == limit {
+			break
+		}
+		if strings.HasPrefix(id.String(), prefix) {
+			matches = append(matches, id)
+		}
+	}
+	return matches, nil
+}
+
+// MatchIDs returns up to limit IDs of stored items starting with prefix,
+// going by the names of the item files alone.
+func (ds *dirStore) MatchIDs(prefix string, limit int) ([]uuid.UUID, error) {
+	entries, err := os.ReadDir(ds.storageDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read storage directory: %w", err)
+	}
+	var matches []uuid.UUID
+	for _, entry := range entries {
+		if len(matches) == limit {
+			break
+		}
+		name := entry.Name()
+		if entry.IsDir() || filepath.Ext(name) != ".json" || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if id, err := uuid.Parse(strings.TrimSuffix(name, ".json")); err == nil {
+			matches = append(matches, id)
+		}
+	}
+	return matches, nil
+}
+
+// MatchIDs returns up to limit IDs of stored items starting with prefix.
+func (s *sqliteStore) MatchIDs(prefix string, limit int) ([]uuid.UUID, error) {
+	return matchSQLIDs(s.db.Query, prefix, limit)
+}
+
+// MatchIDs returns up to limit IDs of stored items starting with prefix.
+func (s *postgresStore) MatchIDs(prefix string, limit int) ([]uuid.UUID, error) {
+	return matchSQLIDs(s.db.Query, prefix, limit)
+}
+
+// matchSQLIDs returns up to limit IDs in the items table starting with
+// prefix, using query to run the lookup. The prefix has no LIKE wildcards,
+// since NormalizeIDPrefix only lets hex digits and dashes through.
+func matchSQLIDs(query func(string, ...any) (*sql.Rows, error), prefix string, limit int) ([]uuid.UUID, error) {
+	rows, err := query("SELECT id FROM items WHERE id LIKE ? ORDER BY id LIMIT ?", prefix+"%", limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to match item IDs: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var matches []uuid.UUID
+	for rows.Next() {
+		var raw string
+		if err := rows.Scan(&raw); err != nil {
+			return nil, fmt.Errorf("failed to scan item ID: %w", err)
+		}
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid item ID in database: %w", err)
+		}
+		matches = append(matches, id)
+	}
+	return matches, rows.Err()
+}
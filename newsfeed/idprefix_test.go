@@ -0,0 +1,71 @@
+package newsfeed
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestResolveID_Prefixes verifies that every backend resolves a full ID, a
+// unique prefix in any case, and a compact listing's ID with its "...", and
+// refuses ambiguous, unknown, short, and malformed prefixes
+func TestResolveID_Prefixes(t *testing.T) {
+	backends := map[string]func(t *testing.T) *NewsFeed{
+		"directory": func(t *testing.T) *NewsFeed {
+			feed, err := NewNewsFeed(t.TempDir())
+			require.NoError(t, err)
+			return feed
+		},
+		"sqlite":     createTestSQLiteFeed,
+		"memory":     func(t *testing.T) *NewsFeed { return NewMemoryNewsFeed() },
+		"postgresql": createTestPostgresFeed,
+	}
+	for name, open := range backends {
+		t.Run(name, func(t *testing.T) {
+			feed := open(t)
+			first := createTestItem("First")
+			first.ID = uuid.MustParse("1b4e28ba-2fa1-11d2-883f-0016d3cca427")
+			second := createTestItem("Second")
+			second.ID = uuid.MustParse("1b4e9999-2fa1-11d2-883f-0016d3cca427")
+			require.NoError(t, feed.Add(first))
+			require.NoError(t, feed.Add(second))
+
+			id, err := feed.ResolveID(first.ID.String())
+			require.NoError(t, err)
+			assert.Equal(t, first.ID, id)
+
+			id, err = feed.ResolveID("1B4E28")
+			require.NoError(t, err)
+			assert.Equal(t, first.ID, id)
+
+			id, err = feed.ResolveID("1b4e9999...")
+			require.NoError(t, err)
+			assert.Equal(t, second.ID, id)
+
+			_, err = feed.ResolveID("1b4e")
+			assert.ErrorIs(t, err, ErrAmbiguousID)
+			_, err = feed.ResolveID("ffff")
+			assert.ErrorIs(t, err, ErrItemNotFound)
+			_, err = feed.ResolveID("1b4")
+			assert.ErrorIs(t, err, ErrInvalidIDPrefix)
+			_, err = feed.ResolveID("1b4e%")
+			assert.ErrorIs(t, err, ErrInvalidIDPrefix)
+		})
+	}
+}
+
+// TestResolveID_UserView verifies that a per-user view of a feed resolves
+// prefixes of the items in its base store
+func TestResolveID_UserView(t *testing.T) {
+	feed := NewMemoryNewsFeed()
+	item := createTestItem("Shared")
+	require.NoError(t, feed.Add(item))
+
+	view := feed.ForUser("alice", memoryStates{})
+	id, err := view.ResolveID(strings.Split(item.ID.String(), "-")[0])
+	require.NoError(t, err)
+	assert.Equal(t, item.ID, id)
+}
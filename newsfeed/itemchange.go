@@ -0,0 +1,83 @@
+package newsfeed
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+)
+
+// maxItemChanges is how many publisher changes an item keeps; older ones are
+// dropped first.
+const maxItemChanges = 10
+
+// ItemChange records a change a publisher made to an item after it was
+// discovered, such as a corrected headline.
+type ItemChange struct {
+	ChangedAt time.Time `json:"changed_at"`
+	// Previous holds the values that were replaced, keyed by field name
+	// ("title", "summary", or "url")
+	Previous map[string]string `json:"previous"`
+}
+
+// HashContent returns a hash of the fields a publisher may change after an
+// item is discovered: its title, summary, and URL. Runs of whitespace are
+// treated as a single space, so reformatting alone doesn't change the hash.
+func HashContent(item NewsItem) string {
+	sum := sha256.Sum256([]byte(collapseSpace(item.Title) + "\n" + collapseSpace(item.Summary) + "\n" + item.URL))
+	return hex.EncodeToString(sum[:16])
+}
+
+// publisherHash returns the hash of the content the publisher last gave
+// item.
+func publisherHash(item NewsItem) string {
+	if item.ContentHash != "" {
+		return item.ContentHash
+	}
+	return HashContent(item)
+}
+
+// collapseSpace trims s and replaces each run of whitespace with one space.
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// ApplyRevision updates item with the title, summary, and URL of revised, a
+// newer copy of it from its publisher. Each field that changed is recorded
+// in the item's Changes with the value it replaced, and UpdatedAt is set to
+// now. A field the reader edited is replaced too, and the edit is recorded
+// as the value replaced. The reader's state, such as pins and notes, is left
+// alone. It reports whether item changed.
+func (item *NewsItem) ApplyRevision(revised NewsItem, now time.Time) bool {
+	before := item.ContentHash
+	previous := map[string]string{}
+	if collapseSpace(revised.Title) != collapseSpace(item.Title) {
+		previous["title"] = item.Title
+		item.Title = revised.Title
+	}
+	if collapseSpace(revised.Summary) != collapseSpace(item.Summary) {
+		previous["summary"] = item.Summary
+		item.Summary = revised.Summary
+		item.SummaryHTML = revised.SummaryHTML
+	}
+	if revised.URL != "" && revised.URL != item.URL {
+		previous["url"] = item.URL
+		item.URL = revised.URL
+	}
+
+	// The item now holds what the publisher gave, unless revised had no URL
+	item.ContentHash = ""
+	if hash := HashContent(revised); hash != HashContent(*item) {
+		item.ContentHash = hash
+	}
+	if len(previous) == 0 {
+		return item.ContentHash != before
+	}
+
+	item.UpdatedAt = &now
+	item.Changes = append(item.Changes, ItemChange{ChangedAt: now, Previous: previous})
+	if len(item.Changes) > maxItemChanges {
+		item.Changes = item.Changes[len(item.Changes)-maxItemChanges:]
+	}
+	return true
+}
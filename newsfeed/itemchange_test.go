@@ -0,0 +1,83 @@
+package newsfeed
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHashContent verifies the hash follows the title, summary, and URL but
+// not whitespace
+func TestHashContent(t *testing.T) {
+	item := NewsItem{Title: "Big News", Summary: "Something happened.", URL: "https://example.com/1"}
+	reformatted := NewsItem{Title: " Big  News", Summary: "Something\nhappened.", URL: "https://example.com/1"}
+	assert.Equal(t, HashContent(item), HashContent(reformatted))
+
+	for _, changed := range []NewsItem{
+		{Title: "Bigger News", Summary: item.Summary, URL: item.URL},
+		{Title: item.Title, Summary: "Something else happened.", URL: item.URL},
+		{Title: item.Title, Summary: item.Summary, URL: "https://example.com/2"},
+	} {
+		assert.NotEqual(t, HashContent(item), HashContent(changed))
+	}
+}
+
+// TestNewsItem_ApplyRevision verifies changed fields are replaced and
+// audited, and that the reader's state is kept
+func TestNewsItem_ApplyRevision(t *testing.T) {
+	readAt := time.Now().Add(-time.Hour)
+	item := NewsItem{
+		Title:   "Big Nwes",
+		Summary: "Something happened.",
+		URL:     "https://example.com/1",
+		ReadAt:  &readAt,
+		Notes:   "Follow up",
+	}
+
+	now := time.Now().UTC()
+	assert.False(t, item.ApplyRevision(NewsItem{Title: "Big  Nwes", Summary: item.Summary, URL: item.URL}, now))
+	assert.Nil(t, item.UpdatedAt)
+
+	assert.True(t, item.ApplyRevision(NewsItem{Title: "Big News", Summary: item.Summary, URL: "https://example.com/big-news"}, now))
+	assert.Equal(t, "Big News", item.Title)
+	assert.Equal(t, "https://example.com/big-news", item.URL)
+	assert.Equal(t, &now, item.UpdatedAt)
+	assert.Equal(t, &readAt, item.ReadAt)
+	assert.Equal(t, "Follow up", item.Notes)
+	require.Len(t, item.Changes, 1)
+	assert.Equal(t, map[string]string{"title": "Big Nwes", "url": "https://example.com/1"}, item.Changes[0].Previous)
+
+	// Only the most recent changes are kept
+	for i := range maxItemChanges + 2 {
+		item.ApplyRevision(NewsItem{Title: item.Title, Summary: string(rune('a' + i))}, now)
+	}
+	assert.Len(t, item.Changes, maxItemChanges)
+	assert.Equal(t, "b", item.Changes[0].Previous["summary"])
+}
+
+// TestDedupIndex_Revised verifies revisions are only reported for changed
+// items from the same source, and that edits aren't mistaken for them
+func TestDedupIndex_Revised(t *testing.T) {
+	sourceID := uuid.New()
+	item := NewsItem{ID: uuid.New(), Title: "Big News", URL: "https://example.com/1", SourceID: &sourceID}
+	idx := NewDedupIndex([]NewsItem{item})
+
+	assert.False(t, idx.Revised(item.ID, item))
+	revised := item
+	revised.Title = "Bigger News"
+	assert.True(t, idx.Revised(item.ID, revised))
+
+	otherSource := uuid.New()
+	revised.SourceID = &otherSource
+	assert.False(t, idx.Revised(item.ID, revised))
+
+	// An edited item still matches what the publisher gave
+	edited := item
+	edited.ContentHash = HashContent(item)
+	edited.Title = "My Title"
+	idx = NewDedupIndex([]NewsItem{edited})
+	assert.False(t, idx.Revised(item.ID, item))
+}
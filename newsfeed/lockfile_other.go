@@ -0,0 +1,11 @@
+//go:build !unix
+
+package newsfeed
+
+import "os"
+
+// lockFile does nothing on systems without flock, where a directory store
+// must not be shared by several processes.
+func lockFile(file *os.File) error {
+	return nil
+}
@@ -0,0 +1,14 @@
+//go:build unix
+
+package newsfeed
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile takes an exclusive lock on file, waiting until no other process
+// holds one. The lock is released when the file is closed.
+func lockFile(file *os.File) error {
+	return syscall.Flock(int(file.Fd()), syscall.LOCK_EX)
+}
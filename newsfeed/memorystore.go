@@ -0,0 +1,224 @@
+package newsfeed
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MemoryScheme is the DSN prefix that selects the in-memory storage backend
+// (for example, "memory://scratch"). Feeds opened with the same name share
+// their items for the life of the process.
+const MemoryScheme = "memory://"
+
+// memoryStore keeps news items in memory, encoded as JSON as the other
+// stores keep them, so that items read from it can be changed freely
+// without changing what is stored. Nothing is written to disk.
+type memoryStore struct {
+	mu        sync.RWMutex
+	items     map[uuid.UUID][]byte
+	order     []uuid.UUID // IDs in the order items were first added
+	revision  int64
+	changes   []changeRecord // Oldest first
+	changeSeq int64
+}
+
+var (
+	memoryStoresMu sync.Mutex
+	memoryStores   = map[string]*memoryStore{}
+)
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{items: map[uuid.UUID][]byte{}}
+}
+
+// NewMemoryNewsFeed creates an empty news feed held only in memory, for
+// tests and for programs that embed newsfed without storage of their own.
+// Its items are lost when it is no longer referenced.
+func NewMemoryNewsFeed() *NewsFeed {
+	return New(newMemoryStore())
+}
+
+// openMemoryStore returns the in-memory store called name, creating it the
+// first time it is opened.
+func openMemoryStore(name string) *memoryStore {
+	memoryStoresMu.Lock()
+	defer memoryStoresMu.Unlock()
+	store, ok := memoryStores[name]
+	if !ok {
+		store = newMemoryStore()
+		memoryStores[name] = store
+	}
+	return store
+}
+
+// Add saves a news item, replacing any existing item with the same ID.
+func (s *memoryStore) Add(item NewsItem) error {
+	return s.AddAll([]NewsItem{item})
+}
+
+// AddAll saves news items together: either all of them are saved or, if one
+// can't be encoded, none are.
+func (s *memoryStore) AddAll(items []NewsItem) error {
+	encoded := make([][]byte, len(items))
+	for i, item := range items {
+		data, err := json.Marshal(item)
+		if err != nil {
+			return fmt.Errorf("failed to marshal news item: %w", err)
+		}
+		encoded[i] = data
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, item := range items {
+		kind := ChangeUpdated
+		if _, ok := s.items[item.ID]; !ok {
+			s.order = append(s.order, item.ID)
+			kind = ChangeCreated
+		}
+		s.items[item.ID] = encoded[i]
+		s.logChange(item.ID, kind)
+	}
+	s.revision++
+	return nil
+}
+
+// Get retrieves a news item by its ID.
+func (s *memoryStore) Get(id uuid.UUID) (*NewsItem, error) {
+	s.mu.RLock()
+	data, ok := s.items[id]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, nil // Item not found (not an error)
+	}
+
+	var item NewsItem
+	if err := json.Unmarshal(data, &item); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal news item: %w", err)
+	}
+	return &item, nil
+}
+
+// List returns all news items, in the order they were added.
+func (s *memoryStore) List() (*ListResult, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := &ListResult{Items: make([]NewsItem, 0, len(s.order))}
+	for _, id := range s.order {
+		var item NewsItem
+		if err := json.Unmarshal(s.items[id], &item); err != nil {
+			result.Errors = append(result.Errors, ReadError{Filename: id.String(), Err: err})
+			continue
+		}
+		result.Items = append(result.Items, item)
+	}
+	return result, nil
+}
+
+// Update updates an existing news item if its version is the stored one.
+func (s *memoryStore) Update(item NewsItem) error {
+	expected := item.Version
+	item.Version++
+	data, err := json.Marshal(item)
+	if err != nil {
+		return fmt.Errorf("failed to marshal news item: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.items[item.ID]
+	if !ok {
+		return ErrItemNotFound
+	}
+	version, err := storedVersion(stored)
+	if err != nil {
+		return err
+	}
+	if version != expected {
+		return ErrVersionConflict
+	}
+	s.items[item.ID] = data
+	s.logChange(item.ID, ChangeUpdated)
+	s.revision++
+	return nil
+}
+
+// Delete removes a news item by its ID.
+func (s *memoryStore) Delete(id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.items[id]; !ok {
+		return fmt.Errorf("failed to delete news item: %w", ErrItemNotFound)
+	}
+	delete(s.items, id)
+	for i, ordered := range s.order {
+		if ordered == id {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			break
+		}
+	}
+	s.logChange(id, ChangeDeleted)
+	s.revision++
+	return nil
+}
+
+// logChange records a change of the given kind to the item with the given
+// ID. The caller must hold s.mu.
+func (s *memoryStore) logChange(id uuid.UUID, kind string) {
+	s.changeSeq++
+	s.changes = append(s.changes, changeRecord{Seq: s.changeSeq, ID: id, Kind: kind, ChangedAt: time.Now().UTC()})
+}
+
+// ChangesAfter returns up to limit changes numbered after seq.
+func (s *memoryStore) ChangesAfter(seq int64, limit int) ([]changeRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return changesAfter(s.changes, seq, limit), nil
+}
+
+// ChangeBounds returns the numbers of the oldest and newest changes kept.
+func (s *memoryStore) ChangeBounds() (int64, int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if len(s.changes) == 0 {
+		return 0, 0, nil
+	}
+	return s.changes[0].Seq, s.changeSeq, nil
+}
+
+// DeletedSince returns the deletions recorded at or after since.
+func (s *memoryStore) DeletedSince(since time.Time) ([]changeRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return deletedSince(s.changes, since), nil
+}
+
+// PruneChanges forgets the changes made before the given time, except the
+// newest.
+func (s *memoryStore) PruneChanges(before time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keep := keptChanges(s.changes, before)
+	pruned := len(s.changes) - len(keep)
+	s.changes = append([]changeRecord(nil), keep...)
+	return pruned, nil
+}
+
+// Revision returns a counter of the changes made to the store.
+func (s *memoryStore) Revision() (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return strconv.FormatInt(s.revision, 10), nil
+}
+
+// Close does nothing: the items stay in memory for other holders of a named
+// store.
+func (s *memoryStore) Close() error {
+	return nil
+}
@@ -0,0 +1,101 @@
+package newsfeed
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMemory_AddGetList verifies that added items are read back unchanged,
+// in the order they were added, and that changing an item read from the
+// store doesn't change what is stored
+func TestMemory_AddGetList(t *testing.T) {
+	feed := NewMemoryNewsFeed()
+
+	first, second := createTestItem("first"), createTestItem("second")
+	first.Metadata = map[string]any{"key": "value"}
+	require.NoError(t, feed.Add(first))
+	require.NoError(t, feed.Add(second))
+
+	retrieved, err := feed.Get(first.ID)
+	require.NoError(t, err)
+	require.NotNil(t, retrieved)
+	assert.Equal(t, first.Title, retrieved.Title)
+	assert.True(t, first.PublishedAt.Equal(retrieved.PublishedAt))
+	retrieved.Metadata["key"] = "changed"
+
+	result, err := feed.List()
+	require.NoError(t, err)
+	require.Len(t, result.Items, 2)
+	assert.Equal(t, first.ID, result.Items[0].ID)
+	assert.Equal(t, second.ID, result.Items[1].ID)
+	assert.Equal(t, "value", result.Items[0].Metadata["key"])
+
+	missing, err := feed.Get(uuid.New())
+	assert.NoError(t, err)
+	assert.Nil(t, missing)
+}
+
+// TestMemory_UpdateDelete verifies that updates and deletes apply to
+// existing items and fail for missing ones, and that each change moves the
+// revision on
+func TestMemory_UpdateDelete(t *testing.T) {
+	feed := NewMemoryNewsFeed()
+
+	item := createTestItem("Original")
+	assert.ErrorIs(t, feed.Update(item), ErrItemNotFound, "Update of a missing item should fail")
+
+	require.NoError(t, feed.Add(item))
+	before, err := feed.Revision()
+	require.NoError(t, err)
+
+	pinnedAt := time.Now().UTC()
+	item.Title = "Updated"
+	item.PinnedAt = &pinnedAt
+	require.NoError(t, feed.Update(item))
+	after, err := feed.Revision()
+	require.NoError(t, err)
+	assert.NotEqual(t, before, after)
+
+	retrieved, err := feed.Get(item.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Updated", retrieved.Title)
+	require.NotNil(t, retrieved.PinnedAt)
+
+	require.NoError(t, feed.Delete(item.ID))
+	assert.ErrorIs(t, feed.Delete(item.ID), ErrItemNotFound, "Delete of a missing item should fail")
+
+	result, err := feed.List()
+	require.NoError(t, err)
+	assert.Empty(t, result.Items)
+}
+
+// TestOpen_Memory verifies that feeds opened with the same memory DSN share
+// their items and that closing one keeps them, while other names and new
+// memory feeds start empty
+func TestOpen_Memory(t *testing.T) {
+	name := MemoryScheme + t.Name()
+	feed, err := Open(name)
+	require.NoError(t, err)
+	require.NoError(t, feed.Add(createTestItem("shared")))
+	require.NoError(t, feed.Close())
+
+	again, err := Open(name)
+	require.NoError(t, err)
+	result, err := again.List()
+	require.NoError(t, err)
+	assert.Len(t, result.Items, 1)
+
+	other, err := Open(name + "-other")
+	require.NoError(t, err)
+	result, err = other.List()
+	require.NoError(t, err)
+	assert.Empty(t, result.Items)
+
+	result, err = NewMemoryNewsFeed().List()
+	require.NoError(t, err)
+	assert.Empty(t, result.Items)
+}
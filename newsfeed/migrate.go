@@ -0,0 +1,135 @@
+package newsfeed
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// ErrMigrationMismatch is returned by Migrate when the destination does not
+// hold the same items as the source after copying.
+var ErrMigrationMismatch = errors.New("destination does not match source")
+
+// MigrateReport summarizes a migration between feeds.
+type MigrateReport struct {
+	Total     int // Items read from the source
+	Copied    int // Items added to the destination
+	Updated   int // Items that were in the destination but differed
+	Unchanged int // Items already in the destination, e.g. from an earlier run
+	// ReadErrors lists source items that could not be read and so were not
+	// copied.
+	ReadErrors []ReadError
+}
+
+// MigrateOptions controls Migrate.
+type MigrateOptions struct {
+	// Progress, when set, is called after each item with the number of
+	// items handled so far and the total.
+	Progress func(done, total int)
+}
+
+// Migrate copies every item in from to to, which may use a different
+// storage backend. Items already in to with identical contents are left
+// alone, so an interrupted migration can be run again to finish it; items
+// that differ are overwritten with the source's version. Afterwards every
+// source item is read back from to and compared, and ErrMigrationMismatch is
+// returned if any is missing or different. Items only in to are kept.
+func Migrate(from, to *NewsFeed, opts MigrateOptions) (*MigrateReport, error) {
+	result, err := from.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source feed: %w", err)
+	}
+
+	report := &MigrateReport{Total: len(result.Items), ReadErrors: result.Errors}
+	hashes := make(map[uuid.UUID][]byte, len(result.Items))
+
+	for i, item := range result.Items {
+		sum, err := itemHash(item)
+		if err != nil {
+			return report, err
+		}
+		hashes[item.ID] = sum
+
+		existing, err := to.Get(item.ID)
+		if err != nil {
+			return report, fmt.Errorf("failed to read %s from destination: %w", item.ID, err)
+		}
+
+		switch {
+		case existing == nil:
+			if err := to.Add(item); err != nil {
+				return report, fmt.Errorf("failed to copy %s: %w", item.ID, err)
+			}
+			report.Copied++
+		default:
+			existingSum, err := itemHash(*existing)
+			if err != nil {
+				return report, err
+			}
+			if bytes.Equal(sum, existingSum) {
+				report.Unchanged++
+				break
+			}
+			// Add replaces the item as it is, where Update would
+			// advance its version
+			if err := to.Add(item); err != nil {
+				return report, fmt.Errorf("failed to update %s: %w", item.ID, err)
+			}
+			report.Updated++
+		}
+
+		if opts.Progress != nil {
+			opts.Progress(i+1, len(result.Items))
+		}
+	}
+
+	if err := verifyMigration(to, hashes); err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+// verifyMigration checks that to holds an identical copy of every item
+// whose hash is in hashes.
+func verifyMigration(to *NewsFeed, hashes map[uuid.UUID][]byte) error {
+	result, err := to.List()
+	if err != nil {
+		return fmt.Errorf("failed to read destination feed: %w", err)
+	}
+
+	found := 0
+	for _, item := range result.Items {
+		want, ok := hashes[item.ID]
+		if !ok {
+			continue
+		}
+		got, err := itemHash(item)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(want, got) {
+			return fmt.Errorf("%w: item %s differs", ErrMigrationMismatch, item.ID)
+		}
+		found++
+	}
+
+	if found != len(hashes) {
+		return fmt.Errorf("%w: %d of %d items are missing", ErrMigrationMismatch, len(hashes)-found, len(hashes))
+	}
+	return nil
+}
+
+// itemHash returns a hash of the item's stored form. Both backends store
+// items as JSON, so an item hashes the same in either.
+func itemHash(item NewsItem) ([]byte, error) {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal news item: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return sum[:], nil
+}
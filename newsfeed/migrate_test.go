@@ -0,0 +1,82 @@
+package newsfeed
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMigrate_DirectoryToSQLite verifies that every item is copied unchanged
+// and that a second run copies nothing
+func TestMigrate_DirectoryToSQLite(t *testing.T) {
+	from, err := NewNewsFeed(t.TempDir())
+	require.NoError(t, err)
+	to := createTestSQLiteFeed(t)
+
+	pinned := time.Now().Add(-time.Hour)
+	for i, title := range []string{"one", "two", "three"} {
+		item := createTestItem(title)
+		if i == 0 {
+			item.PinnedAt = &pinned
+			item.Metadata = map[string]any{"hn_id": 42}
+		}
+		require.NoError(t, from.Add(item))
+	}
+
+	var progress []int
+	report, err := Migrate(from, to, MigrateOptions{Progress: func(done, total int) {
+		assert.Equal(t, 3, total)
+		progress = append(progress, done)
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Total)
+	assert.Equal(t, 3, report.Copied)
+	assert.Equal(t, []int{1, 2, 3}, progress)
+
+	fromItems, err := from.List()
+	require.NoError(t, err)
+	for _, item := range fromItems.Items {
+		copied, err := to.Get(item.ID)
+		require.NoError(t, err)
+		require.NotNil(t, copied)
+		assert.Equal(t, item.Title, copied.Title)
+		assert.Equal(t, item.PinnedAt != nil, copied.PinnedAt != nil)
+	}
+
+	report, err = Migrate(from, to, MigrateOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 0, report.Copied)
+	assert.Equal(t, 3, report.Unchanged)
+}
+
+// TestMigrate_Resume verifies that a partly migrated destination is finished
+// and that stale copies are replaced with the source's version
+func TestMigrate_Resume(t *testing.T) {
+	from := createTestSQLiteFeed(t)
+	to, err := NewNewsFeed(t.TempDir())
+	require.NoError(t, err)
+
+	done := createTestItem("done")
+	stale := createTestItem("stale")
+	missing := createTestItem("missing")
+	for _, item := range []NewsItem{done, stale, missing} {
+		require.NoError(t, from.Add(item))
+	}
+
+	require.NoError(t, to.Add(done))
+	old := stale
+	old.Title = "old title"
+	require.NoError(t, to.Add(old))
+
+	report, err := Migrate(from, to, MigrateOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Copied)
+	assert.Equal(t, 1, report.Updated)
+	assert.Equal(t, 1, report.Unchanged)
+
+	got, err := to.Get(stale.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "stale", got.Title)
+}
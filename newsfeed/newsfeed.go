@@ -2,25 +2,72 @@ package newsfeed
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/pevans/newsfed/sqldb"
 )
 
-// NewsFeed represents a collection of news items stored in a directory
+// ErrItemNotFound is returned when an operation targets a news item that does
+// not exist in the feed.
+var ErrItemNotFound = errors.New("news item not found")
+
+// ErrDuplicateURL is returned by Create when the feed already has an item
+// with the same canonical URL.
+var ErrDuplicateURL = errors.New("an item with this URL already exists")
+
+// ErrVersionConflict is returned by Update when the item was saved by
+// someone else since it was read: its version is not the one stored.
+var ErrVersionConflict = errors.New("news item was changed since it was read")
+
+// ErrInvalidItem is returned by Create and Edit when an item fails
+// validation.
+var ErrInvalidItem = errors.New("invalid news item")
+
+// SQLiteScheme is the DSN prefix that selects the SQLite storage backend (for
+// example, "sqlite://feed.db").
+const SQLiteScheme = "sqlite://"
+
+// PostgresScheme is the DSN prefix that selects the PostgreSQL storage
+// backend (for example, "postgres://user@host/newsfed"). The "postgresql://"
+// prefix is also accepted.
+const PostgresScheme = "postgres://"
+
+// Store is a storage backend for news items. Get returns (nil, nil) when an
+// item does not exist; Update and Delete return ErrItemNotFound. Add saves
+// an item as given, replacing any item with the same ID. Update is a
+// compare-and-swap: it returns ErrVersionConflict unless the item's Version
+// is the stored one, and stores the item with its Version incremented.
+type Store interface {
+	Add(item NewsItem) error
+	Get(id uuid.UUID) (*NewsItem, error)
+	List() (*ListResult, error)
+	Update(item NewsItem) error
+	Delete(id uuid.UUID) error
+	Close() error
+}
+
+// NewsFeed represents a collection of news items held in a storage backend.
 type NewsFeed struct {
-	storageDir string
+	store Store
 }
 
 // ReadError describes a failure to read a single news item file.
 type ReadError struct {
-	Filename string
-	Err      error
+	Filename      string
+	Err           error
+	QuarantinedTo string // Where the file was moved, if it was quarantined
 }
 
 func (e *ReadError) Error() string {
+	if e.QuarantinedTo != "" {
+		return fmt.Sprintf("%s: %v (moved to %s)", e.Filename, e.Err, e.QuarantinedTo)
+	}
 	return fmt.Sprintf("%s: %v", e.Filename, e.Err)
 }
 
@@ -31,130 +78,588 @@ type ListResult struct {
 	Errors []ReadError
 }
 
+// New creates a news feed backed by the given store.
+func New(store Store) *NewsFeed {
+	return &NewsFeed{store: store}
+}
+
 // NewNewsFeed creates a new news feed with the specified storage directory
 func NewNewsFeed(storageDir string) (*NewsFeed, error) {
-	// Create the storage directory if it doesn't exist (0700: owner-only
-	// access)
-	if err := os.MkdirAll(storageDir, 0o700); err != nil {
-		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	store, err := newDirStore(storageDir)
+	if err != nil {
+		return nil, err
 	}
-
-	return &NewsFeed{
-		storageDir: storageDir,
-	}, nil
+	return New(store), nil
 }
 
-// Add saves a news item to the feed
-func (nf *NewsFeed) Add(item NewsItem) error {
-	// Use the item's UUID as the filename
-	filename := filepath.Join(nf.storageDir, item.ID.String()+".json")
+// NewSQLiteNewsFeed creates a new news feed backed by the SQLite database at
+// dbPath.
+func NewSQLiteNewsFeed(dbPath string) (*NewsFeed, error) {
+	store, err := newSQLiteStore(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	return New(store), nil
+}
 
-	// Marshal the item to JSON
-	data, err := json.MarshalIndent(item, "", "  ")
+// NewPostgresNewsFeed creates a new news feed backed by the PostgreSQL
+// database at dsn, creating or upgrading its schema as needed.
+func NewPostgresNewsFeed(dsn string) (*NewsFeed, error) {
+	store, err := newPostgresStore(dsn)
 	if err != nil {
-		return fmt.Errorf("failed to marshal news item: %w", err)
+		return nil, err
 	}
+	return New(store), nil
+}
 
-	// Write to file (0600: owner-only read/write)
-	if err := os.WriteFile(filename, data, 0o600); err != nil {
-		return fmt.Errorf("failed to write news item: %w", err)
+// Open opens a news feed from a DSN. A DSN beginning with "sqlite://" selects
+// the SQLite backend, one beginning with "postgres://" the PostgreSQL
+// backend, and one beginning with "memory://" the in-memory backend; any
+// other DSN is treated as a storage directory.
+func Open(dsn string) (*NewsFeed, error) {
+	if name, ok := strings.CutPrefix(dsn, MemoryScheme); ok {
+		return New(openMemoryStore(name)), nil
 	}
+	if path, ok := strings.CutPrefix(dsn, SQLiteScheme); ok {
+		return NewSQLiteNewsFeed(path)
+	}
+	if sqldb.IsPostgres(dsn) {
+		return NewPostgresNewsFeed(dsn)
+	}
+	return NewNewsFeed(dsn)
+}
 
-	return nil
+// Add saves a news item to the feed
+func (nf *NewsFeed) Add(item NewsItem) error {
+	return nf.store.Add(item)
 }
 
-// List returns all news items in the feed. Corrupted or invalid files are
+// List returns all news items in the feed. Corrupted or invalid items are
 // collected in the result's Errors slice rather than causing the entire
 // operation to fail. A non-nil error return indicates a total failure (e.g.,
 // the storage directory is unreadable).
 func (nf *NewsFeed) List() (*ListResult, error) {
-	entries, err := os.ReadDir(nf.storageDir)
+	return nf.store.List()
+}
+
+// Get retrieves a news item by its ID. Returns (nil, nil) if the item does
+// not exist.
+func (nf *NewsFeed) Get(id uuid.UUID) (*NewsItem, error) {
+	return nf.store.Get(id)
+}
+
+// Delete removes a news item from the feed by its ID.
+func (nf *NewsFeed) Delete(id uuid.UUID) error {
+	return nf.store.Delete(id)
+}
+
+// Update updates an existing news item in the feed. The item must have the
+// version it was read with; if the item was saved since, Update returns
+// ErrVersionConflict and changes nothing, so that concurrent changes aren't
+// lost. The stored item's version is one more than the given item's.
+func (nf *NewsFeed) Update(item NewsItem) error {
+	return nf.store.Update(item)
+}
+
+// save updates item in the feed, as Update does, and advances its version
+// to match the stored item.
+func (nf *NewsFeed) save(item *NewsItem) error {
+	if err := nf.Update(*item); err != nil {
+		return err
+	}
+	item.Version++
+	return nil
+}
+
+// storedVersion returns the version of the item encoded in data.
+func storedVersion(data []byte) (int, error) {
+	var stored struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return 0, fmt.Errorf("failed to unmarshal news item: %w", err)
+	}
+	return stored.Version, nil
+}
+
+// revisioner is implemented by stores that can tell when their contents
+// change.
+type revisioner interface {
+	Revision() (string, error)
+}
+
+// Revision returns an opaque value that changes whenever an item is added,
+// updated, or deleted, including by other processes. Clients that poll the
+// feed can compare revisions to skip re-reading unchanged items, for example
+// as the basis of an HTTP ETag. Stores that don't track changes return an
+// empty string.
+func (nf *NewsFeed) Revision() (string, error) {
+	if r, ok := nf.store.(revisioner); ok {
+		return r.Revision()
+	}
+	return "", nil
+}
+
+// pinger is implemented by stores that can check that they are usable.
+type pinger interface {
+	Ping() error
+}
+
+// Ping checks that the feed's storage can be reached and written to, for
+// health checks. Stores that can't be checked always succeed.
+func (nf *NewsFeed) Ping() error {
+	if p, ok := nf.store.(pinger); ok {
+		return p.Ping()
+	}
+	return nil
+}
+
+// CheckIssue describes one problem found by Check.
+type CheckIssue struct {
+	Filename string
+	Problem  string
+	Repaired bool
+}
+
+// CheckReport contains the results of checking a feed's storage.
+type CheckReport struct {
+	Checked int // Number of items examined
+	Issues  []CheckIssue
+}
+
+// checker is implemented by stores that can check their own consistency.
+type checker interface {
+	Check(repair bool) (*CheckReport, error)
+}
+
+// Check examines the feed's storage for damage and, if repair is set, fixes
+// what it can. Stores that don't support checking return an empty report.
+func (nf *NewsFeed) Check(repair bool) (*CheckReport, error) {
+	c, ok := nf.store.(checker)
+	if !ok {
+		return &CheckReport{}, nil
+	}
+	return c.Check(repair)
+}
+
+// Close releases any resources held by the storage backend.
+func (nf *NewsFeed) Close() error {
+	return nf.store.Close()
+}
+
+// MarkRead records that a news item has been read. An item that is already
+// read keeps its original read time.
+func (nf *NewsFeed) MarkRead(id uuid.UUID) (*NewsItem, error) {
+	item, err := nf.Get(id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read storage directory: %w", err)
+		return nil, err
+	}
+	if item == nil {
+		return nil, ErrItemNotFound
 	}
 
-	result := &ListResult{}
-	for _, entry := range entries {
-		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
-			continue
-		}
+	if item.ReadAt != nil {
+		return item, nil
+	}
 
-		// Read the file
-		filename := filepath.Join(nf.storageDir, entry.Name())
-		data, err := os.ReadFile(filename)
-		if err != nil {
-			result.Errors = append(result.Errors, ReadError{
-				Filename: entry.Name(),
-				Err:      err,
-			})
-			continue
-		}
+	now := time.Now().UTC()
+	item.ReadAt = &now
+	if err := nf.save(item); err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
+// OpenCountKey is the metadata key counting how many times an item has been
+// opened, by every reader.
+const OpenCountKey = "open_count"
+
+// MarkOpened records that a news item's link was followed: it sets OpenedAt,
+// marks the item read if it isn't, and counts the open in its metadata under
+// OpenCountKey.
+func (nf *NewsFeed) MarkOpened(id uuid.UUID) (*NewsItem, error) {
+	item, err := nf.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return nil, ErrItemNotFound
+	}
+
+	now := time.Now().UTC()
+	item.OpenedAt = &now
+	if item.ReadAt == nil {
+		item.ReadAt = &now
+	}
+	count := 0
+	if value, ok := item.MetadataValue(OpenCountKey); ok {
+		count, _ = strconv.Atoi(value)
+	}
+	if item.Metadata == nil {
+		item.Metadata = map[string]any{}
+	}
+	item.Metadata[OpenCountKey] = count + 1
+	if err := nf.save(item); err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
+// MarkUnread clears the read status of a news item.
+func (nf *NewsFeed) MarkUnread(id uuid.UUID) (*NewsItem, error) {
+	item, err := nf.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return nil, ErrItemNotFound
+	}
+
+	if item.ReadAt == nil {
+		return item, nil
+	}
+
+	item.ReadAt = nil
+	if err := nf.save(item); err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
+// Archive hides a news item from listings without deleting it. Archived items
+// are still used for deduplication, so discovery won't add them again. An
+// item that is already archived keeps its original archive time.
+func (nf *NewsFeed) Archive(id uuid.UUID) (*NewsItem, error) {
+	item, err := nf.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return nil, ErrItemNotFound
+	}
 
-		// Unmarshal the news item
-		var item NewsItem
-		if err := json.Unmarshal(data, &item); err != nil {
-			result.Errors = append(result.Errors, ReadError{
-				Filename: entry.Name(),
-				Err:      err,
-			})
+	if item.ArchivedAt != nil {
+		return item, nil
+	}
+
+	now := time.Now().UTC()
+	item.ArchivedAt = &now
+	if err := nf.save(item); err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
+// Unarchive returns an archived news item to listings.
+func (nf *NewsFeed) Unarchive(id uuid.UUID) (*NewsItem, error) {
+	item, err := nf.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return nil, ErrItemNotFound
+	}
+
+	if item.ArchivedAt == nil {
+		return item, nil
+	}
+
+	item.ArchivedAt = nil
+	if err := nf.save(item); err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
+// Star marks a news item as one to keep for reference. Starred items are
+// never pruned. An item that is already starred keeps its original star
+// time.
+func (nf *NewsFeed) Star(id uuid.UUID) (*NewsItem, error) {
+	item, err := nf.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return nil, ErrItemNotFound
+	}
+
+	if item.StarredAt != nil {
+		return item, nil
+	}
+
+	now := time.Now().UTC()
+	item.StarredAt = &now
+	if err := nf.save(item); err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
+// Unstar clears the starred status of a news item.
+func (nf *NewsFeed) Unstar(id uuid.UUID) (*NewsItem, error) {
+	item, err := nf.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return nil, ErrItemNotFound
+	}
+
+	if item.StarredAt == nil {
+		return item, nil
+	}
+
+	item.StarredAt = nil
+	if err := nf.save(item); err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
+// Snooze hides a news item from listings until the given time, when it
+// returns as though it had just been discovered. Snoozing an item that is
+// already snoozed moves its wake time.
+func (nf *NewsFeed) Snooze(id uuid.UUID, until time.Time) (*NewsItem, error) {
+	item, err := nf.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return nil, ErrItemNotFound
+	}
+
+	until = until.UTC()
+	item.SnoozedUntil = &until
+	if err := nf.save(item); err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
+// Unsnooze returns a snoozed news item to listings now.
+func (nf *NewsFeed) Unsnooze(id uuid.UUID) (*NewsItem, error) {
+	item, err := nf.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return nil, ErrItemNotFound
+	}
+
+	if !item.IsSnoozed(time.Now()) {
+		return item, nil
+	}
+
+	item.SnoozedUntil = nil
+	if err := nf.save(item); err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
+// ExpirePins unpins every item whose pin expires at or before now. It
+// returns the items that were unpinned.
+func (nf *NewsFeed) ExpirePins(now time.Time) ([]NewsItem, error) {
+	result, err := nf.store.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var expired []NewsItem
+	for _, item := range result.Items {
+		if item.PinnedAt == nil || item.PinExpiresAt == nil || item.PinExpiresAt.After(now) {
 			continue
 		}
 
-		result.Items = append(result.Items, item)
+		item.PinnedAt = nil
+		item.PinExpiresAt = nil
+		if err := nf.store.Update(item); err != nil {
+			return expired, fmt.Errorf("failed to unpin item %s: %w", item.ID, err)
+		}
+		expired = append(expired, item)
 	}
 
-	return result, nil
+	return expired, nil
 }
 
-// Get retrieves a news item by its ID.
-func (nf *NewsFeed) Get(id uuid.UUID) (*NewsItem, error) {
-	filename := filepath.Join(nf.storageDir, id.String()+".json")
+// SetNotes replaces a news item's notes. Empty notes remove them.
+func (nf *NewsFeed) SetNotes(id uuid.UUID, notes string) (*NewsItem, error) {
+	item, err := nf.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return nil, ErrItemNotFound
+	}
+
+	item.Notes = notes
+	if err := nf.save(item); err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
 
-	// Read the file
-	data, err := os.ReadFile(filename)
+// Create adds an item entered by hand, such as a link saved to read later.
+// The URL must be an absolute http or https URL that is not already in the
+// feed. A missing title defaults to the URL, and missing IDs and dates are
+// filled in. It returns the item as stored.
+func (nf *NewsFeed) Create(item NewsItem) (*NewsItem, error) {
+	item, err := prepareNew(item)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, nil // Item not found (not an error)
-		}
-		return nil, fmt.Errorf("failed to read news item: %w", err)
+		return nil, err
 	}
 
-	// Unmarshal the news item
-	var item NewsItem
-	if err := json.Unmarshal(data, &item); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal news item: %w", err)
+	exists, err := nf.HasURL(item.URL)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrDuplicateURL
 	}
 
+	if err := nf.Add(item); err != nil {
+		return nil, err
+	}
 	return &item, nil
 }
 
-// Delete removes a news item from the feed by its ID.
-func (nf *NewsFeed) Delete(id uuid.UUID) error {
-	filename := filepath.Join(nf.storageDir, id.String()+".json")
-	if err := os.Remove(filename); err != nil {
-		return fmt.Errorf("failed to delete news item: %w", err)
+// prepareNew checks an item entered by hand and fills in the fields Create
+// defaults.
+func prepareNew(item NewsItem) (NewsItem, error) {
+	item.URL = strings.TrimSpace(item.URL)
+	parsed, err := url.Parse(item.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return item, fmt.Errorf("%w: URL must be an absolute http or https URL", ErrInvalidItem)
 	}
-	return nil
+
+	item.Title = strings.TrimSpace(item.Title)
+	if item.Title == "" {
+		item.Title = item.URL
+	}
+	if item.ID == uuid.Nil {
+		item.ID = uuid.New()
+	}
+	if item.DiscoveredAt.IsZero() {
+		item.DiscoveredAt = time.Now()
+	}
+	if item.PublishedAt.IsZero() {
+		item.PublishedAt = item.DiscoveredAt
+	}
+	if item.Authors == nil {
+		item.Authors = []string{}
+	}
+	return item, nil
 }
 
-// Update updates an existing news item in the feed.
-func (nf *NewsFeed) Update(item NewsItem) error {
-	// Check if the item exists
-	filename := filepath.Join(nf.storageDir, item.ID.String()+".json")
-	if _, err := os.Stat(filename); os.IsNotExist(err) {
-		return fmt.Errorf("news item not found")
+// ItemEdit holds the changes Edit makes to an item. Nil fields are left
+// unchanged.
+type ItemEdit struct {
+	Title    *string `json:"title,omitempty"`
+	Summary  *string `json:"summary,omitempty"`
+	Category *string `json:"category,omitempty"` // An empty category removes it
+}
+
+// Edit changes the title, summary, or category of a news item. Titles may
+// not be empty.
+func (nf *NewsFeed) Edit(id uuid.UUID, edit ItemEdit) (*NewsItem, error) {
+	if edit.Title != nil && strings.TrimSpace(*edit.Title) == "" {
+		return nil, fmt.Errorf("%w: title must not be empty", ErrInvalidItem)
 	}
 
-	// Marshal the item to JSON
-	data, err := json.MarshalIndent(item, "", "  ")
+	item, err := nf.Get(id)
 	if err != nil {
-		return fmt.Errorf("failed to marshal news item: %w", err)
+		return nil, err
+	}
+	if item == nil {
+		return nil, ErrItemNotFound
 	}
 
-	// Write to file (0600: owner-only read/write)
-	if err := os.WriteFile(filename, data, 0o600); err != nil {
-		return fmt.Errorf("failed to write news item: %w", err)
+	// Remember what the publisher gave, so the edit isn't mistaken for a
+	// change the publisher made
+	if item.ContentHash == "" {
+		item.ContentHash = HashContent(*item)
+	}
+	if edit.Title != nil {
+		item.Title = strings.TrimSpace(*edit.Title)
+	}
+	if edit.Summary != nil {
+		item.Summary = strings.TrimSpace(*edit.Summary)
+	}
+	if edit.Category != nil {
+		item.Category = nil
+		if category := strings.TrimSpace(*edit.Category); category != "" {
+			item.Category = &category
+		}
+	}
+	if err := nf.save(item); err != nil {
+		return nil, err
 	}
 
-	return nil
+	return item, nil
+}
+
+// ItemPatch holds the changes Patch makes to an item's state. Nil fields
+// are left unchanged. Version, if set, must be the version the item was
+// read with.
+type ItemPatch struct {
+	Version  *int    `json:"version,omitempty"`
+	Pinned   *bool   `json:"pinned,omitempty"`
+	Read     *bool   `json:"read,omitempty"`
+	Starred  *bool   `json:"starred,omitempty"`
+	Archived *bool   `json:"archived,omitempty"`
+	Notes    *string `json:"notes,omitempty"`
+}
+
+// Patch pins, marks read, stars, or archives a news item, or sets its
+// notes, as the patch gives; states the item already has keep their
+// original times. It returns ErrVersionConflict if the patch's version
+// isn't the item's, or if the item is saved by someone else while it is
+// being patched.
+func (nf *NewsFeed) Patch(id uuid.UUID, patch ItemPatch) (*NewsItem, error) {
+	item, err := nf.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return nil, ErrItemNotFound
+	}
+	if patch.Version != nil && *patch.Version != item.Version {
+		return nil, ErrVersionConflict
+	}
+
+	now := time.Now().UTC()
+	setTime := func(field **time.Time, set *bool) {
+		switch {
+		case set == nil:
+		case *set && *field == nil:
+			*field = &now
+		case !*set:
+			*field = nil
+		}
+	}
+	setTime(&item.PinnedAt, patch.Pinned)
+	if item.PinnedAt == nil {
+		item.PinExpiresAt = nil
+	}
+	setTime(&item.ReadAt, patch.Read)
+	setTime(&item.StarredAt, patch.Starred)
+	setTime(&item.ArchivedAt, patch.Archived)
+	if patch.Notes != nil {
+		item.Notes = *patch.Notes
+	}
+	if err := nf.save(item); err != nil {
+		return nil, err
+	}
+
+	return item, nil
 }
@@ -593,5 +593,540 @@ func TestUpdateGet_ConsistentState(t *testing.T) {
 		retrieved, err := feed.Get(item.ID)
 		require.NoError(t, err)
 		assert.Equal(t, title, retrieved.Title, "Get should always return latest Update")
+
+		// The next update is made to the item as stored
+		item.Version = retrieved.Version
+	}
+}
+
+// TestMarkRead_SetsReadAt verifies that MarkRead persists a read timestamp
+func TestMarkRead_SetsReadAt(t *testing.T) {
+	feed, err := NewNewsFeed(t.TempDir())
+	require.NoError(t, err)
+
+	item := createTestItem("Unread")
+	require.NoError(t, feed.Add(item))
+
+	marked, err := feed.MarkRead(item.ID)
+	require.NoError(t, err)
+	require.NotNil(t, marked.ReadAt)
+
+	retrieved, err := feed.Get(item.ID)
+	require.NoError(t, err)
+	assert.True(t, retrieved.IsRead(), "item should be read after MarkRead")
+}
+
+// TestMarkRead_Idempotent verifies that marking a read item again keeps the
+// original read time
+func TestMarkRead_Idempotent(t *testing.T) {
+	feed, err := NewNewsFeed(t.TempDir())
+	require.NoError(t, err)
+
+	readAt := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	item := createTestItem("Already read")
+	item.ReadAt = &readAt
+	require.NoError(t, feed.Add(item))
+
+	marked, err := feed.MarkRead(item.ID)
+	require.NoError(t, err)
+	assert.True(t, readAt.Equal(*marked.ReadAt), "read time should not change")
+}
+
+// TestMarkUnread_ClearsReadAt verifies that MarkUnread reverses MarkRead
+func TestMarkUnread_ClearsReadAt(t *testing.T) {
+	feed, err := NewNewsFeed(t.TempDir())
+	require.NoError(t, err)
+
+	item := createTestItem("Read then unread")
+	require.NoError(t, feed.Add(item))
+
+	_, err = feed.MarkRead(item.ID)
+	require.NoError(t, err)
+	_, err = feed.MarkUnread(item.ID)
+	require.NoError(t, err)
+
+	retrieved, err := feed.Get(item.ID)
+	require.NoError(t, err)
+	assert.False(t, retrieved.IsRead(), "item should be unread after MarkUnread")
+}
+
+// TestMarkRead_NotFound verifies that marking a missing item returns
+// ErrItemNotFound
+func TestMarkRead_NotFound(t *testing.T) {
+	feed, err := NewNewsFeed(t.TempDir())
+	require.NoError(t, err)
+
+	_, err = feed.MarkRead(uuid.New())
+	assert.ErrorIs(t, err, ErrItemNotFound)
+
+	_, err = feed.MarkUnread(uuid.New())
+	assert.ErrorIs(t, err, ErrItemNotFound)
+}
+
+// TestMarkOpened_CountsOpens verifies that MarkOpened records the open time,
+// marks the item read, and counts each open in its metadata, on every
+// backend
+func TestMarkOpened_CountsOpens(t *testing.T) {
+	dirFeed, err := NewNewsFeed(t.TempDir())
+	require.NoError(t, err)
+
+	for name, feed := range map[string]*NewsFeed{"directory": dirFeed, "sqlite": createTestSQLiteFeed(t)} {
+		t.Run(name, func(t *testing.T) {
+			readAt := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+			item := createTestItem("Opened")
+			item.ReadAt = &readAt
+			require.NoError(t, feed.Add(item))
+
+			_, err := feed.MarkOpened(item.ID)
+			require.NoError(t, err)
+			opened, err := feed.MarkOpened(item.ID)
+			require.NoError(t, err)
+			require.NotNil(t, opened.OpenedAt)
+
+			retrieved, err := feed.Get(item.ID)
+			require.NoError(t, err)
+			require.NotNil(t, retrieved.OpenedAt)
+			assert.True(t, readAt.Equal(*retrieved.ReadAt), "read time should not change")
+			count, ok := retrieved.MetadataValue(OpenCountKey)
+			require.True(t, ok)
+			assert.Equal(t, "2", count)
+
+			_, err = feed.MarkOpened(uuid.New())
+			assert.ErrorIs(t, err, ErrItemNotFound)
+		})
+	}
+}
+
+// TestArchive_HidesFromQuery verifies that archived items are excluded from
+// queries unless archived items are requested
+func TestArchive_HidesFromQuery(t *testing.T) {
+	feed, err := NewNewsFeed(t.TempDir())
+	require.NoError(t, err)
+
+	kept := createTestItem("Kept")
+	archived := createTestItem("Archived")
+	require.NoError(t, feed.Add(kept))
+	require.NoError(t, feed.Add(archived))
+
+	marked, err := feed.Archive(archived.ID)
+	require.NoError(t, err)
+	assert.True(t, marked.IsArchived())
+
+	result, err := feed.Query(ListOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{kept.ID}, itemIDs(result.Items))
+
+	result, err = feed.Query(ListOptions{Archived: true})
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{archived.ID}, itemIDs(result.Items))
+
+	// Archived items still count as known URLs
+	known, err := feed.HasURL(archived.URL)
+	require.NoError(t, err)
+	assert.True(t, known)
+
+	_, err = feed.Unarchive(archived.ID)
+	require.NoError(t, err)
+	result, err = feed.Query(ListOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Total)
+}
+
+// TestArchive_NotFound verifies that archiving a missing item returns
+// ErrItemNotFound
+func TestArchive_NotFound(t *testing.T) {
+	feed, err := NewNewsFeed(t.TempDir())
+	require.NoError(t, err)
+
+	_, err = feed.Archive(uuid.New())
+	assert.ErrorIs(t, err, ErrItemNotFound)
+
+	_, err = feed.Unarchive(uuid.New())
+	assert.ErrorIs(t, err, ErrItemNotFound)
+}
+
+// TestStar_FiltersAndUnstar verifies that starring persists, is matched by
+// the Starred filter, and is reversed by Unstar
+func TestStar_FiltersAndUnstar(t *testing.T) {
+	feed, err := NewNewsFeed(t.TempDir())
+	require.NoError(t, err)
+
+	plain := createTestItem("Plain")
+	starred := createTestItem("Starred")
+	require.NoError(t, feed.Add(plain))
+	require.NoError(t, feed.Add(starred))
+
+	marked, err := feed.Star(starred.ID)
+	require.NoError(t, err)
+	assert.True(t, marked.IsStarred())
+
+	result, err := feed.Query(ListOptions{Starred: true})
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{starred.ID}, itemIDs(result.Items))
+
+	_, err = feed.Unstar(starred.ID)
+	require.NoError(t, err)
+	result, err = feed.Query(ListOptions{Starred: true})
+	require.NoError(t, err)
+	assert.Empty(t, result.Items)
+
+	_, err = feed.Star(uuid.New())
+	assert.ErrorIs(t, err, ErrItemNotFound)
+}
+
+// TestSnooze_HidesUntilWake verifies that a snoozed item is hidden until its
+// snooze ends, then returns as though it had just been discovered
+func TestSnooze_HidesUntilWake(t *testing.T) {
+	feed, err := NewNewsFeed(t.TempDir())
+	require.NoError(t, err)
+
+	now := time.Now()
+	weekAgo := now.Add(-7 * 24 * time.Hour)
+	plain := createTestItem("Plain")
+	snoozed := createTestItem("Snoozed")
+	snoozed.DiscoveredAt = weekAgo
+	require.NoError(t, feed.Add(plain))
+	require.NoError(t, feed.Add(snoozed))
+
+	marked, err := feed.Snooze(snoozed.ID, now.Add(3*24*time.Hour))
+	require.NoError(t, err)
+	assert.True(t, marked.IsSnoozed(now))
+
+	result, err := feed.Query(ListOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{plain.ID}, itemIDs(result.Items))
+	result, err = feed.Query(ListOptions{Snoozed: true})
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{snoozed.ID}, itemIDs(result.Items))
+
+	// Once the snooze ends, the item counts as recent again
+	_, err = feed.Snooze(snoozed.ID, now.Add(-time.Hour))
+	require.NoError(t, err)
+	dayAgo := now.Add(-24 * time.Hour)
+	result, err = feed.Query(ListOptions{DiscoveredSince: &dayAgo})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []uuid.UUID{plain.ID, snoozed.ID}, itemIDs(result.Items))
+
+	_, err = feed.Snooze(snoozed.ID, now.Add(time.Hour))
+	require.NoError(t, err)
+	unsnoozed, err := feed.Unsnooze(snoozed.ID)
+	require.NoError(t, err)
+	assert.Nil(t, unsnoozed.SnoozedUntil)
+
+	_, err = feed.Snooze(uuid.New(), now)
+	assert.ErrorIs(t, err, ErrItemNotFound)
+}
+
+// TestSetNotes_ReplacesAndClears verifies that notes are saved and that
+// empty notes remove them
+func TestSetNotes_ReplacesAndClears(t *testing.T) {
+	feed, err := NewNewsFeed(t.TempDir())
+	require.NoError(t, err)
+
+	item := createTestItem("Annotated")
+	require.NoError(t, feed.Add(item))
+
+	_, err = feed.SetNotes(item.ID, "Compare with last year's report")
+	require.NoError(t, err)
+	retrieved, err := feed.Get(item.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Compare with last year's report", retrieved.Notes)
+
+	_, err = feed.SetNotes(item.ID, "")
+	require.NoError(t, err)
+	retrieved, err = feed.Get(item.ID)
+	require.NoError(t, err)
+	assert.Empty(t, retrieved.Notes)
+
+	_, err = feed.SetNotes(uuid.New(), "missing")
+	assert.ErrorIs(t, err, ErrItemNotFound)
+}
+
+// TestCreate_ValidatesAndDeduplicates verifies that hand-entered items get
+// defaults, and that invalid or already saved URLs are rejected
+func TestCreate_ValidatesAndDeduplicates(t *testing.T) {
+	feed, err := NewNewsFeed(t.TempDir())
+	require.NoError(t, err)
+
+	created, err := feed.Create(NewsItem{URL: " https://example.com/article "})
+	require.NoError(t, err)
+	assert.NotEqual(t, uuid.Nil, created.ID)
+	assert.Equal(t, "https://example.com/article", created.Title)
+	assert.False(t, created.DiscoveredAt.IsZero())
+	assert.Equal(t, created.DiscoveredAt, created.PublishedAt)
+
+	retrieved, err := feed.Get(created.ID)
+	require.NoError(t, err)
+	require.NotNil(t, retrieved)
+
+	_, err = feed.Create(NewsItem{URL: "http://example.com/article/?utm_source=mail"})
+	assert.ErrorIs(t, err, ErrDuplicateURL)
+
+	for _, rawURL := range []string{"", "example.com/page", "ftp://example.com/file"} {
+		_, err = feed.Create(NewsItem{URL: rawURL, Title: "Bad"})
+		assert.ErrorIs(t, err, ErrInvalidItem, "URL %q", rawURL)
+	}
+}
+
+// TestEdit_ChangesTitleAndSummary verifies that edits are saved and that an
+// empty title is rejected
+func TestEdit_ChangesTitleAndSummary(t *testing.T) {
+	feed, err := NewNewsFeed(t.TempDir())
+	require.NoError(t, err)
+
+	item := createTestItem("Original")
+	require.NoError(t, feed.Add(item))
+
+	title := "Renamed"
+	_, err = feed.Edit(item.ID, ItemEdit{Title: &title})
+	require.NoError(t, err)
+	retrieved, err := feed.Get(item.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Renamed", retrieved.Title)
+	assert.Equal(t, item.Summary, retrieved.Summary)
+
+	empty := " "
+	_, err = feed.Edit(item.ID, ItemEdit{Title: &empty})
+	assert.ErrorIs(t, err, ErrInvalidItem)
+
+	_, err = feed.Edit(uuid.New(), ItemEdit{Summary: &title})
+	assert.ErrorIs(t, err, ErrItemNotFound)
+}
+
+// TestRevision_ChangesWithItems verifies that both backends report a new
+// revision after items are added, updated, or deleted, and the same revision
+// otherwise
+func TestRevision_ChangesWithItems(t *testing.T) {
+	dirFeed, err := NewNewsFeed(t.TempDir())
+	require.NoError(t, err)
+	sqliteFeed := createTestSQLiteFeed(t)
+
+	for name, feed := range map[string]*NewsFeed{"dir": dirFeed, "sqlite": sqliteFeed} {
+		initial, err := feed.Revision()
+		require.NoError(t, err)
+		require.NotEmpty(t, initial, name)
+
+		item := createTestItem("Revised")
+		require.NoError(t, feed.Add(item))
+		added, err := feed.Revision()
+		require.NoError(t, err)
+		assert.NotEqual(t, initial, added, name)
+
+		unchanged, err := feed.Revision()
+		require.NoError(t, err)
+		assert.Equal(t, added, unchanged, name)
+
+		require.NoError(t, feed.Delete(item.ID))
+		deleted, err := feed.Revision()
+		require.NoError(t, err)
+		assert.NotEqual(t, added, deleted, name)
 	}
+
+	// SQLite counts every write, including updates that keep the item count
+	item := createTestItem("Counted")
+	require.NoError(t, sqliteFeed.Add(item))
+	before, err := sqliteFeed.Revision()
+	require.NoError(t, err)
+	item.Title = "Recounted"
+	require.NoError(t, sqliteFeed.Update(item))
+	after, err := sqliteFeed.Revision()
+	require.NoError(t, err)
+	assert.NotEqual(t, before, after)
+}
+
+// TestAdd_LeavesNoTempFiles verifies atomic writes clean up after themselves
+func TestAdd_LeavesNoTempFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	feed, err := NewNewsFeed(tempDir)
+	require.NoError(t, err)
+
+	item := createTestItem("Article 1")
+	require.NoError(t, feed.Add(item))
+	item.Title = "Updated"
+	require.NoError(t, feed.Update(item))
+
+	// Only the item, the change log, and its lock are left
+	entries, err := os.ReadDir(tempDir)
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	assert.ElementsMatch(t, []string{item.ID.String() + ".json", changesFile, changesLockFile}, names)
+
+	for _, entry := range entries {
+		info, err := entry.Info()
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm(), entry.Name())
+	}
+}
+
+// TestList_IgnoresTempFiles verifies a partially written item is not read
+func TestList_IgnoresTempFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	feed, err := NewNewsFeed(tempDir)
+	require.NoError(t, err)
+
+	partial := filepath.Join(tempDir, uuid.New().String()+".123456.tmp")
+	require.NoError(t, os.WriteFile(partial, []byte(`{"id": "`), 0o600))
+
+	result, err := feed.List()
+	require.NoError(t, err)
+	assert.Empty(t, result.Items)
+	assert.Empty(t, result.Errors)
+}
+
+// TestList_QuarantinesCorruptedFiles verifies corrupted files are moved aside
+// and only reported once
+func TestList_QuarantinesCorruptedFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	feed, err := NewNewsFeed(tempDir)
+	require.NoError(t, err)
+	require.NoError(t, feed.Add(createTestItem("Article 1")))
+
+	corruptedName := uuid.New().String() + ".json"
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, corruptedName), []byte("{invalid json}"), 0o600))
+
+	result, err := feed.List()
+	require.NoError(t, err)
+	require.Len(t, result.Errors, 1)
+	quarantined := filepath.Join(tempDir, QuarantineDir, corruptedName)
+	assert.Equal(t, quarantined, result.Errors[0].QuarantinedTo)
+	assert.FileExists(t, quarantined)
+	assert.NoFileExists(t, filepath.Join(tempDir, corruptedName))
+
+	result, err = feed.List()
+	require.NoError(t, err)
+	assert.Len(t, result.Items, 1)
+	assert.Empty(t, result.Errors, "quarantined file should not be reported again")
+}
+
+// TestCheck_RepairsDirectoryStore verifies Check reports problems without
+// repair and fixes them with repair
+func TestCheck_RepairsDirectoryStore(t *testing.T) {
+	tempDir := t.TempDir()
+	feed, err := NewNewsFeed(tempDir)
+	require.NoError(t, err)
+
+	good := createTestItem("Good")
+	require.NoError(t, feed.Add(good))
+
+	// A misnamed file holding a valid item
+	misnamed := createTestItem("Misnamed")
+	data, err := json.Marshal(misnamed)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "copy.json"), data, 0o644))
+
+	// A corrupted file and a stale temp file
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, uuid.New().String()+".json"), []byte("{"), 0o600))
+	stale := filepath.Join(tempDir, uuid.New().String()+".1.tmp")
+	require.NoError(t, os.WriteFile(stale, []byte("{"), 0o600))
+	old := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(stale, old, old))
+
+	report, err := feed.Check(false)
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Checked)
+	assert.Len(t, report.Issues, 4, "temp file, corrupted file, misnamed file, and permissions")
+	for _, issue := range report.Issues {
+		assert.False(t, issue.Repaired)
+	}
+
+	report, err = feed.Check(true)
+	require.NoError(t, err)
+	for _, issue := range report.Issues {
+		assert.True(t, issue.Repaired, "%s: %s", issue.Filename, issue.Problem)
+	}
+	assert.NoFileExists(t, stale)
+
+	retrieved, err := feed.Get(misnamed.ID)
+	require.NoError(t, err)
+	require.NotNil(t, retrieved, "misnamed item should be reachable by ID")
+	info, err := os.Stat(filepath.Join(tempDir, misnamed.ID.String()+".json"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	report, err = feed.Check(false)
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Checked)
+	assert.Empty(t, report.Issues)
+}
+
+// TestUpdate_VersionConflict verifies that every backend refuses an update
+// made to a stale copy of an item, and advances the version of each update
+// it accepts
+func TestUpdate_VersionConflict(t *testing.T) {
+	backends := map[string]func(t *testing.T) *NewsFeed{
+		"directory": func(t *testing.T) *NewsFeed {
+			feed, err := NewNewsFeed(t.TempDir())
+			require.NoError(t, err)
+			return feed
+		},
+		"sqlite":     createTestSQLiteFeed,
+		"memory":     func(t *testing.T) *NewsFeed { return NewMemoryNewsFeed() },
+		"postgresql": createTestPostgresFeed,
+	}
+	for name, open := range backends {
+		t.Run(name, func(t *testing.T) {
+			feed := open(t)
+			item := createTestItem("Original")
+			require.NoError(t, feed.Add(item))
+
+			first, err := feed.Get(item.ID)
+			require.NoError(t, err)
+			second, err := feed.Get(item.ID)
+			require.NoError(t, err)
+
+			first.Notes = "first"
+			require.NoError(t, feed.Update(*first))
+			second.Notes = "second"
+			assert.ErrorIs(t, feed.Update(*second), ErrVersionConflict)
+
+			stored, err := feed.Get(item.ID)
+			require.NoError(t, err)
+			assert.Equal(t, "first", stored.Notes, "the refused update should change nothing")
+			assert.Equal(t, 1, stored.Version)
+
+			stored.Notes = "again"
+			require.NoError(t, feed.Update(*stored))
+			stored, err = feed.Get(item.ID)
+			require.NoError(t, err)
+			assert.Equal(t, 2, stored.Version)
+
+			missing := createTestItem("Missing")
+			assert.ErrorIs(t, feed.Update(missing), ErrItemNotFound)
+		})
+	}
+}
+
+// TestPatch verifies that a patch changes only the states it gives, keeps
+// the times of states the item already has, and is refused for a stale
+// version
+func TestPatch(t *testing.T) {
+	feed := NewMemoryNewsFeed()
+	item := createTestItem("Patched")
+	require.NoError(t, feed.Add(item))
+
+	yes, no := true, false
+	notes := "worth a second look"
+	patched, err := feed.Patch(item.ID, ItemPatch{Pinned: &yes, Starred: &yes, Notes: &notes})
+	require.NoError(t, err)
+	require.NotNil(t, patched.PinnedAt)
+	require.NotNil(t, patched.StarredAt)
+	assert.Nil(t, patched.ReadAt)
+	assert.Equal(t, notes, patched.Notes)
+	assert.Equal(t, 1, patched.Version, "the returned item should have the stored version")
+	pinnedAt := *patched.PinnedAt
+
+	version := patched.Version
+	patched, err = feed.Patch(item.ID, ItemPatch{Version: &version, Pinned: &yes, Starred: &no})
+	require.NoError(t, err)
+	assert.Equal(t, pinnedAt, *patched.PinnedAt, "an item already pinned should keep its pin time")
+	assert.Nil(t, patched.StarredAt)
+
+	_, err = feed.Patch(item.ID, ItemPatch{Version: &version, Read: &yes})
+	assert.ErrorIs(t, err, ErrVersionConflict)
+
+	_, err = feed.Patch(uuid.New(), ItemPatch{Read: &yes})
+	assert.ErrorIs(t, err, ErrItemNotFound)
 }
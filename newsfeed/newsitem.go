@@ -1,6 +1,8 @@
 package newsfeed
 
 import (
+	"encoding/json"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
@@ -8,14 +10,105 @@ import (
 
 // NewsItem represents a single news item as defined in Spec 1, section 2.1
 type NewsItem struct {
-	ID           uuid.UUID  `json:"id"`
-	Title        string     `json:"title"`
-	Summary      string     `json:"summary"`
-	URL          string     `json:"url"`
-	Publisher    *string    `json:"publisher,omitempty"`
-	Authors      []string   `json:"authors"`
-	PublishedAt  time.Time  `json:"published_at"`
-	DiscoveredAt time.Time  `json:"discovered_at"`
-	PinnedAt     *time.Time `json:"pinned_at,omitempty"`
-	SourceID     *uuid.UUID `json:"source_id,omitempty"`
+	ID               uuid.UUID  `json:"id"`
+	Title            string     `json:"title"`
+	Summary          string     `json:"summary"`                     // Plain text
+	SummaryHTML      string     `json:"summary_html,omitempty"`      // Sanitized HTML of the publisher's summary, if kept
+	GeneratedSummary string     `json:"generated_summary,omitempty"` // Written by a summarizer when the publisher gave no summary
+	URL              string     `json:"url"`
+	Publisher        *string    `json:"publisher,omitempty"`
+	Authors          []string   `json:"authors"`
+	PublishedAt      time.Time  `json:"published_at"`
+	DiscoveredAt     time.Time  `json:"discovered_at"`
+	PinnedAt         *time.Time `json:"pinned_at,omitempty"`
+	PinExpiresAt     *time.Time `json:"pin_expires_at,omitempty"` // When the pin is removed; nil keeps it until unpinned
+	ReadAt           *time.Time `json:"read_at,omitempty"`
+	OpenedAt         *time.Time `json:"opened_at,omitempty"` // When the item's link was last followed through newsfed
+	ArchivedAt       *time.Time `json:"archived_at,omitempty"`
+	StarredAt        *time.Time `json:"starred_at,omitempty"`
+	SnoozedUntil     *time.Time `json:"snoozed_until,omitempty"` // Hidden from listings until this time
+	Notes            string     `json:"notes,omitempty"`
+	SourceID         *uuid.UUID `json:"source_id,omitempty"`
+	GUID             string     `json:"guid,omitempty"` // The feed's identifier for the item, unique within its source
+	Category         *string    `json:"category,omitempty"`
+	Language         string     `json:"language,omitempty"` // ISO 639-1 code such as "en"; empty if unknown
+	// ContentHash is HashContent of the title, summary, and URL the
+	// publisher last gave the item, when they may differ from the item's own
+	// because the item was edited. Empty means they are the item's own.
+	ContentHash string       `json:"content_hash,omitempty"`
+	UpdatedAt   *time.Time   `json:"updated_at,omitempty"` // When the publisher's changes were last applied
+	Changes     []ItemChange `json:"changes,omitempty"`    // Publisher changes applied to the item, oldest first
+	// Metadata holds extra attributes that only some source types provide,
+	// such as a Hacker News story's score.
+	Metadata map[string]any `json:"metadata,omitempty"`
+	// Version counts the times the item has been saved with Update, which
+	// refuses an item whose version isn't the one stored
+	Version int `json:"version"`
+}
+
+// DisplaySummary returns the publisher's summary, or the generated summary
+// if the publisher gave none.
+func (item *NewsItem) DisplaySummary() string {
+	if item.Summary != "" {
+		return item.Summary
+	}
+	return item.GeneratedSummary
+}
+
+// IsRead returns true if the news item has been marked as read.
+func (item *NewsItem) IsRead() bool {
+	return item.ReadAt != nil
+}
+
+// IsArchived returns true if the news item has been archived.
+func (item *NewsItem) IsArchived() bool {
+	return item.ArchivedAt != nil
+}
+
+// IsStarred returns true if the news item has been starred.
+func (item *NewsItem) IsStarred() bool {
+	return item.StarredAt != nil
+}
+
+// IsSnoozed returns true if the news item is snoozed until a time after now.
+func (item *NewsItem) IsSnoozed(now time.Time) bool {
+	return item.SnoozedUntil != nil && item.SnoozedUntil.After(now)
+}
+
+// SurfacedAt returns when the item last came to the reader's attention: when
+// it was discovered, or when its snooze ended if that was later.
+func (item *NewsItem) SurfacedAt() time.Time {
+	if item.SnoozedUntil != nil && item.SnoozedUntil.After(item.DiscoveredAt) {
+		return *item.SnoozedUntil
+	}
+	return item.DiscoveredAt
+}
+
+// MetadataValue returns the metadata value stored under key as text, and
+// whether the key is set. Numbers are written without a trailing ".0", and
+// lists and objects as JSON.
+func (item *NewsItem) MetadataValue(key string) (string, bool) {
+	value, ok := item.Metadata[key]
+	if !ok {
+		return "", false
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case bool:
+		return strconv.FormatBool(v), true
+	case int:
+		return strconv.Itoa(v), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case nil:
+		return "null", true
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
 }
@@ -0,0 +1,306 @@
+package newsfeed
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pevans/newsfed/sqldb"
+)
+
+// postgresMigrations is the PostgreSQL schema, as migrations applied in order
+// by sqldb. The items table has the same columns as in SQLite; timestamps use
+// byte-wise collation so that they sort chronologically.
+var postgresMigrations = []string{
+	`
+	CREATE TABLE items (
+		id TEXT PRIMARY KEY,
+		url TEXT NOT NULL,
+		publisher TEXT,
+		published_at TEXT COLLATE "C" NOT NULL,
+		discovered_at TEXT COLLATE "C" NOT NULL,
+		pinned_at TEXT COLLATE "C",
+		read_at TEXT COLLATE "C",
+		source_id TEXT,
+		data TEXT NOT NULL,
+		archived_at TEXT COLLATE "C",
+		starred_at TEXT COLLATE "C",
+		category TEXT
+	);
+
+	CREATE INDEX idx_items_url ON items(url);
+	CREATE INDEX idx_items_publisher ON items(publisher);
+	CREATE INDEX idx_items_published_at ON items(published_at);
+	CREATE INDEX idx_items_discovered_at ON items(discovered_at);
+	CREATE INDEX idx_items_pinned_at ON items(pinned_at);
+
+	CREATE TABLE feed_revision (
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		revision BIGINT NOT NULL
+	);
+	INSERT INTO feed_revision (id, revision) VALUES (1, 0);
+	`,
+	`
+	CREATE TABLE changes (
+		seq BIGSERIAL PRIMARY KEY,
+		id TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		changed_at TEXT COLLATE "C" NOT NULL
+	);
+	CREATE INDEX idx_changes_changed_at ON changes(changed_at);
+	`,
+}
+
+// postgresStore stores news items in a PostgreSQL database, laid out as in
+// sqliteStore. Several newsfed processes can share one database. Queries are
+// evaluated in memory.
+type postgresStore struct {
+	sqlChangeLog // Filled by each write
+	db           *sqldb.DB
+}
+
+// newPostgresStore connects to the PostgreSQL database at dsn and brings its
+// schema up to date.
+func newPostgresStore(dsn string) (*postgresStore, error) {
+	db, err := sqldb.Open(dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Migrate("newsfeed", postgresMigrations); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to initialize schema: %w", err)
+	}
+	return &postgresStore{sqlChangeLog: sqlChangeLog{db}, db: db}, nil
+}
+
+// write runs fn in a transaction and bumps the feed revision if fn changed
+// anything. Writers take turns on the revision row, so that the changes
+// they log are numbered in the order they commit and a client following
+// the change log never skips one still being written.
+func (s *postgresStore) write(fn func(tx *sqldb.Tx) (int64, error)) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.Exec("SELECT revision FROM feed_revision WHERE id = 1 FOR UPDATE"); err != nil {
+		return fmt.Errorf("failed to lock feed revision: %w", err)
+	}
+	changed, err := fn(tx)
+	if err != nil {
+		return err
+	}
+	if changed > 0 {
+		if _, err := tx.Exec("UPDATE feed_revision SET revision = revision + 1 WHERE id = 1"); err != nil {
+			return fmt.Errorf("failed to update feed revision: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// logChange records a change of the given kind to the item with the given
+// ID in the change log.
+func logChange(tx *sqldb.Tx, id uuid.UUID, kind string) error {
+	now := time.Now()
+	if _, err := tx.Exec("INSERT INTO changes (id, kind, changed_at) VALUES (?, ?, ?)", id.String(), kind, formatTime(&now)); err != nil {
+		return fmt.Errorf("failed to record change: %w", err)
+	}
+	return nil
+}
+
+// Add saves a news item, replacing any item with the same ID.
+func (s *postgresStore) Add(item NewsItem) error {
+	return s.AddAll([]NewsItem{item})
+}
+
+// AddAll saves news items in a single transaction, replacing any items with
+// the same IDs: either all of them are saved or, on error, none are.
+func (s *postgresStore) AddAll(items []NewsItem) error {
+	query := `
+		INSERT INTO items (
+			id, url, publisher, published_at, discovered_at,
+			pinned_at, read_at, source_id, data, archived_at, starred_at,
+			category
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			url = excluded.url, publisher = excluded.publisher,
+			published_at = excluded.published_at,
+			discovered_at = excluded.discovered_at,
+			pinned_at = excluded.pinned_at, read_at = excluded.read_at,
+			source_id = excluded.source_id, data = excluded.data,
+			archived_at = excluded.archived_at,
+			starred_at = excluded.starred_at, category = excluded.category
+	`
+
+	return s.write(func(tx *sqldb.Tx) (int64, error) {
+		for _, item := range items {
+			data, err := json.Marshal(item)
+			if err != nil {
+				return 0, fmt.Errorf("failed to marshal news item: %w", err)
+			}
+			var exists bool
+			if err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM items WHERE id = ?)", item.ID.String()).Scan(&exists); err != nil {
+				return 0, fmt.Errorf("failed to check news item: %w", err)
+			}
+			if _, err := tx.Exec(query, itemColumns(item, data)...); err != nil {
+				return 0, fmt.Errorf("failed to write news item: %w", err)
+			}
+			kind := ChangeCreated
+			if exists {
+				kind = ChangeUpdated
+			}
+			if err := logChange(tx, item.ID, kind); err != nil {
+				return 0, err
+			}
+		}
+		return int64(len(items)), nil
+	})
+}
+
+// Get retrieves a news item by its ID.
+func (s *postgresStore) Get(id uuid.UUID) (*NewsItem, error) {
+	var data string
+	err := s.db.QueryRow("SELECT data FROM items WHERE id = ?", id.String()).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil // Item not found (not an error)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read news item: %w", err)
+	}
+
+	var item NewsItem
+	if err := json.Unmarshal([]byte(data), &item); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal news item: %w", err)
+	}
+
+	return &item, nil
+}
+
+// List returns all news items in the database.
+func (s *postgresStore) List() (*ListResult, error) {
+	rows, err := s.db.Query("SELECT id, data FROM items")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query news items: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	result := &ListResult{}
+	for rows.Next() {
+		var id, data string
+		if err := rows.Scan(&id, &data); err != nil {
+			return nil, fmt.Errorf("failed to scan news item: %w", err)
+		}
+
+		var item NewsItem
+		if err := json.Unmarshal([]byte(data), &item); err != nil {
+			result.Errors = append(result.Errors, ReadError{
+				Filename: id,
+				Err:      err,
+			})
+			continue
+		}
+
+		result.Items = append(result.Items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read news items: %w", err)
+	}
+
+	return result, nil
+}
+
+// Update updates an existing news item if its version is the stored one.
+// The versions are compared by the UPDATE itself, so concurrent writers
+// can't both succeed.
+func (s *postgresStore) Update(item NewsItem) error {
+	expected := item.Version
+	item.Version++
+	data, err := json.Marshal(item)
+	if err != nil {
+		return fmt.Errorf("failed to marshal news item: %w", err)
+	}
+
+	query := `
+		UPDATE items SET
+			id = ?, url = ?, publisher = ?, published_at = ?, discovered_at = ?,
+			pinned_at = ?, read_at = ?, source_id = ?, data = ?, archived_at = ?,
+			starred_at = ?, category = ?
+		WHERE id = ? AND COALESCE((data::jsonb->>'version')::bigint, 0) = ?
+	`
+
+	return s.write(func(tx *sqldb.Tx) (int64, error) {
+		args := append(itemColumns(item, data), item.ID.String(), expected)
+		result, err := tx.Exec(query, args...)
+		if err != nil {
+			return 0, fmt.Errorf("failed to write news item: %w", err)
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rows == 0 {
+			var count int
+			if err := tx.QueryRow("SELECT COUNT(*) FROM items WHERE id = ?", item.ID.String()).Scan(&count); err != nil {
+				return 0, fmt.Errorf("failed to read news item: %w", err)
+			}
+			if count == 0 {
+				return 0, ErrItemNotFound
+			}
+			return 0, ErrVersionConflict
+		}
+		if err := logChange(tx, item.ID, ChangeUpdated); err != nil {
+			return 0, err
+		}
+		return rows, nil
+	})
+}
+
+// Delete removes a news item by its ID.
+func (s *postgresStore) Delete(id uuid.UUID) error {
+	return s.write(func(tx *sqldb.Tx) (int64, error) {
+		result, err := tx.Exec("DELETE FROM items WHERE id = ?", id.String())
+		if err != nil {
+			return 0, fmt.Errorf("failed to delete news item: %w", err)
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rows == 0 {
+			return 0, fmt.Errorf("failed to delete news item: %w", ErrItemNotFound)
+		}
+		if err := logChange(tx, id, ChangeDeleted); err != nil {
+			return 0, err
+		}
+		return rows, nil
+	})
+}
+
+// Revision returns the number of changes made to the items table.
+func (s *postgresStore) Revision() (string, error) {
+	var revision int64
+	if err := s.db.QueryRow("SELECT revision FROM feed_revision WHERE id = 1").Scan(&revision); err != nil {
+		return "", fmt.Errorf("failed to read feed revision: %w", err)
+	}
+	return strconv.FormatInt(revision, 10), nil
+}
+
+// Ping checks that the database can be queried.
+func (s *postgresStore) Ping() error {
+	if _, err := s.Revision(); err != nil {
+		return fmt.Errorf("feed database is not reachable: %w", err)
+	}
+	return nil
+}
+
+// Close closes the database connection pool.
+func (s *postgresStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,86 @@
+package newsfeed
+
+import (
+	"testing"
+
+	"github.com/pevans/newsfed/sqldb/sqldbtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test helper: create a PostgreSQL feed in a fresh schema, skipping the test
+// when no test database is configured
+func createTestPostgresFeed(t *testing.T) *NewsFeed {
+	feed, err := Open(sqldbtest.PostgresDSN(t))
+	require.NoError(t, err, "should create PostgreSQL feed")
+	t.Cleanup(func() { _ = feed.Close() })
+	return feed
+}
+
+// TestPostgres_ItemLifecycle verifies that items can be added, read, updated,
+// and deleted, and that each change moves the revision
+func TestPostgres_ItemLifecycle(t *testing.T) {
+	feed := createTestPostgresFeed(t)
+	require.NoError(t, feed.Ping())
+
+	rev0, err := feed.Revision()
+	require.NoError(t, err)
+
+	item := createTestItem("Postgres item")
+	item.Metadata = map[string]any{"hn_id": float64(7)}
+	require.NoError(t, feed.Add(item))
+
+	got, err := feed.Get(item.ID)
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Equal(t, item.Title, got.Title)
+	assert.Equal(t, item.Metadata, got.Metadata)
+	assert.True(t, item.PublishedAt.Equal(got.PublishedAt))
+
+	rev1, err := feed.Revision()
+	require.NoError(t, err)
+	assert.NotEqual(t, rev0, rev1)
+
+	// Adding an item with the same ID replaces it
+	item.Title = "Replaced"
+	require.NoError(t, feed.Add(item))
+	list, err := feed.List()
+	require.NoError(t, err)
+	require.Len(t, list.Items, 1)
+	assert.Equal(t, "Replaced", list.Items[0].Title)
+
+	item.Title = "Updated"
+	require.NoError(t, feed.Update(item))
+	got, err = feed.Get(item.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Updated", got.Title)
+
+	require.NoError(t, feed.Delete(item.ID))
+	got, err = feed.Get(item.ID)
+	require.NoError(t, err)
+	assert.Nil(t, got)
+
+	assert.ErrorIs(t, feed.Delete(item.ID), ErrItemNotFound)
+	assert.ErrorIs(t, feed.Update(item), ErrItemNotFound)
+}
+
+// TestPostgres_ReopenKeepsItems verifies that reopening the database applies
+// no migrations twice and keeps existing items
+func TestPostgres_ReopenKeepsItems(t *testing.T) {
+	dsn := sqldbtest.PostgresDSN(t)
+
+	feed, err := Open(dsn)
+	require.NoError(t, err)
+	item := createTestItem("kept")
+	require.NoError(t, feed.Add(item))
+	require.NoError(t, feed.Close())
+
+	feed, err = Open(dsn)
+	require.NoError(t, err)
+	defer func() { _ = feed.Close() }()
+
+	got, err := feed.Get(item.ID)
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Equal(t, "kept", got.Title)
+}
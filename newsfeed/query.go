@@ -0,0 +1,283 @@
+package newsfeed
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Sort orders accepted by ListOptions.SortBy.
+const (
+	SortPublished  = "published"
+	SortDiscovered = "discovered"
+	SortPinned     = "pinned"
+	SortScore      = "score"
+)
+
+// ListOptions describes a filtered, sorted, and paginated view of a news
+// feed. The zero value matches every item that isn't archived, sorted by
+// published date.
+type ListOptions struct {
+	// Pinned, when set, keeps only pinned (true) or unpinned (false) items.
+	Pinned *bool
+	// Unread keeps only items that have not been marked as read.
+	Unread bool
+	// Starred keeps only starred items.
+	Starred bool
+	// Archived keeps only archived items. Archived items are otherwise
+	// excluded.
+	Archived bool
+	// Snoozed keeps only items that are still snoozed. Snoozed items are
+	// otherwise excluded until their snooze ends.
+	Snoozed bool
+	// Publisher keeps items whose publisher contains this string
+	// (case-insensitive).
+	Publisher string
+	// Category keeps items from sources in this category
+	// (case-insensitive).
+	Category string
+	// Language keeps items in this language, given as an ISO 639-1 code
+	// (case-insensitive).
+	Language string
+	// SourceID keeps items discovered by this source.
+	SourceID *uuid.UUID
+	// Metadata keeps items whose metadata has each key set to the given
+	// value, compared as text (see NewsItem.MetadataValue).
+	Metadata map[string]string
+	// DiscoveredSince keeps items discovered at or after this time. An item
+	// whose snooze ended at or after this time is kept too, as though it had
+	// just been discovered.
+	DiscoveredSince *time.Time
+	// IncludePinned keeps pinned items even when they fall outside
+	// DiscoveredSince.
+	IncludePinned bool
+	// SortBy is one of SortPublished (default), SortDiscovered, SortPinned,
+	// or SortScore. SortScore orders by score, highest first; the others are
+	// most recent first.
+	SortBy string
+	// Scoring is the model used by SortScore. When nil, the default model is
+	// used.
+	Scoring *ScoreModel
+	// Limit caps the number of returned items; zero means no limit.
+	Limit int
+	// Offset skips this many matching items before returning results.
+	Offset int
+	// Cursor continues a query after the last item of a previous page, as
+	// given by that page's NextCursor. Unlike Offset, it is not thrown off by
+	// items added between pages. It cannot be combined with Offset or
+	// SortScore.
+	Cursor string
+
+	// withoutState evaluates the filters as though no item had a reader's
+	// state, for a userStore that overlays its own on the items found.
+	withoutState bool
+}
+
+// QueryResult holds one page of a query along with the total number of
+// matching items before pagination.
+type QueryResult struct {
+	Items  []NewsItem
+	Total  int
+	Errors []ReadError
+	// Scores holds the score of each returned item when sorted by SortScore.
+	Scores map[uuid.UUID]float64
+	// NextCursor continues the query after this page. It is empty on the
+	// last page and when sorting by SortScore.
+	NextCursor string
+}
+
+// Querier is implemented by storage backends that can evaluate ListOptions
+// natively. Backends that don't implement it are queried by filtering the
+// output of List in memory.
+type Querier interface {
+	Query(opts ListOptions) (*QueryResult, error)
+}
+
+// Validate checks that the options are well formed.
+func (opts ListOptions) Validate() error {
+	switch opts.SortBy {
+	case "", SortPublished, SortDiscovered, SortPinned, SortScore:
+	default:
+		return fmt.Errorf("invalid sort option: %s (must be published, discovered, pinned, or score)", opts.SortBy)
+	}
+	if opts.Limit < 0 {
+		return fmt.Errorf("limit must not be negative")
+	}
+	if opts.Offset < 0 {
+		return fmt.Errorf("offset must not be negative")
+	}
+	if opts.Cursor != "" {
+		if opts.Offset > 0 {
+			return fmt.Errorf("cursor and offset cannot be used together")
+		}
+		if opts.SortBy == SortScore {
+			return fmt.Errorf("cursor cannot be used when sorting by score")
+		}
+		if _, err := decodeCursor(opts.Cursor, opts.SortBy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Query returns the items matching opts. Backends that implement Querier
+// evaluate the query natively; otherwise every item is loaded and filtered.
+func (nf *NewsFeed) Query(opts ListOptions) (*QueryResult, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Scores depend on the pin history of the whole feed and on the full
+	// text of each item, so scored queries are always evaluated in memory
+	if q, ok := nf.store.(Querier); ok && opts.SortBy != SortScore {
+		return q.Query(opts)
+	}
+	return queryList(nf.store, opts)
+}
+
+// queryList evaluates opts in memory against every item in store.
+func queryList(store Store, opts ListOptions) (*QueryResult, error) {
+	result, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+
+	matched := filterItems(result.Items, opts)
+	var scores map[uuid.UUID]float64
+	if opts.SortBy == SortScore {
+		model := ScoreModel{}
+		if opts.Scoring != nil {
+			model = *opts.Scoring
+		}
+		scores = sortByScore(matched, model.NewScorer(result.Items, time.Now()))
+	} else {
+		sortItems(matched, opts.SortBy)
+	}
+
+	page, next, err := pageItems(matched, opts)
+	if err != nil {
+		return nil, err
+	}
+	if scores != nil {
+		pageScores := make(map[uuid.UUID]float64, len(page))
+		for _, item := range page {
+			pageScores[item.ID] = scores[item.ID]
+		}
+		scores = pageScores
+	}
+
+	return &QueryResult{
+		Items:      page,
+		Total:      len(matched),
+		Errors:     result.Errors,
+		Scores:     scores,
+		NextCursor: next,
+	}, nil
+}
+
+// Matches reports whether an item satisfies the filters in opts. Sorting and
+// pagination are not considered.
+func (opts ListOptions) Matches(item NewsItem) bool {
+	if opts.withoutState {
+		ItemState{}.ApplyTo(&item)
+	}
+
+	if opts.Pinned != nil && *opts.Pinned != (item.PinnedAt != nil) {
+		return false
+	}
+
+	if opts.Unread && item.ReadAt != nil {
+		return false
+	}
+
+	if opts.Starred && item.StarredAt == nil {
+		return false
+	}
+
+	if opts.Archived != (item.ArchivedAt != nil) {
+		return false
+	}
+
+	if opts.Snoozed != item.IsSnoozed(time.Now()) {
+		return false
+	}
+
+	if opts.Publisher != "" {
+		if item.Publisher == nil || !strings.Contains(strings.ToLower(*item.Publisher), strings.ToLower(opts.Publisher)) {
+			return false
+		}
+	}
+
+	if opts.Category != "" {
+		if item.Category == nil || !strings.EqualFold(*item.Category, opts.Category) {
+			return false
+		}
+	}
+
+	if opts.Language != "" && !strings.EqualFold(item.Language, opts.Language) {
+		return false
+	}
+
+	if opts.SourceID != nil && (item.SourceID == nil || *item.SourceID != *opts.SourceID) {
+		return false
+	}
+
+	for key, want := range opts.Metadata {
+		if got, ok := item.MetadataValue(key); !ok || got != want {
+			return false
+		}
+	}
+
+	if opts.DiscoveredSince != nil && item.SurfacedAt().Before(*opts.DiscoveredSince) {
+		if !opts.IncludePinned || item.PinnedAt == nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// filterItems returns the items that match opts.
+func filterItems(items []NewsItem, opts ListOptions) []NewsItem {
+	var matched []NewsItem
+	for _, item := range items {
+		if opts.Matches(item) {
+			matched = append(matched, item)
+		}
+	}
+	return matched
+}
+
+// sortItems sorts items in place, most recent first. Ties are broken by ID so
+// that every backend returns the same order.
+func sortItems(items []NewsItem, sortBy string) {
+	sort.Slice(items, func(i, j int) bool {
+		return lessItems(items[i], items[j], sortBy)
+	})
+}
+
+// lessItems reports whether a sorts before b.
+func lessItems(a, b NewsItem, sortBy string) bool {
+	switch sortBy {
+	case SortDiscovered:
+		if !a.DiscoveredAt.Equal(b.DiscoveredAt) {
+			return a.DiscoveredAt.After(b.DiscoveredAt)
+		}
+	case SortPinned:
+		// Pinned items come first, most recently pinned first
+		if (a.PinnedAt == nil) != (b.PinnedAt == nil) {
+			return a.PinnedAt != nil
+		}
+		if a.PinnedAt != nil && !a.PinnedAt.Equal(*b.PinnedAt) {
+			return a.PinnedAt.After(*b.PinnedAt)
+		}
+	default:
+		if !a.PublishedAt.Equal(b.PublishedAt) {
+			return a.PublishedAt.After(b.PublishedAt)
+		}
+	}
+	return a.ID.String() < b.ID.String()
+}
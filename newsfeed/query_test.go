@@ -0,0 +1,280 @@
+package newsfeed
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// querySources are the sources of the items populateQueryFeed adds
+var querySources = []uuid.UUID{
+	uuid.MustParse("550e8400-e29b-41d4-a716-446655440000"),
+	uuid.MustParse("7c9e6679-7425-40de-944b-e07fc1f90ae7"),
+}
+
+// Test helper: populate a feed with items that vary in every field that
+// ListOptions can filter or sort on
+func populateQueryFeed(t *testing.T, feed *NewsFeed) {
+	publishers := []string{"Go Blog", "The Daily", "go weekly"}
+	categories := []string{"golang", "Security"}
+	now := time.Now().UTC()
+
+	for i := range 30 {
+		item := createTestItem(fmt.Sprintf("item-%d", i))
+		publisher := publishers[i%len(publishers)]
+		item.Publisher = &publisher
+		item.PublishedAt = now.Add(-time.Duration(i*7%30) * time.Hour)
+		item.DiscoveredAt = now.Add(-time.Duration(i) * 12 * time.Hour)
+		if i%4 == 0 {
+			pinnedAt := now.Add(-time.Duration(i) * time.Minute)
+			item.PinnedAt = &pinnedAt
+		}
+		if i%3 == 0 {
+			readAt := now
+			item.ReadAt = &readAt
+		}
+		if i%6 == 1 {
+			starredAt := now
+			item.StarredAt = &starredAt
+		}
+		if i%5 == 0 {
+			archivedAt := now
+			item.ArchivedAt = &archivedAt
+		}
+		// Some snoozes are still running and some have ended; the times
+		// are given in another zone, as an API client might
+		if i%8 == 2 {
+			snoozedUntil := now.Add(time.Duration(i) * time.Hour).In(time.FixedZone("", -5*60*60))
+			item.SnoozedUntil = &snoozedUntil
+		}
+		if i%8 == 6 {
+			snoozedUntil := now.Add(-time.Duration(i) * time.Hour).In(time.FixedZone("", 9*60*60))
+			item.SnoozedUntil = &snoozedUntil
+		}
+		if i%7 != 0 {
+			category := categories[i%len(categories)]
+			item.Category = &category
+		}
+		if i%4 != 3 {
+			item.Language = []string{"en", "de", "FR"}[i%3]
+		}
+		if i%5 != 4 {
+			item.SourceID = &querySources[i%2]
+		}
+		if i%2 == 0 {
+			item.Metadata = map[string]any{
+				"score":   i * 10,
+				"flagged": i%4 == 0,
+				"kind":    "story",
+			}
+		}
+		require.NoError(t, feed.Add(item))
+	}
+}
+
+// Test helper: extract the IDs of items in order
+func itemIDs(items []NewsItem) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ID)
+	}
+	return ids
+}
+
+// TestQuery_BackendsAgree verifies that the SQLite backend's native query
+// returns the same items, in the same order, as the in-memory fallback used
+// by the directory backend
+func TestQuery_BackendsAgree(t *testing.T) {
+	dirFeed, err := NewNewsFeed(t.TempDir())
+	require.NoError(t, err)
+	sqliteFeed := createTestSQLiteFeed(t)
+
+	// Add identical items to both backends
+	populateQueryFeed(t, sqliteFeed)
+	result, err := sqliteFeed.List()
+	require.NoError(t, err)
+	for _, item := range result.Items {
+		require.NoError(t, dirFeed.Add(item))
+	}
+
+	yes, no := true, false
+	threeDaysAgo := time.Now().Add(-3 * 24 * time.Hour)
+	optionSets := []ListOptions{
+		{},
+		{Pinned: &yes},
+		{Pinned: &no, SortBy: SortDiscovered},
+		{Unread: true},
+		{Archived: true},
+		{Snoozed: true, SortBy: SortDiscovered},
+		{Starred: true, SortBy: SortDiscovered},
+		{Publisher: "GO"},
+		{Category: "security", Unread: true},
+		{Language: "en"},
+		{Language: "fr", SortBy: SortDiscovered},
+		{SourceID: &querySources[0]},
+		{SourceID: &querySources[1], Unread: true, SortBy: SortDiscovered},
+		{Metadata: map[string]string{"score": "40"}},
+		{Metadata: map[string]string{"flagged": "true", "kind": "story"}},
+		{Metadata: map[string]string{"kind": "link"}},
+		{DiscoveredSince: &threeDaysAgo},
+		{DiscoveredSince: &threeDaysAgo, IncludePinned: true, SortBy: SortPinned},
+		{SortBy: SortPinned, Limit: 5, Offset: 3},
+		{Limit: 7},
+		{Offset: 25},
+		{Offset: 100},
+	}
+
+	for _, opts := range optionSets {
+		fromDir, err := dirFeed.Query(opts)
+		require.NoError(t, err)
+		fromSQLite, err := sqliteFeed.Query(opts)
+		require.NoError(t, err)
+
+		assert.Equal(t, fromDir.Total, fromSQLite.Total, "totals should match for %+v", opts)
+		assert.Equal(t, itemIDs(fromDir.Items), itemIDs(fromSQLite.Items), "items should match for %+v", opts)
+		assert.Equal(t, fromDir.NextCursor, fromSQLite.NextCursor, "cursors should match for %+v", opts)
+	}
+}
+
+// TestQuery_ResultsMatchOptions verifies that every returned item satisfies
+// the filters and that the total counts all matches
+func TestQuery_ResultsMatchOptions(t *testing.T) {
+	feed := createTestSQLiteFeed(t)
+	populateQueryFeed(t, feed)
+
+	all, err := feed.List()
+	require.NoError(t, err)
+
+	yes := true
+	opts := ListOptions{Pinned: &yes, Publisher: "go"}
+	result, err := feed.Query(opts)
+	require.NoError(t, err)
+
+	expected := 0
+	for _, item := range all.Items {
+		if opts.Matches(item) {
+			expected++
+		}
+	}
+	assert.Equal(t, expected, result.Total)
+	for _, item := range result.Items {
+		assert.True(t, opts.Matches(item))
+	}
+}
+
+// TestQuery_InvalidOptions verifies that malformed options are rejected
+func TestQuery_InvalidOptions(t *testing.T) {
+	feed, err := NewNewsFeed(t.TempDir())
+	require.NoError(t, err)
+
+	_, err = feed.Query(ListOptions{SortBy: "title"})
+	assert.Error(t, err)
+
+	_, err = feed.Query(ListOptions{Limit: -1})
+	assert.Error(t, err)
+}
+
+// TestQuery_PublisherEscapesWildcards verifies that LIKE wildcards in the
+// publisher filter are matched literally
+func TestQuery_PublisherEscapesWildcards(t *testing.T) {
+	feed := createTestSQLiteFeed(t)
+
+	for _, name := range []string{"100% Go", "100 Go"} {
+		item := createTestItem(name)
+		publisher := name
+		item.Publisher = &publisher
+		require.NoError(t, feed.Add(item))
+	}
+
+	result, err := feed.Query(ListOptions{Publisher: "100%"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Total)
+}
+
+// Ensure the SQLite backend evaluates queries natively
+var _ Querier = (*sqliteStore)(nil)
+
+// TestQuery_Metadata verifies that metadata filters compare values as text
+// once items have been stored and read back
+func TestQuery_Metadata(t *testing.T) {
+	feed := createTestSQLiteFeed(t)
+	populateQueryFeed(t, feed)
+
+	result, err := feed.Query(ListOptions{Metadata: map[string]string{"score": "40"}})
+	require.NoError(t, err)
+	require.Len(t, result.Items, 1)
+	assert.Equal(t, "item-4", result.Items[0].Title)
+
+	value, ok := result.Items[0].MetadataValue("flagged")
+	assert.True(t, ok)
+	assert.Equal(t, "true", value)
+
+	_, ok = result.Items[0].MetadataValue("missing")
+	assert.False(t, ok)
+}
+
+// TestQuery_CursorPaging verifies that walking a query by cursor visits every
+// item once, even when items are added between pages
+func TestQuery_CursorPaging(t *testing.T) {
+	for _, sortBy := range []string{SortPublished, SortDiscovered, SortPinned} {
+		dirFeed, err := NewNewsFeed(t.TempDir())
+		require.NoError(t, err)
+		feeds := map[string]*NewsFeed{"dir": dirFeed, "sqlite": createTestSQLiteFeed(t)}
+
+		for name, feed := range feeds {
+			populateQueryFeed(t, feed)
+			all, err := feed.Query(ListOptions{SortBy: sortBy})
+			require.NoError(t, err)
+
+			var seen []NewsItem
+			opts := ListOptions{SortBy: sortBy, Limit: 7}
+			for page := 0; ; page++ {
+				result, err := feed.Query(opts)
+				require.NoError(t, err)
+				seen = append(seen, result.Items...)
+
+				// A newer item arriving mid-walk sorts ahead of the cursor
+				// and must not shift later pages
+				if page == 1 {
+					newer := createTestItem("newer")
+					newer.PublishedAt = time.Now().Add(time.Hour)
+					newer.DiscoveredAt = time.Now().Add(time.Hour)
+					pinnedAt := time.Now().Add(time.Hour)
+					newer.PinnedAt = &pinnedAt
+					require.NoError(t, feed.Add(newer))
+				}
+
+				if result.NextCursor == "" {
+					break
+				}
+				opts.Cursor = result.NextCursor
+			}
+
+			assert.Equal(t, itemIDs(all.Items), itemIDs(seen), "%s backend sorted by %s", name, sortBy)
+		}
+	}
+}
+
+// TestQuery_CursorValidation verifies that malformed cursors, and cursors
+// used with a different sort order or an offset, are rejected
+func TestQuery_CursorValidation(t *testing.T) {
+	feed := createTestSQLiteFeed(t)
+	populateQueryFeed(t, feed)
+
+	result, err := feed.Query(ListOptions{Limit: 5})
+	require.NoError(t, err)
+	require.NotEmpty(t, result.NextCursor)
+
+	_, err = feed.Query(ListOptions{Cursor: "not-a-cursor"})
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+
+	_, err = feed.Query(ListOptions{Cursor: result.NextCursor, SortBy: SortDiscovered})
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+
+	_, err = feed.Query(ListOptions{Cursor: result.NextCursor, Offset: 5})
+	assert.Error(t, err)
+}
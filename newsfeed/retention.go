@@ -0,0 +1,67 @@
+package newsfeed
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultRetention is the age after which unpinned items are pruned when no
+// retention period is configured.
+const DefaultRetention = 90 * 24 * time.Hour
+
+// ParseRetention parses a retention period. In addition to the units
+// accepted by time.ParseDuration, a whole number of days may be given with a
+// "d" suffix (for example, "30d").
+func ParseRetention(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid retention period: %s", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid retention period: %s", s)
+	}
+	return d, nil
+}
+
+// PruneOptions controls which items Prune removes.
+type PruneOptions struct {
+	// Before removes items discovered before this time.
+	Before time.Time
+	// DryRun reports the items that would be removed without removing them.
+	DryRun bool
+}
+
+// Prune deletes every unpinned, unstarred item discovered before
+// opts.Before, whether or not it has been read. Pinned and starred items are
+// never pruned. It returns the items that were removed (or, with DryRun,
+// that would be removed).
+func (nf *NewsFeed) Prune(opts PruneOptions) ([]NewsItem, error) {
+	result, err := nf.store.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var pruned []NewsItem
+	for _, item := range result.Items {
+		if item.PinnedAt != nil || item.StarredAt != nil || !item.DiscoveredAt.Before(opts.Before) {
+			continue
+		}
+
+		if !opts.DryRun {
+			if err := nf.store.Delete(item.ID); err != nil {
+				return pruned, fmt.Errorf("failed to delete item %s: %w", item.ID, err)
+			}
+		}
+		pruned = append(pruned, item)
+	}
+
+	return pruned, nil
+}
@@ -0,0 +1,117 @@
+package newsfeed
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseRetention verifies day suffixes and Go durations are accepted
+func TestParseRetention(t *testing.T) {
+	valid := map[string]time.Duration{
+		"30d": 30 * 24 * time.Hour,
+		"1d":  24 * time.Hour,
+		"12h": 12 * time.Hour,
+		"90m": 90 * time.Minute,
+	}
+	for input, expected := range valid {
+		d, err := ParseRetention(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, expected, d, input)
+	}
+
+	for _, input := range []string{"", "d", "-3d", "0d", "abc", "0s", "1.5d"} {
+		_, err := ParseRetention(input)
+		assert.Error(t, err, input)
+	}
+}
+
+// TestPrune_KeepsPinnedStarredAndRecent verifies that only unpinned,
+// unstarred items older than the cutoff are removed, read or not
+func TestPrune_KeepsPinnedStarredAndRecent(t *testing.T) {
+	feed, err := NewNewsFeed(t.TempDir())
+	require.NoError(t, err)
+
+	now := time.Now()
+	old := now.Add(-40 * 24 * time.Hour)
+
+	oldUnread := createTestItem("old unread")
+	oldUnread.DiscoveredAt = old
+	oldRead := createTestItem("old read")
+	oldRead.DiscoveredAt = old
+	oldRead.ReadAt = &now
+	oldPinned := createTestItem("old pinned")
+	oldPinned.DiscoveredAt = old
+	oldPinned.PinnedAt = &now
+	oldStarred := createTestItem("old starred")
+	oldStarred.DiscoveredAt = old
+	oldStarred.StarredAt = &now
+	recent := createTestItem("recent")
+	recent.DiscoveredAt = now
+
+	for _, item := range []NewsItem{oldUnread, oldRead, oldPinned, oldStarred, recent} {
+		require.NoError(t, feed.Add(item))
+	}
+
+	cutoff := now.Add(-30 * 24 * time.Hour)
+
+	// A dry run reports items without removing them
+	pruned, err := feed.Prune(PruneOptions{Before: cutoff, DryRun: true})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"old unread", "old read"}, titles(pruned))
+	all, err := feed.List()
+	require.NoError(t, err)
+	assert.Len(t, all.Items, 5)
+
+	pruned, err = feed.Prune(PruneOptions{Before: cutoff})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"old unread", "old read"}, titles(pruned))
+	all, err = feed.List()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"old pinned", "old starred", "recent"}, titles(all.Items))
+}
+
+// TestExpirePins_UnpinsExpired verifies that only pins whose expiry has
+// passed are removed
+func TestExpirePins_UnpinsExpired(t *testing.T) {
+	feed, err := NewNewsFeed(t.TempDir())
+	require.NoError(t, err)
+
+	now := time.Now()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+
+	expired := createTestItem("expired")
+	expired.PinnedAt = &past
+	expired.PinExpiresAt = &past
+	pending := createTestItem("pending")
+	pending.PinnedAt = &past
+	pending.PinExpiresAt = &future
+	forever := createTestItem("forever")
+	forever.PinnedAt = &past
+	for _, item := range []NewsItem{expired, pending, forever} {
+		require.NoError(t, feed.Add(item))
+	}
+
+	unpinned, err := feed.ExpirePins(now)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"expired"}, titles(unpinned))
+
+	yes := true
+	result, err := feed.Query(ListOptions{Pinned: &yes})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"pending", "forever"}, titles(result.Items))
+	item, err := feed.Get(expired.ID)
+	require.NoError(t, err)
+	assert.Nil(t, item.PinExpiresAt)
+}
+
+// Test helper: extract the titles of items
+func titles(items []NewsItem) []string {
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		result = append(result, item.Title)
+	}
+	return result
+}
@@ -0,0 +1,100 @@
+package newsfeed
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestScorer_RecencyDecay verifies that an item's score halves every
+// half-life
+func TestScorer_RecencyDecay(t *testing.T) {
+	now := time.Now()
+	scorer := ScoreModel{HalfLife: 10 * time.Hour}.NewScorer(nil, now)
+
+	fresh := createTestItem("fresh")
+	fresh.PublishedAt = now
+	older := createTestItem("older")
+	older.PublishedAt = now.Add(-10 * time.Hour)
+	future := createTestItem("future")
+	future.PublishedAt = now.Add(time.Hour)
+
+	assert.InDelta(t, 1.0, scorer.Score(fresh), 1e-9)
+	assert.InDelta(t, 0.5, scorer.Score(older), 1e-9)
+	assert.InDelta(t, 1.0, scorer.Score(future), 1e-9, "items dated in the future count as new")
+}
+
+// TestScorer_WeightsAndBoosts verifies source weights, keyword boosts, and
+// the pin history boost
+func TestScorer_WeightsAndBoosts(t *testing.T) {
+	now := time.Now()
+	favorite, other := uuid.New(), uuid.New()
+
+	pinned := createTestItem("pinned earlier")
+	pinned.SourceID = &favorite
+	pinnedAt := now.Add(-48 * time.Hour)
+	pinned.PinnedAt = &pinnedAt
+
+	model := ScoreModel{
+		SourceWeights: map[uuid.UUID]float64{other: 2},
+		KeywordBoosts: map[string]float64{"GoLang": 0.5, "rust": 0.25},
+		PinBoost:      1,
+	}
+	scorer := model.NewScorer([]NewsItem{pinned}, now)
+
+	weighted := createTestItem("weighted")
+	weighted.SourceID = &other
+	weighted.PublishedAt = now
+	assert.InDelta(t, 2.0, scorer.Score(weighted), 1e-9)
+
+	keywords := createTestItem("golang and rust")
+	keywords.PublishedAt = now
+	assert.InDelta(t, 1.75, scorer.Score(keywords), 1e-9)
+
+	// One pinned item from the source adds PinBoost * log2(2)
+	fromFavorite := createTestItem("from favorite")
+	fromFavorite.SourceID = &favorite
+	fromFavorite.PublishedAt = now
+	assert.InDelta(t, 2.0, scorer.Score(fromFavorite), 1e-9)
+
+	disabled := ScoreModel{PinBoost: -1}.NewScorer([]NewsItem{pinned}, now)
+	assert.InDelta(t, 1.0, disabled.Score(fromFavorite), 1e-9)
+}
+
+// TestQuery_SortScore verifies that both backends rank items by score and
+// report the score of each returned item
+func TestQuery_SortScore(t *testing.T) {
+	dirFeed, err := NewNewsFeed(t.TempDir())
+	require.NoError(t, err)
+	sqliteFeed := createTestSQLiteFeed(t)
+
+	now := time.Now()
+	recent := createTestItem("recent")
+	recent.PublishedAt = now.Add(-time.Hour)
+	boosted := createTestItem("older but about golang")
+	boosted.PublishedAt = now.Add(-30 * time.Hour)
+	old := createTestItem("old")
+	old.PublishedAt = now.Add(-72 * time.Hour)
+
+	opts := ListOptions{
+		SortBy:  SortScore,
+		Scoring: &ScoreModel{KeywordBoosts: map[string]float64{"golang": 4}},
+		Limit:   2,
+	}
+
+	for _, feed := range []*NewsFeed{dirFeed, sqliteFeed} {
+		for _, item := range []NewsItem{old, recent, boosted} {
+			require.NoError(t, feed.Add(item))
+		}
+
+		result, err := feed.Query(opts)
+		require.NoError(t, err)
+		assert.Equal(t, 3, result.Total)
+		assert.Equal(t, []uuid.UUID{boosted.ID, recent.ID}, itemIDs(result.Items))
+		require.Len(t, result.Scores, 2)
+		assert.Greater(t, result.Scores[boosted.ID], result.Scores[recent.ID])
+	}
+}
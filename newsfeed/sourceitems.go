@@ -0,0 +1,57 @@
+package newsfeed
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// sourceItems returns every item discovered by the source with the given
+// ID, including archived and snoozed items.
+func (nf *NewsFeed) sourceItems(sourceID uuid.UUID) ([]NewsItem, error) {
+	result, err := nf.store.List()
+	if err != nil {
+		return nil, err
+	}
+	var items []NewsItem
+	for _, item := range result.Items {
+		if item.SourceID != nil && *item.SourceID == sourceID {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+// DeleteSourceItems removes every item discovered by the source with the
+// given ID, as when the source itself is deleted, and returns how many were
+// removed.
+func (nf *NewsFeed) DeleteSourceItems(sourceID uuid.UUID) (int, error) {
+	items, err := nf.sourceItems(sourceID)
+	if err != nil {
+		return 0, err
+	}
+	for i, item := range items {
+		if err := nf.store.Delete(item.ID); err != nil {
+			return i, fmt.Errorf("failed to delete item %s: %w", item.ID, err)
+		}
+	}
+	return len(items), nil
+}
+
+// OrphanSourceItems keeps the items discovered by the source with the given
+// ID but clears their source, as when the source itself is deleted, and
+// returns how many were changed. The items are then treated as though they
+// were added by hand.
+func (nf *NewsFeed) OrphanSourceItems(sourceID uuid.UUID) (int, error) {
+	items, err := nf.sourceItems(sourceID)
+	if err != nil {
+		return 0, err
+	}
+	for i, item := range items {
+		item.SourceID = nil
+		if err := nf.store.Update(item); err != nil {
+			return i, fmt.Errorf("failed to update item %s: %w", item.ID, err)
+		}
+	}
+	return len(items), nil
+}
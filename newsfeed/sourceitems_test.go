@@ -0,0 +1,57 @@
+package newsfeed
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNewsFeed_DeleteSourceItems verifies that only the source's items are
+// removed, archived ones included
+func TestNewsFeed_DeleteSourceItems(t *testing.T) {
+	feed := NewMemoryNewsFeed()
+	sourceID, otherID := uuid.New(), uuid.New()
+
+	item := createTestItem("from source")
+	item.SourceID = &sourceID
+	archived := createTestItem("archived from source")
+	archived.SourceID = &sourceID
+	archived.ArchivedAt = &archived.DiscoveredAt
+	other := createTestItem("from another source")
+	other.SourceID = &otherID
+	for _, i := range []NewsItem{item, archived, other, createTestItem("by hand")} {
+		require.NoError(t, feed.Add(i))
+	}
+
+	count, err := feed.DeleteSourceItems(sourceID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	result, err := feed.List()
+	require.NoError(t, err)
+	assert.Len(t, result.Items, 2)
+	for _, left := range result.Items {
+		assert.False(t, left.SourceID != nil && *left.SourceID == sourceID)
+	}
+}
+
+// TestNewsFeed_OrphanSourceItems verifies that the source's items are kept
+// without a source
+func TestNewsFeed_OrphanSourceItems(t *testing.T) {
+	feed := NewMemoryNewsFeed()
+	sourceID := uuid.New()
+	item := createTestItem("from source")
+	item.SourceID = &sourceID
+	require.NoError(t, feed.Add(item))
+
+	count, err := feed.OrphanSourceItems(sourceID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	stored, err := feed.Get(item.ID)
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	assert.Nil(t, stored.SourceID)
+}
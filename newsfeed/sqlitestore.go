@@ -0,0 +1,655 @@
+package newsfeed
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// sqliteStore stores news items in a SQLite database. The full item is kept
+// as a JSON document in the data column; the remaining columns mirror the
+// fields used for filtering and sorting so they can be indexed.
+type sqliteStore struct {
+	sqlChangeLog // Filled by triggers on the items table
+	db           *sql.DB
+}
+
+// newSQLiteStore opens (or creates) a SQLite news item store at dbPath.
+func newSQLiteStore(dbPath string) (*sqliteStore, error) {
+	// Check if this is a fresh database creation
+	_, statErr := os.Stat(dbPath)
+	isNew := os.IsNotExist(statErr)
+
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	store := &sqliteStore{sqlChangeLog: sqlChangeLog{db}, db: db}
+	if err := store.initSchema(); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to initialize schema: %w", err)
+	}
+
+	// Set restricted permissions on newly created database files
+	if isNew {
+		if err := os.Chmod(dbPath, 0o600); err != nil {
+			_ = db.Close()
+			return nil, fmt.Errorf("failed to set database permissions: %w", err)
+		}
+	}
+
+	return store, nil
+}
+
+// initSchema creates the items table and its indexes if they don't exist.
+func (s *sqliteStore) initSchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS items (
+		id TEXT PRIMARY KEY,
+		url TEXT NOT NULL,
+		publisher TEXT,
+		published_at TEXT NOT NULL,
+		discovered_at TEXT NOT NULL,
+		pinned_at TEXT,
+		read_at TEXT,
+		source_id TEXT,
+		data TEXT NOT NULL,
+		archived_at TEXT,
+		starred_at TEXT,
+		category TEXT
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_items_url ON items(url);
+	CREATE INDEX IF NOT EXISTS idx_items_publisher ON items(publisher);
+	CREATE INDEX IF NOT EXISTS idx_items_published_at ON items(published_at);
+	CREATE INDEX IF NOT EXISTS idx_items_discovered_at ON items(discovered_at);
+	CREATE INDEX IF NOT EXISTS idx_items_pinned_at ON items(pinned_at);
+	CREATE INDEX IF NOT EXISTS idx_items_source_id ON items(source_id);
+
+	CREATE TABLE IF NOT EXISTS feed_revision (
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		revision INTEGER NOT NULL
+	);
+	INSERT OR IGNORE INTO feed_revision (id, revision) VALUES (1, 0);
+
+	CREATE TRIGGER IF NOT EXISTS items_revision_insert AFTER INSERT ON items
+	BEGIN UPDATE feed_revision SET revision = revision + 1 WHERE id = 1; END;
+	CREATE TRIGGER IF NOT EXISTS items_revision_update AFTER UPDATE ON items
+	BEGIN UPDATE feed_revision SET revision = revision + 1 WHERE id = 1; END;
+	CREATE TRIGGER IF NOT EXISTS items_revision_delete AFTER DELETE ON items
+	BEGIN UPDATE feed_revision SET revision = revision + 1 WHERE id = 1; END;
+
+	CREATE TABLE IF NOT EXISTS changes (
+		seq INTEGER PRIMARY KEY AUTOINCREMENT,
+		id TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		changed_at TEXT NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_changes_changed_at ON changes(changed_at);
+
+	CREATE TRIGGER IF NOT EXISTS items_change_insert AFTER INSERT ON items
+	BEGIN INSERT INTO changes (id, kind, changed_at) VALUES (new.id, 'created', ` + sqliteNow + `); END;
+	CREATE TRIGGER IF NOT EXISTS items_change_update AFTER UPDATE ON items
+	BEGIN INSERT INTO changes (id, kind, changed_at) VALUES (new.id, 'updated', ` + sqliteNow + `); END;
+	CREATE TRIGGER IF NOT EXISTS items_change_delete AFTER DELETE ON items
+	BEGIN INSERT INTO changes (id, kind, changed_at) VALUES (old.id, 'deleted', ` + sqliteNow + `); END;
+	`
+
+	if _, err := s.db.Exec(schema); err != nil {
+		return err
+	}
+
+	// Add columns introduced after the original schema to existing
+	// databases. No existing item is archived, starred, or categorized, so
+	// the new columns start out NULL.
+	return s.addMissingColumns("items", map[string]string{
+		"archived_at": "TEXT",
+		"starred_at":  "TEXT",
+		"category":    "TEXT",
+	})
+}
+
+// sqliteNow is the current time in SQL, written as formatTime writes times.
+const sqliteNow = `strftime('%Y-%m-%dT%H:%M:%f000000Z', 'now')`
+
+// addMissingColumns adds each column that the table does not already have.
+func (s *sqliteStore) addMissingColumns(table string, columns map[string]string) error {
+	rows, err := s.db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return err
+	}
+	existing := map[string]bool{}
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			_ = rows.Close()
+			return err
+		}
+		existing[name] = true
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for name, definition := range columns {
+		if existing[name] {
+			continue
+		}
+		if _, err := s.db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, name, definition)); err != nil {
+			return fmt.Errorf("failed to add column %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Add saves a news item, replacing any existing item with the same ID.
+func (s *sqliteStore) Add(item NewsItem) error {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return fmt.Errorf("failed to marshal news item: %w", err)
+	}
+
+	_, err = s.db.Exec(sqliteInsertItem, itemColumns(item, data)...)
+	if err != nil {
+		return fmt.Errorf("failed to write news item: %w", err)
+	}
+
+	return nil
+}
+
+// sqliteInsertItem saves an item, replacing any existing item with the same
+// ID. A replaced item is updated in place rather than deleted and inserted
+// again, so that the change log records it as updated.
+const sqliteInsertItem = `
+	INSERT INTO items (
+		id, url, publisher, published_at, discovered_at,
+		pinned_at, read_at, source_id, data, archived_at, starred_at,
+		category
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT (id) DO UPDATE SET
+		url = excluded.url, publisher = excluded.publisher,
+		published_at = excluded.published_at,
+		discovered_at = excluded.discovered_at,
+		pinned_at = excluded.pinned_at, read_at = excluded.read_at,
+		source_id = excluded.source_id, data = excluded.data,
+		archived_at = excluded.archived_at,
+		starred_at = excluded.starred_at, category = excluded.category
+`
+
+// AddAll saves news items in a single transaction: either all of them are
+// saved or, on error, none are.
+func (s *sqliteStore) AddAll(items []NewsItem) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, err := tx.Prepare(sqliteInsertItem)
+	if err != nil {
+		return fmt.Errorf("failed to prepare insert: %w", err)
+	}
+	defer func() { _ = stmt.Close() }()
+
+	for _, item := range items {
+		data, err := json.Marshal(item)
+		if err != nil {
+			return fmt.Errorf("failed to marshal news item: %w", err)
+		}
+		if _, err := stmt.Exec(itemColumns(item, data)...); err != nil {
+			return fmt.Errorf("failed to write news item: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// List returns all news items in the database.
+func (s *sqliteStore) List() (*ListResult, error) {
+	rows, err := s.db.Query("SELECT id, data FROM items")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query news items: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	result := &ListResult{}
+	for rows.Next() {
+		var id, data string
+		if err := rows.Scan(&id, &data); err != nil {
+			return nil, fmt.Errorf("failed to scan news item: %w", err)
+		}
+
+		var item NewsItem
+		if err := json.Unmarshal([]byte(data), &item); err != nil {
+			result.Errors = append(result.Errors, ReadError{
+				Filename: id,
+				Err:      err,
+			})
+			continue
+		}
+
+		result.Items = append(result.Items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read news items: %w", err)
+	}
+
+	return result, nil
+}
+
+// Check runs SQLite's integrity check and reports rows whose item data isn't
+// valid JSON. Nothing is repaired; a damaged database should be restored from
+// a backup.
+func (s *sqliteStore) Check(repair bool) (*CheckReport, error) {
+	report := &CheckReport{}
+
+	rows, err := s.db.Query("PRAGMA integrity_check")
+	if err != nil {
+		return nil, fmt.Errorf("failed to check database: %w", err)
+	}
+	for rows.Next() {
+		var msg string
+		if err := rows.Scan(&msg); err != nil {
+			_ = rows.Close()
+			return nil, fmt.Errorf("failed to check database: %w", err)
+		}
+		if msg != "ok" {
+			report.Issues = append(report.Issues, CheckIssue{Problem: msg})
+		}
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to check database: %w", err)
+	}
+
+	result, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	report.Checked = len(result.Items) + len(result.Errors)
+	for _, readErr := range result.Errors {
+		report.Issues = append(report.Issues, CheckIssue{
+			Filename: readErr.Filename,
+			Problem:  fmt.Sprintf("invalid JSON: %v", readErr.Err),
+		})
+	}
+
+	return report, nil
+}
+
+// Get retrieves a news item by its ID.
+func (s *sqliteStore) Get(id uuid.UUID) (*NewsItem, error) {
+	var data string
+	err := s.db.QueryRow("SELECT data FROM items WHERE id = ?", id.String()).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil // Item not found (not an error)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read news item: %w", err)
+	}
+
+	var item NewsItem
+	if err := json.Unmarshal([]byte(data), &item); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal news item: %w", err)
+	}
+
+	return &item, nil
+}
+
+// Delete removes a news item by its ID.
+func (s *sqliteStore) Delete(id uuid.UUID) error {
+	result, err := s.db.Exec("DELETE FROM items WHERE id = ?", id.String())
+	if err != nil {
+		return fmt.Errorf("failed to delete news item: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("failed to delete news item: %w", ErrItemNotFound)
+	}
+
+	return nil
+}
+
+// Update updates an existing news item if its version is the stored one.
+// The versions are compared by the UPDATE itself, so concurrent writers
+// can't both succeed.
+func (s *sqliteStore) Update(item NewsItem) error {
+	expected := item.Version
+	item.Version++
+	data, err := json.Marshal(item)
+	if err != nil {
+		return fmt.Errorf("failed to marshal news item: %w", err)
+	}
+
+	query := `
+		UPDATE items SET
+			id = ?, url = ?, publisher = ?, published_at = ?, discovered_at = ?,
+			pinned_at = ?, read_at = ?, source_id = ?, data = ?, archived_at = ?,
+			starred_at = ?, category = ?
+		WHERE id = ? AND COALESCE(json_extract(data, '$.version'), 0) = ?
+	`
+
+	args := append(itemColumns(item, data), item.ID.String(), expected)
+	result, err := s.db.Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to write news item: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return s.updateMissed(item.ID)
+	}
+
+	return nil
+}
+
+// updateMissed explains why an UPDATE of the item with the given ID changed
+// no rows: the item is gone, or its version has moved on.
+func (s *sqliteStore) updateMissed(id uuid.UUID) error {
+	var count int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM items WHERE id = ?", id.String()).Scan(&count); err != nil {
+		return fmt.Errorf("failed to read news item: %w", err)
+	}
+	if count == 0 {
+		return ErrItemNotFound
+	}
+	return ErrVersionConflict
+}
+
+// snoozedUntil is the Julian day an item's snooze ends, or NULL if it has
+// none.
+const snoozedUntil = "julianday(json_extract(data, '$.snoozed_until'))"
+
+// Query evaluates opts with SQL so that only the requested page of items is
+// read and unmarshaled.
+func (s *sqliteStore) Query(opts ListOptions) (*QueryResult, error) {
+	var whereClauses []string
+	var args []any
+
+	if !opts.withoutState {
+		whereClauses, args = stateClauses(opts)
+	}
+
+	if opts.Publisher != "" {
+		whereClauses = append(whereClauses, `publisher LIKE ? ESCAPE '\'`)
+		args = append(args, "%"+escapeLike(opts.Publisher)+"%")
+	}
+
+	if opts.Category != "" {
+		whereClauses = append(whereClauses, "category = ? COLLATE NOCASE")
+		args = append(args, opts.Category)
+	}
+
+	if opts.Language != "" {
+		whereClauses = append(whereClauses, "json_extract(data, '$.language') = ? COLLATE NOCASE")
+		args = append(args, opts.Language)
+	}
+
+	if opts.SourceID != nil {
+		whereClauses = append(whereClauses, "source_id = ?")
+		args = append(args, opts.SourceID.String())
+	}
+
+	// Metadata lives in the item's JSON data; booleans are compared by name
+	// so that they match NewsItem.MetadataValue
+	for _, key := range slices.Sorted(maps.Keys(opts.Metadata)) {
+		path := "$.metadata." + strconv.Quote(key)
+		whereClauses = append(whereClauses, `(CASE json_type(data, ?)
+			WHEN 'true' THEN 'true'
+			WHEN 'false' THEN 'false'
+			WHEN 'null' THEN 'null'
+			ELSE CAST(json_extract(data, ?) AS TEXT)
+			END) = ?`)
+		args = append(args, path, path, opts.Metadata[key])
+	}
+
+	if opts.DiscoveredSince != nil && opts.withoutState {
+		whereClauses = append(whereClauses, "discovered_at >= ?")
+		args = append(args, formatTime(opts.DiscoveredSince))
+	} else if opts.DiscoveredSince != nil {
+		since := formatTime(opts.DiscoveredSince)
+		if opts.IncludePinned {
+			whereClauses = append(whereClauses, "(discovered_at >= ? OR "+snoozedUntil+" >= julianday(?) OR pinned_at IS NOT NULL)")
+		} else {
+			whereClauses = append(whereClauses, "(discovered_at >= ? OR "+snoozedUntil+" >= julianday(?))")
+		}
+		args = append(args, since, since)
+	}
+
+	where := ""
+	if len(whereClauses) > 0 {
+		where = " WHERE " + strings.Join(whereClauses, " AND ")
+	}
+
+	// Count all matches before pagination
+	var total int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM items"+where, args...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count news items: %w", err)
+	}
+
+	// The cursor narrows the page but not the total
+	if opts.Cursor != "" {
+		c, err := decodeCursor(opts.Cursor, opts.SortBy)
+		if err != nil {
+			return nil, err
+		}
+		clause, cursorArgs := cursorClause(c)
+		if where == "" {
+			where = " WHERE " + clause
+		} else {
+			where += " AND " + clause
+		}
+		args = append(args, cursorArgs...)
+	}
+
+	query := "SELECT id, data FROM items" + where
+	switch opts.SortBy {
+	case SortDiscovered:
+		query += " ORDER BY discovered_at DESC, id"
+	case SortPinned:
+		query += " ORDER BY pinned_at IS NULL, pinned_at DESC, id"
+	default:
+		query += " ORDER BY published_at DESC, id"
+	}
+
+	// One extra row shows whether there is a next page
+	if opts.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", opts.Limit+1)
+	} else if opts.Offset > 0 {
+		query += " LIMIT -1"
+	}
+	if opts.Offset > 0 {
+		query += fmt.Sprintf(" OFFSET %d", opts.Offset)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query news items: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	result := &QueryResult{Total: total}
+	var last NewsItem
+	rowCount := 0
+	for rows.Next() {
+		rowCount++
+		if opts.Limit > 0 && rowCount > opts.Limit {
+			result.NextCursor = encodeCursor(last, opts.SortBy)
+			break
+		}
+
+		var id, data string
+		if err := rows.Scan(&id, &data); err != nil {
+			return nil, fmt.Errorf("failed to scan news item: %w", err)
+		}
+
+		var item NewsItem
+		if err := json.Unmarshal([]byte(data), &item); err != nil {
+			result.Errors = append(result.Errors, ReadError{
+				Filename: id,
+				Err:      err,
+			})
+			continue
+		}
+
+		result.Items = append(result.Items, item)
+		last = item
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read news items: %w", err)
+	}
+
+	return result, nil
+}
+
+// stateClauses returns the conditions and arguments for the filters in
+// opts that depend on the reader's state.
+func stateClauses(opts ListOptions) ([]string, []any) {
+	var whereClauses []string
+
+	if opts.Pinned != nil {
+		if *opts.Pinned {
+			whereClauses = append(whereClauses, "pinned_at IS NOT NULL")
+		} else {
+			whereClauses = append(whereClauses, "pinned_at IS NULL")
+		}
+	}
+
+	if opts.Unread {
+		whereClauses = append(whereClauses, "read_at IS NULL")
+	}
+
+	if opts.Starred {
+		whereClauses = append(whereClauses, "starred_at IS NOT NULL")
+	}
+
+	if opts.Archived {
+		whereClauses = append(whereClauses, "archived_at IS NOT NULL")
+	} else {
+		whereClauses = append(whereClauses, "archived_at IS NULL")
+	}
+
+	// Snooze times live in the item's JSON data, where they keep the offset
+	// they were given with, so they are compared as Julian days
+	now := time.Now()
+	if opts.Snoozed {
+		whereClauses = append(whereClauses, snoozedUntil+" > julianday(?)")
+	} else {
+		whereClauses = append(whereClauses, "("+snoozedUntil+" IS NULL OR "+snoozedUntil+" <= julianday(?))")
+	}
+	return whereClauses, []any{formatTime(&now)}
+}
+
+// cursorClause returns a condition matching the items that sort after c, in
+// the order used by Query.
+func cursorClause(c *pageCursor) (string, []any) {
+	id := c.ID.String()
+	switch c.Sort {
+	case SortDiscovered:
+		key := formatTime(c.Key)
+		return "(discovered_at < ? OR (discovered_at = ? AND id > ?))", []any{key, key, id}
+	case SortPinned:
+		if c.Key == nil {
+			return "(pinned_at IS NULL AND id > ?)", []any{id}
+		}
+		key := formatTime(c.Key)
+		return "(pinned_at IS NULL OR pinned_at < ? OR (pinned_at = ? AND id > ?))", []any{key, key, id}
+	default:
+		key := formatTime(c.Key)
+		return "(published_at < ? OR (published_at = ? AND id > ?))", []any{key, key, id}
+	}
+}
+
+// Revision returns a counter kept by triggers on the items table. It goes up
+// on every insert, update, and delete, whichever process makes the change.
+func (s *sqliteStore) Revision() (string, error) {
+	var revision int64
+	if err := s.db.QueryRow("SELECT revision FROM feed_revision WHERE id = 1").Scan(&revision); err != nil {
+		return "", fmt.Errorf("failed to read feed revision: %w", err)
+	}
+	return strconv.FormatInt(revision, 10), nil
+}
+
+// Ping checks that the database can be queried.
+func (s *sqliteStore) Ping() error {
+	var n int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM sqlite_master").Scan(&n); err != nil {
+		return fmt.Errorf("feed database is not reachable: %w", err)
+	}
+	return nil
+}
+
+// escapeLike escapes the LIKE wildcard characters in s.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// Close closes the database connection.
+func (s *sqliteStore) Close() error {
+	return s.db.Close()
+}
+
+// itemColumns returns the column values for an item in the order used by
+// the INSERT and UPDATE statements.
+func itemColumns(item NewsItem, data []byte) []any {
+	var publisher, sourceID, category any
+	if item.Publisher != nil {
+		publisher = *item.Publisher
+	}
+	if item.Category != nil {
+		category = *item.Category
+	}
+	if item.SourceID != nil {
+		sourceID = item.SourceID.String()
+	}
+
+	return []any{
+		item.ID.String(),
+		item.URL,
+		publisher,
+		formatTime(&item.PublishedAt),
+		formatTime(&item.DiscoveredAt),
+		formatTime(item.PinnedAt),
+		formatTime(item.ReadAt),
+		sourceID,
+		string(data),
+		formatTime(item.ArchivedAt),
+		formatTime(item.StarredAt),
+		category,
+	}
+}
+
+// timeLayout is a fixed-width RFC 3339 layout. Timestamps are stored in UTC
+// with a fixed number of fractional digits so that lexical and chronological
+// order agree.
+const timeLayout = "2006-01-02T15:04:05.000000000Z"
+
+// formatTime formats a timestamp for storage.
+func formatTime(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return t.UTC().Format(timeLayout)
+}
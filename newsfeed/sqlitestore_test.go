@@ -0,0 +1,166 @@
+package newsfeed
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test helper: create a SQLite-backed news feed
+func createTestSQLiteFeed(t *testing.T) *NewsFeed {
+	feed, err := NewSQLiteNewsFeed(filepath.Join(t.TempDir(), "feed.db"))
+	require.NoError(t, err, "should create SQLite feed")
+	t.Cleanup(func() { _ = feed.Close() })
+	return feed
+}
+
+// TestSQLite_AddGet verifies that an added item can be retrieved unchanged
+func TestSQLite_AddGet(t *testing.T) {
+	feed := createTestSQLiteFeed(t)
+
+	item := createTestItem("SQLite item")
+	sourceID := uuid.New()
+	item.SourceID = &sourceID
+	require.NoError(t, feed.Add(item))
+
+	retrieved, err := feed.Get(item.ID)
+	require.NoError(t, err)
+	require.NotNil(t, retrieved)
+	assert.Equal(t, item.Title, retrieved.Title)
+	assert.Equal(t, item.URL, retrieved.URL)
+	assert.Equal(t, *item.Publisher, *retrieved.Publisher)
+	assert.Equal(t, item.Authors, retrieved.Authors)
+	assert.True(t, item.PublishedAt.Equal(retrieved.PublishedAt))
+	assert.Equal(t, sourceID, *retrieved.SourceID)
+}
+
+// TestSQLite_GetNotFound verifies Get returns nil without error for a
+// missing item
+func TestSQLite_GetNotFound(t *testing.T) {
+	feed := createTestSQLiteFeed(t)
+
+	item, err := feed.Get(uuid.New())
+	assert.NoError(t, err)
+	assert.Nil(t, item)
+}
+
+// TestSQLite_ListReturnsAllAdded verifies List returns every added item
+func TestSQLite_ListReturnsAllAdded(t *testing.T) {
+	feed := createTestSQLiteFeed(t)
+
+	added := map[uuid.UUID]bool{}
+	for range 25 {
+		item := createTestItem("item")
+		require.NoError(t, feed.Add(item))
+		added[item.ID] = true
+	}
+
+	result, err := feed.List()
+	require.NoError(t, err)
+	assert.Empty(t, result.Errors)
+	assert.Len(t, result.Items, len(added))
+	for _, item := range result.Items {
+		assert.True(t, added[item.ID])
+	}
+}
+
+// TestSQLite_UpdateDelete verifies update and delete semantics match the
+// directory backend
+func TestSQLite_UpdateDelete(t *testing.T) {
+	feed := createTestSQLiteFeed(t)
+
+	item := createTestItem("Original")
+	assert.ErrorIs(t, feed.Update(item), ErrItemNotFound, "Update of a missing item should fail")
+
+	require.NoError(t, feed.Add(item))
+
+	pinnedAt := time.Now().UTC()
+	item.Title = "Updated"
+	item.PinnedAt = &pinnedAt
+	require.NoError(t, feed.Update(item))
+
+	retrieved, err := feed.Get(item.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Updated", retrieved.Title)
+	require.NotNil(t, retrieved.PinnedAt)
+
+	require.NoError(t, feed.Delete(item.ID))
+	assert.ErrorIs(t, feed.Delete(item.ID), ErrItemNotFound, "Delete of a missing item should fail")
+
+	retrieved, err = feed.Get(item.ID)
+	require.NoError(t, err)
+	assert.Nil(t, retrieved)
+}
+
+// TestSQLite_Permissions verifies a new database is owner-only
+func TestSQLite_Permissions(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "feed.db")
+	feed, err := NewSQLiteNewsFeed(dbPath)
+	require.NoError(t, err)
+	defer func() { _ = feed.Close() }()
+
+	stat, err := os.Stat(dbPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), stat.Mode().Perm())
+}
+
+// TestSQLite_AddsMissingColumns verifies that a database created before
+// items could be archived or starred is upgraded when opened
+func TestSQLite_AddsMissingColumns(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "feed.db")
+
+	db, err := sql.Open("sqlite3", dbPath)
+	require.NoError(t, err)
+	_, err = db.Exec(`CREATE TABLE items (
+		id TEXT PRIMARY KEY,
+		url TEXT NOT NULL,
+		publisher TEXT,
+		published_at TEXT NOT NULL,
+		discovered_at TEXT NOT NULL,
+		pinned_at TEXT,
+		read_at TEXT,
+		source_id TEXT,
+		data TEXT NOT NULL
+	)`)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	feed, err := NewSQLiteNewsFeed(dbPath)
+	require.NoError(t, err)
+	defer func() { _ = feed.Close() }()
+
+	item := createTestItem("Old schema")
+	require.NoError(t, feed.Add(item))
+	_, err = feed.Archive(item.ID)
+	require.NoError(t, err)
+	_, err = feed.Star(item.ID)
+	require.NoError(t, err)
+
+	result, err := feed.Query(ListOptions{Archived: true, Starred: true})
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{item.ID}, itemIDs(result.Items))
+}
+
+// TestOpen_SelectsBackend verifies that Open chooses a backend from the DSN
+func TestOpen_SelectsBackend(t *testing.T) {
+	tempDir := t.TempDir()
+
+	dirFeed, err := Open(filepath.Join(tempDir, "news"))
+	require.NoError(t, err)
+	assert.IsType(t, &dirStore{}, dirFeed.store)
+
+	sqliteFeed, err := Open(SQLiteScheme + filepath.Join(tempDir, "feed.db"))
+	require.NoError(t, err)
+	defer func() { _ = sqliteFeed.Close() }()
+	assert.IsType(t, &sqliteStore{}, sqliteFeed.store)
+
+	memoryFeed, err := Open(MemoryScheme + "test")
+	require.NoError(t, err)
+	assert.IsType(t, &memoryStore{}, memoryFeed.store)
+}
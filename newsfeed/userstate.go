@@ -0,0 +1,323 @@
+package newsfeed
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ItemState is a reader's own state for a news item: what they have pinned,
+// read, opened, archived, starred, or snoozed, and their notes. A feed with one
+// reader keeps it in the items themselves; a feed shared by several readers
+// keeps each reader's state in a StateStore.
+type ItemState struct {
+	PinnedAt     *time.Time `json:"pinned_at,omitempty"`
+	PinExpiresAt *time.Time `json:"pin_expires_at,omitempty"`
+	ReadAt       *time.Time `json:"read_at,omitempty"`
+	OpenedAt     *time.Time `json:"opened_at,omitempty"`
+	ArchivedAt   *time.Time `json:"archived_at,omitempty"`
+	StarredAt    *time.Time `json:"starred_at,omitempty"`
+	SnoozedUntil *time.Time `json:"snoozed_until,omitempty"`
+	Notes        string     `json:"notes,omitempty"`
+	// Version counts the times the state has been changed on its own,
+	// without the item; a StateStore keeps it apart from the state
+	Version int `json:"-"`
+}
+
+// StateOf returns the reader's state held in item.
+func StateOf(item NewsItem) ItemState {
+	return ItemState{
+		PinnedAt:     item.PinnedAt,
+		PinExpiresAt: item.PinExpiresAt,
+		ReadAt:       item.ReadAt,
+		OpenedAt:     item.OpenedAt,
+		ArchivedAt:   item.ArchivedAt,
+		StarredAt:    item.StarredAt,
+		SnoozedUntil: item.SnoozedUntil,
+		Notes:        item.Notes,
+	}
+}
+
+// ApplyTo replaces the reader's state held in item with s.
+func (s ItemState) ApplyTo(item *NewsItem) {
+	item.PinnedAt = s.PinnedAt
+	item.PinExpiresAt = s.PinExpiresAt
+	item.ReadAt = s.ReadAt
+	item.OpenedAt = s.OpenedAt
+	item.ArchivedAt = s.ArchivedAt
+	item.StarredAt = s.StarredAt
+	item.SnoozedUntil = s.SnoozedUntil
+	item.Notes = s.Notes
+}
+
+// IsZero reports whether s holds no state at all, and has never been
+// changed.
+func (s ItemState) IsZero() bool {
+	return s == ItemState{}
+}
+
+// StateStore holds each user's state for the items of a shared feed.
+// ItemState returns the zero state for an item the user has no state for.
+// SetItemState replaces the state of an item, Version included, unless the
+// stored state's Version (zero if there is none) isn't version, in which
+// case it returns ErrVersionConflict and changes nothing; with the zero
+// state it forgets the item's state, whatever its version.
+type StateStore interface {
+	ItemStates(user string) (map[uuid.UUID]ItemState, error)
+	ItemState(user string, id uuid.UUID) (ItemState, error)
+	SetItemState(user string, id uuid.UUID, version int, state ItemState) error
+}
+
+// ForUser returns a view of the feed as seen by user, whose state for each
+// item is kept in states rather than in the items. The items' content is
+// shared: an edit made through the view is seen by every reader, while a
+// pin, read mark, or note is seen only by user. The view shares the feed's
+// store, so closing either closes both.
+func (nf *NewsFeed) ForUser(user string, states StateStore) *NewsFeed {
+	return New(&userStore{base: nf.store, user: user, states: states})
+}
+
+// userStore is a Store that overlays one user's item state, held in a
+// StateStore, on the items of another store. An item's Version, as the user
+// sees it, is the sum of the shared item's version and of the user's state
+// for it, so that a change to the user's state alone doesn't make other
+// users' copies of the item out of date.
+type userStore struct {
+	base   Store
+	user   string
+	states StateStore
+}
+
+// Add saves the item's content to the base store and its state as the
+// user's.
+func (s *userStore) Add(item NewsItem) error {
+	state := StateOf(item)
+	ItemState{}.ApplyTo(&item)
+	if err := s.base.Add(item); err != nil {
+		return err
+	}
+	if state.IsZero() {
+		return nil
+	}
+	return s.setState(item.ID, state)
+}
+
+// setState saves state as the user's state for an item, whatever the
+// user's state for it was, as a change to it.
+func (s *userStore) setState(id uuid.UUID, state ItemState) error {
+	stored, err := s.states.ItemState(s.user, id)
+	if err != nil {
+		return err
+	}
+	state.Version = stored.Version + 1
+	return s.states.SetItemState(s.user, id, stored.Version, state)
+}
+
+// AddAll saves the items' content to the base store in one transaction, as
+// the base store's AddAll does, and then their state as the user's. It
+// returns errNoBatch if the base store can't save items together.
+func (s *userStore) AddAll(items []NewsItem) error {
+	b, ok := s.base.(batchAdder)
+	if !ok {
+		return errNoBatch
+	}
+	states := make([]ItemState, len(items))
+	content := make([]NewsItem, len(items))
+	for i, item := range items {
+		states[i] = StateOf(item)
+		ItemState{}.ApplyTo(&item)
+		content[i] = item
+	}
+	if err := b.AddAll(content); err != nil {
+		return err
+	}
+	for i, state := range states {
+		if state.IsZero() {
+			continue
+		}
+		if err := s.setState(items[i].ID, state); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *userStore) Get(id uuid.UUID) (*NewsItem, error) {
+	item, err := s.base.Get(id)
+	if err != nil || item == nil {
+		return item, err
+	}
+	state, err := s.states.ItemState(s.user, id)
+	if err != nil {
+		return nil, err
+	}
+	overlay(state, item, time.Now())
+	return item, nil
+}
+
+func (s *userStore) List() (*ListResult, error) {
+	result, err := s.base.List()
+	if err != nil {
+		return nil, err
+	}
+	states, err := s.states.ItemStates(s.user)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for i := range result.Items {
+		overlay(states[result.Items[i].ID], &result.Items[i], now)
+	}
+	return result, nil
+}
+
+// Update saves the item's state as the user's and, if its content changed,
+// its content to the base store, keeping the state held there. The version
+// the user sees advances by one either way: the shared item's version if
+// its content changed, and otherwise only the user's state's.
+func (s *userStore) Update(item NewsItem) error {
+	existing, err := s.base.Get(item.ID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return ErrItemNotFound
+	}
+	stored, err := s.states.ItemState(s.user, item.ID)
+	if err != nil {
+		return err
+	}
+	if item.Version != existing.Version+stored.Version {
+		return ErrVersionConflict
+	}
+
+	state := StateOf(item)
+	state.Version = stored.Version
+	StateOf(*existing).ApplyTo(&item)
+	item.Version = existing.Version
+	changed, err := contentChanged(*existing, item)
+	if err != nil {
+		return err
+	}
+	if changed {
+		if err := s.base.Update(item); err != nil {
+			return err
+		}
+	} else {
+		state.Version++
+	}
+	return s.states.SetItemState(s.user, item.ID, stored.Version, state)
+}
+
+// contentChanged reports whether updated differs from existing.
+func contentChanged(existing, updated NewsItem) (bool, error) {
+	before, err := json.Marshal(existing)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal news item: %w", err)
+	}
+	after, err := json.Marshal(updated)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal news item: %w", err)
+	}
+	return !bytes.Equal(before, after), nil
+}
+
+// Delete removes the item for every reader, forgetting the user's state for
+// it. Other users' state for it is ignored from then on.
+func (s *userStore) Delete(id uuid.UUID) error {
+	if err := s.base.Delete(id); err != nil {
+		return err
+	}
+	return s.states.SetItemState(s.user, id, 0, ItemState{})
+}
+
+// Query evaluates opts with the base store's Querier, if it has one, and
+// overlays the user's state on the items found. The base store evaluates
+// the filters that don't depend on the user's state; when opts has any that
+// do, the rest are evaluated in memory against the overlaid items, and
+// otherwise the base store pages through the items as well.
+func (s *userStore) Query(opts ListOptions) (*QueryResult, error) {
+	q, ok := s.base.(Querier)
+	if !ok {
+		return queryList(s, opts)
+	}
+	states, err := s.states.ItemStates(s.user)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+
+	baseOpts := opts
+	baseOpts.withoutState = true
+	dependent := dependsOnState(opts, states, now)
+	if dependent {
+		baseOpts.Limit, baseOpts.Offset, baseOpts.Cursor = 0, 0, ""
+		if baseOpts.SortBy == SortPinned {
+			baseOpts.SortBy = ""
+		}
+	}
+	result, err := q.Query(baseOpts)
+	if err != nil {
+		return nil, err
+	}
+	for i := range result.Items {
+		overlay(states[result.Items[i].ID], &result.Items[i], now)
+	}
+	if !dependent {
+		return result, nil
+	}
+
+	matched := filterItems(result.Items, opts)
+	sortItems(matched, opts.SortBy)
+	page, next, err := pageItems(matched, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &QueryResult{
+		Items:      page,
+		Total:      len(matched),
+		Errors:     result.Errors,
+		NextCursor: next,
+	}, nil
+}
+
+// dependsOnState reports whether the items matching opts, or their order,
+// depend on the user's state, given the user's states: whether opts
+// filters or sorts by state, or the user has archived, snoozed, or pinned
+// items that the default filters or DiscoveredSince would treat otherwise.
+func dependsOnState(opts ListOptions, states map[uuid.UUID]ItemState, now time.Time) bool {
+	if opts.Pinned != nil || opts.Unread || opts.Starred || opts.Archived || opts.Snoozed || opts.SortBy == SortPinned {
+		return true
+	}
+	for _, state := range states {
+		var item NewsItem
+		overlay(state, &item, now)
+		if item.ArchivedAt != nil || item.IsSnoozed(now) {
+			return true
+		}
+		if opts.DiscoveredSince != nil && (item.SnoozedUntil != nil || opts.IncludePinned && item.PinnedAt != nil) {
+			return true
+		}
+	}
+	return false
+}
+
+// overlay applies a user's state to item, and adds the state's version to
+// the item's. A pin that expired before now is left off; ExpirePins only
+// sweeps the shared state, so users' pins lapse when they are next read
+// instead.
+func overlay(state ItemState, item *NewsItem, now time.Time) {
+	if state.PinExpiresAt != nil && !state.PinExpiresAt.After(now) {
+		state.PinnedAt = nil
+		state.PinExpiresAt = nil
+	}
+	state.ApplyTo(item)
+	item.Version += state.Version
+}
+
+func (s *userStore) Close() error {
+	return s.base.Close()
+}
@@ -0,0 +1,229 @@
+package newsfeed
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test helper: a StateStore held in memory
+type memoryStates map[string]map[uuid.UUID]ItemState
+
+func (m memoryStates) ItemStates(user string) (map[uuid.UUID]ItemState, error) {
+	states := make(map[uuid.UUID]ItemState)
+	for id, state := range m[user] {
+		states[id] = state
+	}
+	return states, nil
+}
+
+func (m memoryStates) ItemState(user string, id uuid.UUID) (ItemState, error) {
+	return m[user][id], nil
+}
+
+func (m memoryStates) SetItemState(user string, id uuid.UUID, version int, state ItemState) error {
+	if m[user] == nil {
+		m[user] = make(map[uuid.UUID]ItemState)
+	}
+	if state.IsZero() {
+		delete(m[user], id)
+		return nil
+	}
+	if m[user][id].Version != version {
+		return ErrVersionConflict
+	}
+	m[user][id] = state
+	return nil
+}
+
+// TestForUser_SeparateState verifies that each user's pins, read marks, and
+// notes are their own while edits to the items are shared
+func TestForUser_SeparateState(t *testing.T) {
+	feed := createTestSQLiteFeed(t)
+	states := memoryStates{}
+	alice := feed.ForUser("alice", states)
+	bob := feed.ForUser("bob", states)
+
+	first := createTestItem("First")
+	second := createTestItem("Second")
+	require.NoError(t, feed.Add(first))
+	require.NoError(t, feed.Add(second))
+
+	_, err := alice.MarkRead(first.ID)
+	require.NoError(t, err)
+	_, err = alice.SetNotes(first.ID, "alice's notes")
+	require.NoError(t, err)
+	_, err = bob.Star(second.ID)
+	require.NoError(t, err)
+
+	item, err := alice.Get(first.ID)
+	require.NoError(t, err)
+	assert.True(t, item.IsRead())
+	assert.Equal(t, "alice's notes", item.Notes)
+
+	item, err = bob.Get(first.ID)
+	require.NoError(t, err)
+	assert.False(t, item.IsRead())
+	assert.Empty(t, item.Notes)
+
+	item, err = feed.Get(first.ID)
+	require.NoError(t, err)
+	assert.False(t, item.IsRead(), "the default user's state should be untouched")
+
+	result, err := alice.Query(ListOptions{Unread: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Second"}, titles(result.Items))
+	result, err = bob.Query(ListOptions{Starred: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Second"}, titles(result.Items))
+
+	title := "First, Corrected"
+	_, err = alice.Edit(first.ID, ItemEdit{Title: &title})
+	require.NoError(t, err)
+	item, err = bob.Get(first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, title, item.Title)
+}
+
+// TestForUser_AddAndExpire verifies that an item added through a user's view
+// is shared without their state, and that their expired pins lapse
+func TestForUser_AddAndExpire(t *testing.T) {
+	feed := createTestSQLiteFeed(t)
+	states := memoryStates{}
+	alice := feed.ForUser("alice", states)
+
+	now := time.Now()
+	expired := now.Add(-time.Minute)
+	item := createTestItem("Saved")
+	item.PinnedAt = &now
+	item.PinExpiresAt = &expired
+	item.StarredAt = &now
+	require.NoError(t, alice.Add(item))
+
+	shared, err := feed.Get(item.ID)
+	require.NoError(t, err)
+	assert.Nil(t, shared.PinnedAt)
+	assert.Nil(t, shared.StarredAt)
+
+	mine, err := alice.Get(item.ID)
+	require.NoError(t, err)
+	assert.Nil(t, mine.PinnedAt, "an expired pin should lapse")
+	assert.NotNil(t, mine.StarredAt)
+
+	require.NoError(t, alice.Delete(item.ID))
+	assert.Empty(t, states["alice"])
+}
+
+// Test helper: a Store that counts the times every item is listed, so that
+// tests can tell queries evaluated by the store from those evaluated in
+// memory
+type listCounter struct {
+	Store
+	lists int
+}
+
+func (s *listCounter) List() (*ListResult, error) {
+	s.lists++
+	return s.Store.List()
+}
+
+func (s *listCounter) Query(opts ListOptions) (*QueryResult, error) {
+	return s.Store.(Querier).Query(opts)
+}
+
+// TestForUser_Query verifies that a user's view queries the shared store,
+// paging there when no filter depends on state, and evaluates the filters
+// that do against the user's own state
+func TestForUser_Query(t *testing.T) {
+	base := &listCounter{Store: createTestSQLiteFeed(t).store}
+	feed := New(base)
+	states := memoryStates{}
+	alice := feed.ForUser("alice", states)
+	bob := feed.ForUser("bob", states)
+
+	goPublisher, rustPublisher := "Go Blog", "Rust Blog"
+	first, second, third := createTestItem("First"), createTestItem("Second"), createTestItem("Third")
+	first.Publisher, second.Publisher, third.Publisher = &goPublisher, &rustPublisher, &goPublisher
+	first.PublishedAt = third.PublishedAt.Add(time.Hour)
+	for _, item := range []NewsItem{first, second, third} {
+		require.NoError(t, feed.Add(item))
+	}
+	_, err := bob.Archive(third.ID)
+	require.NoError(t, err)
+
+	page, err := alice.Query(ListOptions{Publisher: "go", Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"First"}, titles(page.Items))
+	assert.Equal(t, 2, page.Total, "bob's archive should not hide the item from alice")
+	require.NotEmpty(t, page.NextCursor)
+	page, err = alice.Query(ListOptions{Publisher: "go", Limit: 1, Cursor: page.NextCursor})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Third"}, titles(page.Items))
+	assert.Empty(t, page.NextCursor)
+
+	_, err = alice.Archive(first.ID)
+	require.NoError(t, err)
+	result, err := alice.Query(ListOptions{Publisher: "go"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Third"}, titles(result.Items))
+	result, err = alice.Query(ListOptions{Archived: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"First"}, titles(result.Items))
+	assert.NotNil(t, result.Items[0].ArchivedAt)
+
+	result, err = bob.Query(ListOptions{Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"First"}, titles(result.Items))
+	assert.Equal(t, 2, result.Total)
+
+	assert.Zero(t, base.lists, "no query should need every item listed")
+}
+
+// TestForUser_VersionsStateSeparately verifies that a change to a user's
+// state leaves the shared item's version, and other users' copies, up to
+// date, while a stale copy of the user's own is still refused
+func TestForUser_VersionsStateSeparately(t *testing.T) {
+	feed := createTestSQLiteFeed(t)
+	states := memoryStates{}
+	alice := feed.ForUser("alice", states)
+	bob := feed.ForUser("bob", states)
+
+	item := createTestItem("Shared")
+	require.NoError(t, feed.Add(item))
+	bobsCopy, err := bob.Get(item.ID)
+	require.NoError(t, err)
+	alicesCopy, err := alice.Get(item.ID)
+	require.NoError(t, err)
+
+	starred, err := alice.Star(item.ID)
+	require.NoError(t, err)
+	assert.Equal(t, alicesCopy.Version+1, starred.Version)
+	mine, err := alice.Get(item.ID)
+	require.NoError(t, err)
+	assert.Equal(t, starred.Version, mine.Version)
+	shared, err := feed.Get(item.ID)
+	require.NoError(t, err)
+	assert.Equal(t, item.Version, shared.Version, "alice's star should not change the shared item")
+
+	read := true
+	_, err = bob.Patch(item.ID, ItemPatch{Version: &bobsCopy.Version, Read: &read})
+	require.NoError(t, err, "bob's copy should still be up to date")
+	_, err = alice.Patch(item.ID, ItemPatch{Version: &alicesCopy.Version, Read: &read})
+	assert.ErrorIs(t, err, ErrVersionConflict)
+
+	// An edit to the shared content advances every user's version
+	bobsCopy, err = bob.Get(item.ID)
+	require.NoError(t, err)
+	title := "Shared, Corrected"
+	edited, err := alice.Edit(item.ID, ItemEdit{Title: &title})
+	require.NoError(t, err)
+	mine, err = alice.Get(item.ID)
+	require.NoError(t, err)
+	assert.Equal(t, edited.Version, mine.Version)
+	assert.True(t, mine.IsStarred(), "an edit should keep alice's state")
+	_, err = bob.Patch(item.ID, ItemPatch{Version: &bobsCopy.Version, Read: &read})
+	assert.ErrorIs(t, err, ErrVersionConflict)
+}
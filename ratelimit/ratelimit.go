@@ -0,0 +1,152 @@
+// Package ratelimit provides token-bucket rate limiting for newsfed's HTTP
+// servers. Each IP address gets its own bucket, and so does each user, so
+// one noisy client can't starve the rest.
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pevans/newsfed/internal/httpjson"
+	"github.com/pevans/newsfed/users"
+)
+
+// idleBuckets is how long a client's bucket is kept after its last request.
+// A bucket idle this long has refilled, so forgetting it changes nothing.
+const idleBuckets = 10 * time.Minute
+
+// Config sets the rate limit applied to each client.
+type Config struct {
+	// Rate is the number of requests per second each client may sustain.
+	// Zero or less disables the limit.
+	Rate float64
+	// Burst is the number of requests a client may make at once before the
+	// rate applies. Values below 1 are treated as 1.
+	Burst int
+}
+
+// bucket is one client's token bucket.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter tracks a token bucket per client.
+type Limiter struct {
+	mu        sync.Mutex
+	config    Config
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// New returns a Limiter that applies config to each client.
+func New(config Config) *Limiter {
+	if config.Burst < 1 {
+		config.Burst = 1
+	}
+	return &Limiter{
+		config:  config,
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Allow takes a token from key's bucket. When the bucket is empty it returns
+// false and how long until a token is available.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	if l.config.Rate <= 0 {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	burst := float64(l.config.Burst)
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst, last: now}
+		l.buckets[key] = b
+	}
+
+	// Refill for the time since the last request
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*l.config.Rate)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	wait := time.Duration((1 - b.tokens) / l.config.Rate * float64(time.Second))
+	return false, wait
+}
+
+// sweep forgets buckets that have been idle long enough to be full again.
+// The caller must hold l.mu.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < idleBuckets {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= idleBuckets {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// AddressMiddleware rejects requests from addresses that are over their
+// limit, whoever the requests authenticate as. It must run before
+// users.Middleware, so that requests whose token is refused still count and
+// a client can't guess tokens without limit.
+func (l *Limiter) AddressMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !l.admit(w, AddressKey(r)) {
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Middleware rejects requests from users that are over their limit, so a
+// token shared across addresses gets one allowance. It must run after
+// users.Middleware. Requests made as the default user pass through, since
+// AddressMiddleware has already limited them by address.
+func (l *Limiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user := users.FromContext(r.Context()); user != nil && !l.admit(w, "user:"+user.Name) {
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// admit takes a token from key's bucket. When the bucket is empty it
+// responds with 429 Too Many Requests and a Retry-After header giving the
+// seconds until the client may try again, and returns false.
+func (l *Limiter) admit(w http.ResponseWriter, key string) bool {
+	allowed, wait := l.Allow(key)
+	if !allowed {
+		seconds := int(math.Ceil(wait.Seconds()))
+		w.Header().Set("Retry-After", fmt.Sprint(max(seconds, 1)))
+		httpjson.Error(w, http.StatusTooManyRequests, "rate limit exceeded")
+	}
+	return allowed
+}
+
+// AddressKey identifies the IP address r was sent from.
+func AddressKey(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
@@ -0,0 +1,154 @@
+package ratelimit
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pevans/newsfed/users"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test helper: create a limiter whose clock is advanced by hand
+func newTestLimiter(config Config) (*Limiter, *time.Time) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := New(config)
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+// TestAllow_BurstThenRate verifies that a client may spend its burst at once
+// and then gets tokens back at the configured rate
+func TestAllow_BurstThenRate(t *testing.T) {
+	l, now := newTestLimiter(Config{Rate: 2, Burst: 3})
+
+	for range 3 {
+		allowed, _ := l.Allow("a")
+		assert.True(t, allowed)
+	}
+	allowed, wait := l.Allow("a")
+	assert.False(t, allowed)
+	assert.Equal(t, 500*time.Millisecond, wait)
+
+	// Other clients have their own buckets
+	allowed, _ = l.Allow("b")
+	assert.True(t, allowed)
+
+	*now = now.Add(500 * time.Millisecond)
+	allowed, _ = l.Allow("a")
+	assert.True(t, allowed)
+	allowed, _ = l.Allow("a")
+	assert.False(t, allowed)
+}
+
+// TestAllow_Disabled verifies that a zero rate allows everything
+func TestAllow_Disabled(t *testing.T) {
+	l, _ := newTestLimiter(Config{})
+	for range 100 {
+		allowed, _ := l.Allow("a")
+		assert.True(t, allowed)
+	}
+}
+
+// TestAddressMiddleware_RejectsWithRetryAfter verifies that requests over
+// the limit get 429 with a Retry-After header and a JSON error, and that
+// addresses are limited separately
+func TestAddressMiddleware_RejectsWithRetryAfter(t *testing.T) {
+	l, _ := newTestLimiter(Config{Rate: 0.5, Burst: 1})
+	handler := l.AddressMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	request := func(addr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/metrics", nil)
+		req.RemoteAddr = addr
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusOK, request("192.0.2.1:5000").Code)
+	rec := request("192.0.2.1:5001")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get("Retry-After"))
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"error": "rate limit exceeded"}`, rec.Body.String())
+
+	assert.Equal(t, http.StatusOK, request("192.0.2.2:5000").Code)
+}
+
+// TestMiddleware_LimitsUsers verifies that users are limited separately,
+// whatever address they send from, and that requests made as the default
+// user are left to the address limit
+func TestMiddleware_LimitsUsers(t *testing.T) {
+	l, _ := newTestLimiter(Config{Rate: 0.5, Burst: 1})
+	handler := l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	request := func(user *users.User, addr string) int {
+		req := httptest.NewRequest("GET", "/api/v1/items", nil)
+		req.RemoteAddr = addr
+		if user != nil {
+			req = req.WithContext(users.NewContext(req.Context(), user))
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	alice := &users.User{Name: "alice"}
+	assert.Equal(t, http.StatusOK, request(alice, "192.0.2.1:5000"))
+	assert.Equal(t, http.StatusTooManyRequests, request(alice, "192.0.2.2:5000"))
+	assert.Equal(t, http.StatusOK, request(&users.User{Name: "bob"}, "192.0.2.1:5000"))
+
+	for range 3 {
+		assert.Equal(t, http.StatusOK, request(nil, "192.0.2.1:5000"))
+	}
+}
+
+// TestAddressMiddleware_LimitsRefusedTokens verifies that requests whose
+// token is refused count against their address, so that guessing tokens
+// ends in 429
+func TestAddressMiddleware_LimitsRefusedTokens(t *testing.T) {
+	store, err := users.NewUserStore(filepath.Join(t.TempDir(), "users.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+	_, _, err = store.Create("alice")
+	require.NoError(t, err)
+
+	l, _ := newTestLimiter(Config{Rate: 1, Burst: 3})
+	handler := l.AddressMiddleware(store.Middleware(l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))))
+
+	var codes []int
+	for i := range 5 {
+		req := httptest.NewRequest("GET", "/api/v1/items", nil)
+		req.RemoteAddr = "192.0.2.1:5000"
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer guess-%d", i))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		codes = append(codes, rec.Code)
+	}
+	assert.Equal(t, []int{
+		http.StatusUnauthorized, http.StatusUnauthorized, http.StatusUnauthorized,
+		http.StatusTooManyRequests, http.StatusTooManyRequests,
+	}, codes)
+}
+
+// TestAddressKey verifies that requests are keyed by the host they came
+// from, not its port, and that bearer tokens are ignored
+func TestAddressKey(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "[2001:db8::1]:443"
+	assert.Equal(t, "ip:2001:db8::1", AddressKey(req))
+
+	req.Header.Set("Authorization", "Bearer abc")
+	req = req.WithContext(users.NewContext(req.Context(), &users.User{Name: "alice"}))
+	assert.Equal(t, "ip:2001:db8::1", AddressKey(req))
+}
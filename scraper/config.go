@@ -6,6 +6,9 @@ type ScraperConfig struct {
 	DiscoveryMode string        `json:"discovery_mode"` // "list" or "direct"
 	ListConfig    *ListConfig   `json:"list_config,omitempty"`
 	ArticleConfig ArticleConfig `json:"article_config"`
+	// RenderJS loads pages in a headless browser and reads them once their
+	// scripts have run, for sites that build their content client-side
+	RenderJS bool `json:"render_js,omitempty"`
 }
 
 // ListConfig defines how to discover articles from listing/index pages. Used
@@ -16,14 +19,38 @@ type ListConfig struct {
 	MaxPages           int    `json:"max_pages"` // Default: 1
 }
 
+// Extraction modes for ArticleConfig.ExtractionMode.
+const (
+	ExtractionSelectors = "selectors"
+	ExtractionAuto      = "auto"
+)
+
 // ArticleConfig defines how to extract metadata from individual article
 // pages. Implements Spec 3 section 2.2.
 type ArticleConfig struct {
-	TitleSelector   string `json:"title_selector"`
-	ContentSelector string `json:"content_selector"`
+	ExtractionMode  string `json:"extraction_mode,omitempty"` // "selectors" (default) or "auto"
+	TitleSelector   string `json:"title_selector,omitempty"`
+	ContentSelector string `json:"content_selector,omitempty"`
 	AuthorSelector  string `json:"author_selector,omitempty"`
 	DateSelector    string `json:"date_selector,omitempty"`
 	DateFormat      string `json:"date_format,omitempty"` // Go time format string
+	// DateFormats are further Go time formats tried after DateFormat.
+	// Common human and relative dates are read without one.
+	DateFormats []string `json:"date_formats,omitempty"`
+	// IgnoreMetadata skips the JSON-LD, Open Graph, and meta tags a page
+	// declares, for sites whose metadata is wrong, so that only selectors
+	// and heuristics are used
+	IgnoreMetadata bool `json:"ignore_metadata,omitempty"`
+}
+
+// UsesAutoExtraction reports whether the title and content should be found
+// with readability heuristics instead of CSS selectors. This is the case when
+// ExtractionMode is "auto" or when neither selector is configured.
+func (c ArticleConfig) UsesAutoExtraction() bool {
+	if c.ExtractionMode == ExtractionAuto {
+		return true
+	}
+	return c.ExtractionMode == "" && c.TitleSelector == "" && c.ContentSelector == ""
 }
 
 // NewListConfig creates a new list configuration with default values.
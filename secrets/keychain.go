@@ -0,0 +1,63 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// The keychain item the master key is read from: a generic password for
+// service "newsfed" and account "master-key".
+const (
+	keychainService = "newsfed"
+	keychainAccount = "master-key"
+)
+
+// keychainTimeout bounds a keychain lookup, which may wait on a prompt to
+// unlock the keychain.
+const keychainTimeout = 30 * time.Second
+
+// KeychainMasterKey returns the master key stored in the OS keychain: the
+// macOS login keychain, read with security(1), or the Secret Service on
+// Linux and the BSDs, read with secret-tool(1). It returns "" without an
+// error if there is no keychain tool or no master key in the keychain.
+func KeychainMasterKey() (string, error) {
+	var name string
+	var args []string
+	switch runtime.GOOS {
+	case "darwin":
+		name = "security"
+		args = []string{"find-generic-password", "-s", keychainService, "-a", keychainAccount, "-w"}
+	case "linux", "freebsd", "openbsd", "netbsd":
+		name = "secret-tool"
+		args = []string{"lookup", "service", keychainService, "account", keychainAccount}
+	default:
+		return "", nil
+	}
+
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return "", nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), keychainTimeout)
+	defer cancel()
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+
+	// Both tools exit with status 1 when the item doesn't exist
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 && ctx.Err() == nil {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read master key from keychain: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimRight(string(out), "\r\n"), nil
+}
@@ -0,0 +1,307 @@
+// Package secrets keeps credentials -- API tokens, proxy passwords, login
+// form passwords -- in the metadata database, encrypted with a key derived
+// from a master key. Source configs refer to a secret by name, as
+// "secret:<name>", instead of holding the credential in plain text, and the
+// reference is resolved only when a request is made.
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pevans/newsfed/audit"
+	"github.com/pevans/newsfed/sqldb"
+)
+
+// Errors for secrets.
+var (
+	ErrNotFound         = errors.New("secret not found")
+	ErrInvalidName      = errors.New("secret name must be 1 to 64 letters, digits, dots, dashes, or underscores")
+	ErrEmptyValue       = errors.New("secret value must not be empty")
+	ErrMasterKeyMissing = errors.New("no master key")
+	ErrDecrypt          = errors.New("secret can't be decrypted with this master key")
+)
+
+// RefPrefix begins a value in a source config that refers to a secret.
+const RefPrefix = "secret:"
+
+// maxNameLength is the longest secret name.
+const maxNameLength = 64
+
+// Secret describes a stored secret. Its value is only returned by Get.
+type Secret struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Store reads and writes secrets in the metadata database.
+type Store struct {
+	db  *sqldb.DB
+	gcm cipher.AEAD // nil without a master key
+}
+
+// migrations are the secrets schema, applied in order by sqldb; new ones
+// must only ever be appended. The schema is the same for SQLite and
+// PostgreSQL.
+var migrations = []string{
+	`
+	CREATE TABLE IF NOT EXISTS secrets (
+		name TEXT PRIMARY KEY,
+		value TEXT NOT NULL,
+		created_at TEXT NOT NULL,
+		updated_at TEXT NOT NULL
+	);
+	`,
+}
+
+// Open opens the secrets in the metadata database at dbPath, or a
+// postgres:// DSN, encrypted with a key derived from masterKey. Without a
+// master key, secrets can be listed and deleted but not read or written.
+func Open(dbPath, masterKey string) (*Store, error) {
+	db, err := sqldb.Open(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	store := &Store{db: db}
+	if masterKey != "" {
+		if store.gcm, err = newCipher(masterKey); err != nil {
+			_ = db.Close()
+			return nil, err
+		}
+	}
+	if err := store.initSchema(); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to initialize schema: %w", err)
+	}
+	return store, nil
+}
+
+// SchemaStatus returns how far the secrets schema of db has been migrated,
+// without migrating it, for newsfed migrate-db status.
+func SchemaStatus(db *sqldb.DB) (sqldb.SchemaStatus, error) {
+	return db.SchemaStatus("secrets", len(migrations))
+}
+
+// initSchema creates the secrets table, or brings it up to date.
+func (s *Store) initSchema() error {
+	if err := audit.InitSchema(s.db); err != nil {
+		return err
+	}
+	return s.db.Migrate("secrets", migrations)
+}
+
+// Close closes the database connection.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// HasMasterKey reports whether the store was opened with a master key, and
+// so can read and write secrets.
+func (s *Store) HasMasterKey() bool {
+	return s != nil && s.gcm != nil
+}
+
+// ValidateName checks that name is usable as a secret name.
+func ValidateName(name string) error {
+	if name == "" || len(name) > maxNameLength {
+		return ErrInvalidName
+	}
+	for _, r := range name {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') &&
+			r != '.' && r != '-' && r != '_' {
+			return ErrInvalidName
+		}
+	}
+	return nil
+}
+
+// Ref returns the name of the secret value refers to, if it is a reference:
+// RefPrefix followed by a valid name.
+func Ref(value string) (string, bool) {
+	name, ok := strings.CutPrefix(value, RefPrefix)
+	if !ok || ValidateName(name) != nil {
+		return "", false
+	}
+	return name, true
+}
+
+// Set stores value under name, replacing any secret already stored there.
+// The audit log records that the secret changed, but not its value.
+func (s *Store) Set(name, value string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	if value == "" {
+		return ErrEmptyValue
+	}
+	sealed, err := s.seal(name, value)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	result, err := tx.Exec("UPDATE secrets SET value = ?, updated_at = ? WHERE name = ?", sealed, now, name)
+	if err != nil {
+		return fmt.Errorf("failed to update secret: %w", err)
+	}
+	change := audit.Change{Old: "(encrypted)", New: "(encrypted)"}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		if _, err := tx.Exec("INSERT INTO secrets (name, value, created_at, updated_at) VALUES (?, ?, ?, ?)",
+			name, sealed, now, now); err != nil {
+			return fmt.Errorf("failed to insert secret: %w", err)
+		}
+		change.Old = nil
+	}
+	if err := audit.Record(tx, audit.SecretSet, RefPrefix+name, map[string]audit.Change{"value": change}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Get returns the value of the secret name. It returns ErrNotFound if there
+// is none, and an error wrapping ErrDecrypt if the store's master key isn't
+// the one it was stored with.
+func (s *Store) Get(name string) (string, error) {
+	if err := ValidateName(name); err != nil {
+		return "", err
+	}
+	if !s.HasMasterKey() {
+		return "", fmt.Errorf("%w: can't read secret %q", ErrMasterKeyMissing, name)
+	}
+
+	var sealed string
+	err := s.db.QueryRow("SELECT value FROM secrets WHERE name = ?", name).Scan(&sealed)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to query secret: %w", err)
+	}
+	return s.open(name, sealed)
+}
+
+// Resolve returns the value of the secret value refers to, or value itself
+// if it isn't a reference. It may be called on a nil Store, which resolves
+// no references.
+func (s *Store) Resolve(value string) (string, error) {
+	name, ok := Ref(value)
+	if !ok {
+		return value, nil
+	}
+	if s == nil {
+		return "", fmt.Errorf("%w: can't read secret %q", ErrMasterKeyMissing, name)
+	}
+	return s.Get(name)
+}
+
+// List returns the stored secrets, without their values, ordered by name.
+func (s *Store) List() ([]Secret, error) {
+	rows, err := s.db.Query("SELECT name, created_at, updated_at FROM secrets ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query secrets: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	list := []Secret{}
+	for rows.Next() {
+		var secret Secret
+		var createdAt, updatedAt string
+		if err := rows.Scan(&secret.Name, &createdAt, &updatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan secret: %w", err)
+		}
+		secret.CreatedAt, _ = time.Parse(time.RFC3339Nano, createdAt)
+		secret.UpdatedAt, _ = time.Parse(time.RFC3339Nano, updatedAt)
+		list = append(list, secret)
+	}
+	return list, rows.Err()
+}
+
+// Delete removes the secret name. It returns ErrNotFound if there is none.
+func (s *Store) Delete(name string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := DeleteTx(tx, name); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteTx removes the secret name within tx, so that a store can delete a
+// secret along with what refers to it. It returns ErrNotFound if there is
+// none.
+func DeleteTx(tx *sqldb.Tx, name string) error {
+	result, err := tx.Exec("DELETE FROM secrets WHERE name = ?", name)
+	if err != nil {
+		return fmt.Errorf("failed to delete secret: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return audit.Record(tx, audit.SecretDeleted, RefPrefix+name, nil)
+}
+
+// newCipher returns the AEAD secrets are sealed with, keyed by HKDF-SHA256
+// over the master key. The master key should be long and random, such as
+// the output of "openssl rand -base64 32"; it is not stretched as a
+// password would be.
+func newCipher(masterKey string) (cipher.AEAD, error) {
+	key, err := hkdf.Key(sha256.New, []byte(masterKey), nil, "newsfed secrets v1", 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive secrets key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts value with AES-256-GCM, bound to name so that a sealed
+// value can't be moved to another secret, and returns it base64-encoded
+// with its nonce first.
+func (s *Store) seal(name, value string) (string, error) {
+	if !s.HasMasterKey() {
+		return "", fmt.Errorf("%w: can't store secret %q", ErrMasterKeyMissing, name)
+	}
+	nonce := make([]byte, s.gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := s.gcm.Seal(nonce, nonce, []byte(value), []byte(name))
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// open reverses seal.
+func (s *Store) open(name, sealed string) (string, error) {
+	raw, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil || len(raw) < s.gcm.NonceSize() {
+		return "", fmt.Errorf("%w: stored secret %q is corrupt", ErrDecrypt, name)
+	}
+	nonce, ciphertext := raw[:s.gcm.NonceSize()], raw[s.gcm.NonceSize():]
+	value, err := s.gcm.Open(nil, nonce, ciphertext, []byte(name))
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrDecrypt, name)
+	}
+	return string(value), nil
+}
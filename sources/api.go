@@ -0,0 +1,279 @@
+package sources
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pevans/newsfed/internal/httpjson"
+	"github.com/pevans/newsfed/newsfeed"
+)
+
+// defaultHistoryLimit is how many attempts HistoryHandler returns when no
+// limit is given, as for newsfed sources history.
+const defaultHistoryLimit = 20
+
+// HistoryHandler lists a source's sync attempts for GET
+// /api/v1/meta/sources/{id}/history, newest first, as ListSyncHistory does.
+// limit caps how many are returned (default 20). It responds 404 for an
+// unknown source.
+func (s *SourceStore) HistoryHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, ok := sourceID(w, r)
+		if !ok {
+			return
+		}
+		limit := defaultHistoryLimit
+		if value := r.URL.Query().Get("limit"); value != "" {
+			var err error
+			if limit, err = strconv.Atoi(value); err != nil || limit < 1 {
+				httpjson.Error(w, http.StatusBadRequest, "limit must be a positive number")
+				return
+			}
+		}
+
+		if _, err := s.GetSource(id); err != nil {
+			writeSourceError(w, err)
+			return
+		}
+		history, err := s.ListSyncHistory(id, limit)
+		if err != nil {
+			httpjson.Error(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if history == nil {
+			history = []SyncAttempt{}
+		}
+		httpjson.Write(w, http.StatusOK, history)
+	})
+}
+
+// sourceID reads the source ID of a request's path, writing 400 if it is
+// malformed.
+func sourceID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		httpjson.Error(w, http.StatusBadRequest, "invalid source ID")
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// writeSourceError responds 404 for an unknown source, or 500 otherwise.
+func writeSourceError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if errors.Is(err, ErrSourceNotFound) {
+		status = http.StatusNotFound
+	}
+	httpjson.Error(w, status, err.Error())
+}
+
+// GetHandler returns a source for GET /api/v1/meta/sources/{id}, redacted
+// as by Source.Redacted so that header values such as Authorization tokens
+// aren't disclosed. It responds 404 for an unknown source.
+func (s *SourceStore) GetHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, ok := sourceID(w, r)
+		if !ok {
+			return
+		}
+		source, err := s.GetSource(id)
+		if err != nil {
+			writeSourceError(w, err)
+			return
+		}
+		httpjson.Write(w, http.StatusOK, source.Redacted())
+	})
+}
+
+// sourcePatch is the body of a request to change a source.
+type sourcePatch struct {
+	Name      *string `json:"name"`
+	Category  *string `json:"category"`
+	UserAgent *string `json:"user_agent"`
+	// HTTPHeaders sets the named headers and keeps the others; a null value
+	// removes a header, and RedactedValue keeps its current value
+	HTTPHeaders map[string]*string `json:"http_headers"`
+}
+
+// PatchHandler changes a source for PATCH /api/v1/meta/sources/{id}, from
+// {"name": "...", "category": "...", "user_agent": "...", "http_headers":
+// {"Authorization": "..."}}, where every field is optional, as newsfed
+// sources update does. An empty user_agent or category clears it. Headers
+// are merged into the source's own: a null value removes a header, and the
+// value RedactedValue, as GetHandler shows it, leaves it unchanged. It
+// responds 200 OK with the redacted source as saved, 400 for an empty name
+// or a malformed header name, and 404 for an unknown source.
+func (s *SourceStore) PatchHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, ok := sourceID(w, r)
+		if !ok {
+			return
+		}
+		var patch sourcePatch
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&patch); err != nil {
+			httpjson.Error(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+			return
+		}
+		if patch.Name != nil && strings.TrimSpace(*patch.Name) == "" {
+			httpjson.Error(w, http.StatusBadRequest, "name must not be empty")
+			return
+		}
+
+		source, err := s.GetSource(id)
+		if err != nil {
+			writeSourceError(w, err)
+			return
+		}
+		update := SourceUpdate{Name: patch.Name, Category: patch.Category, UserAgent: patch.UserAgent}
+		if patch.HTTPHeaders != nil {
+			headers := maps.Clone(source.HTTPHeaders)
+			if headers == nil {
+				headers = map[string]string{}
+			}
+			for name, value := range patch.HTTPHeaders {
+				canonical := http.CanonicalHeaderKey(strings.TrimSpace(name))
+				if canonical == "" || strings.ContainsAny(canonical, " \t:") {
+					httpjson.Error(w, http.StatusBadRequest, fmt.Sprintf("invalid header name %q", name))
+					return
+				}
+				switch {
+				case value == nil:
+					delete(headers, canonical)
+				case *value != RedactedValue:
+					headers[canonical] = strings.TrimSpace(*value)
+				}
+			}
+			update.HTTPHeaders = headers
+		}
+
+		if err := s.UpdateSource(id, update); err != nil {
+			writeSourceError(w, err)
+			return
+		}
+		updated, err := s.GetSource(id)
+		if err != nil {
+			writeSourceError(w, err)
+			return
+		}
+		httpjson.Write(w, http.StatusOK, updated.Redacted())
+	})
+}
+
+// Bulk operations accepted by BatchHandler.
+const (
+	BatchEnable  = "enable"
+	BatchDisable = "disable"
+	BatchDelete  = "delete"
+)
+
+// batchRequest is the body of a request to change several sources at once.
+type batchRequest struct {
+	IDs       []uuid.UUID `json:"ids"`
+	Operation string      `json:"operation"`
+}
+
+// batchResponse reports how many sources a bulk operation changed.
+type batchResponse struct {
+	Operation string `json:"operation"`
+	Changed   int    `json:"changed"`
+}
+
+// BatchHandler enables, disables, or deletes several sources at once for
+// PATCH /api/v1/meta/sources, from {"ids": [...], "operation": "disable"},
+// as newsfed sources enable, disable, and delete do with several IDs. The
+// sources are changed in one transaction, so an unknown source responds 404
+// and changes none of them. A missing or unknown operation, or no IDs,
+// responds 400.
+func (s *SourceStore) BatchHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req batchRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+			httpjson.Error(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+			return
+		}
+		if len(req.IDs) == 0 {
+			httpjson.Error(w, http.StatusBadRequest, "ids must list at least one source")
+			return
+		}
+
+		var err error
+		switch req.Operation {
+		case BatchEnable:
+			now := time.Now().UTC()
+			zero := 0
+			err = s.UpdateSources(req.IDs, SourceUpdate{
+				EnabledAt:        &now,
+				ClearNextFetchAt: true,
+				ClearReprobeAt:   true,
+				ReprobeCount:     &zero,
+			})
+		case BatchDisable:
+			err = s.UpdateSources(req.IDs, SourceUpdate{ClearEnabledAt: true, ClearReprobeAt: true})
+		case BatchDelete:
+			err = s.DeleteSources(req.IDs)
+		default:
+			httpjson.Error(w, http.StatusBadRequest, "operation must be enable, disable, or delete")
+			return
+		}
+		switch {
+		case errors.Is(err, ErrSourceNotFound):
+			httpjson.Error(w, http.StatusNotFound, err.Error())
+		case err != nil:
+			httpjson.Error(w, http.StatusInternalServerError, err.Error())
+		default:
+			httpjson.Write(w, http.StatusOK, batchResponse{Operation: req.Operation, Changed: len(req.IDs)})
+		}
+	})
+}
+
+// CategoryReport is a category with the counts of its sources and of the
+// items in it.
+type CategoryReport struct {
+	Category
+	Items  int `json:"items"`
+	Unread int `json:"unread"`
+}
+
+// ReportCategories returns every category, ordered by name, with the number
+// of items in feed in it and how many of those are unread.
+func (s *SourceStore) ReportCategories(feed *newsfeed.NewsFeed) ([]CategoryReport, error) {
+	categories, err := s.ListCategories()
+	if err != nil {
+		return nil, err
+	}
+	reports := make([]CategoryReport, 0, len(categories))
+	for _, category := range categories {
+		// Limit 1 keeps each query cheap; only the totals are needed
+		all, err := feed.Query(newsfeed.ListOptions{Category: category.Name, Limit: 1})
+		if err != nil {
+			return nil, fmt.Errorf("failed to count items: %w", err)
+		}
+		unread, err := feed.Query(newsfeed.ListOptions{Category: category.Name, Unread: true, Limit: 1})
+		if err != nil {
+			return nil, fmt.Errorf("failed to count items: %w", err)
+		}
+		reports = append(reports, CategoryReport{Category: category, Items: all.Total, Unread: unread.Total})
+	}
+	return reports, nil
+}
+
+// CategoriesHandler lists every category with the counts of its sources,
+// items, and unread items in feed for GET /api/v1/meta/categories, as
+// newsfed sources categories does.
+func (s *SourceStore) CategoriesHandler(feed *newsfeed.NewsFeed) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reports, err := s.ReportCategories(feed)
+		if err != nil {
+			httpjson.Error(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		httpjson.Write(w, http.StatusOK, reports)
+	})
+}
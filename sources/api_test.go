@@ -0,0 +1,216 @@
+package sources
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pevans/newsfed/newsfeed"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHistoryHandler verifies that a source's sync attempts are listed
+// newest first up to the limit, and that unknown sources and malformed
+// parameters are refused
+func TestHistoryHandler(t *testing.T) {
+	store := createTestSourceStore(t)
+	now := time.Now()
+	source, err := store.CreateSource("rss", "http://example.com/feed", "Test", nil, &now)
+	require.NoError(t, err)
+	for i := range 3 {
+		require.NoError(t, store.RecordSyncAttempt(SyncAttempt{
+			SourceID:        source.SourceID,
+			StartedAt:       now.Add(time.Duration(i) * time.Minute),
+			ItemsDiscovered: i,
+		}))
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /api/v1/meta/sources/{id}/history", store.HistoryHandler())
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	rec := get("/api/v1/meta/sources/" + source.SourceID.String() + "/history?limit=2")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var history []SyncAttempt
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &history))
+	require.Len(t, history, 2)
+	assert.Equal(t, 2, history[0].ItemsDiscovered)
+	assert.Equal(t, 1, history[1].ItemsDiscovered)
+
+	assert.Equal(t, http.StatusNotFound, get("/api/v1/meta/sources/"+uuid.NewString()+"/history").Code)
+	assert.Equal(t, http.StatusBadRequest, get("/api/v1/meta/sources/not-an-id/history").Code)
+	assert.Equal(t, http.StatusBadRequest, get("/api/v1/meta/sources/"+source.SourceID.String()+"/history?limit=0").Code)
+}
+
+// TestBatchHandler verifies that a bulk operation changes only the listed
+// sources, that a list naming an unknown source changes none, and that
+// unknown operations are refused
+func TestBatchHandler(t *testing.T) {
+	store := createTestSourceStore(t)
+	now := time.Now()
+	var ids []uuid.UUID
+	for _, name := range []string{"a", "b", "c"} {
+		source, err := store.CreateSource("rss", "http://example.com/"+name, name, nil, &now)
+		require.NoError(t, err)
+		ids = append(ids, source.SourceID)
+	}
+	patch := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		store.BatchHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPatch, "/api/v1/meta/sources", strings.NewReader(body)))
+		return rec
+	}
+	enabled := func(id uuid.UUID) bool {
+		source, err := store.GetSource(id)
+		require.NoError(t, err)
+		return source.EnabledAt != nil
+	}
+	body := func(operation string, ids ...uuid.UUID) string {
+		b, err := json.Marshal(batchRequest{IDs: ids, Operation: operation})
+		require.NoError(t, err)
+		return string(b)
+	}
+
+	rec := patch(body(BatchDisable, ids[0], ids[1]))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.JSONEq(t, `{"operation": "disable", "changed": 2}`, rec.Body.String())
+	assert.False(t, enabled(ids[0]))
+	assert.False(t, enabled(ids[1]))
+	assert.True(t, enabled(ids[2]))
+
+	assert.Equal(t, http.StatusNotFound, patch(body(BatchEnable, ids[0], uuid.New())).Code)
+	assert.False(t, enabled(ids[0]))
+	assert.Equal(t, http.StatusNotFound, patch(body(BatchDelete, ids[2], uuid.New())).Code)
+	_, err := store.GetSource(ids[2])
+	require.NoError(t, err)
+
+	require.Equal(t, http.StatusOK, patch(body(BatchEnable, ids[0])).Code)
+	assert.True(t, enabled(ids[0]))
+	require.Equal(t, http.StatusOK, patch(body(BatchDelete, ids[1], ids[2])).Code)
+	remaining, err := store.ListSources(SourceFilter{})
+	require.NoError(t, err)
+	require.Len(t, remaining, 1)
+	assert.Equal(t, ids[0], remaining[0].SourceID)
+
+	assert.Equal(t, http.StatusBadRequest, patch(body("archive", ids[0])).Code)
+	assert.Equal(t, http.StatusBadRequest, patch(body(BatchDisable)).Code)
+	assert.Equal(t, http.StatusBadRequest, patch(`{"ids": ["not-an-id"], "operation": "disable"}`).Code)
+	assert.True(t, enabled(ids[0]))
+}
+
+// TestGetHandler verifies that a source's header values are redacted, and
+// that unknown and malformed IDs are refused
+func TestGetHandler(t *testing.T) {
+	store := createTestSourceStore(t)
+	now := time.Now()
+	source, err := store.CreateSource("rss", "http://example.com/feed", "Test", nil, &now)
+	require.NoError(t, err)
+	require.NoError(t, store.UpdateSource(source.SourceID, SourceUpdate{HTTPHeaders: map[string]string{
+		"Authorization": "Bearer hunter2",
+		"X-Api-Key":     "secret:feed.key",
+	}}))
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /api/v1/meta/sources/{id}", store.GetHandler())
+	get := func(id string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/meta/sources/"+id, nil))
+		return rec
+	}
+
+	rec := get(source.SourceID.String())
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.NotContains(t, rec.Body.String(), "hunter2")
+	var got Source
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+	assert.Equal(t, map[string]string{"Authorization": RedactedValue, "X-Api-Key": "secret:feed.key"}, got.HTTPHeaders)
+
+	assert.Equal(t, http.StatusNotFound, get(uuid.NewString()).Code)
+	assert.Equal(t, http.StatusBadRequest, get("not-an-id").Code)
+}
+
+// TestPatchHandler verifies that a source's User-Agent and headers are
+// changed, that headers sent back redacted keep their values, and that
+// invalid changes are refused
+func TestPatchHandler(t *testing.T) {
+	store := createTestSourceStore(t)
+	now := time.Now()
+	source, err := store.CreateSource("rss", "http://example.com/feed", "Test", nil, &now)
+	require.NoError(t, err)
+	require.NoError(t, store.UpdateSource(source.SourceID, SourceUpdate{HTTPHeaders: map[string]string{
+		"Authorization": "Bearer hunter2",
+		"X-Old":         "old",
+	}}))
+
+	mux := http.NewServeMux()
+	mux.Handle("PATCH /api/v1/meta/sources/{id}", store.PatchHandler())
+	patch := func(id, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPatch, "/api/v1/meta/sources/"+id, strings.NewReader(body)))
+		return rec
+	}
+
+	rec := patch(source.SourceID.String(), `{"user_agent": "newsfed-test/1.0", "http_headers": {"authorization": "(set)", "x-old": null, "accept-language": "en"}}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.NotContains(t, rec.Body.String(), "hunter2")
+	stored, err := store.GetSource(source.SourceID)
+	require.NoError(t, err)
+	require.NotNil(t, stored.UserAgent)
+	assert.Equal(t, "newsfed-test/1.0", *stored.UserAgent)
+	assert.Equal(t, map[string]string{"Authorization": "Bearer hunter2", "Accept-Language": "en"}, stored.HTTPHeaders)
+
+	rec = patch(source.SourceID.String(), `{"user_agent": "", "http_headers": {"Authorization": "Bearer new"}}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	stored, err = store.GetSource(source.SourceID)
+	require.NoError(t, err)
+	assert.Nil(t, stored.UserAgent)
+	assert.Equal(t, "Bearer new", stored.HTTPHeaders["Authorization"])
+
+	assert.Equal(t, http.StatusBadRequest, patch(source.SourceID.String(), `{"http_headers": {"Bad Name": "x"}}`).Code)
+	assert.Equal(t, http.StatusBadRequest, patch(source.SourceID.String(), `{"name": " "}`).Code)
+	assert.Equal(t, http.StatusNotFound, patch(uuid.NewString(), `{"name": "x"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, patch("not-an-id", `{}`).Code)
+}
+
+// TestCategoriesHandler verifies that each category is listed with the
+// counts of its sources, items, and unread items
+func TestCategoriesHandler(t *testing.T) {
+	store := createTestSourceStore(t)
+	now := time.Now()
+	security := "security"
+	source, err := store.CreateSource("rss", "http://example.com/feed", "Test", nil, &now)
+	require.NoError(t, err)
+	require.NoError(t, store.UpdateSource(source.SourceID, SourceUpdate{Category: &security}))
+	require.NoError(t, store.CreateCategory("golang"))
+
+	feed := newsfeed.NewMemoryNewsFeed()
+	for i := range 3 {
+		item := newsfeed.NewsItem{ID: uuid.New(), Title: "item", URL: "https://example.com/" + strconv.Itoa(i), Category: &security, PublishedAt: now, DiscoveredAt: now}
+		if i == 0 {
+			item.ReadAt = &now
+		}
+		require.NoError(t, feed.Add(item))
+	}
+
+	rec := httptest.NewRecorder()
+	store.CategoriesHandler(feed).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/meta/categories", nil))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var reports []CategoryReport
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &reports))
+	require.Len(t, reports, 2)
+	assert.Equal(t, "golang", reports[0].Name)
+	assert.Equal(t, 0, reports[0].Items)
+	assert.Equal(t, "security", reports[1].Name)
+	assert.Equal(t, 1, reports[1].SourceCount)
+	assert.Equal(t, 3, reports[1].Items)
+	assert.Equal(t, 2, reports[1].Unread)
+}
@@ -0,0 +1,116 @@
+package sources
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidFetchBudget is returned for a fetch budget with a negative
+// limit or a duration that can't be parsed.
+var ErrInvalidFetchBudget = errors.New("invalid fetch budget")
+
+// FetchBudget caps what one sync of a source may spend, so that a
+// misconfigured scraper can't download thousands of pages in a night. A
+// fetch that reaches a limit stops, keeping the items it has already found.
+// Zero limits are unlimited.
+type FetchBudget struct {
+	MaxRequests int    `json:"max_requests,omitempty"` // Requests sent, counting retries and rendered pages
+	MaxBytes    int64  `json:"max_bytes,omitempty"`    // Response body bytes read
+	MaxDuration string `json:"max_duration,omitempty"` // Time after which no new request is started, as a duration
+}
+
+// IsZero reports whether b leaves fetches unlimited.
+func (b FetchBudget) IsZero() bool {
+	return b == FetchBudget{}
+}
+
+// Validate returns an error wrapping ErrInvalidFetchBudget if a limit is
+// negative or the duration can't be parsed.
+func (b FetchBudget) Validate() error {
+	if b.MaxRequests < 0 {
+		return fmt.Errorf("%w: max requests must not be negative", ErrInvalidFetchBudget)
+	}
+	if b.MaxBytes < 0 {
+		return fmt.Errorf("%w: max bytes must not be negative", ErrInvalidFetchBudget)
+	}
+	if _, err := b.Duration(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Duration returns MaxDuration parsed, or zero if it is unset.
+func (b FetchBudget) Duration() (time.Duration, error) {
+	if b.MaxDuration == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(b.MaxDuration)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("%w: max duration must be a positive duration such as 5m: %q", ErrInvalidFetchBudget, b.MaxDuration)
+	}
+	return d, nil
+}
+
+// String describes the limits b sets, such as "200 requests, 50MB, 10m".
+func (b FetchBudget) String() string {
+	var limits []string
+	if b.MaxRequests > 0 {
+		limits = append(limits, fmt.Sprintf("%d requests", b.MaxRequests))
+	}
+	if b.MaxBytes > 0 {
+		limits = append(limits, FormatByteSize(b.MaxBytes))
+	}
+	if b.MaxDuration != "" {
+		limits = append(limits, b.MaxDuration)
+	}
+	if len(limits) == 0 {
+		return "unlimited"
+	}
+	return strings.Join(limits, ", ")
+}
+
+// byteUnits are the suffixes ParseByteSize accepts, largest first so that
+// "MB" is tried before "B". They are powers of 1024.
+var byteUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"B", 1},
+}
+
+// ParseByteSize parses a size in bytes, optionally followed by KB, MB, or
+// GB (ignoring case), each 1024 times the one before, such as "512KB" or
+// "50MB".
+func ParseByteSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	upper := strings.ToUpper(s)
+	unit := int64(1)
+	for _, u := range byteUnits {
+		if n, ok := strings.CutSuffix(upper, u.suffix); ok {
+			upper, unit = strings.TrimSpace(n), u.size
+			break
+		}
+	}
+	n, err := strconv.ParseInt(upper, 10, 64)
+	if err != nil || n < 0 || n > (1<<62)/unit {
+		return 0, fmt.Errorf("invalid size: %q (use bytes or a number followed by KB, MB, or GB)", s)
+	}
+	return n * unit, nil
+}
+
+// FormatByteSize formats n in the largest unit ParseByteSize accepts that
+// divides it, so that it parses back to the same size.
+func FormatByteSize(n int64) string {
+	for _, u := range byteUnits {
+		if n != 0 && n%u.size == 0 {
+			return fmt.Sprintf("%d%s", n/u.size, u.suffix)
+		}
+	}
+	return fmt.Sprintf("%dB", n)
+}
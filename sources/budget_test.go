@@ -0,0 +1,62 @@
+package sources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFetchBudget_Validate verifies that negative limits and bad durations
+// are rejected
+func TestFetchBudget_Validate(t *testing.T) {
+	require.NoError(t, FetchBudget{}.Validate())
+	require.NoError(t, FetchBudget{MaxRequests: 200, MaxBytes: 50 << 20, MaxDuration: "10m"}.Validate())
+
+	for _, budget := range []FetchBudget{
+		{MaxRequests: -1},
+		{MaxBytes: -1},
+		{MaxDuration: "ten minutes"},
+		{MaxDuration: "-5m"},
+	} {
+		assert.ErrorIs(t, budget.Validate(), ErrInvalidFetchBudget, budget)
+	}
+}
+
+// TestFetchBudget_String verifies how a budget's limits are described
+func TestFetchBudget_String(t *testing.T) {
+	assert.Equal(t, "unlimited", FetchBudget{}.String())
+	assert.Equal(t, "200 requests, 50MB, 10m", FetchBudget{MaxRequests: 200, MaxBytes: 50 << 20, MaxDuration: "10m"}.String())
+	assert.Equal(t, "1500B", FetchBudget{MaxBytes: 1500}.String())
+}
+
+// TestParseByteSize verifies that sizes are parsed with or without a unit,
+// and that formatting a size parses back to it
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int64
+	}{
+		{"0", 0},
+		{"1500", 1500},
+		{"1500B", 1500},
+		{"512KB", 512 << 10},
+		{"50mb", 50 << 20},
+		{" 2 GB ", 2 << 30},
+	}
+	for _, tt := range tests {
+		got, err := ParseByteSize(tt.input)
+		require.NoError(t, err, tt.input)
+		assert.Equal(t, tt.want, got, tt.input)
+		if got > 0 {
+			again, err := ParseByteSize(FormatByteSize(got))
+			require.NoError(t, err)
+			assert.Equal(t, got, again, FormatByteSize(got))
+		}
+	}
+
+	for _, input := range []string{"", "MB", "-1KB", "1.5MB", "10TB", "99999999999GB"} {
+		_, err := ParseByteSize(input)
+		assert.Error(t, err, input)
+	}
+}
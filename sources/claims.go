@@ -0,0 +1,114 @@
+package sources
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// claimTimeFormat stores claim expiry times with a fixed width, so that the
+// database compares them correctly as text.
+const claimTimeFormat = "2006-01-02T15:04:05.000000000Z07:00"
+
+// formatClaimTime formats t in UTC with claimTimeFormat.
+func formatClaimTime(t time.Time) string {
+	return t.UTC().Format(claimTimeFormat)
+}
+
+// ClaimSource leases a source to worker until lease has passed, so that
+// other workers sharing the store do not fetch it at the same time. A source
+// can be claimed if it is unclaimed, its lease has expired, or worker already
+// holds it. It returns the source as stored after the claim, or
+// ErrSourceClaimed if another worker holds a current lease.
+func (s *SourceStore) ClaimSource(sourceID uuid.UUID, worker string, lease time.Duration) (*Source, error) {
+	now := time.Now()
+	result, err := s.db.Exec(`
+		UPDATE sources SET claimed_by = ?, claim_expires_at = ?
+		WHERE source_id = ?
+			AND (claimed_by IS NULL OR claimed_by = ? OR claim_expires_at IS NULL OR claim_expires_at <= ?)`,
+		worker, formatClaimTime(now.Add(lease)),
+		sourceID.String(), worker, formatClaimTime(now),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim source: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	source, err := s.GetSource(sourceID)
+	if err != nil {
+		return nil, err
+	}
+	if rows == 0 {
+		return nil, ErrSourceClaimed
+	}
+	return source, nil
+}
+
+// RenewClaim extends worker's lease on a source until lease from now. It
+// returns ErrClaimLost if worker no longer holds the source, for example
+// because its lease expired and another worker claimed it.
+func (s *SourceStore) RenewClaim(sourceID uuid.UUID, worker string, lease time.Duration) error {
+	result, err := s.db.Exec(
+		"UPDATE sources SET claim_expires_at = ? WHERE source_id = ? AND claimed_by = ?",
+		formatClaimTime(time.Now().Add(lease)), sourceID.String(), worker,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to renew claim: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return ErrClaimLost
+	}
+	return nil
+}
+
+// ReleaseClaim gives up worker's lease on a source. Releasing a source that
+// worker does not hold does nothing.
+func (s *SourceStore) ReleaseClaim(sourceID uuid.UUID, worker string) error {
+	_, err := s.db.Exec(
+		"UPDATE sources SET claimed_by = NULL, claim_expires_at = NULL WHERE source_id = ? AND claimed_by = ?",
+		sourceID.String(), worker,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to release claim: %w", err)
+	}
+	return nil
+}
+
+// ReclaimExpired clears leases that expired before now, left behind by
+// workers that stopped without releasing them, and returns how many were
+// cleared. Expired leases never block ClaimSource; clearing them keeps the
+// claims shown for each source accurate.
+func (s *SourceStore) ReclaimExpired(now time.Time) (int, error) {
+	result, err := s.db.Exec(
+		"UPDATE sources SET claimed_by = NULL, claim_expires_at = NULL WHERE claimed_by IS NOT NULL AND claim_expires_at <= ?",
+		formatClaimTime(now),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reclaim expired claims: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return int(rows), nil
+}
+
+// ClaimHolder returns the worker holding a current lease on source, or "" if
+// it is unclaimed or its lease has expired.
+func ClaimHolder(source Source, now time.Time) string {
+	if source.ClaimedBy == nil || source.ClaimExpiresAt == nil || !source.ClaimExpiresAt.After(now) {
+		return ""
+	}
+	return *source.ClaimedBy
+}
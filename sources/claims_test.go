@@ -0,0 +1,112 @@
+package sources
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestClaimSource_Exclusive verifies that a source held by one worker cannot
+// be claimed by another until it is released, and that the holder may claim
+// it again
+func TestClaimSource_Exclusive(t *testing.T) {
+	store := createTestSourceStore(t)
+	source, err := store.CreateSource("rss", "http://example.com/feed", "Feed", nil, nil)
+	require.NoError(t, err)
+
+	claimed, err := store.ClaimSource(source.SourceID, "worker-a", time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, claimed.ClaimedBy)
+	assert.Equal(t, "worker-a", *claimed.ClaimedBy)
+	assert.Equal(t, "worker-a", ClaimHolder(*claimed, time.Now()))
+
+	_, err = store.ClaimSource(source.SourceID, "worker-b", time.Minute)
+	assert.ErrorIs(t, err, ErrSourceClaimed)
+
+	_, err = store.ClaimSource(source.SourceID, "worker-a", time.Minute)
+	assert.NoError(t, err)
+
+	// Releasing as another worker does nothing
+	require.NoError(t, store.ReleaseClaim(source.SourceID, "worker-b"))
+	_, err = store.ClaimSource(source.SourceID, "worker-b", time.Minute)
+	assert.ErrorIs(t, err, ErrSourceClaimed)
+
+	require.NoError(t, store.ReleaseClaim(source.SourceID, "worker-a"))
+	released, err := store.GetSource(source.SourceID)
+	require.NoError(t, err)
+	assert.Nil(t, released.ClaimedBy)
+	assert.Nil(t, released.ClaimExpiresAt)
+
+	_, err = store.ClaimSource(source.SourceID, "worker-b", time.Minute)
+	assert.NoError(t, err)
+
+	_, err = store.ClaimSource(uuid.New(), "worker-a", time.Minute)
+	assert.ErrorIs(t, err, ErrSourceNotFound)
+}
+
+// TestClaimSource_ExpiredLease verifies that an expired lease can be taken
+// over by another worker, after which the original holder cannot renew it
+func TestClaimSource_ExpiredLease(t *testing.T) {
+	store := createTestSourceStore(t)
+	source, err := store.CreateSource("rss", "http://example.com/feed", "Feed", nil, nil)
+	require.NoError(t, err)
+
+	_, err = store.ClaimSource(source.SourceID, "worker-a", -time.Second)
+	require.NoError(t, err)
+
+	claimed, err := store.ClaimSource(source.SourceID, "worker-b", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, "worker-b", *claimed.ClaimedBy)
+
+	assert.ErrorIs(t, store.RenewClaim(source.SourceID, "worker-a", time.Minute), ErrClaimLost)
+	assert.NoError(t, store.RenewClaim(source.SourceID, "worker-b", time.Minute))
+}
+
+// TestRenewClaim_ExtendsLease verifies that renewing moves the lease expiry
+// forward
+func TestRenewClaim_ExtendsLease(t *testing.T) {
+	store := createTestSourceStore(t)
+	source, err := store.CreateSource("rss", "http://example.com/feed", "Feed", nil, nil)
+	require.NoError(t, err)
+
+	claimed, err := store.ClaimSource(source.SourceID, "worker-a", time.Minute)
+	require.NoError(t, err)
+
+	require.NoError(t, store.RenewClaim(source.SourceID, "worker-a", time.Hour))
+	renewed, err := store.GetSource(source.SourceID)
+	require.NoError(t, err)
+	require.NotNil(t, renewed.ClaimExpiresAt)
+	assert.True(t, renewed.ClaimExpiresAt.After(*claimed.ClaimExpiresAt))
+}
+
+// TestReclaimExpired_ClearsOnlyExpired verifies that expired leases are
+// cleared and current ones are kept
+func TestReclaimExpired_ClearsOnlyExpired(t *testing.T) {
+	store := createTestSourceStore(t)
+	expired, err := store.CreateSource("rss", "http://example.com/1", "Expired", nil, nil)
+	require.NoError(t, err)
+	current, err := store.CreateSource("rss", "http://example.com/2", "Current", nil, nil)
+	require.NoError(t, err)
+
+	_, err = store.ClaimSource(expired.SourceID, "worker-a", 500*time.Millisecond)
+	require.NoError(t, err)
+	_, err = store.ClaimSource(current.SourceID, "worker-a", time.Hour)
+	require.NoError(t, err)
+
+	n, err := store.ReclaimExpired(time.Now().Add(time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	got, err := store.GetSource(expired.SourceID)
+	require.NoError(t, err)
+	assert.Nil(t, got.ClaimedBy)
+	assert.Equal(t, "", ClaimHolder(*got, time.Now()))
+
+	got, err = store.GetSource(current.SourceID)
+	require.NoError(t, err)
+	require.NotNil(t, got.ClaimedBy)
+	assert.Equal(t, "worker-a", *got.ClaimedBy)
+}
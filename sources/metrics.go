@@ -0,0 +1,85 @@
+package sources
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// SavedMetrics is a snapshot of a discovery service's cumulative metrics,
+// saved so that they survive restarts. Each worker keeps one snapshot per
+// day, the last one saved that day, so that days can be compared.
+type SavedMetrics struct {
+	Worker  string          // Name of the service that saved the metrics
+	Day     string          // UTC date the snapshot was saved, as YYYY-MM-DD
+	SavedAt time.Time       // When the snapshot was saved
+	Metrics json.RawMessage // The metrics, as encoded by the discovery service
+}
+
+// SaveMetrics stores metrics as worker's snapshot for the day of savedAt,
+// replacing any saved earlier that day.
+func (s *SourceStore) SaveMetrics(worker string, metrics json.RawMessage, savedAt time.Time) error {
+	_, err := s.db.Exec(`
+		INSERT INTO discovery_metrics (worker, day, saved_at, metrics) VALUES (?, ?, ?, ?)
+		ON CONFLICT (worker, day) DO UPDATE SET saved_at = excluded.saved_at, metrics = excluded.metrics`,
+		worker, savedAt.UTC().Format(time.DateOnly), formatTime(&savedAt), string(metrics),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save metrics: %w", err)
+	}
+	return nil
+}
+
+// LatestMetrics returns worker's most recent snapshot, or nil if it has
+// saved none.
+func (s *SourceStore) LatestMetrics(worker string) (*SavedMetrics, error) {
+	rows, err := s.db.Query(`
+		SELECT worker, day, saved_at, metrics FROM discovery_metrics
+		WHERE worker = ? ORDER BY day DESC LIMIT 1`, worker)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query metrics: %w", err)
+	}
+	saved, err := scanSavedMetrics(rows)
+	if err != nil || len(saved) == 0 {
+		return nil, err
+	}
+	return &saved[0], nil
+}
+
+// ListMetrics returns every worker's snapshots saved on or after the UTC
+// date of since, oldest day first. A zero since returns them all.
+func (s *SourceStore) ListMetrics(since time.Time) ([]SavedMetrics, error) {
+	day := ""
+	if !since.IsZero() {
+		day = since.UTC().Format(time.DateOnly)
+	}
+	rows, err := s.db.Query(`
+		SELECT worker, day, saved_at, metrics FROM discovery_metrics
+		WHERE day >= ? ORDER BY day, worker`, day)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query metrics: %w", err)
+	}
+	return scanSavedMetrics(rows)
+}
+
+// scanSavedMetrics reads the snapshots in rows and closes it.
+func scanSavedMetrics(rows *sql.Rows) ([]SavedMetrics, error) {
+	defer func() { _ = rows.Close() }()
+
+	var saved []SavedMetrics
+	for rows.Next() {
+		var m SavedMetrics
+		var savedAt, metrics string
+		if err := rows.Scan(&m.Worker, &m.Day, &savedAt, &metrics); err != nil {
+			return nil, fmt.Errorf("failed to scan metrics: %w", err)
+		}
+		m.SavedAt = parseTime(savedAt)
+		m.Metrics = json.RawMessage(metrics)
+		saved = append(saved, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read metrics: %w", err)
+	}
+	return saved, nil
+}
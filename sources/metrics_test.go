@@ -0,0 +1,46 @@
+package sources
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSaveMetrics_OnePerDay verifies that a worker's metrics saved on the
+// same day replace each other, that each day keeps its own, and that the
+// latest is returned per worker
+func TestSaveMetrics_OnePerDay(t *testing.T) {
+	store := createTestSourceStore(t)
+	day := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	latest, err := store.LatestMetrics("worker-a")
+	require.NoError(t, err)
+	assert.Nil(t, latest)
+
+	require.NoError(t, store.SaveMetrics("worker-a", json.RawMessage(`{"n":1}`), day))
+	require.NoError(t, store.SaveMetrics("worker-a", json.RawMessage(`{"n":2}`), day.Add(time.Hour)))
+	require.NoError(t, store.SaveMetrics("worker-a", json.RawMessage(`{"n":3}`), day.AddDate(0, 0, 1)))
+	require.NoError(t, store.SaveMetrics("worker-b", json.RawMessage(`{"n":4}`), day))
+
+	latest, err = store.LatestMetrics("worker-a")
+	require.NoError(t, err)
+	require.NotNil(t, latest)
+	assert.Equal(t, "2026-03-02", latest.Day)
+	assert.JSONEq(t, `{"n":3}`, string(latest.Metrics))
+
+	all, err := store.ListMetrics(time.Time{})
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	assert.Equal(t, "worker-a", all[0].Worker)
+	assert.JSONEq(t, `{"n":2}`, string(all[0].Metrics))
+	assert.True(t, day.Add(time.Hour).Equal(all[0].SavedAt))
+	assert.Equal(t, "worker-b", all[1].Worker)
+	assert.Equal(t, "2026-03-02", all[2].Day)
+
+	recent, err := store.ListMetrics(day.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	assert.Len(t, recent, 1)
+}
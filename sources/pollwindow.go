@@ -0,0 +1,326 @@
+package sources
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	// Polling windows name time zones, which minimal systems may not have
+	// installed
+	_ "time/tzdata"
+)
+
+// ErrInvalidPollWindow is returned for a polling window that is malformed
+// or never opens.
+var ErrInvalidPollWindow = errors.New("invalid polling window")
+
+// PollWindow limits when a source is fetched on schedule, so that
+// low-priority sources aren't fetched overnight and rate-limited sites are
+// only fetched during their quiet hours. It is either a daily window from
+// Start to End or a cron expression, in TimeZone.
+type PollWindow struct {
+	Start    string `json:"start,omitempty"`     // "HH:MM"; the window opens at this time each day
+	End      string `json:"end,omitempty"`       // "HH:MM"; before Start for a window that spans midnight
+	Cron     string `json:"cron,omitempty"`      // Five-field cron expression matching the minutes a fetch may start in
+	TimeZone string `json:"time_zone,omitempty"` // IANA name such as "Europe/London"; empty for local time
+}
+
+// IsZero reports whether w leaves the source's schedule unrestricted.
+func (w PollWindow) IsZero() bool {
+	return w == PollWindow{}
+}
+
+// Validate returns an error wrapping ErrInvalidPollWindow if w doesn't set
+// exactly one of a daily window or a cron expression, a time or the time
+// zone can't be parsed, or the cron expression never matches.
+func (w PollWindow) Validate() error {
+	if _, err := w.location(); err != nil {
+		return err
+	}
+
+	daily := w.Start != "" || w.End != ""
+	switch {
+	case daily && w.Cron != "":
+		return fmt.Errorf("%w: set a daily window or a cron expression, not both", ErrInvalidPollWindow)
+	case daily:
+		start, err := parseClock(w.Start)
+		if err != nil {
+			return err
+		}
+		end, err := parseClock(w.End)
+		if err != nil {
+			return err
+		}
+		if start == end {
+			return fmt.Errorf("%w: start and end must differ", ErrInvalidPollWindow)
+		}
+	case w.Cron != "":
+		schedule, err := parseCron(w.Cron)
+		if err != nil {
+			return err
+		}
+		if schedule.next(time.Now()).IsZero() {
+			return fmt.Errorf("%w: cron expression %q never matches", ErrInvalidPollWindow, w.Cron)
+		}
+	default:
+		return fmt.Errorf("%w: set a daily window or a cron expression", ErrInvalidPollWindow)
+	}
+	return nil
+}
+
+// Contains reports whether the window is open at t. An invalid window is
+// always open, so a bad setting can't stop a source from being fetched.
+func (w PollWindow) Contains(t time.Time) bool {
+	return w.Next(t).Equal(t)
+}
+
+// Next returns the earliest time at or after t that the window is open: t
+// itself if it is open then. It returns the zero time if the window never
+// opens, and t if the window is invalid.
+func (w PollWindow) Next(t time.Time) time.Time {
+	loc, err := w.location()
+	if err != nil {
+		return t
+	}
+	local := t.In(loc)
+
+	if w.Cron != "" {
+		schedule, err := parseCron(w.Cron)
+		if err != nil {
+			return t
+		}
+		next := schedule.next(local)
+		if next.IsZero() {
+			return next
+		}
+		return next.In(t.Location())
+	}
+
+	start, err := parseClock(w.Start)
+	if err != nil {
+		return t
+	}
+	end, err := parseClock(w.End)
+	if err != nil {
+		return t
+	}
+
+	now := time.Duration(local.Hour())*time.Hour + time.Duration(local.Minute())*time.Minute +
+		time.Duration(local.Second())*time.Second + time.Duration(local.Nanosecond())
+	open := (start < end && now >= start && now < end) ||
+		(start > end && (now >= start || now < end))
+	if open {
+		return t
+	}
+
+	// Closed, so the window next opens at today's start if that is still to
+	// come and tomorrow's otherwise
+	day := 0
+	if now >= start {
+		day = 1
+	}
+	y, m, d := local.Date()
+	opens := time.Date(y, m, d+day, int(start/time.Hour), int(start%time.Hour/time.Minute), 0, 0, loc)
+	return opens.In(t.Location())
+}
+
+// String describes w, such as "06:00-22:00 (Europe/London)".
+func (w PollWindow) String() string {
+	zone := w.TimeZone
+	if zone == "" {
+		zone = "local time"
+	}
+	if w.Cron != "" {
+		return fmt.Sprintf("cron %q (%s)", w.Cron, zone)
+	}
+	return fmt.Sprintf("%s-%s (%s)", w.Start, w.End, zone)
+}
+
+// location returns the time zone w is given in.
+func (w PollWindow) location() (*time.Location, error) {
+	if w.TimeZone == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(w.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unknown time zone %q", ErrInvalidPollWindow, w.TimeZone)
+	}
+	return loc, nil
+}
+
+// parseClock parses an "HH:MM" time of day as the time since midnight.
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("%w: time of day %q must be HH:MM", ErrInvalidPollWindow, s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// cronSchedule is a parsed cron expression. Each field is a bit set of the
+// values it matches.
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// domAny and dowAny record a day of month or day of week that starts
+	// with "*". When both
+	// day fields are restricted, a day matching either matches, as in cron.
+	domAny, dowAny bool
+}
+
+// cronField describes one field of a cron expression.
+type cronField struct {
+	name     string
+	min, max int
+	names    []string // Names for the values from min, if the field has them
+}
+
+var (
+	cronMinute = cronField{name: "minute", min: 0, max: 59}
+	cronHour   = cronField{name: "hour", min: 0, max: 23}
+	cronDom    = cronField{name: "day of month", min: 1, max: 31}
+	cronMonth  = cronField{name: "month", min: 1, max: 12,
+		names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}}
+	cronDow = cronField{name: "day of week", min: 0, max: 7,
+		names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}}
+)
+
+// parseCron parses a five-field cron expression: minute, hour, day of
+// month, month, and day of week. Fields take "*", values, ranges ("1-5"),
+// steps ("*/15", "8-18/2"), and lists of these ("1,15"); months and days of
+// week may also be given by their three-letter English names. Sunday is 0
+// or 7.
+func parseCron(expr string) (*cronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("%w: cron expression %q must have five fields", ErrInvalidPollWindow, expr)
+	}
+
+	var c cronSchedule
+	var err error
+	if c.minute, err = cronMinute.parse(fields[0]); err != nil {
+		return nil, err
+	}
+	if c.hour, err = cronHour.parse(fields[1]); err != nil {
+		return nil, err
+	}
+	if c.dom, err = cronDom.parse(fields[2]); err != nil {
+		return nil, err
+	}
+	if c.month, err = cronMonth.parse(fields[3]); err != nil {
+		return nil, err
+	}
+	if c.dow, err = cronDow.parse(fields[4]); err != nil {
+		return nil, err
+	}
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1 // Sunday
+	}
+	c.domAny = strings.HasPrefix(fields[2], "*")
+	c.dowAny = strings.HasPrefix(fields[4], "*")
+	return &c, nil
+}
+
+// parse parses one field of a cron expression into a bit set.
+func (f cronField) parse(s string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(s, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("%w: invalid step %q in cron %s field", ErrInvalidPollWindow, stepStr, f.name)
+			}
+			step = n
+		}
+
+		lo, hi := f.min, f.max
+		if rng != "*" {
+			loStr, hiStr, isRange := strings.Cut(rng, "-")
+			var err error
+			if lo, err = f.value(loStr); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if isRange {
+				if hi, err = f.value(hiStr); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				hi = f.max
+			}
+			if hi < lo {
+				return 0, fmt.Errorf("%w: range %q in cron %s field runs backward", ErrInvalidPollWindow, rng, f.name)
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+// value parses one value of the field, by number or name.
+func (f cronField) value(s string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return f.min + i, nil
+		}
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < f.min || n > f.max {
+		return 0, fmt.Errorf("%w: %q is not a valid cron %s", ErrInvalidPollWindow, s, f.name)
+	}
+	return n, nil
+}
+
+// matchesDay reports whether the schedule matches t's date.
+func (c *cronSchedule) matchesDay(t time.Time) bool {
+	dom := c.dom&(1<<t.Day()) != 0
+	dow := c.dow&(1<<int(t.Weekday())) != 0
+	if c.domAny || c.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// matches reports whether the schedule matches the minute t falls in.
+func (c *cronSchedule) matches(t time.Time) bool {
+	return c.month&(1<<int(t.Month())) != 0 && c.matchesDay(t) &&
+		c.hour&(1<<t.Hour()) != 0 && c.minute&(1<<t.Minute()) != 0
+}
+
+// cronSearchLimit bounds how far ahead next looks for a match; a schedule
+// that matches at all matches within it.
+const cronSearchLimit = 5 * 366 * 24 * time.Hour
+
+// next returns t if the schedule matches the minute t falls in, or else the
+// start of the next minute it matches, in t's location. It returns the zero
+// time if the schedule never matches.
+func (c *cronSchedule) next(t time.Time) time.Time {
+	if c.matches(t) {
+		return t
+	}
+
+	loc := t.Location()
+	limit := t.Add(cronSearchLimit)
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	for t.Before(limit) {
+		y, m, d := t.Date()
+		switch {
+		case c.month&(1<<int(m)) == 0:
+			t = time.Date(y, m+1, 1, 0, 0, 0, 0, loc)
+		case !c.matchesDay(t):
+			t = time.Date(y, m, d+1, 0, 0, 0, 0, loc)
+		case c.hour&(1<<t.Hour()) == 0:
+			t = time.Date(y, m, d, t.Hour()+1, 0, 0, 0, loc)
+		case c.minute&(1<<t.Minute()) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,99 @@
+package sources
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPollWindow_Daily verifies that a daily window is open between its
+// start and end in its time zone, including one that spans midnight, and
+// that it next opens at its start
+func TestPollWindow_Daily(t *testing.T) {
+	london, err := time.LoadLocation("Europe/London")
+	require.NoError(t, err)
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, time.July, day, hour, minute, 0, 0, london)
+	}
+
+	day := PollWindow{Start: "06:00", End: "22:00", TimeZone: "Europe/London"}
+	require.NoError(t, day.Validate())
+	assert.True(t, day.Contains(at(1, 6, 0)))
+	assert.True(t, day.Contains(at(1, 21, 59)))
+	assert.False(t, day.Contains(at(1, 22, 0)))
+	assert.True(t, day.Next(at(1, 23, 30)).Equal(at(2, 6, 0)))
+	assert.True(t, day.Next(at(1, 3, 0)).Equal(at(1, 6, 0)))
+
+	// The same window in UTC is an hour later in London in summer
+	assert.False(t, day.Contains(at(1, 5, 30).UTC()))
+	assert.True(t, day.Next(at(1, 4, 0).UTC()).Equal(at(1, 6, 0)))
+
+	night := PollWindow{Start: "22:00", End: "06:00", TimeZone: "Europe/London"}
+	require.NoError(t, night.Validate())
+	assert.True(t, night.Contains(at(1, 23, 0)))
+	assert.True(t, night.Contains(at(1, 5, 0)))
+	assert.False(t, night.Contains(at(1, 12, 0)))
+	assert.True(t, night.Next(at(1, 12, 0)).Equal(at(1, 22, 0)))
+}
+
+// TestPollWindow_Cron verifies that a cron window is open in the minutes
+// its expression matches and next opens at the first of them
+func TestPollWindow_Cron(t *testing.T) {
+	window := PollWindow{Cron: "*/30 9-17 * * mon-fri", TimeZone: "America/New_York"}
+	require.NoError(t, window.Validate())
+
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	friday := time.Date(2026, time.July, 3, 9, 30, 20, 0, newYork)
+	assert.True(t, window.Contains(friday))
+	assert.False(t, window.Contains(friday.Add(time.Minute)))
+	assert.True(t, window.Next(friday.Add(time.Minute)).Equal(time.Date(2026, time.July, 3, 10, 0, 0, 0, newYork)))
+
+	// Friday evening waits for Monday morning
+	evening := time.Date(2026, time.July, 3, 18, 0, 0, 0, newYork)
+	assert.True(t, window.Next(evening).Equal(time.Date(2026, time.July, 6, 9, 0, 0, 0, newYork)))
+
+	// With both day fields restricted, either matches
+	schedule, err := parseCron("0 0 1 * sun")
+	require.NoError(t, err)
+	assert.True(t, schedule.matches(time.Date(2026, time.July, 1, 0, 0, 0, 0, time.UTC)))  // A Wednesday
+	assert.True(t, schedule.matches(time.Date(2026, time.July, 5, 0, 0, 0, 0, time.UTC)))  // A Sunday
+	assert.False(t, schedule.matches(time.Date(2026, time.July, 6, 0, 0, 0, 0, time.UTC))) // Neither
+}
+
+// TestPollWindow_Validate verifies that malformed windows are rejected
+func TestPollWindow_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		window PollWindow
+		valid  bool
+	}{
+		{"daily", PollWindow{Start: "06:00", End: "22:00"}, true},
+		{"cron in a zone", PollWindow{Cron: "0 2-5 * * *", TimeZone: "Asia/Tokyo"}, true},
+		{"empty", PollWindow{TimeZone: "UTC"}, false},
+		{"both", PollWindow{Start: "06:00", End: "22:00", Cron: "* * * * *"}, false},
+		{"missing end", PollWindow{Start: "06:00"}, false},
+		{"bad time", PollWindow{Start: "6am", End: "22:00"}, false},
+		{"start is end", PollWindow{Start: "06:00", End: "06:00"}, false},
+		{"unknown zone", PollWindow{Start: "06:00", End: "22:00", TimeZone: "Mars/Olympus"}, false},
+		{"four fields", PollWindow{Cron: "0 6 * *"}, false},
+		{"bad value", PollWindow{Cron: "0 25 * * *"}, false},
+		{"backward range", PollWindow{Cron: "0 9-5 * * *"}, false},
+		{"bad step", PollWindow{Cron: "*/0 * * * *"}, false},
+		{"bad day name", PollWindow{Cron: "0 0 * * funday"}, false},
+		{"never matches", PollWindow{Cron: "0 0 30 feb *"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.window.Validate()
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidPollWindow)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package sources
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pevans/newsfed/sqldb/sqldbtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPostgres_SourceStore verifies the source store against PostgreSQL:
+// sources, duplicate detection, categories, claims, and error and sync
+// history. It is skipped unless a test database is configured.
+func TestPostgres_SourceStore(t *testing.T) {
+	dsn := sqldbtest.PostgresDSN(t)
+	store, err := NewSourceStore(dsn)
+	require.NoError(t, err)
+	defer func() { _ = store.Close() }()
+
+	now := time.Now()
+	source, err := store.CreateSource("website", "http://example.com/1", "Security", createTestScraperConfig(), &now)
+	require.NoError(t, err)
+
+	_, err = store.CreateSource("rss", "http://example.com/1", "Again", nil, &now)
+	assert.ErrorIs(t, err, ErrDuplicateURL)
+
+	retrieved, err := store.GetSource(source.SourceID)
+	require.NoError(t, err)
+	assert.Equal(t, source.ScraperConfig, retrieved.ScraperConfig)
+	require.NotNil(t, retrieved.EnabledAt)
+	assert.True(t, now.Truncate(0).Equal(*retrieved.EnabledAt))
+
+	category := "security"
+	require.NoError(t, store.UpdateSource(source.SourceID, SourceUpdate{Category: &category}))
+	require.NoError(t, store.CreateCategory("Security"))
+	filter := "SECURITY"
+	list, err := store.ListSources(SourceFilter{Category: &filter})
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+
+	categories, err := store.ListCategories()
+	require.NoError(t, err)
+	require.Len(t, categories, 1)
+	assert.Equal(t, 1, categories[0].SourceCount)
+
+	require.NoError(t, store.RecordError(source.SourceID, "boom", now))
+	errs, err := store.ListErrors(source.SourceID, 10)
+	require.NoError(t, err)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "boom", errs[0].Error)
+
+	require.NoError(t, store.RecordSyncAttempt(SyncAttempt{SourceID: source.SourceID, StartedAt: now, Duration: time.Second, ItemsDiscovered: 3}))
+	history, err := store.ListSyncHistory(source.SourceID, 10)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, 3, history[0].ItemsDiscovered)
+
+	_, err = store.ClaimSource(source.SourceID, "worker-a", time.Minute)
+	require.NoError(t, err)
+	_, err = store.ClaimSource(source.SourceID, "worker-b", time.Minute)
+	assert.ErrorIs(t, err, ErrSourceClaimed)
+	require.NoError(t, store.RenewClaim(source.SourceID, "worker-a", time.Minute))
+	n, err := store.ReclaimExpired(now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	require.NoError(t, store.DeleteCategory("security"))
+	require.NoError(t, store.DeleteSources([]uuid.UUID{source.SourceID}))
+	_, err = store.GetSource(source.SourceID)
+	assert.ErrorIs(t, err, ErrSourceNotFound)
+
+	// Reopening applies no migrations twice
+	reopened, err := NewSourceStore(dsn)
+	require.NoError(t, err)
+	require.NoError(t, reopened.Close())
+}
@@ -0,0 +1,166 @@
+package sources
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pevans/newsfed/audit"
+	"github.com/pevans/newsfed/secrets"
+	"github.com/pevans/newsfed/sqldb"
+)
+
+// ErrInvalidSession is returned for a session that can't be used.
+var ErrInvalidSession = errors.New("invalid session")
+
+// Session holds what a website source needs to be let past a login or
+// paywall: cookies sent with every request, a login form submitted before
+// each fetch, or both. Sessions hold credentials, so they are stored as
+// secrets and never returned with the source.
+type Session struct {
+	Cookies []SessionCookie `json:"cookies,omitempty"`
+	Login   *LoginForm      `json:"login,omitempty"`
+}
+
+// SessionCookie is a cookie sent to the source's site.
+type SessionCookie struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Domain string `json:"domain,omitempty"` // Also sent to this domain's subdomains; empty for only the source's host
+	Path   string `json:"path,omitempty"`   // Default: "/"
+}
+
+// LoginForm describes a login form to fill in and submit. The form's other
+// inputs, such as CSRF tokens, are submitted with the values they were
+// given, and the cookies the site sets are kept for the fetch.
+type LoginForm struct {
+	URL          string            `json:"url"`                     // Page the form is on
+	FormSelector string            `json:"form_selector,omitempty"` // CSS selector for the form; default: the first form with a password input
+	Fields       map[string]string `json:"fields"`                  // Input names and the values to submit, such as username and password
+}
+
+// Validate returns an error wrapping ErrInvalidSession if s sets neither
+// cookies nor a login form, or either is malformed.
+func (s Session) Validate() error {
+	if len(s.Cookies) == 0 && s.Login == nil {
+		return fmt.Errorf("%w: set cookies or a login form", ErrInvalidSession)
+	}
+	for _, c := range s.Cookies {
+		if c.Name == "" || strings.ContainsAny(c.Name, "=; \t") {
+			return fmt.Errorf("%w: cookie name %q", ErrInvalidSession, c.Name)
+		}
+		if strings.ContainsAny(c.Value, ";\r\n") {
+			return fmt.Errorf("%w: value of cookie %q", ErrInvalidSession, c.Name)
+		}
+	}
+	if s.Login != nil {
+		u, err := url.Parse(s.Login.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%w: login URL %q must be an http or https URL", ErrInvalidSession, s.Login.URL)
+		}
+		if len(s.Login.Fields) == 0 {
+			return fmt.Errorf("%w: login form needs fields to fill in", ErrInvalidSession)
+		}
+	}
+	return nil
+}
+
+// SessionSecret returns the name of the secret the source's session is
+// stored in.
+func SessionSecret(sourceID uuid.UUID) string {
+	return "session." + sourceID.String()
+}
+
+// SetSession stores session for the source in secretStore, replacing any
+// it had, and refers the source to it. A nil session removes it. The audit
+// log records that the session changed, but not what it holds.
+func (s *SourceStore) SetSession(sourceID uuid.UUID, session *Session, secretStore *secrets.Store) error {
+	name := SessionSecret(sourceID)
+	var ref any
+	if session != nil {
+		if err := session.Validate(); err != nil {
+			return err
+		}
+		data, err := json.Marshal(session)
+		if err != nil {
+			return fmt.Errorf("failed to marshal session: %w", err)
+		}
+		if !secretStore.HasMasterKey() {
+			return fmt.Errorf("%w: can't store session", secrets.ErrMasterKeyMissing)
+		}
+		if err := secretStore.Set(name, string(data)); err != nil {
+			return err
+		}
+		ref = secrets.RefPrefix + name
+	}
+
+	return s.inTx(func(tx *sqldb.Tx) error {
+		before, err := getSource(tx, sourceID)
+		if err != nil {
+			return err
+		}
+		now := time.Now().UTC()
+		if _, err := tx.Exec("UPDATE sources SET session = ?, updated_at = ? WHERE source_id = ?",
+			ref, formatTime(&now), sourceID.String()); err != nil {
+			return fmt.Errorf("failed to update session: %w", err)
+		}
+		if !before.HasSession && session == nil {
+			return nil
+		}
+		if session == nil {
+			if err := deleteSessionSecret(tx, sourceID); err != nil {
+				return err
+			}
+		}
+
+		change := audit.Change{}
+		if before.HasSession {
+			change.Old = "(encrypted)"
+		}
+		if session != nil {
+			change.New = "(encrypted)"
+		}
+		return audit.Record(tx, audit.SourceUpdated, sourceID.String(), map[string]audit.Change{"session": change})
+	})
+}
+
+// GetSession returns the source's session, read from secretStore, or nil
+// if it has none.
+func (s *SourceStore) GetSession(sourceID uuid.UUID, secretStore *secrets.Store) (*Session, error) {
+	var ref sql.NullString
+	err := s.db.QueryRow("SELECT session FROM sources WHERE source_id = ?", sourceID.String()).Scan(&ref)
+	if err == sql.ErrNoRows {
+		return nil, ErrSourceNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query session: %w", err)
+	}
+	if !ref.Valid {
+		return nil, nil
+	}
+
+	data, err := secretStore.Resolve(secrets.RefPrefix + SessionSecret(sourceID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session: %w", err)
+	}
+	var session Session
+	if err := json.Unmarshal([]byte(data), &session); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal session: %w", err)
+	}
+	return &session, nil
+}
+
+// deleteSessionSecret removes the secret holding the source's session
+// within tx, if there is one.
+func deleteSessionSecret(tx *sqldb.Tx, sourceID uuid.UUID) error {
+	err := secrets.DeleteTx(tx, SessionSecret(sourceID))
+	if errors.Is(err, secrets.ErrNotFound) {
+		return nil
+	}
+	return err
+}
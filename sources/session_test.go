@@ -0,0 +1,151 @@
+package sources
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pevans/newsfed/secrets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test helper: create a source store with a website source, and the
+// secrets in the same database that its session is kept in
+func createTestSessionSource(t *testing.T) (*SourceStore, *secrets.Store, Source) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	store, err := NewSourceStore(dbPath)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+	secretStore, err := secrets.Open(dbPath, "test master key")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = secretStore.Close() })
+
+	now := time.Now()
+	source, err := store.CreateSource("website", "https://example.com/news", "Example", createTestScraperConfig(), &now)
+	require.NoError(t, err)
+	return store, secretStore, *source
+}
+
+// TestSetSession_RoundTrip verifies that a stored session is returned, that
+// it is kept as a secret rather than in the clear, and that clearing it
+// leaves the source without one
+func TestSetSession_RoundTrip(t *testing.T) {
+	store, secretStore, source := createTestSessionSource(t)
+
+	session := &Session{
+		Cookies: []SessionCookie{{Name: "sid", Value: "abc123"}},
+		Login: &LoginForm{
+			URL:    "https://example.com/login",
+			Fields: map[string]string{"username": "reader", "password": "hunter2"},
+		},
+	}
+	require.NoError(t, store.SetSession(source.SourceID, session, secretStore))
+
+	got, err := store.GetSession(source.SourceID, secretStore)
+	require.NoError(t, err)
+	assert.Equal(t, session, got)
+
+	updated, err := store.GetSource(source.SourceID)
+	require.NoError(t, err)
+	assert.True(t, updated.HasSession)
+
+	var stored string
+	require.NoError(t, store.db.QueryRow("SELECT session FROM sources WHERE source_id = ?",
+		source.SourceID.String()).Scan(&stored))
+	assert.Equal(t, "secret:"+SessionSecret(source.SourceID), stored)
+	require.NoError(t, store.db.QueryRow("SELECT value FROM secrets WHERE name = ?",
+		SessionSecret(source.SourceID)).Scan(&stored))
+	assert.NotContains(t, stored, "hunter2")
+
+	require.NoError(t, store.SetSession(source.SourceID, nil, secretStore))
+	got, err = store.GetSession(source.SourceID, secretStore)
+	require.NoError(t, err)
+	assert.Nil(t, got)
+	updated, err = store.GetSource(source.SourceID)
+	require.NoError(t, err)
+	assert.False(t, updated.HasSession)
+	_, err = secretStore.Get(SessionSecret(source.SourceID))
+	assert.ErrorIs(t, err, secrets.ErrNotFound)
+}
+
+// TestGetSession_Unreadable verifies that a session can't be read without
+// the master key or moved to another source
+func TestGetSession_Unreadable(t *testing.T) {
+	store, secretStore, source := createTestSessionSource(t)
+	require.NoError(t, store.SetSession(source.SourceID, &Session{Cookies: []SessionCookie{{Name: "sid", Value: "1"}}}, secretStore))
+
+	_, err := store.GetSession(source.SourceID, nil)
+	assert.ErrorIs(t, err, secrets.ErrMasterKeyMissing)
+	assert.ErrorIs(t, store.SetSession(source.SourceID, &Session{Cookies: []SessionCookie{{Name: "sid", Value: "2"}}}, nil),
+		secrets.ErrMasterKeyMissing)
+
+	now := time.Now()
+	second, err := store.CreateSource("website", "https://example.com/other", "Other", createTestScraperConfig(), &now)
+	require.NoError(t, err)
+	_, err = store.db.Exec("UPDATE sources SET session = (SELECT session FROM sources WHERE source_id = ?) WHERE source_id = ?",
+		source.SourceID.String(), second.SourceID.String())
+	require.NoError(t, err)
+	_, err = store.GetSession(second.SourceID, secretStore)
+	assert.ErrorIs(t, err, secrets.ErrNotFound)
+}
+
+// TestSetSession_Audited verifies that session changes are recorded in the
+// audit log without what the session holds
+func TestSetSession_Audited(t *testing.T) {
+	store, secretStore, source := createTestSessionSource(t)
+	require.NoError(t, store.SetSession(source.SourceID, &Session{Cookies: []SessionCookie{{Name: "sid", Value: "secret"}}}, secretStore))
+
+	var changes sql.NullString
+	require.NoError(t, store.db.QueryRow("SELECT changes FROM audit_log WHERE target = ? ORDER BY id DESC LIMIT 1",
+		source.SourceID.String()).Scan(&changes))
+	assert.Contains(t, changes.String, `"new":"(encrypted)"`)
+	assert.NotContains(t, changes.String, "secret")
+}
+
+// TestDeleteSource_RemovesSession verifies that deleting a source deletes
+// the secret its session was kept in
+func TestDeleteSource_RemovesSession(t *testing.T) {
+	store, secretStore, source := createTestSessionSource(t)
+	require.NoError(t, store.SetSession(source.SourceID, &Session{Cookies: []SessionCookie{{Name: "sid", Value: "1"}}}, secretStore))
+
+	require.NoError(t, store.DeleteSources([]uuid.UUID{source.SourceID}))
+	_, err := secretStore.Get(SessionSecret(source.SourceID))
+	assert.ErrorIs(t, err, secrets.ErrNotFound)
+}
+
+// TestSession_Validate verifies that malformed sessions are rejected
+func TestSession_Validate(t *testing.T) {
+	login := func(url string, fields map[string]string) *LoginForm {
+		return &LoginForm{URL: url, Fields: fields}
+	}
+	fields := map[string]string{"password": "x"}
+
+	tests := []struct {
+		name    string
+		session Session
+		valid   bool
+	}{
+		{"cookie", Session{Cookies: []SessionCookie{{Name: "sid", Value: "1"}}}, true},
+		{"login", Session{Login: login("https://example.com/login", fields)}, true},
+		{"empty", Session{}, false},
+		{"unnamed cookie", Session{Cookies: []SessionCookie{{Value: "1"}}}, false},
+		{"cookie name with =", Session{Cookies: []SessionCookie{{Name: "a=b", Value: "1"}}}, false},
+		{"cookie value with ;", Session{Cookies: []SessionCookie{{Name: "sid", Value: "1; admin=1"}}}, false},
+		{"relative login URL", Session{Login: login("/login", fields)}, false},
+		{"login without fields", Session{Login: login("https://example.com/login", nil)}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.session.Validate()
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidSession)
+			}
+		})
+	}
+}
@@ -1,35 +1,54 @@
 package sources
 
 import (
+	"crypto/tls"
 	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
+	"net/url"
 	"os"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/pevans/newsfed/audit"
 	"github.com/pevans/newsfed/scraper"
+	"github.com/pevans/newsfed/secrets"
+	"github.com/pevans/newsfed/sqldb"
 )
 
 // Custom errors for source operations
 var (
 	ErrSourceNotFound    = errors.New("source not found")
 	ErrDuplicateURL      = errors.New("source with this URL already exists")
-	ErrInvalidSourceType = errors.New("source_type must be rss, atom, or website")
+	ErrInvalidSourceType = errors.New("source_type must be rss, atom, json, website, or hackernews")
+	ErrCategoryNotFound  = errors.New("category not found")
+	ErrInvalidCategory   = errors.New("category name must not be empty")
+	ErrSourceClaimed     = errors.New("source is claimed by another worker")
+	ErrClaimLost         = errors.New("claim on source is no longer held")
+	ErrInvalidProxyURL   = errors.New("proxy URL must be an http, https, socks5, or socks5h URL with a host")
+	ErrInvalidTLSVersion = errors.New("minimum TLS version must be 1.0, 1.1, 1.2, or 1.3")
+	ErrAmbiguousSourceID = errors.New("source ID prefix matches more than one source")
+	ErrInvalidSourceID   = errors.New("invalid source ID")
+	ErrInvalidLanguage   = errors.New("language must be a two-letter ISO 639-1 code such as en")
+	ErrInvalidIngestRule = errors.New("invalid ingest rule")
+	ErrInvalidTimeZone   = errors.New("time zone must be an IANA name such as Europe/London")
 )
 
-// SourceStore manages source configurations using SQLite.
+// SourceStore manages source configurations in SQLite or PostgreSQL.
 type SourceStore struct {
-	db *sql.DB
+	db *sqldb.DB
 }
 
 // Source represents a news source configuration.
 type Source struct {
 	SourceID        uuid.UUID              `json:"source_id"`
-	SourceType      string                 `json:"source_type"` // "rss", "atom", "website"
+	SourceType      string                 `json:"source_type"` // "rss", "atom", "json", "website", "hackernews"
 	URL             string                 `json:"url"`
 	Name            string                 `json:"name"`
 	EnabledAt       *time.Time             `json:"enabled_at,omitempty"`
@@ -37,11 +56,184 @@ type Source struct {
 	UpdatedAt       time.Time              `json:"updated_at"`
 	PollingInterval *string                `json:"polling_interval,omitempty"`
 	LastFetchedAt   *time.Time             `json:"last_fetched_at,omitempty"`
+	NextFetchAt     *time.Time             `json:"next_fetch_at,omitempty"`
 	LastModified    *string                `json:"last_modified,omitempty"`
 	ETag            *string                `json:"etag,omitempty"`
 	FetchErrorCount int                    `json:"fetch_error_count"`
 	LastError       *string                `json:"last_error,omitempty"`
 	ScraperConfig   *scraper.ScraperConfig `json:"scraper_config,omitempty"`
+	UserAgent       *string                `json:"user_agent,omitempty"`
+	HTTPHeaders     map[string]string      `json:"http_headers,omitempty"`
+	Category        *string                `json:"category,omitempty"`
+	HackerNews      *HackerNewsConfig      `json:"hackernews_config,omitempty"`
+	ProxyURL        *string                `json:"proxy_url,omitempty"`
+	TLS             *TLSConfig             `json:"tls_config,omitempty"`
+	Language        *string                `json:"language,omitempty"` // Expected ISO 639-1 language of the source's items
+	ClaimedBy       *string                `json:"claimed_by,omitempty"`
+	ClaimExpiresAt  *time.Time             `json:"claim_expires_at,omitempty"`
+	ReprobeAt       *time.Time             `json:"reprobe_at,omitempty"` // When an auto-disabled source is next tried again
+	ReprobeCount    int                    `json:"reprobe_count,omitempty"`
+	IngestRules     *IngestRules           `json:"ingest_rules,omitempty"`
+	PollWindow      *PollWindow            `json:"poll_window,omitempty"`     // When the source may be fetched on schedule
+	HasSession      bool                   `json:"has_session,omitempty"`     // Whether a Session is stored; see SetSession
+	TimeZone        *string                `json:"time_zone,omitempty"`       // IANA time zone of published dates that don't give one
+	PostingCadence  *string                `json:"posting_cadence,omitempty"` // Average time between the source's items, as a duration
+	FetchBudget     *FetchBudget           `json:"fetch_budget,omitempty"`    // What one sync of the source may spend
+}
+
+// RedactedValue stands in for a credential in a redacted source.
+const RedactedValue = "(set)"
+
+// Redacted returns a copy of the source that is safe to show: each HTTP
+// header value, which may hold a credential such as an Authorization token,
+// is replaced with RedactedValue, and the proxy URL's password is masked.
+// Values that only name a secret are left as they are.
+func (s Source) Redacted() Source {
+	if len(s.HTTPHeaders) > 0 {
+		s.HTTPHeaders = maps.Clone(s.HTTPHeaders)
+		for name, value := range s.HTTPHeaders {
+			s.HTTPHeaders[name] = redactValue(value)
+		}
+	}
+	if s.ProxyURL != nil {
+		if _, ok := secrets.Ref(*s.ProxyURL); !ok {
+			proxy := RedactedValue
+			if u, err := url.Parse(*s.ProxyURL); err == nil {
+				proxy = u.Redacted()
+			}
+			s.ProxyURL = &proxy
+		}
+	}
+	return s
+}
+
+// redactValue returns RedactedValue in place of a credential, or value
+// itself if it only refers to a secret by name.
+func redactValue(value string) string {
+	if _, ok := secrets.Ref(value); ok {
+		return value
+	}
+	return RedactedValue
+}
+
+// HackerNewsConfig selects which stories a "hackernews" source discovers.
+type HackerNewsConfig struct {
+	StoryType string `json:"story_type"`          // "top", "new", or "best"
+	MinScore  int    `json:"min_score,omitempty"` // Stories with fewer points are skipped
+}
+
+// TLSConfig customizes how TLS connections to a source are verified.
+type TLSConfig struct {
+	CAFile             string `json:"ca_file,omitempty"`              // PEM bundle of CAs trusted in addition to the system ones
+	InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty"` // Accept any certificate
+	MinVersion         string `json:"min_version,omitempty"`          // "1.0", "1.1", "1.2", or "1.3"
+}
+
+// IngestRules filter the items a source discovers before they are added to
+// the feed. Items that a rule rejects are skipped as if the source had never
+// published them.
+type IngestRules struct {
+	MaxItems         int      `json:"max_items,omitempty"`          // New items added per sync; 0 means no limit
+	IncludeURLs      []string `json:"include_urls,omitempty"`       // Regular expressions; if set, an item's URL must match one
+	ExcludeURLs      []string `json:"exclude_urls,omitempty"`       // Regular expressions; items whose URL matches one are skipped
+	MinContentLength int      `json:"min_content_length,omitempty"` // Items whose summary has fewer characters are skipped
+	IncludeKeywords  []string `json:"include_keywords,omitempty"`   // If set, an item's title must contain one (ignoring case)
+	ExcludeKeywords  []string `json:"exclude_keywords,omitempty"`   // Items whose title contains one (ignoring case) are skipped
+	MaxAge           string   `json:"max_age,omitempty"`            // Items published longer ago are skipped; see ParseMaxAge
+	Dedup            string   `json:"dedup,omitempty"`              // Which items new items are deduplicated against; see DedupGlobal
+}
+
+// The dedup policies of IngestRules. An empty policy is DedupGlobal.
+const (
+	DedupGlobal = "global" // Against every item in the feed
+	DedupSource = "source" // Only against the source's own items
+	DedupOff    = "off"    // Only against the source's own copy of the same item
+)
+
+// IsZero reports whether r lets every item through.
+func (r IngestRules) IsZero() bool {
+	return r.MaxItems == 0 && r.MinContentLength == 0 && r.MaxAge == "" && r.Dedup == "" &&
+		len(r.IncludeURLs) == 0 && len(r.ExcludeURLs) == 0 &&
+		len(r.IncludeKeywords) == 0 && len(r.ExcludeKeywords) == 0
+}
+
+// Validate returns an error wrapping ErrInvalidIngestRule if a limit is
+// negative, a URL pattern is not a valid regular expression, a keyword is
+// blank, the maximum age can't be parsed, or the dedup policy is unknown.
+func (r IngestRules) Validate() error {
+	if r.MaxItems < 0 {
+		return fmt.Errorf("%w: max items must not be negative", ErrInvalidIngestRule)
+	}
+	if r.MinContentLength < 0 {
+		return fmt.Errorf("%w: minimum content length must not be negative", ErrInvalidIngestRule)
+	}
+	for _, pattern := range slices.Concat(r.IncludeURLs, r.ExcludeURLs) {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("%w: URL pattern %q: %v", ErrInvalidIngestRule, pattern, err)
+		}
+	}
+	for _, keyword := range slices.Concat(r.IncludeKeywords, r.ExcludeKeywords) {
+		if strings.TrimSpace(keyword) == "" {
+			return fmt.Errorf("%w: keywords must not be blank", ErrInvalidIngestRule)
+		}
+	}
+	if r.MaxAge != "" {
+		if _, err := ParseMaxAge(r.MaxAge); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidIngestRule, err)
+		}
+	}
+	switch r.Dedup {
+	case "", DedupGlobal, DedupSource, DedupOff:
+	default:
+		return fmt.Errorf("%w: dedup must be %s, %s, or %s", ErrInvalidIngestRule, DedupGlobal, DedupSource, DedupOff)
+	}
+	return nil
+}
+
+// ParseMaxAge parses the age past which items are skipped. In addition to
+// the units accepted by time.ParseDuration, a whole number of days or weeks
+// may be given with a "d" or "w" suffix (for example, "365d").
+func ParseMaxAge(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			count, err := strconv.Atoi(n)
+			if err != nil || count <= 0 {
+				return 0, fmt.Errorf("invalid max age: %s", s)
+			}
+			return time.Duration(count) * unit, nil
+		}
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid max age: %s", s)
+	}
+	return d, nil
+}
+
+// IsZero reports whether c leaves TLS verification at its defaults.
+func (c TLSConfig) IsZero() bool {
+	return c == TLSConfig{}
+}
+
+// Version returns the crypto/tls constant for MinVersion, or 0 if it is
+// unset.
+func (c TLSConfig) Version() (uint16, error) {
+	switch c.MinVersion {
+	case "":
+		return 0, nil
+	case "1.0":
+		return tls.VersionTLS10, nil
+	case "1.1":
+		return tls.VersionTLS11, nil
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, ErrInvalidTLSVersion
+	}
 }
 
 // IsEnabled returns true if the source is currently enabled.
@@ -51,36 +243,59 @@ func (s *Source) IsEnabled() bool {
 
 // SourceUpdate represents fields that can be updated on a source.
 type SourceUpdate struct {
-	Name            *string
-	URL             *string
-	EnabledAt       *time.Time
-	ClearEnabledAt  bool // Set to true to set enabled_at to NULL
-	PollingInterval *string
-	ScraperConfig   *scraper.ScraperConfig
-	LastFetchedAt   *time.Time
-	LastModified    *string
-	ETag            *string
-	FetchErrorCount *int
-	LastError       *string
+	Name             *string
+	URL              *string
+	EnabledAt        *time.Time
+	ClearEnabledAt   bool // Set to true to set enabled_at to NULL
+	PollingInterval  *string
+	ScraperConfig    *scraper.ScraperConfig
+	LastFetchedAt    *time.Time
+	NextFetchAt      *time.Time
+	ClearNextFetchAt bool // Set to true to set next_fetch_at to NULL
+	LastModified     *string
+	ETag             *string
+	FetchErrorCount  *int
+	LastError        *string
+	UserAgent        *string           // Empty string clears the custom User-Agent
+	HTTPHeaders      map[string]string // Replaces all headers; empty map clears them
+	Category         *string           // Empty string removes the source from its category
+	HackerNews       *HackerNewsConfig
+	ProxyURL         *string    // Empty string clears the proxy
+	TLS              *TLSConfig // Replaces the TLS settings; the zero value clears them
+	Language         *string    // Empty string clears the expected language
+	ReprobeAt        *time.Time
+	ClearReprobeAt   bool // Set to true to stop re-probing a disabled source
+	ReprobeCount     *int
+	IngestRules      *IngestRules // Replaces the ingest rules; the zero value clears them
+	PollWindow       *PollWindow  // Replaces the polling window; the zero value clears it
+	TimeZone         *string      // Empty string clears the time zone
+	PostingCadence   *string
+	FetchBudget      *FetchBudget // Replaces the fetch budget; the zero value clears it
 }
 
 // SourceFilter represents filtering options for listing sources.
 type SourceFilter struct {
-	Type    *string // Filter by source_type
-	Enabled *bool   // Filter by enabled status
-	Limit   int     // Pagination limit
-	Offset  int     // Pagination offset
+	Type     *string // Filter by source_type
+	Enabled  *bool   // Filter by enabled status
+	Failing  bool    // Only sources whose last fetch failed
+	Category *string // Filter by category, ignoring case; "" matches uncategorized
+	Limit    int     // Pagination limit
+	Offset   int     // Pagination offset
 }
 
-// NewSourceStore creates a new source store with the given database path.
+// NewSourceStore creates a new source store with the given database path,
+// or a postgres:// DSN.
 func NewSourceStore(dbPath string) (*SourceStore, error) {
 	// Check if this is a fresh database creation
-	_, statErr := os.Stat(dbPath)
-	isNew := os.IsNotExist(statErr)
+	isNew := false
+	if !sqldb.IsPostgres(dbPath) && !sqldb.IsMemory(dbPath) {
+		_, statErr := os.Stat(dbPath)
+		isNew = os.IsNotExist(statErr)
+	}
 
-	db, err := sql.Open("sqlite3", dbPath)
+	db, err := sqldb.Open(dbPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open database: %w", err)
+		return nil, err
 	}
 
 	store := &SourceStore{db: db}
@@ -107,9 +322,161 @@ type SourceError struct {
 	OccurredAt time.Time `json:"occurred_at"`
 }
 
-// initSchema creates the sources table if it doesn't exist.
-func (s *SourceStore) initSchema() error {
-	schema := `
+// Category is a named group of sources, such as "security" or "golang".
+type Category struct {
+	Name        string    `json:"name"`
+	CreatedAt   time.Time `json:"created_at"`
+	SourceCount int       `json:"source_count"`
+}
+
+// SyncAttempt records the outcome of a single fetch of a source. Error is nil
+// when the fetch succeeded. Reprobe marks a fetch of an auto-disabled source
+// to see whether it has recovered.
+type SyncAttempt struct {
+	SourceID        uuid.UUID     `json:"source_id"`
+	StartedAt       time.Time     `json:"started_at"`
+	Duration        time.Duration `json:"duration"`
+	ItemsDiscovered int           `json:"items_discovered"`
+	Error           *string       `json:"error,omitempty"`
+	Reprobe         bool          `json:"reprobe,omitempty"`
+	// DiscoveryLag is the average time between the publication and
+	// discovery of the items found; nil if none had a date to measure
+	DiscoveryLag *time.Duration `json:"discovery_lag,omitempty"`
+}
+
+// SyncStats summarizes a source's sync attempts over a number of days.
+type SyncStats struct {
+	Attempts        int   `json:"attempts"`
+	Failures        int   `json:"failures"`
+	ItemsDiscovered int   `json:"items_discovered"`
+	DailyItems      []int `json:"daily_items"` // New items found each day, oldest first, ending today
+}
+
+// ItemsPerFetch returns the average number of new items found by a
+// successful attempt, or zero if none succeeded.
+func (s SyncStats) ItemsPerFetch() float64 {
+	if succeeded := s.Attempts - s.Failures; succeeded > 0 {
+		return float64(s.ItemsDiscovered) / float64(succeeded)
+	}
+	return 0
+}
+
+// ErrorRate returns the fraction of attempts that failed, or zero if none
+// were made.
+func (s SyncStats) ErrorRate() float64 {
+	if s.Attempts == 0 {
+		return 0
+	}
+	return float64(s.Failures) / float64(s.Attempts)
+}
+
+// postgresMigrations is the PostgreSQL schema, as migrations applied in
+// order by sqldb. Timestamps are stored as RFC 3339 text, as in SQLite, with
+// byte-wise collation so that they sort the same way.
+var postgresMigrations = []string{
+	`
+	CREATE TABLE sources (
+		source_id TEXT PRIMARY KEY,
+		source_type TEXT NOT NULL,
+		url TEXT NOT NULL UNIQUE,
+		name TEXT NOT NULL,
+		enabled_at TEXT COLLATE "C",
+		created_at TEXT COLLATE "C" NOT NULL,
+		updated_at TEXT COLLATE "C" NOT NULL,
+		polling_interval TEXT,
+		last_fetched_at TEXT COLLATE "C",
+		last_modified TEXT,
+		etag TEXT,
+		fetch_error_count INTEGER DEFAULT 0,
+		last_error TEXT,
+		scraper_config TEXT,
+		user_agent TEXT,
+		http_headers TEXT,
+		next_fetch_at TEXT COLLATE "C",
+		category TEXT,
+		hackernews_config TEXT
+	);
+
+	CREATE TABLE categories (
+		name TEXT PRIMARY KEY,
+		created_at TEXT NOT NULL
+	);
+	CREATE UNIQUE INDEX categories_name_lower ON categories (lower(name));
+
+	CREATE TABLE source_errors (
+		id BIGSERIAL PRIMARY KEY,
+		source_id TEXT NOT NULL REFERENCES sources(source_id) ON DELETE CASCADE,
+		error TEXT NOT NULL,
+		occurred_at TEXT COLLATE "C" NOT NULL
+	);
+
+	CREATE TABLE sync_history (
+		id BIGSERIAL PRIMARY KEY,
+		source_id TEXT NOT NULL REFERENCES sources(source_id) ON DELETE CASCADE,
+		started_at TEXT COLLATE "C" NOT NULL,
+		duration_ms INTEGER NOT NULL,
+		items_discovered INTEGER NOT NULL DEFAULT 0,
+		error TEXT
+	);
+	`,
+	`
+	ALTER TABLE sources ADD COLUMN claimed_by TEXT;
+	ALTER TABLE sources ADD COLUMN claim_expires_at TEXT COLLATE "C";
+	`,
+	`ALTER TABLE sources ADD COLUMN proxy_url TEXT`,
+	`ALTER TABLE sources ADD COLUMN tls_config TEXT`,
+	`ALTER TABLE sources ADD COLUMN language TEXT`,
+	`
+	ALTER TABLE sources ADD COLUMN reprobe_at TEXT COLLATE "C";
+	ALTER TABLE sources ADD COLUMN reprobe_count INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE sync_history ADD COLUMN reprobe BOOLEAN NOT NULL DEFAULT FALSE;
+	`,
+	`ALTER TABLE sources ADD COLUMN ingest_rules TEXT`,
+	`ALTER TABLE sources ADD COLUMN poll_window TEXT`,
+	`ALTER TABLE sources ADD COLUMN session TEXT`,
+	`ALTER TABLE sources ADD COLUMN time_zone TEXT`,
+	`ALTER TABLE sync_history ADD COLUMN discovery_lag_ms BIGINT`,
+	`ALTER TABLE sources ADD COLUMN posting_cadence TEXT`,
+	`ALTER TABLE sources ADD COLUMN fetch_budget TEXT`,
+	`
+	CREATE TABLE discovery_metrics (
+		worker TEXT NOT NULL,
+		day TEXT COLLATE "C" NOT NULL,
+		saved_at TEXT COLLATE "C" NOT NULL,
+		metrics TEXT NOT NULL,
+		PRIMARY KEY (worker, day)
+	)
+	`,
+}
+
+// SchemaVersion returns the version of the database's sources schema and
+// the latest version this build knows, for newsfed doctor. A version above
+// latest means a newer newsfed has upgraded the database.
+func (s *SourceStore) SchemaVersion() (version, latest int, err error) {
+	status, err := SchemaStatus(s.db)
+	return status.Version, status.Latest, err
+}
+
+// SchemaStatus returns how far the sources schema of db has been migrated,
+// without migrating it, for newsfed migrate-db status.
+func SchemaStatus(db *sqldb.DB) (sqldb.SchemaStatus, error) {
+	return db.SchemaStatus("sources", len(migrationsFor(db.Dialect)))
+}
+
+// migrationsFor returns the sources migrations for dialect.
+func migrationsFor(dialect sqldb.Dialect) []string {
+	if dialect == sqldb.Postgres {
+		return postgresMigrations
+	}
+	return sqliteMigrations
+}
+
+// sqliteMigrations are the SQLite sources schema, applied in order by
+// initSchema. The first is the schema as it stood when SQLite databases
+// began to be versioned; like postgresMigrations, new ones must only ever
+// be appended.
+var sqliteMigrations = []string{
+	`
 	CREATE TABLE IF NOT EXISTS sources (
 		source_id TEXT PRIMARY KEY,
 		source_type TEXT NOT NULL,
@@ -124,7 +491,29 @@ func (s *SourceStore) initSchema() error {
 		etag TEXT,
 		fetch_error_count INTEGER DEFAULT 0,
 		last_error TEXT,
-		scraper_config TEXT
+		scraper_config TEXT,
+		user_agent TEXT,
+		http_headers TEXT,
+		next_fetch_at TEXT,
+		category TEXT,
+		hackernews_config TEXT,
+		claimed_by TEXT,
+		claim_expires_at TEXT,
+		proxy_url TEXT,
+		tls_config TEXT,
+		language TEXT,
+		reprobe_at TEXT,
+		reprobe_count INTEGER NOT NULL DEFAULT 0,
+		ingest_rules TEXT,
+		poll_window TEXT,
+		session TEXT,
+		time_zone TEXT,
+		posting_cadence TEXT
+	);
+
+	CREATE TABLE IF NOT EXISTS categories (
+		name TEXT PRIMARY KEY COLLATE NOCASE,
+		created_at TEXT NOT NULL
 	);
 
 	CREATE TABLE IF NOT EXISTS source_errors (
@@ -134,10 +523,130 @@ func (s *SourceStore) initSchema() error {
 		occurred_at TEXT NOT NULL,
 		FOREIGN KEY (source_id) REFERENCES sources(source_id) ON DELETE CASCADE
 	);
+
+	CREATE TABLE IF NOT EXISTS sync_history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		source_id TEXT NOT NULL,
+		started_at TEXT NOT NULL,
+		duration_ms INTEGER NOT NULL,
+		items_discovered INTEGER NOT NULL DEFAULT 0,
+		error TEXT,
+		reprobe INTEGER NOT NULL DEFAULT 0,
+		discovery_lag_ms INTEGER,
+		FOREIGN KEY (source_id) REFERENCES sources(source_id) ON DELETE CASCADE
+	);
+	`,
+	`ALTER TABLE sources ADD COLUMN fetch_budget TEXT`,
 	`
+	CREATE TABLE IF NOT EXISTS discovery_metrics (
+		worker TEXT NOT NULL,
+		day TEXT NOT NULL,
+		saved_at TEXT NOT NULL,
+		metrics TEXT NOT NULL,
+		PRIMARY KEY (worker, day)
+	)
+	`,
+}
+
+// initSchema creates the sources tables, or brings them up to date, when
+// the store is opened.
+func (s *SourceStore) initSchema() error {
+	if err := audit.InitSchema(s.db); err != nil {
+		return err
+	}
+	if s.db.Dialect == sqldb.SQLite {
+		if err := s.upgradeUnversioned(); err != nil {
+			return err
+		}
+	}
+	return s.db.Migrate("sources", migrationsFor(s.db.Dialect))
+}
 
-	_, err := s.db.Exec(schema)
-	return err
+// upgradeUnversioned brings a SQLite database made before its schema was
+// versioned up to the first migration, by adding the columns introduced
+// since the tables were created, so that the migrations can take over from
+// there. It does nothing to a new or versioned database.
+func (s *SourceStore) upgradeUnversioned() error {
+	version, err := s.db.SchemaVersion("sources")
+	if err != nil || version > 0 {
+		return err
+	}
+	exists, err := s.db.HasTable("sources")
+	if err != nil || !exists {
+		return err
+	}
+
+	// Create any tables the database predates, then the columns
+	if _, err := s.db.Exec(sqliteMigrations[0]); err != nil {
+		return err
+	}
+	if err := s.addMissingColumns("sync_history", map[string]string{
+		"reprobe":          "INTEGER NOT NULL DEFAULT 0",
+		"discovery_lag_ms": "INTEGER",
+	}); err != nil {
+		return err
+	}
+	return s.addMissingColumns("sources", map[string]string{
+		"user_agent":        "TEXT",
+		"http_headers":      "TEXT",
+		"next_fetch_at":     "TEXT",
+		"category":          "TEXT",
+		"hackernews_config": "TEXT",
+		"claimed_by":        "TEXT",
+		"claim_expires_at":  "TEXT",
+		"proxy_url":         "TEXT",
+		"tls_config":        "TEXT",
+		"language":          "TEXT",
+		"reprobe_at":        "TEXT",
+		"reprobe_count":     "INTEGER NOT NULL DEFAULT 0",
+		"ingest_rules":      "TEXT",
+		"poll_window":       "TEXT",
+		"session":           "TEXT",
+		"time_zone":         "TEXT",
+		"posting_cadence":   "TEXT",
+	})
+}
+
+// addMissingColumns adds each column that the table does not already have.
+func (s *SourceStore) addMissingColumns(table string, columns map[string]string) error {
+	rows, err := s.db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return err
+	}
+	existing := map[string]bool{}
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			_ = rows.Close()
+			return err
+		}
+		existing[name] = true
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for name, definition := range columns {
+		if existing[name] {
+			continue
+		}
+		if _, err := s.db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, name, definition)); err != nil {
+			return fmt.Errorf("failed to add column %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Ping checks that the metadata database can be queried.
+func (s *SourceStore) Ping() error {
+	var n int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM sources").Scan(&n); err != nil {
+		return fmt.Errorf("metadata database is not reachable: %w", err)
+	}
+	return nil
 }
 
 // Close closes the database connection.
@@ -152,7 +661,9 @@ func (s *SourceStore) CreateSource(
 	enabledAt *time.Time,
 ) (*Source, error) {
 	// Validate source type
-	if sourceType != "rss" && sourceType != "atom" && sourceType != "website" {
+	switch sourceType {
+	case "rss", "atom", "json", "website", "hackernews":
+	default:
 		return nil, ErrInvalidSourceType
 	}
 
@@ -188,23 +699,28 @@ func (s *SourceStore) CreateSource(
 		) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	_, err := s.db.Exec(query,
-		source.SourceID.String(),
-		source.SourceType,
-		source.URL,
-		source.Name,
-		formatTime(source.EnabledAt),
-		formatTime(&source.CreatedAt),
-		formatTime(&source.UpdatedAt),
-		scraperConfigJSON,
-	)
-	if err != nil {
-		// Check for duplicate URL constraint violation
-		if strings.Contains(err.Error(), "UNIQUE constraint") ||
-			strings.Contains(err.Error(), "unique constraint") {
-			return nil, ErrDuplicateURL
+	err := s.inTx(func(tx *sqldb.Tx) error {
+		_, err := tx.Exec(query,
+			source.SourceID.String(),
+			source.SourceType,
+			source.URL,
+			source.Name,
+			formatTime(source.EnabledAt),
+			formatTime(&source.CreatedAt),
+			formatTime(&source.UpdatedAt),
+			scraperConfigJSON,
+		)
+		if err != nil {
+			// Check for duplicate URL constraint violation
+			if sqldb.IsUniqueViolation(err) {
+				return ErrDuplicateURL
+			}
+			return fmt.Errorf("failed to insert source: %w", err)
 		}
-		return nil, fmt.Errorf("failed to insert source: %w", err)
+		return recordChange(tx, audit.SourceCreated, nil, source)
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return source, nil
@@ -212,25 +728,74 @@ func (s *SourceStore) CreateSource(
 
 // GetSource retrieves a source by ID.
 func (s *SourceStore) GetSource(sourceID uuid.UUID) (*Source, error) {
-	query := `
-		SELECT source_id, source_type, url, name, enabled_at,
-		       created_at, updated_at, polling_interval, last_fetched_at,
-		       last_modified, etag, fetch_error_count, last_error, scraper_config
-		FROM sources
-		WHERE source_id = ?
-	`
+	return getSource(s.db, sourceID)
+}
 
-	var sourceIDStr, sourceType, url, name, createdAtStr, updatedAtStr string
-	var enabledAtStr, pollingInterval, lastFetchedAtStr, lastModified, etag, lastError, scraperConfigJSON sql.NullString
-	var fetchErrorCount int
+// minSourceIDPrefix is the shortest source ID prefix ResolveSourceID looks
+// up.
+const minSourceIDPrefix = 4
+
+// ResolveSourceID returns the ID of the source whose ID is s, or starts with
+// s, so that a source can be named by the first few characters of its ID. A
+// trailing "..." is ignored, as compact listings print IDs cut short with
+// one. A prefix matching no source returns ErrSourceNotFound, one matching
+// several ErrAmbiguousSourceID, and one that is too short or not made of hex
+// digits and dashes ErrInvalidSourceID.
+func (s *SourceStore) ResolveSourceID(str string) (uuid.UUID, error) {
+	if id, err := uuid.Parse(str); err == nil {
+		return id, nil
+	}
 
-	err := s.db.QueryRow(query, sourceID.String()).Scan(
-		&sourceIDStr, &sourceType, &url, &name,
-		&enabledAtStr, &createdAtStr, &updatedAtStr,
-		&pollingInterval, &lastFetchedAtStr, &lastModified,
-		&etag, &fetchErrorCount, &lastError, &scraperConfigJSON,
-	)
+	prefix := strings.ToLower(strings.TrimSuffix(strings.TrimSpace(str), "..."))
+	if len(prefix) < minSourceIDPrefix || len(prefix) > 36 {
+		return uuid.Nil, fmt.Errorf("%w: %s", ErrInvalidSourceID, str)
+	}
+	for _, r := range prefix {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') && r != '-' {
+			return uuid.Nil, fmt.Errorf("%w: %s", ErrInvalidSourceID, str)
+		}
+	}
+
+	// The prefix can't hold LIKE wildcards, having only hex digits and
+	// dashes
+	rows, err := s.db.Query("SELECT source_id FROM sources WHERE source_id LIKE ? ORDER BY source_id LIMIT 2", prefix+"%")
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to match source IDs: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var matches []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return uuid.Nil, fmt.Errorf("failed to scan source ID: %w", err)
+		}
+		matches = append(matches, id)
+	}
+	if err := rows.Err(); err != nil {
+		return uuid.Nil, fmt.Errorf("failed to match source IDs: %w", err)
+	}
+
+	switch len(matches) {
+	case 0:
+		return uuid.Nil, fmt.Errorf("%w: %s", ErrSourceNotFound, str)
+	case 1:
+		return uuid.Parse(matches[0])
+	default:
+		return uuid.Nil, fmt.Errorf("%w: %s", ErrAmbiguousSourceID, str)
+	}
+}
+
+// rowQuerier is implemented by *sqldb.DB and *sqldb.Tx.
+type rowQuerier interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// getSource retrieves a source by ID from db.
+func getSource(db rowQuerier, sourceID uuid.UUID) (*Source, error) {
+	query := "SELECT " + sourceColumns + " FROM sources WHERE source_id = ?"
 
+	source, err := scanSource(db.QueryRow(query, sourceID.String()))
 	if err == sql.ErrNoRows {
 		return nil, ErrSourceNotFound
 	}
@@ -238,23 +803,13 @@ func (s *SourceStore) GetSource(sourceID uuid.UUID) (*Source, error) {
 		return nil, fmt.Errorf("failed to query source: %w", err)
 	}
 
-	return scanSource(
-		sourceIDStr, sourceType, url, name,
-		createdAtStr, updatedAtStr,
-		enabledAtStr, pollingInterval, lastFetchedAtStr,
-		lastModified, etag, fetchErrorCount, lastError, scraperConfigJSON,
-	)
+	return source, nil
 }
 
 // ListSources lists sources with optional filtering.
 func (s *SourceStore) ListSources(filter SourceFilter) ([]Source, error) {
 	// Build query with WHERE clause based on filter
-	query := `
-		SELECT source_id, source_type, url, name, enabled_at,
-		       created_at, updated_at, polling_interval, last_fetched_at,
-		       last_modified, etag, fetch_error_count, last_error, scraper_config
-		FROM sources
-	`
+	query := "SELECT " + sourceColumns + " FROM sources"
 
 	var whereClauses []string
 	var args []any
@@ -272,6 +827,19 @@ func (s *SourceStore) ListSources(filter SourceFilter) ([]Source, error) {
 		}
 	}
 
+	if filter.Failing {
+		whereClauses = append(whereClauses, "fetch_error_count > 0")
+	}
+
+	if filter.Category != nil {
+		if *filter.Category == "" {
+			whereClauses = append(whereClauses, "category IS NULL")
+		} else {
+			whereClauses = append(whereClauses, "lower(category) = lower(?)")
+			args = append(args, *filter.Category)
+		}
+	}
+
 	if len(whereClauses) > 0 {
 		query += " WHERE " + strings.Join(whereClauses, " AND ")
 	}
@@ -293,30 +861,11 @@ func (s *SourceStore) ListSources(filter SourceFilter) ([]Source, error) {
 
 	var sources []Source
 	for rows.Next() {
-		var sourceIDStr, sourceType, url, name, createdAtStr, updatedAtStr string
-		var enabledAtStr, pollingInterval, lastFetchedAtStr, lastModified, etag, lastError, scraperConfigJSON sql.NullString
-		var fetchErrorCount int
-
-		err := rows.Scan(
-			&sourceIDStr, &sourceType, &url, &name,
-			&enabledAtStr, &createdAtStr, &updatedAtStr,
-			&pollingInterval, &lastFetchedAtStr, &lastModified,
-			&etag, &fetchErrorCount, &lastError, &scraperConfigJSON,
-		)
+		source, err := scanSource(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan source: %w", err)
 		}
 
-		source, err := scanSource(
-			sourceIDStr, sourceType, url, name,
-			createdAtStr, updatedAtStr,
-			enabledAtStr, pollingInterval, lastFetchedAtStr,
-			lastModified, etag, fetchErrorCount, lastError, scraperConfigJSON,
-		)
-		if err != nil {
-			return nil, err
-		}
-
 		sources = append(sources, *source)
 	}
 
@@ -325,7 +874,123 @@ func (s *SourceStore) ListSources(filter SourceFilter) ([]Source, error) {
 
 // UpdateSource updates a source with the provided fields.
 func (s *SourceStore) UpdateSource(sourceID uuid.UUID, update SourceUpdate) error {
-	// Build dynamic UPDATE query based on provided fields
+	setClauses, args, err := updateClauses(update)
+	if err != nil {
+		return err
+	}
+
+	// Add WHERE clause
+	args = append(args, sourceID.String())
+
+	query := fmt.Sprintf("UPDATE sources SET %s WHERE source_id = ?",
+		strings.Join(setClauses, ", "))
+
+	// Fetch bookkeeping is written after every fetch and isn't audited, so
+	// it skips the transaction
+	if !update.changesConfig() {
+		result, err := s.db.Exec(query, args...)
+		if err != nil {
+			// Check for duplicate URL constraint violation
+			if sqldb.IsUniqueViolation(err) {
+				return ErrDuplicateURL
+			}
+			return fmt.Errorf("failed to update source: %w", err)
+		}
+
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rows == 0 {
+			return ErrSourceNotFound
+		}
+		return nil
+	}
+
+	return s.inTx(func(tx *sqldb.Tx) error {
+		before, err := getSource(tx, sourceID)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(query, args...); err != nil {
+			if sqldb.IsUniqueViolation(err) {
+				return ErrDuplicateURL
+			}
+			return fmt.Errorf("failed to update source: %w", err)
+		}
+		after, err := getSource(tx, sourceID)
+		if err != nil {
+			return err
+		}
+		if err := recordChange(tx, audit.SourceUpdated, before, after); err != nil {
+			return err
+		}
+		return registerCategory(tx, update.Category)
+	})
+}
+
+// UpdateSources applies the same update to several sources in one
+// transaction. If any source does not exist, no source is changed and
+// ErrSourceNotFound is returned.
+func (s *SourceStore) UpdateSources(sourceIDs []uuid.UUID, update SourceUpdate) error {
+	setClauses, args, err := updateClauses(update)
+	if err != nil {
+		return err
+	}
+	query := fmt.Sprintf("UPDATE sources SET %s WHERE source_id = ?",
+		strings.Join(setClauses, ", "))
+
+	return s.inTx(func(tx *sqldb.Tx) error {
+		stmt, err := tx.Prepare(query)
+		if err != nil {
+			return fmt.Errorf("failed to prepare update: %w", err)
+		}
+		defer func() { _ = stmt.Close() }()
+
+		audited := update.changesConfig()
+		for _, id := range sourceIDs {
+			var before *Source
+			if audited {
+				if before, err = getSource(tx, id); err != nil {
+					if errors.Is(err, ErrSourceNotFound) {
+						return fmt.Errorf("%w: %s", ErrSourceNotFound, id)
+					}
+					return err
+				}
+			}
+
+			result, err := stmt.Exec(append(args, id.String())...)
+			if err != nil {
+				if sqldb.IsUniqueViolation(err) {
+					return ErrDuplicateURL
+				}
+				return fmt.Errorf("failed to update source %s: %w", id, err)
+			}
+			rows, err := result.RowsAffected()
+			if err != nil {
+				return fmt.Errorf("failed to get rows affected: %w", err)
+			}
+			if rows == 0 {
+				return fmt.Errorf("%w: %s", ErrSourceNotFound, id)
+			}
+
+			if audited {
+				after, err := getSource(tx, id)
+				if err != nil {
+					return err
+				}
+				if err := recordChange(tx, audit.SourceUpdated, before, after); err != nil {
+					return err
+				}
+			}
+		}
+		return registerCategory(tx, update.Category)
+	})
+}
+
+// updateClauses builds the SET clauses and arguments for an update. The
+// updated_at column is always set.
+func updateClauses(update SourceUpdate) ([]string, []any, error) {
 	setClauses := []string{"updated_at = ?"}
 	now := time.Now().UTC()
 	args := []any{formatTime(&now)}
@@ -352,7 +1017,7 @@ func (s *SourceStore) UpdateSource(sourceID uuid.UUID, update SourceUpdate) erro
 	if update.ScraperConfig != nil {
 		data, err := json.Marshal(update.ScraperConfig)
 		if err != nil {
-			return fmt.Errorf("failed to marshal scraper_config: %w", err)
+			return nil, nil, fmt.Errorf("failed to marshal scraper_config: %w", err)
 		}
 		setClauses = append(setClauses, "scraper_config = ?")
 		args = append(args, string(data))
@@ -361,6 +1026,13 @@ func (s *SourceStore) UpdateSource(sourceID uuid.UUID, update SourceUpdate) erro
 		setClauses = append(setClauses, "last_fetched_at = ?")
 		args = append(args, formatTime(update.LastFetchedAt))
 	}
+	if update.ClearNextFetchAt {
+		setClauses = append(setClauses, "next_fetch_at = ?")
+		args = append(args, nil)
+	} else if update.NextFetchAt != nil {
+		setClauses = append(setClauses, "next_fetch_at = ?")
+		args = append(args, formatTime(update.NextFetchAt))
+	}
 	if update.LastModified != nil {
 		setClauses = append(setClauses, "last_modified = ?")
 		args = append(args, *update.LastModified)
@@ -377,49 +1049,335 @@ func (s *SourceStore) UpdateSource(sourceID uuid.UUID, update SourceUpdate) erro
 		setClauses = append(setClauses, "last_error = ?")
 		args = append(args, *update.LastError)
 	}
+	if update.UserAgent != nil {
+		setClauses = append(setClauses, "user_agent = ?")
+		args = append(args, nullIfEmpty(*update.UserAgent))
+	}
+	if update.HTTPHeaders != nil {
+		headersJSON, err := marshalHeaders(update.HTTPHeaders)
+		if err != nil {
+			return nil, nil, err
+		}
+		setClauses = append(setClauses, "http_headers = ?")
+		args = append(args, headersJSON)
+	}
+	if update.Category != nil {
+		setClauses = append(setClauses, "category = ?")
+		args = append(args, nullIfEmpty(*update.Category))
+	}
+	if update.ProxyURL != nil {
+		if *update.ProxyURL != "" {
+			if err := ValidateProxyURL(*update.ProxyURL); err != nil {
+				return nil, nil, err
+			}
+		}
+		setClauses = append(setClauses, "proxy_url = ?")
+		args = append(args, nullIfEmpty(*update.ProxyURL))
+	}
+	if update.Language != nil {
+		if *update.Language != "" {
+			if err := ValidateLanguage(*update.Language); err != nil {
+				return nil, nil, err
+			}
+		}
+		setClauses = append(setClauses, "language = ?")
+		args = append(args, nullIfEmpty(*update.Language))
+	}
+	if update.TimeZone != nil {
+		if *update.TimeZone != "" {
+			if err := ValidateTimeZone(*update.TimeZone); err != nil {
+				return nil, nil, err
+			}
+		}
+		setClauses = append(setClauses, "time_zone = ?")
+		args = append(args, nullIfEmpty(*update.TimeZone))
+	}
+	if update.TLS != nil {
+		var tlsJSON any
+		if !update.TLS.IsZero() {
+			if _, err := update.TLS.Version(); err != nil {
+				return nil, nil, err
+			}
+			data, err := json.Marshal(update.TLS)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to marshal tls_config: %w", err)
+			}
+			tlsJSON = string(data)
+		}
+		setClauses = append(setClauses, "tls_config = ?")
+		args = append(args, tlsJSON)
+	}
+	if update.IngestRules != nil {
+		var rulesJSON any
+		if !update.IngestRules.IsZero() {
+			if err := update.IngestRules.Validate(); err != nil {
+				return nil, nil, err
+			}
+			data, err := json.Marshal(update.IngestRules)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to marshal ingest_rules: %w", err)
+			}
+			rulesJSON = string(data)
+		}
+		setClauses = append(setClauses, "ingest_rules = ?")
+		args = append(args, rulesJSON)
+	}
+	if update.PollWindow != nil {
+		var windowJSON any
+		if !update.PollWindow.IsZero() {
+			if err := update.PollWindow.Validate(); err != nil {
+				return nil, nil, err
+			}
+			data, err := json.Marshal(update.PollWindow)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to marshal poll_window: %w", err)
+			}
+			windowJSON = string(data)
+		}
+		setClauses = append(setClauses, "poll_window = ?")
+		args = append(args, windowJSON)
+	}
+	if update.FetchBudget != nil {
+		var budgetJSON any
+		if !update.FetchBudget.IsZero() {
+			if err := update.FetchBudget.Validate(); err != nil {
+				return nil, nil, err
+			}
+			data, err := json.Marshal(update.FetchBudget)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to marshal fetch_budget: %w", err)
+			}
+			budgetJSON = string(data)
+		}
+		setClauses = append(setClauses, "fetch_budget = ?")
+		args = append(args, budgetJSON)
+	}
+	if update.ClearReprobeAt {
+		setClauses = append(setClauses, "reprobe_at = ?")
+		args = append(args, nil)
+	} else if update.ReprobeAt != nil {
+		setClauses = append(setClauses, "reprobe_at = ?")
+		args = append(args, formatTime(update.ReprobeAt))
+	}
+	if update.ReprobeCount != nil {
+		setClauses = append(setClauses, "reprobe_count = ?")
+		args = append(args, *update.ReprobeCount)
+	}
+	if update.PostingCadence != nil {
+		setClauses = append(setClauses, "posting_cadence = ?")
+		args = append(args, *update.PostingCadence)
+	}
+	if update.HackerNews != nil {
+		data, err := json.Marshal(update.HackerNews)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal hackernews_config: %w", err)
+		}
+		setClauses = append(setClauses, "hackernews_config = ?")
+		args = append(args, string(data))
+	}
 
-	// Add WHERE clause
-	args = append(args, sourceID.String())
+	return setClauses, args, nil
+}
 
-	query := fmt.Sprintf("UPDATE sources SET %s WHERE source_id = ?",
-		strings.Join(setClauses, ", "))
+// changesConfig reports whether update changes a source's configuration, as
+// opposed to only the bookkeeping discovery writes after each fetch. Only
+// configuration changes are audited.
+func (u SourceUpdate) changesConfig() bool {
+	return u.Name != nil || u.URL != nil || u.EnabledAt != nil || u.ClearEnabledAt ||
+		u.PollingInterval != nil || u.ScraperConfig != nil || u.UserAgent != nil ||
+		u.HTTPHeaders != nil || u.Category != nil || u.HackerNews != nil ||
+		u.ProxyURL != nil || u.TLS != nil || u.Language != nil || u.IngestRules != nil ||
+		u.PollWindow != nil || u.TimeZone != nil || u.FetchBudget != nil
+}
 
-	result, err := s.db.Exec(query, args...)
-	if err != nil {
-		// Check for duplicate URL constraint violation
-		if strings.Contains(err.Error(), "UNIQUE constraint") ||
-			strings.Contains(err.Error(), "unique constraint") {
-			return ErrDuplicateURL
-		}
-		return fmt.Errorf("failed to update source: %w", err)
-	}
+// unauditedFields lists the source fields left out of audit entries: its
+// identity, which the entry's target gives, and fetch bookkeeping and claims,
+// which change without anyone acting.
+var unauditedFields = []string{
+	"source_id", "created_at", "updated_at",
+	"last_fetched_at", "next_fetch_at", "last_modified", "etag",
+	"claimed_by", "claim_expires_at", "reprobe_at", "reprobe_count",
+	"posting_cadence",
+	// SetSession records session changes itself, without their contents
+	"has_session",
+}
 
-	rows, err := result.RowsAffected()
+// recordChange records a change to a source in the audit log. Before is nil
+// for a source just created, and after for one just deleted; an update that
+// changed no audited field isn't recorded.
+func recordChange(tx *sqldb.Tx, action string, before, after *Source) error {
+	changes, err := audit.Diff(before, after, unauditedFields...)
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return err
 	}
-	if rows == 0 {
-		return ErrSourceNotFound
+	if len(changes) == 0 && action == audit.SourceUpdated {
+		return nil
 	}
 
-	return nil
+	target := after
+	if target == nil {
+		target = before
+	}
+	return audit.Record(tx, action, target.SourceID.String(), changes)
 }
 
 // DeleteSource deletes a source.
 func (s *SourceStore) DeleteSource(sourceID uuid.UUID) error {
-	result, err := s.db.Exec("DELETE FROM sources WHERE source_id = ?", sourceID.String())
+	return s.inTx(func(tx *sqldb.Tx) error {
+		before, err := getSource(tx, sourceID)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec("DELETE FROM sources WHERE source_id = ?", sourceID.String()); err != nil {
+			return fmt.Errorf("failed to delete source: %w", err)
+		}
+		if before.HasSession {
+			if err := deleteSessionSecret(tx, sourceID); err != nil {
+				return err
+			}
+		}
+		return recordChange(tx, audit.SourceDeleted, before, nil)
+	})
+}
+
+// DeleteSources deletes several sources in one transaction. If any source
+// does not exist, no source is deleted and ErrSourceNotFound is returned.
+func (s *SourceStore) DeleteSources(sourceIDs []uuid.UUID) error {
+	return s.inTx(func(tx *sqldb.Tx) error {
+		stmt, err := tx.Prepare("DELETE FROM sources WHERE source_id = ?")
+		if err != nil {
+			return fmt.Errorf("failed to prepare delete: %w", err)
+		}
+		defer func() { _ = stmt.Close() }()
+
+		for _, id := range sourceIDs {
+			before, err := getSource(tx, id)
+			if err != nil {
+				if errors.Is(err, ErrSourceNotFound) {
+					return fmt.Errorf("%w: %s", ErrSourceNotFound, id)
+				}
+				return err
+			}
+			if _, err := stmt.Exec(id.String()); err != nil {
+				return fmt.Errorf("failed to delete source %s: %w", id, err)
+			}
+			if before.HasSession {
+				if err := deleteSessionSecret(tx, id); err != nil {
+					return err
+				}
+			}
+			if err := recordChange(tx, audit.SourceDeleted, before, nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// execer is implemented by *sqldb.DB and *sqldb.Tx.
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// registerCategory records a category that a source was placed in so that it
+// is listed by ListCategories. Nil and empty names are ignored.
+func registerCategory(db execer, name *string) error {
+	if name == nil || *name == "" {
+		return nil
+	}
+	now := time.Now().UTC()
+	_, err := db.Exec("INSERT INTO categories (name, created_at) VALUES (?, ?) ON CONFLICT DO NOTHING",
+		*name, formatTime(&now))
 	if err != nil {
-		return fmt.Errorf("failed to delete source: %w", err)
+		return fmt.Errorf("failed to register category: %w", err)
 	}
+	return nil
+}
+
+// CreateCategory adds an empty category. Creating a category that already
+// exists is not an error.
+func (s *SourceStore) CreateCategory(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return ErrInvalidCategory
+	}
+	return registerCategory(s.db, &name)
+}
+
+// DeleteCategory removes a category and takes every source out of it. The
+// sources themselves are kept.
+func (s *SourceStore) DeleteCategory(name string) error {
+	return s.inTx(func(tx *sqldb.Tx) error {
+		result, err := tx.Exec("DELETE FROM categories WHERE lower(name) = lower(?)", name)
+		if err != nil {
+			return fmt.Errorf("failed to delete category: %w", err)
+		}
+		deleted, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
 
-	rows, err := result.RowsAffected()
+		now := time.Now().UTC()
+		result, err = tx.Exec(
+			"UPDATE sources SET category = NULL, updated_at = ? WHERE lower(category) = lower(?)",
+			formatTime(&now), name)
+		if err != nil {
+			return fmt.Errorf("failed to clear category from sources: %w", err)
+		}
+		cleared, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+
+		if deleted == 0 && cleared == 0 {
+			return ErrCategoryNotFound
+		}
+		return nil
+	})
+}
+
+// ListCategories returns every category, with the number of sources in it,
+// ordered by name.
+func (s *SourceStore) ListCategories() ([]Category, error) {
+	rows, err := s.db.Query(`
+		SELECT c.name, c.created_at, COUNT(s.source_id)
+		FROM categories c
+		LEFT JOIN sources s ON lower(s.category) = lower(c.name)
+		GROUP BY c.name
+		ORDER BY lower(c.name)
+	`)
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return nil, fmt.Errorf("failed to query categories: %w", err)
 	}
-	if rows == 0 {
-		return ErrSourceNotFound
+	defer func() { _ = rows.Close() }()
+
+	var categories []Category
+	for rows.Next() {
+		var category Category
+		var createdAtStr string
+		if err := rows.Scan(&category.Name, &createdAtStr, &category.SourceCount); err != nil {
+			return nil, fmt.Errorf("failed to scan category: %w", err)
+		}
+		category.CreatedAt = parseTime(createdAtStr)
+		categories = append(categories, category)
 	}
 
+	return categories, rows.Err()
+}
+
+// inTx runs fn in a transaction, committing if it succeeds and rolling back
+// otherwise.
+func (s *SourceStore) inTx(fn func(tx *sqldb.Tx) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
 	return nil
 }
 
@@ -478,15 +1436,257 @@ func (s *SourceStore) ListErrors(sourceID uuid.UUID, limit int) ([]SourceError,
 	return errs, nil
 }
 
-// scanSource is a shared helper that parses SQL row data into a Source
-// struct. This eliminates duplication between GetSource and ListSources.
-func scanSource(
-	sourceIDStr, sourceType, url, name string,
-	createdAtStr, updatedAtStr string,
-	enabledAtStr, pollingInterval, lastFetchedAtStr, lastModified, etag sql.NullString,
-	fetchErrorCount int,
-	lastError, scraperConfigJSON sql.NullString,
-) (*Source, error) {
+// RecordSyncAttempt records a fetch attempt in the source's sync history.
+func (s *SourceStore) RecordSyncAttempt(attempt SyncAttempt) error {
+	query := `
+		INSERT INTO sync_history (source_id, started_at, duration_ms, items_discovered, error, reprobe, discovery_lag_ms)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+	var lagMs any
+	if attempt.DiscoveryLag != nil {
+		lagMs = attempt.DiscoveryLag.Milliseconds()
+	}
+	_, err := s.db.Exec(query,
+		attempt.SourceID.String(),
+		formatTime(&attempt.StartedAt),
+		attempt.Duration.Milliseconds(),
+		attempt.ItemsDiscovered,
+		attempt.Error,
+		attempt.Reprobe,
+		lagMs,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record sync attempt: %w", err)
+	}
+	return nil
+}
+
+// ListSyncHistory returns the sync history for a source, most recent first.
+func (s *SourceStore) ListSyncHistory(sourceID uuid.UUID, limit int) ([]SyncAttempt, error) {
+	query := `
+		SELECT source_id, started_at, duration_ms, items_discovered, error, reprobe, discovery_lag_ms
+		FROM sync_history
+		WHERE source_id = ?
+		ORDER BY started_at DESC, id DESC
+	`
+
+	var args []any
+	args = append(args, sourceID.String())
+
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sync history: %w", err)
+	}
+	return scanSyncAttempts(rows)
+}
+
+// LastSyncAttempts returns the sync attempt recorded last for each source,
+// keyed by source ID. Sources without attempts are left out.
+func (s *SourceStore) LastSyncAttempts() (map[uuid.UUID]SyncAttempt, error) {
+	rows, err := s.db.Query(`
+		SELECT source_id, started_at, duration_ms, items_discovered, error, reprobe, discovery_lag_ms
+		FROM sync_history
+		WHERE id IN (SELECT MAX(id) FROM sync_history GROUP BY source_id)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sync history: %w", err)
+	}
+	history, err := scanSyncAttempts(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	last := make(map[uuid.UUID]SyncAttempt, len(history))
+	for _, attempt := range history {
+		last[attempt.SourceID] = attempt
+	}
+	return last, nil
+}
+
+// scanSyncAttempts reads the sync attempts in rows and closes it.
+func scanSyncAttempts(rows *sql.Rows) ([]SyncAttempt, error) {
+	defer func() { _ = rows.Close() }()
+
+	var history []SyncAttempt
+	for rows.Next() {
+		var sourceIDStr, startedAtStr string
+		var durationMs int64
+		var itemsDiscovered int
+		var errMsg sql.NullString
+		var reprobe bool
+		var lagMs sql.NullInt64
+		if err := rows.Scan(&sourceIDStr, &startedAtStr, &durationMs, &itemsDiscovered, &errMsg, &reprobe, &lagMs); err != nil {
+			return nil, fmt.Errorf("failed to scan sync attempt: %w", err)
+		}
+
+		sid, err := uuid.Parse(sourceIDStr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse source ID: %w", err)
+		}
+
+		attempt := SyncAttempt{
+			SourceID:        sid,
+			StartedAt:       parseTime(startedAtStr),
+			Duration:        time.Duration(durationMs) * time.Millisecond,
+			ItemsDiscovered: itemsDiscovered,
+			Reprobe:         reprobe,
+		}
+		if errMsg.Valid {
+			attempt.Error = &errMsg.String
+		}
+		if lagMs.Valid {
+			lag := time.Duration(lagMs.Int64) * time.Millisecond
+			attempt.DiscoveryLag = &lag
+		}
+		history = append(history, attempt)
+	}
+
+	return history, rows.Err()
+}
+
+// DiscoveryLags returns each source's average discovery lag over the sync
+// attempts started at or after since, weighted by the items each found.
+// Sources with no measured lag in that time are left out.
+func (s *SourceStore) DiscoveryLags(since time.Time) (map[uuid.UUID]time.Duration, error) {
+	// Start times are stored with the offset of the process that recorded
+	// them, so they are compared here rather than as text
+	rows, err := s.db.Query(`
+		SELECT source_id, started_at, discovery_lag_ms, items_discovered
+		FROM sync_history
+		WHERE discovery_lag_ms IS NOT NULL AND items_discovered > 0
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query discovery lag: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	type totals struct{ lagMs, items int64 }
+	bySource := map[string]*totals{}
+	for rows.Next() {
+		var sourceIDStr, startedAtStr string
+		var lagMs, items int64
+		if err := rows.Scan(&sourceIDStr, &startedAtStr, &lagMs, &items); err != nil {
+			return nil, fmt.Errorf("failed to scan discovery lag: %w", err)
+		}
+		if parseTime(startedAtStr).Before(since) {
+			continue
+		}
+		t, ok := bySource[sourceIDStr]
+		if !ok {
+			t = &totals{}
+			bySource[sourceIDStr] = t
+		}
+		t.lagMs += lagMs * items
+		t.items += items
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	lags := make(map[uuid.UUID]time.Duration, len(bySource))
+	for sourceIDStr, t := range bySource {
+		sid, err := uuid.Parse(sourceIDStr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse source ID: %w", err)
+		}
+		lags[sid] = time.Duration(t.lagMs/t.items) * time.Millisecond
+	}
+	return lags, nil
+}
+
+// SyncStats returns each source's sync statistics over the given number of
+// days, ending with the day of now, keyed by source ID. Attempts count
+// toward the day they were recorded on. Sources without attempts in that
+// time are left out.
+func (s *SourceStore) SyncStats(days int, now time.Time) (map[uuid.UUID]*SyncStats, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	first := today.AddDate(0, 0, 1-days)
+
+	rows, err := s.db.Query(`
+		SELECT source_id, substr(started_at, 1, 10), COUNT(*),
+			SUM(CASE WHEN error IS NULL THEN 0 ELSE 1 END), SUM(items_discovered)
+		FROM sync_history
+		WHERE substr(started_at, 1, 10) >= ?
+		GROUP BY source_id, substr(started_at, 1, 10)
+	`, first.Format(time.DateOnly))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sync stats: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	stats := map[uuid.UUID]*SyncStats{}
+	for rows.Next() {
+		var sourceIDStr, dayStr string
+		var attempts, failures, items int64
+		if err := rows.Scan(&sourceIDStr, &dayStr, &attempts, &failures, &items); err != nil {
+			return nil, fmt.Errorf("failed to scan sync stats: %w", err)
+		}
+		sid, err := uuid.Parse(sourceIDStr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse source ID: %w", err)
+		}
+		day, err := time.Parse(time.DateOnly, dayStr)
+		if err != nil || day.After(today) {
+			continue
+		}
+
+		st, ok := stats[sid]
+		if !ok {
+			st = &SyncStats{DailyItems: make([]int, days)}
+			stats[sid] = st
+		}
+		st.Attempts += int(attempts)
+		st.Failures += int(failures)
+		st.ItemsDiscovered += int(items)
+		st.DailyItems[int(day.Sub(first)/(24*time.Hour))] += int(items)
+	}
+	return stats, rows.Err()
+}
+
+// sourceColumns lists the columns read by scanSource, in order.
+const sourceColumns = `source_id, source_type, url, name, enabled_at,
+	created_at, updated_at, polling_interval, last_fetched_at,
+	last_modified, etag, fetch_error_count, last_error, scraper_config,
+	user_agent, http_headers, next_fetch_at, category, hackernews_config,
+	claimed_by, claim_expires_at, proxy_url, tls_config, language,
+	reprobe_at, reprobe_count, ingest_rules, poll_window,
+	session IS NOT NULL, time_zone, posting_cadence, fetch_budget`
+
+// rowScanner is implemented by *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanSource is a shared helper that parses a row selected with
+// sourceColumns into a Source struct. This eliminates duplication between
+// GetSource and ListSources.
+func scanSource(row rowScanner) (*Source, error) {
+	var sourceIDStr, sourceType, url, name, createdAtStr, updatedAtStr string
+	var enabledAtStr, pollingInterval, lastFetchedAtStr, lastModified, etag, lastError, scraperConfigJSON sql.NullString
+	var userAgent, headersJSON, nextFetchAtStr, category, hackerNewsJSON sql.NullString
+	var claimedBy, claimExpiresAtStr, proxyURL, tlsJSON, language, reprobeAtStr, ingestRulesJSON, pollWindowJSON, timeZone, postingCadence, fetchBudgetJSON sql.NullString
+	var fetchErrorCount, reprobeCount int
+	var hasSession bool
+
+	err := row.Scan(
+		&sourceIDStr, &sourceType, &url, &name,
+		&enabledAtStr, &createdAtStr, &updatedAtStr,
+		&pollingInterval, &lastFetchedAtStr, &lastModified,
+		&etag, &fetchErrorCount, &lastError, &scraperConfigJSON,
+		&userAgent, &headersJSON, &nextFetchAtStr, &category, &hackerNewsJSON,
+		&claimedBy, &claimExpiresAtStr, &proxyURL, &tlsJSON, &language,
+		&reprobeAtStr, &reprobeCount, &ingestRulesJSON, &pollWindowJSON,
+		&hasSession, &timeZone, &postingCadence, &fetchBudgetJSON,
+	)
+	if err != nil {
+		return nil, err
+	}
+
 	sourceID, err := uuid.Parse(sourceIDStr)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse source ID: %w", err)
@@ -500,6 +1700,8 @@ func scanSource(
 		CreatedAt:       parseTime(createdAtStr),
 		UpdatedAt:       parseTime(updatedAtStr),
 		FetchErrorCount: fetchErrorCount,
+		ReprobeCount:    reprobeCount,
+		HasSession:      hasSession,
 	}
 
 	// Parse optional timestamps
@@ -511,6 +1713,18 @@ func scanSource(
 		t := parseTime(lastFetchedAtStr.String)
 		source.LastFetchedAt = &t
 	}
+	if nextFetchAtStr.Valid {
+		t := parseTime(nextFetchAtStr.String)
+		source.NextFetchAt = &t
+	}
+	if claimExpiresAtStr.Valid {
+		t := parseTime(claimExpiresAtStr.String)
+		source.ClaimExpiresAt = &t
+	}
+	if reprobeAtStr.Valid {
+		t := parseTime(reprobeAtStr.String)
+		source.ReprobeAt = &t
+	}
 
 	// Parse optional strings
 	if pollingInterval.Valid {
@@ -525,6 +1739,27 @@ func scanSource(
 	if lastError.Valid {
 		source.LastError = &lastError.String
 	}
+	if userAgent.Valid {
+		source.UserAgent = &userAgent.String
+	}
+	if category.Valid {
+		source.Category = &category.String
+	}
+	if claimedBy.Valid {
+		source.ClaimedBy = &claimedBy.String
+	}
+	if proxyURL.Valid {
+		source.ProxyURL = &proxyURL.String
+	}
+	if language.Valid {
+		source.Language = &language.String
+	}
+	if timeZone.Valid {
+		source.TimeZone = &timeZone.String
+	}
+	if postingCadence.Valid {
+		source.PostingCadence = &postingCadence.String
+	}
 
 	// Parse scraper_config JSON
 	if scraperConfigJSON.Valid {
@@ -535,9 +1770,121 @@ func scanSource(
 		source.ScraperConfig = &config
 	}
 
+	// Parse hackernews_config JSON
+	if hackerNewsJSON.Valid {
+		var config HackerNewsConfig
+		if err := json.Unmarshal([]byte(hackerNewsJSON.String), &config); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal hackernews_config: %w", err)
+		}
+		source.HackerNews = &config
+	}
+
+	// Parse tls_config JSON
+	if tlsJSON.Valid {
+		var config TLSConfig
+		if err := json.Unmarshal([]byte(tlsJSON.String), &config); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal tls_config: %w", err)
+		}
+		source.TLS = &config
+	}
+
+	// Parse ingest_rules JSON
+	if ingestRulesJSON.Valid {
+		var rules IngestRules
+		if err := json.Unmarshal([]byte(ingestRulesJSON.String), &rules); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal ingest_rules: %w", err)
+		}
+		source.IngestRules = &rules
+	}
+
+	// Parse poll_window JSON
+	if pollWindowJSON.Valid {
+		var window PollWindow
+		if err := json.Unmarshal([]byte(pollWindowJSON.String), &window); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal poll_window: %w", err)
+		}
+		source.PollWindow = &window
+	}
+
+	// Parse fetch_budget JSON
+	if fetchBudgetJSON.Valid {
+		var budget FetchBudget
+		if err := json.Unmarshal([]byte(fetchBudgetJSON.String), &budget); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal fetch_budget: %w", err)
+		}
+		source.FetchBudget = &budget
+	}
+
+	// Parse http_headers JSON
+	if headersJSON.Valid {
+		if err := json.Unmarshal([]byte(headersJSON.String), &source.HTTPHeaders); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal http_headers: %w", err)
+		}
+	}
+
 	return source, nil
 }
 
+// marshalHeaders encodes headers for storage; an empty set is stored as NULL.
+func marshalHeaders(headers map[string]string) (any, error) {
+	if len(headers) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(headers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal http_headers: %w", err)
+	}
+	return string(data), nil
+}
+
+// ValidateProxyURL checks that rawURL names a proxy that the discovery HTTP
+// client can use, or refers to a secret holding one, which is checked when
+// it is read.
+func ValidateProxyURL(rawURL string) error {
+	if _, ok := secrets.Ref(rawURL); ok {
+		return nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return ErrInvalidProxyURL
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+		return nil
+	default:
+		return ErrInvalidProxyURL
+	}
+}
+
+// ValidateLanguage checks that code is an ISO 639-1 language code: two
+// lowercase letters such as "en".
+func ValidateLanguage(code string) error {
+	if len(code) != 2 || code[0] < 'a' || code[0] > 'z' || code[1] < 'a' || code[1] > 'z' {
+		return ErrInvalidLanguage
+	}
+	return nil
+}
+
+// ValidateTimeZone checks that name is an IANA time zone name such as
+// "Europe/London", or "UTC".
+func ValidateTimeZone(name string) error {
+	if name == "" || name == "Local" {
+		return ErrInvalidTimeZone
+	}
+	if _, err := time.LoadLocation(name); err != nil {
+		return ErrInvalidTimeZone
+	}
+	return nil
+}
+
+// nullIfEmpty returns nil for an empty string so that it is stored as NULL.
+func nullIfEmpty(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
 // Helper functions for time formatting
 func formatTime(t *time.Time) any {
 	if t == nil {
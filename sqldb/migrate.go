@@ -0,0 +1,143 @@
+package sqldb
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Migrate brings the schema of component up to date by applying, in order,
+// each of migrations that has not been applied yet. The migrations applied
+// are recorded by version (their position, from 1) in the schema_migrations
+// table, so new migrations must only ever be appended. Each migration runs in
+// its own transaction. On PostgreSQL an advisory lock keeps instances that
+// start at the same time from applying a migration twice.
+func (db *DB) Migrate(component string, migrations []string) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			component TEXT NOT NULL,
+			version INTEGER NOT NULL,
+			applied_at TEXT NOT NULL,
+			PRIMARY KEY (component, version)
+		)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	for i, migration := range migrations {
+		version := i + 1
+		if err := db.applyMigration(component, version, migration); err != nil {
+			return fmt.Errorf("failed to apply %s migration %d: %w", component, version, err)
+		}
+	}
+	return nil
+}
+
+// applyMigration applies one migration unless it has already been applied.
+func (db *DB) applyMigration(component string, version int, migration string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if db.Dialect == Postgres {
+		if _, err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "newsfed:"+component); err != nil {
+			return err
+		}
+	}
+
+	var applied int
+	err = tx.QueryRow(
+		"SELECT COUNT(*) FROM schema_migrations WHERE component = ? AND version = ?",
+		component, version,
+	).Scan(&applied)
+	if err != nil {
+		return err
+	}
+	if applied > 0 {
+		return nil
+	}
+
+	if _, err := tx.Exec(migration); err != nil {
+		return err
+	}
+	_, err = tx.Exec(
+		"INSERT INTO schema_migrations (component, version, applied_at) VALUES (?, ?, ?)",
+		component, version, time.Now().UTC().Format(time.RFC3339Nano),
+	)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SchemaVersion returns the version of the last migration applied to
+// component, or 0 if none has been, including when the database has no
+// schema_migrations table yet. It doesn't change the database.
+func (db *DB) SchemaVersion(component string) (int, error) {
+	status, err := db.SchemaStatus(component, 0)
+	return status.Version, err
+}
+
+// SchemaStatus is how far the schema of a component has been migrated.
+type SchemaStatus struct {
+	Component string `json:"component"`
+
+	// Version is the last migration applied, or 0 if none has been.
+	Version int `json:"version"`
+
+	// Latest is the last migration this build of newsfed has.
+	Latest int `json:"latest"`
+
+	// AppliedAt is when Version was applied; it is nil at version 0.
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+// Pending returns how many migrations are yet to be applied. It is 0 for a
+// database a newer newsfed has migrated beyond Latest.
+func (s SchemaStatus) Pending() int {
+	return max(s.Latest-s.Version, 0)
+}
+
+// SchemaStatus returns how far the schema of component has been migrated,
+// given the number of migrations this build has for it. It doesn't change
+// the database.
+func (db *DB) SchemaStatus(component string, latest int) (SchemaStatus, error) {
+	status := SchemaStatus{Component: component, Latest: latest}
+	exists, err := db.HasTable("schema_migrations")
+	if err != nil || !exists {
+		return status, err
+	}
+
+	var appliedAt sql.NullString
+	err = db.QueryRow(`
+		SELECT version, applied_at FROM schema_migrations
+		WHERE component = ? ORDER BY version DESC LIMIT 1`,
+		component,
+	).Scan(&status.Version, &appliedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return status, nil
+	}
+	if err != nil {
+		return status, fmt.Errorf("failed to read %s schema version: %w", component, err)
+	}
+	if at, err := time.Parse(time.RFC3339Nano, appliedAt.String); err == nil {
+		status.AppliedAt = &at
+	}
+	return status, nil
+}
+
+// HasTable reports whether the database has a table named name.
+func (db *DB) HasTable(name string) (bool, error) {
+	query := "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?"
+	if db.Dialect == Postgres {
+		query = "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = ?"
+	}
+	var tables int
+	if err := db.QueryRow(query, name).Scan(&tables); err != nil {
+		return false, fmt.Errorf("failed to look for table %s: %w", name, err)
+	}
+	return tables > 0, nil
+}
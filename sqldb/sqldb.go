@@ -0,0 +1,150 @@
+// Package sqldb opens the SQL databases that newsfed keeps its metadata and
+// news items in. A DSN beginning with postgres:// or postgresql:// selects
+// PostgreSQL; any other DSN is the path to a SQLite database.
+//
+// Queries are written once, with ? placeholders, and rewritten to PostgreSQL's
+// numbered placeholders when needed. SQL that differs between the two
+// databases, such as schema definitions, is chosen by Dialect.
+package sqldb
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Dialect identifies the database a DB is connected to.
+type Dialect int
+
+const (
+	SQLite Dialect = iota
+	Postgres
+)
+
+// IsPostgres reports whether dsn selects PostgreSQL.
+func IsPostgres(dsn string) bool {
+	return strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://")
+}
+
+// IsMemory reports whether dsn selects an in-memory SQLite database, which
+// has no file on disk.
+func IsMemory(dsn string) bool {
+	return dsn == ":memory:" || strings.Contains(dsn, "mode=memory")
+}
+
+// DB is a database connection pool that rewrites queries for its dialect.
+type DB struct {
+	*sql.DB
+	Dialect Dialect
+}
+
+// Open opens the database named by dsn.
+func Open(dsn string) (*DB, error) {
+	driver, dialect := "sqlite3", SQLite
+	if IsPostgres(dsn) {
+		driver, dialect = "pgx", Postgres
+	}
+
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	return &DB{DB: db, Dialect: dialect}, nil
+}
+
+// Exec executes a query that returns no rows.
+func (db *DB) Exec(query string, args ...any) (sql.Result, error) {
+	return db.DB.Exec(db.Dialect.Rebind(query), args...)
+}
+
+// Query executes a query that returns rows.
+func (db *DB) Query(query string, args ...any) (*sql.Rows, error) {
+	return db.DB.Query(db.Dialect.Rebind(query), args...)
+}
+
+// QueryRow executes a query that returns at most one row.
+func (db *DB) QueryRow(query string, args ...any) *sql.Row {
+	return db.DB.QueryRow(db.Dialect.Rebind(query), args...)
+}
+
+// Begin starts a transaction.
+func (db *DB) Begin() (*Tx, error) {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	return &Tx{Tx: tx, Dialect: db.Dialect}, nil
+}
+
+// Tx is a transaction that rewrites queries for its dialect.
+type Tx struct {
+	*sql.Tx
+	Dialect Dialect
+}
+
+// Exec executes a query that returns no rows.
+func (tx *Tx) Exec(query string, args ...any) (sql.Result, error) {
+	return tx.Tx.Exec(tx.Dialect.Rebind(query), args...)
+}
+
+// Query executes a query that returns rows.
+func (tx *Tx) Query(query string, args ...any) (*sql.Rows, error) {
+	return tx.Tx.Query(tx.Dialect.Rebind(query), args...)
+}
+
+// QueryRow executes a query that returns at most one row.
+func (tx *Tx) QueryRow(query string, args ...any) *sql.Row {
+	return tx.Tx.QueryRow(tx.Dialect.Rebind(query), args...)
+}
+
+// Prepare creates a prepared statement for use within the transaction.
+func (tx *Tx) Prepare(query string) (*sql.Stmt, error) {
+	return tx.Tx.Prepare(tx.Dialect.Rebind(query))
+}
+
+// Rebind rewrites the ? placeholders in query for the dialect. Question
+// marks inside quoted strings and identifiers are left alone.
+func (d Dialect) Rebind(query string) string {
+	if d != Postgres || !strings.Contains(query, "?") {
+		return query
+	}
+
+	var b strings.Builder
+	b.Grow(len(query) + 8)
+	n := 0
+	var quote byte
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '?':
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// IsUniqueViolation reports whether err is a UNIQUE constraint failure.
+func IsUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == "23505"
+	}
+	return strings.Contains(err.Error(), "UNIQUE constraint") ||
+		strings.Contains(err.Error(), "unique constraint")
+}
@@ -0,0 +1,129 @@
+package sqldb
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRebind verifies that placeholders are numbered for PostgreSQL, except
+// inside quotes, and left alone for SQLite
+func TestRebind(t *testing.T) {
+	query := `SELECT '?', "a?" FROM t WHERE a = ? AND b IN (?, ?)`
+
+	assert.Equal(t, query, SQLite.Rebind(query))
+	assert.Equal(t,
+		`SELECT '?', "a?" FROM t WHERE a = $1 AND b IN ($2, $3)`,
+		Postgres.Rebind(query))
+}
+
+// TestIsPostgres verifies which DSNs select PostgreSQL
+func TestIsPostgres(t *testing.T) {
+	assert.True(t, IsPostgres("postgres://localhost/newsfed"))
+	assert.True(t, IsPostgres("postgresql://localhost/newsfed"))
+	assert.False(t, IsPostgres("metadata.db"))
+	assert.False(t, IsPostgres("sqlite://feed.db"))
+}
+
+// TestIsUniqueViolation verifies that unique violations are recognized from
+// either driver
+func TestIsUniqueViolation(t *testing.T) {
+	assert.True(t, IsUniqueViolation(fmt.Errorf("insert: %w", &pgconn.PgError{Code: "23505"})))
+	assert.False(t, IsUniqueViolation(&pgconn.PgError{Code: "23503"}))
+	assert.True(t, IsUniqueViolation(errors.New("UNIQUE constraint failed: sources.url")))
+	assert.False(t, IsUniqueViolation(errors.New("database is locked")))
+}
+
+// TestMigrate_AppliesOnce verifies that migrations are applied in order,
+// recorded, and skipped when already applied
+func TestMigrate_AppliesOnce(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	migrations := []string{
+		"CREATE TABLE things (id INTEGER PRIMARY KEY)",
+		"ALTER TABLE things ADD COLUMN name TEXT",
+	}
+	require.NoError(t, db.Migrate("things", migrations[:1]))
+	require.NoError(t, db.Migrate("things", migrations))
+	require.NoError(t, db.Migrate("things", migrations))
+
+	_, err = db.Exec("INSERT INTO things (id, name) VALUES (?, ?)", 1, "one")
+	require.NoError(t, err)
+
+	var versions int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM schema_migrations WHERE component = ?", "things").Scan(&versions))
+	assert.Equal(t, 2, versions)
+
+	// A failed migration is rolled back and reported
+	err = db.Migrate("things", append(migrations, "NOT SQL"))
+	assert.ErrorContains(t, err, "things migration 3")
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM schema_migrations WHERE component = ?", "things").Scan(&versions))
+	assert.Equal(t, 2, versions)
+}
+
+// TestSchemaVersion verifies that the version is 0 before any migration,
+// even without a schema_migrations table, and the last applied one after
+func TestSchemaVersion(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	version, err := db.SchemaVersion("things")
+	require.NoError(t, err)
+	assert.Equal(t, 0, version)
+
+	require.NoError(t, db.Migrate("things", []string{
+		"CREATE TABLE things (id INTEGER PRIMARY KEY)",
+		"ALTER TABLE things ADD COLUMN name TEXT",
+	}))
+	version, err = db.SchemaVersion("things")
+	require.NoError(t, err)
+	assert.Equal(t, 2, version)
+
+	version, err = db.SchemaVersion("others")
+	require.NoError(t, err)
+	assert.Equal(t, 0, version)
+}
+
+// TestSchemaStatus verifies that the status reports the applied version,
+// when it was applied, and how many migrations are pending
+func TestSchemaStatus(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	status, err := db.SchemaStatus("things", 2)
+	require.NoError(t, err)
+	assert.Equal(t, SchemaStatus{Component: "things", Latest: 2}, status)
+	assert.Equal(t, 2, status.Pending())
+
+	before := time.Now().Add(-time.Second)
+	require.NoError(t, db.Migrate("things", []string{"CREATE TABLE things (id INTEGER PRIMARY KEY)"}))
+
+	status, err = db.SchemaStatus("things", 2)
+	require.NoError(t, err)
+	assert.Equal(t, 1, status.Version)
+	assert.Equal(t, 1, status.Pending())
+	require.NotNil(t, status.AppliedAt)
+	assert.True(t, status.AppliedAt.After(before))
+
+	// A database migrated beyond this build has nothing pending
+	status, err = db.SchemaStatus("things", 0)
+	require.NoError(t, err)
+	assert.Equal(t, 0, status.Pending())
+
+	exists, err := db.HasTable("things")
+	require.NoError(t, err)
+	assert.True(t, exists)
+	exists, err = db.HasTable("others")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
@@ -0,0 +1,67 @@
+// Package sqldbtest provides PostgreSQL and in-memory SQLite databases for
+// tests.
+package sqldbtest
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/pevans/newsfed/sqldb"
+)
+
+// PostgresEnv names the environment variable holding the DSN of a PostgreSQL
+// database that tests may use. Tests that need PostgreSQL are skipped when it
+// is unset.
+const PostgresEnv = "NEWSFED_TEST_POSTGRES_DSN"
+
+// PostgresDSN returns a DSN for an empty schema in the test database, which
+// is dropped when the test ends. It skips the test if no test database is
+// configured.
+func PostgresDSN(t *testing.T) string {
+	t.Helper()
+
+	base := os.Getenv(PostgresEnv)
+	if base == "" {
+		t.Skipf("%s is not set", PostgresEnv)
+	}
+
+	db, err := sqldb.Open(base)
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	schema := "newsfed_test_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+	if _, err := db.Exec("CREATE SCHEMA " + schema); err != nil {
+		t.Fatalf("failed to create test schema: %v", err)
+	}
+	t.Cleanup(func() {
+		db, err := sqldb.Open(base)
+		if err != nil {
+			return
+		}
+		defer func() { _ = db.Close() }()
+		_, _ = db.Exec("DROP SCHEMA " + schema + " CASCADE")
+	})
+
+	u, err := url.Parse(base)
+	if err != nil {
+		t.Fatalf("invalid %s: %v", PostgresEnv, err)
+	}
+	q := u.Query()
+	q.Set("search_path", schema)
+	u.RawQuery = q.Encode()
+	return fmt.Sprint(u)
+}
+
+// MemoryDSN returns a DSN for an empty in-memory SQLite database, so that a
+// test needs no temporary directory. Stores opened with the same DSN share
+// the database, which lasts as long as one of them is open.
+func MemoryDSN(t *testing.T) string {
+	t.Helper()
+	return "file:newsfed_test_" + strings.ReplaceAll(uuid.NewString(), "-", "") + "?mode=memory&cache=shared"
+}
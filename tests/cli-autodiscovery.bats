@@ -228,3 +228,112 @@ EOF
     assert_failure
     assert_output_contains "no feed links in page"
 }
+
+# ---------------------------------------------------------------------------
+# Spec 10 section 7.6 -- the sources discover command
+# ---------------------------------------------------------------------------
+
+# Serve a page advertising RSS, Atom, and JSON feeds from $1.
+create_multi_feed_site() {
+    local www_dir="$1"
+    mkdir -p "$www_dir"
+    create_rss_feed "$www_dir/feed.xml" "Multi RSS Feed"
+
+    cat > "$www_dir/atom.xml" <<'EOF'
+<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Multi Atom Feed</title>
+  <link href="http://example.com"/>
+  <id>http://example.com</id>
+</feed>
+EOF
+
+    cat > "$www_dir/feed.json" <<'EOF'
+{
+  "version": "https://jsonfeed.org/version/1.1",
+  "title": "Multi JSON Feed",
+  "items": []
+}
+EOF
+
+    cat > "$www_dir/index.html" <<'EOF'
+<!DOCTYPE html>
+<html>
+<head>
+  <title>Multi Feed Site</title>
+  <link rel="alternate" type="application/rss+xml" href="/feed.xml">
+  <link rel="alternate" type="application/atom+xml" href="/atom.xml">
+  <link rel="alternate" type="application/feed+json" href="/feed.json">
+</head>
+<body><p>Hello</p></body>
+</html>
+EOF
+}
+
+@test "autodiscovery: sources discover lists every advertised feed (spec 7.6)" {
+    local www_dir="$TEST_DIR/www-discover"
+    create_multi_feed_site "$www_dir"
+    start_mock_server "$www_dir"
+
+    run newsfed sources discover "http://127.0.0.1:$MOCK_SERVER_PORT/"
+    stop_mock_server
+
+    assert_success
+    assert_output_contains "Found 3 feed(s):"
+    assert_output_contains "1. http://127.0.0.1:$MOCK_SERVER_PORT/feed.xml (RSS)"
+    assert_output_contains "2. http://127.0.0.1:$MOCK_SERVER_PORT/atom.xml (Atom)"
+    assert_output_contains "3. http://127.0.0.1:$MOCK_SERVER_PORT/feed.json"
+    assert_output_contains "Suggested name: Multi Feed Site"
+}
+
+@test "autodiscovery: sources discover does not create a source (spec 7.6)" {
+    local www_dir="$TEST_DIR/www-discover-none"
+    create_multi_feed_site "$www_dir"
+    start_mock_server "$www_dir"
+
+    run newsfed sources discover "http://127.0.0.1:$MOCK_SERVER_PORT/"
+    assert_success
+
+    run newsfed sources list
+    stop_mock_server
+
+    assert_output_not_contains "http://127.0.0.1:$MOCK_SERVER_PORT/"
+}
+
+@test "autodiscovery: sources discover -add -pick adds the chosen feed (spec 7.6)" {
+    local www_dir="$TEST_DIR/www-discover-add"
+    create_multi_feed_site "$www_dir"
+    start_mock_server "$www_dir"
+
+    run newsfed sources discover "http://127.0.0.1:$MOCK_SERVER_PORT/" -add -pick 2
+    stop_mock_server
+
+    assert_success
+    assert_output_contains "Created source: Multi Feed Site (atom)"
+    assert_output_contains "URL: http://127.0.0.1:$MOCK_SERVER_PORT/atom.xml"
+}
+
+@test "autodiscovery: sources discover rejects a pick out of range (spec 7.6)" {
+    local www_dir="$TEST_DIR/www-discover-pick"
+    create_multi_feed_site "$www_dir"
+    start_mock_server "$www_dir"
+
+    run newsfed sources discover "http://127.0.0.1:$MOCK_SERVER_PORT/" -add -pick 9
+    stop_mock_server
+
+    assert_failure
+    assert_output_contains "Error: -pick must be between 1 and 3"
+}
+
+@test "autodiscovery: sources discover suggests the feed title for a feed URL (spec 7.6)" {
+    local www_dir="$TEST_DIR/www-discover-direct"
+    create_rss_feed "$www_dir/feed.xml" "Direct Discover Feed"
+    start_mock_server "$www_dir"
+
+    run newsfed sources discover "http://127.0.0.1:$MOCK_SERVER_PORT/feed.xml"
+    stop_mock_server
+
+    assert_success
+    assert_output_contains "Found 1 feed(s):"
+    assert_output_contains "Suggested name: Direct Discover Feed"
+}
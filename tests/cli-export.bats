@@ -0,0 +1,203 @@
+#!/usr/bin/env bats
+# Test CLI: newsfed export, digest, and collections
+
+load test_helper
+
+setup_file() {
+    setup_test_env
+    build_newsfed "$TEST_DIR"
+    mkdir -p "$NEWSFED_FEED_DSN"
+    newsfed init > /dev/null
+
+    ONE_HOUR_AGO=$(timestamp_hours_ago 1)
+    TWO_HOURS_AGO=$(timestamp_hours_ago 2)
+    TEN_DAYS_AGO=$(timestamp_days_ago 10)
+
+    create_news_item "11111111-1111-1111-1111-111111111111" "First Export Article" "Go Blog" "$ONE_HOUR_AGO" "$ONE_HOUR_AGO"
+    create_news_item "22222222-2222-2222-2222-222222222222" "Second Export Article" "Rust Blog" "$TWO_HOURS_AGO"
+    create_news_item "33333333-3333-3333-3333-333333333333" "Old Export Article" "Go Blog" "$TEN_DAYS_AGO"
+}
+
+teardown_file() {
+    cleanup_test_env
+}
+
+# Test: export command
+
+@test "newsfed export: prints an RSS feed by default" {
+    run newsfed export
+    assert_success
+    assert_output_contains '<rss version="2.0"'
+    assert_output_contains "<title>First Export Article</title>"
+    assert_output_contains "urn:uuid:11111111-1111-1111-1111-111111111111"
+}
+
+@test "newsfed export: writes an Atom feed of pinned items to a file" {
+    run newsfed export --format=atom --pinned -o "$TEST_DIR/pinned.xml"
+    assert_success
+
+    [ -f "$TEST_DIR/pinned.xml" ]
+    grep -q '<feed xmlns="http://www.w3.org/2005/Atom"' "$TEST_DIR/pinned.xml"
+    grep -q "First Export Article" "$TEST_DIR/pinned.xml"
+    [ "$(grep -c "Second Export Article" "$TEST_DIR/pinned.xml")" -eq 0 ]
+}
+
+@test "newsfed export: --title names the feed" {
+    run newsfed export --title="Reading list" --link=https://example.com/reading
+    assert_success
+    assert_output_contains "<title>Reading list</title>"
+    assert_output_contains "<link>https://example.com/reading</link>"
+}
+
+@test "newsfed export: --format=json writes the selected fields" {
+    run newsfed export --pinned --format=json --fields=title,url
+    assert_success
+    assert_output_contains '"title": "First Export Article"'
+    assert_output_contains '"url": "https://example.com/11111111-1111-1111-1111-111111111111"'
+    assert_output_not_contains '"publisher"'
+}
+
+@test "newsfed export: --format=csv writes a header row" {
+    run newsfed export --format=csv --fields=title,publisher
+    assert_success
+    [ "${lines[0]}" = "title,publisher" ]
+    assert_output_contains "First Export Article,Go Blog"
+}
+
+@test "newsfed export: --format=markdown writes a section per item" {
+    run newsfed export --format=markdown --title="Reading list"
+    assert_success
+    assert_output_contains "# Reading list"
+    assert_output_contains "## \[First Export Article\](https://example.com/11111111-1111-1111-1111-111111111111)"
+}
+
+@test "newsfed export: --limit caps the export" {
+    run newsfed export --format=csv --fields=title --limit=1
+    assert_success
+    [ "${#lines[@]}" -eq 2 ]
+}
+
+@test "newsfed export: rejects --fields with feed formats" {
+    run newsfed export --format=rss --fields=title
+    assert_failure
+    assert_output_contains "Error: -fields can only be used with json, csv, and markdown formats"
+}
+
+# Test: digest command
+
+@test "newsfed digest: prints a Markdown digest of recent items" {
+    run newsfed digest
+    assert_success
+    assert_output_contains "# newsfed digest"
+    assert_output_contains "\[First Export Article\]"
+    assert_output_not_contains "Old Export Article"
+}
+
+@test "newsfed digest: --since widens the window" {
+    run newsfed digest --since=30d
+    assert_success
+    assert_output_contains "Old Export Article"
+}
+
+@test "newsfed digest: --group=publisher groups by publisher" {
+    run newsfed digest --group=publisher
+    assert_success
+    assert_output_contains "## Go Blog"
+    assert_output_contains "## Rust Blog"
+}
+
+@test "newsfed digest: writes an HTML digest to a file" {
+    run newsfed digest --format=html -o "$TEST_DIR/digest.html"
+    assert_success
+
+    [ -f "$TEST_DIR/digest.html" ]
+    grep -q "<!DOCTYPE html>" "$TEST_DIR/digest.html"
+    grep -q "First Export Article" "$TEST_DIR/digest.html"
+}
+
+@test "newsfed digest schedule: stores and shows a schedule" {
+    run newsfed digest schedule --cron="0 7 * * 1-5" --to=me@example.com
+    assert_success
+    assert_output_contains "Schedule:  0 7 \* \* 1-5"
+    assert_output_contains "To:        me@example.com"
+
+    run newsfed digest schedule
+    assert_success
+    assert_output_contains "Next run:"
+}
+
+@test "newsfed digest schedule: --clear removes the schedule" {
+    newsfed digest schedule --cron="@daily" --to=me@example.com > /dev/null
+
+    run newsfed digest schedule --clear
+    assert_success
+    assert_output_contains "Digest schedule cleared"
+}
+
+@test "newsfed digest schedule: rejects an invalid cron expression" {
+    run newsfed digest schedule --cron="bad"
+    assert_failure
+    assert_output_contains "invalid schedule"
+}
+
+# Test: collections command
+
+@test "newsfed collections add: creates a collection with items" {
+    run newsfed collections add "interview prep" 11111111-1111-1111-1111-111111111111 22222222-2222-2222-2222-222222222222
+    assert_success
+    assert_output_contains "Created collection: interview prep"
+    assert_output_contains "Added to interview prep: First Export Article"
+
+    run newsfed collections
+    assert_success
+    assert_output_contains "interview prep"
+}
+
+@test "newsfed collections show: lists items in order" {
+    run newsfed collections show "interview prep"
+    assert_success
+
+    first_pos=$(echo "$output" | grep -n "First Export Article" | head -1 | cut -d: -f1)
+    second_pos=$(echo "$output" | grep -n "Second Export Article" | head -1 | cut -d: -f1)
+    [ "$first_pos" -lt "$second_pos" ]
+}
+
+@test "newsfed collections move: reorders items" {
+    run newsfed collections move "interview prep" 22222222-2222-2222-2222-222222222222 1
+    assert_success
+
+    run newsfed collections show "interview prep"
+    first_pos=$(echo "$output" | grep -n "First Export Article" | head -1 | cut -d: -f1)
+    second_pos=$(echo "$output" | grep -n "Second Export Article" | head -1 | cut -d: -f1)
+    [ "$second_pos" -lt "$first_pos" ]
+}
+
+@test "newsfed collections update: changes the description" {
+    run newsfed collections update "interview prep" --description="System design reading"
+    assert_success
+
+    run newsfed collections
+    assert_output_contains "System design reading"
+}
+
+@test "newsfed collections export: writes the collection as Markdown" {
+    run newsfed collections export "interview prep"
+    assert_success
+    assert_output_contains "# interview prep"
+    assert_output_contains "## \[Second Export Article\]"
+}
+
+@test "newsfed collections remove: takes an item out" {
+    run newsfed collections remove "interview prep" 11111111-1111-1111-1111-111111111111
+    assert_success
+
+    run newsfed collections show "interview prep"
+    assert_output_not_contains "First Export Article"
+    assert_output_contains "Second Export Article"
+}
+
+@test "newsfed collections show: returns error for unknown collection" {
+    run newsfed collections show "no such list"
+    assert_failure
+    assert_output_contains "Error: collection not found"
+}
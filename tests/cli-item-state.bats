@@ -0,0 +1,289 @@
+#!/usr/bin/env bats
+# Test CLI: newsfed item state and editing (read, unread, star, unstar, note,
+# archive, unarchive, snooze, unsnooze, add, edit, top)
+
+load test_helper
+
+setup_file() {
+    setup_test_env
+    build_newsfed "$TEST_DIR"
+    mkdir -p "$NEWSFED_FEED_DSN"
+    newsfed init > /dev/null
+
+    ONE_HOUR_AGO=$(timestamp_hours_ago 1)
+    TWO_DAYS_AGO=$(timestamp_days_ago 2)
+
+    create_news_item "11111111-1111-1111-1111-111111111111" "Read State Article" "Test Publisher" "$ONE_HOUR_AGO"
+    create_news_item "22222222-2222-2222-2222-222222222222" "Star State Article" "Test Publisher" "$ONE_HOUR_AGO"
+    create_news_item "33333333-3333-3333-3333-333333333333" "Note Article" "Test Publisher" "$ONE_HOUR_AGO"
+    create_news_item "44444444-4444-4444-4444-444444444444" "Archive Article" "Test Publisher" "$ONE_HOUR_AGO"
+    create_news_item "55555555-5555-5555-5555-555555555555" "Snooze Article" "Test Publisher" "$ONE_HOUR_AGO"
+    create_news_item "66666666-6666-6666-6666-666666666666" "Edit Article" "Test Publisher" "$ONE_HOUR_AGO"
+    create_news_item "77777777-7777-7777-7777-777777777777" "Older Article" "Test Publisher" "$TWO_DAYS_AGO"
+}
+
+teardown_file() {
+    cleanup_test_env
+}
+
+# Test: read and unread commands
+
+@test "newsfed read: marks an item as read" {
+    run newsfed read 11111111-1111-1111-1111-111111111111
+    assert_success
+    assert_output_contains "Marked as read"
+
+    run newsfed show 11111111-1111-1111-1111-111111111111
+    assert_success
+    assert_output_not_contains "Read:        No"
+}
+
+@test "newsfed read: read items are left out of the unread list" {
+    newsfed read 11111111-1111-1111-1111-111111111111 > /dev/null
+
+    run newsfed list --unread
+    assert_success
+    assert_output_not_contains "Read State Article"
+    assert_output_contains "Star State Article"
+}
+
+@test "newsfed unread: marks a read item as unread" {
+    newsfed read 11111111-1111-1111-1111-111111111111 > /dev/null
+
+    run newsfed unread 11111111-1111-1111-1111-111111111111
+    assert_success
+    assert_output_contains "Marked as unread"
+
+    run newsfed show 11111111-1111-1111-1111-111111111111
+    assert_output_contains "Read:        No"
+}
+
+@test "newsfed read: returns error for invalid UUID" {
+    run newsfed read not-a-uuid
+    assert_failure
+    assert_output_contains "Error: invalid item ID"
+}
+
+@test "newsfed read: returns error for non-existent item" {
+    run newsfed read 00000000-0000-0000-0000-000000000000
+    assert_failure
+    assert_output_contains "Error:"
+}
+
+# Test: star and unstar commands
+
+@test "newsfed star: stars an item" {
+    run newsfed star 22222222-2222-2222-2222-222222222222
+    assert_success
+    assert_output_contains "Starred item"
+
+    run newsfed list --starred
+    assert_success
+    assert_output_contains "Star State Article"
+    assert_output_not_contains "Read State Article"
+}
+
+@test "newsfed unstar: unstars a starred item" {
+    newsfed star 22222222-2222-2222-2222-222222222222 > /dev/null
+
+    run newsfed unstar 22222222-2222-2222-2222-222222222222
+    assert_success
+    assert_output_contains "Unstarred item"
+
+    run newsfed list --starred
+    assert_output_not_contains "Star State Article"
+}
+
+# Test: note command
+
+@test "newsfed note: sets and prints notes" {
+    run newsfed note 33333333-3333-3333-3333-333333333333 "Cite in the Q3 review"
+    assert_success
+    assert_output_contains "Saved notes"
+
+    run newsfed note 33333333-3333-3333-3333-333333333333
+    assert_success
+    assert_output_contains "Cite in the Q3 review"
+}
+
+@test "newsfed note: notes are shown by show" {
+    newsfed note 33333333-3333-3333-3333-333333333333 "Shown note" > /dev/null
+
+    run newsfed show 33333333-3333-3333-3333-333333333333
+    assert_success
+    assert_output_contains "Notes:"
+    assert_output_contains "Shown note"
+}
+
+@test "newsfed note: --clear removes notes" {
+    newsfed note 33333333-3333-3333-3333-333333333333 "Soon gone" > /dev/null
+
+    run newsfed note 33333333-3333-3333-3333-333333333333 --clear
+    assert_success
+    assert_output_contains "Cleared notes"
+
+    run newsfed note 33333333-3333-3333-3333-333333333333
+    assert_success
+    assert_output_contains "No notes."
+}
+
+# Test: archive and unarchive commands
+
+@test "newsfed archive: hides an item from listings" {
+    run newsfed archive 44444444-4444-4444-4444-444444444444
+    assert_success
+    assert_output_contains "Archived item"
+
+    run newsfed list
+    assert_success
+    assert_output_not_contains "Archive Article"
+
+    run newsfed list --archived
+    assert_success
+    assert_output_contains "Archive Article"
+}
+
+@test "newsfed unarchive: returns an archived item to listings" {
+    newsfed archive 44444444-4444-4444-4444-444444444444 > /dev/null
+
+    run newsfed unarchive 44444444-4444-4444-4444-444444444444
+    assert_success
+    assert_output_contains "Unarchived item"
+
+    run newsfed list
+    assert_output_contains "Archive Article"
+}
+
+# Test: snooze and unsnooze commands
+
+@test "newsfed snooze: hides an item until it wakes" {
+    run newsfed snooze 55555555-5555-5555-5555-555555555555 3d
+    assert_success
+    assert_output_contains "Snoozed item until"
+
+    run newsfed list
+    assert_success
+    assert_output_not_contains "Snooze Article"
+
+    run newsfed list --snoozed
+    assert_success
+    assert_output_contains "Snooze Article"
+}
+
+@test "newsfed unsnooze: returns a snoozed item to listings" {
+    newsfed snooze 55555555-5555-5555-5555-555555555555 12h > /dev/null
+
+    run newsfed unsnooze 55555555-5555-5555-5555-555555555555
+    assert_success
+    assert_output_contains "Unsnoozed item"
+
+    run newsfed list
+    assert_output_contains "Snooze Article"
+}
+
+@test "newsfed snooze: rejects an invalid duration" {
+    run newsfed snooze 55555555-5555-5555-5555-555555555555 bogus
+    assert_failure
+    assert_output_contains "Error: invalid duration"
+}
+
+# Test: add and edit commands
+
+@test "newsfed add: saves a link as an item" {
+    run newsfed add https://example.com/saved-link --title="A long read"
+    assert_success
+    assert_output_contains "Added: A long read"
+
+    item_id=$(extract_uuid "$output")
+    run newsfed show "$item_id"
+    assert_success
+    assert_output_contains "A long read"
+    assert_output_contains "https://example.com/saved-link"
+}
+
+@test "newsfed add: --pin pins the saved item" {
+    run newsfed add https://example.com/pinned-link --title="Pin on add" --pin
+    assert_success
+
+    run newsfed list --pinned
+    assert_success
+    assert_output_contains "Pin on add"
+}
+
+@test "newsfed add: rejects a URL already in the feed" {
+    newsfed add https://example.com/duplicate-link > /dev/null
+
+    run newsfed add https://example.com/duplicate-link
+    assert_failure
+    assert_output_contains "is already in the feed"
+}
+
+@test "newsfed add: rejects a URL that is not http or https" {
+    run newsfed add not-a-url
+    assert_failure
+    assert_output_contains "URL must be an absolute http or https URL"
+}
+
+@test "newsfed edit: changes title and summary" {
+    run newsfed edit 66666666-6666-6666-6666-666666666666 --title="Corrected title" --summary="Short note"
+    assert_success
+    assert_output_contains "Updated: Corrected title"
+
+    run newsfed show 66666666-6666-6666-6666-666666666666
+    assert_output_contains "Corrected title"
+    assert_output_contains "Short note"
+}
+
+@test "newsfed edit: --clear-summary removes the summary" {
+    run newsfed edit 66666666-6666-6666-6666-666666666666 --clear-summary
+    assert_success
+
+    run newsfed show 66666666-6666-6666-6666-666666666666
+    assert_output_not_contains "Summary:"
+}
+
+@test "newsfed edit: requires a change" {
+    run newsfed edit 66666666-6666-6666-6666-666666666666
+    assert_failure
+    assert_output_contains "Error: at least one of -title, -summary, or -clear-summary is required"
+}
+
+@test "newsfed edit: returns error for non-existent item" {
+    run newsfed edit 00000000-0000-0000-0000-000000000000 --title="Nothing"
+    assert_failure
+    assert_output_contains "Error: news item not found"
+}
+
+# Test: top command
+
+@test "newsfed top: ranks items with their scores" {
+    run newsfed top
+    assert_success
+    assert_output_contains "Score:"
+    assert_output_contains "Older Article"
+}
+
+@test "newsfed top: newer items rank above older ones" {
+    run newsfed top --limit=100
+    assert_success
+
+    newer_pos=$(echo "$output" | grep -n "Star State Article" | head -1 | cut -d: -f1)
+    older_pos=$(echo "$output" | grep -n "Older Article" | head -1 | cut -d: -f1)
+    [ "$newer_pos" -lt "$older_pos" ]
+}
+
+@test "newsfed top: --limit caps the ranking" {
+    run newsfed top --limit=1
+    assert_success
+    [ "$(echo "$output" | grep -c "Score:")" -eq 1 ]
+}
+
+@test "newsfed top: never ranks archived items" {
+    newsfed archive 77777777-7777-7777-7777-777777777777 > /dev/null
+
+    run newsfed top --limit=100
+    newsfed unarchive 77777777-7777-7777-7777-777777777777 > /dev/null
+
+    assert_success
+    assert_output_not_contains "Older Article"
+}
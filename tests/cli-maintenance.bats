@@ -0,0 +1,219 @@
+#!/usr/bin/env bats
+# Test CLI: newsfed maintenance commands (migrate, fsck, journal, metrics,
+# completion)
+
+load test_helper
+
+setup_file() {
+    setup_test_env
+    build_newsfed "$TEST_DIR"
+
+    # The journal is kept in ~/.newsfed
+    export HOME="$TEST_DIR/home"
+    mkdir -p "$HOME"
+}
+
+teardown_file() {
+    cleanup_test_env
+}
+
+# Give each test its own metadata database and feed directory
+setup() {
+    export NEWSFED_METADATA_DSN="$TEST_DIR/metadata-$BATS_TEST_NUMBER.db"
+    export NEWSFED_FEED_DSN="$TEST_DIR/news-$BATS_TEST_NUMBER"
+    mkdir -p "$NEWSFED_FEED_DSN"
+    newsfed init > /dev/null
+}
+
+# Test: migrate command
+
+@test "newsfed migrate: copies a directory feed into SQLite" {
+    create_news_item "11111111-1111-1111-1111-111111111111" "Migrated One" "Test Publisher"
+    create_news_item "22222222-2222-2222-2222-222222222222" "Migrated Two" "Test Publisher"
+
+    run newsfed migrate -to "sqlite://$TEST_DIR/migrated.db"
+    assert_success
+    assert_output_contains "Copied: 2"
+    assert_output_contains "Verified 2 item(s)"
+
+    NEWSFED_FEED_DSN="sqlite://$TEST_DIR/migrated.db" run newsfed list --all
+    assert_success
+    assert_output_contains "Migrated One"
+    assert_output_contains "Migrated Two"
+}
+
+@test "newsfed migrate: skips items already in the destination" {
+    create_news_item "11111111-1111-1111-1111-111111111111" "Migrated Once" "Test Publisher"
+    newsfed migrate -to "sqlite://$TEST_DIR/rerun.db" > /dev/null
+
+    run newsfed migrate -to "sqlite://$TEST_DIR/rerun.db"
+    assert_success
+    assert_output_contains "Copied: 0"
+    assert_output_contains "Already present: 1"
+}
+
+@test "newsfed migrate: requires a destination" {
+    run newsfed migrate
+    assert_failure
+    assert_output_contains "Error:"
+}
+
+# Test: fsck command
+
+@test "newsfed fsck: fixes feed file permissions" {
+    create_news_item "11111111-1111-1111-1111-111111111111" "Healthy Item" "Test Publisher"
+    chmod 644 "$NEWSFED_FEED_DSN/11111111-1111-1111-1111-111111111111.json"
+
+    run newsfed fsck
+    assert_success
+    assert_output_contains "Items checked: 1"
+    assert_output_contains "permissions are 644, expected 600"
+
+    run newsfed fsck
+    assert_success
+    assert_output_contains "No problems found"
+}
+
+@test "newsfed fsck -dry-run: reports damaged items without repairing them" {
+    echo '{' > "$NEWSFED_FEED_DSN/damaged.json"
+
+    run newsfed fsck -dry-run
+    assert_failure
+    assert_output_contains "damaged.json: invalid JSON"
+    assert_output_contains "Run without -dry-run to repair"
+
+    [ -f "$NEWSFED_FEED_DSN/damaged.json" ]
+}
+
+@test "newsfed fsck: quarantines items that are not valid JSON" {
+    echo '{' > "$NEWSFED_FEED_DSN/damaged.json"
+
+    run newsfed fsck
+    assert_success
+    assert_output_contains "repaired: damaged.json"
+
+    [ ! -f "$NEWSFED_FEED_DSN/damaged.json" ]
+    [ -f "$NEWSFED_FEED_DSN/quarantine/damaged.json" ]
+
+    run newsfed fsck
+    assert_success
+    assert_output_contains "No problems found"
+}
+
+# Test: journal command
+
+@test "newsfed journal: shows what discovery did with each item" {
+    rm -f "$HOME/.newsfed/journal.jsonl"
+    create_rss_feed "$TEST_DIR/www-journal/feed.xml" "Journal Feed" 2
+    start_mock_server "$TEST_DIR/www-journal"
+
+    newsfed sources add -type=rss \
+        -url="http://127.0.0.1:${MOCK_SERVER_PORT}/feed.xml" \
+        -name="Journal Source" > /dev/null
+    newsfed sync > /dev/null 2>&1
+
+    stop_mock_server
+
+    run newsfed journal
+    assert_success
+    assert_output_contains "Journal Source added http://example.com/article1"
+    assert_output_contains "title: Article 2"
+}
+
+@test "newsfed journal: -format json prints entries as JSON" {
+    rm -f "$HOME/.newsfed/journal.jsonl"
+    create_rss_feed "$TEST_DIR/www-journal-json/feed.xml" "Journal Feed" 1
+    start_mock_server "$TEST_DIR/www-journal-json"
+
+    newsfed sources add -type=rss \
+        -url="http://127.0.0.1:${MOCK_SERVER_PORT}/feed.xml" \
+        -name="Journal JSON Source" > /dev/null
+    newsfed sync > /dev/null 2>&1
+
+    stop_mock_server
+
+    run newsfed journal -format json
+    assert_success
+    assert_output_contains '"decision": "added"'
+    assert_output_contains '"url": "http://example.com/article1"'
+}
+
+@test "newsfed journal: -decision filters the decisions" {
+    rm -f "$HOME/.newsfed/journal.jsonl"
+    create_rss_feed "$TEST_DIR/www-journal-filter/feed.xml" "Journal Feed" 1
+    start_mock_server "$TEST_DIR/www-journal-filter"
+
+    newsfed sources add -type=rss \
+        -url="http://127.0.0.1:${MOCK_SERVER_PORT}/feed.xml" \
+        -name="Journal Filter Source" > /dev/null
+    newsfed sync > /dev/null 2>&1
+
+    stop_mock_server
+
+    run newsfed journal -decision filtered
+    assert_success
+    assert_output_contains "No decisions recorded."
+}
+
+# Test: metrics command
+
+@test "newsfed metrics: reports when no metrics are saved" {
+    run newsfed metrics
+    assert_success
+    assert_output_contains "No metrics saved."
+}
+
+@test "newsfed metrics: shows metrics saved by the daemon" {
+    now=$(date -u +%Y-%m-%dT%H:%M:%SZ)
+    today=$(date -u +%Y-%m-%d)
+    exec_sqlite "INSERT INTO discovery_metrics (worker, day, saved_at, metrics) VALUES ('test-host', '$today', '$now', '{\"since\":\"$now\",\"sources_fetched_total\":40,\"sources_failed_total\":10,\"items_discovered_total\":7}')"
+
+    run newsfed metrics
+    assert_success
+    assert_output_contains "Metrics saved by: test-host"
+    assert_output_contains "Fetches:          40 succeeded, 10 failed"
+    assert_output_contains "Items discovered: 7"
+
+    run newsfed metrics -days 2
+    assert_success
+    assert_output_contains "$today"
+}
+
+@test "newsfed metrics: -worker shows one worker" {
+    run newsfed metrics -worker nobody
+    assert_success
+    assert_output_contains "No metrics saved by nobody."
+}
+
+@test "newsfed metrics: rejects an unknown format" {
+    run newsfed metrics -format xml
+    assert_failure
+    assert_output_contains "Error: invalid format: xml"
+}
+
+# Test: completion command
+
+@test "newsfed completion bash: completes commands and actions" {
+    run bash -c 'source <(newsfed completion bash)
+        COMP_WORDS=(newsfed col); COMP_CWORD=1; _newsfed; echo "commands: ${COMPREPLY[*]}"
+        COMP_WORDS=(newsfed sources dis); COMP_CWORD=2; _newsfed; echo "actions: ${COMPREPLY[*]}"'
+    assert_success
+    assert_output_contains "commands: collections"
+    assert_output_contains "actions: disable discover"
+}
+
+@test "newsfed completion: prints zsh and fish scripts" {
+    run newsfed completion zsh
+    assert_success
+    assert_output_contains "#compdef newsfed"
+
+    run newsfed completion fish
+    assert_success
+    assert_output_contains "complete -c newsfed"
+}
+
+@test "newsfed completion: rejects an unsupported shell" {
+    run newsfed completion tcsh
+    assert_failure
+    assert_output_contains "Error: unsupported shell: tcsh"
+}
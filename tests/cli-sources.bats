@@ -298,6 +298,68 @@ EOF
     assert_output_contains "Status:      ✓ Enabled"
 }
 
+@test "newsfed sources disable: disables several sources" {
+    output_one=$(newsfed sources add -type=rss -url=https://example.com/bulk-one.xml -name="Bulk One")
+    output_two=$(newsfed sources add -type=rss -url=https://example.com/bulk-two.xml -name="Bulk Two")
+    id_one=$(extract_uuid "$output_one")
+    id_two=$(extract_uuid "$output_two")
+
+    run newsfed sources disable "$id_one" "$id_two"
+    assert_success
+    assert_output_contains "Disabled source: Bulk One"
+    assert_output_contains "Disabled source: Bulk Two"
+
+    run newsfed sources show "$id_two"
+    assert_output_contains "Status:      ✗ Disabled"
+}
+
+@test "newsfed sources disable: changes no source if any ID does not exist" {
+    output_add=$(newsfed sources add -type=rss -url=https://example.com/bulk-atomic.xml -name="Bulk Atomic")
+    source_id=$(extract_uuid "$output_add")
+
+    run newsfed sources disable "$source_id" "00000000-0000-0000-0000-000000000000"
+    assert_failure
+    assert_output_contains "source not found"
+
+    run newsfed sources show "$source_id"
+    assert_output_contains "Status:      ✓ Enabled"
+}
+
+@test "newsfed sources disable: --type selects every source of a type" {
+    rm -f "$NEWSFED_METADATA_DSN"
+    newsfed init > /dev/null
+
+    newsfed sources add -type=rss -url=https://example.com/type-rss.xml -name="Type RSS" > /dev/null
+    newsfed sources add -type=atom -url=https://example.com/type-atom.xml -name="Type Atom" > /dev/null
+
+    run newsfed sources disable --type atom
+    assert_success
+    assert_output_contains "Disabled source: Type Atom"
+    assert_output_not_contains "Type RSS"
+}
+
+@test "newsfed sources enable: --all-failing selects sources whose last fetch failed" {
+    rm -f "$NEWSFED_METADATA_DSN"
+    newsfed init > /dev/null
+
+    output_add=$(newsfed sources add -type=rss -url=http://127.0.0.1:1/failing.xml -name="Failing Source")
+    failing_id=$(extract_uuid "$output_add")
+    newsfed sources add -type=rss -url=https://example.com/idle.xml -name="Idle Source" > /dev/null
+    newsfed sync "$failing_id" > /dev/null 2>&1 || true
+    newsfed sources disable --type rss > /dev/null
+
+    run newsfed sources enable --all-failing
+    assert_success
+    assert_output_contains "Enabled source: Failing Source"
+    assert_output_not_contains "Idle Source"
+}
+
+@test "newsfed sources disable: rejects source IDs combined with a selector" {
+    run newsfed sources disable "00000000-0000-0000-0000-000000000000" --type rss
+    assert_failure
+    assert_output_contains "Error: source IDs cannot be combined with -all-failing or -type"
+}
+
 # Test: Delete sources
 
 @test "newsfed sources delete: deletes a source" {
@@ -318,6 +380,37 @@ EOF
     assert_output_contains "Error:"
 }
 
+@test "newsfed sources delete: --type asks for confirmation" {
+    rm -f "$NEWSFED_METADATA_DSN"
+    newsfed init > /dev/null
+
+    newsfed sources add -type=atom -url=https://example.com/confirm-atom.xml -name="Confirm Atom" > /dev/null
+
+    run bash -c "echo n | newsfed sources delete --type atom"
+    assert_success
+    assert_output_contains "Confirm Atom"
+    assert_output_contains "Cancelled."
+
+    run newsfed sources list
+    assert_output_contains "Confirm Atom"
+}
+
+@test "newsfed sources delete: --type --force deletes without confirmation" {
+    rm -f "$NEWSFED_METADATA_DSN"
+    newsfed init > /dev/null
+
+    newsfed sources add -type=atom -url=https://example.com/force-atom.xml -name="Force Atom" > /dev/null
+    newsfed sources add -type=rss -url=https://example.com/force-rss.xml -name="Force RSS" > /dev/null
+
+    run newsfed sources delete --type atom --force
+    assert_success
+    assert_output_contains "Deleted source:"
+
+    run newsfed sources list
+    assert_output_not_contains "Force Atom"
+    assert_output_contains "Force RSS"
+}
+
 # Test: Sync sources
 
 @test "newsfed sources sync: syncs all enabled sources" {
@@ -2,6 +2,7 @@ package tui
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os/exec"
 	"runtime"
@@ -56,6 +57,7 @@ func togglePinCmd(feed *newsfeed.NewsFeed, item newsfeed.NewsItem) tea.Cmd {
 			item.PinnedAt = &now
 		} else {
 			item.PinnedAt = nil
+			item.PinExpiresAt = nil
 		}
 		return itemPinToggledMsg{err: feed.Update(item)}
 	}
@@ -148,7 +150,7 @@ func loadItemsCmd(feed *newsfeed.NewsFeed, sourceID uuid.UUID) tea.Cmd {
 		}
 		var filtered []newsfeed.NewsItem
 		for _, item := range result.Items {
-			if item.SourceID != nil && *item.SourceID == sourceID {
+			if item.SourceID != nil && *item.SourceID == sourceID && item.ArchivedAt == nil {
 				filtered = append(filtered, item)
 			}
 		}
@@ -167,7 +169,7 @@ func loadItemsCmd(feed *newsfeed.NewsFeed, sourceID uuid.UUID) tea.Cmd {
 func fetchSourceCmd(discSvc *discovery.DiscoveryService, src sources.Source) tea.Cmd {
 	return func() tea.Msg {
 		id := src.SourceID
-		result, err := discSvc.SyncSources(context.Background(), &id, nil)
+		result, err := discSvc.SyncSources(context.Background(), discovery.SyncOptions{SourceID: &id}, nil)
 		if err != nil {
 			return fetchDoneMsg{err: err}
 		}
@@ -184,7 +186,7 @@ func startRefreshAllCmd(ctx context.Context, discSvc *discovery.DiscoveryService
 		progressCh := make(chan discovery.SourceProgress, bufSize)
 		errCh := make(chan error, 1)
 		go func() {
-			_, err := discSvc.SyncSources(ctx, nil, progressCh)
+			_, err := discSvc.SyncSources(ctx, discovery.SyncOptions{}, progressCh)
 			if err != nil {
 				errCh <- err
 			}
@@ -318,6 +320,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, loadSourcesAndRestoreCursorCmd(m.sourceStore, msg.src.SourceID)
 
 	case itemPinToggledMsg:
+		if errors.Is(msg.err, newsfeed.ErrVersionConflict) {
+			// Show the item as it was changed elsewhere
+			m.statusMsg = "Item was changed elsewhere; reloaded"
+			return m, m.loadItemsForCurrent()
+		}
 		if msg.err != nil {
 			m.statusMsg = fmt.Sprintf("Pin error: %v", msg.err)
 			return m, nil
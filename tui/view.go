@@ -437,8 +437,8 @@ func (m Model) itemDetailLines() (lines []string, maxScroll int) {
 	fmt.Fprintf(&sb, "Published: %s\n", item.PublishedAt.Format("2006-01-02"))
 	sb.WriteString(wrapField("URL:       ", item.URL, modalWidth) + "\n")
 
-	if item.Summary != "" {
-		plain := stripHTML(item.Summary)
+	if summary := item.DisplaySummary(); summary != "" {
+		plain := stripHTML(summary)
 		if plain != "" {
 			sb.WriteString("\n")
 			sb.WriteString(wordWrap(plain, modalWidth))
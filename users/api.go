@@ -0,0 +1,102 @@
+package users
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/pevans/newsfed/internal/httpjson"
+	"github.com/pevans/newsfed/newsfeed"
+)
+
+// userKey is the context key under which Middleware stores the request's
+// user.
+type userKey struct{}
+
+// Middleware identifies the user making each request from an
+// "Authorization: Bearer <token>" header, for FromContext and Feed. While
+// there are no users, a request without the header is made as the default
+// user, whose state is kept in the items themselves; once there are, the
+// header is required. A request without a token that is required, or with
+// one that matches no user, is refused with 401 Unauthorized.
+func (s *UserStore) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		if header == "" {
+			required, err := s.Any()
+			if err != nil {
+				httpjson.Error(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+			if required {
+				writeUnauthorized(w, "a bearer token is required")
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok {
+			writeUnauthorized(w, "authorization must be a bearer token")
+			return
+		}
+		user, err := s.Authenticate(strings.TrimSpace(token))
+		if err != nil {
+			writeUnauthorized(w, err.Error())
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), user)))
+	})
+}
+
+// RequireUser refuses requests made as the default user with 401
+// Unauthorized, for endpoints that must not be open to anyone who can reach
+// the server. It must run after Middleware.
+func RequireUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if FromContext(r.Context()) == nil {
+			writeUnauthorized(w, "a bearer token is required")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// NewContext returns a copy of ctx that carries user, for FromContext.
+func NewContext(ctx context.Context, user *User) context.Context {
+	return context.WithValue(ctx, userKey{}, user)
+}
+
+// FromContext returns the user that Middleware or NewContext identified, or
+// nil for the default user.
+func FromContext(ctx context.Context) *User {
+	user, _ := ctx.Value(userKey{}).(*User)
+	return user
+}
+
+// Feed returns feed as seen by the user making r.
+func (s *UserStore) Feed(r *http.Request, feed *newsfeed.NewsFeed) *newsfeed.NewsFeed {
+	if user := FromContext(r.Context()); user != nil {
+		return feed.ForUser(user.Name, s)
+	}
+	return feed
+}
+
+// WhoAmIHandler reports the user making the request for
+// GET /api/v1/users/me, or {"name": null} for the default user.
+func (s *UserStore) WhoAmIHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user := FromContext(r.Context()); user != nil {
+			httpjson.Write(w, http.StatusOK, user)
+			return
+		}
+		httpjson.Write(w, http.StatusOK, map[string]any{"name": nil})
+	})
+}
+
+// writeUnauthorized writes {"error": message} with 401 Unauthorized.
+func writeUnauthorized(w http.ResponseWriter, message string) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="newsfed"`)
+	httpjson.Error(w, http.StatusUnauthorized, message)
+}
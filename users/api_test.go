@@ -0,0 +1,73 @@
+package users
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMiddleware_SelectsUser verifies that a bearer token selects its user,
+// that no token selects the default user only while there are no users, and
+// that a bad token is refused
+func TestMiddleware_SelectsUser(t *testing.T) {
+	store := createTestUserStore(t)
+	server := httptest.NewServer(store.Middleware(store.WhoAmIHandler()))
+	t.Cleanup(server.Close)
+
+	whoami := func(authorization string) (int, map[string]any) {
+		req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		return resp.StatusCode, body
+	}
+
+	status, body := whoami("")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Nil(t, body["name"])
+
+	_, token, err := store.Create("alice")
+	require.NoError(t, err)
+
+	status, body = whoami("Bearer " + token)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "alice", body["name"])
+
+	status, body = whoami("")
+	assert.Equal(t, http.StatusUnauthorized, status)
+	assert.Equal(t, "a bearer token is required", body["error"])
+
+	status, _ = whoami("Bearer wrong")
+	assert.Equal(t, http.StatusUnauthorized, status)
+	status, _ = whoami("Basic " + token)
+	assert.Equal(t, http.StatusUnauthorized, status)
+}
+
+// TestRequireUser verifies that requests made as the default user are
+// refused and those made as a user are served
+func TestRequireUser(t *testing.T) {
+	handler := RequireUser(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("POST", "/", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, `Bearer realm="newsfed"`, rec.Header().Get("WWW-Authenticate"))
+
+	req := httptest.NewRequest("POST", "/", nil)
+	req = req.WithContext(NewContext(req.Context(), &User{Name: "alice"}))
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+}
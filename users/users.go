@@ -0,0 +1,398 @@
+// Package users keeps the readers who share a newsfed instance, each with
+// their own API token and their own state for news items, in the metadata
+// database. The items themselves are shared; see newsfeed.NewsFeed.ForUser.
+package users
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pevans/newsfed/newsfeed"
+	"github.com/pevans/newsfed/sqldb"
+)
+
+var (
+	ErrUserNotFound = errors.New("user not found")
+	ErrUserExists   = errors.New("a user with this name already exists")
+	ErrInvalidName  = errors.New("user name must not be empty or contain spaces")
+	ErrInvalidToken = errors.New("invalid API token")
+)
+
+// User is a reader with their own item state.
+type User struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// UserStore manages users and their item state in SQLite or PostgreSQL. It
+// implements newsfeed.StateStore.
+type UserStore struct {
+	db *sqldb.DB
+}
+
+// NewUserStore creates a new user store with the given database path, or a
+// postgres:// DSN.
+func NewUserStore(dbPath string) (*UserStore, error) {
+	db, err := sqldb.Open(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	store := &UserStore{db: db}
+	if err := store.initSchema(); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to initialize schema: %w", err)
+	}
+
+	return store, nil
+}
+
+// postgresMigrations is the PostgreSQL schema, as migrations applied in
+// order by sqldb. Names are unique regardless of case, as in SQLite.
+var postgresMigrations = []string{
+	`
+	CREATE TABLE users (
+		name TEXT PRIMARY KEY,
+		token_hash TEXT NOT NULL UNIQUE,
+		created_at TEXT NOT NULL
+	);
+	CREATE UNIQUE INDEX users_name_lower ON users (lower(name));
+
+	CREATE TABLE user_item_states (
+		user_name TEXT NOT NULL REFERENCES users(name) ON DELETE CASCADE,
+		item_id TEXT NOT NULL,
+		state TEXT NOT NULL,
+		updated_at TEXT NOT NULL,
+		PRIMARY KEY (user_name, item_id)
+	);
+	`,
+	`ALTER TABLE user_item_states ADD COLUMN version INTEGER NOT NULL DEFAULT 0`,
+}
+
+// sqliteMigrations are the SQLite users schema, applied in order by
+// initSchema. The first is the schema as it stood when SQLite databases
+// began to be versioned, which a database made before then already has;
+// like postgresMigrations, new ones must only ever be appended.
+var sqliteMigrations = []string{
+	`
+	CREATE TABLE IF NOT EXISTS users (
+		name TEXT PRIMARY KEY COLLATE NOCASE,
+		token_hash TEXT NOT NULL UNIQUE,
+		created_at TEXT NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS user_item_states (
+		user_name TEXT NOT NULL,
+		item_id TEXT NOT NULL,
+		state TEXT NOT NULL,
+		updated_at TEXT NOT NULL,
+		PRIMARY KEY (user_name, item_id)
+	);
+	`,
+	`ALTER TABLE user_item_states ADD COLUMN version INTEGER NOT NULL DEFAULT 0`,
+}
+
+// migrationsFor returns the users migrations for dialect.
+func migrationsFor(dialect sqldb.Dialect) []string {
+	if dialect == sqldb.Postgres {
+		return postgresMigrations
+	}
+	return sqliteMigrations
+}
+
+// SchemaStatus returns how far the users schema of db has been migrated,
+// without migrating it, for newsfed migrate-db status.
+func SchemaStatus(db *sqldb.DB) (sqldb.SchemaStatus, error) {
+	return db.SchemaStatus("users", len(migrationsFor(db.Dialect)))
+}
+
+// initSchema creates the user tables, or brings them up to date, when the
+// store is opened.
+func (s *UserStore) initSchema() error {
+	return s.db.Migrate("users", migrationsFor(s.db.Dialect))
+}
+
+// Close closes the database connection.
+func (s *UserStore) Close() error {
+	return s.db.Close()
+}
+
+// Create adds a user, returning them along with their API token. Only a hash
+// of the token is stored, so it can't be shown again; ResetToken issues a
+// new one.
+func (s *UserStore) Create(name string) (*User, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || strings.ContainsAny(name, " \t\r\n") {
+		return nil, "", ErrInvalidName
+	}
+	if _, err := s.Get(name); err == nil {
+		return nil, "", ErrUserExists
+	} else if !errors.Is(err, ErrUserNotFound) {
+		return nil, "", err
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return nil, "", err
+	}
+	_, err = s.db.Exec("INSERT INTO users (name, token_hash, created_at) VALUES (?, ?, ?)",
+		name, hashToken(token), formatTime(time.Now().UTC()))
+	if err != nil {
+		if sqldb.IsUniqueViolation(err) {
+			return nil, "", ErrUserExists
+		}
+		return nil, "", fmt.Errorf("failed to create user: %w", err)
+	}
+
+	user, err := s.Get(name)
+	if err != nil {
+		return nil, "", err
+	}
+	return user, token, nil
+}
+
+// Get returns the user with the given name, ignoring case.
+func (s *UserStore) Get(name string) (*User, error) {
+	return s.scanUser(s.db.QueryRow(
+		"SELECT name, created_at FROM users WHERE lower(name) = lower(?)",
+		strings.TrimSpace(name)))
+}
+
+// List returns every user, ordered by name.
+func (s *UserStore) List() ([]User, error) {
+	rows, err := s.db.Query("SELECT name, created_at FROM users ORDER BY lower(name)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query users: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	users := []User{}
+	for rows.Next() {
+		var user User
+		var createdAt string
+		if err := rows.Scan(&user.Name, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		user.CreatedAt = parseTime(createdAt)
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read users: %w", err)
+	}
+	return users, nil
+}
+
+// Delete removes a user and all of their item state.
+func (s *UserStore) Delete(name string) error {
+	user, err := s.Get(name)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM user_item_states WHERE user_name = ?", user.Name); err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("failed to delete item state: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM users WHERE name = ?", user.Name); err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// ResetToken replaces a user's API token, returning the new one. The old
+// token stops working at once.
+func (s *UserStore) ResetToken(name string) (string, error) {
+	user, err := s.Get(name)
+	if err != nil {
+		return "", err
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return "", err
+	}
+	if _, err := s.db.Exec("UPDATE users SET token_hash = ? WHERE name = ?",
+		hashToken(token), user.Name); err != nil {
+		return "", fmt.Errorf("failed to update user: %w", err)
+	}
+	return token, nil
+}
+
+// Any reports whether there are any users. Once there are, API requests
+// must authenticate as one of them.
+func (s *UserStore) Any() (bool, error) {
+	var exists bool
+	if err := s.db.QueryRow("SELECT EXISTS (SELECT 1 FROM users)").Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to query users: %w", err)
+	}
+	return exists, nil
+}
+
+// Authenticate returns the user whose API token is token.
+func (s *UserStore) Authenticate(token string) (*User, error) {
+	if token == "" {
+		return nil, ErrInvalidToken
+	}
+	user, err := s.scanUser(s.db.QueryRow(
+		"SELECT name, created_at FROM users WHERE token_hash = ?", hashToken(token)))
+	if errors.Is(err, ErrUserNotFound) {
+		return nil, ErrInvalidToken
+	}
+	return user, err
+}
+
+// ItemStates returns user's state for every item they have any state for.
+func (s *UserStore) ItemStates(user string) (map[uuid.UUID]newsfeed.ItemState, error) {
+	rows, err := s.db.Query("SELECT item_id, state, version FROM user_item_states WHERE user_name = ?", user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query item state: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	states := make(map[uuid.UUID]newsfeed.ItemState)
+	for rows.Next() {
+		var itemID, data string
+		var version int
+		if err := rows.Scan(&itemID, &data, &version); err != nil {
+			return nil, fmt.Errorf("failed to scan item state: %w", err)
+		}
+		id, err := uuid.Parse(itemID)
+		if err != nil {
+			continue
+		}
+		var state newsfeed.ItemState
+		if err := json.Unmarshal([]byte(data), &state); err != nil {
+			return nil, fmt.Errorf("failed to decode item state for %s: %w", itemID, err)
+		}
+		state.Version = version
+		states[id] = state
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read item state: %w", err)
+	}
+	return states, nil
+}
+
+// ItemState returns user's state for an item, which is the zero state if
+// they have none.
+func (s *UserStore) ItemState(user string, id uuid.UUID) (newsfeed.ItemState, error) {
+	var state newsfeed.ItemState
+	var data string
+	var version int
+	err := s.db.QueryRow("SELECT state, version FROM user_item_states WHERE user_name = ? AND item_id = ?",
+		user, id.String()).Scan(&data, &version)
+	if err == sql.ErrNoRows {
+		return state, nil
+	}
+	if err != nil {
+		return state, fmt.Errorf("failed to read item state: %w", err)
+	}
+	if err := json.Unmarshal([]byte(data), &state); err != nil {
+		return state, fmt.Errorf("failed to decode item state for %s: %w", id, err)
+	}
+	state.Version = version
+	return state, nil
+}
+
+// SetItemState replaces user's state for an item, Version included, if the
+// stored state's version is version, and returns
+// newsfeed.ErrVersionConflict otherwise. The zero state removes it, whatever
+// its version.
+func (s *UserStore) SetItemState(user string, id uuid.UUID, version int, state newsfeed.ItemState) error {
+	if state.IsZero() {
+		if _, err := s.db.Exec("DELETE FROM user_item_states WHERE user_name = ? AND item_id = ?",
+			user, id.String()); err != nil {
+			return fmt.Errorf("failed to delete item state: %w", err)
+		}
+		return nil
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to encode item state: %w", err)
+	}
+	// Only a state at version 0 may be missing, so only then is the state
+	// inserted
+	now := formatTime(time.Now().UTC())
+	var result sql.Result
+	if version == 0 {
+		result, err = s.db.Exec(`
+			INSERT INTO user_item_states (user_name, item_id, state, version, updated_at) VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT (user_name, item_id) DO UPDATE SET
+				state = excluded.state, version = excluded.version, updated_at = excluded.updated_at
+			WHERE user_item_states.version = 0`,
+			user, id.String(), string(data), state.Version, now)
+	} else {
+		result, err = s.db.Exec(`
+			UPDATE user_item_states SET state = ?, version = ?, updated_at = ?
+			WHERE user_name = ? AND item_id = ? AND version = ?`,
+			string(data), state.Version, now, user, id.String(), version)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save item state: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to save item state: %w", err)
+	} else if n == 0 {
+		return newsfeed.ErrVersionConflict
+	}
+	return nil
+}
+
+// scanUser reads a user from row.
+func (s *UserStore) scanUser(row *sql.Row) (*User, error) {
+	var user User
+	var createdAt string
+	err := row.Scan(&user.Name, &createdAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read user: %w", err)
+	}
+	user.CreatedAt = parseTime(createdAt)
+	return &user, nil
+}
+
+// newToken returns a new random API token.
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken returns the stored form of an API token.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// formatTime formats a timestamp for storage, without its monotonic clock.
+func formatTime(t time.Time) string {
+	return t.Truncate(0).Format(time.RFC3339Nano)
+}
+
+// parseTime parses a stored timestamp.
+func parseTime(s string) time.Time {
+	t, _ := time.Parse(time.RFC3339Nano, s)
+	return t
+}
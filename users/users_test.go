@@ -0,0 +1,164 @@
+package users
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pevans/newsfed/newsfeed"
+	"github.com/pevans/newsfed/sqldb"
+	"github.com/pevans/newsfed/sqldb/sqldbtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test helper: create a test user store
+func createTestUserStore(t *testing.T) *UserStore {
+	store, err := NewUserStore(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err, "should create user store")
+	t.Cleanup(func() { _ = store.Close() })
+	return store
+}
+
+// TestNewUserStore_VersionsSchema verifies that a database made before the
+// users schema was versioned keeps its users and is recorded as up to date
+func TestNewUserStore_VersionsSchema(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	db, err := sqldb.Open(dbPath)
+	require.NoError(t, err)
+	_, err = db.Exec(sqliteMigrations[0])
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO users (name, token_hash, created_at) VALUES ('alice', 'hash', '2026-10-01T00:00:00Z')")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	store, err := NewUserStore(dbPath)
+	require.NoError(t, err)
+	defer func() { _ = store.Close() }()
+
+	_, err = store.Get("alice")
+	assert.NoError(t, err)
+	status, err := SchemaStatus(store.db)
+	require.NoError(t, err)
+	assert.Equal(t, len(sqliteMigrations), status.Version)
+	assert.Equal(t, 0, status.Pending())
+}
+
+// TestCreate_IssuesToken verifies that a new user gets a token that
+// identifies them, that names are unique regardless of case, and that a
+// reset token replaces the old one
+func TestCreate_IssuesToken(t *testing.T) {
+	store := createTestUserStore(t)
+
+	user, token, err := store.Create("Alice")
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", user.Name)
+	assert.NotEmpty(t, token)
+
+	_, _, err = store.Create("alice")
+	assert.ErrorIs(t, err, ErrUserExists)
+	_, _, err = store.Create("two words")
+	assert.ErrorIs(t, err, ErrInvalidName)
+
+	found, err := store.Authenticate(token)
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", found.Name)
+	_, err = store.Authenticate("not-a-token")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	newToken, err := store.ResetToken("ALICE")
+	require.NoError(t, err)
+	_, err = store.Authenticate(token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	_, err = store.Authenticate(newToken)
+	assert.NoError(t, err)
+}
+
+// TestItemState_RoundTrip verifies that item state is saved per user with
+// its version, that the zero state removes it, and that deleting a user
+// removes theirs
+func TestItemState_RoundTrip(t *testing.T) {
+	store := createTestUserStore(t)
+	_, _, err := store.Create("alice")
+	require.NoError(t, err)
+	_, _, err = store.Create("bob")
+	require.NoError(t, err)
+
+	id := uuid.New()
+	readAt := time.Now().UTC().Truncate(time.Second)
+	state := newsfeed.ItemState{ReadAt: &readAt, Notes: "worth a second look", Version: 1}
+	require.NoError(t, store.SetItemState("alice", id, 0, state))
+	require.NoError(t, store.SetItemState("bob", id, 0, newsfeed.ItemState{Notes: "bob's", Version: 1}))
+
+	got, err := store.ItemState("alice", id)
+	require.NoError(t, err)
+	require.NotNil(t, got.ReadAt)
+	assert.True(t, readAt.Equal(*got.ReadAt))
+	assert.Equal(t, "worth a second look", got.Notes)
+	assert.Equal(t, 1, got.Version)
+
+	states, err := store.ItemStates("alice")
+	require.NoError(t, err)
+	assert.Len(t, states, 1)
+	assert.Equal(t, 1, states[id].Version)
+
+	require.NoError(t, store.SetItemState("alice", id, 0, newsfeed.ItemState{}))
+	got, err = store.ItemState("alice", id)
+	require.NoError(t, err)
+	assert.True(t, got.IsZero())
+
+	require.NoError(t, store.Delete("bob"))
+	states, err = store.ItemStates("bob")
+	require.NoError(t, err)
+	assert.Empty(t, states)
+	_, err = store.Get("bob")
+	assert.ErrorIs(t, err, ErrUserNotFound)
+}
+
+// TestSetItemState_RefusesStaleVersion verifies that a state saved over
+// one changed since it was read is refused, whether or not a state was
+// stored at all
+func TestSetItemState_RefusesStaleVersion(t *testing.T) {
+	store := createTestUserStore(t)
+	_, _, err := store.Create("alice")
+	require.NoError(t, err)
+	id := uuid.New()
+
+	assert.ErrorIs(t, store.SetItemState("alice", id, 1, newsfeed.ItemState{Notes: "never read", Version: 2}),
+		newsfeed.ErrVersionConflict)
+	require.NoError(t, store.SetItemState("alice", id, 0, newsfeed.ItemState{Notes: "first", Version: 1}))
+	assert.ErrorIs(t, store.SetItemState("alice", id, 0, newsfeed.ItemState{Notes: "stale", Version: 1}),
+		newsfeed.ErrVersionConflict)
+	require.NoError(t, store.SetItemState("alice", id, 1, newsfeed.ItemState{Notes: "second", Version: 2}))
+
+	got, err := store.ItemState("alice", id)
+	require.NoError(t, err)
+	assert.Equal(t, "second", got.Notes)
+	assert.Equal(t, 2, got.Version)
+}
+
+// TestPostgres_UserStore verifies the user store against PostgreSQL. It is
+// skipped unless a test database is configured.
+func TestPostgres_UserStore(t *testing.T) {
+	store, err := NewUserStore(sqldbtest.PostgresDSN(t))
+	require.NoError(t, err)
+	defer func() { _ = store.Close() }()
+
+	name := "pg-" + uuid.NewString()
+	_, token, err := store.Create(name)
+	require.NoError(t, err)
+	defer func() { _ = store.Delete(name) }()
+
+	user, err := store.Authenticate(token)
+	require.NoError(t, err)
+	assert.Equal(t, name, user.Name)
+
+	id := uuid.New()
+	require.NoError(t, store.SetItemState(name, id, 0, newsfeed.ItemState{Notes: "first", Version: 1}))
+	require.NoError(t, store.SetItemState(name, id, 1, newsfeed.ItemState{Notes: "second", Version: 2}))
+	assert.ErrorIs(t, store.SetItemState(name, id, 1, newsfeed.ItemState{Notes: "stale", Version: 2}), newsfeed.ErrVersionConflict)
+	got, err := store.ItemState(name, id)
+	require.NoError(t, err)
+	assert.Equal(t, "second", got.Notes)
+}